@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+func init() {
+	rootCmd.AddCommand(aliasCmd)
+}
+
+// aliasCmd represents the alias command group
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Manage tag aliases in local storage",
+	Long: `Manage aliases, mutable convenience names such as "stable" or "current" for a tag of
+the same repository in local storage.
+
+An alias is an additional tag on the manifest of its target, so setting or re-pointing it
+copies no content. dump and apply resolve an alias to its target tag. Deleting an alias
+with 'kubectl mft delete' only removes the alias, while deleting the target also removes
+the aliases pointing at its manifest.
+
+Examples:
+  # Point stable at a release
+  kubectl mft alias set myapp:stable myapp:v1.4.2
+
+  # Review what each alias points at
+  kubectl mft alias list
+
+  # Remove an alias
+  kubectl mft delete myapp:stable`,
+}
+
+// resolveAlias resolves a local alias to the tag it points at, reporting the resolution on
+// stderr. Aliases whose target has been moved or deleted keep referring to their own manifest.
+func resolveAlias(ctx context.Context, tag string) (string, error) {
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return "", err
+	}
+	a, err := r.Alias()
+	if err != nil || a == nil {
+		return tag, err
+	}
+
+	target := a.Repository + ":" + a.Target
+	t, err := oci.NewRepository(target)
+	if err != nil {
+		return "", err
+	}
+	if a.Status != mft.AliasCurrent {
+		infof("Alias %s was set to %s, which is %s now; using the manifest of the alias\n", r, t, a.Status)
+		return tag, nil
+	}
+	infof("Resolved alias %s to %s\n", r, t)
+	return target, nil
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type AliasListOpts struct {
+	repository string
+	output     string
+}
+
+var aliasListOpts AliasListOpts
+
+func init() {
+	aliasCmd.AddCommand(aliasListCmd)
+
+	flag := aliasListCmd.Flags()
+	flag.StringVarP(&aliasListOpts.output, OutputFlag, OutputShortFlag, "table", "Output format (table, json, yaml)")
+}
+
+// aliasListCmd represents the alias list command
+var aliasListCmd = &cobra.Command{
+	Use:     "list [repository]",
+	Aliases: []string{"ls"},
+	Short:   "List aliases and the tags they point at",
+	Long: `List the aliases in local storage, of all repositories or of a single one, with the tag
+and manifest digest each alias points at.
+
+The status compares the alias with its target tag:
+  - current: the target tag still refers to the manifest of the alias
+  - stale:   the target tag has been moved to another manifest, e.g. by packing it again
+  - missing: the target tag no longer exists
+
+Examples:
+  # List all aliases
+  kubectl mft alias list
+
+  # List the aliases of one repository as JSON
+  kubectl mft alias list myapp -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			aliasListOpts.repository = args[0]
+		}
+		return runAliasList()
+	},
+}
+
+func runAliasList() error {
+	var r *oci.Repository
+	if aliasListOpts.repository != "" {
+		var err error
+		if r, err = oci.NewRepository(aliasListOpts.repository); err != nil {
+			return err
+		}
+	}
+	res, err := oci.Aliases(r)
+	if err != nil {
+		return err
+	}
+
+	if quiet && aliasListOpts.output == string(mft.ListTable) {
+		var ids []string
+		for _, a := range res.Items() {
+			ids = append(ids, a.Repository+":"+a.Alias)
+		}
+		printIDs(ids)
+		return nil
+	}
+	return res.Print(mft.ListOutput(aliasListOpts.output))
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type AliasSetOpts struct {
+	alias  string
+	target string
+}
+
+var aliasSetOpts AliasSetOpts
+
+func init() {
+	aliasCmd.AddCommand(aliasSetCmd)
+}
+
+// aliasSetCmd represents the alias set command
+var aliasSetCmd = &cobra.Command{
+	Use:   "set <alias> <target-tag>",
+	Short: "Point an alias at a tag",
+	Long: `Point an alias at a tag of the same repository in local storage, creating the alias or
+re-pointing an existing one. Regular tags are never replaced, and aliases cannot point at
+other aliases.
+
+Examples:
+  # Point stable at a release
+  kubectl mft alias set myapp:stable myapp:v1.4.2
+
+  # Re-point current at the newest 1.x release
+  kubectl mft alias set ghcr.io/myorg/app:current ghcr.io/myorg/app:^1`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeTags(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		aliasSetOpts.alias = args[0]
+		aliasSetOpts.target = args[1]
+		return runAliasSet(cmd.Context())
+	},
+}
+
+func runAliasSet(ctx context.Context) error {
+	tag, err := resolveTag(ctx, aliasSetOpts.target, false)
+	if err != nil {
+		return err
+	}
+	target, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+	r, err := oci.NewRepository(aliasSetOpts.alias)
+	if err != nil {
+		return err
+	}
+
+	if err := r.SetAlias(ctx, target); err != nil {
+		return err
+	}
+	printResult(r.String(), "Alias %s now points at %s\n", r, target)
+	return nil
+}
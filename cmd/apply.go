@@ -7,20 +7,46 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/chez-shanpu/kubectl-mft/internal/cluster"
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/hook"
+	"github.com/chez-shanpu/kubectl-mft/internal/imagepolicy"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
 	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/nspolicy"
 	"github.com/chez-shanpu/kubectl-mft/internal/oci"
-	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+	"github.com/chez-shanpu/kubectl-mft/internal/transform"
 )
 
 type ApplyOpts struct {
-	tag        string
-	skipVerify bool
+	tag         string
+	skipVerify  bool
+	refresh     bool
+	withDeps    bool
+	ordering    string
+	waitTimeout time.Duration
+	checkImages bool
+	transforms  []string
+	rewrites    map[string]string
+
+	allowedNamespaces   []string
+	deniedNamespaces    []string
+	denyClusterScoped   bool
+	allowedClusterKinds []string
+
+	createNamespace bool
+	skipPreflight   bool
+	preHooks        []string
+	skipHooks       bool
+
+	output string
 }
 
 var applyOpts ApplyOpts
@@ -30,6 +56,29 @@ func init() {
 
 	flag := applyCmd.Flags()
 	flag.BoolVar(&applyOpts.skipVerify, "skip-verify", false, "Skip signature verification after pulling")
+	flag.BoolVar(&noVerifyCache, NoCacheFlag, false, noCacheUsage)
+	flag.BoolVar(&fetchKeys, FetchKeysFlag, false, fetchKeysUsage)
+	flag.StringSliceVar(&keyFingerprints, KeyFingerprintFlag, nil, keyFingerprintUsage)
+	flag.BoolVar(&tofu, TOFUFlag, false, tofuUsage)
+	flag.IntVar(&requireSignatures, RequireSignaturesFlag, 0, requireSignaturesUsage)
+	flag.StringSliceVar(&trustedKeys, TrustedKeysFlag, nil, trustedKeysUsage)
+	flag.StringVar(&maxSignatureAge, MaxSignatureAgeFlag, "", maxSignatureAgeUsage)
+	flag.BoolVar(&applyOpts.refresh, "refresh", false, "Re-pull the manifest if the local copy differs from the registry")
+	flag.BoolVar(&applyOpts.withDeps, WithDependenciesFlag, false, "Pull and apply the artifacts the manifest depends on, transitively, before it")
+	flag.StringVar(&applyOpts.ordering, "ordering", string(manifest.OrderingAuto), "Resource ordering (auto, none)")
+	flag.DurationVar(&applyOpts.waitTimeout, "wait-timeout", time.Minute, "How long to wait for CRDs to become established")
+	flag.StringArrayVar(&applyOpts.transforms, "transform", nil, "Transform from config.yaml to apply to the resources, can be repeated")
+	flag.StringToStringVar(&applyOpts.rewrites, RewriteImagesFlag, nil, rewriteImagesUsage)
+	flag.BoolVar(&applyOpts.checkImages, "check-images", false, "Refuse to apply manifests referencing images denied by the image policy in config.yaml")
+	flag.StringSliceVar(&applyOpts.allowedNamespaces, "allowed-namespaces", nil, "Refuse to apply manifests touching namespaces other than these patterns, in addition to config.yaml")
+	flag.StringSliceVar(&applyOpts.deniedNamespaces, "denied-namespaces", nil, "Refuse to apply manifests touching namespaces matching these patterns, in addition to config.yaml")
+	flag.BoolVar(&applyOpts.denyClusterScoped, "deny-cluster-scoped", false, "Refuse to apply manifests containing cluster-scoped resources, such as ClusterRoles, CRDs, or webhooks")
+	flag.StringSliceVar(&applyOpts.allowedClusterKinds, "allow-cluster-scoped-kind", nil, "Cluster-scoped kinds allowed despite --deny-cluster-scoped, such as Namespace")
+	flag.BoolVar(&applyOpts.createNamespace, "create-namespace", false, "Create namespaces of the resources that do not exist")
+	flag.BoolVar(&applyOpts.skipPreflight, "skip-preflight", false, "Skip checking that the cluster serves the kinds and namespaces of the resources")
+	flag.StringArrayVar(&applyOpts.preHooks, "pre-hook", nil, "Executable receiving the rendered manifest on stdin before applying, vetoing with a non-zero exit, can be repeated")
+	flag.BoolVar(&applyOpts.skipHooks, "skip-hooks", false, "Skip the pre-apply hooks of config.yaml")
+	flag.StringVarP(&applyOpts.output, OutputFlag, OutputShortFlag, "", "Output format of failures (github)")
 }
 
 // applyCmd represents the apply command
@@ -39,11 +88,121 @@ var applyCmd = &cobra.Command{
 	Long: `Apply downloads a manifest from an OCI-compliant registry (if not already present locally)
 and applies it to the current Kubernetes cluster using 'kubectl apply'.
 
-If the manifest is not found in local storage, it will be automatically pulled from the
-remote registry before applying. Authentication is handled through Docker credential store,
+If the manifest is not found in local storage, it will be automatically fetched from the
+remote registry into the cache before applying, see 'kubectl mft cache'. Authentication is handled through Docker credential store,
 so ensure you are logged into the source registry using 'docker login' if pulling from a
 private registry.
 
+The reference may be a semver range such as 'app:^1.2' or 'app:latest-semver', which
+resolves to the highest matching tag in the registry (or in local storage for simple tag names).
+
+With --ordering auto (the default), resources are applied in waves. Namespaces and
+CustomResourceDefinitions are applied first and the CRDs are waited on until they are
+established, so custom resources in the same manifest can be created in a single run.
+Resources can be assigned to earlier or later waves with the integer annotation
+'kubectl-mft.io/apply-wave' (default 0). With --ordering none, everything is applied at once.
+
+If the tag refers to a bundle, every member is applied in dependency order.
+
+With --with-dependencies, the artifacts the manifest declares with 'kubectl mft pack
+--depends-on' are pulled by their pinned digests if needed, verified, and applied first,
+transitively, every artifact after its own dependencies. Policies, preflight checks, and
+hooks cover the dependencies along with the manifest before anything is applied. Applying
+fails on a dependency cycle, or when two artifacts pin the same tag to different digests.
+
+A manifest that is already stored locally is applied as is. Use --refresh to compare it with
+the registry and re-pull it when the remote digest has changed. The re-pulled manifest is
+verified before it replaces the local copy, which is kept if verification fails. Tags only
+in local storage are never refreshed. With 'verify-local: true' in
+config.yaml, the signature of a locally stored manifest is verified before it is applied as
+well, so a manifest tampered with in local storage is never applied silently. The
+'registries' rules of config.yaml skip verification for registries with 'verify: never', and
+refuse --skip-verify and verify local copies for registries with 'verify: required'.
+
+--trusted-keys, --require-signatures, --max-signature-age, and --tofu set stricter signature
+requirements for both pulled and local manifests, see 'kubectl mft pull --help'. When one of
+them is given, a manifest already stored locally is verified before it is applied, even
+without 'verify-local: true'.
+
+With -o github, a failure, such as a signature verification failure or a policy violation,
+is also printed as a GitHub Actions annotation.
+
+With --transform, the resources are mutated before they are applied by the named transforms
+of config.yaml, in the order given. A transform is an ordered list of mutators: namespace
+sets the namespace of namespaced resources, labels and annotations add metadata,
+image-registry replaces the registry of container images, and resources sets limits and
+requests on containers that do not set them.
+
+  transforms:
+    - name: staging
+      mutators:
+        - type: namespace
+          namespace: staging
+        - type: labels
+          labels: {env: staging}
+        - type: image-registry
+          registries: {docker.io: registry.internal/dockerhub}
+        - type: resources
+          limits: {cpu: 500m, memory: 256Mi}
+
+--rewrite-images replaces the registry of container images after the transforms, e.g. to
+deploy public manifests into a disconnected cluster through an internal mirror. Images without
+a registry are on docker.io, so 'nginx' becomes 'registry.internal/library/nginx' with
+--rewrite-images docker.io=registry.internal.
+
+With --check-images, the container images referenced by the manifest are checked against the
+image policy in config.yaml, after transforms, before anything is applied. Images denied by a
+pattern, or not matching any allowed pattern, are refused. If a scanner is configured, every
+allowed image is scanned with trivy (optionally in client mode against a Trivy server) or
+grype, and images with vulnerabilities of the configured severity or higher are refused.
+
+  images:
+    allow: ["registry.company.com/*", "docker.io/library/*"]
+    deny: ["*:latest"]
+    scanner:
+      type: trivy
+      server: http://trivy.company.com:4954
+      severity: critical
+
+The namespaces touched by the resources, after transforms, are checked against the namespace
+policy of config.yaml before anything is applied, to protect the other tenants of a shared
+cluster. --allowed-namespaces and --denied-namespaces add a policy of their own, so flags only
+ever narrow the configured policy. A resource touches its namespace, or the namespace of the
+current context if it sets none, and a Namespace touches the namespace it creates. If any
+namespace is refused, nothing is applied and every refused namespace is reported with the
+resources touching it. Patterns match namespace names, where "*" matches any sequence of
+characters; denied patterns win over allowed ones.
+
+With denyClusterScoped, or --deny-cluster-scoped, cluster-scoped resources such as
+ClusterRoles, CRDs, webhook configurations, or Namespaces are refused as well, except for the
+kinds of allowClusterScoped, or --allow-cluster-scoped-kind. The scope of a kind is taken from
+the cluster, so custom resources of cluster-scoped CRDs already installed, such as
+ClusterIssuers, count as cluster-scoped, as do kinds made cluster-scoped by a CRD of the
+manifests.
+
+  namespaces:
+    allow: ["team-a", "team-a-*"]
+    deny: ["kube-*"]
+    denyClusterScoped: true
+    allowClusterScoped: [Namespace]
+
+Before anything is applied, the kinds and namespaces of all resources are checked against the
+cluster with 'kubectl api-resources' and 'kubectl get namespaces'. Resources whose apiVersion
+is not served, custom resources whose CRD is neither installed nor part of the manifest, and
+namespaces that neither exist nor are created by the manifest are reported together, instead
+of kubectl failing on them one by one halfway through. With --create-namespace, missing
+namespaces are created instead. Use --skip-preflight to skip the checks.
+
+Pre-apply hooks run organization-specific checks. Each hook receives the rendered manifest,
+after transforms, on stdin, with KUBECTL_MFT_TAG and KUBECTL_MFT_DIGEST set in its environment,
+and vetoes the apply by exiting with a non-zero status. Hooks of config.yaml run first, then
+those given with --pre-hook, in order. Use --skip-hooks to skip the hooks of config.yaml.
+
+  hooks:
+    preApply:
+      - name: policy
+        command: [conftest, test, --policy, /etc/policy, -]
+
 Examples:
   # Apply a locally available manifest
   kubectl mft apply docker.io/myuser/my-app:v1.0.0
@@ -52,16 +211,90 @@ Examples:
   kubectl mft apply registry.company.com/team/app:latest
 
   # Apply without signature verification
-  kubectl mft apply localhost:5000/test-app:dev --skip-verify`,
-	Args: cobra.ExactArgs(1),
+  kubectl mft apply localhost:5000/test-app:dev --skip-verify
+
+  # Track the 1.2 release line
+  kubectl mft apply "registry.company.com/team/app:~1.2"
+
+  # Apply the operator and CRDs the application depends on first
+  kubectl mft apply registry.company.com/team/app:v1.0.0 --with-dependencies
+
+  # Apply everything in a single 'kubectl apply'
+  kubectl mft apply myapp:v1.0.0 --ordering none
+
+  # Re-pull a stale local copy before applying
+  kubectl mft apply registry.company.com/team/app:latest --refresh
+
+  # Apply into the staging namespace with the staging transform
+  kubectl mft apply registry.company.com/team/app:v1.0.0 --transform staging
+
+  # Pull images from an internal mirror in an air-gapped cluster
+  kubectl mft apply registry.company.com/vendor/app:v1.0.0 --rewrite-images docker.io=registry.internal,quay.io=registry.internal/quay
+
+  # Create the namespaces of the resources if they do not exist
+  kubectl mft apply registry.company.com/team/app:v1.0.0 --create-namespace
+
+  # Run a custom check before applying
+  kubectl mft apply registry.company.com/team/app:v1.0.0 --pre-hook ./check.sh
+
+  # Only touch the namespaces of team A
+  kubectl mft apply registry.company.com/team/app:v1.0.0 --allowed-namespaces team-a,team-a-staging
+
+  # Refuse cluster-scoped resources other than Namespaces
+  kubectl mft apply registry.company.com/team/app:v1.0.0 --deny-cluster-scoped --allow-cluster-scoped-kind Namespace
+
+  # Refuse disallowed or critically vulnerable images
+  kubectl mft apply registry.company.com/team/app:v1.0.0 --check-images
+
+  # Annotate a failed apply on the GitHub Actions workflow run
+  kubectl mft apply registry.company.com/team/app:v1.0.0 -o github`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkOutputGitHub(applyOpts.output); err != nil {
+			return err
+		}
 		applyOpts.tag = args[0]
-		return runApply(cmd.Context())
+		err := runApply(cmd.Context())
+		if err != nil && applyOpts.output == outputGitHub {
+			annotateFailure(failureTitle(err, "Apply failed"), err, false)
+		}
+		return err
 	},
 }
 
 func runApply(ctx context.Context) error {
-	r, err := oci.NewRepository(applyOpts.tag)
+	applyDiscovery = nil
+	switch manifest.Ordering(applyOpts.ordering) {
+	case manifest.OrderingAuto, manifest.OrderingNone:
+	default:
+		return fmt.Errorf("unsupported ordering: %s", applyOpts.ordering)
+	}
+	pipeline, err := transformPipeline(applyOpts.transforms)
+	if err != nil {
+		return err
+	}
+	rewrite, err := rewriteImages(applyOpts.rewrites)
+	if err != nil {
+		return err
+	}
+	if rewrite != nil {
+		pipeline = append(pipeline, rewrite)
+	}
+
+	tag, err := resolveTag(ctx, applyOpts.tag, true)
+	if err != nil {
+		return err
+	}
+	if tag, err = resolveAlias(ctx, tag); err != nil {
+		return err
+	}
+
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+	skipVerify, err := skipVerification(r, applyOpts.skipVerify)
 	if err != nil {
 		return err
 	}
@@ -70,44 +303,498 @@ func runApply(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to check local manifest: %w", err)
 	}
+	ttl, err := cacheTTL()
+	if err != nil {
+		return err
+	}
+
+	// Tags not in local storage are fetched into the cache, and fetched again once expired
+	cached := !exists || r.InCache()
+	needPull := !exists || r.CacheExpired(ttl)
+	if exists && !needPull && applyOpts.refresh {
+		if r.Registry() == oci.DefaultRegistry {
+			debugf("Not refreshing %s, it is only in local storage\n", r)
+		} else {
+			upToDate, err := mft.UpToDate(ctx, r)
+			if err != nil {
+				return err
+			}
+			needPull = !upToDate
+		}
+	}
 
-	if !exists {
-		if err := mft.Pull(ctx, r); err != nil {
+	if needPull && cached {
+		if err := fetchIntoCache(ctx, r, skipVerify, ttl); err != nil {
+			return err
+		}
+	} else if needPull {
+		if err := refreshPulled(ctx, r, skipVerify); err != nil {
+			return err
+		}
+	} else if !skipVerify {
+		if err := verifyLocal(ctx, r); err != nil {
 			return err
 		}
+	}
 
-		if !applyOpts.skipVerify {
-			if !signature.PublicKeysExist() {
-				return deletePulledData(ctx, r, fmt.Errorf("no verification keys found, run 'kubectl mft key import <file>' to import a public key, or use '--skip-verify' to skip verification"))
-			}
-			verifier, err := signature.NewVerifierFromKeyDir()
+	// The tag is resolved once, so that the checks and 'kubectl apply' see the same manifest even
+	// if the tag is pushed or pulled again meanwhile
+	root, err := resolveTarget(ctx, r)
+	if err != nil {
+		return err
+	}
+	isBundle, err := mft.IsBundle(ctx, root.pinned)
+	if err != nil {
+		return err
+	}
+	var targets []*applyTarget
+	if applyOpts.withDeps {
+		if isBundle {
+			return fmt.Errorf("--%s is not supported for bundles, order their members with 'kubectl mft bundle create --depends-on'", WithDependenciesFlag)
+		}
+		deps, err := pullDependencies(ctx, r, applyOpts.skipVerify)
+		if err != nil {
+			return err
+		}
+		for _, d := range deps {
+			t, err := resolveTarget(ctx, d)
 			if err != nil {
-				return deletePulledData(ctx, r, err)
+				return err
 			}
-			if err := verifier.Verify(ctx, r.LayoutPath(), r.Tag()); err != nil {
-				return deletePulledData(ctx, r, fmt.Errorf("signature verification failed: %w", err))
+			if isBundle, err := mft.IsBundle(ctx, t.pinned); err != nil {
+				return err
+			} else if isBundle {
+				return fmt.Errorf("dependency %s is a bundle, which --%s does not support", d, WithDependenciesFlag)
+			}
+			if t.docs, err = readDocuments(ctx, t.pinned, pipeline); err != nil {
+				return fmt.Errorf("dependency %s: %w", d, err)
+			}
+			targets = append(targets, t)
+		}
+	}
+	if !isBundle {
+		if root.docs, err = readDocuments(ctx, root.pinned, pipeline); err != nil {
+			return err
+		}
+		targets = append(targets, root)
+		if applyOpts.checkImages {
+			for _, t := range targets {
+				if err := checkImages(ctx, t); err != nil {
+					return err
+				}
+			}
+		}
+		if err := checkNamespaces(ctx, targets); err != nil {
+			return err
+		}
+		if err := preApply(ctx, root.digest, targets); err != nil {
+			return err
+		}
+		for _, t := range targets[:len(targets)-1] {
+			infof("Applying dependency %s\n", t.repo)
+			if err := applyManifest(ctx, t.docs); err != nil {
+				return fmt.Errorf("failed to apply dependency %s: %w", t.repo, err)
+			}
+		}
+		return applyManifest(ctx, root.docs)
+	}
+
+	bundle, err := mft.Bundle(ctx, root.pinned)
+	if err != nil {
+		return err
+	}
+	for _, m := range bundle.Members() {
+		// Members are referenced by digest already
+		mr, err := r.MemberRepository(m)
+		if err != nil {
+			return err
+		}
+		t := &applyTarget{repo: mr, pinned: mr, digest: m.Digest}
+		if t.docs, err = readDocuments(ctx, mr, pipeline); err != nil {
+			return fmt.Errorf("bundle member %s: %w", m.Name, err)
+		}
+		// Every member is checked before the first one is applied
+		if applyOpts.checkImages {
+			if err := checkImages(ctx, t); err != nil {
+				return fmt.Errorf("bundle member %s: %w", m.Name, err)
 			}
 		}
+		targets = append(targets, t)
+	}
+	if err := checkNamespaces(ctx, targets); err != nil {
+		return err
+	}
+	if err := preApply(ctx, root.digest, targets); err != nil {
+		return err
+	}
+	for i, m := range bundle.Members() {
+		infof("Applying bundle member %s (%s)\n", m.Name, m.Reference)
+		if err := applyManifest(ctx, targets[i].docs); err != nil {
+			return fmt.Errorf("failed to apply bundle member %s: %w", m.Name, err)
+		}
 	}
+	return nil
+}
 
+// applyTarget is a manifest of an apply, read once and shared by every check and by
+// 'kubectl apply'.
+type applyTarget struct {
+	// repo is the tag the manifest was resolved from, used in messages
+	repo *oci.Repository
+	// pinned reads the resolved manifest by digest, whatever the tag points to later
+	pinned *oci.Repository
+	digest string
+	// docs are the resources of the manifest after the transform pipeline
+	docs []*manifest.Document
+}
+
+// resolveTarget resolves the tag of r to the digest its manifest is read from.
+func resolveTarget(ctx context.Context, r *oci.Repository) (*applyTarget, error) {
+	d, err := r.Digest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pinned, err := oci.NewRepository(r.Name() + "@" + d)
+	if err != nil {
+		return nil, err
+	}
+	return &applyTarget{repo: r, pinned: pinned, digest: d}, nil
+}
+
+// verifyLocal verifies the signature of a manifest applied from local storage without pulling,
+// if verify-local is enabled in the configuration, verification is required for its registry,
+// or a flag asks for stricter verification, such as --require-signatures or --tofu, which
+// must not be ignored for local copies. Local storage is left untouched on failure.
+func verifyLocal(ctx context.Context, r *oci.Repository) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	reason, hint := "verify-local is enabled", ", or use '--skip-verify' to skip verification"
+	if cfg.VerifyModeFor(r.Registry()) == config.VerifyRequired {
+		reason, hint = fmt.Sprintf("verification is required for %s", r.Registry()), ""
+	} else if flag := strictVerificationFlag(); flag != "" {
+		reason, hint = fmt.Sprintf("--%s is set", flag), ""
+	} else if !cfg.VerifyLocal {
+		return nil
+	}
+
+	debugf("Verifying signature of local copy of %s\n", r)
+	if !verificationKeysExist() {
+		return fmt.Errorf("no verification keys found to verify the local copy (%s), run 'kubectl mft key import <file>' to import a public key%s", reason, hint)
+	}
+	verifier, err := newVerifier(r)
+	if err != nil {
+		return err
+	}
+	if err := verifier.Verify(ctx, r.LayoutPath(), r.Tag()); err != nil {
+		return withExitCode(ExitSignature, fmt.Errorf("signature verification of the local copy failed (%s): %w", reason, err))
+	}
+	if err := checkSignatureRequirements(ctx, r, verifier); err != nil {
+		return err
+	}
+	if tofu {
+		return checkSignerPin(ctx, r)
+	}
+	return nil
+}
+
+// transformPipeline returns the mutators of the named transforms of the configuration, in order.
+func transformPipeline(names []string) (transform.Pipeline, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	var pipeline transform.Pipeline
+	for _, name := range names {
+		t, ok := cfg.TransformNamed(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown transform %q, configure it under 'transforms' in config.yaml", name)
+		}
+		p, err := transform.New(*t)
+		if err != nil {
+			return nil, err
+		}
+		pipeline = append(pipeline, p...)
+	}
+	return pipeline, nil
+}
+
+// rewriteImages returns the mutator replacing image registries given with --rewrite-images,
+// or nil if none is given.
+func rewriteImages(rewrites map[string]string) (transform.Mutator, error) {
+	if len(rewrites) == 0 {
+		return nil, nil
+	}
+	for from, to := range rewrites {
+		if from == "" || strings.Contains(from, "/") || to == "" {
+			return nil, fmt.Errorf("invalid --%s %s=%s, expected <registry>=<replacement>", RewriteImagesFlag, from, to)
+		}
+	}
+	return transform.ImageRegistryMutator(rewrites), nil
+}
+
+// readDocuments reads the resources of a manifest artifact and runs the transform pipeline on them.
+func readDocuments(ctx context.Context, r *oci.Repository, pipeline transform.Pipeline) ([]*manifest.Document, error) {
 	res, err := mft.Dump(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	// The documents are parsed from the stream rather than piped to kubectl, so that content
+	// failing verification at the end of the blob is never applied
+	docs, err := manifest.Read(res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return pipeline.Apply(docs)
+}
+
+// checkImages returns an error if the image policy refuses any image referenced by the manifest.
+func checkImages(ctx context.Context, t *applyTarget) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if !cfg.Images.Configured() {
+		return fmt.Errorf("--check-images requires an image policy, configure 'images' in config.yaml")
+	}
+
+	debugf("Checking images of %s against the image policy\n", t.repo)
+	violations, err := imagepolicy.NewChecker(cfg.Images).Check(ctx, t.docs)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	lines := make([]string, len(violations))
+	for i, v := range violations {
+		lines[i] = "  " + v.String()
+	}
+	return withExitCode(ExitPolicy, fmt.Errorf("refusing to apply %s, %d image(s) violate the image policy:\n%s", t.repo, len(violations), strings.Join(lines, "\n")))
+}
+
+// checkNamespaces returns an error if the namespace policy of the configuration or of the flags
+// refuses any namespace touched by the manifests or any of their cluster-scoped resources,
+// checked together before any is applied.
+func checkNamespaces(ctx context.Context, targets []*applyTarget) error {
+	cfg, err := config.Load()
 	if err != nil {
 		return err
 	}
+	var policies []config.NamespacePolicy
+	if cfg.Namespaces.Configured() {
+		policies = append(policies, cfg.Namespaces)
+	}
+	flags := config.NamespacePolicy{
+		Allow:              applyOpts.allowedNamespaces,
+		Deny:               applyOpts.deniedNamespaces,
+		DenyClusterScoped:  applyOpts.denyClusterScoped,
+		AllowClusterScoped: applyOpts.allowedClusterKinds,
+	}
+	if flags.Configured() {
+		policies = append(policies, flags)
+	}
+	if len(policies) == 0 {
+		return nil
+	}
 
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, res); err != nil {
-		return fmt.Errorf("failed to read manifest: %w", err)
+	docs := targetDocuments(targets)
+	var defaultNamespace string
+	if nspolicy.UsesDefaultNamespace(docs) {
+		if defaultNamespace, err = cluster.DefaultNamespace(ctx); err != nil {
+			return err
+		}
+	}
+	// The cluster tells the scope of custom resources whose CRDs are already installed
+	discovery, err := discoverCluster(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to discover the scope of the resources: %w", err)
 	}
 
+	debugf("Checking the namespaces of %s against the namespace policy\n", applyOpts.tag)
+	violations, err := nspolicy.Check(policies, docs, defaultNamespace, discovery)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	lines := make([]string, len(violations))
+	for i, v := range violations {
+		lines[i] = "  " + v.String()
+	}
+	return withExitCode(ExitPolicy, fmt.Errorf("refusing to apply %s, %d namespace(s) or resource(s) violate the namespace policy:\n%s", applyOpts.tag, len(violations), strings.Join(lines, "\n")))
+}
+
+// preApply runs the pre-apply hooks and the preflight checks on the rendered resources of the
+// targets, which are applied in the given order. The hooks are given digest as the manifest
+// being applied.
+func preApply(ctx context.Context, digest string, targets []*applyTarget) error {
+	hooks, err := preApplyHooks()
+	if err != nil {
+		return err
+	}
+	if len(hooks) == 0 && applyOpts.skipPreflight {
+		return nil
+	}
+
+	docs := targetDocuments(targets)
+	if len(hooks) > 0 {
+		env := []string{"KUBECTL_MFT_TAG=" + applyOpts.tag, "KUBECTL_MFT_DIGEST=" + digest}
+		data := manifest.Join(docs)
+		for _, h := range hooks {
+			debugf("Running pre-apply hook %s\n", h.Name)
+			if err := h.Run(ctx, data, env, os.Stderr); err != nil {
+				return withExitCode(ExitPolicy, fmt.Errorf("refusing to apply %s: %w", applyOpts.tag, err))
+			}
+		}
+	}
+	if applyOpts.skipPreflight {
+		return nil
+	}
+	return preflight(ctx, docs)
+}
+
+// targetDocuments returns the resources of the targets in the order they are applied.
+func targetDocuments(targets []*applyTarget) []*manifest.Document {
+	var docs []*manifest.Document
+	for _, t := range targets {
+		docs = append(docs, t.docs...)
+	}
+	return docs
+}
+
+// preApplyHooks returns the pre-apply hooks of the configuration, unless skipped, followed by
+// those given with --pre-hook.
+func preApplyHooks() ([]hook.Hook, error) {
+	var hooks []hook.Hook
+	if !applyOpts.skipHooks {
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range cfg.Hooks.PreApply {
+			hooks = append(hooks, hook.Hook{Name: h.Name, Command: h.Command})
+		}
+	}
+	for _, path := range applyOpts.preHooks {
+		hooks = append(hooks, hook.Hook{Name: path, Command: []string{path}})
+	}
+	return hooks, nil
+}
+
+// preflight checks that the cluster serves the kinds and namespaces of the resources, and creates
+// missing namespaces with --create-namespace. All problems are reported together.
+func preflight(ctx context.Context, docs []*manifest.Document) error {
+	debugf("Checking the resources of %s against the cluster\n", applyOpts.tag)
+	discovery, err := discoverCluster(ctx)
+	if err != nil {
+		return fmt.Errorf("preflight checks failed: %w", err)
+	}
+	problems, namespaces := discovery.Preflight(docs)
+	var lines []string
+	for _, p := range problems {
+		lines = append(lines, "  "+p.String())
+	}
+	if !applyOpts.createNamespace {
+		for _, ns := range namespaces {
+			lines = append(lines, fmt.Sprintf("  namespace %s does not exist, use --create-namespace to create it", ns))
+		}
+	}
+	if len(lines) > 0 {
+		return withExitCode(ExitPolicy, fmt.Errorf("refusing to apply %s, %d problem(s) found by preflight checks:\n%s", applyOpts.tag, len(lines), strings.Join(lines, "\n")))
+	}
+
+	for _, ns := range namespaces {
+		if err := kubectlCreateNamespace(ctx, ns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyDiscovery is what the cluster serves, discovered once per apply
+var applyDiscovery *cluster.Discovery
+
+// discoverCluster returns what the cluster serves, shared by the namespace policy and the
+// preflight checks of an apply.
+func discoverCluster(ctx context.Context) (*cluster.Discovery, error) {
+	if applyDiscovery == nil {
+		d, err := cluster.Discover(ctx)
+		if err != nil {
+			return nil, err
+		}
+		applyDiscovery = d
+	}
+	return applyDiscovery, nil
+}
+
+func kubectlCreateNamespace(ctx context.Context, name string) error {
+	kubectl := exec.CommandContext(ctx, "kubectl", "create", "namespace", name)
+	kubectl.Stdout = os.Stdout
+	kubectl.Stderr = os.Stderr
+
+	if err := kubectl.Run(); err != nil {
+		return fmt.Errorf("kubectl create namespace %s failed: %w", name, err)
+	}
+	return nil
+}
+
+// applyManifest applies the resources of a manifest artifact with 'kubectl apply', phase by phase.
+func applyManifest(ctx context.Context, docs []*manifest.Document) error {
+	ordering := manifest.Ordering(applyOpts.ordering)
+	if ordering == manifest.OrderingNone {
+		return kubectlApply(ctx, manifest.Join(docs))
+	}
+
+	phases, err := manifest.Plan(docs, ordering)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range phases {
+		if err := kubectlApply(ctx, manifest.Join(p.Documents)); err != nil {
+			return err
+		}
+		if len(p.CRDs) > 0 {
+			if err := waitEstablished(ctx, p.CRDs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func kubectlApply(ctx context.Context, data []byte) error {
 	kubectl := exec.CommandContext(ctx, "kubectl", "apply", "-f", "-")
-	kubectl.Stdin = &buf
+	kubectl.Stdin = bytes.NewReader(data)
 	kubectl.Stdout = os.Stdout
 	kubectl.Stderr = os.Stderr
 
 	if err := kubectl.Run(); err != nil {
 		return fmt.Errorf("kubectl apply failed: %w", err)
 	}
+	return nil
+}
 
+// waitEstablished waits until the named CRDs are established so their kinds can be used.
+func waitEstablished(ctx context.Context, crds []string) error {
+	args := []string{"wait", "--for", "condition=Established", "--timeout", applyOpts.waitTimeout.String()}
+	for _, name := range crds {
+		args = append(args, "customresourcedefinition/"+name)
+	}
+
+	kubectl := exec.CommandContext(ctx, "kubectl", args...)
+	kubectl.Stdout = os.Stdout
+	kubectl.Stderr = os.Stderr
+
+	if err := kubectl.Run(); err != nil {
+		return fmt.Errorf("waiting for CRDs to be established failed: %w", err)
+	}
 	return nil
 }
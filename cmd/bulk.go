@@ -0,0 +1,244 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+const (
+	FromFileFlag  = "from-file"
+	fromFileUsage = "File listing one reference per line to %s, instead of a single tag"
+	ParallelFlag  = "parallel"
+	parallelUsage = "Number of repositories processed concurrently with --from-file"
+	ResumeFlag    = "resume-from-failure"
+	resumeUsage   = "State file recording completed references with --from-file; a rerun skips them, and the file is removed once all succeed"
+)
+
+// defaultParallel is the default of --parallel
+const defaultParallel = 4
+
+// bulkOpts are the flags of the commands processing a file of references
+type bulkOpts struct {
+	fromFile  string
+	parallel  int
+	stateFile string
+}
+
+// addBulkFlags adds the flags of bulkOpts to cmd, which verb the references.
+func addBulkFlags(cmd *cobra.Command, o *bulkOpts, verb string) {
+	flag := cmd.Flags()
+	flag.StringVar(&o.fromFile, FromFileFlag, "", fmt.Sprintf(fromFileUsage, verb))
+	flag.IntVar(&o.parallel, ParallelFlag, defaultParallel, parallelUsage)
+	flag.StringVar(&o.stateFile, ResumeFlag, "", resumeUsage)
+}
+
+// bulkArgs validates the arguments of a command with bulkOpts: either a single tag or --from-file.
+func bulkArgs(o *bulkOpts) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if o.fromFile != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		if cmd.Flags().Changed(ResumeFlag) || cmd.Flags().Changed(ParallelFlag) {
+			return fmt.Errorf("--%s and --%s require --%s", ResumeFlag, ParallelFlag, FromFileFlag)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	}
+}
+
+// readRefsFile reads the references of a file listing one per line. Blank lines and lines
+// starting with # are ignored, and duplicates are dropped.
+func readRefsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open references file: %w", err)
+	}
+	defer f.Close()
+	var refs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || slices.Contains(refs, line) {
+			continue
+		}
+		refs = append(refs, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read references file: %w", err)
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("no references found in %s", path)
+	}
+	return refs, nil
+}
+
+// bulkState records the references completed by a bulk run, so that a rerun skips them.
+type bulkState struct {
+	mu   sync.Mutex
+	path string
+	done map[string]bool
+}
+
+// loadBulkState reads the state file at path, which may not exist yet.
+func loadBulkState(path string) (*bulkState, error) {
+	s := &bulkState{path: path, done: make(map[string]bool)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			s.done[line] = true
+		}
+	}
+	return s, nil
+}
+
+// complete records ref as completed. Each reference is appended as soon as it completes, so
+// the state survives the process being killed.
+func (s *bulkState) complete(ref string) error {
+	if s.path == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to update state file: %w", err)
+	}
+	if _, err := fmt.Fprintln(f, ref); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to update state file: %w", err)
+	}
+	return f.Close()
+}
+
+// runBulk runs fn on every reference of the --from-file list, see runBulkRefs.
+func runBulk(ctx context.Context, verb string, o bulkOpts, fn func(ctx context.Context, ref string) error) error {
+	refs, err := readRefsFile(o.fromFile)
+	if err != nil {
+		return err
+	}
+	return runBulkRefs(ctx, verb, o, o.fromFile, refs, fn)
+}
+
+// runBulkRefs runs fn on every reference read from source not completed by a previous run
+// recorded in the state file. References of different repositories run concurrently, up to
+// the --parallel limit, and references of the same repository one after another, as they share
+// a layout in local storage. Progress is reported on stderr and a summary printed at the end.
+func runBulkRefs(ctx context.Context, verb string, o bulkOpts, source string, refs []string, fn func(ctx context.Context, ref string) error) error {
+	if o.parallel < 1 {
+		return fmt.Errorf("--%s must be at least 1", ParallelFlag)
+	}
+	state, err := loadBulkState(o.stateFile)
+	if err != nil {
+		return err
+	}
+
+	// Group the pending references by repository, keeping the order of the file
+	var groups [][]string
+	index := make(map[string]int)
+	skipped := 0
+	for _, ref := range refs {
+		if state.done[ref] {
+			skipped++
+			continue
+		}
+		r, err := oci.NewRepository(ref)
+		if err != nil {
+			return fmt.Errorf("invalid reference %s in %s: %w", ref, source, err)
+		}
+		i, ok := index[r.Name()]
+		if !ok {
+			i = len(groups)
+			index[r.Name()] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], ref)
+	}
+	if skipped > 0 {
+		infof("Skipping %d reference(s) completed by a previous run\n", skipped)
+	}
+
+	var mu sync.Mutex
+	var failed []string
+	var firstErr error
+	completed := skipped
+	jobs := make(chan []string)
+	var wg sync.WaitGroup
+	for range min(o.parallel, len(groups)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range jobs {
+				for _, ref := range group {
+					if ctx.Err() != nil {
+						return
+					}
+					err := fn(ctx, ref)
+					if err == nil {
+						err = state.complete(ref)
+					}
+					mu.Lock()
+					completed++
+					if err != nil {
+						failed = append(failed, ref)
+						if firstErr == nil {
+							firstErr = err
+						}
+						infof("[%d/%d] Failed to %s %s: %v\n", completed, len(refs), verb, ref, err)
+					} else {
+						infof("[%d/%d] %s\n", completed, len(refs), ref)
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+dispatch:
+	for _, group := range groups {
+		select {
+		case jobs <- group:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	succeeded := len(refs) - skipped - len(failed)
+	printResult("", "%d succeeded, %d skipped, %d failed of %d references\n", succeeded, skipped, len(failed), len(refs))
+	if len(failed) > 0 {
+		hint := ""
+		if o.stateFile != "" {
+			hint = fmt.Sprintf(", rerun with --%s %s to retry them", ResumeFlag, o.stateFile)
+		}
+		return withExitCode(exitCode(firstErr), fmt.Errorf("failed to %s %d reference(s)%s: %s", verb, len(failed), hint, strings.Join(failed, ", ")))
+	}
+	if o.stateFile != "" {
+		if err := os.Remove(o.stateFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove state file: %w", err)
+		}
+	}
+	return nil
+}
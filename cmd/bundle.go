@@ -0,0 +1,34 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+}
+
+// bundleCmd represents the bundle command group
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Manage bundles of manifests",
+	Long: `Manage bundles, artifacts that reference several packed manifests by digest
+like an OCI image index.
+
+A bundle is pushed, pulled, and signed as a single artifact. Applying a bundle with
+'kubectl mft apply' applies every member in dependency order.
+
+Examples:
+  # Create a bundle from packed manifests
+  kubectl mft bundle create myapp-bundle:v1 crds=myapp-crds:v1 app=myapp:v1 --depends-on app=crds
+
+  # Show the members of a bundle
+  kubectl mft bundle show myapp-bundle:v1
+
+  # Push and pull a bundle
+  kubectl mft bundle push ghcr.io/myorg/bundle:v1
+  kubectl mft bundle pull ghcr.io/myorg/bundle:v1`,
+}
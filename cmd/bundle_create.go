@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+)
+
+type BundleCreateOpts struct {
+	tag       string
+	members   []string
+	dependsOn []string
+	skipSign  bool
+	key       string
+}
+
+var bundleCreateOpts BundleCreateOpts
+
+func init() {
+	bundleCmd.AddCommand(bundleCreateCmd)
+
+	flag := bundleCreateCmd.Flags()
+	flag.StringArrayVar(&bundleCreateOpts.dependsOn, "depends-on", nil, "Dependency of a member in the form name=dep[,dep...] (can be repeated)")
+	flag.BoolVar(&bundleCreateOpts.skipSign, "skip-sign", false, "Skip signing the bundle")
+	flag.StringVar(&bundleCreateOpts.key, "key", "", "Private key to use for signing: a key name, ssh:<path>, ssh-agent:[<fingerprint|comment>], or gpg:<key-id> (default: the key configured for the repository, or \"default\")")
+}
+
+// bundleCreateCmd represents the bundle create command
+var bundleCreateCmd = &cobra.Command{
+	Use:   "create <tag> <member>...",
+	Short: "Create a bundle from packed manifests",
+	Long: `Create a bundle referencing packed manifests in local storage.
+
+Each member is given as [name=]tag. The name identifies the member within the bundle
+and defaults to the last path element of its repository. The members and their
+signatures are copied into the bundle's repository, and the bundle is signed like 'pack'.
+
+Members are applied in the order given unless --depends-on declares otherwise.
+
+Examples:
+  # Bundle two manifests, named after their repositories
+  kubectl mft bundle create myapp-bundle:v1 myapp-crds:v1 myapp:v1
+
+  # Apply the application only after its CRDs
+  kubectl mft bundle create myapp-bundle:v1 crds=myapp-crds:v1 app=myapp:v1 --depends-on app=crds`,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeTags(-1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bundleCreateOpts.tag = args[0]
+		bundleCreateOpts.members = args[1:]
+		return runBundleCreate(cmd.Context())
+	},
+}
+
+func runBundleCreate(ctx context.Context) error {
+	members, err := parseBundleMembers(bundleCreateOpts.members, bundleCreateOpts.dependsOn)
+	if err != nil {
+		return err
+	}
+
+	r, err := oci.NewRepository(bundleCreateOpts.tag)
+	if err != nil {
+		return err
+	}
+
+	// Check signing key before saving to avoid partial state
+	var key string
+	if !bundleCreateOpts.skipSign {
+		if key, err = signingKeyFor(bundleCreateOpts.key, r); err != nil {
+			return err
+		}
+		if !signature.SigningKeyExists(key) {
+			return fmt.Errorf("signing key %q not found, run 'kubectl mft key generate' to create a key pair, or use '--skip-sign' to skip signing", key)
+		}
+	}
+
+	if err := mft.CreateBundle(ctx, r, members); err != nil {
+		return err
+	}
+
+	if !bundleCreateOpts.skipSign {
+		signer, err := signature.NewSignerFromRef(key)
+		if err != nil {
+			return deletePackedData(ctx, r, err)
+		}
+		defer signer.Close()
+		if _, err := signer.WithRepository(r.Name()).Sign(ctx, r.LayoutPath(), r.Tag()); err != nil {
+			return deletePackedData(ctx, r, fmt.Errorf("failed to sign bundle: %w", err))
+		}
+		if err := r.SyncMetadata(ctx); err != nil {
+			return err
+		}
+		return r.RecordEvent(ctx, mft.EventSigned)
+	}
+
+	return nil
+}
+
+// parseBundleMembers parses [name=]tag member arguments and name=dep[,dep...] dependency flags.
+func parseBundleMembers(args []string, dependsOn []string) ([]*mft.BundleMember, error) {
+	members := make([]*mft.BundleMember, 0, len(args))
+	byName := make(map[string]*mft.BundleMember, len(args))
+	for _, arg := range args {
+		name, ref, ok := strings.Cut(arg, "=")
+		if !ok {
+			ref = arg
+			name = bundleMemberName(ref)
+		}
+		m := &mft.BundleMember{Name: name, Reference: ref}
+		members = append(members, m)
+		byName[name] = m
+	}
+
+	for _, d := range dependsOn {
+		name, deps, ok := strings.Cut(d, "=")
+		if !ok || deps == "" {
+			return nil, fmt.Errorf("invalid --depends-on %q, expected name=dep[,dep...]", d)
+		}
+		m, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid --depends-on %q: unknown member %q", d, name)
+		}
+		m.DependsOn = append(m.DependsOn, strings.Split(deps, ",")...)
+	}
+	return members, nil
+}
+
+// bundleMemberName returns the last path element of the repository in ref.
+func bundleMemberName(ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref = ref[:i]
+	}
+	return path.Base(ref)
+}
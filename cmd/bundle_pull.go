@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type BundlePullOpts struct {
+	tag        string
+	skipVerify bool
+}
+
+var bundlePullOpts BundlePullOpts
+
+func init() {
+	bundleCmd.AddCommand(bundlePullCmd)
+
+	flag := bundlePullCmd.Flags()
+	flag.BoolVar(&bundlePullOpts.skipVerify, "skip-verify", false, "Skip signature verification after pulling")
+	flag.BoolVar(&noVerifyCache, NoCacheFlag, false, noCacheUsage)
+	flag.BoolVar(&fetchKeys, FetchKeysFlag, false, fetchKeysUsage)
+	flag.StringSliceVar(&keyFingerprints, KeyFingerprintFlag, nil, keyFingerprintUsage)
+	flag.BoolVar(&tofu, TOFUFlag, false, tofuUsage)
+	flag.IntVar(&requireSignatures, RequireSignaturesFlag, 0, requireSignaturesUsage)
+	flag.StringSliceVar(&trustedKeys, TrustedKeysFlag, nil, trustedKeysUsage)
+	flag.StringVar(&maxSignatureAge, MaxSignatureAgeFlag, "", maxSignatureAgeUsage)
+}
+
+// bundlePullCmd represents the bundle pull command
+var bundlePullCmd = &cobra.Command{
+	Use:   "pull <tag>",
+	Short: "Pull a bundle and its members from an OCI registry",
+	Long: `Pull downloads a bundle and every member it references from an OCI-compliant registry.
+
+The signature of the bundle is verified after pulling. Since the bundle references its
+members by digest, this also covers the content of every member.
+
+Examples:
+  kubectl mft bundle pull ghcr.io/myorg/bundle:v1`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bundlePullOpts.tag = args[0]
+		return runBundlePull(cmd.Context())
+	},
+}
+
+func runBundlePull(ctx context.Context) error {
+	r, err := oci.NewRepository(bundlePullOpts.tag)
+	if err != nil {
+		return err
+	}
+
+	skipVerify, err := skipVerification(r, bundlePullOpts.skipVerify)
+	if err != nil {
+		return err
+	}
+
+	existedBefore := r.InStorage()
+
+	if err := mft.Pull(ctx, r); err != nil {
+		return withExitCode(ExitRegistry, err)
+	}
+
+	isBundle, err := mft.IsBundle(ctx, r)
+	if err != nil {
+		return err
+	}
+	if !isBundle {
+		return handleVerifyFailure(ctx, r, existedBefore, fmt.Errorf("%s is not a bundle, use 'pull' instead", bundlePullOpts.tag))
+	}
+
+	if !skipVerify {
+		return verifyPulled(ctx, r, existedBefore)
+	}
+	return nil
+}
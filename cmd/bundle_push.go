@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type BundlePushOpts struct {
+	tag string
+}
+
+var bundlePushOpts BundlePushOpts
+
+func init() {
+	bundleCmd.AddCommand(bundlePushCmd)
+}
+
+// bundlePushCmd represents the bundle push command
+var bundlePushCmd = &cobra.Command{
+	Use:   "push <tag>",
+	Short: "Push a bundle and its members to an OCI registry",
+	Long: `Push uploads a bundle, every member it references, and their signatures to an
+OCI-compliant registry.
+
+Examples:
+  kubectl mft bundle push ghcr.io/myorg/bundle:v1`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bundlePushOpts.tag = args[0]
+		return runBundlePush(cmd.Context())
+	},
+}
+
+func runBundlePush(ctx context.Context) error {
+	r, err := oci.NewRepository(bundlePushOpts.tag)
+	if err != nil {
+		return err
+	}
+
+	isBundle, err := mft.IsBundle(ctx, r)
+	if err != nil {
+		return err
+	}
+	if !isBundle {
+		return fmt.Errorf("%s is not a bundle, use 'push' instead", bundlePushOpts.tag)
+	}
+	return withExitCode(ExitRegistry, mft.Push(ctx, r))
+}
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type BundleShowOpts struct {
+	tag string
+}
+
+var bundleShowOpts BundleShowOpts
+
+func init() {
+	bundleCmd.AddCommand(bundleShowCmd)
+}
+
+// bundleShowCmd represents the bundle show command
+var bundleShowCmd = &cobra.Command{
+	Use:   "show <tag>",
+	Short: "Show the members of a bundle",
+	Long: `Show the members of a bundle in local storage, in the order they are applied.
+
+Examples:
+  kubectl mft bundle show myapp-bundle:v1`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bundleShowOpts.tag = args[0]
+		return runBundleShow(cmd.Context())
+	},
+}
+
+func runBundleShow(ctx context.Context) error {
+	r, err := oci.NewRepository(bundleShowOpts.tag)
+	if err != nil {
+		return err
+	}
+
+	res, err := mft.Bundle(ctx, r)
+	if err != nil {
+		return err
+	}
+	if quiet {
+		var ids []string
+		for _, m := range res.Members() {
+			ids = append(ids, m.Name)
+		}
+		printIDs(ids)
+		return nil
+	}
+	return res.Print()
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// cacheCmd represents the cache command group
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of manifests fetched implicitly",
+	Long: `Manage the cache of manifests fetched implicitly.
+
+When apply or dump is given a tag that is not in local storage, the manifest is fetched
+into a cache instead, separate from the manifests packed or pulled on purpose: list only
+shows cached manifests with --cached, and they are fetched again once older than the cache
+TTL, set with cache.ttl in config.yaml (default: 168h). Expired manifests are evicted
+whenever a manifest is fetched into the cache. Pull a tag to keep it in local storage, or
+pin it with 'kubectl mft pin' to keep it in the cache.
+
+Examples:
+  # Print the cache directory
+  kubectl mft cache dir
+
+  # Show the cached manifests and the size of the cache
+  kubectl mft cache stats
+
+  # Evict expired manifests
+  kubectl mft cache clear --expired
+
+  # Remove every cached manifest
+  kubectl mft cache clear`,
+}
+
+// cacheTTL returns the cache TTL configured in config.yaml.
+func cacheTTL() (time.Duration, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return 0, err
+	}
+	return cfg.Cache.TTLDuration()
+}
+
+// fetchIntoCache pulls r into the cache and verifies its signature unless skipVerify, then
+// evicts the expired manifests of the cache. On verification failure the cached tag is
+// removed, also when it was cached before, so that its next use fetches it again rather than
+// using the unverified manifest.
+func fetchIntoCache(ctx context.Context, r *oci.Repository, skipVerify bool, ttl time.Duration) error {
+	r.UseCache()
+	debugf("Fetching %s into the cache %s\n", r, oci.CacheDir())
+	if err := mft.Pull(ctx, r); err != nil {
+		return withExitCode(ExitRegistry, err)
+	}
+	if !skipVerify {
+		if err := verifyPulled(ctx, r, false); err != nil {
+			return err
+		}
+	}
+	n, err := oci.EvictCache(ctx, ttl)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		debugf("Evicted %d expired manifest(s) from the cache\n", n)
+	}
+	return nil
+}
+
+// fetchIfMissing fetches r into the cache if it is not in local storage or its cached copy
+// expired, verifying it as configured for its registry.
+func fetchIfMissing(ctx context.Context, r *oci.Repository) error {
+	exists, err := r.Exists(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check local manifest: %w", err)
+	}
+	ttl, err := cacheTTL()
+	if err != nil {
+		return err
+	}
+	if exists && !r.CacheExpired(ttl) {
+		return nil
+	}
+	if r.Registry() == oci.DefaultRegistry {
+		return fmt.Errorf("%s not found in local storage", r)
+	}
+	skipVerify, err := skipVerification(r, false)
+	if err != nil {
+		return err
+	}
+	return fetchIntoCache(ctx, r, skipVerify, ttl)
+}
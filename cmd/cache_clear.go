@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type CacheClearOpts struct {
+	expired bool
+}
+
+var cacheClearOpts CacheClearOpts
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+
+	flag := cacheClearCmd.Flags()
+	flag.BoolVar(&cacheClearOpts.expired, "expired", false, "Only evict manifests older than the cache TTL")
+}
+
+// cacheClearCmd represents the cache clear command
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove unpinned cached manifests",
+	Long: `Remove the manifests fetched implicitly by apply and dump.
+
+Manifests in local storage, packed or pulled on purpose, are not affected, and neither are
+cached manifests pinned with 'kubectl mft pin'. Removed manifests are fetched again the next
+time they are used.
+
+Examples:
+  # Remove every cached manifest
+  kubectl mft cache clear
+
+  # Evict manifests older than the cache TTL
+  kubectl mft cache clear --expired`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCacheClear(cmd.Context())
+	},
+}
+
+func runCacheClear(ctx context.Context) error {
+	if !cacheClearOpts.expired {
+		n, err := oci.ClearCache(ctx)
+		if err != nil {
+			return err
+		}
+		printResult("", "Removed %d manifest(s) from the cache %s\n", n, oci.CacheDir())
+		return nil
+	}
+
+	ttl, err := cacheTTL()
+	if err != nil {
+		return err
+	}
+	n, err := oci.EvictCache(ctx, ttl)
+	if err != nil {
+		return err
+	}
+	printResult("", "Evicted %d expired manifest(s) from the cache\n", n)
+	return nil
+}
@@ -0,0 +1,34 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+func init() {
+	cacheCmd.AddCommand(cacheDirCmd)
+}
+
+// cacheDirCmd represents the cache dir command
+var cacheDirCmd = &cobra.Command{
+	Use:   "dir",
+	Short: "Print the cache directory",
+	Long: `Print the directory holding the manifests fetched implicitly by apply and dump.
+
+The directory is manifests under KUBECTL_MFT_CACHE_DIR, or under profiles/<name> in it for
+profiles other than the default one.
+
+Examples:
+  kubectl mft cache dir`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Println(oci.CacheDir())
+		return nil
+	},
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type CacheStatsOpts struct {
+	output string
+}
+
+var cacheStatsOpts CacheStatsOpts
+
+func init() {
+	cacheCmd.AddCommand(cacheStatsCmd)
+
+	flag := cacheStatsCmd.Flags()
+	flag.StringVarP(&cacheStatsOpts.output, OutputFlag, OutputShortFlag, "table", "Output format (table, json, yaml)")
+}
+
+// cacheStatsCmd represents the cache stats command
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the cached manifests and the size of the cache",
+	Long: `Show the manifests fetched implicitly by apply and dump, when they were fetched, whether
+they expired, and the size of the cache on disk.
+
+Examples:
+  kubectl mft cache stats
+
+  # Print the cache as JSON
+  kubectl mft cache stats -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCacheStats()
+	},
+}
+
+func runCacheStats() error {
+	output := mft.ListOutput(cacheStatsOpts.output)
+	switch output {
+	case mft.ListTable, mft.ListJson, mft.ListYaml:
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+
+	ttl, err := cacheTTL()
+	if err != nil {
+		return err
+	}
+	res, err := oci.CacheStats(ttl)
+	if err != nil {
+		return err
+	}
+
+	if quiet && output == mft.ListTable {
+		var ids []string
+		for _, e := range res.Entries() {
+			ids = append(ids, e.Repository+":"+e.Tag)
+		}
+		printIDs(ids)
+		return nil
+	}
+	return res.Print(output)
+}
@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+// completionRemoteTimeout bounds the registry request made to complete remote tags
+const completionRemoteTimeout = 3 * time.Second
+
+// completionRefreshCmd represents the completion refresh command
+var completionRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Rebuild the cache of completion candidates",
+	Long: `Refresh rebuilds the cache that shell completion reads tags from.
+
+Completing a tag reads the references of local storage from a cache in the cache directory,
+so that completion stays fast with hundreds of repositories. Commands that change local
+storage invalidate the cache, and it expires after an hour to pick up changes to system
+storage. Completing a reference of a remote repository after the tag separator, such as
+'ghcr.io/myorg/app:', lists the tags in the registry, and completing a repository under a
+registry named by a 'registries' rule of the configuration without wildcards, such as
+'ghcr.io/', lists the repositories in its catalog. Both are cached for five minutes.
+
+Run refresh after storage was modified by other means, such as manual file operations, or to
+forget the cached remote tags and repositories.
+
+Examples:
+  kubectl mft completion refresh`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCompletionRefresh(cmd.Context())
+	},
+}
+
+func runCompletionRefresh(ctx context.Context) error {
+	n, err := oci.RefreshCompletionCache(ctx)
+	if err != nil {
+		return err
+	}
+	printResult("", "Cached %d references for completion\n", n)
+	return nil
+}
+
+// initCompletionCmd creates the default completion command of cobra ahead of execution and
+// adds the refresh command to it.
+func initCompletionCmd() {
+	rootCmd.InitDefaultCompletionCmd()
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == "completion" {
+			c.AddCommand(completionRefreshCmd)
+			return
+		}
+	}
+}
+
+// completeTags returns a completion function that completes the first n arguments, or every
+// argument if n is negative, with the references of local storage. An argument that already
+// holds the tag separator after a remote repository is completed with the tags of the
+// repository in its registry as well, and one under a configured registry with the
+// repositories of the registry.
+func completeTags(n int) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if n >= 0 && len(args) >= n {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		// Completion does not run the persistent pre-run of the root command
+		if err := initProfile(); err != nil {
+			cobra.CompErrorln(err.Error())
+			return nil, cobra.ShellCompDirectiveError
+		}
+		if err := oci.InitBaseDir(); err != nil {
+			cobra.CompErrorln(err.Error())
+			return nil, cobra.ShellCompDirectiveError
+		}
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		refs, err := oci.CompletionReferences(ctx)
+		if err != nil {
+			cobra.CompErrorln(err.Error())
+			return nil, cobra.ShellCompDirectiveError
+		}
+		if i := strings.LastIndex(toComplete, ":"); i > strings.LastIndex(toComplete, "/") {
+			refs = append(refs, remoteCompletions(ctx, toComplete[:i])...)
+		} else if host, _, ok := strings.Cut(toComplete, "/"); ok {
+			refs = append(refs, repositoryCompletions(ctx, host)...)
+		}
+
+		var completions []cobra.Completion
+		for _, ref := range refs {
+			if strings.HasPrefix(ref, toComplete) && !slices.Contains(completions, ref) {
+				completions = append(completions, ref)
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// repositoryCompletions returns the repositories in the registry if a 'registries' rule of
+// the configuration names it without wildcards. Other registries are not asked, so that
+// completion does not send requests to hosts typed by mistake.
+func repositoryCompletions(ctx context.Context, registry string) []string {
+	cfg, err := config.Load()
+	if err != nil || !slices.ContainsFunc(cfg.Registries, func(r config.Registry) bool {
+		return r.Registry == registry
+	}) || registry == oci.DefaultRegistry {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, completionRemoteTimeout)
+	defer cancel()
+	repos, err := oci.CompletionRepositories(ctx, registry)
+	if err != nil {
+		cobra.CompDebugln(err.Error(), false)
+		return nil
+	}
+	return repos
+}
+
+// remoteCompletions returns the references of the tags of the repository in its registry.
+// Failures are only logged, as completion falls back to local references.
+func remoteCompletions(ctx context.Context, repository string) []string {
+	r, err := oci.NewRepository(repository)
+	if err != nil || r.Registry() == oci.DefaultRegistry {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, completionRemoteTimeout)
+	defer cancel()
+	tags, err := oci.CompletionTags(ctx, r)
+	if err != nil {
+		cobra.CompDebugln(err.Error(), false)
+		return nil
+	}
+	refs := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		refs = append(refs, repository+":"+tag)
+	}
+	return refs
+}
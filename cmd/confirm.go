@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// assumeYes is set by the --yes flag
+var assumeYes bool
+
+// confirm asks the user to confirm a destructive action and reports whether they agreed.
+// With --yes or skip set, it confirms without asking. If stdin is not a terminal, confirm
+// fails instead of treating the missing answer as a refusal, so scripts notice that the
+// action did not happen.
+func confirm(prompt string, skip bool) (bool, error) {
+	if assumeYes || skip {
+		return true, nil
+	}
+	if !stdinIsTerminal() {
+		return false, fmt.Errorf("%s: confirmation required but stdin is not a terminal, use --%s to confirm", strings.TrimSuffix(prompt, "?"), YesFlag)
+	}
+
+	fmt.Printf("%s (y/N): ", prompt)
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return false, nil
+	}
+
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes", nil
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal.
+func stdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
@@ -4,14 +4,26 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/spf13/cobra"
 
 	"github.com/chez-shanpu/kubectl-mft/internal/mft"
 	"github.com/chez-shanpu/kubectl-mft/internal/oci"
 )
 
+type CpOpts struct {
+	force bool
+}
+
+var cpOpts CpOpts
+
 func init() {
 	rootCmd.AddCommand(cpCmd)
+
+	flag := cpCmd.Flags()
+	flag.BoolVar(&cpOpts.force, ForceFlag, false, "Replace the destination tag if it already exists")
 }
 
 // cpCmd represents the cp command
@@ -20,20 +32,110 @@ var cpCmd = &cobra.Command{
 	Short: "Copy a manifest to a new tag",
 	Long: `Copy a manifest from one tag to another in local storage.
 
+Either tag may instead be of the form oci-layout://<dir>[:<tag>] to copy the manifest to or
+from an OCI image layout directory, e.g. to exchange it over removable media, or of the form
+s3://, gs://, or azblob://<bucket>[/<prefix>][:<tag>] to copy it to or from object storage,
+see 'kubectl mft push --help'. Copying to such a destination without a tag keeps the source
+tag. Signatures are not verified, use 'kubectl mft pull' to verify a manifest taken from a
+layout or object storage.
+
 This command performs a deep copy, duplicating both the manifest and its blobs.
-You can copy across different registries or repositories within local storage.`,
-	Args: cobra.ExactArgs(2),
-	RunE: runCopy,
+You can copy across different registries or repositories within local storage.
+
+An existing destination tag is only replaced with --force, e.g. to move a rolling
+alias such as "staging". Blobs shared with the new manifest are reused, and the
+manifest the tag pointed to before is removed unless another tag or bundle uses it.
+
+Signatures are copied along, but signatures bind the tag they were created for, so the
+destination tag must be signed again with 'kubectl mft sign' to verify. Signatures
+created before tags were bound verify under any tag.
+
+Examples:
+  # Copy a manifest to a new tag
+  kubectl mft cp myapp:v1.2.0 myapp:v1.2.0-rc1
+
+  # Point the staging alias at a new release
+  kubectl mft cp --force myapp:v1.3.0 myapp:staging
+
+  # Store a manifest of an OCI image layout under a tag of your choice
+  kubectl mft cp oci-layout:///media/usb/manifests:v1.3.0 myapp:v1.3.0`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeTags(2),
+	RunE:              runCopy,
 }
 
 func runCopy(cmd *cobra.Command, args []string) error {
 	src := args[0]
 	dest := args[1]
+	switch {
+	case isExternalReference(src) && isExternalReference(dest):
+		return fmt.Errorf("cannot copy between %s and %s, one tag must be in local storage", src, dest)
+	case isExternalReference(src):
+		return copyFromExternal(cmd.Context(), src, dest)
+	case isExternalReference(dest):
+		return copyToExternal(cmd.Context(), src, dest)
+	}
 
 	sourceRepo, err := oci.NewRepository(src)
 	if err != nil {
 		return err
 	}
 
-	return mft.Copy(cmd.Context(), sourceRepo, dest)
+	debugf("Copying %s from %s to %s\n", src, sourceRepo.LayoutPath(), dest)
+	return mft.Copy(cmd.Context(), sourceRepo, dest, mft.CopyOptions{Force: cpOpts.force})
+}
+
+// copyFromExternal copies the manifest tagged in an OCI image layout directory or object
+// storage to dest in local storage.
+func copyFromExternal(ctx context.Context, src, dest string) error {
+	e, err := parseExternalReference(src)
+	if err != nil {
+		return err
+	}
+	r, err := oci.NewRepository(dest)
+	if err != nil {
+		return err
+	}
+	exists, err := r.Exists(ctx)
+	if err != nil {
+		return err
+	}
+	if exists && !cpOpts.force {
+		return fmt.Errorf("destination tag %q already exists (use --%s to overwrite)", r.Tag(), ForceFlag)
+	}
+	source, err := e.source(ctx)
+	if err != nil {
+		return err
+	}
+	debugf("Copying %s to %s\n", e, dest)
+	return r.PullFrom(ctx, source, e.tag(), e.String())
+}
+
+// copyToExternal copies the manifest of src in local storage to an OCI image layout directory
+// or object storage.
+func copyToExternal(ctx context.Context, src, dest string) error {
+	e, err := parseExternalReference(dest)
+	if err != nil {
+		return err
+	}
+	r, err := oci.NewRepository(src)
+	if err != nil {
+		return err
+	}
+	e.defaultTag(r.Tag())
+	if !cpOpts.force {
+		exists, err := e.exists(ctx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("destination %s already exists (use --%s to overwrite)", e, ForceFlag)
+		}
+	}
+	target, err := e.target(ctx)
+	if err != nil {
+		return err
+	}
+	debugf("Copying %s from %s to %s\n", src, r.LayoutPath(), e)
+	return r.PushTo(ctx, target, e.tag())
 }
@@ -4,11 +4,8 @@
 package cmd
 
 import (
-	"bufio"
 	"context"
 	"fmt"
-	"os"
-	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -17,8 +14,9 @@ import (
 )
 
 type DeleteOpts struct {
-	tag   string
-	force bool
+	tag    string
+	force  bool
+	dryRun bool
 }
 
 var deleteOpts DeleteOpts
@@ -27,7 +25,8 @@ func init() {
 	rootCmd.AddCommand(deleteCmd)
 
 	flag := deleteCmd.Flags()
-	flag.BoolVarP(&deleteOpts.force, ForceFlag, ForceShortFlag, false, "Skip confirmation prompt")
+	flag.BoolVar(&deleteOpts.force, ForceFlag, false, "Skip confirmation prompt, same as --yes")
+	flag.BoolVar(&deleteOpts.dryRun, DryRunFlag, false, "Show the tags and blobs that would be removed without deleting")
 }
 
 // deleteCmd represents the delete command
@@ -40,21 +39,29 @@ This command deletes a previously stored manifest from the local OCI layout.
 Orphaned blobs (blobs only referenced by the deleted manifest) are automatically removed.
 If the deleted manifest is the last one in the repository, the entire repository directory is removed.
 
-By default, a confirmation prompt is shown before deletion. Use the --force flag to skip confirmation.
+By default, a confirmation prompt is shown before deletion. Use --yes (or --force) to skip
+confirmation. Without --yes, the command fails if stdin is not a terminal.
+
+With --dry-run, the tags and blobs that would be removed are printed without asking
+for confirmation and without deleting anything.
 
 Examples:
   # Delete a manifest with confirmation
   kubectl mft delete registry.example.com/manifests/app:v1.0.0
 
   # Delete without confirmation
-  kubectl mft delete localhost/myapp:latest --force
+  kubectl mft delete localhost/myapp:latest --yes
+
+  # Show what would be removed
+  kubectl mft delete localhost/myapp:latest --dry-run
 
   # Delete with verbose output
   kubectl mft delete localhost/myapp:latest -v
 
   # Delete quietly (no output on success)
   kubectl mft delete localhost/myapp:latest -q`,
-	Args: cobra.ExactArgs(1),
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		deleteOpts.tag = args[0]
 		return runDelete(cmd.Context())
@@ -67,36 +74,39 @@ func runDelete(ctx context.Context) error {
 		return err
 	}
 
-	if !deleteOpts.force {
-		if !confirmDeletion(deleteOpts.tag) {
-			fmt.Println("Deletion cancelled")
+	if deleteOpts.dryRun {
+		res, err := mft.PlanDelete(ctx, r)
+		if err != nil {
+			return err
+		}
+		if res == nil {
+			fmt.Printf("Warning: manifest %s not found locally\n", r)
 			return nil
 		}
+		return res.Print()
 	}
 
-	res, err := mft.Delete(ctx, r)
+	ok, err := confirm(fmt.Sprintf("Delete manifest %s?", r), deleteOpts.force)
 	if err != nil {
 		return err
 	}
-	if res == nil {
-		fmt.Printf("Warning: manifest %s not found locally\n", deleteOpts.tag)
+	if !ok {
+		fmt.Println("Deletion cancelled")
 		return nil
 	}
 
-	res.Print()
-	return nil
-}
-
-// confirmDeletion shows a confirmation prompt and returns true if user confirms
-func confirmDeletion(tag string) bool {
-	fmt.Printf("Delete manifest %s? (y/N): ", tag)
-
-	reader := bufio.NewReader(os.Stdin)
-	response, err := reader.ReadString('\n')
+	debugf("Deleting %s from %s\n", r, r.LayoutPath())
+	res, err := mft.Delete(ctx, r)
 	if err != nil {
-		return false
+		return err
+	}
+	if res == nil {
+		fmt.Printf("Warning: manifest %s not found locally\n", r)
+		return nil
 	}
 
-	response = strings.TrimSpace(strings.ToLower(response))
-	return response == "y" || response == "yes"
+	if !quiet {
+		res.Print()
+	}
+	return nil
 }
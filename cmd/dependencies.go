@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+const (
+	DependsOnFlag  = "depends-on"
+	dependsOnUsage = "Artifact the manifest depends on, as a tag in local storage or <repository>:<tag>@<digest>, can be repeated"
+
+	WithDependenciesFlag = "with-dependencies"
+)
+
+// dependencyAnnotations returns the annotations recording the --depends-on artifacts of pack.
+// Tags are pinned to the digest they point to in local storage.
+func dependencyAnnotations(ctx context.Context, values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	deps := make([]mft.Dependency, 0, len(values))
+	for _, v := range values {
+		if strings.Contains(v, "@") {
+			dep, err := mft.ParseDependency(v)
+			if err != nil {
+				return nil, err
+			}
+			deps = append(deps, dep)
+			continue
+		}
+		r, err := oci.NewRepository(v)
+		if err != nil {
+			return nil, err
+		}
+		d, err := r.Digest(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("dependency %s: %w", v, err)
+		}
+		deps = append(deps, mft.Dependency{Tag: r.Name() + ":" + r.Tag(), Digest: digest.Digest(d)})
+	}
+	return map[string]string{mft.AnnotationDependsOn: mft.FormatDependencies(deps)}, nil
+}
+
+// pullDependencies makes the dependencies of r, transitively, available in local storage at
+// their pinned digests, pulling and verifying those that are missing or differ, and returns
+// them in the order they are to be applied, every artifact after its dependencies.
+func pullDependencies(ctx context.Context, r *oci.Repository, skipVerify bool) ([]*oci.Repository, error) {
+	rootDigest, err := r.Digest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	root := mft.Dependency{Tag: r.Name() + ":" + r.Tag(), Digest: digest.Digest(rootDigest)}
+
+	repos := map[string]*oci.Repository{root.Tag: r}
+	deps, err := mft.ResolveDependencies(ctx, root, func(ctx context.Context, dep mft.Dependency) ([]mft.Dependency, error) {
+		dr, ok := repos[dep.Tag]
+		if !ok {
+			var err error
+			if dr, err = ensureDependency(ctx, dep, skipVerify); err != nil {
+				return nil, fmt.Errorf("dependency %s: %w", dep, err)
+			}
+			repos[dep.Tag] = dr
+		}
+		annotations, err := dr.Annotations(ctx)
+		if err != nil {
+			return nil, err
+		}
+		children, err := mft.ParseDependencies(annotations[mft.AnnotationDependsOn])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dep.Tag, err)
+		}
+		// Tags are compared in their normalized form, e.g. local/app:v1 for app:v1
+		for i, c := range children {
+			cr, err := oci.NewRepository(c.Tag)
+			if err != nil {
+				return nil, err
+			}
+			children[i].Tag = cr.Name() + ":" + cr.Tag()
+		}
+		return children, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make([]*oci.Repository, len(deps))
+	for i, dep := range deps {
+		ordered[i] = repos[dep.Tag]
+	}
+	return ordered, nil
+}
+
+// ensureDependency pulls and verifies the pinned digest of dep unless local storage has it
+// under its tag already. A local copy is checked against the pin, down to the digests of its
+// blobs, and its signature is verified like that of a pulled one. A pulled dependency is
+// verified in a staging layout before it replaces a local copy that differs from the pin.
+func ensureDependency(ctx context.Context, dep mft.Dependency, skipVerify bool) (*oci.Repository, error) {
+	r, err := oci.NewRepository(dep.Tag)
+	if err != nil {
+		return nil, err
+	}
+	skip, err := skipVerification(r, skipVerify)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := r.Exists(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check local manifest: %w", err)
+	}
+	if exists {
+		local, err := r.Digest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if local == dep.Digest.String() {
+			debugf("Dependency %s is in local storage\n", dep)
+			res, err := r.VerifyContent(ctx, false)
+			if err != nil {
+				return nil, err
+			}
+			if !res.OK() {
+				issue := res.Issues()[0]
+				return nil, withExitCode(ExitValidation, fmt.Errorf("local copy does not match the pinned digest, blob %s is corrupted (%s)", issue.Digest, issue.Reason))
+			}
+			if !skip {
+				debugf("Verifying signature of %s\n", r)
+				if err := verifyPulled(ctx, r, true); err != nil {
+					return nil, err
+				}
+			}
+			return r, nil
+		}
+		infof("Local copy of %s differs from the pinned digest %s, pulling it\n", r, dep.Digest)
+	}
+
+	r.SetPinnedDigest(dep.Digest)
+	debugf("Pulling dependency %s into %s\n", dep, r.LayoutPath())
+	s, err := r.StagePull(ctx)
+	if err != nil {
+		return nil, withExitCode(ExitRegistry, err)
+	}
+	defer s.Discard()
+	if !skip {
+		debugf("Verifying signature of %s\n", r)
+		// The staging layout is discarded on failure, nothing is removed from local storage
+		if err := verifyPulled(ctx, s.Repository(), true); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Commit(ctx); err != nil {
+		return nil, err
+	}
+	infof("Pulled dependency %s\n", dep)
+	return r, nil
+}
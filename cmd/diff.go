@@ -0,0 +1,198 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/cluster"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type DiffOpts struct {
+	base        string
+	target      string
+	fromCluster bool
+	output      string
+	color       string
+}
+
+var diffOpts DiffOpts
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	flag := diffCmd.Flags()
+	flag.BoolVar(&diffOpts.fromCluster, "from-cluster", false, "Also compare with the live objects in the current cluster")
+	flag.StringVarP(&diffOpts.output, OutputFlag, OutputShortFlag, "table", "Output format (table, markdown, json, yaml)")
+	flag.StringVar(&diffOpts.color, ColorFlag, colorAuto, colorUsage)
+}
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <base-tag> <target-tag>",
+	Short: "Compare two versions of a manifest, optionally with the live cluster",
+	Long: `Diff compares the resources of two locally stored manifests field by field, such as the
+version running in production and the one about to be promoted. Resources are matched by
+kind, namespace, and name, and reported as added, removed, or changed.
+
+With --from-cluster, the live object of every resource is fetched with 'kubectl get' and
+the comparison becomes three-way. Each changed field is classified as:
+
+  modified   the target changes the field, the cluster still has the base value
+  applied    the target changes the field, the cluster already has the target value
+  drifted    both versions agree, but the live value differs and will be reverted
+  conflict   the live value matches neither version
+
+Only fields set in either version are compared. Fields added by the cluster, such as
+defaults and status, are ignored, as in 'kubectl mft drift'.
+
+Output formats:
+  - table:    The differing fields in a column per version (default), colored in terminals
+  - markdown: A collapsible section with a diff block per resource, for pull request comments
+  - json:     JSON format
+  - yaml:     YAML format
+
+Examples:
+  # Compare two versions
+  kubectl mft diff ghcr.io/myorg/app:v1.0.0 ghcr.io/myorg/app:v1.1.0
+
+  # Comment the differences on a pull request
+  kubectl mft diff ghcr.io/myorg/app:v1.0.0 ghcr.io/myorg/app:v1.1.0 -o markdown | gh pr comment --body-file -
+
+  # Review a promotion against what is actually running
+  kubectl mft diff ghcr.io/myorg/app:v1.0.0 ghcr.io/myorg/app:v1.1.0 --from-cluster`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeTags(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		diffOpts.base = args[0]
+		diffOpts.target = args[1]
+		return runDiff(cmd.Context())
+	},
+}
+
+func runDiff(ctx context.Context) error {
+	color, err := useColor(diffOpts.color)
+	if err != nil {
+		return err
+	}
+	baseTag, baseDocs, err := diffDocuments(ctx, diffOpts.base)
+	if err != nil {
+		return err
+	}
+	targetTag, targetDocs, err := diffDocuments(ctx, diffOpts.target)
+	if err != nil {
+		return err
+	}
+
+	bases := make(map[string]*manifest.Document, len(baseDocs))
+	for _, d := range baseDocs {
+		bases[resourceKey(d)] = d
+	}
+	var resources []*mft.ResourceDiff
+	for _, t := range targetDocs {
+		b := bases[resourceKey(t)]
+		delete(bases, resourceKey(t))
+		rd, err := diffResource(ctx, b, t)
+		if err != nil {
+			return err
+		}
+		resources = append(resources, rd)
+	}
+	// Resources removed by the target, in base order
+	for _, b := range baseDocs {
+		if _, ok := bases[resourceKey(b)]; !ok {
+			continue
+		}
+		rd, err := diffResource(ctx, b, nil)
+		if err != nil {
+			return err
+		}
+		resources = append(resources, rd)
+	}
+
+	return mft.NewDiffResult(baseTag, targetTag, diffOpts.fromCluster, resources).WithColor(color).Print(mft.ListOutput(diffOpts.output))
+}
+
+// diffDocuments returns the resolved tag and the resources of a locally stored manifest.
+func diffDocuments(ctx context.Context, tag string) (string, []*manifest.Document, error) {
+	tag, err := resolveTag(ctx, tag, false)
+	if err != nil {
+		return "", nil, err
+	}
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return "", nil, err
+	}
+	var docs []*manifest.Document
+	all, err := artifactDocuments(ctx, r)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, d := range all {
+		if d.APIVersion != "" && d.Kind != "" {
+			docs = append(docs, d)
+		}
+	}
+	return tag, docs, nil
+}
+
+// resourceKey identifies a resource across manifest versions.
+func resourceKey(d *manifest.Document) string {
+	return fmt.Sprintf("%s/%s/%s", d.Kind, d.Namespace, d.Name)
+}
+
+// diffResource compares the base and target versions of a resource, either of which is nil
+// if the resource is only in the other version, and the live object with --from-cluster.
+func diffResource(ctx context.Context, base, target *manifest.Document) (*mft.ResourceDiff, error) {
+	doc := target
+	if doc == nil {
+		doc = base
+	}
+	rd := &mft.ResourceDiff{Kind: doc.Kind, Namespace: doc.Namespace, Name: doc.Name, Status: mft.ResourceUnchanged}
+	switch {
+	case base == nil:
+		rd.Status = mft.ResourceAdded
+	case target == nil:
+		rd.Status = mft.ResourceRemoved
+	}
+
+	var live cluster.Object
+	if diffOpts.fromCluster {
+		var err error
+		if live, err = cluster.Get(ctx, doc); err != nil {
+			return nil, err
+		}
+		if live == nil {
+			rd.LiveMissing = true
+		} else if ns, ok := cluster.NamespaceOf(live); ok {
+			rd.Namespace = ns
+		}
+	}
+	// Added and removed resources are reported as a whole
+	if base == nil || target == nil {
+		return rd, nil
+	}
+
+	b, err := cluster.Desired(base)
+	if err != nil {
+		return nil, err
+	}
+	t, err := cluster.Desired(target)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cluster.ThreeWay(b, live, t) {
+		if c.Kind != cluster.ChangeDrifted {
+			rd.Status = mft.ResourceChanged
+		}
+		rd.Changes = append(rd.Changes, &mft.FieldChange{Path: c.Path, Base: c.Base, Live: c.Live, Target: c.Target, Kind: c.Kind})
+	}
+	return rd, nil
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/cluster"
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type DriftOpts struct {
+	tag    string
+	output string
+	color  string
+}
+
+var driftOpts DriftOpts
+
+func init() {
+	rootCmd.AddCommand(driftCmd)
+
+	flag := driftCmd.Flags()
+	flag.StringVarP(&driftOpts.output, OutputFlag, OutputShortFlag, "table", "Output format (table, markdown, json, yaml)")
+	flag.StringVar(&driftOpts.color, ColorFlag, colorAuto, colorUsage)
+}
+
+// driftCmd represents the drift command
+var driftCmd = &cobra.Command{
+	Use:   "drift <tag>",
+	Short: "Detect drift between the cluster and a stored manifest",
+	Long: `Drift fetches the live object of every resource in a locally stored manifest with
+'kubectl get' and reports each field whose live value differs from the stored manifest,
+as well as resources missing from the cluster.
+
+Only fields set in the stored manifest are compared. Fields added by the cluster,
+such as defaults and status, are not drift. For bundles, every member is checked.
+
+The command exits with a non-zero status when drift is detected, so it can be used
+in scheduled compliance jobs.
+
+Output formats:
+  - table:    The desired and live values of drifted fields (default), colored in terminals
+  - markdown: A collapsible section with a diff block per drifted resource, for pull request comments
+  - json:     JSON format
+  - yaml:     YAML format
+
+Examples:
+  # Check an applied manifest for drift
+  kubectl mft drift myapp:v1.0.0
+
+  # Produce a machine-readable report
+  kubectl mft drift ghcr.io/myorg/manifests:v1.0.0 -o json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		driftOpts.tag = args[0]
+		return runDrift(cmd.Context())
+	},
+}
+
+func runDrift(ctx context.Context) error {
+	color, err := useColor(driftOpts.color)
+	if err != nil {
+		return err
+	}
+	tag, err := resolveTag(ctx, driftOpts.tag, false)
+	if err != nil {
+		return err
+	}
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+
+	docs, err := artifactDocuments(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	var resources []*mft.ResourceDrift
+	for _, d := range docs {
+		if d.APIVersion == "" || d.Kind == "" {
+			continue
+		}
+		live, err := cluster.Get(ctx, d)
+		if err != nil {
+			return err
+		}
+		rd, err := mft.CompareDrift(d, live)
+		if err != nil {
+			return err
+		}
+		resources = append(resources, rd)
+	}
+
+	result := mft.NewDriftResult(tag, resources).WithColor(color)
+	if err := result.Print(mft.ListOutput(driftOpts.output)); err != nil {
+		return err
+	}
+	if drifted := result.Drifted(); len(drifted) > 0 {
+		return fmt.Errorf("drift detected in %d of %d resources", len(drifted), len(resources))
+	}
+	return nil
+}
@@ -4,20 +4,30 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
 	"github.com/chez-shanpu/kubectl-mft/internal/mft"
 	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/paths"
+	"github.com/chez-shanpu/kubectl-mft/internal/transform"
 )
 
 type DumpOpts struct {
-	output string
-	tag    string
+	output   string
+	tag      string
+	rewrites map[string]string
+	redact   bool
 }
 
 var dumpOpts DumpOpts
@@ -27,6 +37,8 @@ func init() {
 
 	flag := dumpCmd.Flags()
 	flag.StringVarP(&dumpOpts.output, OutputFlag, OutputShortFlag, "", "Output file path (default: stdout)")
+	flag.StringToStringVar(&dumpOpts.rewrites, RewriteImagesFlag, nil, rewriteImagesUsage)
+	flag.BoolVar(&dumpOpts.redact, "redact-secrets", false, "Mask the values of Secrets, showing only their keys and keyed hashes")
 }
 
 // dumpCmd represents the dump command
@@ -35,53 +47,187 @@ var dumpCmd = &cobra.Command{
 	Short: "Dump a manifest from local OCI layout storage",
 	Long: `Dump retrieves and outputs a Kubernetes manifest from local OCI layout storage.
 
-This command reads a previously packed manifest from the local OCI layout and outputs
-its contents either to stdout or to a specified file. The manifest must have been
-previously packed using the 'pack' command.
+This command reads a previously packed or pulled manifest from the local OCI layout and
+outputs its contents either to stdout or to a specified file. A manifest not in local
+storage is fetched from the registry into the cache and verified first, see
+'kubectl mft cache'.
+
+The content is streamed from storage, so large manifests are not held in memory, and checked
+against its digest as it is read. Dump fails if the stored content was modified, removing
+the output file. Content for stdout is spooled to a temporary file and written only after
+it has been verified, so that 'kubectl mft dump app:v1 | kubectl apply -f -' never applies
+modified content.
+
+The reference may be a semver range such as 'myapp:^1.2' or 'myapp:latest-semver', which
+resolves to the highest matching tag in local storage.
+
+With --rewrite-images, the registry of container images is replaced as 'kubectl mft apply'
+does, e.g. to hand the manifest to other tools in a disconnected environment.
+
+With --redact-secrets, the data and stringData values of Secrets, including those in the items
+of lists, are replaced with a truncated HMAC-SHA256 of the value, so the manifest can be
+reviewed or attached to tickets without leaking credentials. Keys are kept, and equal or
+changed values can still be told apart. The HMAC key is generated on first use and kept in
+redaction.key in the data directory, so that values cannot be guessed from the hashes by
+anyone without the key.
 
 Examples:
   # Dump manifest to stdout
   kubectl mft dump registry.example.com/manifests/app:v1.0.0
 
   # Dump manifest to a file
-  kubectl mft dump localhost/myapp:latest -o restored-manifest.yaml`,
-	Args: cobra.ExactArgs(1),
+  kubectl mft dump localhost/myapp:latest -o restored-manifest.yaml
+
+  # Dump the newest 1.x release
+  kubectl mft dump myapp:^1
+
+  # Dump for a review without Secret values
+  kubectl mft dump myapp:v1.0.0 --redact-secrets
+
+  # Dump with images pulled from an internal mirror
+  kubectl mft dump myapp:v1.0.0 --rewrite-images docker.io=registry.internal`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		dumpOpts.tag = args[0]
 		return runDump(cmd.Context())
 	},
 }
 
-func runDump(ctx context.Context) (err error) {
-	r, err := oci.NewRepository(dumpOpts.tag)
+func runDump(ctx context.Context) error {
+	tag, err := resolveTag(ctx, dumpOpts.tag, false)
 	if err != nil {
 		return err
 	}
+	if tag, err = resolveAlias(ctx, tag); err != nil {
+		return err
+	}
 
-	res, err := mft.Dump(ctx, r)
+	r, err := oci.NewRepository(tag)
 	if err != nil {
 		return err
 	}
+	if err := fetchIfMissing(ctx, r); err != nil {
+		return err
+	}
 
-	var w io.Writer
-	if dumpOpts.output == "" {
-		w = os.Stdout
+	var pipeline transform.Pipeline
+	rewrite, err := rewriteImages(dumpOpts.rewrites)
+	if err != nil {
+		return err
+	}
+	if rewrite != nil {
+		pipeline = append(pipeline, rewrite)
+	}
+	if dumpOpts.redact {
+		key, err := redactionKey()
+		if err != nil {
+			return err
+		}
+		pipeline = append(pipeline, transform.RedactSecretsMutator{Key: key})
+	}
+
+	// Without transforms, the content is streamed from the blob instead of held in memory
+	var content io.Reader
+	if len(pipeline) == 0 {
+		rc, err := mft.Dump(ctx, r)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		content = rc
 	} else {
-		f, err := os.Create(dumpOpts.output)
+		docs, err := readDocuments(ctx, r, pipeline)
 		if err != nil {
 			return err
 		}
-		defer func() {
-			if cerr := f.Close(); cerr != nil && err == nil {
-				err = cerr
-			}
-		}()
+		content = bytes.NewReader(manifest.Join(docs))
+	}
 
-		w = f
-		// show output file path after writing
-		defer fmt.Println(dumpOpts.output)
+	if dumpOpts.output == "" {
+		if len(pipeline) == 0 {
+			return writeVerified(os.Stdout, content)
+		}
+		_, err = io.Copy(os.Stdout, content)
+		return err
 	}
 
-	_, err = io.Copy(w, res)
+	f, err := os.Create(dumpOpts.output)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, content)
+	if cerr := f.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+	if err != nil {
+		// Do not leave content that failed verification behind
+		_ = os.Remove(dumpOpts.output)
+		return err
+	}
+	// show output file path after writing
+	fmt.Println(dumpOpts.output)
+	return nil
+}
+
+// redactionKeyFile is the file in the data directory holding the HMAC key of --redact-secrets
+const redactionKeyFile = "redaction.key"
+
+// redactionKey returns the HMAC key of --redact-secrets, generating it on first use.
+func redactionKey() ([]byte, error) {
+	dir, err := paths.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, redactionKeyFile)
+	if key, err := os.ReadFile(path); err == nil && len(key) > 0 {
+		return key, nil
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read redaction key: %w", err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate redaction key: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		// Generated concurrently by another invocation
+		return os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create redaction key: %w", err)
+	}
+	if _, err := f.Write(key); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write redaction key: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write redaction key: %w", err)
+	}
+	return key, nil
+}
+
+// writeVerified copies content to w only once all of it has been read, so that nothing is
+// written when reading fails the digest check at the end. The content is spooled to a
+// temporary file instead of memory.
+func writeVerified(w io.Writer, content io.Reader) error {
+	tmp, err := os.CreateTemp("", "kubectl-mft-dump-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.Copy(w, tmp)
 	return err
 }
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+const (
+	// OnDuplicateFlag selects what happens with resources defined more than once
+	OnDuplicateFlag = "on-duplicate"
+
+	onDuplicateUsage = "Handling of resources defined differently more than once: fail, warn (keep the first), or keep-last"
+
+	duplicateFail     = "fail"
+	duplicateWarn     = "warn"
+	duplicateKeepLast = "keep-last"
+)
+
+// manifestExtensions are the extensions of the files read from a directory
+var manifestExtensions = []string{".yaml", ".yml", ".json"}
+
+// combineSources concatenates the resources of the sources, handling resources defined more
+// than once according to policy. Identical definitions are always kept once.
+func combineSources(sources []manifest.Source, policy string) ([]*manifest.Document, error) {
+	if err := checkOnDuplicate(policy); err != nil {
+		return nil, err
+	}
+	docs, dups, err := manifest.Combine(sources, policy == duplicateKeepLast)
+	if err != nil {
+		return nil, err
+	}
+	var conflicts []string
+	for _, d := range dups {
+		defined := strings.Join(d.Sources, ", ")
+		switch {
+		case !d.Conflict:
+			infof("%s is defined identically by %s, keeping one\n", d.Key, defined)
+		case policy == duplicateFail:
+			conflicts = append(conflicts, fmt.Sprintf("  %s: defined differently by %s", d.Key, defined))
+		case policy == duplicateWarn:
+			infof("Warning: %s is defined differently by %s, keeping the definition of %s\n", d.Key, defined, d.Sources[0])
+		default:
+			infof("%s is defined differently by %s, keeping the definition of %s\n", d.Key, defined, d.Sources[len(d.Sources)-1])
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%d resource(s) are defined differently more than once, use --%s to keep one:\n%s",
+			len(conflicts), OnDuplicateFlag, strings.Join(conflicts, "\n"))
+	}
+	return docs, nil
+}
+
+// checkOnDuplicate returns an error if policy is not a valid --on-duplicate value.
+func checkOnDuplicate(policy string) error {
+	if !slices.Contains([]string{duplicateFail, duplicateWarn, duplicateKeepLast}, policy) {
+		return fmt.Errorf("invalid --%s %q, expected fail, warn, or keep-last", OnDuplicateFlag, policy)
+	}
+	return nil
+}
+
+// readManifestSources reads the resources of manifest files. A directory contributes its
+// files with a manifest extension, in lexical order.
+func readManifestSources(paths []string) ([]manifest.Source, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory %s: %w", p, err)
+		}
+		n := len(files)
+		for _, e := range entries {
+			if !e.IsDir() && slices.Contains(manifestExtensions, strings.ToLower(filepath.Ext(e.Name()))) {
+				files = append(files, filepath.Join(p, e.Name()))
+			}
+		}
+		if len(files) == n {
+			return nil, fmt.Errorf("directory %s holds no manifest files (%s)", p, strings.Join(manifestExtensions, ", "))
+		}
+	}
+
+	sources := make([]manifest.Source, 0, len(files))
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		docs, err := manifest.Split(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", f, err)
+		}
+		sources = append(sources, manifest.Source{Name: f, Docs: docs})
+	}
+	return sources, nil
+}
@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/paths"
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+	"github.com/chez-shanpu/kubectl-mft/internal/validate"
+)
+
+type EnvOpts struct {
+	output string
+}
+
+var envOpts EnvOpts
+
+func init() {
+	rootCmd.AddCommand(envCmd)
+
+	flag := envCmd.Flags()
+	flag.StringVarP(&envOpts.output, OutputFlag, OutputShortFlag, "", "Output format (json)")
+}
+
+// envCmd represents the env command
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Print the resolved kubectl-mft directories",
+	Long: `Env prints the directories kubectl-mft uses for storage, keys, schemas, configuration,
+cache, and temporary working files, after applying environment variable overrides.
+
+Each entry is printed as the environment variable that overrides it.
+Directories default to XDG_DATA_HOME, XDG_CONFIG_HOME, and XDG_CACHE_HOME when set, on
+every OS, and to the per-OS user directories otherwise. Manifests, keys, schemas, configuration,
+and cache of profiles other than "default" (see --profile) are stored under profiles/<name> in the
+data, configuration, and cache directories.
+
+Examples:
+  # Print all directories
+  kubectl mft env
+
+  # Print as JSON
+  kubectl mft env -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runEnv()
+	},
+}
+
+type envVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func runEnv() error {
+	schemaDir, err := validate.SchemaDir()
+	if err != nil {
+		return err
+	}
+	configDir, err := paths.ConfigDir()
+	if err != nil {
+		return err
+	}
+	cacheDir, err := paths.CacheDir()
+	if err != nil {
+		return err
+	}
+
+	vars := []envVar{
+		{Name: "KUBECTL_MFT_PROFILE", Value: paths.Profile()},
+		{Name: "KUBECTL_MFT_STORAGE_DIR", Value: oci.BaseDir()},
+		{Name: "KUBECTL_MFT_SYSTEM_STORAGE_DIR", Value: strings.Join(oci.SystemDirs(), string(os.PathListSeparator))},
+		{Name: "KUBECTL_MFT_KEY_DIR", Value: signature.KeyDir()},
+		{Name: "KUBECTL_MFT_SCHEMA_DIR", Value: schemaDir},
+		{Name: "KUBECTL_MFT_CONFIG_DIR", Value: configDir},
+		{Name: "KUBECTL_MFT_CACHE_DIR", Value: cacheDir},
+		{Name: "KUBECTL_MFT_WORK_DIR", Value: oci.WorkDir()},
+	}
+
+	switch envOpts.output {
+	case "":
+		for _, v := range vars {
+			fmt.Printf("%s=%s\n", v.Name, v.Value)
+		}
+		return nil
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(vars)
+	default:
+		return fmt.Errorf("unsupported output format: %s", envOpts.output)
+	}
+}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"errors"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/validate"
+)
+
+// Exit codes of kubectl-mft. They are part of the command-line interface, so that pipelines
+// can branch on the kind of failure; existing codes must not change.
+const (
+	// ExitError is returned for failures not covered by a more specific code
+	ExitError = 1
+	// ExitValidation is returned when a manifest fails schema or cluster validation, or uses removed API versions
+	ExitValidation = 2
+	// ExitSignature is returned when a signature or pinned digest fails to verify
+	ExitSignature = 3
+	// ExitRegistry is returned when a transfer from or to a registry fails
+	ExitRegistry = 4
+	// ExitPolicy is returned when the image or namespace policy, a pre-apply hook, or the preflight checks reject a manifest
+	ExitPolicy = 5
+	// ExitInterrupted is returned when the command is interrupted
+	ExitInterrupted = 130
+)
+
+// exitError attaches an exit code to an error.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode makes the command exit with code if err, which may be nil, is returned.
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// exitCode returns the exit code for err returned by a command.
+func exitCode(err error) int {
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	var validationErr *validate.Error
+	if errors.As(err, &validationErr) {
+		return ExitValidation
+	}
+	return ExitError
+}
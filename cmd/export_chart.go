@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/chart"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type ExportChartOpts struct {
+	tag     string
+	dir     string
+	name    string
+	version string
+	force   bool
+}
+
+var exportChartOpts ExportChartOpts
+
+func init() {
+	rootCmd.AddCommand(exportChartCmd)
+
+	flag := exportChartCmd.Flags()
+	flag.StringVarP(&exportChartOpts.dir, "dir", "d", "", "Directory to create the chart in (default: the chart name)")
+	flag.StringVar(&exportChartOpts.name, "name", "", "Chart name (default: the last element of the repository)")
+	flag.StringVar(&exportChartOpts.version, "version", "", "Chart version (default: the tag if it is a semantic version)")
+	flag.BoolVar(&exportChartOpts.force, ForceFlag, false, "Write into a directory that is not empty")
+}
+
+// exportChartCmd represents the export-chart command
+var exportChartCmd = &cobra.Command{
+	Use:   "export-chart <tag>",
+	Short: "Export a manifest as a minimal Helm chart",
+	Long: `Export-chart wraps a manifest in local storage into a minimal Helm chart, so that
+it can be installed with Helm.
+
+The chart consists of Chart.yaml, the CustomResourceDefinitions in crds/, and the other
+resources in templates/, one file per resource. The chart has no values: template
+delimiters in the resources are escaped, so Helm installs them unchanged.
+
+The chart is named after the last element of the repository and versioned by the tag,
+or 0.0.0-<tag> if the tag is not a semantic version. The artifact is recorded in the
+kubectl-mft.io/source annotation of Chart.yaml.
+
+Examples:
+  # Export a manifest into ./chart
+  kubectl mft export-chart ghcr.io/myorg/manifests/app:v1.0.0 -d ./chart
+
+  # Install it with Helm
+  helm install app ./chart
+
+  # Override the chart name and version
+  kubectl mft export-chart myapp:latest --name my-app --version 1.0.0-nightly`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		exportChartOpts.tag = args[0]
+		return runExportChart(cmd.Context())
+	},
+}
+
+func runExportChart(ctx context.Context) error {
+	tag, err := resolveTag(ctx, exportChartOpts.tag, false)
+	if err != nil {
+		return err
+	}
+
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+
+	meta := chart.NewMetadata(r.Name(), r.Tag())
+	if exportChartOpts.name != "" {
+		meta.Name = exportChartOpts.name
+	}
+	if exportChartOpts.version != "" {
+		meta.Version = exportChartOpts.version
+	}
+	dir := exportChartOpts.dir
+	if dir == "" {
+		dir = meta.Name
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 && !exportChartOpts.force {
+		return fmt.Errorf("%s is not empty, use --force to write into it", dir)
+	}
+
+	res, err := mft.Dump(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+	docs, err := manifest.Read(res)
+	if err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	written, err := chart.Write(dir, meta, docs)
+	for _, path := range written {
+		debugf("Wrote %s\n", path)
+	}
+	if err != nil {
+		return err
+	}
+	printResult(dir, "Exported %s as chart %s %s to %s\n", r, meta.Name, meta.Version, dir)
+	return nil
+}
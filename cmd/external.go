@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+
+	"oras.land/oras-go/v2"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/objstore"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+// externalRef is a tag outside of local storage and registries, in an OCI image layout
+// directory or in object storage.
+type externalRef struct {
+	layout *oci.LayoutReference
+	object *objstore.Reference
+}
+
+// isExternalReference reports whether ref is an oci-layout://, s3://, gs://, or azblob://
+// reference rather than a tag.
+func isExternalReference(ref string) bool {
+	return oci.IsLayoutReference(ref) || objstore.IsReference(ref)
+}
+
+func parseExternalReference(ref string) (*externalRef, error) {
+	if oci.IsLayoutReference(ref) {
+		l, err := oci.ParseLayoutReference(ref)
+		if err != nil {
+			return nil, err
+		}
+		return &externalRef{layout: &l}, nil
+	}
+	o, err := objstore.ParseReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	return &externalRef{object: &o}, nil
+}
+
+func (e *externalRef) String() string {
+	if e.layout != nil {
+		return e.layout.String()
+	}
+	return e.object.String()
+}
+
+// tag returns the tag of the reference, empty if it has none.
+func (e *externalRef) tag() string {
+	if e.layout != nil {
+		return e.layout.Tag
+	}
+	return e.object.Tag
+}
+
+// defaultTag sets the tag of the reference if it has none.
+func (e *externalRef) defaultTag(tag string) {
+	if e.tag() != "" {
+		return
+	}
+	if e.layout != nil {
+		e.layout.Tag = tag
+	} else {
+		e.object.Tag = tag
+	}
+}
+
+// target opens the reference for writing.
+func (e *externalRef) target(ctx context.Context) (oras.Target, error) {
+	if e.layout != nil {
+		return e.layout.Open()
+	}
+	return objstore.Open(ctx, *e.object)
+}
+
+// source opens the reference for reading.
+func (e *externalRef) source(ctx context.Context) (oras.ReadOnlyGraphTarget, error) {
+	if e.layout != nil {
+		return e.layout.OpenReadOnly(ctx)
+	}
+	return objstore.Open(ctx, *e.object)
+}
+
+// exists reports whether the tag of the reference exists.
+func (e *externalRef) exists(ctx context.Context) (bool, error) {
+	if e.layout != nil {
+		return e.layout.Exists(), nil
+	}
+	t, err := objstore.Open(ctx, *e.object)
+	if err != nil {
+		return false, err
+	}
+	_, err = t.Resolve(ctx, e.object.Tag)
+	return err == nil, nil
+}
@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+	"github.com/chez-shanpu/kubectl-mft/internal/trust"
+)
+
+// FetchKeysFlag fetches the keys named by the signatures of a pulled manifest
+const FetchKeysFlag = "fetch-keys"
+
+const fetchKeysUsage = "If no known key verifies the signature, fetch the keys of the key distribution artifact named by the signature and trust those with an expected fingerprint for the repository"
+
+// KeyFingerprintFlag names a key --fetch-keys may import
+const KeyFingerprintFlag = "key-fingerprint"
+
+const keyFingerprintUsage = "Fingerprint of a key --fetch-keys may import, in addition to signing.fetchKeys of config.yaml, can be repeated"
+
+// fetchKeys is set by --fetch-keys on the commands that verify pulled manifests
+var fetchKeys bool
+
+// fetchKeysMu serializes the imports of fetched keys and the updates of their scopes by
+// concurrent pulls
+var fetchKeysMu sync.Mutex
+
+// keyFingerprints is set by --key-fingerprint on the commands that verify pulled manifests
+var keyFingerprints []string
+
+// fetchSigningKeys fetches the key distribution artifacts named by the signatures of r and
+// imports the keys whose fingerprints are expected for the repository of r, from
+// --key-fingerprint or signing.fetchKeys of config.yaml. The artifact reference is not covered
+// by the signature, so keys are never trusted by their origin alone. Imported keys are scoped
+// to the repository of r: they verify no other repository unless fetched for it as well.
+// It reports whether any key was imported or scoped.
+func fetchSigningKeys(ctx context.Context, r *oci.Repository) (bool, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return false, err
+	}
+	expected := append(slices.Clone(keyFingerprints), cfg.FetchKeyFingerprintsFor(r.Name())...)
+	if len(expected) == 0 {
+		return false, fmt.Errorf("no expected key fingerprint for %s, pass --%s or add a signing.fetchKeys rule to config.yaml with the fingerprints confirmed with the publisher", r.Name(), KeyFingerprintFlag)
+	}
+
+	refs, err := signature.KeysRefs(ctx, r.LayoutPath(), r.Tag())
+	if err != nil {
+		return false, err
+	}
+	if len(refs) == 0 {
+		infof("The signatures of %s name no key distribution artifact to fetch keys from\n", r)
+		return false, nil
+	}
+
+	fetchKeysMu.Lock()
+	defer fetchKeysMu.Unlock()
+	known := make(map[string]string)
+	keys, err := signature.ListKeys()
+	if err != nil {
+		return false, err
+	}
+	for _, k := range keys {
+		if k.Type == "public" {
+			known[k.Fingerprint] = k.Name
+		}
+	}
+	scopes, err := trust.LoadScopes()
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for _, ref := range refs {
+		kr, err := oci.NewRepository(ref)
+		if err != nil {
+			return changed, err
+		}
+		files, err := kr.FetchKeySet(ctx)
+		if err != nil {
+			return changed, withExitCode(ExitRegistry, fmt.Errorf("failed to fetch keys from %s: %w", ref, err))
+		}
+
+		var names []string
+		for _, f := range files {
+			fingerprint, err := signature.PEMFingerprint(f.PEM)
+			if err != nil {
+				return changed, fmt.Errorf("key %q of %s: %w", f.Name, ref, err)
+			}
+			if !slices.Contains(expected, fingerprint) {
+				infof("Key %q (%s) of %s is not expected for %s, skipping it\n", f.Name, fingerprint, ref, r.Name())
+				continue
+			}
+			if name, ok := known[fingerprint]; ok {
+				if !scopes.Allows(name, r.Name()) {
+					scopes.Add(name, r.Name())
+					changed = true
+					infof("Trusting key %q (%s) for %s\n", name, fingerprint, r.Name())
+				} else {
+					debugf("Key %q of %s is already trusted as %q\n", f.Name, ref, name)
+				}
+				continue
+			}
+			name := fetchedKeyName(f.Name, fingerprint)
+			if slices.Contains(names, name) {
+				name = fetchedKeyName("", fingerprint)
+			}
+			names = append(names, name)
+			if err := signature.ImportPublicKeyData(name, f.PEM); err != nil {
+				return changed, err
+			}
+			known[fingerprint] = name
+			scopes.Add(name, r.Name())
+			changed = true
+			infof("Imported key %q (%s) from %s for %s\n", name, fingerprint, ref, r.Name())
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+	return true, trust.SaveScopes(scopes)
+}
+
+// fetchedKeyName returns the name to import a fetched key under. A key whose name is invalid
+// or taken by another key is named after its fingerprint, so no existing key is replaced.
+func fetchedKeyName(name, fingerprint string) string {
+	short := strings.TrimPrefix(fingerprint, "sha256:")[:12]
+	if signature.ValidateKeyName(name) != nil {
+		return "key-" + short
+	}
+	if _, err := signature.ExportPublicKey(name); err == nil {
+		return name + "-" + short
+	}
+	return name
+}
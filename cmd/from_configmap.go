@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type FromConfigMapOpts struct {
+	tag  string
+	file string
+	key  string
+}
+
+var fromConfigMapOpts FromConfigMapOpts
+
+func init() {
+	rootCmd.AddCommand(fromConfigMapCmd)
+
+	flag := fromConfigMapCmd.Flags()
+	flag.StringVarP(&fromConfigMapOpts.file, FileFlag, FileShortFlag, "", "ConfigMap YAML file, or - for stdin")
+	flag.StringVar(&fromConfigMapOpts.key, "key", "", "Data key holding the manifest content (default: the key recorded by to-configmap, or manifest.yaml)")
+
+	_ = fromConfigMapCmd.MarkFlagRequired(FileFlag)
+}
+
+// fromConfigMapCmd represents the from-configmap command
+var fromConfigMapCmd = &cobra.Command{
+	Use:   "from-configmap [<tag>]",
+	Short: "Pack the manifest content of a ConfigMap",
+	Long: `From-configmap packs the manifest content held by a ConfigMap into local storage,
+reversing 'to-configmap'.
+
+Without a tag, the artifact recorded in the kubectl-mft.io/source annotation is used.
+If the ConfigMap records the digest of its content, the content is checked against it
+and modified ConfigMaps are rejected. The packed manifest is not signed; sign it with
+'kubectl mft sign' if needed.
+
+Examples:
+  # Restore a manifest from a ConfigMap file under its original tag
+  kubectl mft from-configmap -f app-manifests.yaml
+
+  # Take the ConfigMap from the cluster and pack it under a new tag
+  kubectl get configmap app-manifests -o yaml | kubectl mft from-configmap -f - myapp:restored`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			fromConfigMapOpts.tag = args[0]
+		}
+		return runFromConfigMap(cmd.Context())
+	},
+}
+
+func runFromConfigMap(ctx context.Context) error {
+	var data []byte
+	var err error
+	if fromConfigMapOpts.file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(fromConfigMapOpts.file)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read ConfigMap: %w", err)
+	}
+	cm, err := manifest.ParseConfigMap(data)
+	if err != nil {
+		return err
+	}
+	content, err := cm.Content(fromConfigMapOpts.key)
+	if err != nil {
+		return err
+	}
+
+	tag := fromConfigMapOpts.tag
+	if tag == "" {
+		tag = cm.Metadata.Annotations[manifest.AnnotationSource]
+	}
+	if tag == "" {
+		return fmt.Errorf("ConfigMap %s does not record its source artifact, specify a tag", cm.Metadata.Name)
+	}
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "kubectl-mft-configmap-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	manifestPath := filepath.Join(dir, "manifest.yaml")
+	if err := os.WriteFile(manifestPath, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	debugf("Packing ConfigMap %s as %s\n", cm.Metadata.Name, r)
+	if err := mft.Save(ctx, r, manifestPath); err != nil {
+		return err
+	}
+	printResult(r.String(), "Packed ConfigMap %s as %s\n", cm.Metadata.Name, r)
+	return nil
+}
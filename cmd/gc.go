@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type GCOpts struct {
+	olderThan time.Duration
+	dryRun    bool
+	output    string
+}
+
+var gcOpts GCOpts
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+
+	flag := gcCmd.Flags()
+	flag.DurationVar(&gcOpts.olderThan, "older-than", 0, "Also remove the tags of local storage last packed, pulled, or copied to longer ago than this duration, e.g. 720h")
+	flag.BoolVar(&gcOpts.dryRun, DryRunFlag, false, "Show the tags that would be removed without removing them")
+	flag.StringVarP(&gcOpts.output, OutputFlag, OutputShortFlag, "table", "Output format (table, json, yaml)")
+}
+
+// gcCmd represents the gc command
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove unpinned cached and old manifests",
+	Long: `Garbage-collect manifests that are not pinned with 'kubectl mft pin'.
+
+Every cached manifest fetched implicitly by apply and dump is removed, whether or not it
+expired. With --older-than, the tags of local storage last packed, pulled, or copied to
+longer ago than the given duration are removed too, after confirmation; tags without such
+an event in the event log fall back to the time of their manifest. Pinned tags are always
+kept, and so are the other tags of their manifest, such as aliases. Read-only system storage
+is never modified.
+
+Examples:
+  # Show what would be removed
+  kubectl mft gc --older-than 720h --dry-run
+
+  # Clear the cache, keeping pinned manifests
+  kubectl mft gc
+
+  # Also remove manifests of local storage older than 30 days
+  kubectl mft gc --older-than 720h --yes`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGC(cmd.Context())
+	},
+}
+
+func runGC(ctx context.Context) error {
+	output := mft.ListOutput(gcOpts.output)
+	switch output {
+	case mft.ListTable, mft.ListJson, mft.ListYaml:
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+	if gcOpts.olderThan < 0 {
+		return fmt.Errorf("--older-than must be positive")
+	}
+
+	if gcOpts.olderThan > 0 && !gcOpts.dryRun {
+		ok, err := confirm(fmt.Sprintf("Remove unpinned manifests of local storage created more than %s ago?", gcOpts.olderThan), false)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Garbage collection cancelled")
+			return nil
+		}
+	}
+
+	res, err := oci.GC(ctx, oci.GCOptions{OlderThan: gcOpts.olderThan, DryRun: gcOpts.dryRun})
+	if err != nil {
+		return err
+	}
+	if quiet && output == mft.ListTable {
+		var ids []string
+		for _, e := range res.Removed() {
+			ids = append(ids, e.Repository+":"+e.Tag)
+		}
+		printIDs(ids)
+		return nil
+	}
+	return res.Print(output)
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/validate"
+)
+
+// outputGitHub is the output format reporting failures as GitHub Actions workflow commands,
+// which show up as annotations on the workflow run and the pull request.
+const outputGitHub = "github"
+
+// annotation is a GitHub Actions error or warning annotation.
+type annotation struct {
+	level string
+	// file and line locate the annotation in the repository, if known
+	file  string
+	line  int
+	title string
+	msg   string
+}
+
+// print prints the annotation as a workflow command on stdout.
+func (a annotation) print() {
+	var props []string
+	if a.file != "" {
+		props = append(props, "file="+escapeProperty(a.file))
+		if a.line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", a.line))
+		}
+	}
+	if a.title != "" {
+		props = append(props, "title="+escapeProperty(a.title))
+	}
+	cmd := "::" + a.level
+	if len(props) > 0 {
+		cmd += " " + strings.Join(props, ",")
+	}
+	fmt.Printf("%s::%s\n", cmd, escapeData(a.msg))
+}
+
+// escapeData escapes the message of a workflow command.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// annotateFailure prints err as error annotations: one per problem for validation errors,
+// located in the manifest file unless the manifest was not read from a file, otherwise one
+// with title.
+func annotateFailure(title string, err error, fromFile bool) {
+	var validationErr *validate.Error
+	if !errors.As(err, &validationErr) {
+		annotation{level: "error", title: title, msg: err.Error()}.print()
+		return
+	}
+	for _, p := range validationErr.Problems {
+		a := annotation{level: "error", title: p.Resource, msg: p.String()}
+		if fromFile {
+			a.file, a.line = p.File, p.Line
+		}
+		if a.title == "" {
+			a.title = title
+		}
+		a.print()
+	}
+}
+
+// annotateWarnings returns a validation option printing validation warnings as warning annotations.
+func annotateWarnings(fromFile bool) validate.Option {
+	return validate.WithWarningHandler(func(p validate.Problem) {
+		a := annotation{level: "warning", title: p.Resource, msg: p.Message}
+		if fromFile {
+			a.file, a.line = p.File, p.Line
+		}
+		a.print()
+	})
+}
+
+// failureTitle returns the annotation title of the failure err of a command, by its kind, or
+// fallback for failures without a more specific exit code.
+func failureTitle(err error, fallback string) string {
+	switch exitCode(err) {
+	case ExitValidation:
+		return "Manifest validation failed"
+	case ExitSignature:
+		return "Signature verification failed"
+	case ExitPolicy:
+		return "Manifest rejected by policy"
+	}
+	return fallback
+}
+
+// checkOutputGitHub returns an error unless output is empty or github.
+func checkOutputGitHub(output string) error {
+	if output != "" && output != outputGitHub {
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+	return nil
+}
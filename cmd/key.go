@@ -15,9 +15,9 @@ func init() {
 var keyCmd = &cobra.Command{
 	Use:   "key",
 	Short: "Manage signing keys",
-	Long: `Manage ECDSA P-256 signing keys for OCI artifact signing and verification.
+	Long: `Manage signing keys for OCI artifact signing and verification.
 
-Keys are stored in ~/.local/share/kubectl-mft/keys/ and used to sign
+Keys are stored in the keys directory shown by 'kubectl mft env' and used to sign
 manifests during pack and verify signatures during pull.
 
 Examples:
@@ -33,6 +33,9 @@ Examples:
   # Export a public key for sharing
   kubectl mft key export --name default
 
+  # Publish public keys to a registry for other teams
+  kubectl mft key publish registry.company.com/team/keys:v3
+
   # Delete a public key
   kubectl mft key delete alice`,
 }
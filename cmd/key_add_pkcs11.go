@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+)
+
+type KeyAddPKCS11Opts struct {
+	module string
+	slot   string
+	id     string
+	label  string
+	force  bool
+}
+
+var keyAddPKCS11Opts KeyAddPKCS11Opts
+
+func init() {
+	keyCmd.AddCommand(keyAddPKCS11Cmd)
+
+	flag := keyAddPKCS11Cmd.Flags()
+	flag.StringVar(&keyAddPKCS11Opts.module, "module", "", "Path to the PKCS#11 module (e.g. /usr/lib/opensc-pkcs11.so)")
+	flag.StringVar(&keyAddPKCS11Opts.slot, "slot", "", "Slot ID of the token")
+	flag.StringVar(&keyAddPKCS11Opts.id, "id", "", "Hex encoded object ID of the key on the token")
+	flag.StringVar(&keyAddPKCS11Opts.label, "label", "", "Object label of the key on the token")
+	flag.BoolVar(&keyAddPKCS11Opts.force, ForceFlag, false, "Overwrite an existing key with the same name")
+
+	_ = keyAddPKCS11Cmd.MarkFlagRequired("module")
+	_ = keyAddPKCS11Cmd.MarkFlagRequired("slot")
+}
+
+// keyAddPKCS11Cmd represents the key add-pkcs11 command
+var keyAddPKCS11Cmd = &cobra.Command{
+	Use:   "add-pkcs11 <name>",
+	Short: "Add a signing key stored on a hardware token",
+	Long: `Add an ECDSA key stored on a hardware token, such as a YubiKey PIV slot,
+as a named signing key. The private key never leaves the token.
+
+The token is accessed through its PKCS#11 module with OpenSC's pkcs11-tool,
+which must be installed. The public key is read from the token and stored as
+<name>.pub, and 'sign', 'pack', and 'bundle create' sign on the token with --key <name>.
+The user PIN is read from KUBECTL_MFT_PKCS11_PIN if set, and prompted for otherwise.
+
+Examples:
+  # Add the key in PIV slot 9c of a YubiKey
+  kubectl mft key add-pkcs11 release --module /usr/lib/libykcs11.so --slot 0 --id 02
+
+  # Sign with the token key
+  kubectl mft sign myapp:v1.0.0 --key release`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runKeyAddPKCS11(args[0])
+	},
+}
+
+func runKeyAddPKCS11(name string) error {
+	key := signature.PKCS11Key{
+		Module: keyAddPKCS11Opts.module,
+		Slot:   keyAddPKCS11Opts.slot,
+		ID:     keyAddPKCS11Opts.id,
+		Label:  keyAddPKCS11Opts.label,
+	}
+	if err := signature.AddPKCS11Key(name, key, keyAddPKCS11Opts.force); err != nil {
+		return err
+	}
+	printResult(name, "PKCS#11 key %q added successfully\n", name)
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+	"github.com/chez-shanpu/kubectl-mft/internal/trust"
 )
 
 type KeyDeleteOpts struct {
@@ -29,14 +30,14 @@ var keyDeleteCmd = &cobra.Command{
 	Long: `Delete a named key from the key directory.
 
 By default, this command deletes the public key. Use --private to delete
-the private key instead.
+the private key instead. A confirmation prompt is shown unless --yes is given.
 
 Examples:
   # Delete a public key
   kubectl mft key delete alice
 
-  # Delete a private key
-  kubectl mft key delete --private alice`,
+  # Delete a private key without confirmation
+  kubectl mft key delete --private --yes alice`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runKeyDelete(args[0], keyDeleteOpts)
@@ -44,17 +45,40 @@ Examples:
 }
 
 func runKeyDelete(name string, opts KeyDeleteOpts) error {
+	kind := "public"
+	if opts.private {
+		kind = "private"
+	}
+	ok, err := confirm(fmt.Sprintf("Delete %s key %q?", kind, name), false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("Deletion cancelled")
+		return nil
+	}
+
 	if opts.private {
 		if err := signature.DeletePrivateKey(name); err != nil {
 			return err
 		}
-		fmt.Printf("Private key %q deleted successfully\n", name)
+		printResult("", "Private key %q deleted successfully\n", name)
 		return nil
 	}
 
 	if err := signature.DeletePublicKey(name); err != nil {
 		return err
 	}
-	fmt.Printf("Public key %q deleted successfully\n", name)
+	// A key imported later under the same name must not inherit the scopes of this one
+	scopes, err := trust.LoadScopes()
+	if err != nil {
+		return err
+	}
+	if scopes.Remove(name) {
+		if err := trust.SaveScopes(scopes); err != nil {
+			return err
+		}
+	}
+	printResult("", "Public key %q deleted successfully\n", name)
 	return nil
 }
@@ -5,6 +5,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -12,8 +13,10 @@ import (
 )
 
 type KeyGenerateOpts struct {
-	name  string
-	force bool
+	name      string
+	force     bool
+	keychain  bool
+	algorithm string
 }
 
 var keyGenerateOpts KeyGenerateOpts
@@ -24,17 +27,26 @@ func init() {
 	flag := keyGenerateCmd.Flags()
 	flag.StringVar(&keyGenerateOpts.name, "name", "default", "Name for the key pair")
 	flag.BoolVar(&keyGenerateOpts.force, ForceFlag, false, "Overwrite existing key pair")
+	flag.BoolVar(&keyGenerateOpts.keychain, "keychain", false, "Store the private key in the OS keychain instead of a file")
+	flag.StringVar(&keyGenerateOpts.algorithm, "algorithm", signature.AlgorithmECDSAP256, fmt.Sprintf("Key algorithm (%s)", strings.Join(signature.Algorithms, ", ")))
 }
 
 // keyGenerateCmd represents the key generate command
 var keyGenerateCmd = &cobra.Command{
 	Use:   "generate",
-	Short: "Generate an ECDSA P-256 key pair for signing",
-	Long: `Generate an ECDSA P-256 key pair and store it in the key directory.
+	Short: "Generate a key pair for signing",
+	Long: `Generate a key pair and store it in the key directory.
 
 The private key is saved as <name>.key and the public key as <name>.pub.
+Keys are ECDSA P-256 by default; use --algorithm to generate ECDSA P-384, Ed25519, or
+RSA 4096 keys instead, e.g. to meet an organization's key policy. Verifiers need no
+configuration, signatures are verified according to the type of the public key.
 Share the public key with others for signature verification.
 
+With --keychain, the private key is stored in the OS keychain (macOS Keychain,
+Secret Service on Linux, Windows Credential Manager) instead of a plaintext file,
+and is loaded from there transparently when signing.
+
 Examples:
   # Generate with default name
   kubectl mft key generate
@@ -43,7 +55,13 @@ Examples:
   kubectl mft key generate --name mykey
 
   # Overwrite existing key pair
-  kubectl mft key generate --force`,
+  kubectl mft key generate --force
+
+  # Generate an Ed25519 key pair
+  kubectl mft key generate --name release --algorithm ed25519
+
+  # Store the private key in the OS keychain
+  kubectl mft key generate --name release --keychain`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runKeyGenerate()
@@ -51,13 +69,18 @@ Examples:
 }
 
 func runKeyGenerate() error {
-	if err := signature.GenerateKeyPair(keyGenerateOpts.name, keyGenerateOpts.force); err != nil {
+	opts := []signature.GenerateOption{signature.WithAlgorithm(keyGenerateOpts.algorithm)}
+	privPath := signature.PrivateKeyPath(keyGenerateOpts.name)
+	if keyGenerateOpts.keychain {
+		opts = append(opts, signature.WithKeychain())
+		privPath = "stored in OS keychain"
+	}
+	if err := signature.GenerateKeyPair(keyGenerateOpts.name, keyGenerateOpts.force, opts...); err != nil {
 		return err
 	}
 
-	privPath := signature.PrivateKeyPath(keyGenerateOpts.name)
 	pubPath := signature.PublicKeyPath(keyGenerateOpts.name)
-	fmt.Printf("Key pair generated successfully\nPrivate key: %s\nPublic key:  %s\nShare the public key with others for signature verification.\n",
+	printResult(keyGenerateOpts.name, "Key pair generated successfully\nPrivate key: %s\nPublic key:  %s\nShare the public key with others for signature verification.\n",
 		privPath, pubPath)
 	return nil
 }
@@ -4,8 +4,6 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 
 	"github.com/chez-shanpu/kubectl-mft/internal/signature"
@@ -29,6 +27,8 @@ var keyImportCmd = &cobra.Command{
 	Use:   "import <public-key-file>",
 	Short: "Import a public key for signature verification",
 	Long: `Import a PEM-encoded public key file into the key directory.
+ECDSA (P-256, P-384, P-521), Ed25519, and RSA (2048 bits or more) keys are accepted.
+SSH public keys in authorized_keys format (Ed25519 or ECDSA) are converted to PEM on import.
 
 The imported key will be used during signature verification when pulling manifests.
 
@@ -37,7 +37,10 @@ Examples:
   kubectl mft key import alice.pub
 
   # Import with a custom name
-  kubectl mft key import /path/to/key.pub --name alice`,
+  kubectl mft key import /path/to/key.pub --name alice
+
+  # Import an SSH public key
+  kubectl mft key import ~/.ssh/id_ed25519.pub --name alice`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runKeyImport(args[0])
@@ -48,6 +51,6 @@ func runKeyImport(srcPath string) error {
 	if err := signature.ImportPublicKey(srcPath, keyImportOpts.name); err != nil {
 		return err
 	}
-	fmt.Printf("Public key imported successfully\n")
+	printResult("", "Public key imported successfully\n")
 	return nil
 }
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+)
+
+type KeyInspectOpts struct {
+	output string
+}
+
+var keyInspectOpts KeyInspectOpts
+
+func init() {
+	keyCmd.AddCommand(keyInspectCmd)
+
+	flag := keyInspectCmd.Flags()
+	flag.StringVarP(&keyInspectOpts.output, OutputFlag, OutputShortFlag, "table", "Output format (table, json)")
+}
+
+// keyInspectCmd represents the key inspect command
+var keyInspectCmd = &cobra.Command{
+	Use:   "inspect <name>",
+	Short: "Show the details and fingerprint of a key",
+	Long: `Show the algorithm, fingerprint, and private key storage of a named key,
+followed by its PEM-encoded public key.
+
+The fingerprint is the SHA-256 hash of the public key's DER encoded
+SubjectPublicKeyInfo. It identifies the key pair regardless of its name and can be
+shared to confirm key identities out of band.
+
+Output formats:
+  - table: Human-readable details (default)
+  - json:  JSON format
+
+Examples:
+  # Inspect the default key
+  kubectl mft key inspect default
+
+  # Inspect a key as JSON
+  kubectl mft key inspect alice -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runKeyInspect(args[0])
+	},
+}
+
+func runKeyInspect(name string) error {
+	details, err := signature.InspectKey(name)
+	if err != nil {
+		return err
+	}
+
+	switch keyInspectOpts.output {
+	case "table":
+		fmt.Printf("Name:        %s\n", details.Name)
+		fmt.Printf("Algorithm:   %s\n", details.Algorithm)
+		fmt.Printf("Fingerprint: %s\n", details.Fingerprint)
+		fmt.Printf("Private key: %s\n", details.PrivateKey)
+		fmt.Printf("Public key:  %s\n\n", details.PublicKeyPath)
+		fmt.Print(details.PublicKeyPEM)
+		return nil
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(details)
+	default:
+		return fmt.Errorf("unsupported output format: %s", keyInspectOpts.output)
+	}
+}
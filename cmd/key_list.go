@@ -4,8 +4,10 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
@@ -13,8 +15,17 @@ import (
 	"github.com/chez-shanpu/kubectl-mft/internal/signature"
 )
 
+type KeyListOpts struct {
+	output string
+}
+
+var keyListOpts KeyListOpts
+
 func init() {
 	keyCmd.AddCommand(keyListCmd)
+
+	flag := keyListCmd.Flags()
+	flag.StringVarP(&keyListOpts.output, OutputFlag, OutputShortFlag, "table", "Output format (table, json)")
 }
 
 // keyListCmd represents the key list command
@@ -23,8 +34,14 @@ var keyListCmd = &cobra.Command{
 	Short: "List all signing keys",
 	Long: `List all keys stored in the key directory.
 
+Each key is shown with the fingerprint of its key pair, the SHA-256 hash of the
+public key's DER encoded SubjectPublicKeyInfo, which can be used to pin key identities.
+
 Examples:
-  kubectl mft key list`,
+  kubectl mft key list
+
+  # List keys as JSON
+  kubectl mft key list -o json`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runKeyList()
@@ -37,15 +54,38 @@ func runKeyList() error {
 		return err
 	}
 
+	switch keyListOpts.output {
+	case "table":
+	case "json":
+		if keys == nil {
+			keys = []signature.KeyInfo{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(keys)
+	default:
+		return fmt.Errorf("unsupported output format: %s", keyListOpts.output)
+	}
+
+	if quiet {
+		var names []string
+		for _, k := range keys {
+			if !slices.Contains(names, k.Name) {
+				names = append(names, k.Name)
+			}
+		}
+		printIDs(names)
+		return nil
+	}
 	if len(keys) == 0 {
 		fmt.Println("No keys found")
 		return nil
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "NAME\tTYPE\tPATH")
+	fmt.Fprintln(w, "NAME\tTYPE\tFINGERPRINT\tPATH")
 	for _, k := range keys {
-		fmt.Fprintf(w, "%s\t%s\t%s\n", k.Name, k.Type, k.Path)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", k.Name, k.Type, k.Fingerprint, k.Path)
 	}
 	return w.Flush()
 }
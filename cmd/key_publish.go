@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+)
+
+type KeyPublishOpts struct {
+	ref   string
+	names []string
+}
+
+var keyPublishOpts KeyPublishOpts
+
+func init() {
+	keyCmd.AddCommand(keyPublishCmd)
+
+	flag := keyPublishCmd.Flags()
+	flag.StringSliceVar(&keyPublishOpts.names, "key", nil, "Public key to publish, can be repeated (default: all public keys)")
+}
+
+// keyPublishCmd represents the key publish command
+var keyPublishCmd = &cobra.Command{
+	Use:   "publish <reference>",
+	Short: "Publish public keys as a key distribution artifact",
+	Long: `Publish public keys to an OCI registry as a key distribution artifact, so that other
+teams can fetch them when verifying your manifests.
+
+Set the reference as signing.keysRef in config.yaml to name it in every new signature:
+
+  signing:
+    keysRef: registry.company.com/team/keys:v3
+
+Verifiers then fetch the keys with 'kubectl mft pull --fetch-keys', naming the fingerprints
+confirmed with you, instead of exchanging key files out of band.
+
+Examples:
+  # Publish all public keys
+  kubectl mft key publish registry.company.com/team/keys:v3
+
+  # Publish only the release key
+  kubectl mft key publish registry.company.com/team/keys:v3 --key release`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keyPublishOpts.ref = args[0]
+		return runKeyPublish(cmd.Context())
+	},
+}
+
+func runKeyPublish(ctx context.Context) error {
+	names := keyPublishOpts.names
+	if len(names) == 0 {
+		keys, err := signature.ListKeys()
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if k.Type == "public" {
+				names = append(names, k.Name)
+			}
+		}
+	}
+
+	var files []oci.PublicKeyFile
+	for _, name := range names {
+		data, err := signature.ExportPublicKey(name)
+		if err != nil {
+			return err
+		}
+		files = append(files, oci.PublicKeyFile{Name: name, PEM: data})
+	}
+
+	r, err := oci.NewRepository(keyPublishOpts.ref)
+	if err != nil {
+		return err
+	}
+	d, err := r.PushKeySet(ctx, files)
+	if err != nil {
+		return withExitCode(ExitRegistry, err)
+	}
+	printResult(d, "Published %d public key(s) to %s (digest: %s)\n", len(files), keyPublishOpts.ref, d)
+	return nil
+}
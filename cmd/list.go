@@ -5,6 +5,8 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -14,6 +16,9 @@ import (
 
 type ListOpts struct {
 	output string
+	events bool
+	since  string
+	cached bool
 }
 
 var listOpts ListOpts
@@ -23,6 +28,9 @@ func init() {
 
 	flag := listCmd.Flags()
 	flag.StringVarP(&listOpts.output, OutputFlag, OutputShortFlag, "table", "Output format (table, json, yaml)")
+	flag.BoolVar(&listOpts.events, "events", false, "List the events of the event log instead of the stored manifests")
+	flag.BoolVar(&listOpts.cached, "cached", false, "Also list the manifests fetched implicitly into the cache")
+	flag.StringVar(&listOpts.since, "since", "", "With --events, only list events after an RFC 3339 timestamp or a duration ago, e.g. 24h")
 }
 
 // listCmd represents the list command
@@ -33,6 +41,19 @@ var listCmd = &cobra.Command{
 
 This command scans the local OCI layout directory and shows information about all
 stored manifests including their repository names, tags, sizes, and creation times.
+Repositories are read in parallel, and repositories whose index has not changed since the
+last run are served from a cache in the cache directory.
+
+Manifests fetched implicitly by apply and dump are kept in a separate cache and are only
+listed with --cached. When a listed manifest is pinned or cached, a STATUS column shows
+which: pinned manifests are never removed by gc, while cached ones are removed by gc and
+expire after the cache TTL.
+
+With --events, the event log of local storage is listed instead: every pack, pull, push,
+copy, alias, delete, and signature of a tag is recorded with its time and manifest digest,
+and the log is kept when the tag or repository is deleted. With --since, only events after
+the given time are listed, so that a synchronization script can store the time of its last
+run and only process what changed since.
 
 Output formats:
   - table: Human-readable table format (default)
@@ -47,19 +68,89 @@ Examples:
   kubectl mft list -o json
 
   # List in YAML format
-  kubectl mft list --output yaml`,
+  kubectl mft list --output yaml
+
+  # Also list the cached manifests
+  kubectl mft list --cached
+
+  # List the events since the last synchronization
+  kubectl mft list --events --since 2026-10-01T09:00:00Z -o json
+
+  # List the events of the last 24 hours
+  kubectl mft list --events --since 24h`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runList(cmd.Context())
 	},
 }
 
 func runList(ctx context.Context) error {
+	if listOpts.events {
+		if listOpts.cached {
+			return fmt.Errorf("--cached cannot be used with --events")
+		}
+		return runListEvents()
+	}
+	if listOpts.since != "" {
+		return fmt.Errorf("--since requires --events")
+	}
+
 	r := oci.NewRegistry()
 	res, err := mft.List(ctx, r)
 	if err != nil {
 		return err
 	}
+	if listOpts.cached {
+		cached, err := oci.ListCache(ctx)
+		if err != nil {
+			return err
+		}
+		res.Add(cached)
+	}
 
 	res.Sort()
+	if quiet && listOpts.output == string(mft.ListTable) {
+		var ids []string
+		for _, i := range res.Items() {
+			ids = append(ids, i.Repository+":"+i.Tag)
+		}
+		printIDs(ids)
+		return nil
+	}
 	return res.Print(mft.ListOutput(listOpts.output))
 }
+
+func runListEvents() error {
+	since, err := parseSince(listOpts.since)
+	if err != nil {
+		return err
+	}
+	res, err := oci.Events(since)
+	if err != nil {
+		return err
+	}
+
+	if quiet && listOpts.output == string(mft.ListTable) {
+		var ids []string
+		for _, e := range res.Items() {
+			ids = append(ids, e.Repository+":"+e.Tag)
+		}
+		printIDs(ids)
+		return nil
+	}
+	return res.Print(mft.ListOutput(listOpts.output))
+}
+
+// parseSince parses an RFC 3339 timestamp, or a duration before now.
+func parseSince(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q, expected an RFC 3339 timestamp or a duration", s)
+	}
+	return time.Now().Add(-d), nil
+}
@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// LockfileFlag is the flag of pull reading a lockfile
+const LockfileFlag = "lockfile"
+
+func init() {
+	rootCmd.AddCommand(lockCmd)
+}
+
+// lockCmd represents the lock command group
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Pin the tags of manifests to digests",
+	Long: `Pin the tags of the manifests an environment depends on to the digests they point to.
+
+A lockfile records the manifest digest of every tag, and 'kubectl mft pull --lockfile'
+pulls exactly those manifests later, even if the tags were pushed again in the meantime,
+so environments are rebuilt reproducibly.
+
+Examples:
+  # Pin the dependencies of deps.yaml
+  kubectl mft lock generate -f deps.yaml -o mft.lock
+
+  # Pull the pinned manifests
+  kubectl mft pull --lockfile mft.lock`,
+}
@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/lock"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type LockGenerateOpts struct {
+	file   string
+	output string
+}
+
+var lockGenerateOpts LockGenerateOpts
+
+func init() {
+	lockCmd.AddCommand(lockGenerateCmd)
+
+	flag := lockGenerateCmd.Flags()
+	flag.StringVarP(&lockGenerateOpts.file, FileFlag, FileShortFlag, "", "Dependency file listing the tags to pin")
+	flag.StringVarP(&lockGenerateOpts.output, OutputFlag, OutputShortFlag, "", "Output file path (default: stdout)")
+
+	_ = lockGenerateCmd.MarkFlagRequired(FileFlag)
+}
+
+// lockGenerateCmd represents the lock generate command
+var lockGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Resolve the tags of a dependency file to digests",
+	Long: `Generate resolves every tag of a dependency file to the digest of the manifest it points
+to in its registry and writes the result as a lockfile. Only the manifests are resolved, no
+content is downloaded.
+
+The dependency file lists the tags under 'artifacts'. Tags may be semver ranges, such as
+'app:~1.2', which resolve to the highest matching tag in the registry. The resolved tag is
+recorded along with the range. A lockfile is itself a valid dependency file, so it can be
+regenerated to update every pin to the current tags, resolving the ranges again:
+
+  artifacts:
+    - tag: ghcr.io/myorg/app:~1.2
+    - tag: ghcr.io/myorg/database:v3.0.0
+
+Examples:
+  # Pin the dependencies of deps.yaml
+  kubectl mft lock generate -f deps.yaml -o mft.lock
+
+  # Update the pins of a lockfile
+  kubectl mft lock generate -f mft.lock -o mft.lock`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLockGenerate(cmd.Context())
+	},
+}
+
+func runLockGenerate(ctx context.Context) error {
+	deps, err := lock.Load(lockGenerateOpts.file)
+	if err != nil {
+		return err
+	}
+
+	locked := &lock.File{}
+	for _, a := range deps.Artifacts {
+		ref := a.Reference()
+		tag, err := resolveTag(ctx, ref, true)
+		if err != nil {
+			return err
+		}
+		r, err := oci.NewRepository(tag)
+		if err != nil {
+			return err
+		}
+		debugf("Resolving %s\n", tag)
+		digest, err := r.RemoteDigest(ctx)
+		if err != nil {
+			return withExitCode(ExitRegistry, err)
+		}
+		if a.Digest != "" && (a.Tag != tag || a.Digest != digest) {
+			infof("Updated %s from %s@%s to %s@%s\n", ref, a.Tag, a.Digest, tag, digest)
+		}
+		pinned := lock.Artifact{Tag: tag, Digest: digest}
+		if tag != ref {
+			pinned.Range = ref
+		}
+		locked.Artifacts = append(locked.Artifacts, pinned)
+	}
+	data, err := locked.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if lockGenerateOpts.output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(lockGenerateOpts.output, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	printResult(lockGenerateOpts.output, "Pinned %d artifact(s) in %s\n", len(locked.Artifacts), lockGenerateOpts.output)
+	return nil
+}
@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/source"
+)
+
+type MergeOpts struct {
+	tags           []string
+	newTag         string
+	onDuplicate    string
+	skipValidation bool
+	skipSign       bool
+	keys           []string
+	timestampURL   string
+	dryRun         bool
+}
+
+var mergeOpts MergeOpts
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+
+	flag := mergeCmd.Flags()
+	flag.StringVarP(&mergeOpts.newTag, TagFlag, TagShortFlag, "", "Tag to pack the merged manifest as")
+	flag.StringVar(&mergeOpts.onDuplicate, OnDuplicateFlag, duplicateFail, onDuplicateUsage)
+	flag.BoolVar(&mergeOpts.skipValidation, "skip-validation", false, "Skip manifest validation of the merged manifest")
+	flag.BoolVar(&offlineValidation, OfflineFlag, false, offlineUsage)
+	flag.BoolVar(&mergeOpts.skipSign, "skip-sign", false, "Skip signing the packed manifest")
+	flag.StringArrayVar(&mergeOpts.keys, "key", nil, signingKeysUsage)
+	flag.StringVar(&mergeOpts.timestampURL, TimestampURLFlag, "", timestampURLUsage)
+	flag.BoolVar(&mergeOpts.dryRun, DryRunFlag, false, "Validate and show the tag and blobs that would be added without changing local storage")
+	_ = mergeCmd.MarkFlagRequired(TagFlag)
+}
+
+// mergeCmd represents the merge command
+var mergeCmd = &cobra.Command{
+	Use:   "merge <tag> <tag>...",
+	Short: "Combine the contents of several manifests into a new manifest",
+	Long: `Merge concatenates the resources of several locally stored manifests, in the order given,
+and packs the result under the tag given with -t, for example to assemble a platform
+release from the manifests of its components. Bundles contribute the resources of all
+their members.
+
+Resources are identified by API group, kind, namespace, and name. A resource defined by
+more than one manifest is only kept once if the definitions hold the same data, ignoring
+formatting and comments. If the definitions differ, nothing is packed and the conflicting
+resources are reported with the manifests defining them, unless --on-duplicate is warn,
+which keeps the first definition with a warning, or keep-last, which keeps the last one.
+
+The merged manifest is validated and signed like with 'pack', and records the merged
+artifacts, as comma-separated <reference>@<digest>, in the io.kubectl-mft.source.merged-from
+annotation. An existing tag is never replaced.
+
+Examples:
+  # Assemble a platform release from component manifests
+  kubectl mft merge ingress:v1.4.0 cert-manager:v1.14.2 monitoring:v3 -t platform:2024.06`,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeTags(-1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mergeOpts.tags = args
+		return runMerge(cmd.Context())
+	},
+}
+
+func runMerge(ctx context.Context) error {
+	newTag := mergeOpts.newTag
+	if err := requireNewTag(ctx, newTag); err != nil {
+		return err
+	}
+
+	sources := make([]manifest.Source, 0, len(mergeOpts.tags))
+	mergedFrom := make([]string, 0, len(mergeOpts.tags))
+	for _, arg := range mergeOpts.tags {
+		tag, err := resolveTag(ctx, arg, false)
+		if err != nil {
+			return err
+		}
+		if tag, err = resolveAlias(ctx, tag); err != nil {
+			return err
+		}
+		r, err := oci.NewRepository(tag)
+		if err != nil {
+			return err
+		}
+		dgst, err := r.Digest(ctx)
+		if err != nil {
+			return err
+		}
+		docs, err := artifactDocuments(ctx, r)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, manifest.Source{Name: tag, Docs: docs})
+		mergedFrom = append(mergedFrom, tag+"@"+dgst)
+	}
+
+	docs, err := combineSources(sources, mergeOpts.onDuplicate)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "kubectl-mft-merge-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "manifest.yaml")
+	if err := os.WriteFile(path, manifest.Join(docs), 0o600); err != nil {
+		return fmt.Errorf("failed to write merged manifest: %w", err)
+	}
+
+	annotations := map[string]string{source.AnnotationMergedFrom: strings.Join(mergedFrom, ",")}
+	if err := packManifest(ctx, path, newTag, annotations, packSettings{
+		skipValidation: mergeOpts.skipValidation,
+		skipSign:       mergeOpts.skipSign,
+		keys:           mergeOpts.keys,
+		timestampURL:   mergeOpts.timestampURL,
+		dryRun:         mergeOpts.dryRun,
+	}); err != nil {
+		return err
+	}
+	if mergeOpts.dryRun {
+		return nil
+	}
+	dst, err := oci.NewRepository(newTag)
+	if err != nil {
+		return err
+	}
+	printResult(dst.String(), "Merged %d resource(s) of %d manifests as %s\n", len(docs), len(sources), dst)
+	return nil
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type MigrateStorageOpts struct {
+	dryRun bool
+}
+
+var migrateStorageOpts MigrateStorageOpts
+
+func init() {
+	rootCmd.AddCommand(migrateStorageCmd)
+
+	flag := migrateStorageCmd.Flags()
+	flag.BoolVar(&migrateStorageOpts.dryRun, DryRunFlag, false, "Report what would be migrated without modifying storage")
+}
+
+// migrateStorageCmd represents the migrate-storage command
+var migrateStorageCmd = &cobra.Command{
+	Use:   "migrate-storage",
+	Short: "Convert legacy storage directories into per-repository OCI layouts",
+	Long: `Migrate-storage converts legacy directories of local storage into the per-repository OCI
+layouts that every command reads and writes.
+
+Legacy directories are OCI layouts directly in the storage directory, such as the flat
+<registry>-<repository>-<tag> directories of older versions. Their tags are not listed under
+their repository, and pack, pull, and apply do not find them. As a directory name cannot be split
+unambiguously, the repository of each tag is read from the title annotation recorded when it was
+packed.
+
+Each tag is copied with its signatures, and keeps its creation time. A legacy directory is
+removed once all its tags are migrated. Tags whose repository is unknown, or whose tag already
+exists in the repository with other content, are skipped, and their directory is kept; copy them
+to a tag of your choice with 'kubectl mft cp', or delete the existing tag and run migrate-storage
+again. A directory without tags is reported and kept, so that its content is not lost. --dry-run
+also reports legacy directories that would migrate different content to the same tag.
+
+Examples:
+  # Show what would be migrated
+  kubectl mft migrate-storage --dry-run
+
+  # Migrate legacy directories
+  kubectl mft migrate-storage`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMigrateStorage(cmd.Context())
+	},
+}
+
+func runMigrateStorage(ctx context.Context) error {
+	res, err := oci.MigrateStorage(ctx, migrateStorageOpts.dryRun)
+	if err != nil {
+		return err
+	}
+	if !quiet || len(res.Skipped()) > 0 {
+		if err := res.Print(); err != nil {
+			return err
+		}
+	}
+	if skipped := res.Skipped(); len(skipped) > 0 {
+		return fmt.Errorf("%d tag(s) or untagged directories of legacy storage could not be migrated", len(skipped))
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/platform"
+	"github.com/chez-shanpu/kubectl-mft/internal/source"
+	"github.com/chez-shanpu/kubectl-mft/internal/validate"
+)
+
+type OpenOpts struct {
+	tag            string
+	newTag         string
+	inPlace        bool
+	skipValidation bool
+	skipSign       bool
+	keys           []string
+	timestampURL   string
+}
+
+var openOpts OpenOpts
+
+func init() {
+	rootCmd.AddCommand(openCmd)
+
+	flag := openCmd.Flags()
+	flag.StringVarP(&openOpts.newTag, TagFlag, TagShortFlag, "", "Tag to pack the edited manifest as")
+	flag.BoolVar(&openOpts.inPlace, "in-place", false, "Pack the edited manifest as the next patch version of the tag, e.g. v1.2.4 for v1.2.3")
+	flag.BoolVar(&openOpts.skipValidation, "skip-validation", false, "Skip manifest validation of the edited manifest")
+	flag.BoolVar(&offlineValidation, OfflineFlag, false, offlineUsage)
+	flag.BoolVar(&openOpts.skipSign, "skip-sign", false, "Skip signing the packed manifest")
+	flag.StringArrayVar(&openOpts.keys, "key", nil, signingKeysUsage)
+	flag.StringVar(&openOpts.timestampURL, TimestampURLFlag, "", timestampURLUsage)
+	openCmd.MarkFlagsMutuallyExclusive(TagFlag, "in-place")
+	openCmd.MarkFlagsOneRequired(TagFlag, "in-place")
+}
+
+// openCmd represents the open command
+var openCmd = &cobra.Command{
+	Use:   "open <tag>",
+	Short: "Edit the content of a manifest and pack it under a new tag",
+	Long: `Open writes the content of a locally stored manifest to a temporary file, opens it in
+an editor, and packs the saved result under a new tag, for a quick patch-and-republish
+loop on hotfixes. The stored manifest itself is never modified.
+
+The editor is taken from KUBE_EDITOR or EDITOR, as with 'kubectl edit', and defaults to vi,
+or notepad on Windows. The setting is split into arguments like a shell does, so a path with
+spaces can be quoted. If the file is saved unchanged, nothing is packed. The edited manifest is validated like
+with 'pack'; if validation fails, the editor can be reopened to fix it.
+
+The new tag is given with -t, or derived with --in-place as the next patch version of the
+opened tag, such as v1.2.4 for v1.2.3. An existing tag is never replaced. The packed
+manifest is signed like with 'pack' and records the opened artifact, as
+<reference>@<digest>, in the io.kubectl-mft.source.derived-from annotation.
+
+Examples:
+  # Fix a typo and pack the result as the next patch release
+  kubectl mft open registry.example.com/manifests/app:v1.2.3 --in-place
+
+  # Edit with VS Code and pack under an explicit tag
+  EDITOR="code --wait" kubectl mft open myapp:v1 -t myapp:v1-hotfix`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		openOpts.tag = args[0]
+		return runOpen(cmd.Context())
+	},
+}
+
+func runOpen(ctx context.Context) error {
+	tag, err := resolveTag(ctx, openOpts.tag, false)
+	if err != nil {
+		return err
+	}
+	if tag, err = resolveAlias(ctx, tag); err != nil {
+		return err
+	}
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+	newTag, err := derivedTag(ctx, tag, r, openOpts.newTag, openOpts.inPlace)
+	if err != nil {
+		return err
+	}
+	dgst, err := r.Digest(ctx)
+	if err != nil {
+		return err
+	}
+	content, err := mft.Dump(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	original, err := io.ReadAll(content)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "kubectl-mft-open-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "manifest.yaml")
+	if err := os.WriteFile(path, original, 0o600); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	for {
+		if err := runEditor(ctx, path); err != nil {
+			return err
+		}
+		edited, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read edited manifest: %w", err)
+		}
+		if bytes.Equal(edited, original) {
+			printResult("", "Edit cancelled, no changes made\n")
+			return nil
+		}
+		if openOpts.skipValidation {
+			break
+		}
+		err = validateEdited(path)
+		if err == nil {
+			break
+		}
+		if !stdinIsTerminal() {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, err)
+		again, err := confirm("Edit the manifest again?", false)
+		if err != nil {
+			return err
+		}
+		if !again {
+			return fmt.Errorf("the edited manifest is invalid and was not packed")
+		}
+	}
+
+	annotations := map[string]string{source.AnnotationDerivedFrom: tag + "@" + dgst}
+	if err := packManifest(ctx, path, newTag, annotations, packSettings{
+		skipValidation: true,
+		skipSign:       openOpts.skipSign,
+		keys:           openOpts.keys,
+		timestampURL:   openOpts.timestampURL,
+	}); err != nil {
+		return err
+	}
+	dst, err := oci.NewRepository(newTag)
+	if err != nil {
+		return err
+	}
+	printResult(dst.String(), "Packed the edited %s as %s\n", r, dst)
+	return nil
+}
+
+// derivedTag returns the tag to pack a manifest derived from the tag of r as: newTag, or with
+// inPlace the next patch version in the same repository. The tag must not exist yet.
+func derivedTag(ctx context.Context, tag string, r *oci.Repository, newTag string, inPlace bool) (string, error) {
+	if inPlace {
+		next, err := oci.NextPatchTag(r.Tag())
+		if err != nil {
+			return "", fmt.Errorf("cannot derive the next tag with --in-place: %w, give a tag with --%s", err, TagFlag)
+		}
+		name := tag
+		if i := strings.LastIndex(tag, ":"); i > strings.LastIndex(tag, "/") {
+			name = tag[:i]
+		}
+		newTag = name + ":" + next
+	}
+	if err := requireNewTag(ctx, newTag); err != nil {
+		return "", err
+	}
+	return newTag, nil
+}
+
+// requireNewTag returns an error if tag already exists in local storage.
+func requireNewTag(ctx context.Context, tag string) error {
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+	exists, err := r.Exists(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check local manifest: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("%s already exists", tag)
+	}
+	return nil
+}
+
+// runEditor opens path in the editor of KUBE_EDITOR or EDITOR, or the default editor of the
+// platform, and waits for it to exit.
+func runEditor(ctx context.Context, path string) error {
+	editor := os.Getenv("KUBE_EDITOR")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	args, err := platform.SplitCommand(editor)
+	if err != nil {
+		return fmt.Errorf("invalid editor: %w", err)
+	}
+	if len(args) == 0 {
+		args = []string{platform.DefaultEditor()}
+	}
+	cmd := exec.CommandContext(ctx, args[0], append(args[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", args[0], err)
+	}
+	return nil
+}
+
+// validateEdited validates an edited manifest like pack does.
+func validateEdited(path string) error {
+	opts, err := validationOptions(nil)
+	if err != nil {
+		return err
+	}
+	debugf("Validating %s\n", path)
+	if err := validate.ValidateManifest(path, opts...); err != nil {
+		return fmt.Errorf("manifest validation failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// Modes of the --color flag
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// quiet and verbose are set by the --quiet and --verbose flags
+var (
+	quiet   bool
+	verbose bool
+)
+
+// printResult prints the success message of a command. With --quiet, only id, the primary
+// identifier of the result, is printed instead, or nothing if id is empty.
+func printResult(id string, format string, a ...any) {
+	if quiet {
+		if id != "" {
+			fmt.Println(id)
+		}
+		return
+	}
+	fmt.Printf(format, a...)
+}
+
+// printIDs prints one primary identifier per line, as list commands do with --quiet.
+func printIDs(ids []string) {
+	for _, id := range ids {
+		fmt.Println(id)
+	}
+}
+
+// infof prints a progress message to stderr unless --quiet is set.
+func infof(format string, a ...any) {
+	if !quiet {
+		fmt.Fprintf(os.Stderr, format, a...)
+	}
+}
+
+// debugf prints a diagnostic message to stderr if --verbose is set.
+func debugf(format string, a ...any) {
+	if verbose {
+		fmt.Fprintf(os.Stderr, format, a...)
+	}
+}
+
+// useColor reports whether text output is colored in the --color mode.
+func useColor(mode string) (bool, error) {
+	switch mode {
+	case colorAlways:
+		return true, nil
+	case colorNever:
+		return false, nil
+	case colorAuto:
+		return os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(os.Stdout.Fd())), nil
+	default:
+		return false, fmt.Errorf("invalid --%s %q, expected %s, %s, or %s", ColorFlag, mode, colorAuto, colorAlways, colorNever)
+	}
+}
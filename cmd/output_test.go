@@ -0,0 +1,796 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/trust"
+)
+
+// setupCmdTest isolates the storage, keys, configuration, and cache of commands run by the test.
+func setupCmdTest(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("KUBECTL_MFT_CONFIG_DIR", filepath.Join(dir, "config"))
+	t.Setenv("KUBECTL_MFT_CACHE_DIR", filepath.Join(dir, "cache"))
+	t.Setenv("KUBECTL_MFT_KEY_DIR", filepath.Join(dir, "keys"))
+	t.Setenv("KUBECTL_MFT_SYSTEM_STORAGE_DIR", "")
+	t.Setenv("KUBECTL_MFT_PROFILE", "")
+	t.Setenv("NO_COLOR", "1")
+}
+
+// runCmd runs the command line args and returns what it printed to stdout and stderr.
+func runCmd(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+	stdout, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	origStdout, origStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	defer func() {
+		os.Stdout, os.Stderr = origStdout, origStderr
+		resetFlags(rootCmd)
+	}()
+
+	rootCmd.SetArgs(args)
+	runErr := rootCmd.Execute()
+
+	out, err := os.ReadFile(stdout.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	errOut, err := os.ReadFile(stderr.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), string(errOut), runErr
+}
+
+// resetFlags restores the default values of the flags of c and its subcommands, which keep
+// the values of the previous execution otherwise.
+func resetFlags(c *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			def := strings.Trim(f.DefValue, "[]")
+			if def == "" {
+				_ = sv.Replace(nil)
+			} else {
+				_ = sv.Replace(strings.Split(def, ","))
+			}
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	c.Flags().VisitAll(reset)
+	c.PersistentFlags().VisitAll(reset)
+	for _, sub := range c.Commands() {
+		resetFlags(sub)
+	}
+}
+
+func TestQuietAndVerboseOutput(t *testing.T) {
+	setupCmdTest(t)
+	manifest := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(manifest, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{"app:v1", "app:v2"} {
+		if _, stderr, err := runCmd(t, "pack", "-f", manifest, "--skip-validation", "--skip-sign", tag); err != nil {
+			t.Fatalf("pack %s failed: %v\nstderr: %s", tag, err, stderr)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantStdout string
+		// wantStderr is a substring of stderr, or empty if nothing must be printed to stderr
+		wantStderr string
+		wantErr    bool
+	}{
+		{
+			name:       "default",
+			args:       []string{"pin", "app:v1"},
+			wantStdout: "Pinned app:v1\n",
+		},
+		{
+			name:       "quiet",
+			args:       []string{"-q", "unpin", "app:v1"},
+			wantStdout: "app:v1\n",
+		},
+		{
+			name:       "verbose",
+			args:       []string{"-v", "pin", "app:v2"},
+			wantStdout: "Pinned app:v2\n",
+			wantStderr: `Profile "default": storage `,
+		},
+		{
+			name:       "quiet list",
+			args:       []string{"list", "-q"},
+			wantStdout: "app:v1\napp:v2\n",
+		},
+		{
+			name:       "quiet and verbose",
+			args:       []string{"-q", "-v", "pin", "app:v1"},
+			wantErr:    true,
+			wantStderr: "none of the others can be",
+		},
+		{
+			name:       "canonical alias",
+			args:       []string{"alias", "set", "local/app:stable", "local/app:v1"},
+			wantStdout: "Alias app:stable now points at app:v1\n",
+		},
+		{
+			name:       "canonical delete",
+			args:       []string{"delete", "--force", "local/app:v2"},
+			wantStdout: "Deleted app:v2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, err := runCmd(t, tt.args...)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("%v succeeded, want an error", tt.args)
+				}
+			} else if err != nil {
+				t.Fatalf("%v failed: %v\nstderr: %s", tt.args, err, stderr)
+			}
+			if stdout != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+			if tt.wantStderr == "" && stderr != "" {
+				t.Errorf("stderr = %q, want nothing", stderr)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestGitHubOutput(t *testing.T) {
+	setupCmdTest(t)
+
+	stdout, _, err := runCmd(t, "pull", "-o", "github", "Invalid Reference")
+	if err == nil {
+		t.Fatal("pull of an invalid reference succeeded, want an error")
+	}
+	if !strings.HasPrefix(stdout, "::error title=Pull failed::") {
+		t.Errorf("stdout = %q, want an error annotation", stdout)
+	}
+
+	if _, _, err := runCmd(t, "apply", "-o", "yaml", "app:v1"); err == nil || !strings.Contains(err.Error(), "unsupported output format") {
+		t.Errorf("apply -o yaml error = %v, want an unsupported output format", err)
+	}
+}
+
+func TestPackReservedAnnotations(t *testing.T) {
+	setupCmdTest(t)
+	manifest := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(manifest, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"io.kubectl-mft.source.url", "io.kubectl-mft.snapshot.context"} {
+		_, _, err := runCmd(t, "pack", "-f", manifest, "--skip-validation", "--skip-sign", "--annotation", key+"=forged", "app:v1")
+		if err == nil || !strings.Contains(err.Error(), "is reserved") {
+			t.Errorf("pack --annotation %s error = %v, want it refused as reserved", key, err)
+		}
+	}
+}
+
+func TestApplyLocalRequireSignatures(t *testing.T) {
+	setupCmdTest(t)
+	manifest := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(manifest, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, err := runCmd(t, "key", "generate"); err != nil {
+		t.Fatalf("key generate failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, stderr, err := runCmd(t, "pack", "-f", manifest, "--skip-validation", "--skip-sign", "app:v1"); err != nil {
+		t.Fatalf("pack failed: %v\nstderr: %s", err, stderr)
+	}
+
+	// The unsigned local copy is verified although verify-local is not enabled
+	_, _, err := runCmd(t, "apply", "--require-signatures", "1", "app:v1")
+	if err == nil {
+		t.Fatal("apply of an unsigned local tag with --require-signatures succeeded")
+	}
+	if code := exitCode(err); code != ExitSignature {
+		t.Errorf("exit code = %d, want %d: %v", code, ExitSignature, err)
+	}
+
+	// The flag also wins over 'verify: never'
+	configDir := os.Getenv("KUBECTL_MFT_CONFIG_DIR")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("registries:\n  - registry: local\n    verify: never\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = runCmd(t, "apply", "--require-signatures", "1", "app:v1")
+	if code := exitCode(err); code != ExitSignature {
+		t.Errorf("exit code with 'verify: never' = %d, want %d: %v", code, ExitSignature, err)
+	}
+}
+
+func TestPullLayoutVerifiesBeforeStoring(t *testing.T) {
+	setupCmdTest(t)
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "app.yaml")
+	writeManifest := func(name string) {
+		t.Helper()
+		if err := os.WriteFile(manifest, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: "+name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, stderr, err := runCmd(t, "key", "generate"); err != nil {
+		t.Fatalf("key generate failed: %v\nstderr: %s", err, stderr)
+	}
+	// An unsigned manifest in a layout claims the repository of a local tag
+	writeManifest("forged")
+	if _, stderr, err := runCmd(t, "pack", "-f", manifest, "--skip-validation", "--skip-sign", "app:v1"); err != nil {
+		t.Fatalf("pack failed: %v\nstderr: %s", err, stderr)
+	}
+	layout := "oci-layout://" + filepath.Join(dir, "layout") + ":v1"
+	if _, stderr, err := runCmd(t, "push", "app:v1", layout); err != nil {
+		t.Fatalf("push to a layout failed: %v\nstderr: %s", err, stderr)
+	}
+	writeManifest("original")
+	if _, stderr, err := runCmd(t, "pack", "-f", manifest, "--skip-validation", "app:v1"); err != nil {
+		t.Fatalf("pack failed: %v\nstderr: %s", err, stderr)
+	}
+
+	// 'verify: never' for the claimed registry does not skip verification
+	configDir := os.Getenv("KUBECTL_MFT_CONFIG_DIR")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("registries:\n  - registry: local\n    verify: never\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err := runCmd(t, "pull", layout)
+	if code := exitCode(err); code != ExitSignature {
+		t.Errorf("exit code of pulling an unsigned layout = %d, want %d: %v", code, ExitSignature, err)
+	}
+	stdout, stderr, err := runCmd(t, "dump", "app:v1")
+	if err != nil {
+		t.Fatalf("dump failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "name: original") {
+		t.Errorf("local app:v1 = %q, want the original manifest kept", stdout)
+	}
+}
+
+func TestPushGitVerifies(t *testing.T) {
+	setupCmdTest(t)
+	manifest := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(manifest, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, err := runCmd(t, "key", "generate"); err != nil {
+		t.Fatalf("key generate failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, stderr, err := runCmd(t, "pack", "-f", manifest, "--skip-validation", "--skip-sign", "app:v1"); err != nil {
+		t.Fatalf("pack failed: %v\nstderr: %s", err, stderr)
+	}
+
+	// The unsigned tag is refused before the repository is cloned
+	_, _, err := runCmd(t, "push", "app:v1", "git+file://"+filepath.ToSlash(filepath.Join(t.TempDir(), "missing")))
+	if code := exitCode(err); code != ExitSignature {
+		t.Errorf("exit code of pushing an unsigned tag to Git = %d, want %d: %v", code, ExitSignature, err)
+	}
+}
+
+func TestDumpStdoutVerifies(t *testing.T) {
+	setupCmdTest(t)
+	content := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n")
+	manifest := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(manifest, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, err := runCmd(t, "pack", "-f", manifest, "--skip-validation", "--skip-sign", "app:v1"); err != nil {
+		t.Fatalf("pack failed: %v\nstderr: %s", err, stderr)
+	}
+
+	// Replace the layer with content of the same size
+	tampered := []byte(strings.Replace(string(content), "name: app", "name: evl", 1))
+	found := false
+	err := filepath.WalkDir(os.Getenv("XDG_DATA_HOME"), func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if b, err := os.ReadFile(path); err == nil && string(b) == string(content) {
+			found = true
+			return os.WriteFile(path, tampered, 0o644)
+		}
+		return nil
+	})
+	if err != nil || !found {
+		t.Fatalf("failed to tamper with the layer: found %v, %v", found, err)
+	}
+
+	stdout, _, err := runCmd(t, "dump", "app:v1")
+	if err == nil {
+		t.Fatal("dump of a tampered layer succeeded")
+	}
+	if stdout != "" {
+		t.Errorf("stdout = %q, want nothing written before verification", stdout)
+	}
+}
+
+// setupCachedTag serves an unsigned local app:v1 as a registry and fetches it into the cache
+// with verification disabled, returning the reference of the cached tag.
+func setupCachedTag(t *testing.T) string {
+	t.Helper()
+	manifest := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(manifest, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, err := runCmd(t, "pack", "-f", manifest, "--skip-validation", "--skip-sign", "app:v1"); err != nil {
+		t.Fatalf("pack failed: %v\nstderr: %s", err, stderr)
+	}
+	srv := httptest.NewServer(oci.RegistryHandler())
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	writeCmdConfig(t, "registries:\n  - registry: "+host+"\n    verify: never\n")
+	tag := host + "/app:v1"
+	if _, stderr, err := runCmd(t, "dump", tag); err != nil {
+		t.Fatalf("dump failed: %v\nstderr: %s", err, stderr)
+	}
+	writeCmdConfig(t, "")
+	if _, stderr, err := runCmd(t, "key", "generate"); err != nil {
+		t.Fatalf("key generate failed: %v\nstderr: %s", err, stderr)
+	}
+	return tag
+}
+
+// writeCmdConfig writes the config.yaml of commands run by the test.
+func writeCmdConfig(t *testing.T, content string) {
+	t.Helper()
+	configDir := os.Getenv("KUBECTL_MFT_CONFIG_DIR")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// listTags returns the tags listed by list, with those of the cache if cached.
+func listTags(t *testing.T, cached bool) []string {
+	t.Helper()
+	args := []string{"list", "-q"}
+	if cached {
+		args = append(args, "--cached")
+	}
+	stdout, stderr, err := runCmd(t, args...)
+	if err != nil {
+		t.Fatalf("list failed: %v\nstderr: %s", err, stderr)
+	}
+	return strings.Fields(stdout)
+}
+
+func TestPullCachedTagFailingVerification(t *testing.T) {
+	setupCmdTest(t)
+	tag := setupCachedTag(t)
+
+	_, _, err := runCmd(t, "pull", tag)
+	if code := exitCode(err); code != ExitSignature {
+		t.Fatalf("exit code of pulling an unsigned tag = %d, want %d: %v", code, ExitSignature, err)
+	}
+	if got := listTags(t, false); slices.Contains(got, tag) {
+		t.Errorf("list = %v, want the unverified %s removed from storage", got, tag)
+	}
+}
+
+func TestExpiredCacheFailingVerification(t *testing.T) {
+	setupCmdTest(t)
+	tag := setupCachedTag(t)
+
+	writeCmdConfig(t, "cache:\n  ttl: 1ns\n")
+	_, _, err := runCmd(t, "dump", tag)
+	if code := exitCode(err); code != ExitSignature {
+		t.Fatalf("exit code of fetching an unsigned tag again = %d, want %d: %v", code, ExitSignature, err)
+	}
+	if got := listTags(t, true); slices.Contains(got, tag) {
+		t.Errorf("list --cached = %v, want the unverified %s removed from the cache", got, tag)
+	}
+}
+
+func TestPullIfNotPresentCachedTag(t *testing.T) {
+	setupCmdTest(t)
+	tag := setupCachedTag(t)
+
+	stdout, stderr, err := runCmd(t, "pull", "--if-not-present", "--skip-verify", tag)
+	if err != nil {
+		t.Fatalf("pull failed: %v\nstderr: %s", err, stderr)
+	}
+	if strings.Contains(stdout, "up to date") {
+		t.Errorf("stdout = %q, want the cached tag pulled", stdout)
+	}
+	if got := listTags(t, false); !slices.Contains(got, tag) {
+		t.Errorf("list = %v, want %s in storage", got, tag)
+	}
+}
+
+func TestPullIfNotPresentKeepsVerifiedCopy(t *testing.T) {
+	setupCmdTest(t)
+	manifest := filepath.Join(t.TempDir(), "app.yaml")
+	writeManifest := func(name string) {
+		t.Helper()
+		if err := os.WriteFile(manifest, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: "+name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, stderr, err := runCmd(t, "key", "generate"); err != nil {
+		t.Fatalf("key generate failed: %v\nstderr: %s", err, stderr)
+	}
+	writeManifest("original")
+	if _, stderr, err := runCmd(t, "pack", "-f", manifest, "--skip-validation", "app:v1"); err != nil {
+		t.Fatalf("pack failed: %v\nstderr: %s", err, stderr)
+	}
+	srv := httptest.NewServer(oci.RegistryHandler())
+	t.Cleanup(srv.Close)
+	tag := strings.TrimPrefix(srv.URL, "http://") + "/app:v1"
+	if _, stderr, err := runCmd(t, "pull", tag); err != nil {
+		t.Fatalf("pull failed: %v\nstderr: %s", err, stderr)
+	}
+
+	// The tag is re-pointed to an unsigned manifest in the registry
+	writeManifest("forged")
+	if _, stderr, err := runCmd(t, "pack", "-f", manifest, "--skip-validation", "--skip-sign", "app:v1"); err != nil {
+		t.Fatalf("pack failed: %v\nstderr: %s", err, stderr)
+	}
+	_, _, err := runCmd(t, "pull", "--if-not-present", tag)
+	if code := exitCode(err); code != ExitSignature {
+		t.Errorf("exit code of pulling an unsigned change = %d, want %d: %v", code, ExitSignature, err)
+	}
+	stdout, stderr, err := runCmd(t, "dump", tag)
+	if err != nil {
+		t.Fatalf("dump failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "name: original") {
+		t.Errorf("local %s = %q, want the verified manifest kept", tag, stdout)
+	}
+}
+
+func TestApplyChecksWhatIsApplied(t *testing.T) {
+	setupCmdTest(t)
+	dir := t.TempDir()
+	for tag, name := range map[string]string{"app:v1": "checked", "app:v2": "swapped"} {
+		manifest := filepath.Join(dir, name+".yaml")
+		if err := os.WriteFile(manifest, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: "+name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, stderr, err := runCmd(t, "pack", "-f", manifest, "--skip-validation", "--skip-sign", tag); err != nil {
+			t.Fatalf("pack %s failed: %v\nstderr: %s", tag, err, stderr)
+		}
+	}
+	var index string
+	err := filepath.WalkDir(os.Getenv("XDG_DATA_HOME"), func(path string, d os.DirEntry, err error) error {
+		if err == nil && d.Name() == "index.json" {
+			index = path
+		}
+		return err
+	})
+	if err != nil || index == "" {
+		t.Fatalf("index.json of app not found: %v", err)
+	}
+
+	// The hook re-points app:v1 to the other manifest once it has accepted the first one, as a
+	// concurrent pack would, and kubectl records what it is given
+	hooked, applied := filepath.Join(dir, "hooked.yaml"), filepath.Join(dir, "applied.yaml")
+	hook := filepath.Join(dir, "hook.sh")
+	script := `#!/bin/sh
+cat > ` + hooked + `
+sed -i 's/ref.name":"v1"/ref.name":"old"/; s/ref.name":"v2"/ref.name":"v1"/' ` + index + "\n"
+	if err := os.WriteFile(hook, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(dir, "bin")
+	if err := os.Mkdir(bin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bin, "kubectl"), []byte("#!/bin/sh\ncat >> "+applied+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if _, stderr, err := runCmd(t, "apply", "--skip-preflight", "--pre-hook", hook, "app:v1"); err != nil {
+		t.Fatalf("apply failed: %v\nstderr: %s", err, stderr)
+	}
+	for _, path := range []string{hooked, applied} {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), "name: checked") || strings.Contains(string(b), "name: swapped") {
+			t.Errorf("%s = %q, want only the manifest the tag pointed to when apply started", filepath.Base(path), b)
+		}
+	}
+}
+
+func TestSignRefusesSystemStorage(t *testing.T) {
+	setupCmdTest(t)
+	systemDir := t.TempDir()
+	manifest := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(manifest, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, err := runCmd(t, "key", "generate"); err != nil {
+		t.Fatalf("key generate failed: %v\nstderr: %s", err, stderr)
+	}
+	t.Setenv("KUBECTL_MFT_STORAGE_DIR", systemDir)
+	if _, stderr, err := runCmd(t, "pack", "-f", manifest, "--skip-validation", "--skip-sign", "app:v1"); err != nil {
+		t.Fatalf("pack failed: %v\nstderr: %s", err, stderr)
+	}
+	index := filepath.Join(systemDir, "local", "app", "index.json")
+	before, err := os.ReadFile(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("KUBECTL_MFT_STORAGE_DIR", t.TempDir())
+	t.Setenv("KUBECTL_MFT_SYSTEM_STORAGE_DIR", systemDir)
+	for _, args := range [][]string{{"sign", "app:v1"}, {"sign", "--output", filepath.Join(t.TempDir(), "app.sig"), "app:v1"}} {
+		if _, _, err := runCmd(t, args...); err == nil || !strings.Contains(err.Error(), "read-only system storage") {
+			t.Errorf("%v error = %v, want the system overlay refused", args, err)
+		}
+	}
+	after, err := os.ReadFile(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("sign modified the system overlay:\nbefore: %s\nafter: %s", before, after)
+	}
+}
+
+func TestListenReplacesOnlySockets(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "data")
+	if err := os.WriteFile(file, []byte("keep"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listen("unix", file); err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Errorf("listen on a regular file error = %v, want it refused", err)
+	}
+	if b, err := os.ReadFile(file); err != nil || string(b) != "keep" {
+		t.Errorf("listen removed or changed the regular file: %q, %v", b, err)
+	}
+
+	// A socket left over by a previous server is replaced
+	socket := filepath.Join(dir, "mft.sock")
+	l, err := listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	if ul, ok := l.(*net.UnixListener); ok {
+		ul.SetUnlinkOnClose(false)
+	}
+	l.Close()
+	l, err = listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen on a stale socket failed: %v", err)
+	}
+	l.Close()
+}
+
+func TestRestrictTrustedKeysHonoursScopes(t *testing.T) {
+	setupCmdTest(t)
+	scopes := &trust.Scopes{}
+	scopes.Add("vendor", "ghcr.io/vendor/app")
+	if err := trust.SaveScopes(scopes); err != nil {
+		t.Fatal(err)
+	}
+	trustedKeys = []string{"vendor", "team"}
+	t.Cleanup(func() { trustedKeys = nil })
+
+	if keys, err := restrictTrustedKeys(&trust.Policy{}, "ghcr.io/vendor/app"); err != nil || !slices.Equal(keys, trustedKeys) {
+		t.Errorf("restrictTrustedKeys() for the scoped repository = %v, %v, want %v", keys, err, trustedKeys)
+	}
+	// A key fetched for one repository is not trusted for another by naming it
+	if _, err := restrictTrustedKeys(&trust.Policy{}, "ghcr.io/other/app"); err == nil || !strings.Contains(err.Error(), `key "vendor"`) {
+		t.Errorf("restrictTrustedKeys() for another repository error = %v, want the scoped key refused", err)
+	}
+}
+
+func TestReadRefsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "refs.txt")
+	content := "# production\nghcr.io/org/app:v1\n\n  ghcr.io/org/db:v2  \nghcr.io/org/app:v1\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	refs, err := readRefsFile(path)
+	if want := []string{"ghcr.io/org/app:v1", "ghcr.io/org/db:v2"}; err != nil || !slices.Equal(refs, want) {
+		t.Errorf("readRefsFile() = %v, %v, want %v", refs, err, want)
+	}
+
+	empty := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(empty, []byte("# nothing yet\n\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readRefsFile(empty); err == nil || !strings.Contains(err.Error(), "no references") {
+		t.Errorf("readRefsFile() of a file without references error = %v", err)
+	}
+	if _, err := readRefsFile(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("readRefsFile() of a missing file succeeded, want an error")
+	}
+}
+
+func TestBulkState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state")
+	s, err := loadBulkState(path)
+	if err != nil || len(s.done) != 0 {
+		t.Fatalf("loadBulkState() of a missing file = %v, %v, want no completed references", s, err)
+	}
+	for _, ref := range []string{"app:v1", "db:v1"} {
+		if err := s.complete(ref); err != nil {
+			t.Fatalf("complete(%s) failed: %v", ref, err)
+		}
+	}
+	s, err = loadBulkState(path)
+	if err != nil {
+		t.Fatalf("loadBulkState() failed: %v", err)
+	}
+	if !s.done["app:v1"] || !s.done["db:v1"] || len(s.done) != 2 {
+		t.Errorf("loadBulkState() done = %v, want app:v1 and db:v1", s.done)
+	}
+
+	// Without a state file, nothing is recorded
+	s, err = loadBulkState("")
+	if err != nil {
+		t.Fatalf("loadBulkState(\"\") failed: %v", err)
+	}
+	if err := s.complete("app:v1"); err != nil {
+		t.Errorf("complete() without a state file failed: %v", err)
+	}
+}
+
+func TestRunBulkRefs(t *testing.T) {
+	setupCmdTest(t)
+	ctx := t.Context()
+	refs := []string{"app:v1", "db:v1", "app:v2", "app:v3", "db:v2"}
+	state := filepath.Join(t.TempDir(), "state")
+	opts := bulkOpts{parallel: 4, stateFile: state}
+
+	// References of one repository run one after another, in order, while the repositories run
+	// concurrently: the first reference of each waits until both have started
+	var mu sync.Mutex
+	var calls []string
+	running := make(map[string]int)
+	started := make(chan struct{}, 2)
+	fn := func(fail string) func(ctx context.Context, ref string) error {
+		return func(ctx context.Context, ref string) error {
+			repo, _, _ := strings.Cut(ref, ":")
+			mu.Lock()
+			calls = append(calls, ref)
+			running[repo]++
+			if running[repo] > 1 {
+				t.Errorf("%s runs concurrently with another reference of %s", ref, repo)
+			}
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				running[repo]--
+				mu.Unlock()
+			}()
+			if ref == "app:v1" || ref == "db:v1" {
+				started <- struct{}{}
+				for len(started) < 2 {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case <-time.After(time.Millisecond):
+					}
+				}
+			}
+			if ref == fail {
+				return errors.New("registry unavailable")
+			}
+			return nil
+		}
+	}
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	err := runBulkRefs(timeout, "pull", opts, "refs.txt", refs, fn("app:v2"))
+	if err == nil || !strings.Contains(err.Error(), "app:v2") {
+		t.Fatalf("runBulkRefs() error = %v, want app:v2 reported as failed", err)
+	}
+	if app := slices.DeleteFunc(slices.Clone(calls), func(ref string) bool { return !strings.HasPrefix(ref, "app:") }); !slices.Equal(app, []string{"app:v1", "app:v2", "app:v3"}) {
+		t.Errorf("references of app ran in order %v, want the order of the file", app)
+	}
+
+	// The state file is kept on failure, listing the completed references
+	s, err := loadBulkState(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.done) != 4 || s.done["app:v2"] {
+		t.Errorf("state after a failure = %v, want every reference but app:v2", s.done)
+	}
+
+	// A rerun only retries the failed reference, and removes the state file once all succeeded
+	calls = nil
+	if err := runBulkRefs(ctx, "pull", opts, "refs.txt", refs, fn("")); err != nil {
+		t.Fatalf("runBulkRefs() rerun failed: %v", err)
+	}
+	if !slices.Equal(calls, []string{"app:v2"}) {
+		t.Errorf("rerun ran %v, want only app:v2", calls)
+	}
+	if _, err := os.Stat(state); !os.IsNotExist(err) {
+		t.Errorf("state file after all succeeded: %v, want it removed", err)
+	}
+}
+
+func TestSignMultipleKeysCommitsTogether(t *testing.T) {
+	setupCmdTest(t)
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "app.yaml")
+	if err := os.WriteFile(manifest, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"dev", "ci"} {
+		if _, stderr, err := runCmd(t, "key", "generate", "--name", name); err != nil {
+			t.Fatalf("key generate %s failed: %v\nstderr: %s", name, err, stderr)
+		}
+	}
+	if _, stderr, err := runCmd(t, "pack", "-f", manifest, "--skip-validation", "--skip-sign", "app:v1"); err != nil {
+		t.Fatalf("pack failed: %v\nstderr: %s", err, stderr)
+	}
+	// A gpg that cannot sign makes the last key fail after the others signed
+	bin := filepath.Join(dir, "bin")
+	if err := os.Mkdir(bin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bin, "gpg"), []byte("#!/bin/sh\necho 'no secret key' >&2\nexit 2\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	_, _, err := runCmd(t, "sign", "--key", "dev", "--key", "ci", "--key", "gpg:MISSING", "app:v1")
+	if err == nil || !strings.Contains(err.Error(), `key "gpg:MISSING"`) {
+		t.Fatalf("sign with a failing key error = %v, want the key reported", err)
+	}
+	if _, _, err := runCmd(t, "verify", "app:v1"); err == nil {
+		t.Error("verify succeeded after a failed sign, want no signature attached")
+	}
+
+	stdout, stderr, err := runCmd(t, "sign", "--key", "dev", "--key", "ci", "app:v1")
+	if err != nil {
+		t.Fatalf("sign failed: %v\nstderr: %s", err, stderr)
+	}
+	if n := strings.Count(stdout, "Signed app:v1"); n != 2 {
+		t.Errorf("sign output = %q, want one signature per key", stdout)
+	}
+	if _, stderr, err := runCmd(t, "verify", "app:v1"); err != nil {
+		t.Errorf("verify failed: %v\nstderr: %s", err, stderr)
+	}
+}
@@ -7,21 +7,49 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/spf13/cobra"
 
+	"github.com/chez-shanpu/kubectl-mft/internal/cluster"
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
 	"github.com/chez-shanpu/kubectl-mft/internal/mft"
 	"github.com/chez-shanpu/kubectl-mft/internal/oci"
 	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+	"github.com/chez-shanpu/kubectl-mft/internal/source"
+	"github.com/chez-shanpu/kubectl-mft/internal/stamp"
 	"github.com/chez-shanpu/kubectl-mft/internal/validate"
+	"github.com/chez-shanpu/kubectl-mft/internal/workspace"
 )
 
 type PackOpts struct {
-	filePath       string
+	filePaths      []string
+	onDuplicate    string
 	tag            string
 	skipValidation bool
+	skipLimits     bool
 	skipSign       bool
-	key            string
+	keys           []string
+	timestampURL   string
+	base           string
+	annotations    []string
+	dependsOn      []string
+	expectedSHA256 string
+	dryRun         bool
+	output         string
+	fromCluster    bool
+	kinds          []string
+	selector       string
+	namespace      string
+	allNamespaces  bool
+	strip          []string
+	excludeKinds   []string
 }
 
 var packOpts PackOpts
@@ -30,17 +58,35 @@ func init() {
 	rootCmd.AddCommand(packCmd)
 
 	flag := packCmd.Flags()
-	flag.StringVarP(&packOpts.filePath, FileFlag, FileShortFlag, "", "Path to the manifest file to pack")
+	flag.StringArrayVarP(&packOpts.filePaths, FileFlag, FileShortFlag, nil, "Path, https:// URL, or oci:// reference of the manifest to pack; directories and repeated files are packed combined")
+	flag.StringVar(&packOpts.onDuplicate, OnDuplicateFlag, duplicateFail, onDuplicateUsage)
 	flag.BoolVar(&packOpts.skipValidation, "skip-validation", false, "Skip manifest validation before packing")
+	flag.BoolVar(&offlineValidation, OfflineFlag, false, offlineUsage)
+	flag.BoolVar(&allowHTTP, AllowHTTPFlag, false, allowHTTPUsage)
+	flag.BoolVar(&packOpts.skipLimits, "skip-limits", false, "Pack even if the manifest exceeds pack.limits of config.yaml")
 	flag.BoolVar(&packOpts.skipSign, "skip-sign", false, "Skip signing the packed manifest")
-	flag.StringVar(&packOpts.key, "key", "default", "Name of the private key to use for signing")
-
-	_ = packCmd.MarkFlagRequired(FileFlag)
+	flag.StringArrayVar(&packOpts.keys, "key", nil, signingKeysUsage)
+	flag.StringVar(&packOpts.timestampURL, TimestampURLFlag, "", timestampURLUsage)
+	flag.StringVar(&packOpts.base, "base", "", "Tag in the same repository to store the manifest as a delta against")
+	flag.StringArrayVar(&packOpts.annotations, "annotation", nil, "Manifest annotation in key=value form, can be repeated")
+	flag.StringArrayVar(&packOpts.dependsOn, DependsOnFlag, nil, dependsOnUsage)
+	flag.StringVar(&packOpts.expectedSHA256, "expected-sha256", "", "SHA-256 digest the manifest content must match, recorded so that verify can recheck it")
+	flag.BoolVar(&packOpts.dryRun, DryRunFlag, false, "Validate and show the tag and blobs that would be added without changing local storage")
+	flag.StringVarP(&packOpts.output, OutputFlag, OutputShortFlag, "", "Output format of validation failures (github)")
+	flag.BoolVar(&packOpts.fromCluster, "from-cluster", false, "Pack a snapshot of live resources of the current cluster instead of a file")
+	flag.StringSliceVar(&packOpts.kinds, "kinds", []string{"all"}, "Resource types to snapshot with --from-cluster")
+	flag.StringVarP(&packOpts.selector, "selector", "l", "", "Label selector of the resources to snapshot with --from-cluster")
+	flag.StringVarP(&packOpts.namespace, "namespace", "n", "", "Namespace of the resources to snapshot with --from-cluster (default: namespace of the current context)")
+	flag.BoolVarP(&packOpts.allNamespaces, "all-namespaces", "A", false, "Snapshot resources of all namespaces with --from-cluster")
+	flag.StringArrayVar(&packOpts.strip, "strip", nil, "Additional field to remove from snapshotted resources, e.g. metadata.labels[pod-template-hash], can be repeated")
+	flag.StringArrayVar(&packOpts.excludeKinds, "exclude-kind", nil, "Additional kind to leave out of snapshots, can be repeated")
+	packCmd.MarkFlagsMutuallyExclusive("from-cluster", FileFlag)
+	packCmd.MarkFlagsMutuallyExclusive("namespace", "all-namespaces")
 }
 
 // packCmd represents the pack command
 var packCmd = &cobra.Command{
-	Use:   "pack <tag>",
+	Use:   "pack [<tag>]",
 	Short: "Save a Kubernetes manifest into an OCI image layout",
 	Long: `Save packages a single Kubernetes manifest file into an OCI (Open Container
 Initiative) image layout format for distribution and versioning.
@@ -54,6 +100,118 @@ The packed manifest is stored in OCI image layout format, allowing it to be:
 - Tagged and versioned like container images
 - Pulled and deployed using standard OCI tools
 
+The manifest is packed and signed in a staging layout and only added to local storage
+once every step has succeeded, so a failed pack leaves no partial artifact behind.
+With --dry-run, the manifest is validated and packed in the staging layout only, and the
+tag and the blobs that would be added to local storage are printed instead.
+
+-f may be repeated, and may name a directory, whose .yaml, .yml, and .json files are read
+in lexical order. The resources of all files are then packed combined as one manifest.
+Resources are identified by API group, kind, namespace, and name; a resource defined
+identically more than once is kept once. A resource defined differently more than once
+fails packing by default, listing the files defining it, so that conflicting definitions
+are not shipped silently. --on-duplicate warn keeps the first definition with a warning,
+and keep-last keeps the last one.
+
+The manifest may be downloaded instead of read from a file: -f accepts an https:// URL,
+or an oci:// reference to an artifact in a registry, which may have been created by
+another tool. The URL and the digest of the downloaded content are recorded in the
+io.kubectl-mft.source.url and io.kubectl-mft.source.digest annotations, so that
+third-party manifests are snapshotted and versioned in your registry. The recorded URL
+omits user information and query parameters, which may hold credentials. --annotation cannot
+set io.kubectl-mft.source.* or io.kubectl-mft.snapshot.* annotations. Plain http:// URLs are refused unless
+--allow-http is given, as their content could be tampered with in transit.
+
+With --expected-sha256, packing fails unless the content, downloaded or read from a file,
+has the given SHA-256 digest. The digest is recorded in the io.kubectl-mft.source.pinned-digest
+annotation, which is covered by the signature, and 'kubectl mft verify' checks the stored
+content against it again.
+
+Schemas of custom resources are also looked up in the remote catalogs listed as
+validation.catalogs in config.yaml, and cached like the schemas of built-in resources.
+With --offline, only cached schemas are used and nothing is downloaded.
+
+With --base, only a patch against an existing tag in the same repository is stored.
+The base content is shared with the new artifact, so registries only store and transfer
+the changed lines. dump, pull, and apply reconstruct the full content transparently.
+
+With --from-cluster, the live resources matching --kinds, -l, and -n are exported from
+the current cluster with 'kubectl get' and packed as an auditable point-in-time snapshot
+of what is actually running. Resources owned by a controller, such as the Pods of a
+Deployment, are left out. The context and the selection are recorded in the
+io.kubectl-mft.snapshot.context and io.kubectl-mft.snapshot.selector annotations.
+'all' selects the kinds of kubectl's 'all' category; add e.g. --kinds all,configmaps
+to include others.
+
+So that snapshots can be applied again and compared, fields maintained by the cluster are
+removed, and kinds created by the cluster are left out. By default, status, the uid,
+resourceVersion, generation, creationTimestamp, managedFields, selfLink, and deletion
+fields of the metadata, the last-applied-configuration annotation, and the tolerations
+added to Pods by the DefaultTolerationSeconds admission plugin ('defaultTolerations') are
+removed, and Events, Endpoints, and EndpointSlices are left out. snapshot.strip and
+snapshot.excludeKinds in config.yaml replace these defaults; --strip and --exclude-kind
+add to them. Fields are dot-separated paths, with keys containing dots in brackets and
+'*' matching every key or list element:
+
+  snapshot:
+    strip: [status, metadata.uid, metadata.resourceVersion, metadata.managedFields,
+            "metadata.annotations[deployment.kubernetes.io/revision]", defaultTolerations]
+    excludeKinds: [Event, Endpoints, EndpointSlice, Lease]
+
+With --depends-on, the manifest declares the artifacts it requires, such as the CRDs or the
+operator its resources need, in the io.kubectl-mft.depends-on annotation, which is covered by
+the signature. A tag is pinned to the digest it points to in local storage, or a dependency
+is given as <repository>:<tag>@<digest>. 'kubectl mft pull --with-dependencies' and 'kubectl
+mft apply --with-dependencies' fetch the dependencies by digest and apply them first.
+
+Every packed manifest is stamped with the annotation templates of pack.annotations in
+config.yaml, so that the registry carries consistent metadata such as the maintainer,
+license, source repository, and pipeline run. Templates are Go templates of .Repository,
+.Tag, and .Git.Commit, .Git.Branch, and .Git.RemoteURL of the checkout containing the
+manifest file, which are empty for a manifest fetched from a URL; env reads an environment
+variable and required fails packing on an empty value. Empty annotations are omitted, and
+--annotation and the workspace override them:
+
+  pack:
+    annotations:
+      org.opencontainers.image.licenses: Apache-2.0
+      org.opencontainers.image.authors: '{{ env "MAINTAINER" | required "MAINTAINER is not set" }}'
+      org.opencontainers.image.source: "{{ .Git.RemoteURL }}"
+      org.opencontainers.image.revision: "{{ .Git.Commit }}"
+      io.example.pipeline.run-id: '{{ env "GITHUB_RUN_ID" }}'
+
+pack.limits in config.yaml refuses manifests too large for registries and clusters, such as
+an accidentally rendered bundle of several hundred megabytes, before anything is stored.
+maxSize limits the manifest, maxDocuments its number of resources, and maxSecretSize the
+YAML of each Secret; sizes are quantities such as 10Mi. --skip-limits packs regardless.
+
+  pack:
+    limits:
+      maxSize: 10Mi
+      maxDocuments: 500
+      maxSecretSize: 512Ki
+
+With -o github, validation failures and warnings are also printed as GitHub Actions
+annotations, located at the offending line of the manifest file. Failures of snapshots,
+downloaded manifests, and combined files are not located, as they have no file in the
+repository.
+
+Without arguments inside a workspace, every manifest declared in the workspace file
+(.mft.yaml in the current directory or a parent) is packed with the workspace's tag
+prefix, signing key, annotations, and validation options. Flags override the workspace.
+
+  tagPrefix: registry.example.com/manifests/
+  signingKey: release
+  annotations:
+    org.opencontainers.image.source: https://github.com/example/app
+  validation:
+    schemaLocations: [https://example.com/schemas/{{ .ResourceKind }}.json]
+  manifests:
+    - path: deploy/app.yaml
+      tag: app:v1.0.0
+    - path: deploy/db.yaml
+      tag: db:v1.0.0
+
 Examples:
   # Save a manifest file with a full OCI reference
   kubectl mft pack -f deployment.yaml registry.example.com/manifests/app:v1.0.0
@@ -62,53 +220,521 @@ Examples:
   kubectl mft pack -f app.yaml localhost/myapp:production-v2.1.0
 
   # Save a manifest with Docker Hub reference
-  kubectl mft pack -f service.yaml docker.io/myorg/manifests:latest`,
-	Args: cobra.ExactArgs(1),
+  kubectl mft pack -f service.yaml docker.io/myorg/manifests:latest
+
+  # Snapshot a third-party install manifest
+  kubectl mft pack -f https://github.com/cert-manager/cert-manager/releases/download/v1.14.0/cert-manager.yaml registry.example.com/vendor/cert-manager:v1.14.0
+
+  # Fail unless the upstream content is unchanged
+  kubectl mft pack -f https://example.com/install.yaml --expected-sha256 2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae vendor/app:v1
+
+  # Pack every manifest file of a directory, the last definition of a resource winning
+  kubectl mft pack -f deploy/ --on-duplicate keep-last registry.example.com/manifests/app:v1.0.0
+
+  # Store v1.1.0 as a delta against v1.0.0
+  kubectl mft pack -f deployment.yaml --base v1.0.0 registry.example.com/manifests/app:v1.1.0
+
+  # Sign with both a developer key and a CI key
+  kubectl mft pack -f app.yaml --key dev --key ci myapp:v1
+
+  # Declare that the application requires the operator released before
+  kubectl mft pack -f app.yaml --depends-on registry.example.com/manifests/operator:v2.0.0 registry.example.com/manifests/app:v1.0.0
+
+  # Add annotations to the manifest
+  kubectl mft pack -f app.yaml --annotation org.opencontainers.image.revision=abc123 myapp:v1
+
+  # Pack every manifest of the workspace
+  kubectl mft pack
+
+  # Show what packing would add to local storage
+  kubectl mft pack -f app.yaml --dry-run myapp:v1
+
+  # Snapshot the live resources of an application
+  kubectl mft pack --from-cluster -l app=web -n prod mysnapshot:2024-06-01`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkOutputGitHub(packOpts.output); err != nil {
+			return err
+		}
+		source.SetAllowHTTP(allowHTTP)
+		if packOpts.fromCluster {
+			if len(args) == 0 {
+				return fmt.Errorf("a tag is required with --from-cluster")
+			}
+			packOpts.tag = args[0]
+			return runPackFromCluster(cmd.Context())
+		}
+		if len(args) == 0 {
+			return runPackWorkspace(cmd.Context())
+		}
+		if len(packOpts.filePaths) == 0 {
+			return fmt.Errorf("required flag(s) \"%s\" not set", FileFlag)
+		}
 		packOpts.tag = args[0]
 		return runPack(cmd.Context())
 	},
 }
 
+// packSettings are the options shared by all manifests packed by one invocation.
+type packSettings struct {
+	skipValidation  bool
+	schemaLocations []string
+	skipLimits      bool
+	skipSign        bool
+	keys            []string
+	timestampURL    string
+	base            string
+	expectedDigest  digest.Digest
+	dryRun          bool
+	// annotate prints validation failures as GitHub Actions annotations
+	annotate bool
+	// temporary is set if the manifest file was written by kubectl-mft, so that annotations
+	// do not point into it
+	temporary bool
+}
+
 func runPack(ctx context.Context) error {
-	if !packOpts.skipValidation {
-		tmpl, err := validate.SchemaLocationTemplate()
+	if err := checkOnDuplicate(packOpts.onDuplicate); err != nil {
+		return err
+	}
+	annotations, err := packAnnotations(ctx)
+	if err != nil {
+		return err
+	}
+	var expected digest.Digest
+	if packOpts.expectedSHA256 != "" {
+		if expected, err = source.ParseSHA256(packOpts.expectedSHA256); err != nil {
+			return err
+		}
+	}
+	settings := packSettings{
+		skipValidation: packOpts.skipValidation,
+		skipLimits:     packOpts.skipLimits,
+		skipSign:       packOpts.skipSign,
+		keys:           packOpts.keys,
+		timestampURL:   packOpts.timestampURL,
+		base:           packOpts.base,
+		expectedDigest: expected,
+		dryRun:         packOpts.dryRun,
+		annotate:       packOpts.output == outputGitHub,
+	}
+	filePath := packOpts.filePaths[0]
+	if len(packOpts.filePaths) > 1 || isDir(filePath) {
+		dir, err := os.MkdirTemp("", "kubectl-mft-combined-")
 		if err != nil {
-			return fmt.Errorf("failed to resolve schema directory: %w", err)
+			return fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		if filePath, err = combineManifestFiles(packOpts.filePaths, packOpts.onDuplicate, dir); err != nil {
+			return err
+		}
+		// validation failures are located in the combined file, not in the packed files
+		settings.temporary = true
+	}
+	return packManifest(ctx, filePath, packOpts.tag, annotations, settings)
+}
+
+// combineManifestFiles writes the combined resources of manifest files and directories to a
+// file in dir and returns its path.
+func combineManifestFiles(paths []string, onDuplicate, dir string) (string, error) {
+	for _, p := range paths {
+		if source.IsRemote(p) {
+			return "", fmt.Errorf("%s: only local files and directories can be packed combined", p)
 		}
-		if err := validate.ValidateManifest(packOpts.filePath,
-			validate.WithSchemaLocations(tmpl),
-		); err != nil {
+	}
+	sources, err := readManifestSources(paths)
+	if err != nil {
+		return "", err
+	}
+	docs, err := combineSources(sources, onDuplicate)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "manifest.yaml")
+	if err := os.WriteFile(path, manifest.Join(docs), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write combined manifest: %w", err)
+	}
+	debugf("Combined %d resource(s) of %d file(s)\n", len(docs), len(sources))
+	return path, nil
+}
+
+// isDir reports whether path is an existing directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// runPackFromCluster packs a snapshot of the live resources selected by the flags.
+func runPackFromCluster(ctx context.Context) error {
+	if packOpts.base != "" || packOpts.expectedSHA256 != "" {
+		return fmt.Errorf("--base and --expected-sha256 are not supported with --from-cluster")
+	}
+	annotations, err := packAnnotations(ctx)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	strip := cfg.Snapshot.Strip
+	if len(strip) == 0 {
+		strip = cluster.DefaultStrip
+	}
+	excludeKinds := cfg.Snapshot.ExcludeKinds
+	if len(excludeKinds) == 0 {
+		excludeKinds = cluster.DefaultExcludeKinds
+	}
+	opts := cluster.ExportOptions{
+		Kinds:         packOpts.kinds,
+		Selector:      packOpts.selector,
+		Namespace:     packOpts.namespace,
+		AllNamespaces: packOpts.allNamespaces,
+		Strip:         append(slices.Clone(strip), packOpts.strip...),
+		ExcludeKinds:  append(slices.Clone(excludeKinds), packOpts.excludeKinds...),
+	}
+	currentContext, err := cluster.CurrentContext(ctx)
+	if err != nil {
+		return err
+	}
+	debugf("Exporting %s from context %s\n", opts.Selection(), currentContext)
+	data, err := cluster.Export(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("no resources found for %s", opts.Selection())
+	}
+
+	dir, err := os.MkdirTemp("", "kubectl-mft-snapshot-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot.yaml")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	// The recorded provenance always wins over other annotations
+	maps.Copy(annotations, map[string]string{
+		cluster.AnnotationSnapshotContext:  currentContext,
+		cluster.AnnotationSnapshotSelector: opts.Selection(),
+	})
+	return packManifest(ctx, path, packOpts.tag, annotations, packSettings{
+		skipValidation: packOpts.skipValidation,
+		skipLimits:     packOpts.skipLimits,
+		skipSign:       packOpts.skipSign,
+		keys:           packOpts.keys,
+		timestampURL:   packOpts.timestampURL,
+		dryRun:         packOpts.dryRun,
+		annotate:       packOpts.output == outputGitHub,
+		temporary:      true,
+	})
+}
+
+// offlineValidation is set by --offline on the commands that validate manifests
+var offlineValidation bool
+
+// allowHTTP is set by --allow-http on the commands that download -f URLs
+var allowHTTP bool
+
+// validationOptions returns the options validating manifests against the registered CRD schemas,
+// the given schema locations, and the schema catalogs configured in config.yaml.
+func validationOptions(locations []string) ([]validate.Option, error) {
+	tmpl, err := validate.SchemaLocationTemplate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve schema directory: %w", err)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	debugf("Schema locations: %s\n", strings.Join(append([]string{tmpl}, locations...), ", "))
+	opts := []validate.Option{
+		validate.WithSchemaLocations(append([]string{tmpl}, locations...)...),
+		validate.WithCatalogs(cfg.Validation.Catalogs...),
+	}
+	if offlineValidation || cfg.Validation.Offline {
+		opts = append(opts, validate.WithOffline())
+	}
+	return opts, nil
+}
+
+func runPackWorkspace(ctx context.Context) error {
+	if len(packOpts.filePaths) > 0 {
+		return fmt.Errorf("a tag is required with --%s", FileFlag)
+	}
+	if packOpts.base != "" {
+		return fmt.Errorf("--base is not supported when packing a workspace")
+	}
+	if packOpts.expectedSHA256 != "" {
+		return fmt.Errorf("--expected-sha256 is not supported when packing a workspace")
+	}
+	path, ok, err := workspace.Find(".")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no tag given and no %s found in the current directory or its parents", workspace.FileName)
+	}
+	ws, err := workspace.Load(path)
+	if err != nil {
+		return err
+	}
+	flagAnnotations, err := packAnnotations(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(packOpts.keys) == 0 && ws.SigningKey != "" {
+		packOpts.keys = []string{ws.SigningKey}
+	}
+	settings := packSettings{
+		skipValidation:  packOpts.skipValidation || ws.Validation.Skip,
+		schemaLocations: ws.Validation.SchemaLocations,
+		skipLimits:      packOpts.skipLimits,
+		skipSign:        packOpts.skipSign,
+		keys:            packOpts.keys,
+		timestampURL:    packOpts.timestampURL,
+		dryRun:          packOpts.dryRun,
+		annotate:        packOpts.output == outputGitHub,
+	}
+	for _, t := range ws.Targets() {
+		maps.Copy(t.Annotations, flagAnnotations)
+		if err := packManifest(ctx, t.Path, t.Tag, t.Annotations, settings); err != nil {
+			return fmt.Errorf("failed to pack %s: %w", t.Tag, err)
+		}
+		if settings.dryRun {
+			continue
+		}
+		r, err := oci.NewRepository(t.Tag)
+		if err != nil {
+			return err
+		}
+		printResult(r.String(), "Packed %s as %s\n", t.Path, r)
+	}
+	return nil
+}
+
+// packManifest validates, saves, and signs a single manifest.
+func packManifest(ctx context.Context, filePath, tag string, annotations map[string]string, o packSettings) error {
+	remote := source.IsRemote(filePath)
+	// Remote manifests are not described by any local Git checkout
+	gitDir := ""
+	if !remote {
+		gitDir = filepath.Dir(filePath)
+	}
+	if remote {
+		src, err := source.Fetch(ctx, filePath)
+		if err != nil {
+			return err
+		}
+		debugf("Downloaded %s with digest %s\n", src.URL, src.Digest())
+		dir, err := os.MkdirTemp("", "kubectl-mft-source-")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		filePath = filepath.Join(dir, "manifest.yaml")
+		if err := os.WriteFile(filePath, src.Data, 0o644); err != nil {
+			return fmt.Errorf("failed to write downloaded manifest: %w", err)
+		}
+
+		// The recorded provenance always wins over other annotations
+		annotations = maps.Clone(annotations)
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		maps.Copy(annotations, src.Annotations())
+	}
+	if o.expectedDigest != "" {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to read manifest file: %w", err)
+		}
+		if err := source.CheckDigest(data, o.expectedDigest); err != nil {
+			return err
+		}
+		debugf("Content matches the expected digest %s\n", o.expectedDigest)
+		annotations = maps.Clone(annotations)
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[source.AnnotationPinnedDigest] = o.expectedDigest.String()
+	}
+
+	if !o.skipLimits {
+		if err := checkLimits(filePath); err != nil {
+			return err
+		}
+	}
+
+	if !o.skipValidation {
+		opts, err := validationOptions(o.schemaLocations)
+		if err != nil {
+			return err
+		}
+		if o.annotate {
+			opts = append(opts, annotateWarnings(!remote && !o.temporary))
+		}
+		debugf("Validating %s\n", filePath)
+		if err := validate.ValidateManifest(filePath, opts...); err != nil {
+			if o.annotate {
+				annotateFailure("Manifest validation failed", err, !remote && !o.temporary)
+			}
 			return fmt.Errorf("manifest validation failed: %w", err)
 		}
 	}
 
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+	if annotations, err = stampAnnotations(ctx, r, gitDir, annotations); err != nil {
+		return err
+	}
+	r.SetAnnotations(annotations)
+
 	// Check signing key before saving to avoid partial state
-	if !packOpts.skipSign {
-		if !signature.PrivateKeyExists(packOpts.key) {
-			return fmt.Errorf("signing key %q not found, run 'kubectl mft key generate' to create a key pair, or use '--skip-sign' to skip signing", packOpts.key)
+	var keys []string
+	var timestampURL string
+	if !o.skipSign {
+		if keys, err = signingKeysFor(o.keys, r); err != nil {
+			return err
+		}
+		if timestampURL, err = timestampURLFor(o.timestampURL); err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if !signature.SigningKeyExists(key) {
+				return fmt.Errorf("signing key %q not found, run 'kubectl mft key generate' to create a key pair, or use '--skip-sign' to skip signing", key)
+			}
 		}
 	}
 
-	r, err := oci.NewRepository(packOpts.tag)
+	// Pack and sign in a staging layout, local storage only changes once all steps succeeded
+	staged, err := r.Stage(ctx, filePath, o.base)
 	if err != nil {
 		return err
 	}
-	if err := mft.Save(ctx, r, packOpts.filePath); err != nil {
-		return err
+	defer staged.Discard()
+	debugf("Packed %s as %s in staging layout %s\n", filePath, tag, staged.LayoutPath())
+
+	if o.dryRun {
+		res, err := staged.Plan(ctx)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			res.AddNote(fmt.Sprintf("sign with key %q", key))
+		}
+		if len(keys) > 0 && timestampURL != "" {
+			res.AddNote(fmt.Sprintf("timestamp signatures with %s", timestampURL))
+		}
+		return res.Print()
 	}
 
-	if !packOpts.skipSign {
-		signer, err := signature.NewSignerFromKeyDir(packOpts.key)
+	for _, key := range keys {
+		debugf("Signing %s with key %q\n", tag, key)
+		signer, err := newSigner(r, key, timestampURL)
 		if err != nil {
-			return deletePackedData(ctx, r, err)
+			return err
 		}
-		if _, err := signer.Sign(ctx, r.LayoutPath(), r.Tag()); err != nil {
-			return deletePackedData(ctx, r, fmt.Errorf("failed to sign manifest: %w", err))
+		defer signer.Close()
+		if _, err := signer.Sign(ctx, staged.LayoutPath(), r.Tag()); err != nil {
+			return fmt.Errorf("failed to sign manifest with key %q: %w", key, err)
 		}
 	}
 
-	return nil
+	debugf("Committing %s to %s\n", tag, r.LayoutPath())
+	return staged.Commit(ctx)
+}
+
+// packAnnotations returns the annotations of --annotation and --depends-on.
+func packAnnotations(ctx context.Context) (map[string]string, error) {
+	annotations, err := parseAnnotations(packOpts.annotations)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := dependencyAnnotations(ctx, packOpts.dependsOn)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(annotations, deps)
+	return annotations, nil
+}
+
+// checkLimits refuses the manifest file if it exceeds pack.limits of config.yaml.
+func checkLimits(filePath string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	limits, err := cfg.Pack.Limits.Parsed()
+	if err != nil {
+		return err
+	}
+	if limits == (manifest.Limits{}) {
+		return nil
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest file: %w", err)
+	}
+	exceeded, err := limits.Check(data)
+	if err != nil {
+		return withExitCode(ExitValidation, err)
+	}
+	if len(exceeded) == 0 {
+		return nil
+	}
+	return withExitCode(ExitValidation, fmt.Errorf("manifest exceeds pack.limits of config.yaml:\n  %s\n"+
+		"Split it into several artifacts linked with --depends-on, keep large data out of the manifest, or pass --skip-limits",
+		strings.Join(exceeded, "\n  ")))
+}
+
+// stampAnnotations returns annotations with the annotation templates of config.yaml rendered
+// for r added, the Git checkout containing dir describing the source. An empty dir leaves the
+// Git fields empty.
+func stampAnnotations(ctx context.Context, r *oci.Repository, dir string, annotations map[string]string) (map[string]string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Pack.Annotations) == 0 {
+		return annotations, nil
+	}
+	data := stamp.Data{Repository: r.Name(), Tag: r.Tag()}
+	if dir != "" {
+		data.Git = stamp.GitInfo(ctx, dir)
+	}
+	stamped, err := stamp.Render(cfg.Pack.Annotations, data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pack.annotations in config: %w", err)
+	}
+	debugf("Stamping %d annotations from config\n", len(stamped))
+	maps.Copy(stamped, annotations)
+	return stamped, nil
+}
+
+// parseAnnotations parses key=value annotation flags.
+func parseAnnotations(values []string) (map[string]string, error) {
+	annotations := make(map[string]string, len(values))
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid annotation %q, expected key=value", v)
+		}
+		if strings.HasPrefix(key, source.ProvenancePrefix) {
+			return nil, fmt.Errorf("annotation %s is reserved, it records where kubectl-mft downloaded the manifest from", key)
+		}
+		if strings.HasPrefix(key, cluster.SnapshotPrefix) {
+			return nil, fmt.Errorf("annotation %s is reserved, it records the cluster a snapshot was taken from", key)
+		}
+		annotations[key] = value
+	}
+	return annotations, nil
 }
 
 func deletePackedData(ctx context.Context, r *oci.Repository, originalErr error) error {
@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/patch"
+	"github.com/chez-shanpu/kubectl-mft/internal/source"
+)
+
+type PatchOpts struct {
+	tag            string
+	patchFile      string
+	patchType      string
+	target         string
+	newTag         string
+	inPlace        bool
+	skipValidation bool
+	skipSign       bool
+	keys           []string
+	timestampURL   string
+	dryRun         bool
+}
+
+var patchOpts PatchOpts
+
+func init() {
+	rootCmd.AddCommand(patchCmd)
+
+	flag := patchCmd.Flags()
+	flag.StringVar(&patchOpts.patchFile, "patch-file", "", "Path of the patch file")
+	flag.StringVar(&patchOpts.patchType, "type", string(patch.Strategic), "Type of the patch (strategic, merge, json)")
+	flag.StringVar(&patchOpts.target, "target", "", "Resource a JSON patch applies to, as <kind>/<name> or <kind>/<namespace>/<name>")
+	flag.StringVarP(&patchOpts.newTag, TagFlag, TagShortFlag, "", "Tag to pack the patched manifest as")
+	flag.BoolVar(&patchOpts.inPlace, "in-place", false, "Pack the patched manifest as the next patch version of the tag, e.g. v1.2.4 for v1.2.3")
+	flag.BoolVar(&patchOpts.skipValidation, "skip-validation", false, "Skip manifest validation of the patched manifest")
+	flag.BoolVar(&offlineValidation, OfflineFlag, false, offlineUsage)
+	flag.BoolVar(&patchOpts.skipSign, "skip-sign", false, "Skip signing the packed manifest")
+	flag.StringArrayVar(&patchOpts.keys, "key", nil, signingKeysUsage)
+	flag.StringVar(&patchOpts.timestampURL, TimestampURLFlag, "", timestampURLUsage)
+	flag.BoolVar(&patchOpts.dryRun, DryRunFlag, false, "Validate and show the tag and blobs that would be added without changing local storage")
+	_ = patchCmd.MarkFlagRequired("patch-file")
+	patchCmd.MarkFlagsMutuallyExclusive(TagFlag, "in-place")
+	patchCmd.MarkFlagsOneRequired(TagFlag, "in-place")
+}
+
+// patchCmd represents the patch command
+var patchCmd = &cobra.Command{
+	Use:   "patch <tag>",
+	Short: "Apply a patch to a manifest and pack the result under a new tag",
+	Long: `Patch applies a strategic merge, JSON merge, or JSON patch to the resources of a locally
+stored manifest and packs the result under a new tag. The stored manifest itself is never
+modified, and resources the patch does not match are kept as they are.
+
+--type selects the type of the patch, like with 'kubectl patch':
+  strategic  A strategic merge patch (default). Lists of well-known fields, such as
+             containers, env, volumes, and ports, are merged by their key, and the
+             "$patch: delete" and "$patch: replace" directives are supported,
+             also as a "- $patch: replace" element replacing the list. Other
+             lists are replaced.
+  merge      A JSON merge patch (RFC 7386). Lists are replaced, null removes a field.
+  json       A JSON patch (RFC 6902), a list of operations.
+
+A strategic merge or JSON merge patch file holds one YAML or JSON document per patched
+resource, which names it by kind, metadata.name, and optionally metadata.namespace. Every
+document must match a resource. A JSON patch is applied to the resources matching
+--target, which may be omitted if the manifest holds a single resource.
+
+The new tag is given with -t, or derived with --in-place as the next patch version of the
+patched tag. An existing tag is never replaced. The packed manifest is validated and signed
+like with 'pack', and records its provenance in annotations: the patched artifact, as
+<reference>@<digest>, in io.kubectl-mft.source.derived-from, and the type and digest of
+the patch file in io.kubectl-mft.source.patch-type and io.kubectl-mft.source.patch-digest.
+
+Examples:
+  # Bump the image of a Deployment and pack the result as the next patch release
+  kubectl mft patch ghcr.io/myorg/manifests:v1.2.3 --patch-file fix.yaml --in-place
+
+  # Apply a JSON patch to a single resource
+  kubectl mft patch myapp:v1 --patch-file ops.json --type json --target Deployment/app -t myapp:v1-hotfix`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		patchOpts.tag = args[0]
+		return runPatch(cmd.Context())
+	},
+}
+
+func runPatch(ctx context.Context) error {
+	typ, err := patch.ParseType(patchOpts.patchType)
+	if err != nil {
+		return err
+	}
+	var target *patch.Target
+	if patchOpts.target != "" {
+		if target, err = patch.ParseTarget(patchOpts.target); err != nil {
+			return err
+		}
+	}
+	data, err := os.ReadFile(patchOpts.patchFile)
+	if err != nil {
+		return fmt.Errorf("failed to read patch file: %w", err)
+	}
+	p, err := patch.Parse(typ, data, target)
+	if err != nil {
+		return err
+	}
+
+	tag, err := resolveTag(ctx, patchOpts.tag, false)
+	if err != nil {
+		return err
+	}
+	if tag, err = resolveAlias(ctx, tag); err != nil {
+		return err
+	}
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+	newTag, err := derivedTag(ctx, tag, r, patchOpts.newTag, patchOpts.inPlace)
+	if err != nil {
+		return err
+	}
+	dgst, err := r.Digest(ctx)
+	if err != nil {
+		return err
+	}
+	docs, err := readDocuments(ctx, r, nil)
+	if err != nil {
+		return err
+	}
+	docs, patched, err := p.Apply(docs)
+	if err != nil {
+		return err
+	}
+	for _, d := range patched {
+		debugf("Patched %s\n", d)
+	}
+
+	dir, err := os.MkdirTemp("", "kubectl-mft-patch-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "manifest.yaml")
+	if err := os.WriteFile(path, manifest.Join(docs), 0o600); err != nil {
+		return fmt.Errorf("failed to write patched manifest: %w", err)
+	}
+
+	annotations := map[string]string{
+		source.AnnotationDerivedFrom: tag + "@" + dgst,
+		source.AnnotationPatchType:   string(typ),
+		source.AnnotationPatchDigest: digest.FromBytes(data).String(),
+	}
+	if err := packManifest(ctx, path, newTag, annotations, packSettings{
+		skipValidation: patchOpts.skipValidation,
+		skipSign:       patchOpts.skipSign,
+		keys:           patchOpts.keys,
+		timestampURL:   patchOpts.timestampURL,
+		dryRun:         patchOpts.dryRun,
+	}); err != nil {
+		return err
+	}
+	if patchOpts.dryRun {
+		return nil
+	}
+	dst, err := oci.NewRepository(newTag)
+	if err != nil {
+		return err
+	}
+	printResult(dst.String(), "Patched %d resource(s) of %s as %s\n", len(patched), r, dst)
+	return nil
+}
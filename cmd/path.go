@@ -13,13 +13,17 @@ import (
 )
 
 type PathOpts struct {
-	tag string
+	tag          string
+	verifyDigest bool
 }
 
 var pathOpts PathOpts
 
 func init() {
 	rootCmd.AddCommand(pathCmd)
+
+	flag := pathCmd.Flags()
+	flag.BoolVar(&pathOpts.verifyDigest, "verify-digest", false, "Print the expected digest along with the path, in sha256sum format")
 }
 
 // pathCmd represents the path command
@@ -31,13 +35,22 @@ var pathCmd = &cobra.Command{
 This command returns the absolute file path to the manifest blob in the OCI layout directory.
 The manifest must have been previously packed using the 'pack' command or pulled using the 'pull' command.
 
+Before the path is printed, the blob is checked against the size and digest recorded in the
+manifest, and the command fails if the blob is missing or has been modified. With --verify-digest,
+the expected digest is printed as well, in the format of sha256sum, so the file can be checked
+again with 'sha256sum -c' right before it is used.
+
 Examples:
   # Get the path to a manifest
   kubectl mft path registry.example.com/manifests/app:v1.0.0
 
   # Use with kubectl debug --custom option
-  kubectl debug my-pod --custom $(kubectl mft path localhost/debug-container:latest)`,
-	Args: cobra.ExactArgs(1),
+  kubectl debug my-pod --custom $(kubectl mft path localhost/debug-container:latest)
+
+  # Check the blob again right before using it
+  kubectl mft path --verify-digest localhost/debug-container:latest | sha256sum -c`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		pathOpts.tag = args[0]
 		return runPath(cmd.Context())
@@ -54,6 +67,10 @@ func runPath(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if pathOpts.verifyDigest {
+		res.PrintChecksum()
+		return nil
+	}
 	res.Print()
 	return nil
 }
@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type PinOpts struct {
+	tag string
+}
+
+var pinOpts PinOpts
+
+func init() {
+	rootCmd.AddCommand(pinCmd)
+}
+
+// pinCmd represents the pin command
+var pinCmd = &cobra.Command{
+	Use:   "pin <tag>",
+	Short: "Protect a manifest from garbage collection",
+	Long: `Pin a tag in local storage or the cache, so that gc never removes its manifest, nor the
+other tags of the manifest, and a cached tag never expires. Pinning is recorded on the tag,
+so packing or pulling the tag again replaces it unpinned. Tags of read-only system storage
+cannot be pinned. Unpin the tag with 'kubectl mft unpin'.
+
+Examples:
+  # Keep a golden version while gc cleans up everything else
+  kubectl mft pin ghcr.io/myorg/app:v1.4.2
+
+  # Show the pinned manifests
+  kubectl mft list --cached`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pinOpts.tag = args[0]
+		return runPin(cmd.Context())
+	},
+}
+
+func runPin(ctx context.Context) error {
+	r, err := oci.NewRepository(pinOpts.tag)
+	if err != nil {
+		return err
+	}
+	if err := r.SetPinned(ctx, true); err != nil {
+		return err
+	}
+	printResult(r.String(), "Pinned %s\n", r)
+	return nil
+}
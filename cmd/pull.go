@@ -8,16 +8,25 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/spf13/cobra"
 
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/lock"
 	"github.com/chez-shanpu/kubectl-mft/internal/mft"
 	"github.com/chez-shanpu/kubectl-mft/internal/oci"
-	"github.com/chez-shanpu/kubectl-mft/internal/signature"
 )
 
 type PullOpts struct {
-	tag        string
-	skipVerify bool
+	tag            string
+	skipVerify     bool
+	ifNotPresent   bool
+	bandwidthLimit string
+	anyArtifact    bool
+	lockfile       string
+	withDeps       bool
+	output         string
+	bulk           bulkOpts
 }
 
 var pullOpts PullOpts
@@ -27,11 +36,26 @@ func init() {
 
 	flag := pullCmd.Flags()
 	flag.BoolVar(&pullOpts.skipVerify, "skip-verify", false, "Skip signature verification after pulling")
+	flag.BoolVar(&noVerifyCache, NoCacheFlag, false, noCacheUsage)
+	flag.BoolVar(&fetchKeys, FetchKeysFlag, false, fetchKeysUsage)
+	flag.StringSliceVar(&keyFingerprints, KeyFingerprintFlag, nil, keyFingerprintUsage)
+	flag.BoolVar(&tofu, TOFUFlag, false, tofuUsage)
+	flag.IntVar(&requireSignatures, RequireSignaturesFlag, 0, requireSignaturesUsage)
+	flag.StringSliceVar(&trustedKeys, TrustedKeysFlag, nil, trustedKeysUsage)
+	flag.StringVar(&maxSignatureAge, MaxSignatureAgeFlag, "", maxSignatureAgeUsage)
+	flag.BoolVar(&pullOpts.ifNotPresent, "if-not-present", false, "Skip pulling when the local copy matches the remote digest")
+	flag.StringVar(&pullOpts.bandwidthLimit, BandwidthLimitFlag, "", bandwidthLimitUsage)
+	flag.BoolVar(&pullOpts.anyArtifact, "any-artifact", false, "Adopt artifacts not created by kubectl-mft, such as those pushed by oras or Flux")
+	flag.StringVar(&pullOpts.lockfile, LockfileFlag, "", "Lockfile generated by 'kubectl mft lock generate' to pull every pinned manifest of, instead of a single tag")
+	flag.BoolVar(&pullOpts.withDeps, WithDependenciesFlag, false, "Also pull the artifacts the manifest depends on, transitively, by their pinned digests")
+	flag.StringVarP(&pullOpts.output, OutputFlag, OutputShortFlag, "", "Output format of failures (github)")
+	addBulkFlags(pullCmd, &pullOpts.bulk, "pull")
+	pullCmd.MarkFlagsMutuallyExclusive(FromFileFlag, LockfileFlag)
 }
 
 // pullCmd represents the pull command
 var pullCmd = &cobra.Command{
-	Use:   "pull <tag>",
+	Use:   "pull <tag> | <layout-or-object-storage-reference> | --from-file <file> | --lockfile <file>",
 	Short: "Pull a manifest from an OCI registry",
 	Long: `Pull downloads a previously pushed Kubernetes manifest from an OCI-compliant registry
 to local storage for further use.
@@ -40,6 +64,87 @@ The manifest must have been previously pushed to the registry using the 'push' c
 Authentication is handled through Docker credential store, so ensure you are logged
 into the source registry using 'docker login' before pulling.
 
+The reference may be a semver range such as 'app:~1.2' or 'app:latest-semver', which
+resolves to the highest matching tag in the registry.
+
+A reference of the form oci-layout://<dir>:<tag> pulls the manifest from an OCI image layout
+directory written by 'kubectl mft push', such as on removable media, without a registry. It
+is stored under the repository it was packed for, as recorded in the manifest, once its
+signature is verified. Since the manifest names the repository itself, 'verify: never' rules
+do not apply to it, only --skip-verify skips verification. Use 'kubectl mft cp oci-layout://<dir>:<tag> <tag>' to store it under another tag.
+References of the form s3://<bucket>[/<prefix>]:<tag>, gs://..., and azblob://... pull
+from object storage the same way, with the credentials described in 'kubectl mft push
+--help'.
+
+The signature of the pulled manifest is verified unless --skip-verify is given. The
+'registries' rules of config.yaml set the verification mode per registry: 'verify: never'
+skips verification without the flag, unless --tofu, --trusted-keys, --require-signatures,
+or --max-signature-age asks for it, and 'verify: required' refuses --skip-verify and the
+adoption of unsigned artifacts with --any-artifact.
+A tag already in local storage is only replaced once the pulled manifest has been
+verified, so a manifest failing verification leaves the local copy in place.
+
+With --if-not-present, the digest of the remote manifest is resolved first and the pull
+is skipped when it matches the local copy. A tag only in the cache, fetched implicitly by
+apply or dump, is pulled into local storage.
+
+Mirrors configured for the registry in config.yaml are tried in order before the
+registry itself. The repository that served the manifest is recorded in local storage.
+
+With --any-artifact, artifacts not created by kubectl-mft, such as those pushed with
+'oras push' or 'flux push artifact', are adopted: the YAML files in their layers, plain
+or in tar archives, are packed as a kubectl-mft artifact under the same tag in local
+storage. Adopted artifacts carry no kubectl-mft signature and are not verified; sign
+them with 'kubectl mft sign' before relying on them. Pushing the tag afterwards
+replaces the original artifact in the registry.
+
+With --fetch-keys, a manifest that no known key verifies is verified again with the keys of
+the key distribution artifact named by its signatures, as published with 'kubectl mft key
+publish'. The artifact is named by a hint outside the signature, so only keys whose
+fingerprints are expected for the repository are imported: those given with
+--key-fingerprint, and those of the first signing.fetchKeys rule of config.yaml matching the
+repository. Imported keys are scoped to the repository, they verify no other repository
+unless fetched for it too. Keys already in the key directory are never replaced.
+
+With --tofu (trust on first use), the keys that signed the first manifest pulled from a
+repository are pinned, like SSH known_hosts does for host keys, and later manifests of the
+repository must be signed by one of them, even if other trusted keys would verify them.
+After a key rotation, run 'kubectl mft trust unpin <repository>' to pin the new key on the
+next pull.
+
+Stricter gates than a signature by any trusted key can be set per pull: --trusted-keys
+verifies with the named keys only, which the trust policy must trust for the repository,
+--require-signatures N requires signatures by N distinct trusted keys, and
+--max-signature-age rejects manifests signed longer ago than the given duration, such as
+90d. The age of a signature is taken from its RFC 3161 timestamp if it has one, and
+otherwise from the signing time in its payload; legacy signatures without either do not
+count towards the requirements.
+
+With --bandwidth-limit, downloads are throttled to the given rate, e.g. to avoid saturating
+a shared uplink. Units are B, KB, MB, and GB per second, in powers of 1024.
+
+With --from-file, every reference listed in the file, one per line, is pulled. Blank lines
+and lines starting with # are ignored. Up to --parallel repositories are pulled concurrently,
+the tags of one repository one after another, and a summary is printed at the end. A failed
+reference does not stop the others. With --resume-from-failure, completed references are
+recorded in the given state file, and a rerun with the same file skips them, so a mirror job
+of many artifacts does not start over after a network failure.
+
+With --lockfile, every tag of a lockfile written by 'kubectl mft lock generate' is pulled the
+same way, but by the digest pinned for it rather than the manifest the tag points to now, so
+a re-pushed tag cannot change what is pulled. The manifest is stored under its tag as usual,
+and --if-not-present compares the local copy with the pinned digest.
+
+With --with-dependencies, the artifacts the manifest declares with 'kubectl mft pack
+--depends-on' are pulled as well, transitively, by the digests pinned in the declaration and
+under their tags, and verified like the manifest. Dependencies already in local storage at
+their pinned digest are not downloaded again, but their blobs are checked against the pin and
+their signatures are verified unless --skip-verify is given. Pulling fails on a dependency
+cycle, or when two artifacts pin the same tag to different digests.
+
+With -o github, a failure, such as a signature verification failure, is also printed as a
+GitHub Actions annotation.
+
 Examples:
   # Pull manifest from Docker Hub
   kubectl mft pull docker.io/myuser/my-app:v1.0.0
@@ -48,46 +153,340 @@ Examples:
   kubectl mft pull registry.company.com/team/app:latest
 
   # Pull from localhost registry
-  kubectl mft pull localhost:5000/test-app:dev`,
-	Args: cobra.ExactArgs(1),
+  kubectl mft pull localhost:5000/test-app:dev
+
+  # Pull the newest release
+  kubectl mft pull registry.company.com/team/app:latest-semver
+
+  # Pull a manifest from a USB drive
+  kubectl mft pull oci-layout:///media/usb/manifests:v1.0.0
+
+  # Pull a manifest from a GCS bucket
+  kubectl mft pull gs://team-manifests/app:v1.0.0
+
+  # Pull without using more than 10MB/s of the uplink
+  kubectl mft pull --bandwidth-limit 10MB/s registry.company.com/team/app:latest
+
+  # Adopt manifests published with 'flux push artifact'
+  kubectl mft pull --any-artifact ghcr.io/myorg/flux-manifests:v1.0.0
+
+  # Pull a manifest of another team, fetching its signing keys on first use
+  kubectl mft pull --fetch-keys --key-fingerprint sha256:3f2a... registry.company.com/other-team/app:v2.0.0
+
+  # Pin the publisher key on first pull and reject manifests signed by other keys later
+  kubectl mft pull --tofu registry.company.com/other-team/app:v2.0.0
+
+  # Require fresh signatures by both release managers
+  kubectl mft pull --trusted-keys alice,bob --require-signatures 2 --max-signature-age 90d registry.company.com/team/app:v1.0.0
+
+  # Pull only when the registry has a different version
+  kubectl mft pull --if-not-present registry.company.com/team/app:latest
+
+  # Pull many references, resuming where a previous run failed
+  kubectl mft pull --from-file tags.txt --if-not-present --resume-from-failure pull.state
+
+  # Pull an application along with the operator and CRDs it depends on
+  kubectl mft pull --with-dependencies registry.company.com/team/app:v1.0.0
+
+  # Pull exactly the manifests pinned by a lockfile
+  kubectl mft pull --lockfile mft.lock --if-not-present
+
+  # Annotate a failed pull on the GitHub Actions workflow run
+  kubectl mft pull -o github registry.company.com/team/app:v1.0.0`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if pullOpts.lockfile != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return bulkArgs(&pullOpts.bulk)(cmd, args)
+	},
+	ValidArgsFunction: completeTags(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		pullOpts.tag = args[0]
-		return runPull(cmd.Context())
+		if err := checkOutputGitHub(pullOpts.output); err != nil {
+			return err
+		}
+		var err error
+		if pullOpts.lockfile != "" {
+			err = runPullLockfile(cmd.Context())
+		} else if pullOpts.bulk.fromFile != "" {
+			err = runBulk(cmd.Context(), "pull", pullOpts.bulk, pullTag)
+		} else {
+			pullOpts.tag = args[0]
+			err = runPull(cmd.Context())
+		}
+		if err != nil && pullOpts.output == outputGitHub {
+			annotateFailure(failureTitle(err, "Pull failed"), err, false)
+		}
+		return err
 	},
 }
 
 func runPull(ctx context.Context) error {
-	r, err := oci.NewRepository(pullOpts.tag)
+	return pullTag(ctx, pullOpts.tag)
+}
+
+// runPullLockfile pulls and verifies the manifest pinned for every tag of the lockfile, like
+// --from-file does for a list of tags.
+func runPullLockfile(ctx context.Context) error {
+	if pullOpts.anyArtifact {
+		return fmt.Errorf("--any-artifact is not supported with --%s", LockfileFlag)
+	}
+	f, err := lock.Load(pullOpts.lockfile)
 	if err != nil {
 		return err
 	}
+	if err := f.Locked(); err != nil {
+		return err
+	}
+	refs := make([]string, 0, len(f.Artifacts))
+	pinned := make(map[string]digest.Digest, len(f.Artifacts))
+	for _, a := range f.Artifacts {
+		refs = append(refs, a.Tag)
+		pinned[a.Tag] = digest.Digest(a.Digest)
+	}
+	return runBulkRefs(ctx, "pull", pullOpts.bulk, pullOpts.lockfile, refs, func(ctx context.Context, tag string) error {
+		return pullRef(ctx, tag, pinned[tag])
+	})
+}
 
-	// Check if manifest already exists locally before pull
-	existedBefore, err := r.Exists(ctx)
+// pullTag pulls and verifies a single tag.
+func pullTag(ctx context.Context, tag string) error {
+	if isExternalReference(tag) {
+		return pullExternal(ctx, tag)
+	}
+	tag, err := resolveTag(ctx, tag, true)
 	if err != nil {
-		return fmt.Errorf("failed to check local manifest: %w", err)
+		return err
 	}
+	return pullRef(ctx, tag, "")
+}
 
-	if err := mft.Pull(ctx, r); err != nil {
+// pullRef pulls and verifies a single tag, which is not resolved further. A pinned digest is
+// pulled instead of the manifest the tag points to in the registry.
+func pullRef(ctx context.Context, tag string, pinned digest.Digest) error {
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+	if pinned != "" {
+		r.SetPinnedDigest(pinned)
+	}
+	if err := setBandwidthLimit(r, pullOpts.bandwidthLimit); err != nil {
+		return err
+	}
+	skipVerify, err := skipVerification(r, pullOpts.skipVerify)
+	if err != nil {
 		return err
 	}
 
-	if !pullOpts.skipVerify {
-		if !signature.PublicKeysExist() {
-			return handleVerifyFailure(ctx, r, existedBefore, fmt.Errorf("no verification keys found, run 'kubectl mft key import <file>' to import a public key, or use '--skip-verify' to skip verification"))
+	// Check if manifest already exists in the writable storage before pull. Copies in the
+	// cache or system storage count as absent: they are not replaced by the pull, and a
+	// manifest failing verification must not be kept in their place.
+	existedBefore := r.InStorage()
+
+	if pullOpts.ifNotPresent && existedBefore {
+		upToDate, err := pulledUpToDate(ctx, r, pinned)
+		if err != nil {
+			return err
+		}
+		if upToDate {
+			printResult("", "%s is up to date\n", r)
+			return pullDependenciesOf(ctx, r)
 		}
-		verifier, err := signature.NewVerifierFromKeyDir()
+	}
+
+	debugf("Pulling %s into %s\n", r, r.LayoutPath())
+	if pullOpts.anyArtifact {
+		adopted, err := r.PullAny(ctx)
 		if err != nil {
-			return handleVerifyFailure(ctx, r, existedBefore, err)
+			return withExitCode(ExitRegistry, err)
 		}
-		if err := verifier.Verify(ctx, r.LayoutPath(), r.Tag()); err != nil {
-			return handleVerifyFailure(ctx, r, existedBefore, fmt.Errorf("signature verification failed: %w", err))
+		if adopted {
+			if verificationRequired(r) {
+				return handleVerifyFailure(ctx, r, existedBefore, withExitCode(ExitSignature,
+					fmt.Errorf("%s was not created by kubectl-mft and carries no signature, but verification is required for %s in config.yaml", tag, r.Registry())))
+			}
+			infof("Adopted %s, which was not created by kubectl-mft, without signature verification\n", r)
+			return nil
+		}
+	} else if existedBefore {
+		// The local copy is only replaced once the pulled manifest has been verified
+		if err := refreshPulled(ctx, r, skipVerify); err != nil {
+			return err
+		}
+		skipVerify = true
+	} else if err := mft.Pull(ctx, r); err != nil {
+		return withExitCode(ExitRegistry, err)
+	}
+	if from, err := r.PulledFrom(); err == nil && from != "" && from != r.Name() {
+		infof("Pulled %s from mirror %s\n", r, from)
+	}
+
+	if !skipVerify {
+		debugf("Verifying signature of %s\n", r)
+		if err := verifyPulled(ctx, r, existedBefore); err != nil {
+			return err
 		}
 	}
+	return pullDependenciesOf(ctx, r)
+}
 
+// pullDependenciesOf pulls the dependencies of r with --with-dependencies.
+func pullDependenciesOf(ctx context.Context, r *oci.Repository) error {
+	if !pullOpts.withDeps {
+		return nil
+	}
+	_, err := pullDependencies(ctx, r, pullOpts.skipVerify)
+	return err
+}
+
+// pulledUpToDate reports whether the local copy of r is the pinned manifest, or, without a
+// pinned digest, the manifest the tag points to in the registry.
+func pulledUpToDate(ctx context.Context, r *oci.Repository, pinned digest.Digest) (bool, error) {
+	if pinned == "" {
+		return mft.UpToDate(ctx, r)
+	}
+	local, err := r.Digest(ctx)
+	if err != nil {
+		return false, err
+	}
+	return local == pinned.String(), nil
+}
+
+// pullExternal pulls and verifies a single tag of an OCI image layout directory or object
+// storage into the repository it was packed for. The repository is named by the unverified
+// manifest itself, so its 'verify: never' rule does not skip verification, and the manifest
+// is verified in a staging layout before it replaces a local copy of the tag.
+func pullExternal(ctx context.Context, ref string) error {
+	if pullOpts.anyArtifact || pullOpts.ifNotPresent {
+		return fmt.Errorf("--any-artifact and --if-not-present are not supported when pulling from %s", ref)
+	}
+	src, err := parseExternalReference(ref)
+	if err != nil {
+		return err
+	}
+	source, err := src.source(ctx)
+	if err != nil {
+		return withExitCode(ExitRegistry, err)
+	}
+	name, err := oci.RepositoryNameIn(ctx, source, src.tag(), src.String())
+	if err != nil {
+		return withExitCode(ExitRegistry, err)
+	}
+	tag := name + ":" + src.tag()
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+	if pullOpts.skipVerify && verificationRequired(r) {
+		return withExitCode(ExitSignature, fmt.Errorf("--skip-verify is not allowed for %s, verification is required in config.yaml", r.Registry()))
+	}
+
+	debugf("Pulling %s from %s into %s\n", tag, src, r.LayoutPath())
+	s, err := r.StagePullFrom(ctx, source, src.tag(), src.String())
+	if err != nil {
+		return withExitCode(ExitRegistry, err)
+	}
+	defer s.Discard()
+	if !pullOpts.skipVerify {
+		debugf("Verifying signature of %s\n", r)
+		// The staging layout is discarded on failure, nothing is removed from local storage
+		if err := verifyPulled(ctx, s.Repository(), true); err != nil {
+			return err
+		}
+	}
+	if err := s.Commit(ctx); err != nil {
+		return err
+	}
+	infof("Pulled %s from %s\n", r, src)
+	return pullDependenciesOf(ctx, r)
+}
+
+// skipVerification reports whether the signature of a manifest of r is not verified, given
+// --skip-verify and the verification mode of its registry in the configuration. Flags asking
+// for stricter verification, such as --tofu, win over 'verify: never'.
+func skipVerification(r *oci.Repository, skipVerify bool) (bool, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return false, err
+	}
+	switch cfg.VerifyModeFor(r.Registry()) {
+	case config.VerifyNever:
+		if flag := strictVerificationFlag(); flag != "" && !skipVerify {
+			debugf("Verifying signature despite 'verify: never' for %s in config.yaml, --%s is set\n", r.Registry(), flag)
+			return false, nil
+		}
+		debugf("Skipping signature verification, verification is disabled for %s in config.yaml\n", r.Registry())
+		return true, nil
+	case config.VerifyRequired:
+		if skipVerify {
+			return false, withExitCode(ExitSignature, fmt.Errorf("--skip-verify is not allowed for %s, verification is required in config.yaml", r.Registry()))
+		}
+	}
+	return skipVerify, nil
+}
+
+// verificationRequired reports whether the configuration requires the signatures of the
+// manifests of r to be verified. A configuration that cannot be loaded requires it.
+func verificationRequired(r *oci.Repository) bool {
+	cfg, err := config.Load()
+	return err != nil || cfg.VerifyModeFor(r.Registry()) == config.VerifyRequired
+}
+
+// verifyPulled verifies the signature of a pulled manifest. With --fetch-keys, the keys named
+// by its signatures are fetched if no known key verifies it. With --tofu, its signing keys
+// are checked against the keys pinned for the repository. On failure the pulled data is
+// removed unless the tag already existed locally before the pull.
+func verifyPulled(ctx context.Context, r *oci.Repository, existedBefore bool) error {
+	err := verifyPulledSignature(ctx, r)
+	if err != nil && fetchKeys {
+		imported, fetchErr := fetchSigningKeys(ctx, r)
+		if fetchErr != nil {
+			return handleVerifyFailure(ctx, r, existedBefore, errors.Join(err, fetchErr))
+		}
+		if imported {
+			err = verifyPulledSignature(ctx, r)
+		}
+	}
+	if err == nil && tofu {
+		err = checkSignerPin(ctx, r)
+	}
+	if err != nil {
+		return handleVerifyFailure(ctx, r, existedBefore, err)
+	}
 	return nil
 }
 
+// refreshPulled pulls r again into a staging layout and verifies the staged manifest unless
+// skipVerify before it replaces the local copy, so the local copy is kept on failure.
+func refreshPulled(ctx context.Context, r *oci.Repository, skipVerify bool) error {
+	debugf("Refreshing %s in %s\n", r, r.LayoutPath())
+	s, err := r.StagePull(ctx)
+	if err != nil {
+		return withExitCode(ExitRegistry, err)
+	}
+	defer s.Discard()
+	if !skipVerify {
+		if err := verifyPulled(ctx, s.Repository(), true); err != nil {
+			return fmt.Errorf("%w, keeping the local copy of %s", err, r)
+		}
+	}
+	return s.Commit(ctx)
+}
+
+func verifyPulledSignature(ctx context.Context, r *oci.Repository) error {
+	if !verificationKeysExist() {
+		return fmt.Errorf("no verification keys found, run 'kubectl mft key import <file>' to import a public key, or use '--skip-verify' to skip verification")
+	}
+	verifier, err := newVerifier(r)
+	if err != nil {
+		return err
+	}
+	if err := verifier.Verify(ctx, r.LayoutPath(), r.Tag()); err != nil {
+		return withExitCode(ExitSignature, fmt.Errorf("signature verification failed: %w", err))
+	}
+	return checkSignatureRequirements(ctx, r, verifier)
+}
+
 func handleVerifyFailure(ctx context.Context, r *oci.Repository, existedBefore bool, originalErr error) error {
 	if existedBefore {
 		// Manifest existed before pull; don't attempt further deletion
@@ -102,3 +501,17 @@ func deletePulledData(ctx context.Context, r *oci.Repository, originalErr error)
 	}
 	return originalErr
 }
+
+// setBandwidthLimit applies the --bandwidth-limit flag to the registry transfers of r.
+func setBandwidthLimit(r *oci.Repository, limit string) error {
+	if limit == "" {
+		return nil
+	}
+	bytesPerSecond, err := oci.ParseBandwidth(limit)
+	if err != nil {
+		return err
+	}
+	debugf("Limiting transfers to %s/s\n", mft.FormatSize(bytesPerSecond))
+	r.SetBandwidthLimit(bytesPerSecond)
+	return nil
+}
@@ -5,26 +5,44 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
 
+	"github.com/chez-shanpu/kubectl-mft/internal/gitexport"
+	"github.com/chez-shanpu/kubectl-mft/internal/lock"
 	"github.com/chez-shanpu/kubectl-mft/internal/mft"
 	"github.com/chez-shanpu/kubectl-mft/internal/oci"
 )
 
 type PushOpts struct {
-	tag string
+	tag            string
+	destination    string
+	dryRun         bool
+	bandwidthLimit string
+	message        string
+	skipVerify     bool
+	bulk           bulkOpts
 }
 
 var pushOpts PushOpts
 
 func init() {
 	rootCmd.AddCommand(pushCmd)
+
+	flag := pushCmd.Flags()
+	flag.BoolVar(&pushOpts.dryRun, DryRunFlag, false, "Show the blobs that would be uploaded without pushing")
+	flag.StringVar(&pushOpts.bandwidthLimit, BandwidthLimitFlag, "", bandwidthLimitUsage)
+	flag.StringVar(&pushOpts.message, "message", "", "Commit message when pushing to a Git repository (default: \"Export <tag>\" with the digest)")
+	flag.BoolVar(&pushOpts.skipVerify, "skip-verify", false, "Skip signature verification before pushing to a Git repository")
+	addBulkFlags(pushCmd, &pushOpts.bulk, "push")
+	pushCmd.MarkFlagsMutuallyExclusive(FromFileFlag, DryRunFlag)
 }
 
 // pushCmd represents the push command
 var pushCmd = &cobra.Command{
-	Use:   "push <tag>",
+	Use:   "push <tag> [<destination>] | --from-file <file>",
 	Short: "Push a packaged manifest to an OCI registry",
 	Long: `Push uploads a previously packaged Kubernetes manifest to an OCI-compliant registry.
 
@@ -32,6 +50,46 @@ The manifest must be packaged using the 'pack' command before it can be pushed.
 Authentication is handled through Docker credential store, so ensure you are logged
 into the target registry using 'docker login' before pushing.
 
+With a destination of the form oci-layout://<dir>[:<tag>], the manifest and its signatures
+are written to the OCI image layout directory instead of a registry, under the given tag or
+the tag of the manifest. The directory is created if needed. Layouts can be exchanged over
+shared filesystems, removable media, or artifact stores that only accept files, and are read
+back with 'kubectl mft pull oci-layout://<dir>:<tag>'.
+
+Likewise, a destination of the form s3://<bucket>[/<prefix>][:<tag>], gs://..., or
+azblob://<container>[/<prefix>][:<tag>] writes the manifest as an OCI image layout under the
+prefix of an Amazon S3 or Google Cloud Storage bucket, or of an Azure Blob Storage container,
+for teams that cannot run a registry. Credentials are taken from the environment:
+  s3      AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN, or the profile
+          of AWS_PROFILE in ~/.aws/credentials, or the AWS CLI; AWS_REGION, or the region
+          of ~/.aws/config; AWS_ENDPOINT_URL_S3 selects an S3-compatible service such as MinIO
+  gs      GOOGLE_OAUTH_ACCESS_TOKEN, or the login of gcloud
+  azblob  AZURE_STORAGE_ACCOUNT with AZURE_STORAGE_SAS_TOKEN, or the login of az
+The proxy, CA file, and headers of the 'http' settings of config.yaml for the host of the
+service apply as for registries.
+
+A destination of the form git+<url>[//<path>][?ref=<branch>], where url is an ssh://,
+https://, or file:// URL of a Git repository, hands the manifest off to Git-based delivery
+such as Argo CD or Flux. The repository is cloned with the git command and its credentials,
+the resources are written to manifest.yaml under the path on the branch, or the default
+branch, along with an mft.lock lockfile pinning the tag to its digest, and the
+change is committed with --message and pushed. Other files under the path are kept. The
+signature is verified first like 'kubectl mft pull' does, unless --skip-verify is given or
+verification is disabled for the registry in config.yaml.
+
+With --dry-run, the registry is only asked which blobs it already has, and the tag and
+the blobs that would be uploaded are printed instead of pushing.
+
+With --bandwidth-limit, uploads are throttled to the given rate, e.g. to avoid saturating
+a shared uplink. Units are B, KB, MB, and GB per second, in powers of 1024.
+
+With --from-file, every reference listed in the file, one per line, is pushed. Blank lines
+and lines starting with # are ignored. Up to --parallel repositories are pushed concurrently,
+the tags of one repository one after another, and a summary is printed at the end. A failed
+reference does not stop the others. With --resume-from-failure, completed references are
+recorded in the given state file, and a rerun with the same file skips them, so a mirror job
+of many artifacts does not start over after a network failure.
+
 Examples:
   # Push manifest to Docker Hub
   kubectl mft push docker.io/myuser/my-app:v1.0.0
@@ -40,18 +98,163 @@ Examples:
   kubectl mft push registry.company.com/team/app:latest
 
   # Push to localhost registry
-  kubectl mft push localhost:5000/test-app:dev`,
-	Args: cobra.ExactArgs(1),
+  kubectl mft push localhost:5000/test-app:dev
+
+  # Push without using more than 10MB/s of the uplink
+  kubectl mft push --bandwidth-limit 10MB/s registry.company.com/team/app:latest
+
+  # Write a manifest to a USB drive
+  kubectl mft push registry.company.com/team/app:v1.0.0 oci-layout:///media/usb/manifests
+
+  # Publish a manifest to an S3 bucket
+  kubectl mft push registry.company.com/team/app:v1.0.0 s3://team-manifests/app
+
+  # Hand a release off to Argo CD through a GitOps repository
+  kubectl mft push registry.company.com/team/app:v1.0.0 \
+    'git+ssh://git@github.com/team/gitops.git//clusters/prod/app?ref=main'
+
+  # Show what would be uploaded
+  kubectl mft push --dry-run registry.company.com/team/app:latest
+
+  # Push many references, resuming where a previous run failed
+  kubectl mft push --from-file tags.txt --parallel 8 --resume-from-failure push.state`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 2 {
+			args = args[:1]
+		}
+		return bulkArgs(&pushOpts.bulk)(cmd, args)
+	},
+	ValidArgsFunction: completeTags(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if pushOpts.bulk.fromFile != "" {
+			return runBulk(cmd.Context(), "push", pushOpts.bulk, pushTag)
+		}
 		pushOpts.tag = args[0]
+		if len(args) == 2 {
+			pushOpts.destination = args[1]
+		}
 		return runPush(cmd.Context())
 	},
 }
 
 func runPush(ctx context.Context) error {
-	r, err := oci.NewRepository(pushOpts.tag)
+	if gitexport.IsReference(pushOpts.destination) {
+		return pushGit(ctx, pushOpts.tag, pushOpts.destination)
+	}
+	if pushOpts.destination != "" {
+		return pushExternal(ctx, pushOpts.tag, pushOpts.destination)
+	}
+	return pushTag(ctx, pushOpts.tag)
+}
+
+// pushExternal writes a single tag to an OCI image layout directory or object storage.
+func pushExternal(ctx context.Context, tag, destination string) error {
+	if pushOpts.dryRun {
+		return fmt.Errorf("--%s is not supported when pushing to %s", DryRunFlag, destination)
+	}
+	dst, err := parseExternalReference(destination)
+	if err != nil {
+		return err
+	}
+	r, err := oci.NewRepository(tag)
 	if err != nil {
 		return err
 	}
-	return mft.Push(ctx, r)
+	dst.defaultTag(r.Tag())
+	target, err := dst.target(ctx)
+	if err != nil {
+		return err
+	}
+	debugf("Pushing %s from %s to %s\n", r, r.LayoutPath(), dst)
+	if err := r.PushTo(ctx, target, dst.tag()); err != nil {
+		return withExitCode(ExitRegistry, err)
+	}
+	infof("Pushed %s to %s\n", r, dst)
+	return nil
+}
+
+// pushGit commits the resources of a single tag to a Git repository and pushes them.
+func pushGit(ctx context.Context, tag, destination string) error {
+	if pushOpts.dryRun {
+		return fmt.Errorf("--%s is not supported when pushing to %s", DryRunFlag, destination)
+	}
+	ref, err := gitexport.ParseReference(destination)
+	if err != nil {
+		return err
+	}
+	tag, err = resolveTag(ctx, tag, false)
+	if err != nil {
+		return err
+	}
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+	skipVerify, err := skipVerification(r, pushOpts.skipVerify)
+	if err != nil {
+		return err
+	}
+	if !skipVerify {
+		debugf("Verifying signature of %s\n", r)
+		if err := verifyPulledSignature(ctx, r); err != nil {
+			return err
+		}
+	}
+	digest, err := r.Digest(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := mft.Dump(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+	data, err := io.ReadAll(res)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+	lockfile, err := (&lock.File{Artifacts: []lock.Artifact{{Tag: tag, Digest: digest}}}).Marshal()
+	if err != nil {
+		return err
+	}
+	files := map[string][]byte{
+		"manifest.yaml": data,
+		"mft.lock":      lockfile,
+	}
+	message := pushOpts.message
+	if message == "" {
+		message = fmt.Sprintf("Export %s\n\nDigest: %s", r, digest)
+	}
+
+	debugf("Exporting %s to %s\n", r, ref)
+	commit, err := gitexport.Export(ctx, ref, files, message)
+	if err != nil {
+		return withExitCode(ExitRegistry, err)
+	}
+	if commit == "" {
+		infof("%s is already up to date with %s\n", ref, r)
+		return nil
+	}
+	infof("Pushed %s to %s as commit %s\n", r, ref, commit)
+	return nil
+}
+
+// pushTag pushes a single tag.
+func pushTag(ctx context.Context, tag string) error {
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+	if err := setBandwidthLimit(r, pushOpts.bandwidthLimit); err != nil {
+		return err
+	}
+	debugf("Pushing %s from %s\n", tag, r.LayoutPath())
+	if pushOpts.dryRun {
+		res, err := mft.PlanPush(ctx, r)
+		if err != nil {
+			return err
+		}
+		return res.Print()
+	}
+	return withExitCode(ExitRegistry, mft.Push(ctx, r))
 }
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/rbac"
+)
+
+type RBACOpts struct {
+	tag            string
+	output         string
+	failOnFindings bool
+}
+
+var rbacOpts RBACOpts
+
+func init() {
+	rootCmd.AddCommand(rbacCmd)
+
+	flag := rbacCmd.Flags()
+	flag.StringVarP(&rbacOpts.output, OutputFlag, OutputShortFlag, "table", "Output format (table, json, yaml)")
+	flag.BoolVar(&rbacOpts.failOnFindings, "fail-on-findings", false, "Exit with an error if any grant is flagged")
+}
+
+// rbacCmd represents the rbac command
+var rbacCmd = &cobra.Command{
+	Use:   "rbac <tag>",
+	Short: "Report the RBAC permissions a manifest grants",
+	Long: `RBAC extracts the Roles, ClusterRoles, RoleBindings, and ClusterRoleBindings of a locally
+stored manifest and reports the permissions each binding grants: which verbs on which resources
+each subject receives, in which namespace or cluster-wide.
+
+Grants are flagged for security review when they:
+  - bind the cluster-admin ClusterRole
+  - use wildcard verbs, API groups, resources, or non-resource URLs
+  - allow privilege escalation with the escalate, bind, or impersonate verbs
+  - allow reading Secrets, including with wildcard verbs
+  - grant access to anonymous users, or to every authenticated user
+    through the system:authenticated group
+  - bind a role not defined in the manifest, whose permissions cannot be analyzed,
+    or an aggregated ClusterRole, whose permissions depend on the cluster
+
+With --fail-on-findings, the command exits with an error if any grant is flagged, so it can
+be used as a review gate in CI. For bundles, the resources of every member are analyzed together.
+
+Output formats:
+  - table: Human-readable table format (default)
+  - json:  JSON format
+  - yaml:  YAML format
+
+Examples:
+  # Review the permissions granted by a manifest
+  kubectl mft rbac ghcr.io/myorg/operator:v1.0.0
+
+  # Fail a CI job on wildcard or cluster-admin grants
+  kubectl mft rbac ghcr.io/myorg/operator:v1.0.0 --fail-on-findings -o json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rbacOpts.tag = args[0]
+		return runRBAC(cmd.Context())
+	},
+}
+
+func runRBAC(ctx context.Context) error {
+	tag, err := resolveTag(ctx, rbacOpts.tag, false)
+	if err != nil {
+		return err
+	}
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+
+	docs, err := artifactDocuments(ctx, r)
+	if err != nil {
+		return err
+	}
+	grants, err := rbac.Analyze(docs)
+	if err != nil {
+		return err
+	}
+
+	res := mft.NewRBACResult(tag, grants)
+	if err := res.Print(mft.ListOutput(rbacOpts.output)); err != nil {
+		return err
+	}
+	if flagged := len(res.Flagged()); rbacOpts.failOnFindings && flagged > 0 {
+		return fmt.Errorf("%d RBAC grant(s) of %s flagged for review", flagged, tag)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(registryCmd)
+}
+
+// registryCmd represents the registry command group
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Inspect remote registries",
+	Long: `Inspect remote OCI registries used with push and pull.
+
+Examples:
+  # Check whether a registry supports what kubectl-mft needs
+  kubectl mft registry info ghcr.io/myorg/manifests`,
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type RegistryInfoOpts struct {
+	target string
+	output string
+}
+
+var registryInfoOpts RegistryInfoOpts
+
+func init() {
+	registryCmd.AddCommand(registryInfoCmd)
+
+	flag := registryInfoCmd.Flags()
+	flag.StringVarP(&registryInfoOpts.output, OutputFlag, OutputShortFlag, "table", "Output format (table, json, yaml)")
+}
+
+// registryInfoCmd represents the registry info command
+var registryInfoCmd = &cobra.Command{
+	Use:   "info <registry>[/<repository>]",
+	Short: "Probe a registry and report its compatibility",
+	Long: `Probe a remote registry for the capabilities kubectl-mft relies on and print a
+compatibility report.
+
+The following checks are run:
+  - api:            the OCI distribution API is served, with its reported version
+  - chunked-upload: blob upload sessions can be started, with the minimum chunk length
+  - artifact-type:  manifests with the kubectl-mft artifact and layer media types are accepted
+  - referrers:      the OCI referrers API is supported; without it signatures are
+                    published under sha256-<digest>.sig tags
+
+The checks other than api need a repository and push permission on it. The
+artifact-type check pushes an untagged manifest to the repository and deletes it
+afterwards. Credentials are taken from Docker's credential store.
+
+The command fails if the registry is not compatible.
+
+Examples:
+  # Check the registry API only
+  kubectl mft registry info ghcr.io
+
+  # Run all checks against a repository
+  kubectl mft registry info ghcr.io/myorg/manifests
+
+  # Report as JSON
+  kubectl mft registry info ghcr.io/myorg/manifests -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registryInfoOpts.target = args[0]
+		return runRegistryInfo(cmd.Context())
+	},
+}
+
+func runRegistryInfo(ctx context.Context) error {
+	output := mft.ListOutput(registryInfoOpts.output)
+	switch output {
+	case mft.ListTable, mft.ListJson, mft.ListYaml:
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+
+	debugf("Probing %s\n", registryInfoOpts.target)
+	res, err := oci.ProbeRegistry(ctx, registryInfoOpts.target)
+	if err != nil {
+		return err
+	}
+	if !quiet || output != mft.ListTable {
+		if err := res.Print(output); err != nil {
+			return err
+		}
+	}
+	if !res.Compatible() {
+		return fmt.Errorf("%s is not compatible with kubectl-mft", registryInfoOpts.target)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type ReindexOpts struct {
+	disable bool
+}
+
+var reindexOpts ReindexOpts
+
+func init() {
+	rootCmd.AddCommand(reindexCmd)
+
+	flag := reindexCmd.Flags()
+	flag.BoolVar(&reindexOpts.disable, "disable", false, "Remove the metadata index and scan the storage directories again")
+}
+
+// reindexCmd represents the reindex command
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuild the metadata index of local storage",
+	Long: `Reindex rebuilds the metadata index of local storage from the OCI layouts and the
+event logs.
+
+The metadata index is optional. Running reindex for the first time creates it; from then on,
+pack, pull, cp, delete, sign, and bundle commands keep it up to date, and list, 'tag ls', and
+'list --events' read the writable storage and its events from the index instead of scanning
+every repository and event log. Read-only system storage is always scanned.
+
+Run reindex again if the storage directory was modified by other means, such as an older
+kubectl-mft version or manual file operations.
+
+Examples:
+  # Create or rebuild the metadata index
+  kubectl mft reindex
+
+  # Remove the metadata index
+  kubectl mft reindex --disable`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReindex(cmd.Context())
+	},
+}
+
+func runReindex(ctx context.Context) error {
+	if reindexOpts.disable {
+		if err := oci.DisableMetadataIndex(); err != nil {
+			return err
+		}
+		printResult("", "Metadata index removed\n")
+		return nil
+	}
+
+	n, err := oci.Reindex(ctx)
+	if err != nil {
+		return err
+	}
+	printResult("", "Indexed %d manifests in %s\n", n, oci.MetadataIndexPath())
+	return nil
+}
@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+	"github.com/chez-shanpu/kubectl-mft/internal/validate"
+)
+
+type ReleaseOpts struct {
+	tag            string
+	filePath       string
+	key            string
+	provenance     string
+	skipValidation bool
+	output         string
+}
+
+var releaseOpts ReleaseOpts
+
+func init() {
+	rootCmd.AddCommand(releaseCmd)
+
+	flag := releaseCmd.Flags()
+	flag.StringVarP(&releaseOpts.filePath, FileFlag, FileShortFlag, "", "Path to the manifest file to release")
+	flag.StringVar(&releaseOpts.key, "key", "", "Private key to use for signing (default: the key configured for the repository, or \"default\")")
+	flag.StringVar(&releaseOpts.provenance, "provenance", "", "Path to a provenance attestation (JSON, e.g. an in-toto statement) to attach to the manifest")
+	flag.BoolVar(&releaseOpts.skipValidation, "skip-validation", false, "Skip manifest validation")
+	flag.BoolVar(&offlineValidation, OfflineFlag, false, offlineUsage)
+	flag.StringVarP(&releaseOpts.output, OutputFlag, OutputShortFlag, "table", "Output format (table, json, yaml)")
+
+	_ = releaseCmd.MarkFlagRequired(FileFlag)
+}
+
+// releaseCmd represents the release command
+var releaseCmd = &cobra.Command{
+	Use:   "release <tag>",
+	Short: "Validate, pack, sign, and push a manifest in one step",
+	Long: `Release runs the steps of publishing a manifest in order: validate, pack, sign,
+attach provenance (with --provenance), and push. The outcome of every step is reported
+in the selected output format, so CI pipelines can replace separate invocations with
+a single command.
+
+The tag must not exist in local storage yet. The manifest is only added to local
+storage once it has been signed, and if attaching provenance or pushing fails, the
+local tag is deleted again, so a failed release can be retried as is. The provenance
+attestation is stored as a referrer of the manifest and pushed along with the signature.
+
+Output formats:
+  - table: Human-readable table format (default)
+  - json:  JSON format
+  - yaml:  YAML format
+
+Examples:
+  # Release a manifest
+  kubectl mft release -f deployment.yaml ghcr.io/myorg/manifests/app:v1.0.0
+
+  # Release with a provenance attestation and a specific key, reporting as JSON
+  kubectl mft release -f deployment.yaml --provenance provenance.json --key release -o json ghcr.io/myorg/manifests/app:v1.0.0`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		releaseOpts.tag = args[0]
+		return runRelease(cmd.Context())
+	},
+}
+
+func runRelease(ctx context.Context) error {
+	output := mft.ListOutput(releaseOpts.output)
+	switch output {
+	case mft.ListTable, mft.ListJson, mft.ListYaml:
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+
+	res := mft.NewReleaseResult(releaseOpts.tag)
+	err := release(ctx, res)
+	if quiet && output == mft.ListTable && err == nil {
+		printIDs([]string{releaseOpts.tag})
+		return nil
+	}
+	if printErr := res.Print(output); printErr != nil {
+		return errors.Join(err, printErr)
+	}
+	return err
+}
+
+func release(ctx context.Context, res *mft.ReleaseResult) error {
+	r, err := oci.NewRepository(releaseOpts.tag)
+	if err != nil {
+		return err
+	}
+	exists, err := r.Exists(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%s already exists in local storage, delete it or release a new tag", releaseOpts.tag)
+	}
+
+	// Check the signing key before packing to avoid partial state
+	key, err := signingKeyFor(releaseOpts.key, r)
+	if err != nil {
+		return err
+	}
+	if !signature.SigningKeyExists(key) {
+		return fmt.Errorf("signing key %q not found, run 'kubectl mft key generate' to create a key pair", key)
+	}
+
+	if releaseOpts.skipValidation {
+		res.Add("validate", mft.ReleaseStepSkipped, "")
+	} else {
+		opts, err := validationOptions(nil)
+		if err != nil {
+			return err
+		}
+		err = validate.ValidateManifest(releaseOpts.filePath, opts...)
+		if err := recordStep(res, "validate", "", err); err != nil {
+			return fmt.Errorf("manifest validation failed: %w", err)
+		}
+	}
+
+	// Pack and sign in a staging layout, so a signing failure leaves local storage untouched
+	staged, err := r.Stage(ctx, releaseOpts.filePath, "")
+	if err := recordStep(res, "pack", r.String(), err); err != nil {
+		return err
+	}
+	defer staged.Discard()
+
+	signer, err := signature.NewSignerFromRef(key)
+	if err != nil {
+		return recordStep(res, "sign", "", err)
+	}
+	defer signer.Close()
+	sig, err := signer.WithRepository(r.Name()).Sign(ctx, staged.LayoutPath(), r.Tag())
+	if err != nil {
+		return recordStep(res, "sign", "", fmt.Errorf("failed to sign manifest: %w", err))
+	}
+	res.Add("sign", mft.ReleaseStepDone, sig.Digest)
+	if err := staged.Commit(ctx); err != nil {
+		return err
+	}
+
+	if err := publish(ctx, r, res); err != nil {
+		if _, deleteErr := mft.Delete(ctx, r); deleteErr != nil {
+			res.Add("rollback", mft.ReleaseStepFailed, deleteErr.Error())
+			return errors.Join(err, fmt.Errorf("failed to clean up packed data: %w", deleteErr))
+		}
+		res.Add("rollback", mft.ReleaseStepDone, "deleted local tag")
+		return err
+	}
+	return nil
+}
+
+// publish attaches the provenance to the committed manifest and pushes it with its referrers.
+func publish(ctx context.Context, r *oci.Repository, res *mft.ReleaseResult) error {
+	if releaseOpts.provenance == "" {
+		res.Add("provenance", mft.ReleaseStepSkipped, "")
+	} else {
+		d, err := r.AttachProvenance(ctx, releaseOpts.provenance)
+		if err := recordStep(res, "provenance", d, err); err != nil {
+			return err
+		}
+	}
+
+	return recordStep(res, "push", r.String(), withExitCode(ExitRegistry, mft.Push(ctx, r)))
+}
+
+// recordStep records the outcome of a release step and returns err.
+func recordStep(res *mft.ReleaseResult, name, message string, err error) error {
+	if err != nil {
+		res.Add(name, mft.ReleaseStepFailed, err.Error())
+		return err
+	}
+	res.Add(name, mft.ReleaseStepDone, message)
+	return nil
+}
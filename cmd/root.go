@@ -4,12 +4,17 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
 	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/paths"
 	"github.com/chez-shanpu/kubectl-mft/internal/signature"
 )
 
@@ -26,10 +31,51 @@ const (
 	FileFlag      = "file"
 	FileShortFlag = "f"
 
-	ForceFlag      = "force"
-	ForceShortFlag = "y"
+	ForceFlag = "force"
+
+	TagFlag      = "tag"
+	TagShortFlag = "t"
+
+	YesFlag      = "yes"
+	YesShortFlag = "y"
+
+	QuietFlag      = "quiet"
+	QuietShortFlag = "q"
+
+	VerboseFlag      = "verbose"
+	VerboseShortFlag = "v"
+
+	NoCacheFlag = "no-cache"
+
+	DryRunFlag = "dry-run"
+
+	BandwidthLimitFlag  = "bandwidth-limit"
+	bandwidthLimitUsage = "Limit the transfer rate to the registry, e.g. 10MB/s (default: unlimited)"
+
+	RewriteImagesFlag  = "rewrite-images"
+	rewriteImagesUsage = "Replace the registry of container images, as <registry>=<replacement>, can be repeated"
+
+	OfflineFlag  = "offline"
+	offlineUsage = "Only use schemas that are already cached, without downloading any"
+
+	AllowHTTPFlag  = "allow-http"
+	allowHTTPUsage = "Allow downloading -f URLs over plain http://"
+
+	ColorFlag  = "color"
+	colorUsage = "Color text output: auto (if stdout is a terminal and NO_COLOR is unset), always, or never"
+
+	RawRefFlag = "raw-ref"
+
+	TimestampURLFlag  = "timestamp-url"
+	timestampURLUsage = "URL of an RFC 3161 timestamping authority to timestamp signatures with (default: signing.timestampURL from config)"
 )
 
+// profile is set by the --profile flag
+var profile string
+
+// rawRef is set by the --raw-ref flag
+var rawRef bool
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:          "kubectl-mft",
@@ -40,12 +86,17 @@ var rootCmd = &cobra.Command{
 		if cmd.Name() == "help" || cmd.Name() == "completion" {
 			return nil
 		}
+		oci.SetStrictReferences(!rawRef)
+		if err := initProfile(); err != nil {
+			return err
+		}
 		if err := signature.InitKeyDir(); err != nil {
 			return err
 		}
 		if err := oci.InitBaseDir(); err != nil {
 			return err
 		}
+		debugf("Profile %q: storage %s, keys %s\n", paths.Profile(), oci.BaseDir(), signature.KeyDir())
 		return nil
 	},
 }
@@ -53,13 +104,63 @@ var rootCmd = &cobra.Command{
 func init() {
 	// Customize version output template
 	rootCmd.SetVersionTemplate(fmt.Sprintf("kubectl-mft version %s (commit: %s)\n", version, commit))
+
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, YesFlag, YesShortFlag, false, "Confirm destructive actions without prompting")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, QuietFlag, QuietShortFlag, false, "Suppress success messages and print only the primary identifiers of results")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, VerboseFlag, VerboseShortFlag, false, "Print additional diagnostics to stderr")
+	rootCmd.MarkFlagsMutuallyExclusive(QuietFlag, VerboseFlag)
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Profile whose manifests, keys, schemas, configuration, and cache are used (default: KUBECTL_MFT_PROFILE, the profile in config.yaml, or \"default\")")
+	rootCmd.PersistentFlags().BoolVar(&rawRef, RawRefFlag, false, "Accept references refused as ambiguous or malformed, such as localhost:5000, as parsed")
+}
+
+// initProfile selects the profile from --profile, KUBECTL_MFT_PROFILE, or the configuration, in this order.
+func initProfile() error {
+	name := profile
+	if name == "" {
+		name = os.Getenv("KUBECTL_MFT_PROFILE")
+	}
+	if name == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		name = cfg.Profile
+	}
+	return paths.SetProfile(name)
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// The context passed to commands is canceled on the first interrupt, so that running
+// operations stop and clean up partial state. A second interrupt terminates immediately.
 func Execute() {
-	err := rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	initCompletionCmd()
+	err := rootCmd.ExecuteContext(ctx)
+	interrupted := ctx.Err() != nil
+	stop()
 	if err != nil {
-		os.Exit(1)
+		if interrupted {
+			os.Exit(ExitInterrupted)
+		}
+		os.Exit(exitCode(err))
+	}
+}
+
+// resolveTag resolves a semver range or latest-semver reference to a concrete tag,
+// reporting the resolution on stderr.
+func resolveTag(ctx context.Context, tag string, remote bool) (string, error) {
+	resolved, err := oci.ResolveSemverTag(ctx, tag, remote)
+	if err != nil {
+		return "", err
+	}
+	if resolved != tag {
+		infof("Resolved %s to %s\n", tag, resolved)
 	}
+	return resolved, nil
 }
@@ -27,6 +27,9 @@ Examples:
   # List registered schemas
   kubectl mft schema list
 
+  # Print a registered schema
+  kubectl mft schema show cilium.io/CiliumNetworkPolicy@v2
+
   # Delete a registered schema
   kubectl mft schema delete cilium.io/CiliumNetworkPolicy`,
 }
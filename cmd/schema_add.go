@@ -4,15 +4,18 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/chez-shanpu/kubectl-mft/internal/source"
 	"github.com/chez-shanpu/kubectl-mft/internal/validate"
 )
 
 type SchemaAddOpts struct {
-	filePath string
+	filePaths []string
 }
 
 var schemaAddOpts SchemaAddOpts
@@ -21,7 +24,8 @@ func init() {
 	schemaCmd.AddCommand(schemaAddCmd)
 
 	flag := schemaAddCmd.Flags()
-	flag.StringVarP(&schemaAddOpts.filePath, FileFlag, FileShortFlag, "", "Path to the CRD YAML file")
+	flag.StringArrayVarP(&schemaAddOpts.filePaths, FileFlag, FileShortFlag, nil, "Path to a CRD YAML file or a directory of them, or an https:// URL, can be repeated")
+	flag.BoolVar(&allowHTTP, AllowHTTPFlag, false, allowHTTPUsage)
 
 	_ = schemaAddCmd.MarkFlagRequired(FileFlag)
 }
@@ -35,22 +39,65 @@ var schemaAddCmd = &cobra.Command{
 The command reads the CRD YAML file, extracts the OpenAPI v3 schema from each version,
 and stores it locally for use during pack validation.
 
+A file may hold many CRDs as a multi-document YAML bundle; documents that are not CRDs
+are skipped. -f also accepts a directory, whose .yaml, .yml, and .json files are read
+recursively, or an https:// URL to download the CRDs from. Every registered CRD is
+listed with its versions. Registration is all-or-nothing: if any CRD of any -f is
+invalid or has no version with a schema, none is registered.
+
 Examples:
   # Register a CRD schema from a file
   kubectl mft schema add -f ciliumnetworkpolicy-crd.yaml
 
   # Register a CRD schema from a downloaded file
-  kubectl mft schema add -f cert-manager-certificate-crd.yaml`,
+  kubectl mft schema add -f cert-manager-certificate-crd.yaml
+
+  # Register all CRDs of a release
+  kubectl mft schema add -f https://github.com/cert-manager/cert-manager/releases/download/v1.14.0/cert-manager.crds.yaml
+
+  # Register the CRDs of a directory
+  kubectl mft schema add -f config/crd/`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runSchemaAdd()
+		return runSchemaAdd(cmd.Context())
 	},
 }
 
-func runSchemaAdd() error {
-	if err := validate.RegisterCRDSchema(schemaAddOpts.filePath); err != nil {
+func runSchemaAdd(ctx context.Context) error {
+	source.SetAllowHTTP(allowHTTP)
+	// Read all sources before registering, so that nothing is registered if any is invalid
+	var crds []validate.CRDManifest
+	for _, path := range schemaAddOpts.filePaths {
+		found, err := readSchemas(ctx, path)
+		if err != nil {
+			return err
+		}
+		crds = append(crds, found...)
+	}
+	registered, err := validate.RegisterCRDs(crds)
+	if err != nil {
 		return err
 	}
-	fmt.Println("CRD schema registered successfully")
+	for _, crd := range registered {
+		name := crd.Group + "/" + crd.Kind
+		printResult(name, "CRD schema %s registered successfully (%s)\n", name, strings.Join(crd.Versions, ", "))
+	}
 	return nil
 }
+
+// readSchemas reads the CRDs of a file, directory, or URL.
+func readSchemas(ctx context.Context, path string) ([]validate.CRDManifest, error) {
+	if !source.IsRemote(path) {
+		return validate.ReadCRDs(path)
+	}
+	debugf("Downloading %s\n", path)
+	src, err := source.Fetch(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	crds, err := validate.ParseCRDs(src.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return crds, nil
+}
@@ -21,14 +21,15 @@ var schemaDeleteCmd = &cobra.Command{
 	Short: "Delete a registered CRD schema",
 	Long: `Delete a registered CRD schema from local storage.
 
-All versions of the specified resource schema will be removed.
+All versions of the specified resource schema will be removed. A confirmation
+prompt is shown unless --yes is given.
 
 Examples:
   # Delete a CRD schema
   kubectl mft schema delete cilium.io/CiliumNetworkPolicy
 
-  # Delete another CRD schema
-  kubectl mft schema delete cert-manager.io/Certificate`,
+  # Delete a CRD schema without confirmation
+  kubectl mft schema delete --yes cert-manager.io/Certificate`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runSchemaDelete(args[0])
@@ -41,9 +42,18 @@ func runSchemaDelete(groupKind string) error {
 		return err
 	}
 
+	ok, err := confirm(fmt.Sprintf("Delete CRD schema %s/%s?", group, kind), false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("Deletion cancelled")
+		return nil
+	}
+
 	if err := validate.DeleteSchema(group, kind); err != nil {
 		return err
 	}
-	fmt.Printf("CRD schema %s/%s deleted successfully\n", group, kind)
+	printResult("", "CRD schema %s/%s deleted successfully\n", group, kind)
 	return nil
 }
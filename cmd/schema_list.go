@@ -5,16 +5,30 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
 	"github.com/chez-shanpu/kubectl-mft/internal/validate"
 )
 
+type SchemaListOpts struct {
+	output  string
+	group   string
+	kind    string
+	version string
+}
+
+var schemaListOpts SchemaListOpts
+
 func init() {
 	schemaCmd.AddCommand(schemaListCmd)
+
+	flag := schemaListCmd.Flags()
+	flag.StringVarP(&schemaListOpts.output, OutputFlag, OutputShortFlag, "table", "Output format (table, json, yaml)")
+	flag.StringVar(&schemaListOpts.group, "group", "", "Only list schemas of the API group")
+	flag.StringVar(&schemaListOpts.kind, "kind", "", "Only list schemas of the kind, compared case-insensitively")
+	flag.StringVar(&schemaListOpts.version, "version", "", "Only list schemas of the API version")
 }
 
 // schemaListCmd represents the schema list command
@@ -23,8 +37,17 @@ var schemaListCmd = &cobra.Command{
 	Short: "List registered CRD schemas",
 	Long: `List all CRD schemas registered for manifest validation.
 
+The list can be narrowed down with --group, --kind, and --version, and printed as
+JSON or YAML for tooling with -o.
+
 Examples:
-  kubectl mft schema list`,
+  kubectl mft schema list
+
+  # List the schemas of an API group as JSON
+  kubectl mft schema list --group cilium.io -o json
+
+  # List the v1 schemas
+  kubectl mft schema list --version v1`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runSchemaList()
@@ -32,20 +55,29 @@ Examples:
 }
 
 func runSchemaList() error {
-	schemas, err := validate.ListSchemas()
+	output := mft.ListOutput(schemaListOpts.output)
+	switch output {
+	case mft.ListTable, mft.ListJson, mft.ListYaml:
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+
+	schemas, err := validate.FilterSchemas(validate.SchemaFilter{
+		Group:   schemaListOpts.group,
+		Kind:    schemaListOpts.kind,
+		Version: schemaListOpts.version,
+	})
 	if err != nil {
 		return err
 	}
 
-	if len(schemas) == 0 {
-		fmt.Println("No CRD schemas registered")
+	if quiet && output == mft.ListTable {
+		var ids []string
+		for _, s := range schemas {
+			ids = append(ids, s.Group+"/"+s.Kind)
+		}
+		printIDs(ids)
 		return nil
 	}
-
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "GROUP\tKIND\tVERSION")
-	for _, s := range schemas {
-		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Group, s.Kind, s.Version)
-	}
-	return w.Flush()
+	return mft.NewSchemaListResult(schemas).Print(output)
 }
@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/validate"
+)
+
+func init() {
+	schemaCmd.AddCommand(schemaShowCmd)
+}
+
+// schemaShowCmd represents the schema show command
+var schemaShowCmd = &cobra.Command{
+	Use:   "show <group/kind>[@<version>]",
+	Short: "Print a registered CRD schema",
+	Long: `Print the JSON schema registered for a custom resource.
+
+The version may be omitted if only one version of the resource is registered.
+
+Examples:
+  # Print the v1 schema of a resource
+  kubectl mft schema show example.com/MyResource@v1
+
+  # Print the schema of a resource with a single registered version
+  kubectl mft schema show cilium.io/CiliumNetworkPolicy`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSchemaShow(args[0])
+	},
+}
+
+func runSchemaShow(ref string) error {
+	filter, err := validate.ParseSchemaRef(ref)
+	if err != nil {
+		return err
+	}
+	info, data, err := validate.ReadSchema(filter)
+	if err != nil {
+		return err
+	}
+	debugf("Showing schema %s/%s@%s\n", info.Group, info.Kind, info.Version)
+	if _, err := os.Stdout.Write(data); err != nil {
+		return err
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		_, err = os.Stdout.Write([]byte("\n"))
+	}
+	return err
+}
@@ -0,0 +1,257 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/paths"
+	"github.com/chez-shanpu/kubectl-mft/internal/platform"
+	"github.com/chez-shanpu/kubectl-mft/internal/server"
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+	"github.com/chez-shanpu/kubectl-mft/pkg/mft"
+)
+
+type ServeOpts struct {
+	listen    string
+	tokenFile string
+	key       string
+	tlsCert   string
+	tlsKey    string
+}
+
+var serveOpts ServeOpts
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	flag := serveCmd.Flags()
+	flag.StringVar(&serveOpts.listen, "listen", "", "Address to listen on, unix://<path> or <host>:<port> (default: serve.sock in the cache directory)")
+	flag.StringVar(&serveOpts.tokenFile, "token-file", "", "File holding the bearer token required from clients (default: KUBECTL_MFT_SERVE_TOKEN)")
+	flag.StringVar(&serveOpts.key, "key", "", "Name of the private key signing manifests packed through the API")
+	flag.StringVar(&serveOpts.tlsCert, "tls-cert", "", "Certificate file to serve HTTPS with")
+	flag.StringVar(&serveOpts.tlsKey, "tls-key", "", "Private key file of --tls-cert")
+	serveCmd.MarkFlagsRequiredTogether("tls-cert", "tls-key")
+}
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve local storage over an HTTP API",
+	Long: `Serve exposes the local storage over an HTTP API, so that many short-lived jobs on one
+machine, such as CI jobs on a runner, share one warm storage directory instead of each
+maintaining its own.
+
+  GET  /v1/manifests          list the stored manifests as JSON
+  GET  /v1/dump?ref=<ref>     return the content of a manifest
+  POST /v1/pack?ref=<ref>     pack the manifest in the request body (add &skipValidation=true to skip validation)
+  POST /v1/verify?ref=<ref>   verify the signature of a manifest like 'kubectl mft verify'
+
+By default, the API listens on a unix socket only accessible to the current user. Clients
+authenticate with a bearer token read from --token-file or KUBECTL_MFT_SERVE_TOKEN, which is
+required when listening on a TCP address. A TCP address other than loopback also requires
+--tls-cert and --tls-key, so that the token is not sent in plain text. With --key, manifests
+packed through the API are signed with the named key.
+
+Packing applies pack.limits of config.yaml and records the change in the metadata index and
+the event log, like 'kubectl mft pack'. Verification applies the trust policy, and reports
+"verified": false for registries whose verification is disabled in config.yaml.
+
+The server stops on interrupt, after finishing the requests in progress.
+
+Examples:
+  # Serve on the default unix socket
+  kubectl mft serve
+
+  # Serve on a TCP port with a token, signing packed manifests
+  kubectl mft serve --listen 127.0.0.1:8480 --token-file /etc/kubectl-mft/token --key ci
+
+  # Serve to other machines over HTTPS
+  kubectl mft serve --listen :8443 --token-file /etc/kubectl-mft/token --tls-cert server.crt --tls-key server.key
+
+  # List manifests from a job
+  curl --unix-socket ~/.cache/kubectl-mft/serve.sock http://localhost/v1/manifests`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runServe(cmd.Context())
+	},
+}
+
+func runServe(ctx context.Context) error {
+	token, err := serveToken()
+	if err != nil {
+		return err
+	}
+	network, address, err := listenAddress(serveOpts.listen)
+	if err != nil {
+		return err
+	}
+	if network == "tcp" && token == "" {
+		return fmt.Errorf("a token is required when listening on %s, use --token-file or KUBECTL_MFT_SERVE_TOKEN", address)
+	}
+	if network == "tcp" && serveOpts.tlsCert == "" && !isLoopback(address) {
+		return fmt.Errorf("--tls-cert and --tls-key are required when listening on %s, which is not a loopback address", address)
+	}
+	if network == "unix" && serveOpts.tlsCert != "" {
+		return fmt.Errorf("--tls-cert is only supported when listening on a TCP address")
+	}
+
+	store, err := mft.NewLocalStore("")
+	if err != nil {
+		return err
+	}
+	opts := []server.Option{server.WithToken(token), server.WithVerifier(serveVerify)}
+	if serveOpts.key != "" {
+		key, err := signature.LoadPrivateKey(serveOpts.key)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, server.WithSigner(key))
+	}
+	s, err := server.New(store, opts...)
+	if err != nil {
+		return err
+	}
+
+	l, err := listen(network, address)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	if serveOpts.tlsCert != "" {
+		infof("Serving local storage on https://%s\n", address)
+		err = srv.ServeTLS(l, serveOpts.tlsCert, serveOpts.tlsKey)
+	} else {
+		infof("Serving local storage on %s://%s\n", network, address)
+		err = srv.Serve(l)
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// serveVerify verifies a stored manifest for the API like 'kubectl mft verify', applying the
+// trust policy. It reports false without verifying if verification is disabled for the
+// registry of the manifest in config.yaml.
+func serveVerify(ctx context.Context, ref string) (bool, error) {
+	r, err := oci.NewRepository(ref)
+	if err != nil {
+		return false, err
+	}
+	if skip, err := skipVerification(r, false); err != nil || skip {
+		return false, err
+	}
+	verifier, err := newVerifier(r)
+	if err != nil {
+		return false, err
+	}
+	if err := verifier.Verify(ctx, r.LayoutPath(), r.Tag()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// isLoopback reports whether the host of the TCP address is a loopback address. An empty
+// host listens on all interfaces.
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// serveToken returns the bearer token from --token-file or KUBECTL_MFT_SERVE_TOKEN.
+func serveToken() (string, error) {
+	if serveOpts.tokenFile == "" {
+		return os.Getenv("KUBECTL_MFT_SERVE_TOKEN"), nil
+	}
+	data, err := os.ReadFile(serveOpts.tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", serveOpts.tokenFile)
+	}
+	return token, nil
+}
+
+// listenAddress returns the network and address of --listen.
+func listenAddress(listen string) (string, string, error) {
+	if listen == "" {
+		cacheDir, err := paths.CacheDir()
+		if err != nil {
+			return "", "", err
+		}
+		return "unix", filepath.Join(cacheDir, "serve.sock"), nil
+	}
+	if path, ok := strings.CutPrefix(listen, "unix://"); ok {
+		if path == "" {
+			return "", "", fmt.Errorf("invalid --listen %s, expected unix://<path>", listen)
+		}
+		return "unix", path, nil
+	}
+	if _, _, err := net.SplitHostPort(listen); err != nil {
+		return "", "", fmt.Errorf("invalid --listen %s, expected unix://<path> or <host>:<port>: %w", listen, err)
+	}
+	return "tcp", listen, nil
+}
+
+// listen listens on the address. A unix socket left over by a previous server is replaced,
+// any other file at its path is refused, and the socket is only accessible to the current user: it is created under a umask that
+// denies access to others, so that it is never accessible before it is restricted.
+func listen(network, address string) (net.Listener, error) {
+	if network != "unix" {
+		return net.Listen(network, address)
+	}
+	if err := os.MkdirAll(filepath.Dir(address), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	// Only a socket is removed, --listen must not delete an arbitrary file
+	if fi, err := os.Lstat(address); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("cannot listen on %s: it exists and is not a socket", address)
+		}
+		if err := os.Remove(address); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to check socket %s: %w", address, err)
+	}
+	mask := platform.Umask(0o177)
+	l, err := net.Listen(network, address)
+	platform.Umask(mask)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(address, 0o600); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return l, nil
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type ServeRegistryOpts struct {
+	addr    string
+	tlsCert string
+	tlsKey  string
+}
+
+var serveRegistryOpts ServeRegistryOpts
+
+func init() {
+	rootCmd.AddCommand(serveRegistryCmd)
+
+	flag := serveRegistryCmd.Flags()
+	flag.StringVar(&serveRegistryOpts.addr, "addr", ":5001", "Address to listen on")
+	flag.StringVar(&serveRegistryOpts.tlsCert, "tls-cert", "", "Certificate file to serve HTTPS with")
+	flag.StringVar(&serveRegistryOpts.tlsKey, "tls-key", "", "Private key file of --tls-cert")
+	serveRegistryCmd.MarkFlagsRequiredTogether("tls-cert", "tls-key")
+}
+
+// serveRegistryCmd represents the serve-registry command
+var serveRegistryCmd = &cobra.Command{
+	Use:   "serve-registry",
+	Short: "Serve local storage as a read-only OCI registry",
+	Long: `Serve-registry implements the pull API of the OCI distribution spec on the manifests of
+local storage, so that a laptop or bastion host can act as a temporary registry for clusters
+in an isolated network segment. Signatures and other referrers are served through the
+referrers API, so pulled manifests can be verified as usual.
+
+A repository is named by its storage path: 'ghcr.io/myorg/app' for manifests packed as
+'ghcr.io/myorg/app:v1', and 'local/app', or just 'app', for manifests packed as 'app:v1'.
+Pushing is not supported.
+
+The registry serves plain HTTP unless --tls-cert and --tls-key are given. It stops on
+interrupt, after finishing the requests in progress.
+
+Examples:
+  # Serve local storage on port 5001
+  kubectl mft serve-registry
+
+  # Pull from another host
+  kubectl mft pull bastion.internal:5001/ghcr.io/myorg/app:v1
+
+  # Serve HTTPS
+  kubectl mft serve-registry --addr :443 --tls-cert bastion.crt --tls-key bastion.key`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runServeRegistry(cmd.Context())
+	},
+}
+
+func runServeRegistry(ctx context.Context) error {
+	l, err := net.Listen("tcp", serveRegistryOpts.addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: oci.RegistryHandler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	scheme := "http"
+	if serveRegistryOpts.tlsCert != "" {
+		scheme = "https"
+	}
+	infof("Serving %s as a read-only registry on %s://%s\n", oci.BaseDir(), scheme, l.Addr())
+
+	if scheme == "https" {
+		err = srv.ServeTLS(l, serveRegistryOpts.tlsCert, serveRegistryOpts.tlsKey)
+	} else {
+		err = srv.Serve(l)
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("registry server failed: %w", err)
+	}
+	return nil
+}
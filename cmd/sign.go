@@ -6,16 +6,23 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
 
 	"github.com/spf13/cobra"
 
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
 	"github.com/chez-shanpu/kubectl-mft/internal/oci"
 	"github.com/chez-shanpu/kubectl-mft/internal/signature"
 )
 
 type SignOpts struct {
-	tag string
-	key string
+	tag          string
+	keys         []string
+	output       string
+	timestampURL string
 }
 
 var signOpts SignOpts
@@ -24,50 +31,250 @@ func init() {
 	rootCmd.AddCommand(signCmd)
 
 	flag := signCmd.Flags()
-	flag.StringVar(&signOpts.key, "key", "default", "Name of the private key to use for signing")
+	flag.StringArrayVar(&signOpts.keys, "key", nil, signingKeysUsage)
+	flag.StringVar(&signOpts.output, OutputFlag, "", "Write a detached signature to the file instead of attaching it to the manifest")
+	flag.StringVar(&signOpts.timestampURL, TimestampURLFlag, "", timestampURLUsage)
 }
 
+const signingKeysUsage = "Private key to use for signing: a key name, ssh:<path>, ssh-agent:[<fingerprint|comment>], or gpg:<key-id>; can be repeated to add one signature per key (default: the key configured for the repository, or \"default\")"
+
 // signCmd represents the sign command
 var signCmd = &cobra.Command{
 	Use:   "sign <tag>",
 	Short: "Sign a packed manifest",
 	Long: `Sign a previously packed manifest in local OCI layout storage.
+Manifests provided by read-only system storage or only cached are refused.
 
 The signing key must be generated first using 'kubectl mft key generate'.
+Existing Ed25519 or ECDSA SSH keys can be used instead with --key ssh:<path>,
+or --key ssh-agent:[<fingerprint|comment>] for keys held by ssh-agent.
+Encrypted SSH key files are decrypted with KUBECTL_MFT_SSH_PASSPHRASE.
+With --key gpg:<key-id>, the signature is created by the local GPG keyring.
+--key can be repeated to attach one signature per key in a single pass, for
+example a developer key and a CI key. The signatures are attached only if every
+key signed the manifest.
+
+The signature covers a versioned payload in a DSSE envelope holding the manifest digest
+and media type, the repository, the tag, and the signing time. Verification requires the
+digest, media type, and tag to match, so a signature cannot be replayed onto another tag
+of the same manifest. Signatures of earlier versions, which cover the manifest digest
+only, are still verified.
+
+With --output, the signature is written to a file instead of being attached to the
+manifest, for workflows where signatures travel through a different channel than the
+registry. The file is checked with 'kubectl mft verify --signature'.
+
+With --timestamp-url, or signing.timestampURL in config.yaml, the signature is
+timestamped by an RFC 3161 timestamping authority. verify checks the timestamp
+against the system roots, or the PEM file set as signing.timestampRoots, and
+reports the time. PGP keys that expired after the timestamp, and keys retired in
+the trust policy after it, still verify the signature.
+
+Without --key, the key is selected by the signing rules in config.yaml in the
+configuration directory (see 'kubectl mft env'), for example:
+
+  signing:
+    defaultKey: default
+    keys:
+      - repository: registry.company.com/prod/*
+        key: prod-release
 
 Examples:
   # Sign a local manifest
   kubectl mft sign myapp:v1.0.0
 
   # Sign a manifest with registry reference
-  kubectl mft sign registry.example.com/manifests/app:v1.0.0`,
-	Args: cobra.ExactArgs(1),
+  kubectl mft sign registry.example.com/manifests/app:v1.0.0
+
+  # Sign with an SSH key
+  kubectl mft sign myapp:v1.0.0 --key ssh:~/.ssh/id_ed25519
+
+  # Sign with a key held by ssh-agent
+  kubectl mft sign myapp:v1.0.0 --key ssh-agent:alice@example.com
+
+  # Sign with a GPG key
+  kubectl mft sign myapp:v1.0.0 --key gpg:alice@example.com
+
+  # Write a detached signature to a file
+  kubectl mft sign myapp:v1.0.0 --output myapp.sig
+
+  # Sign with both a developer key and a CI key
+  kubectl mft sign myapp:v1.0.0 --key dev --key ci
+
+  # Timestamp the signature
+  kubectl mft sign myapp:v1.0.0 --timestamp-url http://timestamp.digicert.com`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		signOpts.tag = args[0]
 		return runSign(cmd.Context())
 	},
 }
 
-func runSign(ctx context.Context) error {
-	if !signature.PrivateKeyExists(signOpts.key) {
-		return fmt.Errorf("signing key %q not found, run 'kubectl mft key generate' to create a key pair", signOpts.key)
+// signingKeyFor returns key if set, and otherwise the signing key configured for the repository.
+func signingKeyFor(key string, r *oci.Repository) (string, error) {
+	if key != "" {
+		return key, nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return "", err
 	}
+	return cfg.Signing.KeyFor(r.Name()), nil
+}
+
+// signingKeysFor returns keys without duplicates, or the signing key configured
+// for the repository if none are given.
+func signingKeysFor(keys []string, r *oci.Repository) ([]string, error) {
+	if len(keys) == 0 {
+		key, err := signingKeyFor("", r)
+		if err != nil {
+			return nil, err
+		}
+		return []string{key}, nil
+	}
+	var out []string
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("signing key must not be empty")
+		}
+		if !slices.Contains(out, key) {
+			out = append(out, key)
+		}
+	}
+	return out, nil
+}
 
+// timestampURLFor returns url if set, and otherwise the timestamping authority configured for signing.
+func timestampURLFor(url string) (string, error) {
+	if url != "" {
+		return url, nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return "", err
+	}
+	return cfg.Signing.TimestampURL, nil
+}
+
+// timestampClient creates the HTTP client for the timestamping authority at rawURL, using the
+// proxy and CA file configured for its host.
+func timestampClient(rawURL string) (*http.Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid timestamping authority URL %q", rawURL)
+	}
+	return oci.NewHTTPClient(u.Host)
+}
+
+// newSigner creates a Signer for the key reference that records the repository of r in its
+// signing payloads, timestamps signatures with the timestamping authority at timestampURL,
+// if set, and names the key distribution artifact configured as signing.keysRef. Close the
+// Signer when done.
+func newSigner(r *oci.Repository, key, timestampURL string) (*signature.Signer, error) {
+	signer, err := signature.NewSignerFromRef(key)
+	if err != nil {
+		return nil, err
+	}
+	signer = signer.WithRepository(r.Name())
+	if timestampURL != "" {
+		client, err := timestampClient(timestampURL)
+		if err != nil {
+			signer.Close()
+			return nil, err
+		}
+		signer = signer.WithTimestampAuthority(timestampURL, client)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		signer.Close()
+		return nil, err
+	}
+	if cfg.Signing.KeysRef != "" {
+		signer = signer.WithKeysRef(cfg.Signing.KeysRef)
+	}
+	return signer, nil
+}
+
+func runSign(ctx context.Context) error {
 	r, err := oci.NewRepository(signOpts.tag)
 	if err != nil {
 		return err
 	}
+	// Signatures are attached to the layout the tag is read from, which system overlays and the
+	// cache are not meant to be
+	if r.ReadOnly() {
+		if r.InCache() {
+			return fmt.Errorf("%s is only cached, pull it into local storage to sign it", r)
+		}
+		return fmt.Errorf("%s is provided by read-only system storage and cannot be signed", r)
+	}
 
-	signer, err := signature.NewSignerFromKeyDir(signOpts.key)
+	keys, err := signingKeysFor(signOpts.keys, r)
 	if err != nil {
 		return err
 	}
+	timestampURL, err := timestampURLFor(signOpts.timestampURL)
+	if err != nil {
+		return err
+	}
+	signers := make([]*signature.Signer, 0, len(keys))
+	for _, key := range keys {
+		if !signature.SigningKeyExists(key) {
+			return fmt.Errorf("signing key %q not found, run 'kubectl mft key generate' to create a key pair", key)
+		}
+		signer, err := newSigner(r, key, timestampURL)
+		if err != nil {
+			return err
+		}
+		defer signer.Close()
+		signers = append(signers, signer)
+	}
 
-	result, err := signer.Sign(ctx, r.LayoutPath(), r.Tag())
+	if signOpts.output != "" {
+		if len(signers) > 1 {
+			return fmt.Errorf("--%s accepts a single signing key", OutputFlag)
+		}
+		return signDetached(ctx, r, signers[0], signOpts.output)
+	}
+
+	// The signatures are committed together, so that a failing key leaves the tag unchanged
+	staged, err := r.StageSign(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to sign manifest: %w", err)
+		return err
 	}
+	defer staged.Discard()
+	var digests []string
+	for i, signer := range signers {
+		result, err := signer.Sign(ctx, staged.LayoutPath(), r.Tag())
+		if err != nil {
+			return fmt.Errorf("failed to sign manifest with key %q: %w", keys[i], err)
+		}
+		digests = append(digests, result.Digest)
+	}
+	if err := staged.Commit(ctx); err != nil {
+		return err
+	}
+
+	for _, d := range digests {
+		printResult(d, "Signed %s (signature digest: %s)\n", r, d)
+	}
+	return nil
+}
 
-	fmt.Printf("Signed %s (signature digest: %s)\n", r.Tag(), result.Digest)
+// signDetached writes a detached signature of the manifest to path.
+func signDetached(ctx context.Context, r *oci.Repository, signer *signature.Signer, path string) error {
+	sig, err := signer.SignDetached(ctx, r.LayoutPath(), r.Tag())
+	if err != nil {
+		return err
+	}
+	data, err := sig.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write detached signature: %w", err)
+	}
+	printResult(path, "Signed %s (detached signature: %s)\n", r, path)
 	return nil
 }
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+	"github.com/chez-shanpu/kubectl-mft/internal/trust"
+)
+
+const (
+	// RequireSignaturesFlag requires signatures by several distinct trusted keys
+	RequireSignaturesFlag = "require-signatures"
+	// TrustedKeysFlag restricts verification to the named keys
+	TrustedKeysFlag = "trusted-keys"
+	// MaxSignatureAgeFlag rejects manifests signed too long ago
+	MaxSignatureAgeFlag = "max-signature-age"
+)
+
+const (
+	requireSignaturesUsage = "Require signatures by at least this many distinct trusted keys"
+	trustedKeysUsage       = "Names of the keys in the key directory to verify with, instead of all keys trusted for the repository"
+	maxSignatureAgeUsage   = "Reject manifests whose signatures are older than this, e.g. 90d or 12h"
+)
+
+// requireSignatures, trustedKeys, and maxSignatureAge are set by the flags of the commands
+// that verify pulled manifests
+var (
+	requireSignatures int
+	trustedKeys       []string
+	maxSignatureAge   string
+)
+
+// restrictTrustedKeys returns the keys given by --trusted-keys after checking that the trust
+// policy trusts them for the repository, or without a rule for it, that no key is scoped to
+// other repositories by --fetch-keys, so the flag can only narrow the trusted keys.
+func restrictTrustedKeys(policy *trust.Policy, repository string) ([]string, error) {
+	rule := policy.RuleFor(repository)
+	if rule == nil {
+		scopes, err := trust.LoadScopes()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range trustedKeys {
+			if !scopes.Allows(name, repository) {
+				return nil, fmt.Errorf("--%s: key %q is only trusted for the repositories it was fetched for, not %s", TrustedKeysFlag, name, repository)
+			}
+		}
+		return trustedKeys, nil
+	}
+	for _, name := range trustedKeys {
+		if !slices.Contains(rule.Keys, name) {
+			return nil, fmt.Errorf("--%s: key %q is not trusted for %s by the trust policy rule %q", TrustedKeysFlag, name, repository, rule.Repository)
+		}
+	}
+	return trustedKeys, nil
+}
+
+// strictVerificationFlag returns the first flag set among --require-signatures, --trusted-keys,
+// --max-signature-age, and --tofu, which ask for more than a signature by any trusted key, or
+// an empty string if none is set.
+func strictVerificationFlag() string {
+	switch {
+	case requireSignatures != 0:
+		return RequireSignaturesFlag
+	case len(trustedKeys) > 0:
+		return TrustedKeysFlag
+	case maxSignatureAge != "":
+		return MaxSignatureAgeFlag
+	case tofu:
+		return TOFUFlag
+	}
+	return ""
+}
+
+// signatureRequirements returns the requirements set by --require-signatures and
+// --max-signature-age.
+func signatureRequirements() (signature.Requirements, error) {
+	if requireSignatures < 0 {
+		return signature.Requirements{}, fmt.Errorf("--%s must not be negative", RequireSignaturesFlag)
+	}
+	maxAge, err := parseAge(maxSignatureAge)
+	if err != nil {
+		return signature.Requirements{}, fmt.Errorf("invalid --%s: %w", MaxSignatureAgeFlag, err)
+	}
+	return signature.Requirements{MinSignatures: requireSignatures, MaxAge: maxAge}, nil
+}
+
+// checkSignatureRequirements checks the signatures of the verified manifest r against
+// --require-signatures and --max-signature-age.
+func checkSignatureRequirements(ctx context.Context, r *oci.Repository, verifier *signature.Verifier) error {
+	req, err := signatureRequirements()
+	if err != nil || req.IsZero() {
+		return err
+	}
+	_, results, err := verifier.Inspect(ctx, r.LayoutPath(), r.Tag())
+	if err != nil {
+		return err
+	}
+	if err := req.Check(results, time.Now()); err != nil {
+		return withExitCode(ExitSignature, fmt.Errorf("signature requirements of %s not met: %w", r, err))
+	}
+	return nil
+}
+
+// parseAge parses a duration, which unlike time.ParseDuration may also be given in days,
+// e.g. 90d.
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a duration", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("%q is not a duration", s)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%q is not a positive duration", s)
+	}
+	return d, nil
+}
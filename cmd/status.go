@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/cluster"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type StatusOpts struct {
+	tag    string
+	output string
+}
+
+var statusOpts StatusOpts
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	flag := statusCmd.Flags()
+	flag.StringVarP(&statusOpts.output, OutputFlag, OutputShortFlag, "table", "Output format (table, json, yaml)")
+}
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status <tag>",
+	Short: "Show the cluster status of the resources in a manifest",
+	Long: `Status reports, for every resource in a locally stored manifest, whether it exists in
+the current Kubernetes cluster, whether it still matches the stored manifest, and whether
+it is healthy. Cluster state is read with 'kubectl get'.
+
+A resource is out of sync when a field set in the stored manifest has a different live
+value. Fields added by the cluster, such as defaults and status, are not compared.
+For bundles, the resources of every member are reported.
+
+Output formats:
+  - table: Human-readable table format (default)
+  - json:  JSON format
+  - yaml:  YAML format
+
+Examples:
+  # Show the status of an applied manifest
+  kubectl mft status myapp:v1.0.0
+
+  # Show the status as JSON for dashboards
+  kubectl mft status ghcr.io/myorg/manifests:v1.0.0 -o json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		statusOpts.tag = args[0]
+		return runStatus(cmd.Context())
+	},
+}
+
+func runStatus(ctx context.Context) error {
+	tag, err := resolveTag(ctx, statusOpts.tag, false)
+	if err != nil {
+		return err
+	}
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+
+	docs, err := artifactDocuments(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	var statuses []*mft.ResourceStatus
+	for _, d := range docs {
+		if d.APIVersion == "" || d.Kind == "" {
+			continue
+		}
+		s, err := resourceStatus(ctx, d)
+		if err != nil {
+			return err
+		}
+		statuses = append(statuses, s)
+	}
+
+	return mft.NewStatusResult(tag, statuses).Print(mft.ListOutput(statusOpts.output))
+}
+
+func resourceStatus(ctx context.Context, d *manifest.Document) (*mft.ResourceStatus, error) {
+	s := &mft.ResourceStatus{Kind: d.Kind, Namespace: d.Namespace, Name: d.Name}
+
+	live, diffs, err := compareLive(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	health, msg := cluster.Assess(live)
+	s.Health, s.Message = string(health), msg
+	if live == nil {
+		return s, nil
+	}
+	s.Exists = true
+	if ns, ok := cluster.NamespaceOf(live); ok {
+		s.Namespace = ns
+	}
+
+	s.InSync = len(diffs) == 0
+	for _, diff := range diffs {
+		s.Drift = append(s.Drift, diff.String())
+	}
+	if !s.InSync && s.Message == "" {
+		s.Message = fmt.Sprintf("%d field(s) differ: %s", len(diffs), diffs[0].Path)
+	}
+	return s, nil
+}
+
+// compareLive fetches the live object of d and compares it with the stored manifest.
+// The returned object is nil if the resource does not exist in the cluster.
+func compareLive(ctx context.Context, d *manifest.Document) (cluster.Object, []cluster.Difference, error) {
+	live, err := cluster.Get(ctx, d)
+	if err != nil || live == nil {
+		return nil, nil, err
+	}
+	desired, err := cluster.Desired(d)
+	if err != nil {
+		return nil, nil, err
+	}
+	return live, cluster.Compare(desired, live), nil
+}
+
+// artifactDocuments returns the resources of a locally stored manifest.
+// For a bundle, the resources of every member are returned in dependency order.
+func artifactDocuments(ctx context.Context, r *oci.Repository) ([]*manifest.Document, error) {
+	isBundle, err := mft.IsBundle(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := []*oci.Repository{r}
+	if isBundle {
+		bundle, err := mft.Bundle(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		repos = repos[:0]
+		for _, m := range bundle.Members() {
+			member, err := r.MemberRepository(m)
+			if err != nil {
+				return nil, err
+			}
+			repos = append(repos, member)
+		}
+	}
+
+	var docs []*manifest.Document
+	for _, repo := range repos {
+		res, err := mft.Dump(ctx, repo)
+		if err != nil {
+			return nil, err
+		}
+		d, err := manifest.Read(res)
+		res.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse manifest %s: %w", repo, err)
+		}
+		docs = append(docs, d...)
+	}
+	return docs, nil
+}
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type SummarizeOpts struct {
+	tag    string
+	output string
+}
+
+var summarizeOpts SummarizeOpts
+
+func init() {
+	rootCmd.AddCommand(summarizeCmd)
+
+	flag := summarizeCmd.Flags()
+	flag.StringVarP(&summarizeOpts.output, OutputFlag, OutputShortFlag, "table", "Output format (table, json, yaml)")
+}
+
+// summarizeCmd represents the summarize command
+var summarizeCmd = &cobra.Command{
+	Use:   "summarize <tag>",
+	Short: "Summarize what a manifest deploys",
+	Long: `Summarize prints a summary of the resources in a locally stored manifest, for reviewers
+approving a deployment artifact: the number of resources by kind, the namespaces they are
+created in, the container images used, the CustomResourceDefinitions introduced, the Roles and
+bindings granting RBAC permissions, and the replicas and resource requests and limits of the
+workloads.
+
+Totals multiply the requests and limits of a pod by its replicas, counting one pod per
+DaemonSet. Init containers are not counted. For bundles, the resources of every member
+are summarized together.
+
+Output formats:
+  - table: Human-readable summary (default)
+  - json:  JSON format
+  - yaml:  YAML format
+
+Examples:
+  # Summarize a manifest for review
+  kubectl mft summarize ghcr.io/myorg/manifests:v1.0.0
+
+  # Summarize as JSON for an approval bot
+  kubectl mft summarize ghcr.io/myorg/manifests:v1.0.0 -o json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		summarizeOpts.tag = args[0]
+		return runSummarize(cmd.Context())
+	},
+}
+
+func runSummarize(ctx context.Context) error {
+	tag, err := resolveTag(ctx, summarizeOpts.tag, false)
+	if err != nil {
+		return err
+	}
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+
+	docs, err := artifactDocuments(ctx, r)
+	if err != nil {
+		return err
+	}
+	summary, err := manifest.Summarize(docs)
+	if err != nil {
+		return err
+	}
+	return mft.NewSummaryResult(tag, summary).Print(mft.ListOutput(summarizeOpts.output))
+}
@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+}
+
+// tagCmd represents the tag command group
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Manage the tags of a repository",
+	Long: `Manage the tags of a single repository in local storage or in an OCI registry.
+
+Examples:
+  # List the local tags of a repository
+  kubectl mft tag ls myapp
+
+  # List the tags of a repository in the registry
+  kubectl mft tag ls ghcr.io/myorg/manifests --remote`,
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type TagLsOpts struct {
+	repository string
+	output     string
+	remote     bool
+	digests    bool
+	pageSize   int
+}
+
+var tagLsOpts TagLsOpts
+
+func init() {
+	tagCmd.AddCommand(tagLsCmd)
+
+	flag := tagLsCmd.Flags()
+	flag.StringVarP(&tagLsOpts.output, OutputFlag, OutputShortFlag, "table", "Output format (table, json, yaml)")
+	flag.BoolVar(&tagLsOpts.remote, "remote", false, "List the tags in the OCI registry instead of local storage")
+	flag.BoolVar(&tagLsOpts.digests, "digests", false, "Show the manifest digest of each tag")
+	flag.IntVar(&tagLsOpts.pageSize, "page-size", 0, "Number of tags requested per page from the registry (default: registry default)")
+}
+
+// tagLsCmd represents the tag ls command
+var tagLsCmd = &cobra.Command{
+	Use:     "ls <repository>",
+	Aliases: []string{"list"},
+	Short:   "List the tags of a repository",
+	Long: `List the tags of a single repository.
+
+Tags are read from local storage by default. With --remote, they are listed from the
+OCI registry, following the registry's pagination until all tags are retrieved.
+Semantic version tags are sorted by version, followed by the remaining tags in lexical order.
+
+With --digests, the manifest digest of each tag is shown. For remote repositories this
+resolves every tag with an additional request.
+
+Examples:
+  # List local tags
+  kubectl mft tag ls myapp
+
+  # List remote tags with their digests
+  kubectl mft tag ls ghcr.io/myorg/manifests --remote --digests
+
+  # List remote tags as JSON, 100 tags per request
+  kubectl mft tag ls ghcr.io/myorg/manifests --remote --page-size 100 -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tagLsOpts.repository = args[0]
+		return runTagLs(cmd.Context())
+	},
+}
+
+func runTagLs(ctx context.Context) error {
+	r, err := oci.NewRepository(tagLsOpts.repository)
+	if err != nil {
+		return err
+	}
+
+	res, err := mft.Tags(ctx, r, mft.TagsOptions{
+		Remote:   tagLsOpts.remote,
+		Digests:  tagLsOpts.digests,
+		PageSize: tagLsOpts.pageSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	res.Sort()
+	if quiet && tagLsOpts.output == string(mft.ListTable) {
+		var ids []string
+		for _, t := range res.Items() {
+			ids = append(ids, t.Tag)
+		}
+		printIDs(ids)
+		return nil
+	}
+	return res.Print(mft.ListOutput(tagLsOpts.output))
+}
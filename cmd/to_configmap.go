@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type ToConfigMapOpts struct {
+	tag       string
+	name      string
+	namespace string
+	key       string
+	output    string
+}
+
+var toConfigMapOpts ToConfigMapOpts
+
+func init() {
+	rootCmd.AddCommand(toConfigMapCmd)
+
+	flag := toConfigMapCmd.Flags()
+	flag.StringVar(&toConfigMapOpts.name, "name", "", "Name of the ConfigMap")
+	flag.StringVarP(&toConfigMapOpts.namespace, "namespace", "n", "", "Namespace of the ConfigMap")
+	flag.StringVar(&toConfigMapOpts.key, "key", manifest.ConfigMapKey, "Data key holding the manifest content")
+	flag.StringVarP(&toConfigMapOpts.output, OutputFlag, OutputShortFlag, "", "Output file path (default: stdout)")
+
+	_ = toConfigMapCmd.MarkFlagRequired("name")
+}
+
+// toConfigMapCmd represents the to-configmap command
+var toConfigMapCmd = &cobra.Command{
+	Use:   "to-configmap <tag>",
+	Short: "Convert a manifest into a ConfigMap",
+	Long: `To-configmap prints a ConfigMap holding the content of a manifest in local storage,
+for operators that read manifests from ConfigMaps.
+
+The ConfigMap is annotated with the artifact (kubectl-mft.io/source), its manifest
+digest (kubectl-mft.io/digest), and the digest of the content
+(kubectl-mft.io/content-digest), so that 'from-configmap' can detect modifications.
+ConfigMaps hold at most 1MiB, larger manifests cannot be converted.
+
+Examples:
+  # Print a ConfigMap named app-manifests
+  kubectl mft to-configmap ghcr.io/myorg/manifests/app:v1.0.0 --name app-manifests
+
+  # Create it in the cluster
+  kubectl mft to-configmap myapp:v1.0.0 --name app-manifests -n operators | kubectl apply -f -`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		toConfigMapOpts.tag = args[0]
+		return runToConfigMap(cmd.Context())
+	},
+}
+
+func runToConfigMap(ctx context.Context) error {
+	tag, err := resolveTag(ctx, toConfigMapOpts.tag, false)
+	if err != nil {
+		return err
+	}
+
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+	manifestDigest, err := r.Digest(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := mft.Dump(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+	content, err := io.ReadAll(res)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	cm, err := manifest.NewConfigMap(toConfigMapOpts.name, toConfigMapOpts.namespace, toConfigMapOpts.key,
+		content, r.Name()+":"+r.Tag(), manifestDigest)
+	if err != nil {
+		return err
+	}
+	data, err := cm.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to marshal ConfigMap: %w", err)
+	}
+
+	if toConfigMapOpts.output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(toConfigMapOpts.output, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", toConfigMapOpts.output, err)
+	}
+	fmt.Println(toConfigMapOpts.output)
+	return nil
+}
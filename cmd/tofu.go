@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/trust"
+)
+
+// TOFUFlag pins the signing keys of a repository on first pull
+const TOFUFlag = "tofu"
+
+const tofuUsage = "Trust on first use: pin the keys that signed the first pulled manifest of a repository and reject manifests signed by other keys later"
+
+// tofu is set by --tofu on the commands that verify pulled manifests
+var tofu bool
+
+// pinsMu serializes updates of the pinned signers by concurrent pulls
+var pinsMu sync.Mutex
+
+// checkSignerPin checks the keys that signed the verified manifest r against the keys its
+// repository is pinned to, pinning them if the repository is pulled for the first time.
+func checkSignerPin(ctx context.Context, r *oci.Repository) error {
+	verifier, err := newVerifier(r)
+	if err != nil {
+		return err
+	}
+	fingerprints, err := verifier.SignerFingerprints(ctx, r.LayoutPath(), r.Tag())
+	if err != nil {
+		return err
+	}
+
+	pinsMu.Lock()
+	defer pinsMu.Unlock()
+	pins, err := trust.LoadPins()
+	if err != nil {
+		return err
+	}
+	pinned, err := pins.Check(r.Name(), fingerprints)
+	if err != nil {
+		return withExitCode(ExitSignature, fmt.Errorf("%w; if the publisher rotated its key, run 'kubectl mft trust unpin %s' and pull again to pin the new key", err, r.Name()))
+	}
+	if !pinned {
+		return nil
+	}
+	if err := trust.SavePins(pins); err != nil {
+		return err
+	}
+	infof("Pinned %s to key(s) %s\n", r.Name(), strings.Join(fingerprints, ", "))
+	return nil
+}
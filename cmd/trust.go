@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(trustCmd)
+}
+
+// trustCmd represents the trust command group
+var trustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Manage trust bundles and pinned signing keys",
+	Long: `Export and import trust bundles for verifying manifests on air-gapped machines.
+
+A trust bundle is a single file holding the trusted public keys and the trust policy,
+signed by a trust-root key that is kept apart from the keys verifying manifests. Importing
+it reproduces the verification setup of the exporting machine, so verification works
+identically offline.
+
+The trust policy restricts which keys may sign the manifests of a repository. It is stored
+as trust-policy.yaml in the configuration directory (see 'kubectl mft env'):
+
+  rules:
+    - repository: registry.company.com/prod/*
+      keys: [prod-release]
+
+Pulling with --tofu pins the keys that signed the first pulled manifest of a repository,
+and later manifests signed by other keys are rejected. 'trust pins' lists the pins and
+'trust unpin' removes one, so the next pull pins the keys again.
+
+Examples:
+  # Export all public keys and the installed policy, signed with the default key
+  kubectl mft trust export -o trust.json
+
+  # Import a bundle signed by the installed trust-root key
+  kubectl mft trust import trust.json
+
+  # Accept the new key of a publisher that rotated its signing key
+  kubectl mft trust unpin registry.company.com/team/app`,
+}
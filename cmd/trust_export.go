@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+	"github.com/chez-shanpu/kubectl-mft/internal/trust"
+)
+
+type TrustExportOpts struct {
+	output   string
+	key      string
+	keyNames []string
+	policy   string
+}
+
+var trustExportOpts TrustExportOpts
+
+func init() {
+	trustCmd.AddCommand(trustExportCmd)
+
+	flag := trustExportCmd.Flags()
+	flag.StringVarP(&trustExportOpts.output, OutputFlag, OutputShortFlag, "", "Output file path (default: stdout)")
+	flag.StringVar(&trustExportOpts.key, "key", "", "Trust-root key of the key directory that signs the bundle (default: signing.defaultKey from config, or \"default\")")
+	flag.StringSliceVar(&trustExportOpts.keyNames, "trusted-key", nil, "Public key to include in the bundle, can be repeated (default: all public keys except the trust-root key)")
+	flag.StringVar(&trustExportOpts.policy, "policy", "", "Trust policy file to include (default: the installed trust policy)")
+}
+
+// trustExportCmd represents the trust export command
+var trustExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a signed trust bundle",
+	Long: `Export the trusted public keys and the trust policy as a single trust bundle for
+air-gapped machines, signed by a trust-root key.
+
+The trust-root key only signs trust bundles. Importing machines keep it apart from the key
+directory, so it never verifies manifests, and it must not be one of the included keys.
+Every key referenced by the trust policy must be included in the bundle.
+
+Examples:
+  # Export all public keys and the installed trust policy
+  kubectl mft trust export -o trust.json
+
+  # Export selected keys with a policy, signed by the trust-root key
+  kubectl mft trust export --trusted-key prod-release --trusted-key staging \
+    --policy trust-policy.yaml --key trust-root -o trust.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTrustExport(cmd.Context())
+	},
+}
+
+func runTrustExport(ctx context.Context) error {
+	key := trustExportOpts.key
+	if key == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		key = cfg.Signing.DefaultKey
+		if key == "" {
+			key = config.DefaultSigningKey
+		}
+	}
+	if !signature.PrivateKeyExists(key) {
+		return fmt.Errorf("trust-root key %q not found in the key directory, run 'kubectl mft key generate' to create a key pair", key)
+	}
+
+	keyNames := trustExportOpts.keyNames
+	if len(keyNames) == 0 {
+		keys, err := signature.ListKeys()
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if k.Type == "public" && k.Name != key {
+				keyNames = append(keyNames, k.Name)
+			}
+		}
+	}
+
+	policy, err := loadTrustPolicy(trustExportOpts.policy)
+	if err != nil {
+		return err
+	}
+
+	bundle, err := trust.NewBundle(key, keyNames, *policy)
+	if err != nil {
+		return err
+	}
+
+	signer, err := signature.NewSignerFromKeyDir(key)
+	if err != nil {
+		return err
+	}
+
+	data, err := bundle.Sign(ctx, signer)
+	if err != nil {
+		return err
+	}
+
+	if trustExportOpts.output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(trustExportOpts.output, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write trust bundle: %w", err)
+	}
+	printResult("", "Exported trust bundle with %d key(s) to %s (trust-root fingerprint: %s)\n", len(bundle.Keys), trustExportOpts.output, bundle.Root.Fingerprint)
+	return nil
+}
+
+// loadTrustPolicy reads the trust policy from path, or the installed policy if path is empty.
+func loadTrustPolicy(path string) (*trust.Policy, error) {
+	if path == "" {
+		return trust.LoadPolicy()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust policy: %w", err)
+	}
+	policy, err := trust.ParsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trust policy %s: %w", path, err)
+	}
+	return policy, nil
+}
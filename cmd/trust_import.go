@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/trust"
+)
+
+type TrustImportOpts struct {
+	file        string
+	fingerprint string
+}
+
+var trustImportOpts TrustImportOpts
+
+func init() {
+	trustCmd.AddCommand(trustImportCmd)
+
+	flag := trustImportCmd.Flags()
+	flag.StringVar(&trustImportOpts.fingerprint, "fingerprint", "", "Trust the bundle if it is signed by its trust-root key with this fingerprint")
+}
+
+// trustImportCmd represents the trust import command
+var trustImportCmd = &cobra.Command{
+	Use:   "import <bundle-file>",
+	Short: "Import a signed trust bundle",
+	Long: `Import a trust bundle created by 'kubectl mft trust export'.
+
+The bundle must be signed by the trust-root key installed by a previous import. To bootstrap
+trust, or to switch to another trust-root key, pass the fingerprint of the bundle's trust-root
+key, obtained out of band, with --fingerprint. A bundle created before the installed one is
+rejected, so an older bundle cannot restore removed keys or a looser policy.
+
+The public keys of the bundle are imported into the key directory, replacing keys of the
+same name. Keys imported by the previously installed bundle and dropped by this one are
+removed, so that a revoked key stops verifying; other keys are kept. The installed trust policy and trust-root key are replaced
+by those of the bundle. The trust-root key is kept apart from the key directory and never
+verifies manifests.
+
+Examples:
+  # Import a bundle signed by the installed trust-root key
+  kubectl mft trust import trust.json
+
+  # Bootstrap trust on an air-gapped machine
+  kubectl mft trust import trust.json --fingerprint sha256:3b1f...`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		trustImportOpts.file = args[0]
+		return runTrustImport(cmd.Context())
+	},
+}
+
+func runTrustImport(ctx context.Context) error {
+	data, err := os.ReadFile(trustImportOpts.file)
+	if err != nil {
+		return fmt.Errorf("failed to read trust bundle: %w", err)
+	}
+
+	var bundle *trust.Bundle
+	if trustImportOpts.fingerprint != "" {
+		bundle, err = trust.OpenWithFingerprint(ctx, data, trustImportOpts.fingerprint)
+	} else {
+		bundle, err = trust.Open(ctx, data)
+		if errors.Is(err, trust.ErrNoRoot) {
+			err = fmt.Errorf("%w, use --fingerprint to trust the bundle's trust-root key", err)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := bundle.Install(); err != nil {
+		return err
+	}
+
+	printResult("", "Imported trust bundle created %s: %d key(s), %d policy rule(s)\n",
+		bundle.Created.Format("2006-01-02 15:04:05"), len(bundle.Keys), len(bundle.Policy.Rules))
+	return nil
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/trust"
+)
+
+type TrustPinsOpts struct {
+	output string
+}
+
+var trustPinsOpts TrustPinsOpts
+
+func init() {
+	trustCmd.AddCommand(trustPinsCmd)
+
+	flag := trustPinsCmd.Flags()
+	flag.StringVarP(&trustPinsOpts.output, OutputFlag, OutputShortFlag, "table", "Output format (table, json)")
+}
+
+// trustPinsCmd represents the trust pins command
+var trustPinsCmd = &cobra.Command{
+	Use:   "pins",
+	Short: "List the signing keys pinned by --tofu",
+	Long: `List the repositories whose signing keys were pinned by pulling with --tofu.
+
+Pins are stored as known-signers.yaml in the configuration directory (see 'kubectl mft env').
+
+Examples:
+  kubectl mft trust pins
+
+  # List pins as JSON
+  kubectl mft trust pins -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTrustPins()
+	},
+}
+
+func runTrustPins() error {
+	pins, err := trust.LoadPins()
+	if err != nil {
+		return err
+	}
+
+	switch trustPinsOpts.output {
+	case "table":
+	case "json":
+		if pins.Pins == nil {
+			pins.Pins = []trust.Pin{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(pins.Pins)
+	default:
+		return fmt.Errorf("unsupported output format: %s", trustPinsOpts.output)
+	}
+
+	if len(pins.Pins) == 0 {
+		fmt.Println("No pins found")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tPINNED\tFINGERPRINTS")
+	for _, pin := range pins.Pins {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", pin.Repository, pin.Pinned.Local().Format(time.RFC3339), strings.Join(pin.Fingerprints, ","))
+	}
+	return w.Flush()
+}
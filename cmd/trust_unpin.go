@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/trust"
+)
+
+func init() {
+	trustCmd.AddCommand(trustUnpinCmd)
+}
+
+// trustUnpinCmd represents the trust unpin command
+var trustUnpinCmd = &cobra.Command{
+	Use:   "unpin <repository>",
+	Short: "Remove the signing keys pinned for a repository",
+	Long: `Remove the signing keys pinned for a repository by pulling with --tofu.
+
+The next pull of the repository with --tofu pins the keys that signed it again. Use this
+after the publisher rotated its signing key, once the new key was confirmed out of band.
+
+Examples:
+  kubectl mft trust unpin registry.company.com/team/app`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTrustUnpin(args[0])
+	},
+}
+
+func runTrustUnpin(repository string) error {
+	pinsMu.Lock()
+	defer pinsMu.Unlock()
+	pins, err := trust.LoadPins()
+	if err != nil {
+		return err
+	}
+	if !pins.Remove(repository) {
+		return fmt.Errorf("%s is not pinned", repository)
+	}
+	if err := trust.SavePins(pins); err != nil {
+		return err
+	}
+	printResult(repository, "Unpinned %s\n", repository)
+	return nil
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type UnpackOpts struct {
+	tag   string
+	dir   string
+	force bool
+}
+
+var unpackOpts UnpackOpts
+
+func init() {
+	rootCmd.AddCommand(unpackCmd)
+
+	flag := unpackCmd.Flags()
+	flag.StringVarP(&unpackOpts.dir, "dir", "d", ".", "Directory to write the resource files to, created if missing")
+	flag.BoolVar(&unpackOpts.force, ForceFlag, false, "Overwrite existing files")
+}
+
+// unpackCmd represents the unpack command
+var unpackCmd = &cobra.Command{
+	Use:   "unpack <tag>",
+	Short: "Write the resources of a manifest to a directory, one file per resource",
+	Long: `Unpack writes each YAML document of a manifest in local storage to its own file,
+so that the content can be used with tools expecting a directory of manifests.
+
+Files are named <namespace>_<kind>_<name>.yaml, or <kind>_<name>.yaml for cluster-scoped
+resources, with the kind in lower case. The documents are written unchanged. Existing
+files are not overwritten unless --force is given.
+
+The reference may be a semver range such as 'myapp:^1.2' or 'myapp:latest-semver', which
+resolves to the highest matching tag in local storage.
+
+Examples:
+  # Unpack a manifest into ./out
+  kubectl mft unpack registry.example.com/manifests/app:v1.0.0 -d ./out
+
+  # Refresh a previously unpacked directory
+  kubectl mft unpack myapp:v1.1.0 -d ./out --force`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		unpackOpts.tag = args[0]
+		return runUnpack(cmd.Context())
+	},
+}
+
+func runUnpack(ctx context.Context) error {
+	tag, err := resolveTag(ctx, unpackOpts.tag, false)
+	if err != nil {
+		return err
+	}
+
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+
+	res, err := mft.Dump(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+	docs, err := manifest.Read(res)
+	if err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	// Check every file first, so that an existing file does not leave a partial directory
+	names := manifest.FileNames(docs)
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(unpackOpts.dir, name)
+		if unpackOpts.force {
+			continue
+		}
+		if _, err := os.Stat(paths[i]); err == nil {
+			return fmt.Errorf("%s already exists, use --force to overwrite", paths[i])
+		}
+	}
+
+	if err := os.MkdirAll(unpackOpts.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", unpackOpts.dir, err)
+	}
+	for i, d := range docs {
+		if err := os.WriteFile(paths[i], d.Raw, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", paths[i], err)
+		}
+		debugf("Wrote %s to %s\n", d, paths[i])
+	}
+
+	if quiet {
+		printIDs(paths)
+		return nil
+	}
+	fmt.Printf("Unpacked %d resources of %s to %s\n", len(docs), tag, unpackOpts.dir)
+	return nil
+}
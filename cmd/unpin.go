@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type UnpinOpts struct {
+	tag string
+}
+
+var unpinOpts UnpinOpts
+
+func init() {
+	rootCmd.AddCommand(unpinCmd)
+}
+
+// unpinCmd represents the unpin command
+var unpinCmd = &cobra.Command{
+	Use:   "unpin <tag>",
+	Short: "Allow garbage collection of a pinned manifest",
+	Long: `Unpin a tag pinned with 'kubectl mft pin', so that gc may remove it again and, if it is
+cached, it expires after the cache TTL. Unpinning a tag that is not pinned does nothing.
+
+Examples:
+  kubectl mft unpin ghcr.io/myorg/app:v1.4.2`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		unpinOpts.tag = args[0]
+		return runUnpin(cmd.Context())
+	},
+}
+
+func runUnpin(ctx context.Context) error {
+	r, err := oci.NewRepository(unpinOpts.tag)
+	if err != nil {
+		return err
+	}
+	if err := r.SetPinned(ctx, false); err != nil {
+		return err
+	}
+	printResult(r.String(), "Unpinned %s\n", r)
+	return nil
+}
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/cluster"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/validate"
+)
+
+type ValidateOpts struct {
+	tag            string
+	filePath       string
+	againstCluster bool
+	output         string
+}
+
+var validateOpts ValidateOpts
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	flag := validateCmd.Flags()
+	flag.StringVarP(&validateOpts.filePath, FileFlag, FileShortFlag, "", "Path to the manifest file to validate")
+	flag.BoolVar(&validateOpts.againstCluster, "against-cluster", false, "Validate against the APIs and CRDs served by the current cluster")
+	flag.BoolVar(&offlineValidation, OfflineFlag, false, offlineUsage)
+	flag.StringVarP(&validateOpts.output, OutputFlag, OutputShortFlag, "", "Output format of validation failures (github)")
+}
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate [<tag>]",
+	Short: "Validate a manifest file or a stored manifest",
+	Long: `Validate checks a manifest file, or a locally stored manifest, the same way pack does:
+against the built-in Kubernetes schemas, the registered CRD schemas, and the configured
+schema catalogs.
+
+With --against-cluster, the manifest is validated against the OpenAPI schemas of the
+current cluster instead, using 'kubectl create --dry-run=client --validate=strict'. Only
+the APIs and CRDs installed in that cluster are accepted, which catches resources that pass
+offline validation but would be rejected there, such as kinds of missing CRDs or fields
+unknown to the cluster's Kubernetes version. Nothing is created in the cluster.
+
+With -o github, validation failures and warnings are also printed as GitHub Actions
+annotations. Failures of a manifest file are located at the offending line.
+
+An invalid manifest makes the command exit with status 2.
+
+Examples:
+  # Validate a manifest file
+  kubectl mft validate -f deployment.yaml
+
+  # Validate a stored manifest against the current cluster
+  kubectl mft validate myapp:v1.0.0 --against-cluster
+
+  # Annotate a pull request with validation failures in a GitHub Actions workflow
+  kubectl mft validate -f deployment.yaml -o github`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			validateOpts.tag = args[0]
+		}
+		return runValidate(cmd.Context())
+	},
+}
+
+func runValidate(ctx context.Context) error {
+	if (validateOpts.tag == "") == (validateOpts.filePath == "") {
+		return fmt.Errorf("specify either a tag or --%s", FileFlag)
+	}
+	if validateOpts.againstCluster && offlineValidation {
+		return fmt.Errorf("--against-cluster cannot be combined with --%s", OfflineFlag)
+	}
+	if err := checkOutputGitHub(validateOpts.output); err != nil {
+		return err
+	}
+	annotate := validateOpts.output == outputGitHub
+	fromFile := validateOpts.filePath != ""
+
+	name, data, err := validationInput(ctx)
+	if err != nil {
+		return err
+	}
+
+	if validateOpts.againstCluster {
+		problems, err := cluster.Validate(ctx, data)
+		if err != nil {
+			return err
+		}
+		if len(problems) > 0 {
+			if annotate {
+				for _, p := range problems {
+					a := annotation{level: "error", title: "Cluster validation failed", msg: p}
+					if fromFile {
+						a.file = validateOpts.filePath
+					}
+					a.print()
+				}
+			}
+			return withExitCode(ExitValidation, fmt.Errorf("manifest validation against the cluster failed:\n  %s", strings.Join(problems, "\n  ")))
+		}
+		printResult(name, "Validated %s against the cluster: manifest is valid\n", name)
+		return nil
+	}
+
+	path := validateOpts.filePath
+	if path == "" {
+		// kubeconform reads manifests from files
+		dir, err := os.MkdirTemp("", "kubectl-mft-validate-")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		path = filepath.Join(dir, "manifest.yaml")
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+	}
+	opts, err := validationOptions(nil)
+	if err != nil {
+		return err
+	}
+	if annotate {
+		opts = append(opts, annotateWarnings(fromFile))
+	}
+	if err := validate.ValidateManifest(path, opts...); err != nil {
+		if annotate {
+			annotateFailure("Manifest validation failed", err, fromFile)
+		}
+		return fmt.Errorf("manifest validation failed: %w", err)
+	}
+	printResult(name, "Validated %s: manifest is valid\n", name)
+	return nil
+}
+
+// validationInput returns the name and content of the manifest to validate.
+// For a bundle, the resources of every member are validated together.
+func validationInput(ctx context.Context) (string, []byte, error) {
+	if validateOpts.filePath != "" {
+		data, err := os.ReadFile(validateOpts.filePath)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read manifest file: %w", err)
+		}
+		return validateOpts.filePath, data, nil
+	}
+
+	tag, err := resolveTag(ctx, validateOpts.tag, false)
+	if err != nil {
+		return "", nil, err
+	}
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return "", nil, err
+	}
+	docs, err := artifactDocuments(ctx, r)
+	if err != nil {
+		return "", nil, err
+	}
+	return tag, manifest.Join(docs), nil
+}
@@ -6,21 +6,36 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"time"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/spf13/cobra"
 
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
 	"github.com/chez-shanpu/kubectl-mft/internal/oci"
 	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+	"github.com/chez-shanpu/kubectl-mft/internal/source"
+	"github.com/chez-shanpu/kubectl-mft/internal/trust"
 )
 
 type VerifyOpts struct {
-	tag string
+	tag       string
+	signature string
+	output    string
 }
 
 var verifyOpts VerifyOpts
 
 func init() {
 	rootCmd.AddCommand(verifyCmd)
+
+	flag := verifyCmd.Flags()
+	flag.BoolVar(&noVerifyCache, NoCacheFlag, false, noCacheUsage)
+	flag.StringVar(&verifyOpts.signature, "signature", "", "Verify against a detached signature file created by 'kubectl mft sign --output' instead of the attached signatures")
+	flag.StringVarP(&verifyOpts.output, OutputFlag, OutputShortFlag, "", "Output format (github to annotate failures, json for a report of each signature)")
 }
 
 // verifyCmd represents the verify command
@@ -30,14 +45,40 @@ var verifyCmd = &cobra.Command{
 	Long: `Verify the signature of a previously pulled or packed manifest in local storage.
 
 At least one public key must be imported using 'kubectl mft key import' for verification.
+Successful verifications are cached for 24 hours per manifest digest and set of trusted keys;
+use --no-cache to verify again regardless.
+If a rule of the trust policy (see 'kubectl mft trust') matches the repository, only its keys are trusted.
+With 'signing.trustGPG: true' in config.yaml, PGP signatures are verified against the local
+GPG keyring, which requires the signing key to be fully or ultimately trusted and neither
+revoked nor expired. Otherwise PGP signatures are not trusted.
+
+With --signature, the manifest is verified against a detached signature file written by
+'kubectl mft sign --output' instead of the signatures attached to it. The cache is not used.
+
+If the manifest was packed with --expected-sha256, its content is also checked against
+the pinned digest.
+
+With -o github, a verification failure is also printed as a GitHub Actions annotation.
+With -o json, every signature is verified on its own and a report is printed: the digest,
+annotations, and verifying key fingerprint of each signature, whether it verified or why not,
+the trusted keys, and the decision with its reason. The cache is not used, and a denied
+manifest is reported as well.
+A signature or pinned digest that does not verify makes the command exit with status 3.
 
 Examples:
   # Verify a local manifest
   kubectl mft verify myapp:v1.0.0
 
   # Verify a manifest with registry reference
-  kubectl mft verify registry.example.com/manifests/app:v1.0.0`,
-	Args: cobra.ExactArgs(1),
+  kubectl mft verify registry.example.com/manifests/app:v1.0.0
+
+  # Log why an admission pipeline accepted or rejected a manifest
+  kubectl mft verify myapp:v1.0.0 -o json
+
+  # Verify against a detached signature received separately
+  kubectl mft verify myapp:v1.0.0 --signature myapp.sig`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		verifyOpts.tag = args[0]
 		return runVerify(cmd.Context())
@@ -45,7 +86,24 @@ Examples:
 }
 
 func runVerify(ctx context.Context) error {
-	if !signature.PublicKeysExist() {
+	if verifyOpts.output == outputJSON {
+		if verifyOpts.signature != "" {
+			return fmt.Errorf("-o json cannot be combined with --signature")
+		}
+		return verify(ctx)
+	}
+	if err := checkOutputGitHub(verifyOpts.output); err != nil {
+		return err
+	}
+	err := verify(ctx)
+	if err != nil && verifyOpts.output == outputGitHub {
+		annotateFailure("Signature verification failed", err, false)
+	}
+	return err
+}
+
+func verify(ctx context.Context) error {
+	if !verificationKeysExist() {
 		return fmt.Errorf("no verification keys found, run 'kubectl mft key import <file>' to import a public key")
 	}
 
@@ -53,17 +111,204 @@ func runVerify(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if verifyOpts.output == outputJSON {
+		return verifyJSON(ctx, r)
+	}
 
-	verifier, err := signature.NewVerifierFromKeyDir()
+	verifier, err := newVerifier(r)
 	if err != nil {
 		return err
 	}
 
-	err = verifier.Verify(ctx, r.LayoutPath(), r.Tag())
+	debugf("Verifying %s in %s\n", r, r.LayoutPath())
+	var timestamps []signature.Timestamp
+	if verifyOpts.signature != "" {
+		timestamps, err = verifyDetached(ctx, verifier, r, verifyOpts.signature)
+	} else if err = verifier.Verify(ctx, r.LayoutPath(), r.Tag()); err != nil {
+		err = withExitCode(ExitSignature, err)
+	} else {
+		timestamps, err = verifier.Timestamps(ctx, r.LayoutPath(), r.Tag())
+	}
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Verified %s: signature is valid\n", r.Tag())
+	pinned, err := checkPinnedDigest(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	printResult("", "Verified %s: signature is valid\n", r)
+	for _, ts := range timestamps {
+		printResult("", "Verified %s: signed no later than %s (timestamp by %s)\n", r, ts.Time.Format(time.RFC3339), ts.Authority)
+	}
+	if pinned != "" {
+		printResult("", "Verified %s: content matches pinned digest %s\n", r, pinned)
+	}
 	return nil
 }
+
+// verifyDetached verifies the manifest against the detached signature file at path and returns
+// the timestamp of the signature, if any.
+func verifyDetached(ctx context.Context, verifier *signature.Verifier, r *oci.Repository, path string) ([]signature.Timestamp, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read detached signature: %w", err)
+	}
+	sig, err := signature.ParseDetachedSignature(data)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := verifier.VerifyDetached(ctx, r.LayoutPath(), r.Tag(), sig)
+	if err != nil || ts == nil {
+		return nil, withExitCode(ExitSignature, err)
+	}
+	return []signature.Timestamp{*ts}, nil
+}
+
+// checkPinnedDigest checks the content of the manifest against the digest pinned with
+// pack --expected-sha256 and returns the pinned digest, or an empty digest if none was pinned.
+func checkPinnedDigest(ctx context.Context, r *oci.Repository) (digest.Digest, error) {
+	annotations, err := r.Annotations(ctx)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := annotations[source.AnnotationPinnedDigest]; !ok {
+		return "", nil
+	}
+	res, err := mft.Dump(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	defer res.Close()
+	data, err := io.ReadAll(res)
+	if err != nil {
+		return "", err
+	}
+	pinned, err := source.CheckPinned(annotations, data)
+	if err != nil {
+		return "", withExitCode(ExitSignature, fmt.Errorf("content of %s was modified after packing: %w", r, err))
+	}
+	return pinned, nil
+}
+
+// noVerifyCache is set by --no-cache on the commands that verify signatures
+var noVerifyCache bool
+
+const noCacheUsage = "Verify signatures without using cached verification results"
+
+// keyDirVerifier creates a Verifier trusting the keys of the key directory that are not scoped
+// to other repositories than repository, and the local GPG keyring if 'signing.trustGPG' is
+// enabled in the configuration.
+func keyDirVerifier(repository string) (*signature.Verifier, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := trust.LoadScopes()
+	if err != nil {
+		return nil, err
+	}
+	var verifier *signature.Verifier
+	if len(scopes.Scopes) == 0 {
+		verifier, err = signature.NewVerifierFromKeyDir()
+	} else {
+		verifier, err = scopedVerifier(scopes, repository)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Signing.TrustGPG {
+		verifier = verifier.WithGPG()
+	}
+	return verifier, nil
+}
+
+// scopedVerifier creates a Verifier trusting the public keys of the key directory that the
+// scopes allow for the repository.
+func scopedVerifier(scopes *trust.Scopes, repository string) (*signature.Verifier, error) {
+	keys, err := signature.ListKeys()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, k := range keys {
+		if k.Type == "public" && scopes.Allows(k.Name, repository) {
+			names = append(names, k.Name)
+		}
+	}
+	return signature.NewVerifierFromKeyNames(names)
+}
+
+// verificationKeysExist reports whether a public key exists in the key directory, or the local
+// GPG keyring holds one and 'signing.trustGPG' is enabled in the configuration.
+func verificationKeysExist() bool {
+	if signature.PublicKeysExist() {
+		return true
+	}
+	cfg, err := config.Load()
+	return err == nil && cfg.Signing.TrustGPG && signature.GPGKeysExist()
+}
+
+// newVerifier creates a Verifier for the repository that uses the verification cache unless
+// --no-cache is set. If a rule of the trust policy matches the repository, only its keys are
+// trusted, otherwise all keys of the key directory. Keys given by --trusted-keys narrow this
+// further.
+func newVerifier(r *oci.Repository) (*signature.Verifier, error) {
+	policy, err := trust.LoadPolicy()
+	if err != nil {
+		return nil, err
+	}
+	var verifier *signature.Verifier
+	if len(trustedKeys) > 0 {
+		var keys []string
+		if keys, err = restrictTrustedKeys(policy, r.Name()); err != nil {
+			return nil, err
+		}
+		verifier, err = signature.NewVerifierFromKeyNames(keys)
+	} else if rule := policy.RuleFor(r.Name()); rule != nil {
+		verifier, err = policyVerifier(rule)
+	} else {
+		verifier, err = keyDirVerifier(r.Name())
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Signing.TimestampRoots != "" {
+		roots, err := signature.LoadCertPool(cfg.Signing.TimestampRoots)
+		if err != nil {
+			return nil, err
+		}
+		verifier = verifier.WithTimestampRoots(roots)
+	}
+	if noVerifyCache {
+		return verifier, nil
+	}
+	cache, err := signature.DefaultVerifyCache()
+	if err != nil {
+		return nil, err
+	}
+	return verifier.WithCache(cache), nil
+}
+
+// policyVerifier creates a Verifier trusting the keys of the trust policy rule, and its retired
+// keys for signatures timestamped before their retirement.
+func policyVerifier(rule *trust.Rule) (*signature.Verifier, error) {
+	verifier, err := signature.NewVerifierFromKeyNames(rule.Keys)
+	if err != nil || len(rule.Retired) == 0 {
+		return verifier, err
+	}
+	retired := make([]signature.RetiredKey, 0, len(rule.Retired))
+	for _, k := range rule.Retired {
+		pub, err := signature.LoadPublicKey(k.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load retired key %q: %w", k.Key, err)
+		}
+		retired = append(retired, signature.RetiredKey{PublicKey: pub, Until: k.Until})
+	}
+	return verifier.WithRetiredKeys(retired), nil
+}
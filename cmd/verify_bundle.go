@@ -0,0 +1,294 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/imagepolicy"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+	"github.com/chez-shanpu/kubectl-mft/internal/nspolicy"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/validate"
+)
+
+type VerifyBundleOpts struct {
+	tag               string
+	kubernetesVersion string
+	defaultNamespace  string
+	skip              []string
+}
+
+var verifyBundleOpts VerifyBundleOpts
+
+func init() {
+	rootCmd.AddCommand(verifyBundleCmd)
+
+	flag := verifyBundleCmd.Flags()
+	flag.BoolVar(&offlineValidation, OfflineFlag, false, offlineUsage)
+	flag.BoolVar(&noVerifyCache, NoCacheFlag, false, noCacheUsage)
+	flag.StringVar(&verifyBundleOpts.kubernetesVersion, "kubernetes-version", "", "Only report API versions removed in this Kubernetes version or earlier, such as 1.29")
+	flag.StringVar(&verifyBundleOpts.defaultNamespace, "default-namespace", "default", "Namespace of resources without one, for the namespace policy")
+	flag.StringSliceVar(&verifyBundleOpts.skip, "skip", nil, "Checks to skip: validation, signature, policy, deprecation")
+}
+
+// verifyBundleCmd represents the verify-bundle command
+var verifyBundleCmd = &cobra.Command{
+	Use:   "verify-bundle <tag>",
+	Short: "Run every check on a manifest and print one JSON report",
+	Long: `Verify-bundle runs every check on a manifest or bundle in local storage, as a single gate
+for CI pipelines before promotion, and prints one consolidated JSON report on stdout:
+
+  validation   the resources are valid against the schemas, as with 'kubectl mft validate'
+  signature    the signature and pinned digest verify, as with 'kubectl mft verify'
+  policy       the image and namespace policies of config.yaml accept the resources, as
+               with 'kubectl mft apply'; skipped if neither is configured
+  deprecation  no resource uses an API version removed from Kubernetes
+
+All checks run even if one fails. Each is reported with its status (pass, fail, or skip) and
+its findings. Resources without a namespace are checked against the namespace policy in
+--default-namespace, since no cluster is contacted. With --kubernetes-version, only API
+versions removed in that version or earlier fail the deprecation check.
+
+The exit status is that of the first failing check: 2 for validation and deprecation, 3 for
+the signature, and 5 for the policy.
+
+Examples:
+  # Gate a manifest before promotion
+  kubectl mft verify-bundle registry.example.com/manifests/app:v1.0.0
+
+  # Only fail on APIs no longer served by the target clusters
+  kubectl mft verify-bundle myapp:v1.0.0 --kubernetes-version 1.29
+
+  # Skip the signature check of an unsigned development build
+  kubectl mft verify-bundle myapp:dev --skip signature`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		verifyBundleOpts.tag = args[0]
+		return runVerifyBundle(cmd.Context())
+	},
+}
+
+// Checks of verify-bundle, in the order they run
+const (
+	checkValidation  = "validation"
+	checkSignature   = "signature"
+	checkPolicy      = "policy"
+	checkDeprecation = "deprecation"
+)
+
+var bundleChecks = []string{checkValidation, checkSignature, checkPolicy, checkDeprecation}
+
+// Statuses of a check of verify-bundle
+const (
+	checkPass = "pass"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+// bundleReport is the report printed by verify-bundle
+type bundleReport struct {
+	Tag    string        `json:"tag"`
+	Digest string        `json:"digest"`
+	Passed bool          `json:"passed"`
+	Checks []bundleCheck `json:"checks"`
+}
+
+// bundleCheck is the result of a check of verify-bundle
+type bundleCheck struct {
+	Name     string   `json:"name"`
+	Status   string   `json:"status"`
+	Findings []string `json:"findings"`
+}
+
+// bundleChecker runs a check and returns its findings, which fail the check if not empty, or
+// whether the check does not apply. A returned error fails the check with the error as its
+// finding.
+type bundleChecker func(ctx context.Context, r *oci.Repository, docs []*manifest.Document) (findings []string, skip bool, err error)
+
+func runVerifyBundle(ctx context.Context) error {
+	checks := []struct {
+		name string
+		code int
+		run  bundleChecker
+	}{
+		{checkValidation, ExitValidation, bundleValidation},
+		{checkSignature, ExitSignature, bundleSignature},
+		{checkPolicy, ExitPolicy, bundlePolicy},
+		{checkDeprecation, ExitValidation, bundleDeprecation},
+	}
+	for _, s := range verifyBundleOpts.skip {
+		if !slices.Contains(bundleChecks, s) {
+			return fmt.Errorf("invalid --skip %q, expected validation, signature, policy, or deprecation", s)
+		}
+	}
+	if _, err := manifest.DeprecatedAPIs(nil, verifyBundleOpts.kubernetesVersion); err != nil {
+		return err
+	}
+
+	tag, err := resolveTag(ctx, verifyBundleOpts.tag, false)
+	if err != nil {
+		return err
+	}
+	r, err := oci.NewRepository(tag)
+	if err != nil {
+		return err
+	}
+	dgst, err := r.Digest(ctx)
+	if err != nil {
+		return err
+	}
+	docs, err := artifactDocuments(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	report := bundleReport{Tag: tag, Digest: dgst, Passed: true}
+	var failed []string
+	code := 0
+	for _, c := range checks {
+		res := bundleCheck{Name: c.name, Status: checkPass, Findings: []string{}}
+		if slices.Contains(verifyBundleOpts.skip, c.name) {
+			res.Status = checkSkip
+			report.Checks = append(report.Checks, res)
+			continue
+		}
+		debugf("Running the %s check of %s\n", c.name, tag)
+		findings, skip, err := c.run(ctx, r, docs)
+		switch {
+		case err != nil:
+			res.Status, res.Findings = checkFail, []string{err.Error()}
+		case skip:
+			res.Status = checkSkip
+		case len(findings) > 0:
+			res.Status, res.Findings = checkFail, findings
+		}
+		if res.Status == checkFail {
+			report.Passed = false
+			failed = append(failed, c.name)
+			if code == 0 {
+				code = c.code
+			}
+		}
+		report.Checks = append(report.Checks, res)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	if !report.Passed {
+		return withExitCode(code, fmt.Errorf("%s failed %d check(s): %s", tag, len(failed), strings.Join(failed, ", ")))
+	}
+	return nil
+}
+
+// bundleValidation validates the resources against the schemas, as validate does.
+func bundleValidation(_ context.Context, _ *oci.Repository, docs []*manifest.Document) ([]string, bool, error) {
+	// kubeconform reads manifests from files
+	dir, err := os.MkdirTemp("", "kubectl-mft-verify-bundle-")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "manifest.yaml")
+	if err := os.WriteFile(path, manifest.Join(docs), 0o600); err != nil {
+		return nil, false, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	opts, err := validationOptions(nil)
+	if err != nil {
+		return nil, false, err
+	}
+	err = validate.ValidateManifest(path, opts...)
+	var validationErr *validate.Error
+	if !errors.As(err, &validationErr) {
+		return nil, false, err
+	}
+	findings := make([]string, len(validationErr.Problems))
+	for i, p := range validationErr.Problems {
+		findings[i] = p.String()
+		if p.Resource != "" {
+			findings[i] = p.Resource + ": " + findings[i]
+		}
+	}
+	return findings, false, nil
+}
+
+// bundleSignature verifies the signature and the pinned digest, as verify does.
+func bundleSignature(ctx context.Context, r *oci.Repository, _ []*manifest.Document) ([]string, bool, error) {
+	if !verificationKeysExist() {
+		return []string{"no verification keys found, run 'kubectl mft key import <file>' to import a public key"}, false, nil
+	}
+	verifier, err := newVerifier(r)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := verifier.Verify(ctx, r.LayoutPath(), r.Tag()); err != nil {
+		return []string{err.Error()}, false, nil
+	}
+	if _, err := checkPinnedDigest(ctx, r); err != nil {
+		if exitCode(err) != ExitSignature {
+			return nil, false, err
+		}
+		return []string{err.Error()}, false, nil
+	}
+	return nil, false, nil
+}
+
+// bundlePolicy checks the resources against the image and namespace policies of the
+// configuration, as apply does. It is skipped if neither is configured.
+func bundlePolicy(ctx context.Context, _ *oci.Repository, docs []*manifest.Document) ([]string, bool, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, false, err
+	}
+	if !cfg.Images.Configured() && !cfg.Namespaces.Configured() {
+		return nil, true, nil
+	}
+	var findings []string
+	if cfg.Images.Configured() {
+		violations, err := imagepolicy.NewChecker(cfg.Images).Check(ctx, docs)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, v := range violations {
+			findings = append(findings, "image "+v.String())
+		}
+	}
+	if cfg.Namespaces.Configured() {
+		violations, err := nspolicy.Check([]config.NamespacePolicy{cfg.Namespaces}, docs, verifyBundleOpts.defaultNamespace, nil)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, v := range violations {
+			findings = append(findings, v.String())
+		}
+	}
+	return findings, false, nil
+}
+
+// bundleDeprecation reports the resources using API versions removed from Kubernetes.
+func bundleDeprecation(_ context.Context, _ *oci.Repository, docs []*manifest.Document) ([]string, bool, error) {
+	apis, err := manifest.DeprecatedAPIs(docs, verifyBundleOpts.kubernetesVersion)
+	if err != nil {
+		return nil, false, err
+	}
+	findings := make([]string, len(apis))
+	for i, a := range apis {
+		findings[i] = a.String()
+	}
+	return findings, false, nil
+}
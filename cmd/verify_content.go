@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+type VerifyContentOpts struct {
+	tags   []string
+	repair bool
+}
+
+var verifyContentOpts VerifyContentOpts
+
+func init() {
+	rootCmd.AddCommand(verifyContentCmd)
+
+	flag := verifyContentCmd.Flags()
+	flag.BoolVar(&verifyContentOpts.repair, "repair", false, "Re-pull corrupted blobs from the registry")
+}
+
+// verifyContentCmd represents the verify-content command
+var verifyContentCmd = &cobra.Command{
+	Use:   "verify-content [tag]",
+	Short: "Check the integrity of blobs in local OCI layout storage",
+	Long: `Verify-content recomputes the digest of every blob stored for a manifest and compares
+it with the digest recorded in its descriptor, detecting bit-rot or manual tampering.
+
+The manifest, its content layers, and its signatures are checked. If no tag is given,
+every manifest in local storage is checked.
+
+With --repair, corrupted or missing blobs are removed and re-pulled from the registry.
+Manifests packed locally without a registry cannot be repaired.
+
+Examples:
+  # Check a single manifest
+  kubectl mft verify-content registry.example.com/manifests/app:v1.0.0
+
+  # Check every manifest in local storage
+  kubectl mft verify-content
+
+  # Re-pull corrupted blobs
+  kubectl mft verify-content registry.example.com/manifests/app:v1.0.0 --repair`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTags(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		verifyContentOpts.tags = args
+		return runVerifyContent(cmd.Context())
+	},
+}
+
+func runVerifyContent(ctx context.Context) error {
+	tags := verifyContentOpts.tags
+	if len(tags) == 0 {
+		res, err := mft.List(ctx, oci.NewRegistry())
+		if err != nil {
+			return err
+		}
+		res.Sort()
+		for _, i := range res.Items() {
+			tags = append(tags, i.Repository+":"+i.Tag)
+		}
+	}
+
+	var corrupted int
+	for _, tag := range tags {
+		r, err := oci.NewRepository(tag)
+		if err != nil {
+			return err
+		}
+
+		res, err := mft.VerifyContent(ctx, r, verifyContentOpts.repair)
+		if err != nil {
+			return err
+		}
+		if !quiet || !res.OK() {
+			res.Print()
+		}
+		corrupted += len(res.Issues())
+	}
+
+	if corrupted > 0 {
+		return fmt.Errorf("content verification failed: %d corrupted blob(s) found", corrupted)
+	}
+	return nil
+}
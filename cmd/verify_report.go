@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+	"github.com/chez-shanpu/kubectl-mft/internal/trust"
+)
+
+// outputJSON is the output format of verify printing a verification report as JSON
+const outputJSON = "json"
+
+// Policy decisions of a verification report
+const (
+	decisionAllow = "allow"
+	decisionDeny  = "deny"
+)
+
+// verifyReport is the verification report printed by verify -o json
+type verifyReport struct {
+	Repository   string                      `json:"repository"`
+	Tag          string                      `json:"tag"`
+	Digest       string                      `json:"digest"`
+	Verified     bool                        `json:"verified"`
+	Signatures   []signature.SignatureResult `json:"signatures"`
+	PinnedDigest string                      `json:"pinnedDigest,omitempty"`
+	Policy       verifyPolicy                `json:"policy"`
+}
+
+// verifyPolicy describes the keys trusted for the repository and the decision on the manifest
+type verifyPolicy struct {
+	// Rule is the repository pattern of the matching trust policy rule, empty if all keys of
+	// the key directory are trusted
+	Rule        string              `json:"rule,omitempty"`
+	TrustedKeys []signature.KeyInfo `json:"trustedKeys"`
+	Decision    string              `json:"decision"`
+	Reason      string              `json:"reason"`
+}
+
+// verifyJSON verifies every signature of the manifest on its own and prints the results with
+// the policy decision as JSON. A denied manifest is reported and fails with ExitSignature.
+func verifyJSON(ctx context.Context, r *oci.Repository) error {
+	verifier, err := newVerifier(r)
+	if err != nil {
+		return err
+	}
+	d, results, err := verifier.Inspect(ctx, r.LayoutPath(), r.Tag())
+	if err != nil {
+		return err
+	}
+	report := verifyReport{Repository: r.Name(), Tag: r.Tag(), Digest: d.String(), Signatures: results}
+	if report.Policy, err = trustedKeysFor(r); err != nil {
+		return err
+	}
+
+	var signers []string
+	for _, res := range results {
+		if res.Verified && !slices.Contains(signers, res.KeyFingerprint) {
+			signers = append(signers, res.KeyFingerprint)
+		}
+	}
+	var reason error
+	switch {
+	case len(results) == 0:
+		reason = fmt.Errorf("no signature found for %q", r)
+	case len(signers) == 0:
+		reason = fmt.Errorf("none of the %d signature(s) of %q verifies with the trusted keys", len(results), r)
+	default:
+		pinned, err := checkPinnedDigest(ctx, r)
+		if err != nil && exitCode(err) != ExitSignature {
+			return err
+		}
+		reason = err
+		report.PinnedDigest = pinned.String()
+	}
+
+	report.Verified = reason == nil
+	if report.Verified {
+		report.Policy.Decision = decisionAllow
+		report.Policy.Reason = "signed by trusted key(s) " + strings.Join(signers, ", ")
+	} else {
+		report.Policy.Decision = decisionDeny
+		report.Policy.Reason = reason.Error()
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	if reason != nil {
+		return withExitCode(ExitSignature, reason)
+	}
+	return nil
+}
+
+// trustedKeysFor returns the public keys trusted for the repository, as selected by newVerifier.
+func trustedKeysFor(r *oci.Repository) (verifyPolicy, error) {
+	policy, err := trust.LoadPolicy()
+	if err != nil {
+		return verifyPolicy{}, err
+	}
+	var res verifyPolicy
+	rule := policy.RuleFor(r.Name())
+	if rule != nil {
+		res.Rule = rule.Repository
+	}
+	keys, err := signature.ListKeys()
+	if err != nil {
+		return verifyPolicy{}, err
+	}
+	res.TrustedKeys = []signature.KeyInfo{}
+	for _, k := range keys {
+		if k.Type == "public" && (rule == nil || slices.Contains(rule.Keys, k.Name)) {
+			res.TrustedKeys = append(res.TrustedKeys, k)
+		}
+	}
+	return res, nil
+}
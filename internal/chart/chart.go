@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+// Package chart wraps Kubernetes manifests into a minimal Helm chart.
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"gopkg.in/yaml.v3"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+// Metadata is the content of Chart.yaml.
+type Metadata struct {
+	APIVersion  string            `yaml:"apiVersion"`
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description"`
+	Type        string            `yaml:"type"`
+	Version     string            `yaml:"version"`
+	AppVersion  string            `yaml:"appVersion"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// NewMetadata returns the chart metadata for the artifact repository:tag. The chart is named
+// after the last path element of the repository and versioned by the tag if it is a semantic
+// version, or as 0.0.0-<tag> otherwise, as Helm requires semantic versions.
+func NewMetadata(repository, tag string) *Metadata {
+	name := repository[strings.LastIndex(repository, "/")+1:]
+	return &Metadata{
+		APIVersion:  "v2",
+		Name:        ChartName(name),
+		Description: fmt.Sprintf("Kubernetes manifests of %s:%s exported by kubectl-mft", repository, tag),
+		Type:        "application",
+		Version:     ChartVersion(tag),
+		AppVersion:  tag,
+		Annotations: map[string]string{manifest.AnnotationSource: repository + ":" + tag},
+	}
+}
+
+// ChartName converts name to a valid chart name of lower case letters, digits, and dashes.
+func ChartName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '-'
+		}
+	}, name)
+	if name = strings.Trim(name, "-"); name == "" {
+		return "manifests"
+	}
+	return name
+}
+
+// ChartVersion converts tag to a semantic version. Only a tag that is a complete semantic
+// version, optionally prefixed with v, is used as is. Any other tag, including one with leading
+// zeros such as 1.02.0, becomes the pre-release of 0.0.0.
+func ChartVersion(tag string) string {
+	if v, err := semver.StrictNewVersion(strings.TrimPrefix(tag, "v")); err == nil {
+		return v.String()
+	}
+	sanitized := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '-'
+		}
+	}, tag)
+	// Pre-release identifiers must not be empty, and numeric ones must not have leading zeros
+	var identifiers []string
+	for _, id := range strings.Split(sanitized, ".") {
+		if id == "" {
+			continue
+		}
+		if strings.Trim(id, "0123456789") == "" {
+			if id = strings.TrimLeft(id, "0"); id == "" {
+				id = "0"
+			}
+		}
+		identifiers = append(identifiers, id)
+	}
+	if len(identifiers) == 0 {
+		return "0.0.0"
+	}
+	return "0.0.0-" + strings.Join(identifiers, ".")
+}
+
+// Write creates the chart in dir: Chart.yaml, the CustomResourceDefinitions in crds/, and the
+// other resources in templates/, one file per resource. Template delimiters in the resources are
+// escaped, so Helm renders them unchanged. It returns the paths of the written files.
+func Write(dir string, meta *Metadata, docs []*manifest.Document) ([]string, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(meta); err != nil {
+		return nil, fmt.Errorf("failed to marshal Chart.yaml: %w", err)
+	}
+	files := map[string][]byte{"Chart.yaml": buf.Bytes()}
+	order := []string{"Chart.yaml"}
+
+	names := manifest.FileNames(docs)
+	for i, d := range docs {
+		path := filepath.Join("templates", names[i])
+		content := escapeTemplate(d.Raw)
+		if d.Kind == "CustomResourceDefinition" {
+			// Helm installs crds/ before templates/ and does not render them
+			path, content = filepath.Join("crds", names[i]), d.Raw
+		}
+		files[path] = content
+		order = append(order, path)
+	}
+
+	var written []string
+	for _, name := range order {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return written, fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, files[name], 0o644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// templateEscaper replaces template delimiters with actions printing them
+var templateEscaper = strings.NewReplacer("{{", `{{ "{{" }}`, "}}", `{{ "}}" }}`)
+
+func escapeTemplate(raw []byte) []byte {
+	return []byte(templateEscaper.Replace(string(raw)))
+}
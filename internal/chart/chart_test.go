@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package chart
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"text/template"
+
+	"github.com/Masterminds/semver/v3"
+	"gopkg.in/yaml.v3"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+func TestChartVersion(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{tag: "v1.2.3", want: "1.2.3"},
+		{tag: "1.2.3-rc.1", want: "1.2.3-rc.1"},
+		{tag: "v1.2", want: "0.0.0-v1.2"},
+		{tag: "01.2.3", want: "0.0.0-1.2.3"},
+		{tag: "1.02.3", want: "0.0.0-1.2.3"},
+		{tag: "1.2.3-rc.01", want: "0.0.0-1.2.3-rc.1"},
+		{tag: "latest", want: "0.0.0-latest"},
+		{tag: "main_abc123", want: "0.0.0-main-abc123"},
+		{tag: "a..b.", want: "0.0.0-a.b"},
+	}
+	for _, tt := range tests {
+		got := ChartVersion(tt.tag)
+		if got != tt.want {
+			t.Errorf("ChartVersion(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+		if _, err := semver.StrictNewVersion(got); err != nil {
+			t.Errorf("ChartVersion(%q) = %q is not a strict semantic version: %v", tt.tag, got, err)
+		}
+	}
+}
+
+func TestChartName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "my-app", want: "my-app"},
+		{name: "My_App.v2", want: "my-app-v2"},
+		{name: "__", want: "manifests"},
+	}
+	for _, tt := range tests {
+		if got := ChartName(tt.name); got != tt.want {
+			t.Errorf("ChartName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	docs, err := manifest.Split([]byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  namespace: ns
+data:
+  template: "Hello {{ .Name }}"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	written, err := Write(dir, NewMetadata("ghcr.io/org/app", "v1.0.0"), docs)
+	if err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	want := []string{
+		filepath.Join(dir, "Chart.yaml"),
+		filepath.Join(dir, "crds", "customresourcedefinition_widgets.example.com.yaml"),
+		filepath.Join(dir, "templates", "ns_configmap_app.yaml"),
+	}
+	if !slices.Equal(written, want) {
+		t.Errorf("Write() = %v, want %v", written, want)
+	}
+
+	data, err := os.ReadFile(want[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta Metadata
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		t.Fatalf("invalid Chart.yaml: %v", err)
+	}
+	if meta.Name != "app" || meta.Version != "1.0.0" || meta.AppVersion != "v1.0.0" {
+		t.Errorf("Chart.yaml = %+v, want app 1.0.0 (v1.0.0)", meta)
+	}
+
+	// Rendering the template yields the original resource
+	data, err = os.ReadFile(want[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := template.New("cm").Parse(string(data))
+	if err != nil {
+		t.Fatalf("template does not parse: %v", err)
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, nil); err != nil {
+		t.Fatalf("template does not render: %v", err)
+	}
+	if !bytes.Equal(rendered.Bytes(), docs[1].Raw) {
+		t.Errorf("rendered template = %q, want %q", rendered.String(), docs[1].Raw)
+	}
+}
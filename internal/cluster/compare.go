@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+// ignoredAnnotations are set by kubectl itself and never match the stored manifest
+var ignoredAnnotations = map[string]bool{
+	"kubectl.kubernetes.io/last-applied-configuration": true,
+}
+
+// Difference is a field whose live value does not match the stored manifest
+type Difference struct {
+	Path    string `json:"path" yaml:"path"`
+	Desired any    `json:"desired" yaml:"desired"`
+	Live    any    `json:"live" yaml:"live"`
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: desired %s, live %s", d.Path, shorten(FormatValue(d.Desired)), shorten(FormatValue(d.Live)))
+}
+
+// Desired decodes the stored manifest document into the same representation as a live object.
+func Desired(doc *manifest.Document) (Object, error) {
+	var v any
+	if err := yaml.Unmarshal(doc.Raw, &v); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", doc, err)
+	}
+	// Round-trip through JSON so numbers and maps have the same types as decoded kubectl output
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %w", doc, err)
+	}
+	var obj Object
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %w", doc, err)
+	}
+	return obj, nil
+}
+
+// Compare reports the fields set in desired whose live value differs.
+// Fields only present in live, such as defaults and status, are not differences.
+// Lists must have the same length and are compared element by element.
+func Compare(desired, live Object) []Difference {
+	var diffs []Difference
+	for _, k := range sortedKeys(desired) {
+		switch k {
+		case "status":
+			continue
+		case "metadata":
+			dm, _ := desired[k].(Object)
+			lm, _ := live[k].(Object)
+			for _, field := range []string{"labels", "annotations"} {
+				dv, _ := dm[field].(Object)
+				lv, _ := lm[field].(Object)
+				for _, key := range sortedKeys(dv) {
+					if field == "annotations" && ignoredAnnotations[key] {
+						continue
+					}
+					diffs = compareValue(fmt.Sprintf("metadata.%s[%s]", field, key), dv[key], lv[key], diffs)
+				}
+			}
+		default:
+			diffs = compareValue(k, desired[k], live[k], diffs)
+		}
+	}
+	return diffs
+}
+
+func compareValue(path string, desired, live any, diffs []Difference) []Difference {
+	switch d := desired.(type) {
+	case Object:
+		l, ok := live.(Object)
+		if !ok {
+			return append(diffs, Difference{Path: path, Desired: desired, Live: live})
+		}
+		for _, k := range sortedKeys(d) {
+			diffs = compareValue(path+"."+k, d[k], l[k], diffs)
+		}
+		return diffs
+	case []any:
+		l, ok := live.([]any)
+		if !ok || len(l) != len(d) {
+			return append(diffs, Difference{Path: path, Desired: desired, Live: live})
+		}
+		for i := range d {
+			diffs = compareValue(fmt.Sprintf("%s[%d]", path, i), d[i], l[i], diffs)
+		}
+		return diffs
+	default:
+		if !reflect.DeepEqual(desired, live) {
+			return append(diffs, Difference{Path: path, Desired: desired, Live: live})
+		}
+		return diffs
+	}
+}
+
+func sortedKeys(m Object) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// FormatValue formats a field value of a difference as compact JSON, or "<unset>" if the
+// field is not set.
+func FormatValue(v any) string {
+	if v == nil {
+		return "<unset>"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// shorten truncates a formatted value to 80 characters.
+func shorten(s string) string {
+	if len(s) > 80 {
+		s = s[:77] + "..."
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cluster
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+func TestCompare(t *testing.T) {
+	docs, err := manifest.Split([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    app: web
+  annotations:
+    owner: team-a
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+`))
+	if err != nil {
+		t.Fatalf("Split() unexpected error: %v", err)
+	}
+	desired, err := Desired(docs[0])
+	if err != nil {
+		t.Fatalf("Desired() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		live      string
+		wantPaths []string
+	}{
+		{
+			name: "in sync with defaults and status",
+			live: `{"apiVersion":"apps/v1","kind":"Deployment",
+				"metadata":{"name":"app","uid":"1","labels":{"app":"web"},"annotations":{"owner":"team-a","kubectl.kubernetes.io/last-applied-configuration":"{}"}},
+				"spec":{"replicas":2,"strategy":{"type":"RollingUpdate"},"template":{"spec":{"containers":[{"name":"web","image":"nginx:1.25","imagePullPolicy":"IfNotPresent"}]}}},
+				"status":{"replicas":2}}`,
+		},
+		{
+			name: "drifted scalar, label, and list",
+			live: `{"apiVersion":"apps/v1","kind":"Deployment",
+				"metadata":{"name":"app","labels":{"app":"api"}},
+				"spec":{"replicas":3,"template":{"spec":{"containers":[{"name":"web","image":"nginx:1.26"}]}}}}`,
+			wantPaths: []string{
+				"metadata.labels[app]",
+				"metadata.annotations[owner]",
+				"spec.replicas",
+				"spec.template.spec.containers[0].image",
+			},
+		},
+		{
+			name: "list length differs",
+			live: `{"apiVersion":"apps/v1","kind":"Deployment",
+				"metadata":{"name":"app","labels":{"app":"web"},"annotations":{"owner":"team-a"}},
+				"spec":{"replicas":2,"template":{"spec":{"containers":[{"name":"web","image":"nginx:1.25"},{"name":"sidecar"}]}}}}`,
+			wantPaths: []string{"spec.template.spec.containers"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var live Object
+			if err := json.Unmarshal([]byte(tt.live), &live); err != nil {
+				t.Fatalf("invalid test object: %v", err)
+			}
+			diffs := Compare(desired, live)
+			if len(diffs) != len(tt.wantPaths) {
+				t.Fatalf("Compare() = %v, want paths %v", diffs, tt.wantPaths)
+			}
+			for i, d := range diffs {
+				if d.Path != tt.wantPaths[i] {
+					t.Errorf("Compare()[%d].Path = %q, want %q", i, d.Path, tt.wantPaths[i])
+				}
+			}
+		})
+	}
+}
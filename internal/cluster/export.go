@@ -0,0 +1,306 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os/exec"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// SnapshotPrefix is the prefix of the annotations recording the provenance of a snapshot
+	SnapshotPrefix = "io.kubectl-mft.snapshot."
+	// AnnotationSnapshotContext records the kubeconfig context a snapshot was exported from
+	AnnotationSnapshotContext = "io.kubectl-mft.snapshot.context"
+	// AnnotationSnapshotSelector records the resource types, namespace, and label selector of a snapshot
+	AnnotationSnapshotSelector = "io.kubectl-mft.snapshot.selector"
+)
+
+// StripDefaultTolerations names the stripper removing the tolerations added to Pods by the
+// DefaultTolerationSeconds admission plugin
+const StripDefaultTolerations = "defaultTolerations"
+
+// DefaultStrip are the fields removed from exported resources unless configured otherwise:
+// the status and the metadata maintained by the cluster.
+var DefaultStrip = []string{
+	"status",
+	"metadata.uid",
+	"metadata.resourceVersion",
+	"metadata.generation",
+	"metadata.creationTimestamp",
+	"metadata.managedFields",
+	"metadata.selfLink",
+	"metadata.deletionTimestamp",
+	"metadata.deletionGracePeriodSeconds",
+	"metadata.annotations[kubectl.kubernetes.io/last-applied-configuration]",
+	StripDefaultTolerations,
+}
+
+// DefaultExcludeKinds are the kinds never exported unless configured otherwise, as they
+// are created by the cluster and would conflict when applied again.
+var DefaultExcludeKinds = []string{"Event", "Endpoints", "EndpointSlice"}
+
+// defaultTolerations are the tolerations added to Pods by the DefaultTolerationSeconds admission plugin
+var defaultTolerations = []Object{
+	{"key": "node.kubernetes.io/not-ready", "operator": "Exists", "effect": "NoExecute", "tolerationSeconds": float64(300)},
+	{"key": "node.kubernetes.io/unreachable", "operator": "Exists", "effect": "NoExecute", "tolerationSeconds": float64(300)},
+}
+
+// ExportOptions selects the live resources to export.
+type ExportOptions struct {
+	// Kinds are the resource types passed to 'kubectl get', such as 'deployments' or 'all'
+	Kinds []string
+	// Selector is a label selector, empty for all resources
+	Selector string
+	// Namespace is the namespace of the resources, empty for the namespace of the current context
+	Namespace     string
+	AllNamespaces bool
+	// Strip are the fields removed from every resource, see ParseFieldPath, or
+	// StripDefaultTolerations
+	Strip []string
+	// ExcludeKinds are the kinds left out
+	ExcludeKinds []string
+}
+
+// Selection describes the selected resources, as recorded in AnnotationSnapshotSelector.
+func (o ExportOptions) Selection() string {
+	s := strings.Join(o.Kinds, ",")
+	switch {
+	case o.AllNamespaces:
+		s += " in all namespaces"
+	case o.Namespace != "":
+		s += " in namespace " + o.Namespace
+	}
+	if o.Selector != "" {
+		s += " matching " + o.Selector
+	}
+	return s
+}
+
+// Export fetches the live resources selected by opts with 'kubectl get' and returns them as a
+// multi-document YAML manifest. The fields of opts.Strip are removed, and resources of
+// opts.ExcludeKinds and resources owned by a controller, such as the Pods of a ReplicaSet,
+// are left out, the latter since their owner is exported.
+func Export(ctx context.Context, opts ExportOptions) ([]byte, error) {
+	strip, err := newStripper(opts.Strip)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"get", strings.Join(opts.Kinds, ","), "-o", "json"}
+	if opts.Selector != "" {
+		args = append(args, "-l", opts.Selector)
+	}
+	if opts.AllNamespaces {
+		args = append(args, "--all-namespaces")
+	} else if opts.Namespace != "" {
+		args = append(args, "-n", opts.Namespace)
+	}
+
+	var stdout, stderr bytes.Buffer
+	kubectl := exec.CommandContext(ctx, "kubectl", args...)
+	kubectl.Stdout = &stdout
+	kubectl.Stderr = &stderr
+	if err := kubectl.Run(); err != nil {
+		return nil, fmt.Errorf("kubectl get %s failed: %w: %s", args[1], err, strings.TrimSpace(stderr.String()))
+	}
+
+	var list struct {
+		Items []Object `json:"items"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &list); err != nil {
+		return nil, fmt.Errorf("failed to decode kubectl output: %w", err)
+	}
+	return exportObjects(list.Items, strip, opts.ExcludeKinds)
+}
+
+// exportObjects encodes the objects not owned by a controller nor of an excluded kind as YAML
+// documents, after stripping them.
+func exportObjects(objs []Object, strip stripper, excludeKinds []string) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, obj := range objs {
+		if controlled(obj) || slices.Contains(excludeKinds, fmt.Sprint(obj["kind"])) {
+			continue
+		}
+		strip(obj)
+		if err := encoder.Encode(obj); err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", describe(obj), err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// stripper removes fields from an object.
+type stripper func(obj Object)
+
+// newStripper returns a stripper removing the given fields.
+func newStripper(fields []string) (stripper, error) {
+	var paths [][]string
+	tolerations := false
+	for _, f := range fields {
+		if f == StripDefaultTolerations {
+			tolerations = true
+			continue
+		}
+		path, err := ParseFieldPath(f)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return func(obj Object) {
+		for _, path := range paths {
+			removePath(obj, path)
+		}
+		if tolerations && obj["kind"] == "Pod" {
+			removeDefaultTolerations(obj)
+		}
+	}, nil
+}
+
+// ParseFieldPath parses a field path of dot-separated keys, such as 'metadata.uid'. A key in
+// brackets may contain dots, as in 'metadata.labels[app.kubernetes.io/name]', and '*' matches
+// every key of a map or element of a list.
+func ParseFieldPath(s string) ([]string, error) {
+	var path []string
+	rest := s
+	for rest != "" {
+		var key string
+		if strings.HasPrefix(rest, "[") {
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid field path %q: unterminated bracket", s)
+			}
+			key, rest = rest[1:end], rest[end+1:]
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key, rest = rest[:end], rest[end:]
+		}
+		if key == "" {
+			return nil, fmt.Errorf("invalid field path %q: empty key", s)
+		}
+		path = append(path, key)
+		if strings.HasPrefix(rest, ".") {
+			rest = rest[1:]
+			if rest == "" {
+				return nil, fmt.Errorf("invalid field path %q: empty key", s)
+			}
+		} else if rest != "" && !strings.HasPrefix(rest, "[") {
+			return nil, fmt.Errorf("invalid field path %q", s)
+		}
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty field path")
+	}
+	return path, nil
+}
+
+// removePath removes the field at path from v, and the maps and lists left empty by its removal.
+// It reports whether anything was removed.
+func removePath(v any, path []string) bool {
+	removed := false
+	switch n := v.(type) {
+	case Object:
+		keys := []string{path[0]}
+		if path[0] == "*" {
+			keys = slices.Collect(maps.Keys(n))
+		}
+		for _, k := range keys {
+			child, ok := n[k]
+			if !ok {
+				continue
+			}
+			if len(path) == 1 || (removePath(child, path[1:]) && empty(child)) {
+				delete(n, k)
+			}
+			removed = true
+		}
+	case []any:
+		if len(path) == 1 {
+			return false
+		}
+		for i, elem := range n {
+			if path[0] == "*" || path[0] == strconv.Itoa(i) {
+				removed = removePath(elem, path[1:]) || removed
+			}
+		}
+	}
+	return removed
+}
+
+// empty reports whether v is an empty map or list.
+func empty(v any) bool {
+	switch n := v.(type) {
+	case Object:
+		return len(n) == 0
+	case []any:
+		return len(n) == 0
+	}
+	return false
+}
+
+// removeDefaultTolerations removes the tolerations added by the DefaultTolerationSeconds admission
+// plugin from a Pod.
+func removeDefaultTolerations(pod Object) {
+	spec, _ := pod["spec"].(Object)
+	tolerations, _ := spec["tolerations"].([]any)
+	if tolerations == nil {
+		return
+	}
+	kept := slices.DeleteFunc(tolerations, func(t any) bool {
+		return slices.ContainsFunc(defaultTolerations, func(d Object) bool { return reflect.DeepEqual(t, d) })
+	})
+	if len(kept) == 0 {
+		delete(spec, "tolerations")
+	} else {
+		spec["tolerations"] = kept
+	}
+}
+
+// controlled reports whether the object is managed by a controller.
+func controlled(obj Object) bool {
+	refs, _ := getPath(obj, "metadata", "ownerReferences").([]any)
+	for _, ref := range refs {
+		if r, ok := ref.(Object); ok && r["controller"] == true {
+			return true
+		}
+	}
+	return false
+}
+
+// describe returns the kind and name of a live object.
+func describe(obj Object) string {
+	name, _ := getPath(obj, "metadata", "name").(string)
+	return fmt.Sprintf("%v/%s", obj["kind"], name)
+}
+
+// CurrentContext returns the name of the current kubeconfig context.
+func CurrentContext(ctx context.Context) (string, error) {
+	var stdout, stderr bytes.Buffer
+	kubectl := exec.CommandContext(ctx, "kubectl", "config", "current-context")
+	kubectl.Stdout = &stdout
+	kubectl.Stderr = &stderr
+	if err := kubectl.Run(); err != nil {
+		return "", fmt.Errorf("kubectl config current-context failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cluster
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestExportObjects(t *testing.T) {
+	live := `[
+  {
+    "apiVersion": "apps/v1",
+    "kind": "Deployment",
+    "metadata": {
+      "name": "web",
+      "namespace": "prod",
+      "uid": "0b7c",
+      "resourceVersion": "42",
+      "generation": 3,
+      "creationTimestamp": "2024-06-01T00:00:00Z",
+      "labels": {"app": "web"},
+      "annotations": {"kubectl.kubernetes.io/last-applied-configuration": "{}"},
+      "managedFields": [{"manager": "kubectl"}]
+    },
+    "spec": {"replicas": 2},
+    "status": {"readyReplicas": 2}
+  },
+  {
+    "apiVersion": "apps/v1",
+    "kind": "ReplicaSet",
+    "metadata": {
+      "name": "web-5d8f",
+      "namespace": "prod",
+      "ownerReferences": [{"kind": "Deployment", "name": "web", "controller": true}]
+    }
+  },
+  {
+    "apiVersion": "v1",
+    "kind": "ConfigMap",
+    "metadata": {
+      "name": "web-config",
+      "namespace": "prod",
+      "annotations": {"owner": "team-a"},
+      "ownerReferences": [{"kind": "Deployment", "name": "web"}]
+    },
+    "data": {"key": "value"}
+  },
+  {
+    "apiVersion": "v1",
+    "kind": "Endpoints",
+    "metadata": {"name": "web", "namespace": "prod"}
+  }
+]`
+	var objs []Object
+	if err := json.Unmarshal([]byte(live), &objs); err != nil {
+		t.Fatal(err)
+	}
+
+	strip, err := newStripper(DefaultStrip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := exportObjects(objs, strip, DefaultExcludeKinds)
+	if err != nil {
+		t.Fatalf("exportObjects() unexpected error: %v", err)
+	}
+	want := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: web
+  name: web
+  namespace: prod
+spec:
+  replicas: 2
+---
+apiVersion: v1
+data:
+  key: value
+kind: ConfigMap
+metadata:
+  annotations:
+    owner: team-a
+  name: web-config
+  namespace: prod
+  ownerReferences:
+    - kind: Deployment
+      name: web
+`
+	if string(got) != want {
+		t.Errorf("exportObjects() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestStripper(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []string
+		obj    string
+		want   string
+	}{
+		{
+			name:   "default tolerations of a Pod",
+			fields: []string{StripDefaultTolerations},
+			obj: `{"kind": "Pod", "spec": {"tolerations": [
+				{"key": "node.kubernetes.io/not-ready", "operator": "Exists", "effect": "NoExecute", "tolerationSeconds": 300},
+				{"key": "dedicated", "operator": "Equal", "value": "web", "effect": "NoSchedule"},
+				{"key": "node.kubernetes.io/unreachable", "operator": "Exists", "effect": "NoExecute", "tolerationSeconds": 300}
+			]}}`,
+			want: `{"kind": "Pod", "spec": {"tolerations": [
+				{"key": "dedicated", "operator": "Equal", "value": "web", "effect": "NoSchedule"}
+			]}}`,
+		},
+		{
+			name:   "only default tolerations",
+			fields: []string{StripDefaultTolerations},
+			obj: `{"kind": "Pod", "spec": {"tolerations": [
+				{"key": "node.kubernetes.io/not-ready", "operator": "Exists", "effect": "NoExecute", "tolerationSeconds": 300}
+			]}}`,
+			want: `{"kind": "Pod", "spec": {}}`,
+		},
+		{
+			name:   "customized tolerations are kept",
+			fields: []string{StripDefaultTolerations},
+			obj: `{"kind": "Pod", "spec": {"tolerations": [
+				{"key": "node.kubernetes.io/not-ready", "operator": "Exists", "effect": "NoExecute", "tolerationSeconds": 60}
+			]}}`,
+			want: `{"kind": "Pod", "spec": {"tolerations": [
+				{"key": "node.kubernetes.io/not-ready", "operator": "Exists", "effect": "NoExecute", "tolerationSeconds": 60}
+			]}}`,
+		},
+		{
+			name:   "wildcard in lists",
+			fields: []string{"spec.template.spec.containers.*.terminationMessagePath"},
+			obj:    `{"spec": {"template": {"spec": {"containers": [{"name": "a", "terminationMessagePath": "/dev/termination-log"}, {"name": "b"}]}}}}`,
+			want:   `{"spec": {"template": {"spec": {"containers": [{"name": "a"}, {"name": "b"}]}}}}`,
+		},
+		{
+			name:   "emptied maps are removed",
+			fields: []string{"metadata.annotations[deployment.kubernetes.io/revision]"},
+			obj:    `{"metadata": {"name": "web", "annotations": {"deployment.kubernetes.io/revision": "3"}}}`,
+			want:   `{"metadata": {"name": "web"}}`,
+		},
+		{
+			name:   "list index",
+			fields: []string{"spec.ports.0.nodePort"},
+			obj:    `{"spec": {"ports": [{"port": 80, "nodePort": 30080}, {"port": 443, "nodePort": 30443}]}}`,
+			want:   `{"spec": {"ports": [{"port": 80}, {"port": 443, "nodePort": 30443}]}}`,
+		},
+		{
+			name:   "missing fields",
+			fields: []string{"status", "metadata.labels.app"},
+			obj:    `{"metadata": {"name": "web"}}`,
+			want:   `{"metadata": {"name": "web"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strip, err := newStripper(tt.fields)
+			if err != nil {
+				t.Fatalf("newStripper() unexpected error: %v", err)
+			}
+			var obj, want Object
+			if err := json.Unmarshal([]byte(tt.obj), &obj); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			strip(obj)
+			if !reflect.DeepEqual(obj, want) {
+				t.Errorf("stripped = %v, want %v", obj, want)
+			}
+		})
+	}
+}
+
+func TestParseFieldPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: "status", want: []string{"status"}},
+		{path: "metadata.uid", want: []string{"metadata", "uid"}},
+		{path: "metadata.labels[app.kubernetes.io/name]", want: []string{"metadata", "labels", "app.kubernetes.io/name"}},
+		{path: "metadata.annotations[a.b].c", want: []string{"metadata", "annotations", "a.b", "c"}},
+		{path: "spec.*.name", want: []string{"spec", "*", "name"}},
+		{path: "", wantErr: true},
+		{path: "metadata.", wantErr: true},
+		{path: "metadata..uid", wantErr: true},
+		{path: "metadata.labels[app", wantErr: true},
+		{path: "metadata[]", wantErr: true},
+		{path: "metadata[a]b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := ParseFieldPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFieldPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFieldPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportOptionsSelection(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ExportOptions
+		want string
+	}{
+		{"kinds only", ExportOptions{Kinds: []string{"all"}}, "all"},
+		{"namespace and selector", ExportOptions{Kinds: []string{"all", "configmaps"}, Namespace: "prod", Selector: "app=web"}, "all,configmaps in namespace prod matching app=web"},
+		{"all namespaces", ExportOptions{Kinds: []string{"deployments"}, Namespace: "prod", AllNamespaces: true}, "deployments in all namespaces"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Selection(); got != tt.want {
+				t.Errorf("Selection() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cluster
+
+import (
+	"fmt"
+)
+
+// Health is the health of a live resource
+type Health string
+
+const (
+	Healthy     Health = "Healthy"
+	Progressing Health = "Progressing"
+	Degraded    Health = "Degraded"
+	Missing     Health = "Missing"
+)
+
+// Assess evaluates the health of a live resource from its status.
+// Kinds without a known status contract are healthy unless a Ready condition says otherwise.
+// It returns the health and a short reason when not healthy.
+func Assess(obj Object) (Health, string) {
+	if obj == nil {
+		return Missing, "not found in the cluster"
+	}
+
+	status, _ := obj["status"].(Object)
+	spec, _ := obj["spec"].(Object)
+	kind, _ := obj["kind"].(string)
+
+	if g, og := number(getPath(obj, "metadata", "generation")), number(status["observedGeneration"]); og > 0 && og < g {
+		return Progressing, "waiting for the controller to observe the latest generation"
+	}
+
+	switch kind {
+	case "Deployment", "StatefulSet", "ReplicaSet":
+		want := int64(1)
+		if r, ok := spec["replicas"]; ok {
+			want = number(r)
+		}
+		field := "availableReplicas"
+		if kind == "StatefulSet" {
+			field = "readyReplicas"
+		}
+		if got := number(status[field]); got < want {
+			return Progressing, fmt.Sprintf("%d/%d replicas ready", got, want)
+		}
+		if kind == "Deployment" && conditionStatus(status, "Progressing") == "False" {
+			return Degraded, conditionMessage(status, "Progressing")
+		}
+	case "DaemonSet":
+		want, got := number(status["desiredNumberScheduled"]), number(status["numberReady"])
+		if got < want {
+			return Progressing, fmt.Sprintf("%d/%d pods ready", got, want)
+		}
+	case "Pod":
+		switch phase, _ := status["phase"].(string); phase {
+		case "Succeeded":
+			return Healthy, ""
+		case "Failed":
+			return Degraded, "pod failed"
+		case "Running":
+			if conditionStatus(status, "Ready") != "True" {
+				return Progressing, "pod not ready"
+			}
+		default:
+			return Progressing, fmt.Sprintf("pod is %s", phase)
+		}
+	case "Job":
+		if conditionStatus(status, "Failed") == "True" {
+			return Degraded, conditionMessage(status, "Failed")
+		}
+		if conditionStatus(status, "Complete") != "True" {
+			return Progressing, "job has not completed"
+		}
+	case "PersistentVolumeClaim":
+		if phase, _ := status["phase"].(string); phase != "Bound" {
+			return Progressing, fmt.Sprintf("claim is %s", phase)
+		}
+	case "CustomResourceDefinition":
+		if conditionStatus(status, "Established") != "True" {
+			return Progressing, "not established"
+		}
+	case "Namespace":
+		if phase, _ := status["phase"].(string); phase != "" && phase != "Active" {
+			return Degraded, fmt.Sprintf("namespace is %s", phase)
+		}
+	case "Service":
+		if t, _ := spec["type"].(string); t == "LoadBalancer" {
+			if ingress, _ := getPath(status, "loadBalancer", "ingress").([]any); len(ingress) == 0 {
+				return Progressing, "waiting for a load balancer"
+			}
+		}
+	default:
+		switch conditionStatus(status, "Ready") {
+		case "False":
+			return Degraded, conditionMessage(status, "Ready")
+		case "Unknown":
+			return Progressing, conditionMessage(status, "Ready")
+		}
+	}
+	return Healthy, ""
+}
+
+func conditionStatus(status Object, condType string) string {
+	if c := condition(status, condType); c != nil {
+		s, _ := c["status"].(string)
+		return s
+	}
+	return ""
+}
+
+func conditionMessage(status Object, condType string) string {
+	if c := condition(status, condType); c != nil {
+		if m, _ := c["message"].(string); m != "" {
+			return m
+		}
+		if r, _ := c["reason"].(string); r != "" {
+			return r
+		}
+	}
+	return fmt.Sprintf("%s condition is not true", condType)
+}
+
+func condition(status Object, condType string) Object {
+	conds, _ := status["conditions"].([]any)
+	for _, c := range conds {
+		if m, ok := c.(Object); ok && m["type"] == condType {
+			return m
+		}
+	}
+	return nil
+}
+
+func getPath(obj Object, path ...string) any {
+	var cur any = obj
+	for _, p := range path {
+		m, ok := cur.(Object)
+		if !ok {
+			return nil
+		}
+		cur = m[p]
+	}
+	return cur
+}
+
+func number(v any) int64 {
+	switch n := v.(type) {
+	case float64:
+		return int64(n)
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	}
+	return 0
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cluster
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAssess(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  string
+		want Health
+	}{
+		{name: "missing", obj: "", want: Missing},
+		{name: "deployment available", obj: `{"kind":"Deployment","spec":{"replicas":2},"status":{"availableReplicas":2}}`, want: Healthy},
+		{name: "deployment rolling out", obj: `{"kind":"Deployment","spec":{"replicas":2},"status":{"availableReplicas":1}}`, want: Progressing},
+		{name: "deployment default replicas", obj: `{"kind":"Deployment","spec":{},"status":{}}`, want: Progressing},
+		{name: "deployment deadline exceeded", obj: `{"kind":"Deployment","spec":{"replicas":1},"status":{"availableReplicas":1,"conditions":[{"type":"Progressing","status":"False","reason":"ProgressDeadlineExceeded"}]}}`, want: Degraded},
+		{name: "stale generation", obj: `{"kind":"Deployment","metadata":{"generation":3},"spec":{"replicas":1},"status":{"observedGeneration":2,"availableReplicas":1}}`, want: Progressing},
+		{name: "statefulset ready", obj: `{"kind":"StatefulSet","spec":{"replicas":1},"status":{"readyReplicas":1}}`, want: Healthy},
+		{name: "daemonset not ready", obj: `{"kind":"DaemonSet","status":{"desiredNumberScheduled":3,"numberReady":2}}`, want: Progressing},
+		{name: "pod running ready", obj: `{"kind":"Pod","status":{"phase":"Running","conditions":[{"type":"Ready","status":"True"}]}}`, want: Healthy},
+		{name: "pod failed", obj: `{"kind":"Pod","status":{"phase":"Failed"}}`, want: Degraded},
+		{name: "job complete", obj: `{"kind":"Job","status":{"conditions":[{"type":"Complete","status":"True"}]}}`, want: Healthy},
+		{name: "job failed", obj: `{"kind":"Job","status":{"conditions":[{"type":"Failed","status":"True"}]}}`, want: Degraded},
+		{name: "pvc pending", obj: `{"kind":"PersistentVolumeClaim","status":{"phase":"Pending"}}`, want: Progressing},
+		{name: "crd established", obj: `{"kind":"CustomResourceDefinition","status":{"conditions":[{"type":"Established","status":"True"}]}}`, want: Healthy},
+		{name: "load balancer pending", obj: `{"kind":"Service","spec":{"type":"LoadBalancer"},"status":{"loadBalancer":{}}}`, want: Progressing},
+		{name: "configmap", obj: `{"kind":"ConfigMap","data":{}}`, want: Healthy},
+		{name: "custom resource not ready", obj: `{"kind":"Widget","status":{"conditions":[{"type":"Ready","status":"False","message":"broken"}]}}`, want: Degraded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var obj Object
+			if tt.obj != "" {
+				if err := json.Unmarshal([]byte(tt.obj), &obj); err != nil {
+					t.Fatalf("invalid test object: %v", err)
+				}
+			}
+			if got, msg := Assess(obj); got != tt.want {
+				t.Errorf("Assess() = %s (%s), want %s", got, msg, tt.want)
+			}
+		})
+	}
+}
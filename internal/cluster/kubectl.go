@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+// Object is a Kubernetes resource decoded from JSON
+type Object = map[string]any
+
+// Get fetches the live state of the resource described by doc from the current cluster
+// using 'kubectl get'. It returns nil if the resource, or its kind, does not exist.
+func Get(ctx context.Context, doc *manifest.Document) (Object, error) {
+	var stdout, stderr bytes.Buffer
+	kubectl := exec.CommandContext(ctx, "kubectl", "get", "-f", "-", "-o", "json", "--ignore-not-found")
+	kubectl.Stdin = bytes.NewReader(doc.Raw)
+	kubectl.Stdout = &stdout
+	kubectl.Stderr = &stderr
+
+	if err := kubectl.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		// The CRD for the kind is not installed
+		if strings.Contains(msg, "no matches for kind") || strings.Contains(msg, "the server doesn't have a resource type") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("kubectl get %s failed: %w: %s", doc, err, msg)
+	}
+
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return nil, nil
+	}
+
+	var obj Object
+	if err := json.Unmarshal(stdout.Bytes(), &obj); err != nil {
+		return nil, fmt.Errorf("failed to decode kubectl output for %s: %w", doc, err)
+	}
+	// A single object is returned as a List with one item
+	if obj["kind"] == "List" {
+		items, _ := obj["items"].([]any)
+		if len(items) == 0 {
+			return nil, nil
+		}
+		item, ok := items[0].(Object)
+		if !ok {
+			return nil, fmt.Errorf("unexpected kubectl output for %s", doc)
+		}
+		return item, nil
+	}
+	return obj, nil
+}
+
+// NamespaceOf returns the namespace of a live object, if it is namespaced.
+func NamespaceOf(obj Object) (string, bool) {
+	ns, ok := getPath(obj, "metadata", "namespace").(string)
+	return ns, ok && ns != ""
+}
+
+// DefaultNamespace returns the namespace of the current kubeconfig context, which namespaced
+// resources without a namespace are created in.
+func DefaultNamespace(ctx context.Context) (string, error) {
+	var stdout, stderr bytes.Buffer
+	kubectl := exec.CommandContext(ctx, "kubectl", "config", "view", "--minify", "-o", "jsonpath={..namespace}")
+	kubectl.Stdout = &stdout
+	kubectl.Stderr = &stderr
+	if err := kubectl.Run(); err != nil {
+		return "", fmt.Errorf("kubectl config view failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if ns := strings.TrimSpace(stdout.String()); ns != "" {
+		return ns, nil
+	}
+	return "default", nil
+}
@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+// APIResource is a kind served by the cluster
+type APIResource struct {
+	APIVersion string
+	Kind       string
+	Namespaced bool
+}
+
+// Discovery is what the cluster serves: its resource kinds and existing namespaces.
+type Discovery struct {
+	resources map[string]APIResource
+	// groupVersions is the set of served apiVersions
+	groupVersions map[string]bool
+	// namespaces is the set of existing namespaces, nil if they could not be listed
+	namespaces map[string]bool
+}
+
+// NewDiscovery returns a Discovery of the given resources and namespaces.
+// A nil namespaces slice means that the existing namespaces are unknown.
+func NewDiscovery(resources []APIResource, namespaces []string) *Discovery {
+	d := &Discovery{resources: make(map[string]APIResource), groupVersions: make(map[string]bool)}
+	for _, r := range resources {
+		d.resources[r.APIVersion+"/"+r.Kind] = r
+		d.groupVersions[r.APIVersion] = true
+	}
+	if namespaces != nil {
+		d.namespaces = make(map[string]bool, len(namespaces))
+		for _, ns := range namespaces {
+			d.namespaces[ns] = true
+		}
+	}
+	return d
+}
+
+// Discover asks the current cluster for the kinds it serves with 'kubectl api-resources' and
+// for its namespaces with 'kubectl get namespaces'. If the namespaces cannot be listed, for
+// example for lack of permissions, they are left unknown and not checked.
+func Discover(ctx context.Context) (*Discovery, error) {
+	var stdout, stderr bytes.Buffer
+	kubectl := exec.CommandContext(ctx, "kubectl", "api-resources")
+	kubectl.Stdout = &stdout
+	kubectl.Stderr = &stderr
+	// kubectl reports unavailable aggregated APIs as an error but still lists the others
+	if err := kubectl.Run(); err != nil && stdout.Len() == 0 {
+		return nil, fmt.Errorf("kubectl api-resources failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	resources, err := parseAPIResources(stdout.String())
+	if err != nil {
+		return nil, err
+	}
+
+	stdout.Reset()
+	kubectl = exec.CommandContext(ctx, "kubectl", "get", "namespaces", "-o", "name")
+	kubectl.Stdout = &stdout
+	var namespaces []string
+	if err := kubectl.Run(); err == nil {
+		namespaces = []string{}
+		for line := range strings.Lines(stdout.String()) {
+			if ns, ok := strings.CutPrefix(strings.TrimSpace(line), "namespace/"); ok {
+				namespaces = append(namespaces, ns)
+			}
+		}
+	}
+	return NewDiscovery(resources, namespaces), nil
+}
+
+// parseAPIResources parses the table printed by 'kubectl api-resources'. Columns are located
+// by their header, since the SHORTNAMES column is empty for most kinds.
+func parseAPIResources(out string) ([]APIResource, error) {
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) == 0 || lines[0] == "" {
+		return nil, nil
+	}
+	header := lines[0]
+	columns := []string{"APIVERSION", "NAMESPACED", "KIND"}
+	starts := make([]int, len(columns))
+	for i, c := range columns {
+		if starts[i] = strings.Index(header, c); starts[i] < 0 {
+			return nil, fmt.Errorf("unexpected kubectl api-resources output: no %s column", c)
+		}
+	}
+	field := func(line string, i int) string {
+		if starts[i] >= len(line) {
+			return ""
+		}
+		f := line[starts[i]:]
+		if end := strings.IndexByte(f, ' '); end >= 0 {
+			f = f[:end]
+		}
+		return f
+	}
+
+	resources := make([]APIResource, 0, len(lines)-1)
+	for _, line := range lines[1:] {
+		r := APIResource{APIVersion: field(line, 0), Namespaced: field(line, 1) == "true", Kind: field(line, 2)}
+		if r.APIVersion == "" || r.Kind == "" {
+			continue
+		}
+		resources = append(resources, r)
+	}
+	return resources, nil
+}
+
+// Problem is a reason why a resource cannot be applied to the cluster
+type Problem struct {
+	Resource string
+	Message  string
+}
+
+func (p Problem) String() string {
+	return p.Resource + ": " + p.Message
+}
+
+// Namespaced reports whether the cluster serves resources of the kind in a namespace, and
+// whether it serves the kind at all.
+func (d *Discovery) Namespaced(apiVersion, kind string) (namespaced, known bool) {
+	r, ok := d.resources[apiVersion+"/"+kind]
+	return r.Namespaced, ok
+}
+
+// Preflight checks that the cluster serves the kind of every resource, counting CRDs and
+// namespaces created by the manifest itself. It returns the problems found, and separately
+// the namespaces that do not exist, since those can be created before applying.
+func (d *Discovery) Preflight(docs []*manifest.Document) ([]Problem, []string) {
+	kinds := maps.Clone(d.resources)
+	groupVersions := maps.Clone(d.groupVersions)
+	created := make(map[string]bool)
+	for _, doc := range docs {
+		switch {
+		case doc.Kind == "Namespace" && doc.APIVersion == "v1":
+			created[doc.Name] = true
+		case doc.Kind == "CustomResourceDefinition" && doc.APIVersion == "apiextensions.k8s.io/v1":
+			for _, r := range crdResources(doc) {
+				kinds[r.APIVersion+"/"+r.Kind] = r
+				groupVersions[r.APIVersion] = true
+			}
+		}
+	}
+
+	var problems []Problem
+	var missing []string
+	for _, doc := range docs {
+		if doc.APIVersion == "" || doc.Kind == "" {
+			continue
+		}
+		r, ok := kinds[doc.APIVersion+"/"+doc.Kind]
+		if !ok {
+			problems = append(problems, Problem{Resource: doc.String(), Message: unservedMessage(doc, groupVersions)})
+			continue
+		}
+		if !r.Namespaced || doc.Namespace == "" || d.namespaces == nil {
+			continue
+		}
+		if !d.namespaces[doc.Namespace] && !created[doc.Namespace] && !slices.Contains(missing, doc.Namespace) {
+			missing = append(missing, doc.Namespace)
+		}
+	}
+	return problems, missing
+}
+
+// unservedMessage explains why the cluster does not serve the kind of doc.
+func unservedMessage(doc *manifest.Document, groupVersions map[string]bool) string {
+	group, _, _ := strings.Cut(doc.APIVersion, "/")
+	switch {
+	case groupVersions[doc.APIVersion]:
+		return fmt.Sprintf("kind %s is not served in %s", doc.Kind, doc.APIVersion)
+	case strings.Contains(group, ".") && !strings.HasSuffix(group, ".k8s.io"):
+		return fmt.Sprintf("no CRD for %s in %s is installed", doc.Kind, doc.APIVersion)
+	default:
+		return fmt.Sprintf("apiVersion %s is not served by the cluster", doc.APIVersion)
+	}
+}
+
+// crdResources returns the kinds defined by a CustomResourceDefinition, one per served version.
+func crdResources(doc *manifest.Document) []APIResource {
+	var crd struct {
+		Spec struct {
+			Group string `yaml:"group"`
+			Scope string `yaml:"scope"`
+			Names struct {
+				Kind string `yaml:"kind"`
+			} `yaml:"names"`
+			Versions []struct {
+				Name   string `yaml:"name"`
+				Served bool   `yaml:"served"`
+			} `yaml:"versions"`
+		} `yaml:"spec"`
+	}
+	if err := yaml.Unmarshal(doc.Raw, &crd); err != nil {
+		return nil
+	}
+	var resources []APIResource
+	for _, v := range crd.Spec.Versions {
+		if !v.Served {
+			continue
+		}
+		resources = append(resources, APIResource{
+			APIVersion: crd.Spec.Group + "/" + v.Name,
+			Kind:       crd.Spec.Names.Kind,
+			Namespaced: crd.Spec.Scope != "Cluster",
+		})
+	}
+	return resources
+}
@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cluster
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+func TestParseAPIResources(t *testing.T) {
+	out := `NAME                              SHORTNAMES   APIVERSION                        NAMESPACED   KIND
+configmaps                        cm           v1                                true         ConfigMap
+namespaces                        ns           v1                                false        Namespace
+deployments                       deploy       apps/v1                           true         Deployment
+widgets                                        example.com/v1                    true         Widget
+`
+	got, err := parseAPIResources(out)
+	if err != nil {
+		t.Fatalf("parseAPIResources failed: %v", err)
+	}
+	want := []APIResource{
+		{APIVersion: "v1", Kind: "ConfigMap", Namespaced: true},
+		{APIVersion: "v1", Kind: "Namespace", Namespaced: false},
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespaced: true},
+		{APIVersion: "example.com/v1", Kind: "Widget", Namespaced: true},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseAPIResources() = %v, want %v", got, want)
+	}
+
+	if _, err := parseAPIResources("NAME SHORTNAMES\nconfigmaps cm\n"); err == nil {
+		t.Error("expected error for output without an APIVERSION column")
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	discovery := NewDiscovery([]APIResource{
+		{APIVersion: "v1", Kind: "ConfigMap", Namespaced: true},
+		{APIVersion: "v1", Kind: "Namespace"},
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespaced: true},
+		{APIVersion: "apiextensions.k8s.io/v1", Kind: "CustomResourceDefinition"},
+	}, []string{"default"})
+
+	tests := []struct {
+		name         string
+		manifest     string
+		wantProblems []string
+		wantMissing  []string
+	}{
+		{
+			name: "all served",
+			manifest: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+  namespace: default
+`,
+		},
+		{
+			name: "unserved apiVersion, kind, and missing CRD",
+			manifest: `apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: old
+---
+apiVersion: apps/v1
+kind: Widget
+metadata:
+  name: w
+---
+apiVersion: example.com/v1
+kind: Gadget
+metadata:
+  name: g
+`,
+			wantProblems: []string{
+				"Deployment old: apiVersion extensions/v1beta1 is not served by the cluster",
+				"Widget w: kind Widget is not served in apps/v1",
+				"Gadget g: no CRD for Gadget in example.com/v1 is installed",
+			},
+		},
+		{
+			name: "CRD and namespace created by the manifest",
+			manifest: `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gadgets.example.com
+spec:
+  group: example.com
+  scope: Namespaced
+  names:
+    kind: Gadget
+  versions:
+    - name: v1
+      served: true
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: app
+---
+apiVersion: example.com/v1
+kind: Gadget
+metadata:
+  name: g
+  namespace: app
+`,
+		},
+		{
+			name: "missing namespaces",
+			manifest: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+  namespace: team
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: b
+  namespace: team
+`,
+			wantMissing: []string{"team"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := manifest.Split([]byte(tt.manifest))
+			if err != nil {
+				t.Fatal(err)
+			}
+			problems, missing := discovery.Preflight(docs)
+			var got []string
+			for _, p := range problems {
+				got = append(got, p.String())
+			}
+			if !slices.Equal(got, tt.wantProblems) {
+				t.Errorf("problems = %q, want %q", got, tt.wantProblems)
+			}
+			if !slices.Equal(missing, tt.wantMissing) {
+				t.Errorf("missing namespaces = %q, want %q", missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestPreflight_UnknownNamespaces(t *testing.T) {
+	discovery := NewDiscovery([]APIResource{{APIVersion: "v1", Kind: "ConfigMap", Namespaced: true}}, nil)
+	docs, err := manifest.Split([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  namespace: team\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems, missing := discovery.Preflight(docs); len(problems) != 0 || len(missing) != 0 {
+		t.Errorf("Preflight() = %v, %v, want no problems when namespaces are unknown", problems, missing)
+	}
+}
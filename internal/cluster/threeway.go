@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cluster
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Kinds of field changes between two manifest versions
+const (
+	// ChangeModified is a field the target version changes, still at its base value in the cluster
+	ChangeModified = "modified"
+	// ChangeApplied is a field the target version changes that already has its target value in the cluster
+	ChangeApplied = "applied"
+	// ChangeDrifted is a field both versions agree on whose live value differs, reverted by applying the target
+	ChangeDrifted = "drifted"
+	// ChangeConflict is a field the target version changes whose live value matches neither version
+	ChangeConflict = "conflict"
+)
+
+// FieldChange is a field whose value differs between a base and a target version of a
+// resource, or between the versions and the live object.
+type FieldChange struct {
+	Path   string
+	Base   any
+	Live   any
+	Target any
+	Kind   string
+}
+
+// ThreeWay compares the fields set in the base or the target version of a resource, and their
+// live values if live is not nil. Fields only present in live, such as defaults and status, are
+// not compared. Without live, every field differing between the versions is ChangeModified.
+func ThreeWay(base, live, target Object) []FieldChange {
+	b, t := flatten(base), flatten(target)
+	var l map[string]any
+	if live != nil {
+		l = flatten(live)
+	}
+
+	paths := make(map[string]bool, len(b)+len(t))
+	for p := range b {
+		paths[p] = true
+	}
+	for p := range t {
+		paths[p] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	var changes []FieldChange
+	for _, p := range sorted {
+		c := FieldChange{Path: p, Base: b[p], Target: t[p]}
+		changed := !reflect.DeepEqual(c.Base, c.Target)
+		if live == nil {
+			if changed {
+				c.Kind = ChangeModified
+				changes = append(changes, c)
+			}
+			continue
+		}
+		c.Live = l[p]
+		switch {
+		case !changed && reflect.DeepEqual(c.Live, c.Base):
+			continue
+		case !changed:
+			c.Kind = ChangeDrifted
+		case reflect.DeepEqual(c.Live, c.Base):
+			c.Kind = ChangeModified
+		case reflect.DeepEqual(c.Live, c.Target):
+			c.Kind = ChangeApplied
+		default:
+			c.Kind = ChangeConflict
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// flatten returns the leaf values of the fields compared by ThreeWay by path, using the path
+// notation of Compare. Of the metadata, only labels and annotations are compared, and status
+// is ignored.
+func flatten(obj Object) map[string]any {
+	fields := make(map[string]any)
+	for k, v := range obj {
+		switch k {
+		case "status":
+		case "metadata":
+			m, _ := v.(Object)
+			for _, field := range []string{"labels", "annotations"} {
+				values, _ := m[field].(Object)
+				for key, value := range values {
+					if field == "annotations" && ignoredAnnotations[key] {
+						continue
+					}
+					fields[fmt.Sprintf("metadata.%s[%s]", field, key)] = value
+				}
+			}
+		default:
+			flattenValue(k, v, fields)
+		}
+	}
+	return fields
+}
+
+func flattenValue(path string, v any, fields map[string]any) {
+	switch n := v.(type) {
+	case Object:
+		if len(n) == 0 {
+			fields[path] = n
+		}
+		for k, child := range n {
+			flattenValue(path+"."+k, child, fields)
+		}
+	case []any:
+		if len(n) == 0 {
+			fields[path] = n
+		}
+		for i, child := range n {
+			flattenValue(fmt.Sprintf("%s[%d]", path, i), child, fields)
+		}
+	default:
+		fields[path] = v
+	}
+}
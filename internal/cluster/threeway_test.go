@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cluster
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestThreeWay(t *testing.T) {
+	decode := func(s string) Object {
+		t.Helper()
+		if s == "" {
+			return nil
+		}
+		var obj Object
+		if err := json.Unmarshal([]byte(s), &obj); err != nil {
+			t.Fatal(err)
+		}
+		return obj
+	}
+	base := `{"kind": "Deployment", "metadata": {"name": "web", "labels": {"app": "web", "tier": "front"}},
+		"spec": {"replicas": 2, "paused": false, "template": {"spec": {"containers": [{"name": "web", "image": "nginx:1.25"}]}}}}`
+	target := `{"kind": "Deployment", "metadata": {"name": "web", "labels": {"app": "web", "team": "a"}},
+		"spec": {"replicas": 4, "paused": false, "template": {"spec": {"containers": [{"name": "web", "image": "nginx:1.26"}]}}}}`
+
+	tests := []struct {
+		name string
+		live string
+		want []FieldChange
+	}{
+		{
+			name: "without live",
+			want: []FieldChange{
+				{Path: "metadata.labels[team]", Target: "a", Kind: ChangeModified},
+				{Path: "metadata.labels[tier]", Base: "front", Kind: ChangeModified},
+				{Path: "spec.replicas", Base: float64(2), Target: float64(4), Kind: ChangeModified},
+				{Path: "spec.template.spec.containers[0].image", Base: "nginx:1.25", Target: "nginx:1.26", Kind: ChangeModified},
+			},
+		},
+		{
+			name: "with live",
+			live: `{"kind": "Deployment", "metadata": {"name": "web", "uid": "x", "labels": {"app": "web", "tier": "front", "team": "a"},
+				"annotations": {"kubectl.kubernetes.io/last-applied-configuration": "{}"}},
+				"spec": {"replicas": 3, "paused": true, "strategy": {"type": "RollingUpdate"},
+				"template": {"spec": {"containers": [{"name": "web", "image": "nginx:1.25"}]}}}, "status": {"replicas": 3}}`,
+			want: []FieldChange{
+				{Path: "metadata.labels[team]", Live: "a", Target: "a", Kind: ChangeApplied},
+				{Path: "metadata.labels[tier]", Base: "front", Live: "front", Kind: ChangeModified},
+				{Path: "spec.paused", Base: false, Live: true, Target: false, Kind: ChangeDrifted},
+				{Path: "spec.replicas", Base: float64(2), Live: float64(3), Target: float64(4), Kind: ChangeConflict},
+				{Path: "spec.template.spec.containers[0].image", Base: "nginx:1.25", Live: "nginx:1.25", Target: "nginx:1.26", Kind: ChangeModified},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ThreeWay(decode(base), decode(tt.live), decode(target))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ThreeWay() =\n%+v\nwant:\n%+v", got, tt.want)
+			}
+		})
+	}
+}
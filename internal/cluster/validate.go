@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Validate validates a manifest against the APIs served by the current cluster, including its
+// CRDs. 'kubectl create --dry-run=client --validate=strict' checks every resource against the
+// cluster's OpenAPI schemas without creating anything, and fails for kinds the cluster does not serve.
+// It returns the validation problems, or an error if the cluster could not be asked.
+func Validate(ctx context.Context, data []byte) ([]string, error) {
+	var stderr bytes.Buffer
+	kubectl := exec.CommandContext(ctx, "kubectl", "create", "--dry-run=client", "--validate=strict", "-o", "name", "-f", "-")
+	kubectl.Stdin = bytes.NewReader(data)
+	kubectl.Stderr = &stderr
+
+	err := kubectl.Run()
+	if err == nil {
+		return nil, nil
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("kubectl create failed: %w", err)
+	}
+	problems := validationProblems(stderr.String())
+	if len(problems) == 0 {
+		return nil, fmt.Errorf("kubectl create failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return problems, nil
+}
+
+// validationProblems extracts the validation problems from the error output of kubectl.
+// Errors that are not about the resources, such as an unreachable cluster, are not included.
+func validationProblems(stderr string) []string {
+	var problems []string
+	for line := range strings.Lines(stderr) {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.Contains(line, "error validating data"):
+			// Drop the hint on turning validation off, it does not apply here
+			line, _, _ = strings.Cut(line, "; if you choose to ignore these errors")
+			_, detail, _ := strings.Cut(line, "error validating data: ")
+			problems = append(problems, strings.TrimSpace(detail))
+		case strings.Contains(line, "resource mapping not found"):
+			// The CRD of the kind is not installed in the cluster
+			if _, detail, found := strings.Cut(line, "no matches for kind"); found {
+				line = "no matches for kind" + detail
+			}
+			problems = append(problems, line)
+		case strings.Contains(line, "strict decoding error"):
+			_, detail, _ := strings.Cut(line, "strict decoding error: ")
+			problems = append(problems, detail)
+		}
+	}
+	return problems
+}
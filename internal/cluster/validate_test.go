@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package cluster
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestValidationProblems(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		want   []string
+	}{
+		{
+			name:   "unknown field",
+			stderr: `error: error validating "STDIN": error validating data: ValidationError(Deployment.spec): unknown field "replica" in io.k8s.api.apps.v1.DeploymentSpec; if you choose to ignore these errors, turn validation off with --validate=false` + "\n",
+			want:   []string{`ValidationError(Deployment.spec): unknown field "replica" in io.k8s.api.apps.v1.DeploymentSpec`},
+		},
+		{
+			name: "missing crd",
+			stderr: `error: resource mapping not found for name: "w" namespace: "" from "STDIN": no matches for kind "Widget" in version "example.com/v1"
+ensure CRDs are installed first
+`,
+			want: []string{`no matches for kind "Widget" in version "example.com/v1"`},
+		},
+		{
+			name:   "strict decoding",
+			stderr: `Error from server (BadRequest): error when creating "STDIN": Deployment in version "v1" cannot be handled as a Deployment: strict decoding error: unknown field "spec.replica"` + "\n",
+			want:   []string{`unknown field "spec.replica"`},
+		},
+		{
+			name:   "unreachable cluster",
+			stderr: "error: couldn't get current server API group list: connection refused\n",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validationProblems(tt.stderr); !slices.Equal(got, tt.want) {
+				t.Errorf("validationProblems() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
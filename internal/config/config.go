@@ -0,0 +1,540 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+	"github.com/chez-shanpu/kubectl-mft/internal/paths"
+)
+
+const (
+	fileName = "config.yaml"
+
+	// DefaultSigningKey is the signing key used when neither a flag nor the configuration selects one
+	DefaultSigningKey = "default"
+
+	// ProxyDirect as the proxy of a registry disables proxying
+	ProxyDirect = "direct"
+)
+
+// Verification modes of a registry, see Registry
+const (
+	// VerifyNever skips signature verification of manifests pulled from the registry
+	VerifyNever = "never"
+	// VerifyRequired refuses to skip signature verification of manifests pulled from the registry
+	VerifyRequired = "required"
+)
+
+// Config is the user configuration stored as config.yaml in the configuration directory.
+type Config struct {
+	// Profile selects the default profile, see paths.SetProfile
+	Profile string  `yaml:"profile,omitempty"`
+	Signing Signing `yaml:"signing"`
+	// VerifyLocal makes apply verify the signature of manifests already in local storage,
+	// not only of the ones it pulls
+	VerifyLocal bool `yaml:"verify-local,omitempty"`
+	// HTTP configures the client used to talk to registries
+	HTTP HTTP `yaml:"http,omitempty"`
+	// Mirrors are tried in order before the origin registry when pulling
+	Mirrors []Mirror `yaml:"mirrors,omitempty"`
+	// Registries configure the treatment of manifests from the registries matching a pattern.
+	// The first matching rule wins.
+	Registries []Registry `yaml:"registries,omitempty"`
+	// Images is the image policy enforced by apply --check-images
+	Images ImagePolicy `yaml:"images,omitempty"`
+	// Namespaces is the namespace policy enforced by apply
+	Namespaces NamespacePolicy `yaml:"namespaces,omitempty"`
+	// Transforms are named mutator pipelines selected with apply --transform
+	Transforms []Transform `yaml:"transforms,omitempty"`
+	// Validation configures manifest validation during pack and release
+	Validation Validation `yaml:"validation,omitempty"`
+	// Hooks are commands run by apply, see Hooks
+	Hooks Hooks `yaml:"hooks,omitempty"`
+	// Snapshot configures the resources exported by pack --from-cluster
+	Snapshot Snapshot `yaml:"snapshot,omitempty"`
+	// Pack configures the metadata stamped on every packed manifest
+	Pack Pack `yaml:"pack,omitempty"`
+	// Cache configures the cache of manifests fetched implicitly, such as by apply
+	Cache Cache `yaml:"cache,omitempty"`
+}
+
+// DefaultCacheTTL is how long manifests fetched implicitly are kept in the cache by default.
+const DefaultCacheTTL = 7 * 24 * time.Hour
+
+// Cache configures the cache of manifests fetched implicitly by apply and dump.
+type Cache struct {
+	// TTL is how long a cached manifest is used before it is evicted and fetched again,
+	// such as "24h" (default: 168h)
+	TTL string `yaml:"ttl,omitempty"`
+}
+
+// TTLDuration returns the parsed TTL, or DefaultCacheTTL if it is unset.
+func (c Cache) TTLDuration() (time.Duration, error) {
+	if c.TTL == "" {
+		return DefaultCacheTTL, nil
+	}
+	ttl, err := time.ParseDuration(c.TTL)
+	if err != nil {
+		return 0, fmt.Errorf("ttl: %w", err)
+	}
+	if ttl <= 0 {
+		return 0, fmt.Errorf("ttl: must be positive")
+	}
+	return ttl, nil
+}
+
+// Pack configures the metadata stamped on every packed manifest.
+type Pack struct {
+	// Annotations maps annotation keys to templates rendered when packing, such as
+	// '{{ env "CI_PIPELINE_ID" }}' or "{{ .Git.RemoteURL }}", see stamp.Data. Annotations of
+	// the workspace and --annotation override them.
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+	// Limits refuse to pack manifests too large for registries and clusters to handle
+	Limits Limits `yaml:"limits,omitempty"`
+}
+
+// Limits are guardrails on the size of packed manifests. Sizes are Kubernetes quantities
+// such as "10Mi". Unset limits are unlimited.
+type Limits struct {
+	// MaxSize is the largest manifest
+	MaxSize string `yaml:"maxSize,omitempty"`
+	// MaxDocuments is the largest number of resources in a manifest
+	MaxDocuments int `yaml:"maxDocuments,omitempty"`
+	// MaxSecretSize is the largest Secret, measured as its YAML document
+	MaxSecretSize string `yaml:"maxSecretSize,omitempty"`
+}
+
+// Parsed returns the limits with their sizes parsed.
+func (l Limits) Parsed() (manifest.Limits, error) {
+	res := manifest.Limits{MaxDocuments: l.MaxDocuments}
+	if l.MaxDocuments < 0 {
+		return res, fmt.Errorf("maxDocuments: must not be negative")
+	}
+	var err error
+	if l.MaxSize != "" {
+		if res.MaxSize, err = manifest.ParseSize(l.MaxSize); err != nil {
+			return res, fmt.Errorf("maxSize: %w", err)
+		}
+	}
+	if l.MaxSecretSize != "" {
+		if res.MaxSecretSize, err = manifest.ParseSize(l.MaxSecretSize); err != nil {
+			return res, fmt.Errorf("maxSecretSize: %w", err)
+		}
+	}
+	return res, nil
+}
+
+// Snapshot configures how live resources are cleaned up when exported from a cluster.
+type Snapshot struct {
+	// Strip are the fields removed from every exported resource, replacing the default
+	// strippers when set
+	Strip []string `yaml:"strip,omitempty"`
+	// ExcludeKinds are the kinds never exported, replacing the default excludes when set
+	ExcludeKinds []string `yaml:"excludeKinds,omitempty"`
+}
+
+// Hooks are commands that receive the rendered manifest on stdin.
+type Hooks struct {
+	// PreApply hooks run before a manifest is applied. A hook exiting with a non-zero
+	// status vetoes the apply.
+	PreApply []Hook `yaml:"preApply,omitempty"`
+}
+
+// Hook is a command run with its arguments, without a shell.
+type Hook struct {
+	Name    string   `yaml:"name"`
+	Command []string `yaml:"command"`
+}
+
+// Validation configures the schemas manifests are validated against.
+type Validation struct {
+	// Catalogs are remote schema location templates, such as the community CRDs-catalog, that
+	// schemas of custom resources are downloaded from and cached
+	Catalogs []string `yaml:"catalogs,omitempty"`
+	// Offline only uses schemas that are already cached, of built-in resources and catalogs
+	Offline bool `yaml:"offline,omitempty"`
+}
+
+// Transform is a named, ordered list of mutators applied to the resources of a manifest.
+type Transform struct {
+	Name     string    `yaml:"name"`
+	Mutators []Mutator `yaml:"mutators"`
+}
+
+// Mutator configures a single mutation. Type selects the mutation and which other fields apply:
+//
+//   - namespace: sets Namespace on namespaced resources
+//   - labels: adds Labels to the metadata of every resource
+//   - annotations: adds Annotations to the metadata of every resource
+//   - image-registry: replaces the registry of container images according to Registries
+//   - resources: sets Limits and Requests on containers that do not set them
+type Mutator struct {
+	Type        string            `yaml:"type"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+	// Registries maps registry hosts such as "docker.io" to their replacement, which may
+	// include a repository prefix such as "registry.internal/dockerhub"
+	Registries map[string]string `yaml:"registries,omitempty"`
+	Limits     map[string]string `yaml:"limits,omitempty"`
+	Requests   map[string]string `yaml:"requests,omitempty"`
+}
+
+// TransformNamed returns the transform with the given name.
+func (c *Config) TransformNamed(name string) (*Transform, bool) {
+	for i := range c.Transforms {
+		if c.Transforms[i].Name == name {
+			return &c.Transforms[i], true
+		}
+	}
+	return nil, false
+}
+
+// ImagePolicy restricts the container images that applied manifests may reference.
+// Patterns match normalized image references such as "docker.io/library/nginx:latest",
+// where "*" matches any sequence of characters.
+type ImagePolicy struct {
+	// Allow lists the allowed image patterns. If empty, every image not denied is allowed.
+	Allow []string `yaml:"allow,omitempty"`
+	// Deny lists image patterns that are refused even if allowed, such as "*:latest"
+	Deny []string `yaml:"deny,omitempty"`
+	// Scanner scans the allowed images for vulnerabilities
+	Scanner *Scanner `yaml:"scanner,omitempty"`
+}
+
+// NamespacePolicy restricts the namespaces that applied manifests may touch, to protect the
+// other tenants of a shared cluster. Patterns match namespace names, where "*" matches any
+// sequence of characters.
+type NamespacePolicy struct {
+	// Allow lists the allowed namespace patterns. If empty, every namespace not denied is allowed.
+	Allow []string `yaml:"allow,omitempty"`
+	// Deny lists namespace patterns that are refused even if allowed, such as "kube-*"
+	Deny []string `yaml:"deny,omitempty"`
+	// DenyClusterScoped refuses cluster-scoped resources, such as ClusterRoles, CRDs, and
+	// webhooks, except for the kinds of AllowClusterScoped
+	DenyClusterScoped bool `yaml:"denyClusterScoped,omitempty"`
+	// AllowClusterScoped lists the cluster-scoped kinds allowed despite DenyClusterScoped, such as "Namespace"
+	AllowClusterScoped []string `yaml:"allowClusterScoped,omitempty"`
+}
+
+// Configured reports whether the policy restricts any namespace or cluster-scoped resource.
+func (p NamespacePolicy) Configured() bool {
+	return len(p.Allow) > 0 || len(p.Deny) > 0 || p.DenyClusterScoped
+}
+
+// Scanner configures the vulnerability scanner run on each image.
+type Scanner struct {
+	// Type is the scanner command, "trivy" or "grype"
+	Type string `yaml:"type"`
+	// Server is the URL of a Trivy server to scan with instead of a local database, for trivy only
+	Server string `yaml:"server,omitempty"`
+	// Severity is the lowest severity that refuses an image: low, medium, high, or critical
+	// (the default)
+	Severity string `yaml:"severity,omitempty"`
+}
+
+// Scanner types
+const (
+	ScannerTrivy = "trivy"
+	ScannerGrype = "grype"
+)
+
+// Configured reports whether the policy restricts any image.
+func (p ImagePolicy) Configured() bool {
+	return len(p.Allow) > 0 || len(p.Deny) > 0 || p.Scanner != nil
+}
+
+// Mirror lists the mirrors of the registries matching a pattern.
+type Mirror struct {
+	// Registry is a registry host pattern such as "docker.io", where "*" matches any sequence of characters
+	Registry string `yaml:"registry"`
+	// Endpoints are mirror hosts, optionally followed by a repository prefix such as
+	// "mirror.internal/dockerhub", in the order they are tried
+	Endpoints []string `yaml:"endpoints"`
+}
+
+// Registry configures the treatment of manifests from the registries matching a pattern.
+type Registry struct {
+	// Registry is a registry host pattern such as "localhost:*", where "*" matches any sequence
+	// of characters. Repositories without a registry are in the registry "local".
+	Registry string `yaml:"registry"`
+	// Verify is the verification mode: VerifyNever, VerifyRequired, or empty to verify unless
+	// verification is skipped with --skip-verify
+	Verify string `yaml:"verify,omitempty"`
+}
+
+// Signing configures which key signs manifests of a repository.
+type Signing struct {
+	// DefaultKey signs repositories not matched by any rule. It defaults to "default".
+	DefaultKey string `yaml:"defaultKey,omitempty"`
+	// Keys maps repository patterns to key names. The first matching rule wins.
+	Keys []KeyRule `yaml:"keys,omitempty"`
+	// TimestampURL is the RFC 3161 timestamping authority that timestamps new signatures
+	TimestampURL string `yaml:"timestampURL,omitempty"`
+	// TimestampRoots is a PEM file of the root certificates of trusted timestamping authorities.
+	// The system roots are trusted if it is empty.
+	TimestampRoots string `yaml:"timestampRoots,omitempty"`
+	// KeysRef is the key distribution artifact holding the public keys of the signer, published
+	// with 'key publish'. New signatures name it, so verifiers can fetch the keys with --fetch-keys.
+	KeysRef string `yaml:"keysRef,omitempty"`
+	// FetchKeys are the fingerprints of the keys --fetch-keys may import for the repositories
+	// matching a pattern. The first matching rule wins.
+	FetchKeys []FetchKeysRule `yaml:"fetchKeys,omitempty"`
+	// TrustGPG verifies PGP signatures against the local GPG keyring. Otherwise PGP signatures
+	// are not trusted, whatever keys the keyring holds.
+	TrustGPG bool `yaml:"trustGPG,omitempty"`
+}
+
+// KeyRule selects the signing key for the repositories matching a pattern.
+type KeyRule struct {
+	// Repository is a repository name pattern such as "registry.company.com/prod/*".
+	// "*" matches any sequence of characters, including "/".
+	// Repositories without a registry are named "local/<name>".
+	Repository string `yaml:"repository"`
+	// Key is the signing key reference, as accepted by --key
+	Key string `yaml:"key"`
+}
+
+// FetchKeysRule names the keys --fetch-keys may import for the repositories matching a pattern.
+type FetchKeysRule struct {
+	// Repository is a repository name pattern, see KeyRule
+	Repository string `yaml:"repository"`
+	// Fingerprints are the fingerprints of the keys, as shown by 'key list'
+	Fingerprints []string `yaml:"fingerprints"`
+}
+
+// HTTP configures the proxy, headers, user agent, and trusted certificate authorities of registry
+// requests. The proxy and certificate authorities also apply to timestamping authorities.
+// Without a proxy, HTTPS_PROXY, HTTP_PROXY, and NO_PROXY are honored.
+type HTTP struct {
+	// UserAgent replaces the default User-Agent header
+	UserAgent string `yaml:"userAgent,omitempty"`
+	// Headers are added to every registry request
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// CAFile is a PEM file of certificate authorities trusted in addition to the system roots
+	CAFile string `yaml:"caFile,omitempty"`
+	// Registries override the settings for the registries matching a pattern. The first matching rule wins.
+	Registries []RegistryHTTP `yaml:"registries,omitempty"`
+}
+
+// RegistryHTTP overrides the HTTP settings for the registries matching a pattern.
+type RegistryHTTP struct {
+	// Registry is a registry host pattern such as "*.company.com", where "*" matches any sequence of characters
+	Registry string `yaml:"registry"`
+	// Proxy is the URL of the proxy used for the registry, ignoring the environment,
+	// or "direct" to connect without a proxy
+	Proxy string `yaml:"proxy,omitempty"`
+	// UserAgent replaces the User-Agent header for the registry
+	UserAgent string `yaml:"userAgent,omitempty"`
+	// Headers are added to the global headers, replacing those with the same name
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// CAFile replaces the global CA file for the registry
+	CAFile string `yaml:"caFile,omitempty"`
+}
+
+// For returns the settings for the registry host: the global settings merged with the first matching rule.
+func (h HTTP) For(registry string) RegistryHTTP {
+	res := RegistryHTTP{Registry: registry, UserAgent: h.UserAgent, Headers: make(map[string]string), CAFile: h.CAFile}
+	for name, value := range h.Headers {
+		res.Headers[name] = value
+	}
+	for _, rule := range h.Registries {
+		if !MatchRepository(rule.Registry, registry) {
+			continue
+		}
+		res.Proxy = rule.Proxy
+		if rule.UserAgent != "" {
+			res.UserAgent = rule.UserAgent
+		}
+		for name, value := range rule.Headers {
+			res.Headers[name] = value
+		}
+		if rule.CAFile != "" {
+			res.CAFile = rule.CAFile
+		}
+		break
+	}
+	return res
+}
+
+// MirrorsFor returns the mirror endpoints of the registry host from the first matching rule.
+func (c *Config) MirrorsFor(registry string) []string {
+	for _, m := range c.Mirrors {
+		if MatchRepository(m.Registry, registry) {
+			return m.Endpoints
+		}
+	}
+	return nil
+}
+
+// VerifyModeFor returns the verification mode of the registry host from the first matching rule.
+func (c *Config) VerifyModeFor(registry string) string {
+	for _, rule := range c.Registries {
+		if MatchRepository(rule.Registry, registry) {
+			return rule.Verify
+		}
+	}
+	return ""
+}
+
+// FetchKeyFingerprintsFor returns the fingerprints of the keys --fetch-keys may import for the
+// repository from the first matching rule.
+func (c *Config) FetchKeyFingerprintsFor(repository string) []string {
+	for _, rule := range c.Signing.FetchKeys {
+		if MatchRepository(rule.Repository, repository) {
+			return rule.Fingerprints
+		}
+	}
+	return nil
+}
+
+// Path returns the path of the configuration file.
+func Path() (string, error) {
+	dir, err := paths.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fileName), nil
+}
+
+// Load reads the configuration file. A missing file yields an empty configuration.
+func Load() (*Config, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse decodes a configuration file. Unknown fields are rejected to catch typos.
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	for i, rule := range cfg.Signing.Keys {
+		if rule.Repository == "" || rule.Key == "" {
+			return nil, fmt.Errorf("signing.keys[%d]: repository and key are required", i)
+		}
+	}
+	for i, m := range cfg.Mirrors {
+		if m.Registry == "" || len(m.Endpoints) == 0 {
+			return nil, fmt.Errorf("mirrors[%d]: registry and endpoints are required", i)
+		}
+	}
+	for i, rule := range cfg.Signing.FetchKeys {
+		if rule.Repository == "" || len(rule.Fingerprints) == 0 {
+			return nil, fmt.Errorf("signing.fetchKeys[%d]: repository and fingerprints are required", i)
+		}
+	}
+	for i, rule := range cfg.Registries {
+		if rule.Registry == "" {
+			return nil, fmt.Errorf("registries[%d]: registry is required", i)
+		}
+		switch rule.Verify {
+		case "", VerifyNever, VerifyRequired:
+		default:
+			return nil, fmt.Errorf("registries[%d]: unsupported verify mode %q, expected %s or %s", i, rule.Verify, VerifyNever, VerifyRequired)
+		}
+	}
+	names := make(map[string]bool)
+	for i, t := range cfg.Transforms {
+		if t.Name == "" || len(t.Mutators) == 0 {
+			return nil, fmt.Errorf("transforms[%d]: name and mutators are required", i)
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("transforms[%d]: duplicate name %q", i, t.Name)
+		}
+		names[t.Name] = true
+	}
+	for i, h := range cfg.Hooks.PreApply {
+		if h.Name == "" || len(h.Command) == 0 || h.Command[0] == "" {
+			return nil, fmt.Errorf("hooks.preApply[%d]: name and command are required", i)
+		}
+	}
+	if sc := cfg.Images.Scanner; sc != nil {
+		switch sc.Type {
+		case ScannerTrivy:
+		case ScannerGrype:
+			if sc.Server != "" {
+				return nil, fmt.Errorf("images.scanner: server is only supported by %s", ScannerTrivy)
+			}
+		default:
+			return nil, fmt.Errorf("images.scanner: unsupported type %q, expected %s or %s", sc.Type, ScannerTrivy, ScannerGrype)
+		}
+		switch strings.ToLower(sc.Severity) {
+		case "", "low", "medium", "high", "critical":
+		default:
+			return nil, fmt.Errorf("images.scanner: unsupported severity %q", sc.Severity)
+		}
+	}
+	if _, err := cfg.Pack.Limits.Parsed(); err != nil {
+		return nil, fmt.Errorf("pack.limits.%w", err)
+	}
+	if _, err := cfg.Cache.TTLDuration(); err != nil {
+		return nil, fmt.Errorf("cache.%w", err)
+	}
+	for i, rule := range cfg.HTTP.Registries {
+		if rule.Registry == "" {
+			return nil, fmt.Errorf("http.registries[%d]: registry is required", i)
+		}
+		if rule.Proxy == "" || rule.Proxy == ProxyDirect {
+			continue
+		}
+		if u, err := url.Parse(rule.Proxy); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("http.registries[%d]: invalid proxy URL %q", i, rule.Proxy)
+		}
+	}
+	return &cfg, nil
+}
+
+// KeyFor returns the signing key for the repository: the key of the first matching rule,
+// otherwise the default key.
+func (s Signing) KeyFor(repository string) string {
+	for _, rule := range s.Keys {
+		if MatchRepository(rule.Repository, repository) {
+			return rule.Key
+		}
+	}
+	if s.DefaultKey != "" {
+		return s.DefaultKey
+	}
+	return DefaultSigningKey
+}
+
+// MatchRepository reports whether the repository name matches pattern,
+// where "*" matches any sequence of characters.
+func MatchRepository(pattern, repository string) bool {
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$").MatchString(repository)
+}
@@ -0,0 +1,250 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMatchRepository(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		repository string
+		want       bool
+	}{
+		{pattern: "registry.company.com/prod/*", repository: "registry.company.com/prod/app", want: true},
+		{pattern: "registry.company.com/prod/*", repository: "registry.company.com/prod/team/app", want: true},
+		{pattern: "registry.company.com/prod/*", repository: "registry.company.com/production/app", want: false},
+		{pattern: "registry.company.com/prod/*", repository: "registry.company.com/dev/app", want: false},
+		{pattern: "*/app", repository: "ghcr.io/org/app", want: true},
+		{pattern: "local/myapp", repository: "local/myapp", want: true},
+		{pattern: "local/myapp", repository: "local/myapp2", want: false},
+		{pattern: "ghcr.io/a.b/*", repository: "ghcr.io/aXb/app", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchRepository(tt.pattern, tt.repository); got != tt.want {
+			t.Errorf("MatchRepository(%q, %q) = %v, want %v", tt.pattern, tt.repository, got, tt.want)
+		}
+	}
+}
+
+func TestSigningKeyFor(t *testing.T) {
+	cfg, err := Parse([]byte(`
+signing:
+  defaultKey: dev
+  keys:
+    - repository: registry.company.com/prod/*
+      key: prod-release
+    - repository: registry.company.com/*
+      key: company
+`))
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		repository string
+		want       string
+	}{
+		{repository: "registry.company.com/prod/app", want: "prod-release"},
+		{repository: "registry.company.com/staging/app", want: "company"},
+		{repository: "local/myapp", want: "dev"},
+	}
+	for _, tt := range tests {
+		if got := cfg.Signing.KeyFor(tt.repository); got != tt.want {
+			t.Errorf("KeyFor(%q) = %q, want %q", tt.repository, got, tt.want)
+		}
+	}
+
+	if got := (Signing{}).KeyFor("local/myapp"); got != DefaultSigningKey {
+		t.Errorf("KeyFor() without config = %q, want %q", got, DefaultSigningKey)
+	}
+}
+
+func TestFetchKeyFingerprintsFor(t *testing.T) {
+	cfg, err := Parse([]byte(`
+signing:
+  fetchKeys:
+    - repository: registry.company.com/team-a/*
+      fingerprints: [sha256:aaa]
+`))
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	if got := cfg.FetchKeyFingerprintsFor("registry.company.com/team-a/app"); !slices.Equal(got, []string{"sha256:aaa"}) {
+		t.Errorf("FetchKeyFingerprintsFor() = %v, want [sha256:aaa]", got)
+	}
+	if got := cfg.FetchKeyFingerprintsFor("registry.company.com/team-b/app"); got != nil {
+		t.Errorf("FetchKeyFingerprintsFor() of an unmatched repository = %v, want nil", got)
+	}
+}
+
+func TestHTTPFor(t *testing.T) {
+	cfg, err := Parse([]byte(`
+http:
+  userAgent: kubectl-mft-ci
+  headers:
+    X-Team: platform
+    X-Env: dev
+  caFile: /etc/ssl/company.pem
+  registries:
+    - registry: "*.company.com"
+      proxy: http://proxy.company.com:3128
+      headers:
+        X-Env: prod
+      caFile: /etc/ssl/registry.pem
+    - registry: registry.company.com
+      proxy: direct
+`))
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	got := cfg.HTTP.For("registry.company.com")
+	if got.Proxy != "http://proxy.company.com:3128" {
+		t.Errorf("Proxy = %q, want the first matching rule", got.Proxy)
+	}
+	if got.Headers["X-Team"] != "platform" || got.Headers["X-Env"] != "prod" {
+		t.Errorf("Headers = %v, want global headers overridden by the rule", got.Headers)
+	}
+	if got.UserAgent != "kubectl-mft-ci" {
+		t.Errorf("UserAgent = %q, want %q", got.UserAgent, "kubectl-mft-ci")
+	}
+	if got.CAFile != "/etc/ssl/registry.pem" {
+		t.Errorf("CAFile = %q, want the rule's CA file", got.CAFile)
+	}
+
+	got = cfg.HTTP.For("ghcr.io")
+	if got.Proxy != "" || got.Headers["X-Env"] != "dev" || got.CAFile != "/etc/ssl/company.pem" {
+		t.Errorf("For(ghcr.io) = %+v, want global settings", got)
+	}
+}
+
+func TestMirrorsFor(t *testing.T) {
+	cfg, err := Parse([]byte(`
+mirrors:
+  - registry: docker.io
+    endpoints:
+      - mirror.internal/dockerhub
+      - mirror2.internal/dockerhub
+  - registry: "*.company.com"
+    endpoints:
+      - mirror.internal/company
+`))
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		registry string
+		want     []string
+	}{
+		{registry: "docker.io", want: []string{"mirror.internal/dockerhub", "mirror2.internal/dockerhub"}},
+		{registry: "registry.company.com", want: []string{"mirror.internal/company"}},
+		{registry: "ghcr.io", want: nil},
+	}
+	for _, tt := range tests {
+		if got := cfg.MirrorsFor(tt.registry); !slices.Equal(got, tt.want) {
+			t.Errorf("MirrorsFor(%q) = %v, want %v", tt.registry, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyModeFor(t *testing.T) {
+	cfg, err := Parse([]byte(`
+registries:
+  - registry: "localhost:*"
+    verify: never
+  - registry: local
+    verify: never
+  - registry: registry.prod
+    verify: required
+`))
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		registry string
+		want     string
+	}{
+		{registry: "localhost:5000", want: VerifyNever},
+		{registry: "local", want: VerifyNever},
+		{registry: "registry.prod", want: VerifyRequired},
+		{registry: "ghcr.io", want: ""},
+	}
+	for _, tt := range tests {
+		if got := cfg.VerifyModeFor(tt.registry); got != tt.want {
+			t.Errorf("VerifyModeFor(%q) = %q, want %q", tt.registry, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "unknown field", data: "signing:\n  defaultkey: dev\n"},
+		{name: "rule without key", data: "signing:\n  keys:\n    - repository: ghcr.io/*\n"},
+		{name: "fetch keys without fingerprints", data: "signing:\n  fetchKeys:\n    - repository: ghcr.io/*\n"},
+		{name: "registry without pattern", data: "http:\n  registries:\n    - proxy: direct\n"},
+		{name: "mirror without endpoints", data: "mirrors:\n  - registry: docker.io\n"},
+		{name: "verify rule without registry", data: "registries:\n  - verify: never\n"},
+		{name: "unknown verify mode", data: "registries:\n  - registry: docker.io\n    verify: always\n"},
+		{name: "invalid proxy", data: "http:\n  registries:\n    - registry: ghcr.io\n      proxy: proxy:3128\n"},
+		{name: "transform without mutators", data: "transforms:\n  - name: prod\n"},
+		{name: "duplicate transform", data: "transforms:\n  - name: prod\n    mutators: [{type: namespace, namespace: a}]\n  - name: prod\n    mutators: [{type: namespace, namespace: b}]\n"},
+		{name: "unknown scanner", data: "images:\n  scanner:\n    type: clair\n"},
+		{name: "grype server", data: "images:\n  scanner:\n    type: grype\n    server: http://trivy:4954\n"},
+		{name: "unknown severity", data: "images:\n  scanner:\n    type: trivy\n    severity: severe\n"},
+		{name: "hook without command", data: "hooks:\n  preApply:\n    - name: policy\n"},
+		{name: "invalid size limit", data: "pack:\n  limits:\n    maxSize: 10MB\n"},
+		{name: "negative document limit", data: "pack:\n  limits:\n    maxDocuments: -1\n"},
+		{name: "invalid cache ttl", data: "cache:\n  ttl: 1w\n"},
+		{name: "negative cache ttl", data: "cache:\n  ttl: -1h\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.data)); err == nil {
+				t.Errorf("Parse() expected error")
+			}
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	t.Setenv("KUBECTL_MFT_CONFIG_DIR", filepath.Join(t.TempDir(), "missing"))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if len(cfg.Signing.Keys) != 0 {
+		t.Errorf("Load() = %+v, want empty config", cfg)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("KUBECTL_MFT_CONFIG_DIR", dir)
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("profile: work\nsigning:\n  defaultKey: dev\n"), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if cfg.Signing.DefaultKey != "dev" {
+		t.Errorf("DefaultKey = %q, want %q", cfg.Signing.DefaultKey, "dev")
+	}
+	if cfg.Profile != "work" {
+		t.Errorf("Profile = %q, want %q", cfg.Profile, "work")
+	}
+}
@@ -0,0 +1,253 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package delta
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// header identifies the patch format and its version.
+const header = "kubectl-mft-delta/v1\n"
+
+// Patch operations. Each operation is written on its own line followed by its argument.
+//
+//	=<n>      copy the next n lines from the base
+//	-<n>      skip the next n lines of the base
+//	+<size>   insert the following size bytes verbatim
+const (
+	opCopy   = '='
+	opSkip   = '-'
+	opInsert = '+'
+)
+
+// MaxEdits is the largest number of inserted and removed lines Diff computes a patch for.
+// The memory of the search grows with its square, and a larger patch saves little over
+// storing the content in full.
+const MaxEdits = 2000
+
+// ErrTooManyChanges is returned by Diff when base and target differ by more than MaxEdits lines.
+var ErrTooManyChanges = errors.New("content differs by too many lines for a delta")
+
+// Diff computes a line-based patch that transforms base into target. It returns
+// ErrTooManyChanges if they differ by more than MaxEdits lines.
+func Diff(base, target []byte) ([]byte, error) {
+	a := splitLines(base)
+	b := splitLines(target)
+
+	// Trim the common prefix and suffix so the diff only runs over the changed region
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && bytes.Equal(a[prefix], b[prefix]) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		bytes.Equal(a[len(a)-1-suffix], b[len(b)-1-suffix]) {
+		suffix++
+	}
+
+	edits, ok := myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], MaxEdits)
+	if !ok {
+		return nil, ErrTooManyChanges
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	writeOp(&buf, opCopy, prefix)
+
+	bi := prefix
+	for i := 0; i < len(edits); {
+		j := i
+		for j < len(edits) && edits[j] == edits[i] {
+			j++
+		}
+		n := j - i
+		switch edits[i] {
+		case opCopy:
+			writeOp(&buf, opCopy, n)
+			bi += n
+		case opSkip:
+			writeOp(&buf, opSkip, n)
+		case opInsert:
+			ins := bytes.Join(b[bi:bi+n], nil)
+			writeOp(&buf, opInsert, len(ins))
+			buf.Write(ins)
+			bi += n
+		}
+		i = j
+	}
+
+	writeOp(&buf, opCopy, suffix)
+	return buf.Bytes(), nil
+}
+
+// Apply reconstructs the target content by applying patch to base.
+// It fails if the patch does not consume the base exactly, which indicates
+// the patch was computed against different content.
+func Apply(base, patch []byte) ([]byte, error) {
+	r := bufio.NewReader(bytes.NewReader(patch))
+
+	h, err := r.ReadString('\n')
+	if err != nil || h != header {
+		return nil, fmt.Errorf("invalid delta patch: missing %q header", header[:len(header)-1])
+	}
+
+	lines := splitLines(base)
+	pos := 0
+
+	var out bytes.Buffer
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF && line == "" {
+			break
+		}
+		if err != nil || len(line) < 2 {
+			return nil, fmt.Errorf("invalid delta patch: truncated operation")
+		}
+
+		n, err := strconv.Atoi(line[1 : len(line)-1])
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid delta patch: bad operation %q", line[:len(line)-1])
+		}
+
+		switch line[0] {
+		case opCopy:
+			if pos+n > len(lines) {
+				return nil, fmt.Errorf("delta patch does not match base content: copy past end of base")
+			}
+			for _, l := range lines[pos : pos+n] {
+				out.Write(l)
+			}
+			pos += n
+		case opSkip:
+			if pos+n > len(lines) {
+				return nil, fmt.Errorf("delta patch does not match base content: skip past end of base")
+			}
+			pos += n
+		case opInsert:
+			if _, err := io.CopyN(&out, r, int64(n)); err != nil {
+				return nil, fmt.Errorf("invalid delta patch: truncated insert")
+			}
+		default:
+			return nil, fmt.Errorf("invalid delta patch: unknown operation %q", line[0])
+		}
+	}
+
+	if pos != len(lines) {
+		return nil, fmt.Errorf("delta patch does not match base content: %d base lines left unconsumed", len(lines)-pos)
+	}
+
+	return out.Bytes(), nil
+}
+
+func writeOp(buf *bytes.Buffer, op byte, n int) {
+	if n == 0 {
+		return
+	}
+	buf.WriteByte(op)
+	buf.WriteString(strconv.Itoa(n))
+	buf.WriteByte('\n')
+}
+
+// splitLines splits b into lines, keeping the trailing newline on each line.
+// The last line is returned without a newline if b does not end with one.
+func splitLines(b []byte) [][]byte {
+	var lines [][]byte
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			lines = append(lines, b)
+			break
+		}
+		lines = append(lines, b[:i+1])
+		b = b[i+1:]
+	}
+	return lines
+}
+
+// myers returns the shortest edit script transforming a into b, one operation per line,
+// or false if it needs more than maxD insertions and removals. Only the diagonals reachable
+// at each depth are kept for the backtrack, so memory is O(D²) with D bounded by maxD.
+func myers(a, b [][]byte, maxD int) ([]byte, bool) {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil, true
+	}
+
+	maxD = min(maxD, n+m)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+2)
+
+	// trace[d] holds v[offset-d .. offset+d] before depth d is searched
+	var trace [][]int
+	depth := -1
+search:
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && bytes.Equal(a[x], b[y]) {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				depth = d
+				break search
+			}
+		}
+	}
+	if depth < 0 {
+		return nil, false
+	}
+
+	// Walk the trace backwards to recover the edit script
+	var edits []byte
+	x, y := n, m
+	for d := depth; d > 0; d-- {
+		// at returns v[offset+k] of depth d, stored at index k+d of trace[d]
+		at := func(k int) int { return trace[d][k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, opCopy)
+			x--
+			y--
+		}
+		if x == prevX {
+			edits = append(edits, opInsert)
+		} else {
+			edits = append(edits, opSkip)
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		edits = append(edits, opCopy)
+		x--
+		y--
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits, true
+}
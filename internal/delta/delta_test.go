@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package delta
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiffApplyRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		base   string
+		target string
+	}{
+		{
+			name:   "identical content",
+			base:   "a\nb\nc\n",
+			target: "a\nb\nc\n",
+		},
+		{
+			name:   "single line changed",
+			base:   "apiVersion: v1\nkind: ConfigMap\ndata:\n  key: old\n",
+			target: "apiVersion: v1\nkind: ConfigMap\ndata:\n  key: new\n",
+		},
+		{
+			name:   "lines inserted and removed",
+			base:   "a\nb\nc\nd\ne\n",
+			target: "a\nx\nc\ne\nf\ng\n",
+		},
+		{
+			name:   "empty base",
+			base:   "",
+			target: "a\nb\n",
+		},
+		{
+			name:   "empty target",
+			base:   "a\nb\n",
+			target: "",
+		},
+		{
+			name:   "missing trailing newline",
+			base:   "a\nb",
+			target: "a\nb\nc",
+		},
+		{
+			name:   "multi-document manifest",
+			base:   "kind: A\n---\nkind: B\n---\nkind: C\n",
+			target: "kind: A\n---\nkind: B2\n---\nkind: C\n---\nkind: D\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := Diff([]byte(tt.base), []byte(tt.target))
+			if err != nil {
+				t.Fatalf("Diff() unexpected error: %v", err)
+			}
+
+			got, err := Apply([]byte(tt.base), patch)
+			if err != nil {
+				t.Fatalf("Apply() unexpected error: %v", err)
+			}
+			if string(got) != tt.target {
+				t.Errorf("Apply() = %q, want %q", got, tt.target)
+			}
+		})
+	}
+}
+
+func TestDiffIsSmallerForSmallChanges(t *testing.T) {
+	var base strings.Builder
+	for i := 0; i < 1000; i++ {
+		base.WriteString("  - name: container-with-a-reasonably-long-name\n")
+	}
+	target := strings.Replace(base.String(), "container-with", "changed-with", 1)
+
+	patch, err := Diff([]byte(base.String()), []byte(target))
+	if err != nil {
+		t.Fatalf("Diff() unexpected error: %v", err)
+	}
+	if len(patch) >= len(target)/10 {
+		t.Errorf("patch size %d should be much smaller than target size %d", len(patch), len(target))
+	}
+}
+
+func TestDiffTooManyChanges(t *testing.T) {
+	var base, target strings.Builder
+	for i := 0; i < MaxEdits; i++ {
+		fmt.Fprintf(&base, "base-%d\n", i)
+		fmt.Fprintf(&target, "target-%d\n", i)
+	}
+	if _, err := Diff([]byte(base.String()), []byte(target.String())); !errors.Is(err, ErrTooManyChanges) {
+		t.Errorf("Diff() error = %v, want ErrTooManyChanges", err)
+	}
+
+	// Just below the limit a patch is still computed
+	half := strings.Join(strings.SplitAfter(target.String(), "\n")[:MaxEdits/2-1], "")
+	if _, err := Diff([]byte(half), nil); err != nil {
+		t.Errorf("Diff() below MaxEdits error = %v", err)
+	}
+}
+
+func TestApplyRejectsMismatchedBase(t *testing.T) {
+	patch, err := Diff([]byte("a\nb\nc\n"), []byte("a\nx\nc\n"))
+	if err != nil {
+		t.Fatalf("Diff() unexpected error: %v", err)
+	}
+
+	if _, err := Apply([]byte("a\n"), patch); err == nil {
+		t.Error("Apply() expected error for mismatched base, got nil")
+	}
+}
+
+func TestApplyRejectsInvalidPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch []byte
+	}{
+		{name: "missing header", patch: []byte("=1\n")},
+		{name: "unknown operation", patch: []byte(header + "?1\n")},
+		{name: "bad count", patch: []byte(header + "=x\n")},
+		{name: "truncated insert", patch: []byte(header + "+10\nabc")},
+		{name: "empty operation", patch: []byte(header + "\n")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Apply([]byte("a\n"), tt.patch); err == nil {
+				t.Error("Apply() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	got := splitLines([]byte("a\nb\nc"))
+	want := [][]byte{[]byte("a\n"), []byte("b\n"), []byte("c")}
+	if len(got) != len(want) {
+		t.Fatalf("splitLines() returned %d lines, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !bytes.Equal(got[i], want[i]) {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+// Package gitexport writes manifests into a Git repository and pushes them, so that Git-based
+// delivery such as Argo CD or Flux picks them up.
+package gitexport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Scheme is the prefix of Git repository references
+const Scheme = "git+"
+
+// Reference is a path on a branch of a Git repository, such as
+// "git+ssh://git@github.com/org/gitops.git//clusters/prod/app?ref=main".
+type Reference struct {
+	// URL is the URL git clones, without the git+ prefix
+	URL string
+	// Path is the directory in the repository the manifests are written to, empty for the root
+	Path string
+	// Branch is the branch to commit to, empty for the default branch of the repository
+	Branch string
+}
+
+// IsReference reports whether ref refers to a Git repository.
+func IsReference(ref string) bool {
+	return strings.HasPrefix(ref, Scheme) && strings.Contains(ref, "://")
+}
+
+// ParseReference parses a reference of the form "git+<url>[//<path>][?ref=<branch>]", where
+// url is an ssh://, https://, http://, or file:// URL of the repository.
+func ParseReference(ref string) (Reference, error) {
+	if !IsReference(ref) {
+		return Reference{}, fmt.Errorf("%q is not a Git repository reference, expected git+<url>[//<path>][?ref=<branch>]", ref)
+	}
+	u, err := url.Parse(strings.TrimPrefix(ref, Scheme))
+	if err != nil {
+		return Reference{}, fmt.Errorf("invalid Git repository reference %q: %w", ref, err)
+	}
+	switch u.Scheme {
+	case "ssh", "https", "http", "file":
+	default:
+		return Reference{}, fmt.Errorf("invalid Git repository reference %q: unsupported scheme %q", ref, u.Scheme)
+	}
+	var res Reference
+	query := u.Query()
+	res.Branch = query.Get("ref")
+	query.Del("ref")
+	u.RawQuery = query.Encode()
+
+	repoPath, dir, _ := strings.Cut(u.Path, "//")
+	u.Path, u.RawPath = repoPath, ""
+	if strings.Trim(repoPath, "/") == "" {
+		return Reference{}, fmt.Errorf("invalid Git repository reference %q: empty repository path", ref)
+	}
+	if dir != "" {
+		dir = path.Clean(dir)
+		if dir == ".." || strings.HasPrefix(dir, "../") {
+			return Reference{}, fmt.Errorf("invalid Git repository reference %q: path leaves the repository", ref)
+		}
+		if dir != "." {
+			res.Path = strings.Trim(dir, "/")
+		}
+	}
+	res.URL = u.String()
+	return res, nil
+}
+
+func (r Reference) String() string {
+	s := Scheme + r.URL
+	if r.Path != "" {
+		s += "//" + r.Path
+	}
+	if r.Branch != "" {
+		s += "?ref=" + url.QueryEscape(r.Branch)
+	}
+	return s
+}
+
+// Export clones the branch of ref, replaces the files of its path with files, keyed by their
+// name relative to the path, and commits and pushes them with message. Other files of the
+// path are left in place. It returns the commit pushed, or an empty string if the branch
+// already had the content.
+func Export(ctx context.Context, ref Reference, files map[string][]byte, message string) (string, error) {
+	dir, err := os.MkdirTemp("", "kubectl-mft-git-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	clone := []string{"clone", "--depth", "1", "--quiet"}
+	if ref.Branch != "" {
+		clone = append(clone, "--branch", ref.Branch)
+	}
+	if _, err := git(ctx, "", append(clone, "--", ref.URL, dir)...); err != nil {
+		return "", err
+	}
+	branch := ref.Branch
+	if branch == "" {
+		// Also resolves the branch of an empty repository, which has no commit yet
+		if branch, err = git(ctx, dir, "symbolic-ref", "--short", "HEAD"); err != nil {
+			return "", err
+		}
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(ref.Path))
+	for name, data := range files {
+		p := filepath.Join(target, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return "", fmt.Errorf("failed to create directory for %s: %w", name, err)
+		}
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	pathspec := ref.Path
+	if pathspec == "" {
+		pathspec = "."
+	}
+	if _, err := git(ctx, dir, "add", "--all", "--", pathspec); err != nil {
+		return "", err
+	}
+	if status, err := git(ctx, dir, "status", "--porcelain", "--", pathspec); err != nil {
+		return "", err
+	} else if status == "" {
+		return "", nil
+	}
+	if _, err := git(ctx, dir, "commit", "--quiet", "--message", message); err != nil {
+		return "", err
+	}
+	commit, err := git(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if _, err := git(ctx, dir, "push", "--quiet", "origin", "HEAD:refs/heads/"+branch); err != nil {
+		return "", err
+	}
+	return commit, nil
+}
+
+// git runs git in dir and returns its trimmed standard output.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Fail instead of prompting for credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
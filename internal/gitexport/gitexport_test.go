@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package gitexport
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    Reference
+		wantErr bool
+	}{
+		{
+			ref:  "git+ssh://git@github.com/org/gitops.git//clusters/prod/app?ref=main",
+			want: Reference{URL: "ssh://git@github.com/org/gitops.git", Path: "clusters/prod/app", Branch: "main"},
+		},
+		{
+			ref:  "git+https://github.com/org/gitops.git",
+			want: Reference{URL: "https://github.com/org/gitops.git"},
+		},
+		{
+			ref:  "git+file:///srv/git/gitops.git//app/",
+			want: Reference{URL: "file:///srv/git/gitops.git", Path: "app"},
+		},
+		{ref: "git+https://github.com/org/gitops.git//../etc", wantErr: true},
+		{ref: "git+https://github.com", wantErr: true},
+		{ref: "git+ftp://example.com/gitops.git", wantErr: true},
+		{ref: "git@github.com:org/gitops.git", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := ParseReference(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseReference() = %+v, want %+v", got, tt.want)
+			}
+			if !tt.wantErr {
+				if again, err := ParseReference(got.String()); err != nil || again != got {
+					t.Errorf("ParseReference(String()) = %+v, %v, want %+v", again, err, got)
+				}
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	for k, v := range map[string]string{
+		"GIT_AUTHOR_NAME": "test", "GIT_AUTHOR_EMAIL": "test@example.com",
+		"GIT_COMMITTER_NAME": "test", "GIT_COMMITTER_EMAIL": "test@example.com",
+		"GIT_CONFIG_GLOBAL": os.DevNull, "GIT_CONFIG_NOSYSTEM": "1",
+	} {
+		t.Setenv(k, v)
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	bare := filepath.Join(dir, "gitops.git")
+	work := filepath.Join(dir, "work")
+	run := func(dir string, args ...string) string {
+		t.Helper()
+		out, err := git(ctx, dir, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	run("", "init", "--quiet", "--bare", "--initial-branch", "main", bare)
+	run("", "init", "--quiet", "--initial-branch", "main", work)
+	if err := os.WriteFile(filepath.Join(work, "README.md"), []byte("gitops\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run(work, "add", "README.md")
+	run(work, "commit", "--quiet", "--message", "init")
+	run(work, "push", "--quiet", bare, "main")
+
+	ref := Reference{URL: "file://" + filepath.ToSlash(bare), Path: "apps/app", Branch: "main"}
+	files := map[string][]byte{"manifest.yaml": []byte("kind: ConfigMap\n")}
+	commit, err := Export(ctx, ref, files, "Export app:v1")
+	if err != nil {
+		t.Fatalf("Export() failed: %v", err)
+	}
+	if commit == "" {
+		t.Fatal("Export() did not commit")
+	}
+	if got := run("", "--git-dir", bare, "rev-parse", "main"); got != commit {
+		t.Errorf("main = %s, want %s", got, commit)
+	}
+	if got := run("", "--git-dir", bare, "show", "main:apps/app/manifest.yaml"); got != "kind: ConfigMap" {
+		t.Errorf("exported manifest = %q", got)
+	}
+
+	// Exporting the same content again does not commit
+	commit, err = Export(ctx, ref, files, "Export app:v1")
+	if err != nil {
+		t.Fatalf("Export() of unchanged content failed: %v", err)
+	}
+	if commit != "" {
+		t.Errorf("Export() of unchanged content committed %s", commit)
+	}
+}
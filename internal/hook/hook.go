@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+// Package hook runs user commands that inspect a rendered manifest and may veto an operation.
+package hook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Hook is a command run with its arguments, without a shell
+type Hook struct {
+	Name    string
+	Command []string
+}
+
+// Run runs the hook with the manifest on stdin and env added to the environment. The output of
+// the hook is written to out. A hook exiting with a non-zero status rejects the manifest, and
+// the returned error holds the last line the hook printed as the reason.
+func (h Hook) Run(ctx context.Context, manifest []byte, env []string, out io.Writer) error {
+	if len(h.Command) == 0 {
+		return fmt.Errorf("hook %s has no command", h.Name)
+	}
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(manifest)
+	cmd.Stdout = io.MultiWriter(out, &output)
+	cmd.Stderr = io.MultiWriter(out, &output)
+	cmd.Env = append(os.Environ(), env...)
+
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return fmt.Errorf("failed to run hook %s: %w", h.Name, err)
+	}
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if reason := strings.TrimSpace(lines[len(lines)-1]); reason != "" {
+		return fmt.Errorf("hook %s rejected the manifest (%s): %s", h.Name, exitErr, reason)
+	}
+	return fmt.Errorf("hook %s rejected the manifest (%s)", h.Name, exitErr)
+}
@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package hook
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHookRun(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "check.sh")
+	// Rejects manifests with a Secret, and reports the tag it was given
+	err := os.WriteFile(script, []byte(`#!/bin/sh
+echo "checking $KUBECTL_MFT_TAG"
+if grep -q "kind: Secret"; then
+  echo "Secrets are not allowed" >&2
+  exit 3
+fi
+`), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		hook     Hook
+		manifest string
+		wantErr  string
+	}{
+		{name: "accepted", hook: Hook{Name: "check", Command: []string{script}}, manifest: "kind: ConfigMap\n"},
+		{name: "rejected", hook: Hook{Name: "check", Command: []string{script}}, manifest: "kind: Secret\n", wantErr: "hook check rejected the manifest (exit status 3): Secrets are not allowed"},
+		{name: "arguments", hook: Hook{Name: "false", Command: []string{"sh", "-c", "exit 1"}}, wantErr: "hook false rejected the manifest (exit status 1)"},
+		{name: "missing command", hook: Hook{Name: "missing", Command: []string{filepath.Join(dir, "missing")}}, wantErr: "failed to run hook missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			err := tt.hook.Run(context.Background(), []byte(tt.manifest), []string{"KUBECTL_MFT_TAG=app:v1"}, &out)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Run failed: %v", err)
+				}
+				if !strings.Contains(out.String(), "checking app:v1") {
+					t.Errorf("output = %q, want the tag from the environment", out.String())
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("Run() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+// Package imagepolicy checks the container images of manifests against the configured image policy.
+package imagepolicy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+// Violation is an image refused by the policy.
+type Violation struct {
+	Image string
+	// Resources are the resources referencing the image, such as "Deployment default/app"
+	Resources []string
+	Reason    string
+}
+
+func (v *Violation) String() string {
+	return fmt.Sprintf("%s (%s): %s", v.Image, strings.Join(v.Resources, ", "), v.Reason)
+}
+
+// Checker checks images against an image policy.
+type Checker struct {
+	policy config.ImagePolicy
+	// scan returns the vulnerabilities of an image, it is replaced in tests
+	scan func(ctx context.Context, image string) ([]Vulnerability, error)
+}
+
+func NewChecker(policy config.ImagePolicy) *Checker {
+	c := &Checker{policy: policy}
+	if policy.Scanner != nil {
+		c.scan = func(ctx context.Context, image string) ([]Vulnerability, error) {
+			return scan(ctx, policy.Scanner, image)
+		}
+	}
+	return c
+}
+
+// Check returns the images referenced by docs that the policy refuses. Denied and unlisted
+// images are refused without scanning them.
+func (c *Checker) Check(ctx context.Context, docs []*manifest.Document) ([]*Violation, error) {
+	resources := make(map[string][]string)
+	var images []string
+	for _, d := range docs {
+		refs, err := d.Images()
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range refs {
+			if _, ok := resources[image]; !ok {
+				images = append(images, image)
+			}
+			resources[image] = append(resources[image], d.String())
+		}
+	}
+	slices.Sort(images)
+
+	var violations []*Violation
+	for _, image := range images {
+		reason, err := c.checkImage(ctx, image)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			violations = append(violations, &Violation{Image: image, Resources: resources[image], Reason: reason})
+		}
+	}
+	return violations, nil
+}
+
+// checkImage returns why the image is refused, or an empty string if it is not.
+func (c *Checker) checkImage(ctx context.Context, image string) (string, error) {
+	name := manifest.NormalizeImage(image)
+	for _, pattern := range c.policy.Deny {
+		if config.MatchRepository(pattern, name) {
+			return fmt.Sprintf("denied by %q", pattern), nil
+		}
+	}
+	if len(c.policy.Allow) > 0 && !slices.ContainsFunc(c.policy.Allow, func(pattern string) bool {
+		return config.MatchRepository(pattern, name)
+	}) {
+		return fmt.Sprintf("%s matches no allowed pattern", name), nil
+	}
+	if c.scan == nil {
+		return "", nil
+	}
+
+	vulns, err := c.scan(ctx, image)
+	if err != nil {
+		return "", err
+	}
+	threshold := parseSeverity(c.policy.Scanner.Severity)
+	if threshold == severityUnknown {
+		threshold = severityCritical
+	}
+	var ids []string
+	for _, v := range vulns {
+		if parseSeverity(v.Severity) >= threshold && !slices.Contains(ids, v.ID) {
+			ids = append(ids, v.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return "", nil
+	}
+	slices.Sort(ids)
+	listed := ids
+	if len(listed) > 3 {
+		listed = append(listed[:3:3], "...")
+	}
+	noun := "vulnerabilities"
+	if len(ids) == 1 {
+		noun = "vulnerability"
+	}
+	return fmt.Sprintf("%d %s of severity %s or higher (%s)",
+		len(ids), noun, threshold, strings.Join(listed, ", ")), nil
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package imagepolicy
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+const testManifest = `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  namespace: default
+spec:
+  containers:
+    - name: web
+      image: nginx
+    - name: app
+      image: registry.example.com/app:v1
+    - name: vulnerable
+      image: registry.example.com/legacy:v1
+`
+
+func TestCheck(t *testing.T) {
+	docs, err := manifest.Split([]byte(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vulns := map[string][]Vulnerability{
+		"registry.example.com/legacy:v1": {
+			{ID: "CVE-2024-0002", Severity: "HIGH"},
+			{ID: "CVE-2024-0001", Severity: "Critical"},
+			{ID: "CVE-2024-0003", Severity: "LOW"},
+		},
+	}
+	fakeScan := func(_ context.Context, image string) ([]Vulnerability, error) {
+		return vulns[image], nil
+	}
+
+	tests := []struct {
+		name    string
+		policy  config.ImagePolicy
+		want    []string
+		reasons []string
+	}{
+		{
+			name:   "deny",
+			policy: config.ImagePolicy{Deny: []string{"*:latest"}},
+			want:   []string{"nginx"},
+		},
+		{
+			name:   "allow",
+			policy: config.ImagePolicy{Allow: []string{"registry.example.com/*"}},
+			want:   []string{"nginx"},
+		},
+		{
+			name:   "deny overrides allow",
+			policy: config.ImagePolicy{Allow: []string{"*"}, Deny: []string{"*/legacy:*"}},
+			want:   []string{"registry.example.com/legacy:v1"},
+		},
+		{
+			name:    "critical vulnerabilities",
+			policy:  config.ImagePolicy{Scanner: &config.Scanner{Type: config.ScannerTrivy}},
+			want:    []string{"registry.example.com/legacy:v1"},
+			reasons: []string{"1 vulnerability of severity critical or higher (CVE-2024-0001)"},
+		},
+		{
+			name:    "high vulnerabilities",
+			policy:  config.ImagePolicy{Scanner: &config.Scanner{Type: config.ScannerGrype, Severity: "high"}},
+			want:    []string{"registry.example.com/legacy:v1"},
+			reasons: []string{"2 vulnerabilities of severity high or higher (CVE-2024-0001, CVE-2024-0002)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(tt.policy)
+			if c.scan != nil {
+				c.scan = fakeScan
+			}
+			violations, err := c.Check(context.Background(), docs)
+			if err != nil {
+				t.Fatalf("Check() unexpected error: %v", err)
+			}
+			var got, reasons []string
+			for _, v := range violations {
+				got = append(got, v.Image)
+				reasons = append(reasons, v.Reason)
+				if !slices.Equal(v.Resources, []string{"Pod default/web"}) {
+					t.Errorf("Check() resources = %v, want [Pod default/web]", v.Resources)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Check() images = %v, want %v", got, tt.want)
+			}
+			if tt.reasons != nil && !slices.Equal(reasons, tt.reasons) {
+				t.Errorf("Check() reasons = %v, want %v", reasons, tt.reasons)
+			}
+		})
+	}
+}
+
+func TestParseReports(t *testing.T) {
+	trivy := `{"Results":[{"Target":"app","Vulnerabilities":[{"VulnerabilityID":"CVE-1","Severity":"CRITICAL"}]},{"Target":"lib"}]}`
+	got, err := parseTrivy([]byte(trivy))
+	if err != nil || !slices.Equal(got, []Vulnerability{{ID: "CVE-1", Severity: "CRITICAL"}}) {
+		t.Errorf("parseTrivy() = %v, %v", got, err)
+	}
+
+	grype := `{"matches":[{"vulnerability":{"id":"GHSA-1","severity":"High"}}]}`
+	got, err = parseGrype([]byte(grype))
+	if err != nil || !slices.Equal(got, []Vulnerability{{ID: "GHSA-1", Severity: "High"}}) {
+		t.Errorf("parseGrype() = %v, %v", got, err)
+	}
+}
+
+func TestScanReference(t *testing.T) {
+	tests := []struct {
+		image   string
+		want    string
+		wantErr bool
+	}{
+		{image: "nginx", want: "docker.io/library/nginx:latest"},
+		{image: "registry.example.com:5000/app:v1", want: "registry.example.com:5000/app:v1"},
+		{image: "--config=/etc/passwd", wantErr: true},
+		{image: "-o", wantErr: true},
+		{image: "dir:/etc", wantErr: true},
+		{image: "docker-archive:/tmp/image.tar", wantErr: true},
+		{image: "registry.example.com:https/app", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := scanReference(tt.image)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("scanReference(%q) = %q, want an error", tt.image, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("scanReference(%q) = %q, %v, want %q", tt.image, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package imagepolicy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"oras.land/oras-go/v2/registry"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+// Vulnerability is a vulnerability reported by a scanner.
+type Vulnerability struct {
+	ID       string
+	Severity string
+}
+
+type severity int
+
+const (
+	severityUnknown severity = iota
+	severityLow
+	severityMedium
+	severityHigh
+	severityCritical
+)
+
+func (s severity) String() string {
+	switch s {
+	case severityLow:
+		return "low"
+	case severityMedium:
+		return "medium"
+	case severityHigh:
+		return "high"
+	case severityCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// parseSeverity parses the severities of Trivy (e.g. "HIGH") and Grype (e.g. "High").
+// Negligible and unknown severities never refuse an image.
+func parseSeverity(s string) severity {
+	switch strings.ToLower(s) {
+	case "low":
+		return severityLow
+	case "medium":
+		return severityMedium
+	case "high":
+		return severityHigh
+	case "critical":
+		return severityCritical
+	default:
+		return severityUnknown
+	}
+}
+
+// scan runs the configured scanner on the image and returns the vulnerabilities it found.
+func scan(ctx context.Context, sc *config.Scanner, image string) ([]Vulnerability, error) {
+	ref, err := scanReference(image)
+	if err != nil {
+		return nil, err
+	}
+	var args []string
+	var parse func([]byte) ([]Vulnerability, error)
+	switch sc.Type {
+	case config.ScannerTrivy:
+		args = []string{"image", "--quiet", "--format", "json", "--scanners", "vuln"}
+		if sc.Server != "" {
+			args = append(args, "--server", sc.Server)
+		}
+		parse = parseTrivy
+	case config.ScannerGrype:
+		args = []string{"--quiet", "--output", "json"}
+		parse = parseGrype
+	default:
+		return nil, fmt.Errorf("unsupported scanner %q", sc.Type)
+	}
+
+	var stdout, stderr bytes.Buffer
+	// The image comes from the manifest, so it must never be taken for a flag
+	cmd := exec.CommandContext(ctx, sc.Type, append(args, "--", ref)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed to scan %s: %w: %s", sc.Type, image, err, strings.TrimSpace(stderr.String()))
+	}
+	vulns, err := parse(stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s report of %s: %w", sc.Type, image, err)
+	}
+	return vulns, nil
+}
+
+// scanReference returns the full reference of an image to pass to a scanner, or an error if the
+// image is not an image reference. Images of a manifest are untrusted, and must not be taken for
+// a flag or for a source scheme of the scanner, such as dir:<path> for grype.
+func scanReference(image string) (string, error) {
+	ref, err := registry.ParseReference(manifest.NormalizeImage(image))
+	if err != nil {
+		return "", fmt.Errorf("refusing to scan %q: %w", image, err)
+	}
+	// A scheme parses as a registry with an empty or non-numeric port
+	if _, port, ok := strings.Cut(ref.Registry, ":"); ok && (port == "" || strings.Trim(port, "0123456789") != "") {
+		return "", fmt.Errorf("refusing to scan %q: invalid registry %q", image, ref.Registry)
+	}
+	return ref.String(), nil
+}
+
+// parseTrivy parses the JSON report of 'trivy image'.
+func parseTrivy(data []byte) ([]Vulnerability, error) {
+	var report struct {
+		Results []struct {
+			Vulnerabilities []struct {
+				VulnerabilityID string
+				Severity        string
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	var vulns []Vulnerability
+	for _, r := range report.Results {
+		for _, v := range r.Vulnerabilities {
+			vulns = append(vulns, Vulnerability{ID: v.VulnerabilityID, Severity: v.Severity})
+		}
+	}
+	return vulns, nil
+}
+
+// parseGrype parses the JSON report of grype.
+func parseGrype(data []byte) ([]Vulnerability, error) {
+	var report struct {
+		Matches []struct {
+			Vulnerability struct {
+				ID       string `json:"id"`
+				Severity string `json:"severity"`
+			} `json:"vulnerability"`
+		} `json:"matches"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	var vulns []Vulnerability
+	for _, m := range report.Matches {
+		vulns = append(vulns, Vulnerability{ID: m.Vulnerability.ID, Severity: m.Vulnerability.Severity})
+	}
+	return vulns, nil
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+// Package lock reads and writes lockfiles, which pin the tags of manifests to the digests they
+// pointed to when the lockfile was generated, so that environments can be rebuilt
+// reproducibly after tags are pushed again.
+package lock
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/opencontainers/go-digest"
+	"gopkg.in/yaml.v3"
+)
+
+// header is written at the top of generated lockfiles
+const header = "# Generated by kubectl-mft, do not edit\n"
+
+// File lists artifacts. Without digests, it declares the dependencies a lockfile is generated
+// from, where tags may be semver ranges such as "app:~1.2". With digests, it is a lockfile.
+type File struct {
+	Artifacts []Artifact `yaml:"artifacts"`
+}
+
+// Artifact is a tag, pinned to a manifest digest in lockfiles.
+type Artifact struct {
+	Tag    string `yaml:"tag"`
+	Digest string `yaml:"digest,omitempty"`
+	// Range is the semver range, such as "app:~1.2", that Tag was resolved from, which is
+	// resolved again when the lockfile is regenerated
+	Range string `yaml:"range,omitempty"`
+}
+
+// Reference returns what the artifact is resolved from when the lockfile is generated: its
+// range if it has one, and its tag otherwise.
+func (a Artifact) Reference() string {
+	if a.Range != "" {
+		return a.Range
+	}
+	return a.Tag
+}
+
+// Load reads the file at path.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Parse decodes a dependency file or lockfile. Unknown fields are rejected to catch typos.
+func Parse(data []byte) (*File, error) {
+	var f File
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(f.Artifacts) == 0 {
+		return nil, fmt.Errorf("artifacts: at least one artifact is required")
+	}
+	seen := make(map[string]bool)
+	for i, a := range f.Artifacts {
+		if a.Tag == "" {
+			return nil, fmt.Errorf("artifacts[%d]: tag is required", i)
+		}
+		if seen[a.Tag] {
+			return nil, fmt.Errorf("artifacts[%d]: duplicate tag %q", i, a.Tag)
+		}
+		seen[a.Tag] = true
+		if a.Digest != "" {
+			if _, err := digest.Parse(a.Digest); err != nil {
+				return nil, fmt.Errorf("artifacts[%d]: invalid digest %q: %w", i, a.Digest, err)
+			}
+		}
+	}
+	return &f, nil
+}
+
+// Locked returns an error naming the first artifact without a digest.
+func (f *File) Locked() error {
+	for _, a := range f.Artifacts {
+		if a.Digest == "" {
+			return fmt.Errorf("%s is not pinned to a digest, generate the lockfile with 'kubectl mft lock generate'", a.Tag)
+		}
+	}
+	return nil
+}
+
+// Marshal encodes the file as a generated lockfile.
+func (f *File) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(f); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
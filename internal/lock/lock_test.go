@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package lock
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "dependencies", data: "artifacts:\n- tag: ghcr.io/org/app:~1.2\n- tag: ghcr.io/org/db:v3\n"},
+		{name: "lockfile", data: "artifacts:\n- tag: ghcr.io/org/app:v1.2.3\n  digest: sha256:5778a57faa1a054fd8bab4b78f2b22f92abb651ebe45daee7be9d0e6069e6be3\n"},
+		{name: "empty", data: "", wantErr: true},
+		{name: "lockfile with range", data: "artifacts:\n- tag: ghcr.io/org/app:v1.2.3\n  digest: sha256:5778a57faa1a054fd8bab4b78f2b22f92abb651ebe45daee7be9d0e6069e6be3\n  range: ghcr.io/org/app:~1.2\n"},
+		{name: "missing tag", data: "artifacts:\n- digest: sha256:5778a57faa1a054fd8bab4b78f2b22f92abb651ebe45daee7be9d0e6069e6be3\n", wantErr: true},
+		{name: "duplicate tag", data: "artifacts:\n- tag: app:v1\n- tag: app:v1\n", wantErr: true},
+		{name: "invalid digest", data: "artifacts:\n- tag: app:v1\n  digest: sha256:abc\n", wantErr: true},
+		{name: "unknown field", data: "artifacts:\n- tag: app:v1\n  version: v1\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	f := &File{Artifacts: []Artifact{
+		{Tag: "ghcr.io/org/app:v1.2.3", Digest: "sha256:5778a57faa1a054fd8bab4b78f2b22f92abb651ebe45daee7be9d0e6069e6be3", Range: "ghcr.io/org/app:~1.2"},
+	}}
+	if err := f.Locked(); err != nil {
+		t.Errorf("Locked() = %v", err)
+	}
+	data, err := f.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() of marshaled lockfile failed: %v\n%s", err, data)
+	}
+	if got.Artifacts[0] != f.Artifacts[0] {
+		t.Errorf("round trip = %+v, want %+v", got.Artifacts[0], f.Artifacts[0])
+	}
+	if ref := got.Artifacts[0].Reference(); ref != "ghcr.io/org/app:~1.2" {
+		t.Errorf("Reference() = %q, want the range", ref)
+	}
+
+	if err := (&File{Artifacts: []Artifact{{Tag: "app:v1"}}}).Locked(); err == nil {
+		t.Errorf("Locked() of an unpinned artifact succeeded")
+	}
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ConfigMapKey is the default data key holding the manifest content
+	ConfigMapKey = "manifest.yaml"
+
+	// MaxConfigMapSize is the largest amount of data Kubernetes accepts in a ConfigMap
+	MaxConfigMapSize = 1 << 20
+
+	// AnnotationSource records the artifact the content of a ConfigMap was taken from
+	AnnotationSource = "kubectl-mft.io/source"
+	// AnnotationDigest records the manifest digest of the artifact
+	AnnotationDigest = "kubectl-mft.io/digest"
+	// AnnotationContentDigest records the digest of the content, to detect modifications
+	AnnotationContentDigest = "kubectl-mft.io/content-digest"
+	// annotationContentKey records the data key holding the content
+	annotationContentKey = "kubectl-mft.io/content-key"
+)
+
+// ConfigMap is a Kubernetes ConfigMap holding the content of a manifest artifact.
+type ConfigMap struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   ConfigMapMetadata `yaml:"metadata"`
+	Data       map[string]string `yaml:"data"`
+}
+
+// ConfigMapMetadata is the metadata of a ConfigMap.
+type ConfigMapMetadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// NewConfigMap returns a ConfigMap holding content under key, annotated with the source artifact,
+// its manifest digest, and the digest of the content.
+func NewConfigMap(name, namespace, key string, content []byte, source, manifestDigest string) (*ConfigMap, error) {
+	if len(content) > MaxConfigMapSize {
+		return nil, fmt.Errorf("manifest content is %d bytes, ConfigMaps hold at most %d bytes", len(content), MaxConfigMapSize)
+	}
+	return &ConfigMap{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata: ConfigMapMetadata{
+			Name:      name,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				AnnotationSource:        source,
+				AnnotationDigest:        manifestDigest,
+				AnnotationContentDigest: digest.FromBytes(content).String(),
+				annotationContentKey:    key,
+			},
+		},
+		Data: map[string]string{key: string(content)},
+	}, nil
+}
+
+// Marshal encodes the ConfigMap as YAML.
+func (c *ConfigMap) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseConfigMap decodes a ConfigMap.
+func ParseConfigMap(data []byte) (*ConfigMap, error) {
+	var c ConfigMap
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse ConfigMap: %w", err)
+	}
+	if c.Kind != "ConfigMap" {
+		return nil, fmt.Errorf("expected a ConfigMap, got %q", c.Kind)
+	}
+	return &c, nil
+}
+
+// Content returns the manifest content of the ConfigMap, from key or, if key is empty, from the
+// key recorded when the ConfigMap was created. If the ConfigMap records the digest of the content,
+// the content is checked against it.
+func (c *ConfigMap) Content(key string) ([]byte, error) {
+	if key == "" {
+		key = c.Metadata.Annotations[annotationContentKey]
+	}
+	if key == "" {
+		key = ConfigMapKey
+	}
+	content, ok := c.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("ConfigMap %s has no data key %q", c.Metadata.Name, key)
+	}
+	if want := c.Metadata.Annotations[AnnotationContentDigest]; want != "" {
+		if got := digest.FromString(content).String(); got != want {
+			return nil, fmt.Errorf("content of ConfigMap %s has digest %s, but %s is recorded, it was modified", c.Metadata.Name, got, want)
+		}
+	}
+	return []byte(content), nil
+}
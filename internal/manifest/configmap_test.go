@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConfigMapRoundTrip(t *testing.T) {
+	content := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n---\napiVersion: v1\nkind: Service\nmetadata:\n  name: app\n")
+
+	cm, err := NewConfigMap("app-manifests", "apps", "app.yaml", content, "ghcr.io/org/app:v1", "sha256:abc")
+	if err != nil {
+		t.Fatalf("NewConfigMap() failed: %v", err)
+	}
+	data, err := cm.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+
+	parsed, err := ParseConfigMap(data)
+	if err != nil {
+		t.Fatalf("ParseConfigMap() failed: %v", err)
+	}
+	if parsed.Metadata.Annotations[AnnotationSource] != "ghcr.io/org/app:v1" {
+		t.Errorf("source annotation = %q, want %q", parsed.Metadata.Annotations[AnnotationSource], "ghcr.io/org/app:v1")
+	}
+	got, err := parsed.Content("")
+	if err != nil {
+		t.Fatalf("Content() failed: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("Content() = %q, want %q", got, content)
+	}
+
+	parsed.Data["app.yaml"] += "# edited\n"
+	if _, err := parsed.Content(""); err == nil || !strings.Contains(err.Error(), "modified") {
+		t.Errorf("Content() of a modified ConfigMap error = %v, want modified", err)
+	}
+	if _, err := parsed.Content("other.yaml"); err == nil {
+		t.Error("Content() of a missing key succeeded, want error")
+	}
+}
+
+func TestNewConfigMapTooLarge(t *testing.T) {
+	content := bytes.Repeat([]byte("#"), MaxConfigMapSize+1)
+	if _, err := NewConfigMap("app", "", ConfigMapKey, content, "app:v1", "sha256:abc"); err == nil {
+		t.Error("NewConfigMap() of oversized content succeeded, want error")
+	}
+}
+
+func TestParseConfigMapWrongKind(t *testing.T) {
+	if _, err := ParseConfigMap([]byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: s\n")); err == nil {
+		t.Error("ParseConfigMap() of a Secret succeeded, want error")
+	}
+}
@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// removedAPI is a deprecated API version of a kind that Kubernetes no longer serves.
+type removedAPI struct {
+	removedIn   string
+	replacement string
+}
+
+// removedAPIs are the deprecated API versions removed from Kubernetes, by apiVersion and kind
+var removedAPIs = map[[2]string]removedAPI{
+	{"extensions/v1beta1", "DaemonSet"}:                                        {"1.16", "apps/v1"},
+	{"extensions/v1beta1", "Deployment"}:                                       {"1.16", "apps/v1"},
+	{"extensions/v1beta1", "NetworkPolicy"}:                                    {"1.16", "networking.k8s.io/v1"},
+	{"extensions/v1beta1", "PodSecurityPolicy"}:                                {"1.16", "policy/v1beta1"},
+	{"extensions/v1beta1", "ReplicaSet"}:                                       {"1.16", "apps/v1"},
+	{"apps/v1beta1", "Deployment"}:                                             {"1.16", "apps/v1"},
+	{"apps/v1beta1", "StatefulSet"}:                                            {"1.16", "apps/v1"},
+	{"apps/v1beta2", "DaemonSet"}:                                              {"1.16", "apps/v1"},
+	{"apps/v1beta2", "Deployment"}:                                             {"1.16", "apps/v1"},
+	{"apps/v1beta2", "ReplicaSet"}:                                             {"1.16", "apps/v1"},
+	{"apps/v1beta2", "StatefulSet"}:                                            {"1.16", "apps/v1"},
+	{"extensions/v1beta1", "Ingress"}:                                          {"1.22", "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", "Ingress"}:                                   {"1.22", "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", "IngressClass"}:                              {"1.22", "networking.k8s.io/v1"},
+	{"admissionregistration.k8s.io/v1beta1", "MutatingWebhookConfiguration"}:   {"1.22", "admissionregistration.k8s.io/v1"},
+	{"admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration"}: {"1.22", "admissionregistration.k8s.io/v1"},
+	{"apiextensions.k8s.io/v1beta1", "CustomResourceDefinition"}:               {"1.22", "apiextensions.k8s.io/v1"},
+	{"apiregistration.k8s.io/v1beta1", "APIService"}:                           {"1.22", "apiregistration.k8s.io/v1"},
+	{"certificates.k8s.io/v1beta1", "CertificateSigningRequest"}:               {"1.22", "certificates.k8s.io/v1"},
+	{"coordination.k8s.io/v1beta1", "Lease"}:                                   {"1.22", "coordination.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRole"}:                       {"1.22", "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRoleBinding"}:                {"1.22", "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "Role"}:                              {"1.22", "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "RoleBinding"}:                       {"1.22", "rbac.authorization.k8s.io/v1"},
+	{"scheduling.k8s.io/v1beta1", "PriorityClass"}:                             {"1.22", "scheduling.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSIDriver"}:                                    {"1.22", "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSINode"}:                                      {"1.22", "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "StorageClass"}:                                 {"1.22", "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "VolumeAttachment"}:                             {"1.22", "storage.k8s.io/v1"},
+	{"batch/v1beta1", "CronJob"}:                                               {"1.25", "batch/v1"},
+	{"discovery.k8s.io/v1beta1", "EndpointSlice"}:                              {"1.25", "discovery.k8s.io/v1"},
+	{"events.k8s.io/v1beta1", "Event"}:                                         {"1.25", "events.k8s.io/v1"},
+	{"autoscaling/v2beta1", "HorizontalPodAutoscaler"}:                         {"1.25", "autoscaling/v2"},
+	{"node.k8s.io/v1beta1", "RuntimeClass"}:                                    {"1.25", "node.k8s.io/v1"},
+	{"policy/v1beta1", "PodDisruptionBudget"}:                                  {"1.25", "policy/v1"},
+	{"policy/v1beta1", "PodSecurityPolicy"}:                                    {"1.25", ""},
+	{"autoscaling/v2beta2", "HorizontalPodAutoscaler"}:                         {"1.26", "autoscaling/v2"},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "FlowSchema"}:                     {"1.26", "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "PriorityLevelConfiguration"}:     {"1.26", "flowcontrol.apiserver.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSIStorageCapacity"}:                           {"1.27", "storage.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "FlowSchema"}:                     {"1.29", "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "PriorityLevelConfiguration"}:     {"1.29", "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "FlowSchema"}:                     {"1.32", "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "PriorityLevelConfiguration"}:     {"1.32", "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// DeprecatedAPI is a resource using an API version removed from Kubernetes.
+type DeprecatedAPI struct {
+	// Resource is the resource, such as "CronJob default/backup"
+	Resource   string `json:"resource"`
+	APIVersion string `json:"apiVersion"`
+	// RemovedIn is the Kubernetes version that no longer serves the API version, such as "1.25"
+	RemovedIn string `json:"removedIn"`
+	// Replacement is the API version to use instead, empty if the kind was removed altogether
+	Replacement string `json:"replacement,omitempty"`
+}
+
+func (d *DeprecatedAPI) String() string {
+	if d.Replacement == "" {
+		return fmt.Sprintf("%s: %s is removed in Kubernetes %s without replacement", d.Resource, d.APIVersion, d.RemovedIn)
+	}
+	return fmt.Sprintf("%s: %s is removed in Kubernetes %s, use %s", d.Resource, d.APIVersion, d.RemovedIn, d.Replacement)
+}
+
+// DeprecatedAPIs returns the resources of docs using API versions removed from Kubernetes, in
+// order. If kubernetesVersion is not empty, only API versions removed in that version or
+// earlier are returned.
+func DeprecatedAPIs(docs []*Document, kubernetesVersion string) ([]*DeprecatedAPI, error) {
+	var target *semver.Version
+	if kubernetesVersion != "" {
+		v, err := semver.NewVersion(kubernetesVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Kubernetes version %q: %w", kubernetesVersion, err)
+		}
+		target = v
+	}
+	var res []*DeprecatedAPI
+	for _, d := range docs {
+		api, ok := removedAPIs[[2]string{d.APIVersion, d.Kind}]
+		if !ok {
+			continue
+		}
+		if target != nil && target.LessThan(semver.MustParse(api.removedIn)) {
+			continue
+		}
+		res = append(res, &DeprecatedAPI{Resource: d.String(), APIVersion: d.APIVersion, RemovedIn: api.removedIn, Replacement: api.replacement})
+	}
+	return res, nil
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDeprecatedAPIs(t *testing.T) {
+	docs, err := Split([]byte(`apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+  namespace: default
+---
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: backup
+  namespace: default
+---
+apiVersion: policy/v1beta1
+kind: PodSecurityPolicy
+metadata:
+  name: restricted
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		version string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "any version",
+			want: []string{
+				"Ingress default/web: extensions/v1beta1 is removed in Kubernetes 1.22, use networking.k8s.io/v1",
+				"CronJob default/backup: batch/v1beta1 is removed in Kubernetes 1.25, use batch/v1",
+				"PodSecurityPolicy restricted: policy/v1beta1 is removed in Kubernetes 1.25 without replacement",
+			},
+		},
+		{
+			name:    "target version",
+			version: "1.24",
+			want:    []string{"Ingress default/web: extensions/v1beta1 is removed in Kubernetes 1.22, use networking.k8s.io/v1"},
+		},
+		{
+			name:    "before any removal",
+			version: "v1.21.3",
+		},
+		{
+			name:    "invalid version",
+			version: "latest",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apis, err := DeprecatedAPIs(docs, tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DeprecatedAPIs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, a := range apis {
+				got = append(got, a.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("DeprecatedAPIs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ResourceKey identifies a resource by its API group, kind, namespace, and name, so that the
+// same resource at different API versions is one resource.
+type ResourceKey struct {
+	Group     string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// Key returns the key identifying the resource of the document.
+func (d *Document) Key() ResourceKey {
+	group, _, ok := strings.Cut(d.APIVersion, "/")
+	if !ok {
+		group = ""
+	}
+	return ResourceKey{Group: group, Kind: d.Kind, Namespace: d.Namespace, Name: d.Name}
+}
+
+// String returns the key such as "Deployment.apps default/app".
+func (k ResourceKey) String() string {
+	kind := k.Kind
+	if k.Group != "" {
+		kind += "." + k.Group
+	}
+	if k.Namespace != "" {
+		return fmt.Sprintf("%s %s/%s", kind, k.Namespace, k.Name)
+	}
+	return fmt.Sprintf("%s %s", kind, k.Name)
+}
+
+// Source is a named set of documents, such as the content of an artifact or a file.
+type Source struct {
+	Name string
+	Docs []*Document
+}
+
+// Duplicate is a resource defined more than once.
+type Duplicate struct {
+	Key ResourceKey
+	// Sources are the names of the sources defining the resource, once per definition
+	Sources []string
+	// Conflict is true if the definitions differ. Definitions that only differ in formatting,
+	// comments, or key order are identical.
+	Conflict bool
+}
+
+// Combine concatenates the documents of the sources in order, keeping one definition of
+// resources defined more than once, and returns the duplicates in the order of their first
+// definition. The first definition is kept, or with keepLast the last one, at the position of
+// the first. Documents without a kind or name are always kept.
+func Combine(sources []Source, keepLast bool) ([]*Document, []*Duplicate, error) {
+	var docs []*Document
+	first := make(map[ResourceKey]int)
+	dups := make(map[ResourceKey]*Duplicate)
+	var order []ResourceKey
+	for _, src := range sources {
+		for _, d := range src.Docs {
+			if d.Kind == "" || d.Name == "" {
+				docs = append(docs, d)
+				continue
+			}
+			key := d.Key()
+			i, ok := first[key]
+			if !ok {
+				first[key] = len(docs)
+				dups[key] = &Duplicate{Key: key, Sources: []string{src.Name}}
+				docs = append(docs, d)
+				continue
+			}
+			dup := dups[key]
+			if len(dup.Sources) == 1 {
+				order = append(order, key)
+			}
+			dup.Sources = append(dup.Sources, src.Name)
+			if !dup.Conflict {
+				same, err := sameContent(docs[i], d)
+				if err != nil {
+					return nil, nil, fmt.Errorf("%s: %w", src.Name, err)
+				}
+				dup.Conflict = !same
+			}
+			if keepLast {
+				docs[i] = d
+			}
+		}
+	}
+	res := make([]*Duplicate, len(order))
+	for i, key := range order {
+		res[i] = dups[key]
+	}
+	return docs, res, nil
+}
+
+// sameContent reports whether two documents hold the same data.
+func sameContent(a, b *Document) (bool, error) {
+	var va, vb any
+	if err := yaml.Unmarshal(a.Raw, &va); err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", a, err)
+	}
+	if err := yaml.Unmarshal(b.Raw, &vb); err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", b, err)
+	}
+	return reflect.DeepEqual(va, vb), nil
+}
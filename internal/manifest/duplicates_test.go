@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCombine(t *testing.T) {
+	split := func(s string) []*Document {
+		docs, err := Split([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return docs
+	}
+	a := split(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: platform
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: platform
+spec:
+  replicas: 1
+`)
+	b := split(`# same namespace, other formatting
+apiVersion: v1
+kind: Namespace
+metadata: {name: platform}
+---
+apiVersion: apps/v1beta1
+kind: Deployment
+metadata:
+  name: app
+  namespace: platform
+spec:
+  replicas: 2
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  namespace: platform
+`)
+
+	sources := []Source{{Name: "a:v1", Docs: a}, {Name: "b:v1", Docs: b}}
+	docs, dups, err := Combine(sources, false)
+	if err != nil {
+		t.Fatalf("Combine() unexpected error: %v", err)
+	}
+	var got []string
+	for _, d := range docs {
+		got = append(got, d.String())
+	}
+	want := []string{"Namespace platform", "Deployment platform/app", "ConfigMap platform/app"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Combine() documents = %v, want %v", got, want)
+	}
+	if docs[1] != a[1] {
+		t.Errorf("Combine() did not keep the first definition")
+	}
+
+	if len(dups) != 2 {
+		t.Fatalf("Combine() returned %d duplicates, want 2", len(dups))
+	}
+	tests := []struct {
+		key      string
+		conflict bool
+	}{
+		{key: "Namespace platform", conflict: false},
+		{key: "Deployment.apps platform/app", conflict: true},
+	}
+	for i, tt := range tests {
+		if got := dups[i].Key.String(); got != tt.key {
+			t.Errorf("dups[%d].Key = %q, want %q", i, got, tt.key)
+		}
+		if dups[i].Conflict != tt.conflict {
+			t.Errorf("dups[%d].Conflict = %v, want %v", i, dups[i].Conflict, tt.conflict)
+		}
+		if !slices.Equal(dups[i].Sources, []string{"a:v1", "b:v1"}) {
+			t.Errorf("dups[%d].Sources = %v", i, dups[i].Sources)
+		}
+	}
+
+	docs, _, err = Combine(sources, true)
+	if err != nil {
+		t.Fatalf("Combine() unexpected error: %v", err)
+	}
+	if len(docs) != 3 || docs[0] != b[0] || docs[1] != b[1] {
+		t.Errorf("Combine() with keepLast did not keep the last definitions in place")
+	}
+}
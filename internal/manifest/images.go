@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// containerFields are the fields of a pod spec that hold containers with an image
+var containerFields = []string{"containers", "initContainers", "ephemeralContainers"}
+
+// Images returns the sorted container images referenced by the document, without duplicates.
+// Pod specs are found wherever they are nested, so workloads, CronJobs, and custom resources
+// embedding pod templates are covered alike.
+func (d *Document) Images() ([]string, error) {
+	var obj any
+	if err := yaml.Unmarshal(d.Raw, &obj); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", d, err)
+	}
+	seen := make(map[string]bool)
+	collectImages(obj, func(image string) { seen[image] = true })
+	return slices.Sorted(maps.Keys(seen)), nil
+}
+
+func collectImages(v any, add func(string)) {
+	switch v := v.(type) {
+	case map[string]any:
+		for _, field := range containerFields {
+			containers, _ := v[field].([]any)
+			for _, c := range containers {
+				if c, ok := c.(map[string]any); ok {
+					if image, ok := c["image"].(string); ok && image != "" {
+						add(image)
+					}
+				}
+			}
+		}
+		for _, child := range v {
+			collectImages(child, add)
+		}
+	case []any:
+		for _, child := range v {
+			collectImages(child, add)
+		}
+	}
+}
+
+// SplitImage splits an image reference into its registry and the rest of the reference, as
+// resolved by container runtimes: images without a registry are on docker.io, and official
+// images are in its library/ namespace. For example, "nginx:1.25" is split into "docker.io"
+// and "library/nginx:1.25". index.docker.io, the legacy name of docker.io, becomes docker.io.
+func SplitImage(image string) (registry, rest string) {
+	registry, rest, found := strings.Cut(image, "/")
+	if !found || !strings.ContainsAny(registry, ".:") && registry != "localhost" {
+		registry, rest = "docker.io", image
+	}
+	if registry == "index.docker.io" {
+		registry = "docker.io"
+	}
+	if registry == "docker.io" && !strings.Contains(strings.SplitN(rest, "@", 2)[0], "/") {
+		rest = "library/" + rest
+	}
+	return registry, rest
+}
+
+// NormalizeImage returns the full reference of an image, as split by SplitImage, where images
+// without a tag or digest are tagged latest. For example, "nginx" becomes
+// "docker.io/library/nginx:latest".
+func NormalizeImage(image string) string {
+	registry, rest := SplitImage(image)
+	name, _, hasDigest := strings.Cut(rest, "@")
+	if !hasDigest && !strings.Contains(name[strings.LastIndex(name, "/")+1:], ":") {
+		rest += ":latest"
+	}
+	return registry + "/" + rest
+}
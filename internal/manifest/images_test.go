@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestImages(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{
+			name: "deployment",
+			raw: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: init
+          image: busybox:1.36
+      containers:
+        - name: app
+          image: registry.example.com/app:v1
+        - name: sidecar
+          image: busybox:1.36
+`,
+			want: []string{"busybox:1.36", "registry.example.com/app:v1"},
+		},
+		{
+			name: "cronjob",
+			raw: `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: job
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: job
+              image: alpine
+`,
+			want: []string{"alpine"},
+		},
+		{
+			name: "no pod spec",
+			raw:  "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\ndata:\n  image: nginx\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := Split([]byte(tt.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := docs[0].Images()
+			if err != nil {
+				t.Fatalf("Images() unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Images() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeImage(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"nginx", "docker.io/library/nginx:latest"},
+		{"nginx:1.25", "docker.io/library/nginx:1.25"},
+		{"bitnami/redis:7", "docker.io/bitnami/redis:7"},
+		{"docker.io/nginx", "docker.io/library/nginx:latest"},
+		{"index.docker.io/nginx", "docker.io/library/nginx:latest"},
+		{"index.docker.io/bitnami/redis:7", "docker.io/bitnami/redis:7"},
+		{"ghcr.io/org/app", "ghcr.io/org/app:latest"},
+		{"localhost:5000/app:dev", "localhost:5000/app:dev"},
+		{"localhost/app", "localhost/app:latest"},
+		{"nginx@sha256:abc", "docker.io/library/nginx@sha256:abc"},
+		{"ghcr.io/org/app:v1@sha256:abc", "ghcr.io/org/app:v1@sha256:abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := NormalizeImage(tt.image); got != tt.want {
+				t.Errorf("NormalizeImage(%q) = %q, want %q", tt.image, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"fmt"
+)
+
+// Limits are guardrails on the size of a manifest. Zero values are unlimited.
+type Limits struct {
+	// MaxSize is the largest manifest in bytes
+	MaxSize int64
+	// MaxDocuments is the largest number of resources
+	MaxDocuments int
+	// MaxSecretSize is the largest Secret in bytes, measured as its YAML document
+	MaxSecretSize int64
+}
+
+// Check returns the limits exceeded by the manifest data, each as a sentence naming the
+// offending value and the limit. A manifest exceeding MaxSize is not split into resources.
+func (l Limits) Check(data []byte) ([]string, error) {
+	if l.MaxSize > 0 && int64(len(data)) > l.MaxSize {
+		return []string{fmt.Sprintf("the manifest is %s, more than the maximum size of %s", formatBytes(float64(len(data))), formatBytes(float64(l.MaxSize)))}, nil
+	}
+	if l.MaxDocuments == 0 && l.MaxSecretSize == 0 {
+		return nil, nil
+	}
+	docs, err := Split(data)
+	if err != nil {
+		return nil, err
+	}
+	var exceeded []string
+	if l.MaxDocuments > 0 && len(docs) > l.MaxDocuments {
+		exceeded = append(exceeded, fmt.Sprintf("the manifest has %d resources, more than the maximum of %d", len(docs), l.MaxDocuments))
+	}
+	if l.MaxSecretSize > 0 {
+		for _, d := range docs {
+			if d.Kind != "Secret" || d.APIVersion != "v1" || int64(len(d.Raw)) <= l.MaxSecretSize {
+				continue
+			}
+			exceeded = append(exceeded, fmt.Sprintf("%s is %s, more than the maximum Secret size of %s", d, formatBytes(float64(len(d.Raw))), formatBytes(float64(l.MaxSecretSize))))
+		}
+	}
+	return exceeded, nil
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLimitsCheck(t *testing.T) {
+	secret := "apiVersion: v1\nkind: Secret\nmetadata:\n  name: certs\n  namespace: app\ndata:\n  tls.key: " + strings.Repeat("A", 2048) + "\n"
+	configMap := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n"
+	data := []byte(secret + "---\n" + configMap + "---\n" + configMap)
+
+	tests := []struct {
+		name   string
+		limits Limits
+		want   []string
+	}{
+		{name: "unlimited", limits: Limits{}},
+		{name: "within limits", limits: Limits{MaxSize: 1 << 20, MaxDocuments: 3, MaxSecretSize: 4096}},
+		{name: "size", limits: Limits{MaxSize: 1024, MaxDocuments: 1}, want: []string{"the manifest is 2.2Ki, more than the maximum size of 1Ki"}},
+		{name: "documents", limits: Limits{MaxDocuments: 2}, want: []string{"the manifest has 3 resources, more than the maximum of 2"}},
+		{name: "secret", limits: Limits{MaxSecretSize: 1024}, want: []string{"Secret app/certs is 2.08Ki, more than the maximum Secret size of 1Ki"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.limits.Check(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "10Mi", want: 10 << 20},
+		{in: "500k", want: 500000},
+		{in: "1024", want: 1024},
+		{in: "10MB", wantErr: true},
+		{in: "0", wantErr: true},
+		{in: "500m", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSize(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSize(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSize(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a single resource of a multi-document YAML manifest.
+// Raw holds the original text of the document so it can be re-emitted unchanged.
+type Document struct {
+	Raw         []byte
+	APIVersion  string
+	Kind        string
+	Name        string
+	Namespace   string
+	Annotations map[string]string
+}
+
+type header struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name        string            `yaml:"name"`
+		Namespace   string            `yaml:"namespace"`
+		Annotations map[string]string `yaml:"annotations"`
+	} `yaml:"metadata"`
+}
+
+// Split splits a multi-document YAML manifest at "---" separators.
+// Documents that contain only whitespace or comments are dropped.
+func Split(data []byte) ([]*Document, error) {
+	return Read(bytes.NewReader(data))
+}
+
+// Read reads a multi-document YAML manifest from r and splits it like Split, without
+// holding more than the documents in memory.
+func Read(r io.Reader) ([]*Document, error) {
+	var docs []*Document
+	var buf bytes.Buffer
+
+	flush := func() error {
+		raw := bytes.Clone(buf.Bytes())
+		buf.Reset()
+		if isBlank(raw) {
+			return nil
+		}
+		d, err := parse(raw)
+		if err != nil {
+			return fmt.Errorf("document %d: %w", len(docs)+1, err)
+		}
+		docs = append(docs, d)
+		return nil
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		if line != "" {
+			line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+			if isSeparator(line) {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			} else {
+				buf.WriteString(line)
+				buf.WriteByte('\n')
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// Join concatenates documents into a multi-document YAML manifest.
+func Join(docs []*Document) []byte {
+	var buf bytes.Buffer
+	for i, d := range docs {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(d.Raw)
+	}
+	return buf.Bytes()
+}
+
+// String returns a short identifier of the resource such as "Deployment default/app".
+func (d *Document) String() string {
+	if d.Namespace != "" {
+		return fmt.Sprintf("%s %s/%s", d.Kind, d.Namespace, d.Name)
+	}
+	return fmt.Sprintf("%s %s", d.Kind, d.Name)
+}
+
+// FileNames returns a file name for each document, such as "ns_configmap_app.yaml", or
+// "namespace_ns.yaml" for cluster-scoped resources. Characters other than letters, digits,
+// dots, and dashes are replaced, and names used more than once get a numeric suffix.
+func FileNames(docs []*Document) []string {
+	names := make([]string, len(docs))
+	used := make(map[string]bool)
+	for i, d := range docs {
+		name := d.Name
+		if name == "" {
+			name = fmt.Sprintf("unnamed-%d", i+1)
+		}
+		parts := []string{strings.ToLower(d.Kind), name}
+		if d.Namespace != "" {
+			parts = append([]string{d.Namespace}, parts...)
+		}
+		for j, p := range parts {
+			parts[j] = sanitizeFileName(p)
+		}
+		base := strings.Join(parts, "_")
+
+		fileName := base + ".yaml"
+		for n := 2; used[fileName]; n++ {
+			fileName = fmt.Sprintf("%s-%d.yaml", base, n)
+		}
+		used[fileName] = true
+		names[i] = fileName
+	}
+	return names
+}
+
+// sanitizeFileName replaces the characters of s that are not safe in file names on every OS.
+func sanitizeFileName(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		default:
+			return '-'
+		}
+	}, s)
+}
+
+func parse(raw []byte) (*Document, error) {
+	var h header
+	if err := yaml.Unmarshal(raw, &h); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	return &Document{
+		Raw:         raw,
+		APIVersion:  h.APIVersion,
+		Kind:        h.Kind,
+		Name:        h.Metadata.Name,
+		Namespace:   h.Metadata.Namespace,
+		Annotations: h.Metadata.Annotations,
+	}, nil
+}
+
+func isSeparator(line string) bool {
+	if !strings.HasPrefix(line, "---") {
+		return false
+	}
+	rest := strings.TrimSpace(line[3:])
+	return rest == "" || strings.HasPrefix(rest, "#")
+}
+
+func isBlank(raw []byte) bool {
+	for _, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSplit(t *testing.T) {
+	data := []byte(`# leading comment
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: ns
+--- # separator with comment
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+  namespace: ns
+  annotations:
+    key: value
+---
+# comment only
+---
+
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: s
+  namespace: ns
+data:
+  text: "--- not a separator"
+`)
+
+	docs, err := Split(data)
+	if err != nil {
+		t.Fatalf("Split() unexpected error: %v", err)
+	}
+	if len(docs) != 3 {
+		t.Fatalf("Split() returned %d documents, want 3", len(docs))
+	}
+
+	if got := docs[0].String(); got != "Namespace ns" {
+		t.Errorf("docs[0] = %q, want %q", got, "Namespace ns")
+	}
+	if got := docs[1].String(); got != "ConfigMap ns/cm" {
+		t.Errorf("docs[1] = %q, want %q", got, "ConfigMap ns/cm")
+	}
+	if docs[1].Annotations["key"] != "value" {
+		t.Errorf("docs[1] annotations = %v, want key=value", docs[1].Annotations)
+	}
+	if docs[2].Kind != "Secret" {
+		t.Errorf("docs[2].Kind = %q, want Secret", docs[2].Kind)
+	}
+
+	joined, err := Split(Join(docs))
+	if err != nil {
+		t.Fatalf("Split(Join()) unexpected error: %v", err)
+	}
+	if len(joined) != len(docs) {
+		t.Fatalf("Split(Join()) returned %d documents, want %d", len(joined), len(docs))
+	}
+	for i := range docs {
+		if string(joined[i].Raw) != string(docs[i].Raw) {
+			t.Errorf("document %d changed after Join: %q, want %q", i, joined[i].Raw, docs[i].Raw)
+		}
+	}
+}
+
+func TestSplit_InvalidYAML(t *testing.T) {
+	if _, err := Split([]byte("kind: [unterminated\n")); err == nil {
+		t.Errorf("Split() expected error for invalid YAML")
+	}
+}
+
+func TestRead(t *testing.T) {
+	// Lines longer than a read buffer, CRLF line endings, and a missing final newline
+	value := strings.Repeat("a", 100_000)
+	data := "apiVersion: v1\r\nkind: ConfigMap\r\nmetadata:\r\n  name: big\r\ndata:\r\n  key: " + value + "\r\n---\r\napiVersion: v1\nkind: Secret\nmetadata:\n  name: s"
+	docs, err := Read(iotest.HalfReader(strings.NewReader(data)))
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if len(docs) != 2 || docs[0].Name != "big" || docs[1].Kind != "Secret" {
+		t.Fatalf("Read() = %v, want ConfigMap big and Secret s", docs)
+	}
+	if !strings.Contains(string(docs[0].Raw), "key: "+value+"\n") {
+		t.Errorf("Read() lost the long line")
+	}
+
+	if _, err := Read(iotest.ErrReader(iotest.ErrTimeout)); err == nil {
+		t.Errorf("Read() of a failing reader expected an error")
+	}
+}
+
+func TestFileNames(t *testing.T) {
+	docs := []*Document{
+		{Kind: "Namespace", Name: "ns"},
+		{Kind: "ConfigMap", Name: "app", Namespace: "ns"},
+		{Kind: "ConfigMap", Name: "app", Namespace: "ns"},
+		{Kind: "ClusterRole", Name: "system:controller"},
+		{Kind: "ConfigMap", Namespace: "ns"},
+		{Name: "no-kind"},
+	}
+	want := []string{
+		"namespace_ns.yaml",
+		"ns_configmap_app.yaml",
+		"ns_configmap_app-2.yaml",
+		"clusterrole_system-controller.yaml",
+		"ns_configmap_unnamed-5.yaml",
+		"unknown_no-kind.yaml",
+	}
+
+	got := FileNames(docs)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("FileNames()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
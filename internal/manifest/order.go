@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// AnnotationWave sets the apply wave of a resource. Waves are applied in ascending order
+// and resources without the annotation belong to wave 0.
+const AnnotationWave = "kubectl-mft.io/apply-wave"
+
+// Ordering selects how the resources of a manifest are ordered when applied
+type Ordering string
+
+const (
+	// OrderingNone applies all resources at once
+	OrderingNone Ordering = "none"
+	// OrderingAuto applies resources by wave, with Namespaces and CRDs first within each wave
+	OrderingAuto Ordering = "auto"
+)
+
+// Phase is a set of resources applied together
+type Phase struct {
+	Wave      int
+	Documents []*Document
+	// CRDs lists the names of the CustomResourceDefinitions in the phase,
+	// which must be established before the next phase is applied
+	CRDs []string
+}
+
+// Plan groups the documents into phases in apply order.
+func Plan(docs []*Document, ordering Ordering) ([]*Phase, error) {
+	switch ordering {
+	case OrderingNone:
+		return []*Phase{{Documents: docs}}, nil
+	case OrderingAuto:
+	default:
+		return nil, fmt.Errorf("unsupported ordering: %s", ordering)
+	}
+
+	type key struct {
+		wave       int
+		foundation bool
+	}
+	phases := make(map[key]*Phase)
+	var keys []key
+
+	for _, d := range docs {
+		wave := 0
+		if v, ok := d.Annotations[AnnotationWave]; ok {
+			w, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid %s annotation %q: must be an integer", d, AnnotationWave, v)
+			}
+			wave = w
+		}
+
+		k := key{wave: wave, foundation: isFoundation(d)}
+		p, ok := phases[k]
+		if !ok {
+			p = &Phase{Wave: wave}
+			phases[k] = p
+			keys = append(keys, k)
+		}
+		p.Documents = append(p.Documents, d)
+		if isCRD(d) {
+			p.CRDs = append(p.CRDs, d.Name)
+		}
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].wave != keys[j].wave {
+			return keys[i].wave < keys[j].wave
+		}
+		return keys[i].foundation && !keys[j].foundation
+	})
+
+	plan := make([]*Phase, 0, len(keys))
+	for _, k := range keys {
+		plan = append(plan, phases[k])
+	}
+	return plan, nil
+}
+
+// isFoundation reports whether other resources may depend on d existing first.
+func isFoundation(d *Document) bool {
+	return d.Kind == "Namespace" && d.APIVersion == "v1" || isCRD(d)
+}
+
+func isCRD(d *Document) bool {
+	return d.Kind == "CustomResourceDefinition" && d.APIVersion == "apiextensions.k8s.io/v1"
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"strings"
+	"testing"
+)
+
+func doc(apiVersion, kind, name string, annotations map[string]string) *Document {
+	return &Document{APIVersion: apiVersion, Kind: kind, Name: name, Annotations: annotations}
+}
+
+func TestPlan(t *testing.T) {
+	docs := []*Document{
+		doc("v1", "ConfigMap", "late", map[string]string{AnnotationWave: "1"}),
+		doc("example.com/v1", "Widget", "w", nil),
+		doc("apiextensions.k8s.io/v1", "CustomResourceDefinition", "widgets.example.com", nil),
+		doc("v1", "Namespace", "ns", nil),
+		doc("v1", "ServiceAccount", "early", map[string]string{AnnotationWave: "-1"}),
+	}
+
+	t.Run("auto", func(t *testing.T) {
+		phases, err := Plan(docs, OrderingAuto)
+		if err != nil {
+			t.Fatalf("Plan() unexpected error: %v", err)
+		}
+
+		var got []string
+		for _, p := range phases {
+			var names []string
+			for _, d := range p.Documents {
+				names = append(names, d.Name)
+			}
+			got = append(got, strings.Join(names, ","))
+		}
+		want := []string{"early", "widgets.example.com,ns", "w", "late"}
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("Plan() phases = %v, want %v", got, want)
+		}
+		if len(phases[1].CRDs) != 1 || phases[1].CRDs[0] != "widgets.example.com" {
+			t.Errorf("Plan() CRDs of foundation phase = %v, want [widgets.example.com]", phases[1].CRDs)
+		}
+	})
+
+	t.Run("none", func(t *testing.T) {
+		phases, err := Plan(docs, OrderingNone)
+		if err != nil {
+			t.Fatalf("Plan() unexpected error: %v", err)
+		}
+		if len(phases) != 1 || len(phases[0].Documents) != len(docs) {
+			t.Errorf("Plan() with none should return a single phase with all documents")
+		}
+	})
+
+	t.Run("invalid wave", func(t *testing.T) {
+		_, err := Plan([]*Document{doc("v1", "ConfigMap", "cm", map[string]string{AnnotationWave: "first"})}, OrderingAuto)
+		if err == nil {
+			t.Errorf("Plan() expected error for a non-integer wave")
+		}
+	})
+
+	t.Run("unsupported ordering", func(t *testing.T) {
+		if _, err := Plan(docs, "random"); err == nil {
+			t.Errorf("Plan() expected error for an unsupported ordering")
+		}
+	})
+}
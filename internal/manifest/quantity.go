@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// quantitySuffixes are the multipliers of Kubernetes quantity suffixes, binary suffixes first
+// so that "Mi" is not taken for "M"
+var quantitySuffixes = []struct {
+	suffix     string
+	multiplier float64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40}, {"Pi", 1 << 50}, {"Ei", 1 << 60},
+	{"m", 1e-3}, {"k", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12}, {"P", 1e15}, {"E", 1e18},
+}
+
+// parseQuantity parses a Kubernetes resource quantity such as "500m", "1.5", or "256Mi".
+func parseQuantity(s string) (float64, error) {
+	number, multiplier := s, 1.0
+	for _, q := range quantitySuffixes {
+		if n, ok := strings.CutSuffix(s, q.suffix); ok {
+			number, multiplier = n, q.multiplier
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	return v * multiplier, nil
+}
+
+// formatCPU formats a number of cores, in millicores below one core.
+func formatCPU(cores float64) string {
+	if cores < 1 {
+		return fmt.Sprintf("%.0fm", cores*1000)
+	}
+	return strconv.FormatFloat(math.Round(cores*1000)/1000, 'f', -1, 64)
+}
+
+// formatBytes formats a number of bytes with the largest binary suffix that keeps the value at least 1.
+func formatBytes(b float64) string {
+	suffixes := []string{"", "Ki", "Mi", "Gi", "Ti", "Pi"}
+	i := 0
+	for ; b >= 1024 && i < len(suffixes)-1; i++ {
+		b /= 1024
+	}
+	return strconv.FormatFloat(math.Round(b*100)/100, 'f', -1, 64) + suffixes[i]
+}
+
+// ParseSize parses a size in bytes given as a Kubernetes quantity such as "10Mi" or "500k".
+func ParseSize(s string) (int64, error) {
+	v, err := parseQuantity(strings.TrimSpace(s))
+	if err != nil || v < 1 {
+		return 0, fmt.Errorf("invalid size %q, expected a quantity such as 10Mi", s)
+	}
+	return int64(v), nil
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// clusterScopedKinds are the built-in kinds of cluster-scoped resources
+var clusterScopedKinds = map[string]bool{
+	"APIService":                       true,
+	"CSIDriver":                        true,
+	"CSINode":                          true,
+	"CertificateSigningRequest":        true,
+	"ClusterRole":                      true,
+	"ClusterRoleBinding":               true,
+	"ComponentStatus":                  true,
+	"ClusterTrustBundle":               true,
+	"CustomResourceDefinition":         true,
+	"DeviceClass":                      true,
+	"FlowSchema":                       true,
+	"IPAddress":                        true,
+	"IngressClass":                     true,
+	"MutatingAdmissionPolicy":          true,
+	"MutatingAdmissionPolicyBinding":   true,
+	"MutatingWebhookConfiguration":     true,
+	"Namespace":                        true,
+	"Node":                             true,
+	"PersistentVolume":                 true,
+	"PriorityClass":                    true,
+	"PriorityLevelConfiguration":       true,
+	"RuntimeClass":                     true,
+	"ServiceCIDR":                      true,
+	"StorageClass":                     true,
+	"ValidatingAdmissionPolicy":        true,
+	"ValidatingAdmissionPolicyBinding": true,
+	"ValidatingWebhookConfiguration":   true,
+	"VolumeAttachment":                 true,
+	"VolumeAttributesClass":            true,
+	"VolumeSnapshotClass":              true,
+}
+
+// ClusterScopedKind reports whether resources of the kind are cluster-scoped. Only built-in
+// kinds are known, custom resources are assumed to be namespaced.
+func ClusterScopedKind(kind string) bool {
+	return clusterScopedKinds[kind]
+}
+
+// ClusterScopedCustomKinds returns the kinds defined as cluster-scoped by the
+// CustomResourceDefinitions among docs.
+func ClusterScopedCustomKinds(docs []*Document) (map[string]bool, error) {
+	kinds := make(map[string]bool)
+	for _, d := range docs {
+		if !isCRD(d) {
+			continue
+		}
+		var crd struct {
+			Spec struct {
+				Scope string `yaml:"scope"`
+				Names struct {
+					Kind string `yaml:"kind"`
+				} `yaml:"names"`
+			} `yaml:"spec"`
+		}
+		if err := yaml.Unmarshal(d.Raw, &crd); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", d, err)
+		}
+		if crd.Spec.Scope == "Cluster" && crd.Spec.Names.Kind != "" {
+			kinds[crd.Spec.Names.Kind] = true
+		}
+	}
+	return kinds, nil
+}
@@ -0,0 +1,256 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Summary describes what a manifest deploys, for reviewers approving it.
+type Summary struct {
+	Resources int          `json:"resources" yaml:"resources"`
+	Kinds     []*KindCount `json:"kinds" yaml:"kinds"`
+	// Namespaces are the namespaces resources are created in, including created Namespaces
+	Namespaces []string `json:"namespaces" yaml:"namespaces"`
+	// CurrentNamespace counts the namespaced resources without a namespace, which are created
+	// in the namespace of the current context
+	CurrentNamespace int                `json:"currentNamespace" yaml:"currentNamespace"`
+	Images           []string           `json:"images" yaml:"images"`
+	CRDs             []string           `json:"crds" yaml:"crds"`
+	Roles            []*RoleSummary     `json:"roles" yaml:"roles"`
+	Bindings         []*BindingSummary  `json:"bindings" yaml:"bindings"`
+	Workloads        []*WorkloadSummary `json:"workloads" yaml:"workloads"`
+	// Replicas is the total number of pods of the workloads, counting one per DaemonSet
+	Replicas int `json:"replicas" yaml:"replicas"`
+	// Requests and Limits are the totals of the workloads, multiplied by their replicas
+	Requests Resources `json:"requests" yaml:"requests"`
+	Limits   Resources `json:"limits" yaml:"limits"`
+}
+
+// KindCount is the number of resources of a kind.
+type KindCount struct {
+	Kind  string `json:"kind" yaml:"kind"`
+	Count int    `json:"count" yaml:"count"`
+}
+
+// RoleSummary is a Role or ClusterRole and the number of rules it grants.
+type RoleSummary struct {
+	Kind      string `json:"kind" yaml:"kind"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name      string `json:"name" yaml:"name"`
+	Rules     int    `json:"rules" yaml:"rules"`
+}
+
+// BindingSummary is a RoleBinding or ClusterRoleBinding.
+type BindingSummary struct {
+	Kind      string `json:"kind" yaml:"kind"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name      string `json:"name" yaml:"name"`
+	// Role is the bound role, such as "ClusterRole/view"
+	Role string `json:"role" yaml:"role"`
+	// Subjects are the bound subjects, such as "ServiceAccount app/controller"
+	Subjects []string `json:"subjects" yaml:"subjects"`
+}
+
+// WorkloadSummary is a resource that runs pods.
+type WorkloadSummary struct {
+	Kind      string `json:"kind" yaml:"kind"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name      string `json:"name" yaml:"name"`
+	Replicas  int    `json:"replicas" yaml:"replicas"`
+	// PerNode is set for DaemonSets, which run one pod per node
+	PerNode bool `json:"perNode,omitempty" yaml:"perNode,omitempty"`
+	// Requests and Limits are the resources of a single pod
+	Requests Resources `json:"requests" yaml:"requests"`
+	Limits   Resources `json:"limits" yaml:"limits"`
+}
+
+// Resources are CPU and memory quantities, such as "250m" and "512Mi".
+type Resources struct {
+	CPU    string `json:"cpu,omitempty" yaml:"cpu,omitempty"`
+	Memory string `json:"memory,omitempty" yaml:"memory,omitempty"`
+}
+
+// resourceTotals accumulates CPU cores and memory bytes.
+type resourceTotals struct {
+	cpu, memory float64
+}
+
+func (t *resourceTotals) add(o resourceTotals, n int) {
+	t.cpu += o.cpu * float64(n)
+	t.memory += o.memory * float64(n)
+}
+
+func (t resourceTotals) resources() Resources {
+	var r Resources
+	if t.cpu > 0 {
+		r.CPU = formatCPU(t.cpu)
+	}
+	if t.memory > 0 {
+		r.Memory = formatBytes(t.memory)
+	}
+	return r
+}
+
+// podTemplatePaths are the paths of the pod spec and the replica count of workload kinds
+var podTemplatePaths = map[string]struct {
+	spec     []string
+	replicas []string
+}{
+	"Pod":                   {spec: []string{"spec"}},
+	"Deployment":            {spec: []string{"spec", "template", "spec"}, replicas: []string{"spec", "replicas"}},
+	"StatefulSet":           {spec: []string{"spec", "template", "spec"}, replicas: []string{"spec", "replicas"}},
+	"ReplicaSet":            {spec: []string{"spec", "template", "spec"}, replicas: []string{"spec", "replicas"}},
+	"ReplicationController": {spec: []string{"spec", "template", "spec"}, replicas: []string{"spec", "replicas"}},
+	"DaemonSet":             {spec: []string{"spec", "template", "spec"}},
+	"Job":                   {spec: []string{"spec", "template", "spec"}, replicas: []string{"spec", "parallelism"}},
+	"CronJob": {
+		spec:     []string{"spec", "jobTemplate", "spec", "template", "spec"},
+		replicas: []string{"spec", "jobTemplate", "spec", "parallelism"},
+	},
+}
+
+// Summarize describes the resources of a manifest.
+func Summarize(docs []*Document) (*Summary, error) {
+	s := &Summary{
+		Namespaces: []string{},
+		Images:     []string{},
+		CRDs:       []string{},
+		Roles:      []*RoleSummary{},
+		Bindings:   []*BindingSummary{},
+		Workloads:  []*WorkloadSummary{},
+	}
+	kinds := make(map[string]int)
+	namespaces := make(map[string]bool)
+	images := make(map[string]bool)
+	var requests, limits resourceTotals
+
+	for _, d := range docs {
+		if d.Kind == "" {
+			continue
+		}
+		s.Resources++
+		kinds[d.Kind]++
+		switch {
+		case d.Kind == "Namespace":
+			namespaces[d.Name] = true
+		case d.Namespace != "":
+			namespaces[d.Namespace] = true
+		case !ClusterScopedKind(d.Kind):
+			s.CurrentNamespace++
+		}
+
+		var obj map[string]any
+		if err := yaml.Unmarshal(d.Raw, &obj); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", d, err)
+		}
+		refs, err := d.Images()
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range refs {
+			images[image] = true
+		}
+
+		switch d.Kind {
+		case "CustomResourceDefinition":
+			s.CRDs = append(s.CRDs, d.Name)
+		case "Role", "ClusterRole":
+			rules, _ := obj["rules"].([]any)
+			s.Roles = append(s.Roles, &RoleSummary{Kind: d.Kind, Namespace: d.Namespace, Name: d.Name, Rules: len(rules)})
+		case "RoleBinding", "ClusterRoleBinding":
+			s.Bindings = append(s.Bindings, summarizeBinding(d, obj))
+		}
+
+		paths, ok := podTemplatePaths[d.Kind]
+		if !ok {
+			continue
+		}
+		w := &WorkloadSummary{Kind: d.Kind, Namespace: d.Namespace, Name: d.Name, Replicas: 1, PerNode: d.Kind == "DaemonSet"}
+		if paths.replicas != nil {
+			if n, ok := lookupPath(obj, paths.replicas).(int); ok {
+				w.Replicas = n
+			}
+		}
+		podSpec, _ := lookupPath(obj, paths.spec).(map[string]any)
+		podRequests, podLimits, err := podResources(podSpec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", d, err)
+		}
+		w.Requests, w.Limits = podRequests.resources(), podLimits.resources()
+		requests.add(podRequests, w.Replicas)
+		limits.add(podLimits, w.Replicas)
+		s.Replicas += w.Replicas
+		s.Workloads = append(s.Workloads, w)
+	}
+
+	for _, kind := range slices.Sorted(maps.Keys(kinds)) {
+		s.Kinds = append(s.Kinds, &KindCount{Kind: kind, Count: kinds[kind]})
+	}
+	s.Namespaces = append(s.Namespaces, slices.Sorted(maps.Keys(namespaces))...)
+	s.Images = append(s.Images, slices.Sorted(maps.Keys(images))...)
+	s.Requests, s.Limits = requests.resources(), limits.resources()
+	return s, nil
+}
+
+func summarizeBinding(d *Document, obj map[string]any) *BindingSummary {
+	b := &BindingSummary{Kind: d.Kind, Namespace: d.Namespace, Name: d.Name, Subjects: []string{}}
+	if ref, ok := obj["roleRef"].(map[string]any); ok {
+		b.Role = fmt.Sprintf("%v/%v", ref["kind"], ref["name"])
+	}
+	subjects, _ := obj["subjects"].([]any)
+	for _, subject := range subjects {
+		subject, ok := subject.(map[string]any)
+		if !ok {
+			continue
+		}
+		name := fmt.Sprint(subject["name"])
+		if ns, ok := subject["namespace"].(string); ok && ns != "" {
+			name = ns + "/" + name
+		}
+		b.Subjects = append(b.Subjects, fmt.Sprintf("%v %s", subject["kind"], name))
+	}
+	return b
+}
+
+// podResources returns the sum of the requests and limits of the containers of a pod spec.
+// Init containers run before the other containers and are not counted.
+func podResources(spec map[string]any) (requests, limits resourceTotals, err error) {
+	containers, _ := spec["containers"].([]any)
+	for _, c := range containers {
+		resources, _ := lookupPath(c, []string{"resources"}).(map[string]any)
+		for field, totals := range map[string]*resourceTotals{"requests": &requests, "limits": &limits} {
+			values, _ := resources[field].(map[string]any)
+			for name, total := range map[string]*float64{"cpu": &totals.cpu, "memory": &totals.memory} {
+				v, ok := values[name]
+				if !ok {
+					continue
+				}
+				q, err := parseQuantity(strings.TrimSpace(fmt.Sprint(v)))
+				if err != nil {
+					return requests, limits, fmt.Errorf("%s.%s: %w", field, name, err)
+				}
+				*total += q
+			}
+		}
+	}
+	return requests, limits, nil
+}
+
+// lookupPath returns the value at the path of nested mappings, or nil.
+func lookupPath(v any, path []string) any {
+	for _, key := range path {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package manifest
+
+import (
+	"slices"
+	"testing"
+)
+
+const summaryManifest = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: app
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: app
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.25
+          resources:
+            requests: {cpu: 250m, memory: 256Mi}
+            limits: {cpu: "1", memory: 512Mi}
+        - name: sidecar
+          image: ghcr.io/org/sidecar:v1
+          resources:
+            requests: {cpu: 50m, memory: 64Mi}
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+  namespace: kube-system
+spec:
+  template:
+    spec:
+      containers:
+        - name: agent
+          image: ghcr.io/org/agent:v2
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cfg
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: reader
+rules:
+  - apiGroups: [""]
+    resources: [pods]
+    verbs: [get, list]
+  - apiGroups: [apps]
+    resources: [deployments]
+    verbs: [get]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: reader
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: reader
+subjects:
+  - kind: ServiceAccount
+    name: web
+    namespace: app
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+`
+
+func TestSummarize(t *testing.T) {
+	docs, err := Split([]byte(summaryManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Summarize(docs)
+	if err != nil {
+		t.Fatalf("Summarize() unexpected error: %v", err)
+	}
+
+	if s.Resources != 7 {
+		t.Errorf("Resources = %d, want 7", s.Resources)
+	}
+	if want := []string{"app", "kube-system"}; !slices.Equal(s.Namespaces, want) {
+		t.Errorf("Namespaces = %v, want %v", s.Namespaces, want)
+	}
+	if s.CurrentNamespace != 1 {
+		t.Errorf("CurrentNamespace = %d, want 1", s.CurrentNamespace)
+	}
+	if want := []string{"ghcr.io/org/agent:v2", "ghcr.io/org/sidecar:v1", "nginx:1.25"}; !slices.Equal(s.Images, want) {
+		t.Errorf("Images = %v, want %v", s.Images, want)
+	}
+	if want := []string{"widgets.example.com"}; !slices.Equal(s.CRDs, want) {
+		t.Errorf("CRDs = %v, want %v", s.CRDs, want)
+	}
+	if len(s.Bindings) != 1 || s.Bindings[0].Role != "ClusterRole/reader" ||
+		!slices.Equal(s.Bindings[0].Subjects, []string{"ServiceAccount app/web"}) {
+		t.Errorf("Bindings = %+v", s.Bindings)
+	}
+	if len(s.Roles) != 1 || s.Roles[0].Rules != 2 {
+		t.Errorf("Roles = %+v", s.Roles)
+	}
+
+	if len(s.Workloads) != 2 {
+		t.Fatalf("Workloads = %d, want 2", len(s.Workloads))
+	}
+	web := s.Workloads[0]
+	if web.Replicas != 3 || web.Requests != (Resources{CPU: "300m", Memory: "320Mi"}) || web.Limits != (Resources{CPU: "1", Memory: "512Mi"}) {
+		t.Errorf("Workloads[0] = %+v", web)
+	}
+	if !s.Workloads[1].PerNode {
+		t.Errorf("Workloads[1].PerNode = false, want true for a DaemonSet")
+	}
+	if s.Replicas != 4 || s.Requests != (Resources{CPU: "900m", Memory: "960Mi"}) || s.Limits != (Resources{CPU: "3", Memory: "1.5Gi"}) {
+		t.Errorf("totals = %d, %+v, %+v", s.Replicas, s.Requests, s.Limits)
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{in: "500m", want: 0.5},
+		{in: "2", want: 2},
+		{in: "1.5", want: 1.5},
+		{in: "256Mi", want: 256 << 20},
+		{in: "1G", want: 1e9},
+		{in: "1e3", want: 1000},
+		{in: "lots", wantErr: true},
+		{in: "-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseQuantity(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseQuantity(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseQuantity(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
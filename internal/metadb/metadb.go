@@ -0,0 +1,227 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package metadb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	repositoriesBucket = []byte("repositories")
+	eventsBucket       = []byte("events")
+)
+
+// openTimeout bounds how long Open waits for another kubectl-mft process holding the database
+const openTimeout = 5 * time.Second
+
+// Record is the metadata of a tagged manifest in local storage.
+type Record struct {
+	// Repository is the repository name as shown by list, without the default registry
+	Repository  string            `json:"repository"`
+	Tag         string            `json:"tag"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Created     time.Time         `json:"created"`
+	Signed      bool              `json:"signed"`
+	Pinned      bool              `json:"pinned,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Event is an entry of the event log, encoded by the caller.
+type Event struct {
+	Time time.Time
+	Data []byte
+}
+
+// DB is a metadata index of local storage backed by bbolt. Records are grouped by repository,
+// so that the records of a repository can be replaced after it changed. Events are keyed by
+// time, so that the events since a given time are found without reading the others.
+type DB struct {
+	db *bolt.DB
+}
+
+// Open opens the database at path, creating it if it does not exist.
+func Open(path string) (*DB, error) {
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metadata index %s: %w", path, err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(repositoriesBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(eventsBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize metadata index: %w", err)
+	}
+	return &DB{db: db}, nil
+}
+
+// Exists reports whether a database exists at path.
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// PutRepository replaces the records of the repository. No records removes the repository.
+func (d *DB) PutRepository(repository string, records []Record) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		return putRepository(tx.Bucket(repositoriesBucket), repository, records)
+	})
+}
+
+// Rebuild replaces all records with the given records by repository, and all events with
+// the given events.
+func (d *DB) Rebuild(repositories map[string][]Record, events []Event) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{repositoriesBucket, eventsBucket} {
+			if err := tx.DeleteBucket(name); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+				return err
+			}
+		}
+		b, err := tx.CreateBucket(repositoriesBucket)
+		if err != nil {
+			return err
+		}
+		for repository, records := range repositories {
+			if err := putRepository(b, repository, records); err != nil {
+				return err
+			}
+		}
+		eb, err := tx.CreateBucket(eventsBucket)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if err := putEvent(eb, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddEvent adds an event.
+func (d *DB) AddEvent(e Event) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		return putEvent(tx.Bucket(eventsBucket), e)
+	})
+}
+
+// Events returns the data of the events after since, oldest first.
+func (d *DB) Events(since time.Time) ([][]byte, error) {
+	var events [][]byte
+	err := d.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(eventsBucket).Cursor()
+		// Keys start with the time, so seeking past since skips all older events
+		for k, v := c.Seek(eventKey(since.Add(time.Nanosecond), 0)); k != nil; k, v = c.Next() {
+			events = append(events, slices.Clone(v))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata index: %w", err)
+	}
+	return events, nil
+}
+
+// RepositoryRecords returns the records of the repository ordered by tag.
+func (d *DB) RepositoryRecords(repository string) ([]Record, error) {
+	var records []Record
+	err := d.db.View(func(tx *bolt.Tx) error {
+		rb := tx.Bucket(repositoriesBucket).Bucket([]byte(repository))
+		if rb == nil {
+			return nil
+		}
+		return rb.ForEach(func(_, v []byte) error {
+			var r Record
+			if err := json.Unmarshal(v, &r); err != nil {
+				return fmt.Errorf("corrupted record in repository %s: %w", repository, err)
+			}
+			records = append(records, r)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata index: %w", err)
+	}
+	return records, nil
+}
+
+// Records returns all records ordered by repository and tag.
+func (d *DB) Records() ([]Record, error) {
+	var records []Record
+	err := d.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(repositoriesBucket).ForEachBucket(func(name []byte) error {
+			return tx.Bucket(repositoriesBucket).Bucket(name).ForEach(func(_, v []byte) error {
+				var r Record
+				if err := json.Unmarshal(v, &r); err != nil {
+					return fmt.Errorf("corrupted record in repository %s: %w", name, err)
+				}
+				records = append(records, r)
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata index: %w", err)
+	}
+	return records, nil
+}
+
+func putRepository(b *bolt.Bucket, repository string, records []Record) error {
+	if err := b.DeleteBucket([]byte(repository)); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	rb, err := b.CreateBucket([]byte(repository))
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		if err := rb.Put([]byte(r.Tag), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putEvent stores an event under its time followed by a sequence number, which keeps events
+// recorded at the same time apart.
+func putEvent(b *bolt.Bucket, e Event) error {
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	return b.Put(eventKey(e.Time, seq), e.Data)
+}
+
+// eventKey returns the key of an event, which sorts by time. Times before the Unix epoch sort first.
+func eventKey(t time.Time, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(max(t.UnixNano(), 0)))
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package metadb
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func recordKeys(t *testing.T, db *DB) string {
+	t.Helper()
+	records, err := db.Records()
+	if err != nil {
+		t.Fatalf("Records() failed: %v", err)
+	}
+	var keys []string
+	for _, r := range records {
+		keys = append(keys, r.Repository+":"+r.Tag)
+	}
+	return strings.Join(keys, ",")
+}
+
+func TestDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.db")
+	if Exists(path) {
+		t.Fatal("Exists() = true before Open")
+	}
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer db.Close()
+	if !Exists(path) {
+		t.Fatal("Exists() = false after Open")
+	}
+
+	if err := db.PutRepository("local/b", []Record{{Repository: "b", Tag: "v1"}}); err != nil {
+		t.Fatalf("PutRepository() failed: %v", err)
+	}
+	if err := db.PutRepository("local/a", []Record{{Repository: "a", Tag: "v2"}, {Repository: "a", Tag: "v1", Signed: true}}); err != nil {
+		t.Fatalf("PutRepository() failed: %v", err)
+	}
+	if got, want := recordKeys(t, db), "a:v1,a:v2,b:v1"; got != want {
+		t.Errorf("Records() = %s, want %s", got, want)
+	}
+
+	// Replacing a repository drops its previous records
+	if err := db.PutRepository("local/a", []Record{{Repository: "a", Tag: "v3"}}); err != nil {
+		t.Fatalf("PutRepository() failed: %v", err)
+	}
+	if err := db.PutRepository("local/b", nil); err != nil {
+		t.Fatalf("PutRepository() failed: %v", err)
+	}
+	if got, want := recordKeys(t, db), "a:v3"; got != want {
+		t.Errorf("Records() = %s, want %s", got, want)
+	}
+
+	if err := db.Rebuild(map[string][]Record{"local/c": {{Repository: "c", Tag: "v1"}}}, nil); err != nil {
+		t.Fatalf("Rebuild() failed: %v", err)
+	}
+	if got, want := recordKeys(t, db), "c:v1"; got != want {
+		t.Errorf("Records() after Rebuild = %s, want %s", got, want)
+	}
+}
+
+func TestDBEvents(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "metadata.db"))
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer db.Close()
+
+	base := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	if err := db.Rebuild(nil, []Event{{Time: base.Add(time.Hour), Data: []byte("b")}, {Time: base, Data: []byte("a")}}); err != nil {
+		t.Fatalf("Rebuild() failed: %v", err)
+	}
+	for _, data := range []string{"c", "d"} {
+		// Events recorded at the same time are both kept
+		if err := db.AddEvent(Event{Time: base.Add(2 * time.Hour), Data: []byte(data)}); err != nil {
+			t.Fatalf("AddEvent() failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		since time.Time
+		want  string
+	}{
+		{time.Time{}, "a,b,c,d"},
+		{base, "b,c,d"},
+		{base.Add(90 * time.Minute), "c,d"},
+		{base.Add(2 * time.Hour), ""},
+	}
+	for _, tt := range tests {
+		events, err := db.Events(tt.since)
+		if err != nil {
+			t.Fatalf("Events() failed: %v", err)
+		}
+		var got []string
+		for _, e := range events {
+			got = append(got, string(e))
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("Events(%v) = %v, want %s", tt.since, got, tt.want)
+		}
+	}
+
+	if err := db.PutRepository("local/a", []Record{{Repository: "a", Tag: "v2"}, {Repository: "a", Tag: "v1"}}); err != nil {
+		t.Fatalf("PutRepository() failed: %v", err)
+	}
+	records, err := db.RepositoryRecords("local/a")
+	if err != nil || len(records) != 2 || records[0].Tag != "v1" {
+		t.Errorf("RepositoryRecords() = %v, %v", records, err)
+	}
+	if records, err := db.RepositoryRecords("local/missing"); err != nil || len(records) != 0 {
+		t.Errorf("RepositoryRecords() of a missing repository = %v, %v", records, err)
+	}
+}
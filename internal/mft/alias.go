@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/goccy/go-yaml"
+)
+
+// States of an alias relative to the tag it was pointed at
+const (
+	// AliasCurrent means the target tag still refers to the manifest of the alias
+	AliasCurrent = "current"
+	// AliasStale means the target tag has been moved to another manifest since the alias was set
+	AliasStale = "stale"
+	// AliasMissing means the target tag has been deleted
+	AliasMissing = "missing"
+)
+
+// AliasInfo represents an alias tag and the tag it points at
+type AliasInfo struct {
+	Repository string `json:"repository" yaml:"repository"`
+	Alias      string `json:"alias" yaml:"alias"`
+	Target     string `json:"target" yaml:"target"`
+	Digest     string `json:"digest" yaml:"digest"`
+	// Status is one of AliasCurrent, AliasStale, or AliasMissing
+	Status string `json:"status" yaml:"status"`
+}
+
+// AliasesResult represents the aliases in local storage
+type AliasesResult struct {
+	aliases []*AliasInfo
+}
+
+func NewAliasesResult(aliases []*AliasInfo) *AliasesResult {
+	return &AliasesResult{aliases: aliases}
+}
+
+func (r *AliasesResult) Items() []*AliasInfo {
+	return r.aliases
+}
+
+func (r *AliasesResult) Print(output ListOutput) error {
+	aliases := r.aliases
+	if aliases == nil {
+		aliases = []*AliasInfo{}
+	}
+
+	switch output {
+	case ListTable:
+		return r.printTable()
+	case ListJson:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(aliases)
+	case ListYaml:
+		encoder := yaml.NewEncoder(os.Stdout)
+		defer encoder.Close()
+		return encoder.Encode(aliases)
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+}
+
+func (r *AliasesResult) printTable() error {
+	if len(r.aliases) == 0 {
+		fmt.Println("No aliases found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tALIAS\tTARGET\tSTATUS\tDIGEST")
+	for _, a := range r.aliases {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", a.Repository, a.Alias, a.Target, a.Status, a.Digest)
+	}
+	return w.Flush()
+}
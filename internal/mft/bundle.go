@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// BundleMember represents a manifest artifact referenced by a bundle
+type BundleMember struct {
+	// Name identifies the member within the bundle
+	Name string `json:"name" yaml:"name"`
+	// Reference is the tag the member was taken from when the bundle was created
+	Reference string `json:"reference" yaml:"reference"`
+	// Digest is the manifest digest of the member
+	Digest string `json:"digest,omitempty" yaml:"digest,omitempty"`
+	// DependsOn lists the names of members that must be applied before this one
+	DependsOn []string `json:"dependsOn,omitempty" yaml:"dependsOn,omitempty"`
+}
+
+// BundleResult represents the members of a bundle in dependency order
+type BundleResult struct {
+	members []*BundleMember
+}
+
+func NewBundleResult(members []*BundleMember) *BundleResult {
+	return &BundleResult{members: members}
+}
+
+func (r *BundleResult) Members() []*BundleMember {
+	return r.members
+}
+
+func (r *BundleResult) Print() error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tREFERENCE\tDIGEST\tDEPENDS ON")
+	for _, m := range r.members {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.Name, m.Reference, m.Digest, strings.Join(m.DependsOn, ","))
+	}
+	return w.Flush()
+}
+
+// SortBundleMembers returns the members ordered so that every member comes after its dependencies.
+// Members without a dependency relation keep their original order. It fails on duplicate names,
+// unknown dependencies, and dependency cycles.
+func SortBundleMembers(members []*BundleMember) ([]*BundleMember, error) {
+	byName := make(map[string]*BundleMember, len(members))
+	for _, m := range members {
+		if m.Name == "" {
+			return nil, fmt.Errorf("bundle member %s has no name", m.Reference)
+		}
+		if _, ok := byName[m.Name]; ok {
+			return nil, fmt.Errorf("duplicate bundle member name %q", m.Name)
+		}
+		byName[m.Name] = m
+	}
+	for _, m := range members {
+		for _, dep := range m.DependsOn {
+			if _, ok := byName[dep]; !ok {
+				return nil, fmt.Errorf("bundle member %q depends on unknown member %q", m.Name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(members))
+	sorted := make([]*BundleMember, 0, len(members))
+
+	var visit func(m *BundleMember) error
+	visit = func(m *BundleMember) error {
+		switch state[m.Name] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle detected at bundle member %q", m.Name)
+		}
+		state[m.Name] = visiting
+		for _, dep := range m.DependsOn {
+			if err := visit(byName[dep]); err != nil {
+				return err
+			}
+		}
+		state[m.Name] = done
+		sorted = append(sorted, m)
+		return nil
+	}
+
+	for _, m := range members {
+		if err := visit(m); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// CreateBundle creates a bundle artifact referencing the given member artifacts
+func CreateBundle(ctx context.Context, r Repository, members []*BundleMember) error {
+	return r.CreateBundle(ctx, members)
+}
+
+// Bundle returns the members of a bundle artifact in dependency order
+func Bundle(ctx context.Context, r Repository) (*BundleResult, error) {
+	return r.Bundle(ctx)
+}
+
+// IsBundle reports whether the artifact is a bundle
+func IsBundle(ctx context.Context, r Repository) (bool, error) {
+	return r.IsBundle(ctx)
+}
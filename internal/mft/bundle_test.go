@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSortBundleMembers(t *testing.T) {
+	tests := []struct {
+		name    string
+		members []*BundleMember
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "no dependencies keeps order",
+			members: []*BundleMember{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+			want:    "a,b,c",
+		},
+		{
+			name: "dependencies come first",
+			members: []*BundleMember{
+				{Name: "app", DependsOn: []string{"crds", "ns"}},
+				{Name: "crds"},
+				{Name: "ns"},
+				{Name: "monitoring", DependsOn: []string{"app"}},
+			},
+			want: "crds,ns,app,monitoring",
+		},
+		{
+			name:    "duplicate name",
+			members: []*BundleMember{{Name: "a"}, {Name: "a"}},
+			wantErr: "duplicate",
+		},
+		{
+			name:    "unknown dependency",
+			members: []*BundleMember{{Name: "a", DependsOn: []string{"b"}}},
+			wantErr: "unknown member",
+		},
+		{
+			name: "cycle",
+			members: []*BundleMember{
+				{Name: "a", DependsOn: []string{"b"}},
+				{Name: "b", DependsOn: []string{"a"}},
+			},
+			wantErr: "cycle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SortBundleMembers(tt.members)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SortBundleMembers() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SortBundleMembers() unexpected error: %v", err)
+			}
+			names := make([]string, 0, len(got))
+			for _, m := range got {
+				names = append(names, m.Name)
+			}
+			if strings.Join(names, ",") != tt.want {
+				t.Errorf("SortBundleMembers() = %v, want %s", names, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// CacheEntry represents a tag in the cache of manifests fetched implicitly
+type CacheEntry struct {
+	Repository string `json:"repository" yaml:"repository"`
+	Tag        string `json:"tag" yaml:"tag"`
+	Digest     string `json:"digest" yaml:"digest"`
+	// Fetched is when the tag was fetched, zero if unknown
+	Fetched time.Time `json:"fetched" yaml:"fetched"`
+	// Expired reports whether the tag is fetched again on its next use
+	Expired bool `json:"expired" yaml:"expired"`
+	// Pinned reports whether the tag is pinned, so that it never expires
+	Pinned bool `json:"pinned,omitempty" yaml:"pinned,omitempty"`
+}
+
+// CacheResult represents the content of the cache of manifests fetched implicitly
+type CacheResult struct {
+	dir     string
+	entries []*CacheEntry
+	size    int64
+}
+
+type cacheReport struct {
+	Dir     string        `json:"dir" yaml:"dir"`
+	Size    int64         `json:"size" yaml:"size"`
+	Entries []*CacheEntry `json:"entries" yaml:"entries"`
+}
+
+func NewCacheResult(dir string, entries []*CacheEntry, size int64) *CacheResult {
+	return &CacheResult{dir: dir, entries: entries, size: size}
+}
+
+func (r *CacheResult) Entries() []*CacheEntry {
+	return r.entries
+}
+
+// Size returns the size of the cache on disk in bytes
+func (r *CacheResult) Size() int64 {
+	return r.size
+}
+
+func (r *CacheResult) Print(output ListOutput) error {
+	report := cacheReport{Dir: r.dir, Size: r.size, Entries: r.entries}
+	if report.Entries == nil {
+		report.Entries = []*CacheEntry{}
+	}
+
+	switch output {
+	case ListTable:
+		return r.printTable()
+	case ListJson:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case ListYaml:
+		encoder := yaml.NewEncoder(os.Stdout)
+		defer encoder.Close()
+		return encoder.Encode(report)
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+}
+
+func (r *CacheResult) printTable() error {
+	expired := 0
+	for _, e := range r.entries {
+		if e.Expired {
+			expired++
+		}
+	}
+	fmt.Printf("%d cached manifest(s), %d expired, %s in %s\n", len(r.entries), expired, FormatSize(r.size), r.dir)
+	if len(r.entries) == 0 {
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tTAG\tFETCHED\tSTATUS")
+	for _, e := range r.entries {
+		fetched, status := "-", "valid"
+		if !e.Fetched.IsZero() {
+			fetched = e.Fetched.Local().Format("2006-01-02 15:04:05")
+		}
+		if e.Expired {
+			status = "expired"
+		}
+		if e.Pinned {
+			status = "pinned"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Repository, e.Tag, fetched, status)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI escape sequences of the colors used in text output
+const (
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorBold   = "\x1b[1m"
+	colorReset  = "\x1b[0m"
+)
+
+// painter colors text output if enabled.
+type painter bool
+
+func (p painter) paint(color, s string) string {
+	if !p || color == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// cell is a cell of columns, printed in color if set.
+type cell struct {
+	text  string
+	color string
+}
+
+// columns aligns rows of cells in columns separated by three spaces like the tabwriter of
+// the other tables, but measures the cells without their colors, which tabwriter would count.
+type columns struct {
+	rows [][]cell
+}
+
+func (c *columns) add(cells ...cell) {
+	c.rows = append(c.rows, cells)
+}
+
+// write writes the rows to w without trailing empty cells.
+func (c *columns) write(w io.Writer, p painter) {
+	var widths []int
+	for _, row := range c.rows {
+		for i, cl := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(cl.text))
+		}
+	}
+	for _, row := range c.rows {
+		last := len(row) - 1
+		for last >= 0 && row[last].text == "" {
+			last--
+		}
+		var b strings.Builder
+		for i, cl := range row[:last+1] {
+			b.WriteString(p.paint(cl.color, cl.text))
+			if i < last {
+				b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cl.text)+3))
+			}
+		}
+		fmt.Fprintln(w, b.String())
+	}
+}
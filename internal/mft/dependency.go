@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// AnnotationDependsOn holds the comma separated dependencies of an artifact, each of the form
+// <repository>:<tag>@<digest>, see Dependency
+const AnnotationDependsOn = "io.kubectl-mft.depends-on"
+
+// Dependency is an artifact another artifact depends on, pinned to a manifest digest. It is
+// pulled by digest and stored under its tag.
+type Dependency struct {
+	Tag    string
+	Digest digest.Digest
+}
+
+// ParseDependency parses a dependency of the form <repository>:<tag>@<digest>.
+func ParseDependency(s string) (Dependency, error) {
+	tag, d, ok := strings.Cut(strings.TrimSpace(s), "@")
+	if !ok || tag == "" {
+		return Dependency{}, fmt.Errorf("invalid dependency %q, expected <repository>:<tag>@<digest>", s)
+	}
+	if i := strings.LastIndex(tag, ":"); i < 0 || i < strings.LastIndex(tag, "/") {
+		return Dependency{}, fmt.Errorf("invalid dependency %q: the tag is required to store the dependency under", s)
+	}
+	dgst, err := digest.Parse(d)
+	if err != nil {
+		return Dependency{}, fmt.Errorf("invalid dependency %q: %w", s, err)
+	}
+	return Dependency{Tag: tag, Digest: dgst}, nil
+}
+
+// ParseDependencies parses the value of the AnnotationDependsOn annotation.
+func ParseDependencies(value string) ([]Dependency, error) {
+	var deps []Dependency
+	for s := range strings.SplitSeq(value, ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		dep, err := ParseDependency(s)
+		if err != nil {
+			return nil, err
+		}
+		deps = append(deps, dep)
+	}
+	return deps, nil
+}
+
+// FormatDependencies returns the value of the AnnotationDependsOn annotation for deps.
+func FormatDependencies(deps []Dependency) string {
+	values := make([]string, len(deps))
+	for i, dep := range deps {
+		values[i] = dep.String()
+	}
+	return strings.Join(values, ",")
+}
+
+func (d Dependency) String() string {
+	return d.Tag + "@" + d.Digest.String()
+}
+
+// ResolveDependencies returns the transitive dependencies of root, ordered so that every
+// artifact comes after its dependencies. deps returns the direct dependencies of an artifact,
+// fetching it first if needed. It fails on dependency cycles and on a tag pinned to different
+// digests by different artifacts, as both could not be stored under the tag.
+func ResolveDependencies(ctx context.Context, root Dependency, deps func(ctx context.Context, dep Dependency) ([]Dependency, error)) ([]Dependency, error) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	pinned := make(map[string]digest.Digest)
+	var sorted []Dependency
+	var path []string
+
+	var visit func(d Dependency) error
+	visit = func(d Dependency) error {
+		if p, ok := pinned[d.Tag]; ok && p != d.Digest {
+			return fmt.Errorf("%s is required at both %s and %s", d.Tag, p, d.Digest)
+		}
+		pinned[d.Tag] = d.Digest
+		switch state[d.Tag] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle detected: %s -> %s", strings.Join(path, " -> "), d.Tag)
+		}
+		state[d.Tag] = visiting
+		path = append(path, d.Tag)
+		children, err := deps(ctx, d)
+		if err != nil {
+			return err
+		}
+		for _, c := range children {
+			if err := visit(c); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[d.Tag] = done
+		sorted = append(sorted, d)
+		return nil
+	}
+
+	if err := visit(root); err != nil {
+		return nil, err
+	}
+	// The root is visited last
+	return sorted[:len(sorted)-1], nil
+}
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func TestParseDependencies(t *testing.T) {
+	d1 := digest.FromString("crds")
+	d2 := digest.FromString("operator")
+	tests := []struct {
+		name    string
+		value   string
+		want    []Dependency
+		wantErr bool
+	}{
+		{
+			name:  "several",
+			value: "ghcr.io/org/crds:v1@" + d1.String() + ", localhost:5000/operator:v2@" + d2.String(),
+			want:  []Dependency{{Tag: "ghcr.io/org/crds:v1", Digest: d1}, {Tag: "localhost:5000/operator:v2", Digest: d2}},
+		},
+		{name: "empty", value: ""},
+		{name: "missing digest", value: "ghcr.io/org/crds:v1", wantErr: true},
+		{name: "missing tag", value: "localhost:5000/crds@" + d1.String(), wantErr: true},
+		{name: "invalid digest", value: "ghcr.io/org/crds:v1@sha256:abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDependencies(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDependencies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseDependencies() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ParseDependencies()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+			if !tt.wantErr && tt.value != "" {
+				if again, _ := ParseDependencies(FormatDependencies(got)); len(again) != len(got) {
+					t.Errorf("FormatDependencies() does not round trip: %v", again)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveDependencies(t *testing.T) {
+	dep := func(tag string) Dependency {
+		return Dependency{Tag: tag, Digest: digest.FromString(tag)}
+	}
+	tests := []struct {
+		name    string
+		graph   map[string][]Dependency
+		want    string
+		wantErr string
+	}{
+		{
+			name: "dependencies come first",
+			graph: map[string][]Dependency{
+				"app:v1":      {dep("operator:v1"), dep("crds:v1")},
+				"operator:v1": {dep("crds:v1")},
+			},
+			want: "crds:v1,operator:v1",
+		},
+		{
+			name:  "no dependencies",
+			graph: map[string][]Dependency{},
+			want:  "",
+		},
+		{
+			name: "cycle",
+			graph: map[string][]Dependency{
+				"app:v1":      {dep("operator:v1")},
+				"operator:v1": {dep("app:v1")},
+			},
+			wantErr: "cycle detected: app:v1 -> operator:v1 -> app:v1",
+		},
+		{
+			name: "conflicting digests",
+			graph: map[string][]Dependency{
+				"app:v1":      {dep("operator:v1"), {Tag: "crds:v1", Digest: digest.FromString("other")}},
+				"operator:v1": {dep("crds:v1")},
+			},
+			wantErr: "required at both",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveDependencies(context.Background(), dep("app:v1"), func(_ context.Context, d Dependency) ([]Dependency, error) {
+				return tt.graph[d.Tag], nil
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ResolveDependencies() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tags := make([]string, len(got))
+			for i, d := range got {
+				tags[i] = d.Tag
+			}
+			if s := strings.Join(tags, ","); s != tt.want {
+				t.Errorf("ResolveDependencies() = %s, want %s", s, tt.want)
+			}
+		})
+	}
+}
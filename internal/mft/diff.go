@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/cluster"
+)
+
+// Resource states of a diff between two manifest versions
+const (
+	ResourceAdded     = "added"
+	ResourceRemoved   = "removed"
+	ResourceChanged   = "changed"
+	ResourceUnchanged = "unchanged"
+)
+
+// FieldChange represents a field that differs between two manifest versions or the live object
+type FieldChange struct {
+	Path   string `json:"path" yaml:"path"`
+	Base   any    `json:"base" yaml:"base"`
+	Live   any    `json:"live,omitempty" yaml:"live,omitempty"`
+	Target any    `json:"target" yaml:"target"`
+	// Kind is one of cluster.ChangeModified, ChangeApplied, ChangeDrifted, or ChangeConflict
+	Kind string `json:"kind" yaml:"kind"`
+}
+
+// ResourceDiff represents the differences of a resource between two manifest versions
+type ResourceDiff struct {
+	Kind      string `json:"kind" yaml:"kind"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name      string `json:"name" yaml:"name"`
+	// Status is one of ResourceAdded, ResourceRemoved, ResourceChanged, or ResourceUnchanged
+	Status string `json:"status" yaml:"status"`
+	// LiveMissing is set when compared with the cluster and the resource does not exist there
+	LiveMissing bool           `json:"liveMissing,omitempty" yaml:"liveMissing,omitempty"`
+	Changes     []*FieldChange `json:"changes,omitempty" yaml:"changes,omitempty"`
+}
+
+// DiffResult represents the differences between two manifest versions, and the live cluster
+type DiffResult struct {
+	base        string
+	target      string
+	fromCluster bool
+	resources   []*ResourceDiff
+	color       painter
+}
+
+type diffReport struct {
+	Base        string          `json:"base" yaml:"base"`
+	Target      string          `json:"target" yaml:"target"`
+	FromCluster bool            `json:"fromCluster" yaml:"fromCluster"`
+	Resources   []*ResourceDiff `json:"resources" yaml:"resources"`
+}
+
+func NewDiffResult(base, target string, fromCluster bool, resources []*ResourceDiff) *DiffResult {
+	return &DiffResult{base: base, target: target, fromCluster: fromCluster, resources: resources}
+}
+
+// WithColor returns the result colored in text output if color is set.
+func (r *DiffResult) WithColor(color bool) *DiffResult {
+	c := *r
+	c.color = painter(color)
+	return &c
+}
+
+func (r *DiffResult) Print(output ListOutput) error {
+	report := diffReport{Base: r.base, Target: r.target, FromCluster: r.fromCluster, Resources: r.resources}
+	if report.Resources == nil {
+		report.Resources = []*ResourceDiff{}
+	}
+
+	switch output {
+	case ListTable:
+		r.writeText(os.Stdout)
+		return nil
+	case ListMarkdown:
+		r.writeMarkdown(os.Stdout)
+		return nil
+	case ListJson:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case ListYaml:
+		encoder := yaml.NewEncoder(os.Stdout)
+		defer encoder.Close()
+		return encoder.Encode(report)
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+}
+
+// differs reports whether a resource is shown: it differs between the versions or the cluster.
+func (d *ResourceDiff) differs() bool {
+	return d.Status != ResourceUnchanged || len(d.Changes) > 0 || d.LiveMissing
+}
+
+func (d *ResourceDiff) title() (name, status string) {
+	name = d.Name
+	if d.Namespace != "" {
+		name = d.Namespace + "/" + name
+	}
+	status = d.Status
+	if d.LiveMissing {
+		status += ", missing from cluster"
+	}
+	return d.Kind + " " + name, status
+}
+
+// writeText writes a column per version holding the values of the fields that differ.
+func (r *DiffResult) writeText(w io.Writer) {
+	var table columns
+	header := []cell{{"RESOURCE", colorBold}, {"STATUS", colorBold}, {"FIELD", colorBold}, {"CHANGE", colorBold}, {r.base, colorBold}}
+	if r.fromCluster {
+		header = append(header, cell{"LIVE", colorBold})
+	}
+	table.add(append(header, cell{r.target, colorBold})...)
+	shown := 0
+	for _, d := range r.resources {
+		if !d.differs() {
+			continue
+		}
+		shown++
+		name, status := d.title()
+		if len(d.Changes) == 0 {
+			table.add(cell{text: name}, cell{text: status})
+			continue
+		}
+		for i, c := range d.Changes {
+			row := []cell{{}, {}, {text: c.Path}, {text: c.Kind}, {cluster.FormatValue(c.Base), colorRed}}
+			if i == 0 {
+				row[0], row[1] = cell{text: name}, cell{text: status}
+			}
+			if r.fromCluster {
+				row = append(row, cell{cluster.FormatValue(c.Live), colorYellow})
+			}
+			table.add(append(row, cell{cluster.FormatValue(c.Target), colorGreen})...)
+		}
+	}
+	if shown == 0 {
+		fmt.Fprintf(w, "No differences between %s and %s (%d resources)\n", r.base, r.target, len(r.resources))
+		return
+	}
+	table.write(w, r.color)
+}
+
+// writeMarkdown writes a collapsible section per differing resource holding a diff block, as
+// rendered by GitHub and GitLab in pull request comments.
+func (r *DiffResult) writeMarkdown(w io.Writer) {
+	var shown []*ResourceDiff
+	for _, d := range r.resources {
+		if d.differs() {
+			shown = append(shown, d)
+		}
+	}
+	fmt.Fprintf(w, "### Diff of `%s` and `%s`\n\n", r.base, r.target)
+	if len(shown) == 0 {
+		fmt.Fprintf(w, "No differences (%d resources)\n", len(r.resources))
+		return
+	}
+	fmt.Fprintf(w, "%d of %d resources differ.\n", len(shown), len(r.resources))
+	for _, d := range shown {
+		name, status := d.title()
+		if len(d.Changes) == 0 {
+			fmt.Fprintf(w, "\n- `%s` %s\n", name, status)
+			continue
+		}
+		var b strings.Builder
+		for _, c := range d.Changes {
+			fmt.Fprintf(&b, "@@ %s [%s] @@\n", c.Path, c.Kind)
+			fmt.Fprintf(&b, "- %s\n", cluster.FormatValue(c.Base))
+			if r.fromCluster {
+				fmt.Fprintf(&b, "  live: %s\n", cluster.FormatValue(c.Live))
+			}
+			fmt.Fprintf(&b, "+ %s\n", cluster.FormatValue(c.Target))
+		}
+		writeMarkdownDetails(w, fmt.Sprintf("<code>%s</code> %s", name, status), b.String())
+	}
+}
+
+// writeMarkdownDetails writes a collapsed section with a diff block.
+func writeMarkdownDetails(w io.Writer, summary, diff string) {
+	fmt.Fprintf(w, "\n<details>\n<summary>%s</summary>\n\n```diff\n%s```\n\n</details>\n", summary, diff)
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"strings"
+	"testing"
+)
+
+func testDiffResult() *DiffResult {
+	return NewDiffResult("app:v1", "app:v2", false, []*ResourceDiff{
+		{
+			Kind: "Deployment", Namespace: "default", Name: "app", Status: ResourceChanged,
+			Changes: []*FieldChange{
+				{Path: "spec.replicas", Base: 2, Target: 3, Kind: "modified"},
+				{Path: "spec.template.spec.containers[0].image", Base: "app:1.0", Target: "app:1.1", Kind: "modified"},
+			},
+		},
+		{Kind: "ConfigMap", Name: "extra", Status: ResourceAdded},
+		{Kind: "Service", Name: "app", Status: ResourceUnchanged},
+	})
+}
+
+func TestDiffResult_Text(t *testing.T) {
+	var b strings.Builder
+	testDiffResult().writeText(&b)
+	want := "" +
+		"RESOURCE                 STATUS    FIELD                                    CHANGE     app:v1      app:v2\n" +
+		"Deployment default/app   changed   spec.replicas                            modified   2           3\n" +
+		"                                   spec.template.spec.containers[0].image   modified   \"app:1.0\"   \"app:1.1\"\n" +
+		"ConfigMap extra          added\n"
+	if got := b.String(); got != want {
+		t.Errorf("writeText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDiffResult_Color(t *testing.T) {
+	var plain, colored strings.Builder
+	testDiffResult().writeText(&plain)
+	testDiffResult().WithColor(true).writeText(&colored)
+
+	got := colored.String()
+	for _, want := range []string{colorRed + "2" + colorReset, colorGreen + "3" + colorReset, colorBold + "RESOURCE" + colorReset} {
+		if !strings.Contains(got, want) {
+			t.Errorf("colored output does not contain %q:\n%s", want, got)
+		}
+	}
+	// The colors do not shift the columns
+	for _, code := range []string{colorRed, colorGreen, colorBold, colorReset} {
+		got = strings.ReplaceAll(got, code, "")
+	}
+	if got != plain.String() {
+		t.Errorf("colored output without escapes =\n%s\nwant\n%s", got, plain.String())
+	}
+}
+
+func TestDiffResult_Markdown(t *testing.T) {
+	var b strings.Builder
+	testDiffResult().writeMarkdown(&b)
+	want := "### Diff of `app:v1` and `app:v2`\n\n" +
+		"2 of 3 resources differ.\n" +
+		"\n<details>\n<summary><code>Deployment default/app</code> changed</summary>\n\n" +
+		"```diff\n" +
+		"@@ spec.replicas [modified] @@\n- 2\n+ 3\n" +
+		"@@ spec.template.spec.containers[0].image [modified] @@\n- \"app:1.0\"\n+ \"app:1.1\"\n" +
+		"```\n\n</details>\n" +
+		"\n- `ConfigMap extra` added\n"
+	if got := b.String(); got != want {
+		t.Errorf("writeMarkdown() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDriftResult_Text(t *testing.T) {
+	r := NewDriftResult("app:v1", []*ResourceDrift{
+		{Kind: "Deployment", Namespace: "default", Name: "app", Differences: []*FieldDifference{{Path: "spec.replicas", Desired: 3, Live: 5}}},
+		{Kind: "ConfigMap", Name: "app", Missing: true},
+		{Kind: "Service", Name: "app"},
+	})
+	var b strings.Builder
+	r.writeText(&b)
+	want := "" +
+		"RESOURCE                 FIELD           DESIRED   LIVE\n" +
+		"Deployment default/app   spec.replicas   3         5\n" +
+		"ConfigMap app                                      missing from cluster\n"
+	if got := b.String(); got != want {
+		t.Errorf("writeText() =\n%s\nwant\n%s", got, want)
+	}
+
+	b.Reset()
+	r.writeMarkdown(&b)
+	for _, want := range []string{"2 of 3 resources drifted.", "@@ spec.replicas @@\n- 5\n+ 3\n", "- `ConfigMap app` missing from cluster"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("writeMarkdown() does not contain %q:\n%s", want, b.String())
+		}
+	}
+}
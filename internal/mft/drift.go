@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/cluster"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+// FieldDifference represents a field whose live value does not match the stored manifest
+type FieldDifference struct {
+	Path    string `json:"path" yaml:"path"`
+	Desired any    `json:"desired" yaml:"desired"`
+	Live    any    `json:"live" yaml:"live"`
+}
+
+// ResourceDrift represents the differences between a resource of an artifact and its live object
+type ResourceDrift struct {
+	Kind        string             `json:"kind" yaml:"kind"`
+	Namespace   string             `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name        string             `json:"name" yaml:"name"`
+	Missing     bool               `json:"missing" yaml:"missing"`
+	Differences []*FieldDifference `json:"differences,omitempty" yaml:"differences,omitempty"`
+}
+
+// Drifted reports whether the live object is missing or differs from the stored manifest
+func (d *ResourceDrift) Drifted() bool {
+	return d.Missing || len(d.Differences) > 0
+}
+
+// CompareDrift compares a resource of an artifact with its live object, which is nil if the
+// resource is missing from the cluster. Resources without a namespace take the one of their
+// live object.
+func CompareDrift(doc *manifest.Document, live cluster.Object) (*ResourceDrift, error) {
+	rd := &ResourceDrift{Kind: doc.Kind, Namespace: doc.Namespace, Name: doc.Name}
+	if live == nil {
+		rd.Missing = true
+		return rd, nil
+	}
+	if ns, ok := cluster.NamespaceOf(live); ok {
+		rd.Namespace = ns
+	}
+	desired, err := cluster.Desired(doc)
+	if err != nil {
+		return nil, err
+	}
+	for _, diff := range cluster.Compare(desired, live) {
+		rd.Differences = append(rd.Differences, &FieldDifference{Path: diff.Path, Desired: diff.Desired, Live: diff.Live})
+	}
+	return rd, nil
+}
+
+// DriftResult represents the drift of every resource of an artifact
+type DriftResult struct {
+	tag       string
+	resources []*ResourceDrift
+	color     painter
+}
+
+type driftReport struct {
+	Tag       string           `json:"tag" yaml:"tag"`
+	Drifted   bool             `json:"drifted" yaml:"drifted"`
+	Resources []*ResourceDrift `json:"resources" yaml:"resources"`
+}
+
+func NewDriftResult(tag string, resources []*ResourceDrift) *DriftResult {
+	return &DriftResult{tag: tag, resources: resources}
+}
+
+// WithColor returns the result colored in text output if color is set.
+func (r *DriftResult) WithColor(color bool) *DriftResult {
+	c := *r
+	c.color = painter(color)
+	return &c
+}
+
+func (r *DriftResult) Resources() []*ResourceDrift {
+	return r.resources
+}
+
+// Drifted returns the resources that are missing or differ from the stored manifest
+func (r *DriftResult) Drifted() []*ResourceDrift {
+	var drifted []*ResourceDrift
+	for _, d := range r.resources {
+		if d.Drifted() {
+			drifted = append(drifted, d)
+		}
+	}
+	return drifted
+}
+
+func (r *DriftResult) Print(output ListOutput) error {
+	report := driftReport{Tag: r.tag, Drifted: len(r.Drifted()) > 0, Resources: r.resources}
+	if report.Resources == nil {
+		report.Resources = []*ResourceDrift{}
+	}
+
+	switch output {
+	case ListTable:
+		r.writeText(os.Stdout)
+		return nil
+	case ListMarkdown:
+		r.writeMarkdown(os.Stdout)
+		return nil
+	case ListJson:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case ListYaml:
+		encoder := yaml.NewEncoder(os.Stdout)
+		defer encoder.Close()
+		return encoder.Encode(report)
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+}
+
+func (d *ResourceDrift) title() string {
+	if d.Namespace != "" {
+		return d.Kind + " " + d.Namespace + "/" + d.Name
+	}
+	return d.Kind + " " + d.Name
+}
+
+// writeText writes the desired and the live value of every field that drifted in columns.
+func (r *DriftResult) writeText(w io.Writer) {
+	drifted := r.Drifted()
+	if len(drifted) == 0 {
+		fmt.Fprintf(w, "No drift detected in %s (%d resources)\n", r.tag, len(r.resources))
+		return
+	}
+
+	var table columns
+	table.add(cell{"RESOURCE", colorBold}, cell{"FIELD", colorBold}, cell{"DESIRED", colorBold}, cell{"LIVE", colorBold})
+	for _, d := range drifted {
+		if d.Missing {
+			table.add(cell{text: d.title()}, cell{}, cell{}, cell{"missing from cluster", colorRed})
+			continue
+		}
+		for i, f := range d.Differences {
+			name := ""
+			if i == 0 {
+				name = d.title()
+			}
+			table.add(cell{text: name}, cell{text: f.Path}, cell{cluster.FormatValue(f.Desired), colorGreen}, cell{cluster.FormatValue(f.Live), colorRed})
+		}
+	}
+	table.write(w, r.color)
+}
+
+// writeMarkdown writes a collapsible section per drifted resource holding a diff block from the
+// live to the desired value, which is the change applying the manifest would make.
+func (r *DriftResult) writeMarkdown(w io.Writer) {
+	drifted := r.Drifted()
+	fmt.Fprintf(w, "### Drift of `%s`\n\n", r.tag)
+	if len(drifted) == 0 {
+		fmt.Fprintf(w, "No drift detected (%d resources)\n", len(r.resources))
+		return
+	}
+	fmt.Fprintf(w, "%d of %d resources drifted.\n", len(drifted), len(r.resources))
+	for _, d := range drifted {
+		if d.Missing {
+			fmt.Fprintf(w, "\n- `%s` missing from cluster\n", d.title())
+			continue
+		}
+		var b strings.Builder
+		for _, f := range d.Differences {
+			fmt.Fprintf(&b, "@@ %s @@\n- %s\n+ %s\n", f.Path, cluster.FormatValue(f.Live), cluster.FormatValue(f.Desired))
+		}
+		writeMarkdownDetails(w, fmt.Sprintf("<code>%s</code> drifted", d.title()), b.String())
+	}
+}
@@ -0,0 +1,196 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/cluster"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+func TestCompareDrift(t *testing.T) {
+	docs, err := manifest.Split([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    app: web
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: web
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+        ports:
+        - containerPort: 80
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		live          string
+		wantMissing   bool
+		wantNamespace string
+		want          []*FieldDifference
+	}{
+		{
+			name:        "missing from cluster",
+			wantMissing: true,
+		},
+		{
+			name: "in sync, defaults and status ignored",
+			live: `{"apiVersion":"apps/v1","kind":"Deployment",
+				"metadata":{"name":"app","namespace":"prod","labels":{"app":"web"}},
+				"spec":{"replicas":2,"selector":{"matchLabels":{"app":"web"}},"revisionHistoryLimit":10,
+					"template":{"spec":{"containers":[{"name":"web","image":"nginx:1.25","ports":[{"containerPort":80,"protocol":"TCP"}]}]}}},
+				"status":{"replicas":2}}`,
+			wantNamespace: "prod",
+		},
+		{
+			name: "scalar and label changed",
+			live: `{"apiVersion":"apps/v1","kind":"Deployment",
+				"metadata":{"name":"app","labels":{"app":"api"}},
+				"spec":{"replicas":5,"selector":{"matchLabels":{"app":"web"}},
+					"template":{"spec":{"containers":[{"name":"web","image":"nginx:1.25","ports":[{"containerPort":80}]}]}}}}`,
+			want: []*FieldDifference{
+				{Path: "metadata.labels[app]", Desired: "web", Live: "api"},
+				{Path: "spec.replicas", Desired: float64(2), Live: float64(5)},
+			},
+		},
+		{
+			name: "nested list element changed",
+			live: `{"apiVersion":"apps/v1","kind":"Deployment",
+				"metadata":{"name":"app","labels":{"app":"web"}},
+				"spec":{"replicas":2,"selector":{"matchLabels":{"app":"web"}},
+					"template":{"spec":{"containers":[{"name":"web","image":"nginx:1.26","ports":[{"containerPort":8080}]}]}}}}`,
+			want: []*FieldDifference{
+				{Path: "spec.template.spec.containers[0].image", Desired: "nginx:1.25", Live: "nginx:1.26"},
+				{Path: "spec.template.spec.containers[0].ports[0].containerPort", Desired: float64(80), Live: float64(8080)},
+			},
+		},
+		{
+			name: "field unset live",
+			live: `{"apiVersion":"apps/v1","kind":"Deployment",
+				"metadata":{"name":"app"},
+				"spec":{"selector":{"matchLabels":{"app":"web"}},
+					"template":{"spec":{"containers":[{"name":"web","image":"nginx:1.25","ports":[{"containerPort":80}]}]}}}}`,
+			want: []*FieldDifference{
+				{Path: "metadata.labels[app]", Desired: "web", Live: nil},
+				{Path: "spec.replicas", Desired: float64(2), Live: nil},
+			},
+		},
+		{
+			name: "list length changed",
+			live: `{"apiVersion":"apps/v1","kind":"Deployment",
+				"metadata":{"name":"app","labels":{"app":"web"}},
+				"spec":{"replicas":2,"selector":{"matchLabels":{"app":"web"}},
+					"template":{"spec":{"containers":[{"name":"web","image":"nginx:1.25","ports":[{"containerPort":80}]},{"name":"sidecar"}]}}}}`,
+			want: []*FieldDifference{
+				{
+					Path: "spec.template.spec.containers",
+					Desired: []any{
+						cluster.Object{"name": "web", "image": "nginx:1.25", "ports": []any{cluster.Object{"containerPort": float64(80)}}},
+					},
+					Live: []any{
+						cluster.Object{"name": "web", "image": "nginx:1.25", "ports": []any{cluster.Object{"containerPort": float64(80)}}},
+						cluster.Object{"name": "sidecar"},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var live cluster.Object
+			if tt.live != "" {
+				if err := json.Unmarshal([]byte(tt.live), &live); err != nil {
+					t.Fatalf("invalid test object: %v", err)
+				}
+			}
+			got, err := CompareDrift(docs[0], live)
+			if err != nil {
+				t.Fatalf("CompareDrift() failed: %v", err)
+			}
+			if got.Kind != "Deployment" || got.Name != "app" || got.Namespace != tt.wantNamespace {
+				t.Errorf("CompareDrift() resource = %s, want Deployment app in %q", got.title(), tt.wantNamespace)
+			}
+			if got.Missing != tt.wantMissing {
+				t.Errorf("CompareDrift() Missing = %v, want %v", got.Missing, tt.wantMissing)
+			}
+			if !reflect.DeepEqual(got.Differences, tt.want) {
+				t.Errorf("CompareDrift() Differences = %s, want %s", formatDifferences(got.Differences), formatDifferences(tt.want))
+			}
+			if want := tt.wantMissing || len(tt.want) > 0; got.Drifted() != want {
+				t.Errorf("Drifted() = %v, want %v", got.Drifted(), want)
+			}
+		})
+	}
+}
+
+func formatDifferences(diffs []*FieldDifference) string {
+	var parts []string
+	for _, d := range diffs {
+		parts = append(parts, d.Path+": "+cluster.FormatValue(d.Desired)+" -> "+cluster.FormatValue(d.Live))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func TestDriftResult_FieldValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		diff    *FieldDifference
+		wantRow string
+		wantMD  string
+	}{
+		{
+			name:    "number",
+			diff:    &FieldDifference{Path: "spec.replicas", Desired: float64(2), Live: float64(5)},
+			wantRow: "spec.replicas   2         5",
+			wantMD:  "@@ spec.replicas @@\n- 5\n+ 2\n",
+		},
+		{
+			name:    "string",
+			diff:    &FieldDifference{Path: "spec.image", Desired: "nginx:1.25", Live: "nginx:1.26"},
+			wantRow: `spec.image   "nginx:1.25"   "nginx:1.26"`,
+			wantMD:  "@@ spec.image @@\n- \"nginx:1.26\"\n+ \"nginx:1.25\"\n",
+		},
+		{
+			name:    "unset live",
+			diff:    &FieldDifference{Path: "metadata.labels[app]", Desired: "web", Live: nil},
+			wantRow: `metadata.labels[app]   "web"     <unset>`,
+			wantMD:  "@@ metadata.labels[app] @@\n- <unset>\n+ \"web\"\n",
+		},
+		{
+			name:    "object",
+			diff:    &FieldDifference{Path: "spec.selector", Desired: cluster.Object{"app": "web"}, Live: "web"},
+			wantRow: `spec.selector   {"app":"web"}   "web"`,
+			wantMD:  "@@ spec.selector @@\n- \"web\"\n+ {\"app\":\"web\"}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewDriftResult("app:v1", []*ResourceDrift{{Kind: "Deployment", Name: "app", Differences: []*FieldDifference{tt.diff}}})
+			var b strings.Builder
+			r.writeText(&b)
+			if !strings.Contains(b.String(), "Deployment app   "+tt.wantRow+"\n") {
+				t.Errorf("writeText() =\n%s\nwant the row %q", b.String(), tt.wantRow)
+			}
+			b.Reset()
+			r.writeMarkdown(&b)
+			if !strings.Contains(b.String(), tt.wantMD) {
+				t.Errorf("writeMarkdown() =\n%s\nwant it to contain %q", b.String(), tt.wantMD)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Types of events recorded in the event log of local storage
+const (
+	EventPacked   = "packed"
+	EventPulled   = "pulled"
+	EventPushed   = "pushed"
+	EventCopied   = "copied"
+	EventAliased  = "aliased"
+	EventDeleted  = "deleted"
+	EventSigned   = "signed"
+	EventMigrated = "migrated"
+	EventPinned   = "pinned"
+	EventUnpinned = "unpinned"
+)
+
+// Event represents a change of a tag in local storage, or a push of it
+type Event struct {
+	Time       time.Time `json:"time" yaml:"time"`
+	Type       string    `json:"type" yaml:"type"`
+	Repository string    `json:"repository" yaml:"repository"`
+	Tag        string    `json:"tag" yaml:"tag"`
+	Digest     string    `json:"digest,omitempty" yaml:"digest,omitempty"`
+}
+
+// EventsResult represents the events recorded in the event log, oldest first
+type EventsResult struct {
+	events []*Event
+}
+
+func NewEventsResult(events []*Event) *EventsResult {
+	return &EventsResult{events: events}
+}
+
+func (r *EventsResult) Items() []*Event {
+	return r.events
+}
+
+func (r *EventsResult) Print(output ListOutput) error {
+	events := r.events
+	if events == nil {
+		events = []*Event{}
+	}
+
+	switch output {
+	case ListTable:
+		return r.printTable()
+	case ListJson:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(events)
+	case ListYaml:
+		encoder := yaml.NewEncoder(os.Stdout)
+		defer encoder.Close()
+		return encoder.Encode(events)
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+}
+
+func (r *EventsResult) printTable() error {
+	if len(r.events) == 0 {
+		fmt.Println("No events found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "TIME\tEVENT\tREPOSITORY\tTAG\tDIGEST")
+	for _, e := range r.events {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Time.Local().Format(time.RFC3339), e.Type, e.Repository, e.Tag, e.Digest)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Outcomes of garbage collection for a tag
+const (
+	GCRemoved = "removed"
+	GCPinned  = "pinned"
+)
+
+// GCEntry represents a tag considered by garbage collection
+type GCEntry struct {
+	Repository string `json:"repository" yaml:"repository"`
+	Tag        string `json:"tag" yaml:"tag"`
+	Digest     string `json:"digest" yaml:"digest"`
+	// Cached reports whether the tag is in the cache rather than in local storage
+	Cached bool   `json:"cached" yaml:"cached"`
+	Status string `json:"status" yaml:"status"`
+}
+
+// GCResult represents the tags removed, or kept because they are pinned, by garbage collection
+type GCResult struct {
+	dryRun  bool
+	entries []*GCEntry
+}
+
+func NewGCResult(dryRun bool) *GCResult {
+	return &GCResult{dryRun: dryRun}
+}
+
+func (r *GCResult) Add(e *GCEntry) {
+	r.entries = append(r.entries, e)
+}
+
+func (r *GCResult) Entries() []*GCEntry {
+	return r.entries
+}
+
+// Removed returns the tags removed, or that would be removed by a dry run
+func (r *GCResult) Removed() []*GCEntry {
+	var removed []*GCEntry
+	for _, e := range r.entries {
+		if e.Status == GCRemoved {
+			removed = append(removed, e)
+		}
+	}
+	return removed
+}
+
+func (r *GCResult) Print(output ListOutput) error {
+	switch output {
+	case ListTable:
+		return r.printTable()
+	case ListJson:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r.entries)
+	case ListYaml:
+		encoder := yaml.NewEncoder(os.Stdout)
+		defer encoder.Close()
+		return encoder.Encode(r.entries)
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+}
+
+func (r *GCResult) printTable() error {
+	if len(r.entries) == 0 {
+		fmt.Println("Nothing to collect")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tTAG\tLOCATION\tSTATUS")
+	for _, e := range r.entries {
+		location := "storage"
+		if e.Cached {
+			location = "cache"
+		}
+		status := e.Status
+		switch {
+		case status == GCPinned:
+			status = "kept, pinned"
+		case r.dryRun:
+			status = "would remove"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Repository, e.Tag, location, status)
+	}
+	return w.Flush()
+}
@@ -4,16 +4,17 @@
 package mft
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"sort"
+	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/goccy/go-yaml"
 )
 
@@ -22,6 +23,10 @@ type Info struct {
 	Tag        string    `json:"tag" yaml:"tag"`
 	Size       string    `json:"size" yaml:"size"`
 	Created    time.Time `json:"created" yaml:"created"`
+	// Pinned reports whether the tag is pinned, so that gc never removes it
+	Pinned bool `json:"pinned,omitempty" yaml:"pinned,omitempty"`
+	// Cached reports whether the tag was fetched implicitly into the cache
+	Cached bool `json:"cached,omitempty" yaml:"cached,omitempty"`
 }
 
 type Registry interface {
@@ -34,6 +39,8 @@ const (
 	ListTable ListOutput = "table"
 	ListJson  ListOutput = "json"
 	ListYaml  ListOutput = "yaml"
+	// ListMarkdown renders a report as Markdown for pull request comments, where supported
+	ListMarkdown ListOutput = "markdown"
 )
 
 // ListResult represents information about a stored manifest
@@ -45,6 +52,10 @@ func NewListResult(info []*Info) *ListResult {
 	return &ListResult{info: info}
 }
 
+func (r *ListResult) Items() []*Info {
+	return r.info
+}
+
 func (r *ListResult) Print(output ListOutput) error {
 	switch output {
 	case ListTable:
@@ -58,6 +69,19 @@ func (r *ListResult) Print(output ListOutput) error {
 	}
 }
 
+// Add appends the entries of info whose tag is not listed yet.
+func (r *ListResult) Add(info []*Info) {
+	seen := make(map[string]bool, len(r.info))
+	for _, i := range r.info {
+		seen[i.Repository+":"+i.Tag] = true
+	}
+	for _, i := range info {
+		if !seen[i.Repository+":"+i.Tag] {
+			r.info = append(r.info, i)
+		}
+	}
+}
+
 func (r *ListResult) Sort() {
 	sort.Slice(r.info, func(i, j int) bool {
 		if r.info[i].Repository != r.info[j].Repository {
@@ -73,12 +97,36 @@ func (r *ListResult) printTable() error {
 		return nil
 	}
 
+	// The status column is only shown when a tag is pinned or cached
+	showStatus := false
+	for _, i := range r.info {
+		showStatus = showStatus || i.Pinned || i.Cached
+	}
+
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "REPOSITORY\tTAG\tSIZE\tCREATED")
+	if showStatus {
+		fmt.Fprintln(w, "REPOSITORY\tTAG\tSIZE\tCREATED\tSTATUS")
+	} else {
+		fmt.Fprintln(w, "REPOSITORY\tTAG\tSIZE\tCREATED")
+	}
 
 	for _, i := range r.info {
 		created := i.Created.Format("2006-01-02 15:04:05")
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", i.Repository, i.Tag, i.Size, created)
+		if !showStatus {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", i.Repository, i.Tag, i.Size, created)
+			continue
+		}
+		var status []string
+		if i.Cached {
+			status = append(status, "cached")
+		}
+		if i.Pinned {
+			status = append(status, "pinned")
+		}
+		if len(status) == 0 {
+			status = append(status, "-")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", i.Repository, i.Tag, i.Size, created, strings.Join(status, ","))
 	}
 
 	return w.Flush()
@@ -101,64 +149,221 @@ func List(ctx context.Context, r Registry) (*ListResult, error) {
 }
 
 type Repository interface {
-	Copy(ctx context.Context, dest string) error
+	Bundle(ctx context.Context) (*BundleResult, error)
+	Copy(ctx context.Context, dest string, opts CopyOptions) error
+	CreateBundle(ctx context.Context, members []*BundleMember) error
 	Delete(ctx context.Context) (*DeleteResult, error)
-	Dump(ctx context.Context) (*DumpResult, error)
+	Dump(ctx context.Context) (io.ReadCloser, error)
+	IsBundle(ctx context.Context) (bool, error)
 	Path(ctx context.Context) (*PathResult, error)
+	PlanDelete(ctx context.Context) (*PlanResult, error)
+	PlanPush(ctx context.Context) (*PlanResult, error)
 	Pull(ctx context.Context) error
 	Push(ctx context.Context) error
 	Save(ctx context.Context, manifestPath string) error
+	SaveDelta(ctx context.Context, manifestPath string, base string) error
+	Tags(ctx context.Context, opts TagsOptions) (*TagsResult, error)
+	UpToDate(ctx context.Context) (bool, error)
+	VerifyContent(ctx context.Context, repair bool) (*VerifyContentResult, error)
 }
 
 // DeleteResult represents the result of a delete operation
 type DeleteResult struct {
+	ref string
+}
+
+func NewDeleteResult(ref string) *DeleteResult {
+	return &DeleteResult{ref: ref}
+}
+
+func (r *DeleteResult) Print() {
+	fmt.Printf("Deleted %s\n", r.ref)
+}
+
+type PathResult struct {
+	path   string
+	digest string
+}
+
+func NewPathResult(path string, digest string) *PathResult {
+	return &PathResult{path: path, digest: digest}
+}
+
+func (r *PathResult) Digest() string {
+	return r.digest
+}
+
+func (r *PathResult) Print() {
+	fmt.Println(r.path)
+}
+
+// PrintChecksum prints the expected digest and the path in the format of sha256sum,
+// so the file can be checked again later with 'sha256sum -c'.
+func (r *PathResult) PrintChecksum() {
+	_, encoded, _ := strings.Cut(r.digest, ":")
+	fmt.Printf("%s  %s\n", encoded, r.path)
+}
+
+// ContentIssue describes a blob whose on-disk content does not match its descriptor
+type ContentIssue struct {
+	Digest string
+	Path   string
+	Reason string
+}
+
+// VerifyContentResult represents the result of a content integrity check
+type VerifyContentResult struct {
 	repository string
 	tag        string
+	checked    int
+	repaired   int
+	issues     []*ContentIssue
 }
 
-func NewDeleteResult(repository string, tag string) *DeleteResult {
-	return &DeleteResult{
+func NewVerifyContentResult(repository string, tag string) *VerifyContentResult {
+	return &VerifyContentResult{
 		repository: repository,
 		tag:        tag,
 	}
 }
 
-func (r *DeleteResult) Print() {
-	fmt.Printf("Deleted %s:%s\n", r.repository, r.tag)
+func (r *VerifyContentResult) AddChecked() {
+	r.checked++
 }
 
-type DumpResult struct {
-	data []byte
+func (r *VerifyContentResult) AddIssue(digest, path, reason string) {
+	r.issues = append(r.issues, &ContentIssue{Digest: digest, Path: path, Reason: reason})
 }
 
-func NewDumpResult(data []byte) *DumpResult {
-	return &DumpResult{data: data}
+// MarkRepaired records how many corrupted blobs were re-pulled before this result was computed
+func (r *VerifyContentResult) MarkRepaired(n int) {
+	r.repaired = n
 }
 
-func (r *DumpResult) Read(p []byte) (n int, err error) {
-	return bytes.NewReader(r.data).Read(p)
+func (r *VerifyContentResult) Issues() []*ContentIssue {
+	return r.issues
 }
 
-func (r *DumpResult) WriteTo(w io.Writer) (int64, error) {
-	n, err := w.Write(r.data)
-	return int64(n), err
+func (r *VerifyContentResult) OK() bool {
+	return len(r.issues) == 0
 }
 
-type PathResult struct {
-	path string
+func (r *VerifyContentResult) Print() {
+	for _, i := range r.issues {
+		fmt.Printf("%s:%s: blob %s is corrupted (%s)\n", r.repository, r.tag, i.Digest, i.Reason)
+	}
+	if r.repaired > 0 {
+		fmt.Printf("%s:%s: repaired %d blob(s)\n", r.repository, r.tag, r.repaired)
+	}
+	if r.OK() {
+		fmt.Printf("%s:%s: %d blob(s) verified\n", r.repository, r.tag, r.checked)
+	}
 }
 
-func NewPathResult(path string) *PathResult {
-	return &PathResult{path: path}
+// TagInfo represents a tag of a single repository
+type TagInfo struct {
+	Tag    string `json:"tag" yaml:"tag"`
+	Digest string `json:"digest,omitempty" yaml:"digest,omitempty"`
 }
 
-func (r *PathResult) Print() {
-	fmt.Println(r.path)
+// CopyOptions controls how a manifest is copied
+type CopyOptions struct {
+	// Force replaces an existing destination tag
+	Force bool
 }
 
-// Copy copies a manifest from the source repository to a new destination tag in local storage.
-func Copy(ctx context.Context, r Repository, dest string) error {
-	return r.Copy(ctx, dest)
+// TagsOptions controls how the tags of a repository are listed
+type TagsOptions struct {
+	// Remote lists the tags from the registry instead of local storage
+	Remote bool
+	// Digests resolves the manifest digest of each tag
+	Digests bool
+	// PageSize is the number of tags requested per page from the registry, 0 for the registry default
+	PageSize int
+}
+
+// TagsResult represents the tags of a single repository
+type TagsResult struct {
+	repository string
+	tags       []*TagInfo
+	digests    bool
+}
+
+func NewTagsResult(repository string, tags []*TagInfo, digests bool) *TagsResult {
+	return &TagsResult{repository: repository, tags: tags, digests: digests}
+}
+
+func (r *TagsResult) Items() []*TagInfo {
+	return r.tags
+}
+
+// Sort orders semver tags by version followed by the remaining tags in lexical order
+func (r *TagsResult) Sort() {
+	versions := make(map[string]*semver.Version, len(r.tags))
+	for _, t := range r.tags {
+		if v, err := semver.NewVersion(t.Tag); err == nil {
+			versions[t.Tag] = v
+		}
+	}
+
+	sort.SliceStable(r.tags, func(i, j int) bool {
+		vi, iok := versions[r.tags[i].Tag]
+		vj, jok := versions[r.tags[j].Tag]
+		switch {
+		case iok && jok:
+			if !vi.Equal(vj) {
+				return vi.LessThan(vj)
+			}
+			return r.tags[i].Tag < r.tags[j].Tag
+		case iok != jok:
+			return iok
+		default:
+			return r.tags[i].Tag < r.tags[j].Tag
+		}
+	})
+}
+
+func (r *TagsResult) Print(output ListOutput) error {
+	switch output {
+	case ListTable:
+		return r.printTable()
+	case ListJson:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r.tags)
+	case ListYaml:
+		encoder := yaml.NewEncoder(os.Stdout)
+		defer encoder.Close()
+		return encoder.Encode(r.tags)
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+}
+
+func (r *TagsResult) printTable() error {
+	if len(r.tags) == 0 {
+		fmt.Printf("No tags found for %s\n", r.repository)
+		return nil
+	}
+
+	if !r.digests {
+		for _, t := range r.tags {
+			fmt.Println(t.Tag)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "TAG\tDIGEST")
+	for _, t := range r.tags {
+		fmt.Fprintf(w, "%s\t%s\n", t.Tag, t.Digest)
+	}
+	return w.Flush()
+}
+
+// Copy copies a manifest from the source repository to a destination tag in local storage.
+func Copy(ctx context.Context, r Repository, dest string, opts CopyOptions) error {
+	return r.Copy(ctx, dest, opts)
 }
 
 // Delete removes a manifest from local OCI layout storage
@@ -166,8 +371,8 @@ func Delete(ctx context.Context, r Repository) (*DeleteResult, error) {
 	return r.Delete(ctx)
 }
 
-// Dump retrieves and outputs a manifest from local OCI layout storage
-func Dump(ctx context.Context, r Repository) (*DumpResult, error) {
+// Dump streams the content of a manifest from local OCI layout storage. The caller closes it.
+func Dump(ctx context.Context, r Repository) (io.ReadCloser, error) {
 	return r.Dump(ctx)
 }
 
@@ -175,17 +380,47 @@ func Path(ctx context.Context, r Repository) (*PathResult, error) {
 	return r.Path(ctx)
 }
 
+// PlanDelete reports what Delete would remove without removing it, it returns nil if the tag does not exist
+func PlanDelete(ctx context.Context, r Repository) (*PlanResult, error) {
+	return r.PlanDelete(ctx)
+}
+
+// PlanPush reports what Push would upload to an OCI registry without uploading it
+func PlanPush(ctx context.Context, r Repository) (*PlanResult, error) {
+	return r.PlanPush(ctx)
+}
+
 // Pull pulls a Kubernetes manifest from an OCI registry
 func Pull(ctx context.Context, r Repository) error {
 	return r.Pull(ctx)
 }
 
+// UpToDate reports whether the local copy of a manifest matches the one in the OCI registry
+func UpToDate(ctx context.Context, r Repository) (bool, error) {
+	return r.UpToDate(ctx)
+}
+
 // Push pushes a Kubernetes manifest to an OCI registry
 func Push(ctx context.Context, r Repository) error {
 	return r.Push(ctx)
 }
 
+// VerifyContent checks the integrity of the blobs of a manifest in local OCI layout storage
+func VerifyContent(ctx context.Context, r Repository, repair bool) (*VerifyContentResult, error) {
+	return r.VerifyContent(ctx, repair)
+}
+
+// Tags lists the tags of a single repository in local storage or in the OCI registry
+func Tags(ctx context.Context, r Repository, opts TagsOptions) (*TagsResult, error) {
+	return r.Tags(ctx, opts)
+}
+
 // Save packages a Kubernetes manifest into OCI layout format
 func Save(ctx context.Context, r Repository, manifest string) error {
 	return r.Save(ctx, manifest)
 }
+
+// SaveDelta packages a Kubernetes manifest as a patch against a base tag in the same repository
+func SaveDelta(ctx context.Context, r Repository, manifest string, base string) error {
+	return r.SaveDelta(ctx, manifest, base)
+}
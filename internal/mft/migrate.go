@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+// Outcomes of migrating a tag of a legacy storage directory
+const (
+	MigrationMigrated = "migrated"
+	MigrationExists   = "exists"
+	MigrationSkipped  = "skipped"
+)
+
+// Migration represents the migration of a tag of a legacy storage directory into the
+// layout of its repository
+type Migration struct {
+	// Dir is the legacy directory, relative to the storage directory
+	Dir string `json:"dir" yaml:"dir"`
+	// Tag is the tag in the legacy directory, empty for a directory without tags
+	Tag        string `json:"tag" yaml:"tag"`
+	Repository string `json:"repository,omitempty" yaml:"repository,omitempty"`
+	Digest     string `json:"digest,omitempty" yaml:"digest,omitempty"`
+	Status     string `json:"status" yaml:"status"`
+	// Reason explains why the tag was skipped
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// MigrateResult represents the migration of the legacy directories of local storage
+type MigrateResult struct {
+	dryRun     bool
+	migrations []*Migration
+	removed    []string
+}
+
+func NewMigrateResult(dryRun bool) *MigrateResult {
+	return &MigrateResult{dryRun: dryRun}
+}
+
+func (r *MigrateResult) Add(m *Migration) {
+	r.migrations = append(r.migrations, m)
+}
+
+// AddRemoved records a legacy directory removed after all its tags were migrated
+func (r *MigrateResult) AddRemoved(dir string) {
+	r.removed = append(r.removed, dir)
+}
+
+func (r *MigrateResult) Migrations() []*Migration {
+	return r.migrations
+}
+
+func (r *MigrateResult) Removed() []string {
+	return r.removed
+}
+
+// Skipped returns the tags that were not migrated
+func (r *MigrateResult) Skipped() []*Migration {
+	var skipped []*Migration
+	for _, m := range r.migrations {
+		if m.Status == MigrationSkipped {
+			skipped = append(skipped, m)
+		}
+	}
+	return skipped
+}
+
+func (r *MigrateResult) Print() error {
+	if len(r.migrations) == 0 {
+		fmt.Println("No legacy storage directories found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "DIRECTORY\tTAG\tREPOSITORY\tSTATUS")
+	for _, m := range r.migrations {
+		status := m.Status
+		if r.dryRun && status == MigrationMigrated {
+			status = "would migrate"
+		}
+		if m.Reason != "" {
+			status += ": " + m.Reason
+		}
+		tag, repository := m.Tag, m.Repository
+		if tag == "" {
+			tag = "-"
+		}
+		if repository == "" {
+			repository = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.Dir, tag, repository, status)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, dir := range r.removed {
+		fmt.Printf("Removed legacy directory %s\n", dir)
+	}
+	return nil
+}
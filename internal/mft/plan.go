@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+// PlanAction is the kind of change a dry run reports
+type PlanAction string
+
+const (
+	PlanCreate PlanAction = "create"
+	PlanUpload PlanAction = "upload"
+	PlanRemove PlanAction = "remove"
+)
+
+// PlanBlob is a blob that a dry run would create, upload, or remove
+type PlanBlob struct {
+	Digest    string
+	MediaType string
+	Size      int64
+}
+
+// PlanResult represents the tags and blobs an operation would change, without changing them
+type PlanResult struct {
+	action PlanAction
+	tags   []string
+	blobs  []*PlanBlob
+	notes  []string
+}
+
+func NewPlanResult(action PlanAction, tags []string, blobs []*PlanBlob) *PlanResult {
+	return &PlanResult{action: action, tags: tags, blobs: blobs}
+}
+
+// AddNote records an additional step the operation would perform, e.g. signing.
+func (r *PlanResult) AddNote(note string) {
+	r.notes = append(r.notes, note)
+}
+
+func (r *PlanResult) Tags() []string {
+	return r.tags
+}
+
+func (r *PlanResult) Blobs() []*PlanBlob {
+	return r.blobs
+}
+
+// Size returns the total size of the planned blobs in bytes.
+func (r *PlanResult) Size() int64 {
+	var size int64
+	for _, b := range r.blobs {
+		size += b.Size
+	}
+	return size
+}
+
+func (r *PlanResult) Print() error {
+	var tagVerb, blobVerb string
+	switch r.action {
+	case PlanCreate:
+		tagVerb, blobVerb = "create tag", "add"
+	case PlanUpload:
+		tagVerb, blobVerb = "push tag", "upload"
+	case PlanRemove:
+		tagVerb, blobVerb = "delete tag", "remove"
+	}
+
+	fmt.Println("Dry run, nothing was changed")
+	for _, t := range r.tags {
+		fmt.Printf("Would %s %s\n", tagVerb, t)
+	}
+	if len(r.blobs) == 0 {
+		fmt.Printf("Would %s no blobs\n", blobVerb)
+	} else {
+		fmt.Printf("Would %s %d blob(s), %s in total:\n", blobVerb, len(r.blobs), FormatSize(r.Size()))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		for _, b := range r.blobs {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", b.Digest, b.MediaType, FormatSize(b.Size))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	for _, n := range r.notes {
+		fmt.Printf("Would %s\n", n)
+	}
+	return nil
+}
+
+// FormatSize formats byte size to human-readable format
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%dB", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
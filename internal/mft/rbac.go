@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/rbac"
+)
+
+// RBACResult represents the permissions granted by the RBAC resources of an artifact
+type RBACResult struct {
+	tag    string
+	grants []*rbac.Grant
+}
+
+type rbacReport struct {
+	Tag      string        `json:"tag" yaml:"tag"`
+	Findings int           `json:"findings" yaml:"findings"`
+	Grants   []*rbac.Grant `json:"grants" yaml:"grants"`
+}
+
+func NewRBACResult(tag string, grants []*rbac.Grant) *RBACResult {
+	return &RBACResult{tag: tag, grants: grants}
+}
+
+func (r *RBACResult) Grants() []*rbac.Grant {
+	return r.grants
+}
+
+// Flagged returns the grants with findings
+func (r *RBACResult) Flagged() []*rbac.Grant {
+	var flagged []*rbac.Grant
+	for _, g := range r.grants {
+		if len(g.Findings) > 0 {
+			flagged = append(flagged, g)
+		}
+	}
+	return flagged
+}
+
+func (r *RBACResult) Print(output ListOutput) error {
+	report := rbacReport{Tag: r.tag, Findings: len(r.Flagged()), Grants: r.grants}
+	if report.Grants == nil {
+		report.Grants = []*rbac.Grant{}
+	}
+
+	switch output {
+	case ListTable:
+		return r.printTable()
+	case ListJson:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case ListYaml:
+		encoder := yaml.NewEncoder(os.Stdout)
+		defer encoder.Close()
+		return encoder.Encode(report)
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+}
+
+func (r *RBACResult) printTable() error {
+	if len(r.grants) == 0 {
+		fmt.Printf("No RBAC permissions granted by %s\n", r.tag)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SUBJECT\tSCOPE\tROLE\tVERBS\tRESOURCES\tFINDINGS")
+	for _, g := range r.grants {
+		resources := g.Resources
+		if len(g.ResourceNames) > 0 {
+			resources = make([]string, len(g.Resources))
+			for i, res := range g.Resources {
+				resources[i] = fmt.Sprintf("%s[%s]", res, strings.Join(g.ResourceNames, ","))
+			}
+		}
+		resources = append(resources[:len(resources):len(resources)], g.NonResourceURLs...)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", g.Subject, g.Scope, g.Role,
+			strings.Join(g.Verbs, ","), strings.Join(resources, ","), strings.Join(g.Findings, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if flagged := len(r.Flagged()); flagged > 0 {
+		fmt.Printf("\n%d grant(s) flagged for review\n", flagged)
+	}
+	return nil
+}
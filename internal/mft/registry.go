@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/goccy/go-yaml"
+)
+
+// RegistryCheckStatus is the outcome of a single registry capability check
+type RegistryCheckStatus string
+
+const (
+	RegistryCheckSupported   RegistryCheckStatus = "supported"
+	RegistryCheckUnsupported RegistryCheckStatus = "unsupported"
+	RegistryCheckSkipped     RegistryCheckStatus = "skipped"
+	RegistryCheckUnknown     RegistryCheckStatus = "unknown"
+)
+
+// RegistryCheck represents a single capability check and its outcome
+type RegistryCheck struct {
+	Name    string              `json:"name" yaml:"name"`
+	Status  RegistryCheckStatus `json:"status" yaml:"status"`
+	Message string              `json:"message,omitempty" yaml:"message,omitempty"`
+	// Required checks make the registry incompatible with kubectl-mft when unsupported
+	Required bool `json:"required" yaml:"required"`
+}
+
+// RegistryInfoResult represents the compatibility report of a registry
+type RegistryInfoResult struct {
+	target string
+	checks []*RegistryCheck
+}
+
+type registryReport struct {
+	Target     string           `json:"target" yaml:"target"`
+	Compatible bool             `json:"compatible" yaml:"compatible"`
+	Checks     []*RegistryCheck `json:"checks" yaml:"checks"`
+}
+
+func NewRegistryInfoResult(target string) *RegistryInfoResult {
+	return &RegistryInfoResult{target: target}
+}
+
+// Add records the outcome of a check.
+func (r *RegistryInfoResult) Add(name string, status RegistryCheckStatus, required bool, message string) {
+	r.checks = append(r.checks, &RegistryCheck{Name: name, Status: status, Message: message, Required: required})
+}
+
+func (r *RegistryInfoResult) Checks() []*RegistryCheck {
+	return r.checks
+}
+
+// Compatible reports whether no required check found the capability unsupported.
+func (r *RegistryInfoResult) Compatible() bool {
+	for _, c := range r.checks {
+		if c.Required && c.Status == RegistryCheckUnsupported {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *RegistryInfoResult) Print(output ListOutput) error {
+	report := registryReport{Target: r.target, Compatible: r.Compatible(), Checks: r.checks}
+	if report.Checks == nil {
+		report.Checks = []*RegistryCheck{}
+	}
+
+	switch output {
+	case ListTable:
+		return r.printTable()
+	case ListJson:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case ListYaml:
+		encoder := yaml.NewEncoder(os.Stdout)
+		defer encoder.Close()
+		return encoder.Encode(report)
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+}
+
+func (r *RegistryInfoResult) printTable() error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tMESSAGE")
+	for _, c := range r.checks {
+		message, _, _ := strings.Cut(c.Message, "\n")
+		fmt.Fprintf(w, "%s\t%s\t%s\n", c.Name, c.Status, message)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if r.Compatible() {
+		fmt.Printf("\n%s is compatible with kubectl-mft\n", r.target)
+	} else {
+		fmt.Printf("\n%s is not compatible with kubectl-mft\n", r.target)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ReleaseStepStatus is the outcome of a single step of a release
+type ReleaseStepStatus string
+
+const (
+	ReleaseStepDone    ReleaseStepStatus = "done"
+	ReleaseStepSkipped ReleaseStepStatus = "skipped"
+	ReleaseStepFailed  ReleaseStepStatus = "failed"
+)
+
+// ReleaseStep represents a single step of a release and its outcome
+type ReleaseStep struct {
+	Name    string            `json:"name" yaml:"name"`
+	Status  ReleaseStepStatus `json:"status" yaml:"status"`
+	Message string            `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// ReleaseResult represents the outcome of every step of a release
+type ReleaseResult struct {
+	tag   string
+	steps []*ReleaseStep
+}
+
+type releaseReport struct {
+	Tag       string         `json:"tag" yaml:"tag"`
+	Succeeded bool           `json:"succeeded" yaml:"succeeded"`
+	Steps     []*ReleaseStep `json:"steps" yaml:"steps"`
+}
+
+func NewReleaseResult(tag string) *ReleaseResult {
+	return &ReleaseResult{tag: tag}
+}
+
+// Add records the outcome of a step.
+func (r *ReleaseResult) Add(name string, status ReleaseStepStatus, message string) {
+	r.steps = append(r.steps, &ReleaseStep{Name: name, Status: status, Message: message})
+}
+
+func (r *ReleaseResult) Steps() []*ReleaseStep {
+	return r.steps
+}
+
+// Succeeded reports whether no step failed.
+func (r *ReleaseResult) Succeeded() bool {
+	for _, s := range r.steps {
+		if s.Status == ReleaseStepFailed {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *ReleaseResult) Print(output ListOutput) error {
+	report := releaseReport{Tag: r.tag, Succeeded: r.Succeeded(), Steps: r.steps}
+	if report.Steps == nil {
+		report.Steps = []*ReleaseStep{}
+	}
+
+	switch output {
+	case ListTable:
+		return r.printTable()
+	case ListJson:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case ListYaml:
+		encoder := yaml.NewEncoder(os.Stdout)
+		defer encoder.Close()
+		return encoder.Encode(report)
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+}
+
+func (r *ReleaseResult) printTable() error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "STEP\tSTATUS\tMESSAGE")
+	for _, s := range r.steps {
+		// Only the first line of multi-line errors fits the table
+		message, _, _ := strings.Cut(s.Message, "\n")
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Name, s.Status, message)
+	}
+	return w.Flush()
+}
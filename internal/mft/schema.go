@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/validate"
+)
+
+// SchemaListResult represents the registered CRD schemas
+type SchemaListResult struct {
+	schemas []validate.SchemaInfo
+}
+
+func NewSchemaListResult(schemas []validate.SchemaInfo) *SchemaListResult {
+	return &SchemaListResult{schemas: schemas}
+}
+
+func (r *SchemaListResult) Print(output ListOutput) error {
+	schemas := r.schemas
+	if schemas == nil {
+		schemas = []validate.SchemaInfo{}
+	}
+
+	switch output {
+	case ListTable:
+		return r.printTable()
+	case ListJson:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(schemas)
+	case ListYaml:
+		encoder := yaml.NewEncoder(os.Stdout)
+		defer encoder.Close()
+		return encoder.Encode(schemas)
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+}
+
+func (r *SchemaListResult) printTable() error {
+	if len(r.schemas) == 0 {
+		fmt.Println("No CRD schemas registered")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "GROUP\tKIND\tVERSION")
+	for _, s := range r.schemas {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Group, s.Kind, s.Version)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ResourceStatus represents the state of a single resource of an artifact in the cluster
+type ResourceStatus struct {
+	Kind      string   `json:"kind" yaml:"kind"`
+	Namespace string   `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name      string   `json:"name" yaml:"name"`
+	Exists    bool     `json:"exists" yaml:"exists"`
+	InSync    bool     `json:"inSync" yaml:"inSync"`
+	Drift     []string `json:"drift,omitempty" yaml:"drift,omitempty"`
+	Health    string   `json:"health" yaml:"health"`
+	Message   string   `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// StatusResult represents the state of every resource of an artifact in the cluster
+type StatusResult struct {
+	tag       string
+	resources []*ResourceStatus
+}
+
+func NewStatusResult(tag string, resources []*ResourceStatus) *StatusResult {
+	return &StatusResult{tag: tag, resources: resources}
+}
+
+func (r *StatusResult) Resources() []*ResourceStatus {
+	return r.resources
+}
+
+func (r *StatusResult) Print(output ListOutput) error {
+	switch output {
+	case ListTable:
+		return r.printTable()
+	case ListJson:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r.resources)
+	case ListYaml:
+		encoder := yaml.NewEncoder(os.Stdout)
+		defer encoder.Close()
+		return encoder.Encode(r.resources)
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+}
+
+func (r *StatusResult) printTable() error {
+	if len(r.resources) == 0 {
+		fmt.Printf("No resources found in %s\n", r.tag)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAMESPACE\tNAME\tSYNC\tHEALTH\tMESSAGE")
+	for _, s := range r.resources {
+		sync := "Synced"
+		switch {
+		case !s.Exists:
+			sync = "Missing"
+		case !s.InSync:
+			sync = "OutOfSync"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", s.Kind, s.Namespace, s.Name, sync, s.Health, s.Message)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+// SummaryResult represents the summary of the resources of an artifact
+type SummaryResult struct {
+	tag     string
+	summary *manifest.Summary
+}
+
+type summaryReport struct {
+	Tag               string `json:"tag" yaml:"tag"`
+	*manifest.Summary `json:",inline" yaml:",inline"`
+}
+
+func NewSummaryResult(tag string, summary *manifest.Summary) *SummaryResult {
+	return &SummaryResult{tag: tag, summary: summary}
+}
+
+func (r *SummaryResult) Summary() *manifest.Summary {
+	return r.summary
+}
+
+func (r *SummaryResult) Print(output ListOutput) error {
+	report := summaryReport{Tag: r.tag, Summary: r.summary}
+	switch output {
+	case ListTable:
+		return r.printText()
+	case ListJson:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case ListYaml:
+		encoder := yaml.NewEncoder(os.Stdout)
+		defer encoder.Close()
+		return encoder.Encode(report)
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+}
+
+func (r *SummaryResult) printText() error {
+	s := r.summary
+	fmt.Printf("Summary of %s\n\n", r.tag)
+
+	fmt.Printf("Resources:  %d\n", s.Resources)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	for _, k := range s.Kinds {
+		fmt.Fprintf(w, "  %s\t%d\n", k.Kind, k.Count)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	namespaces := s.Namespaces
+	if s.CurrentNamespace > 0 {
+		namespaces = append(namespaces[:len(namespaces):len(namespaces)],
+			fmt.Sprintf("%d resource(s) in the current namespace", s.CurrentNamespace))
+	}
+	printList("Namespaces", namespaces)
+	printList("Images", s.Images)
+	printList("CRDs", s.CRDs)
+
+	var rbac []string
+	for _, role := range s.Roles {
+		rbac = append(rbac, fmt.Sprintf("%s %s: %d rule(s)", role.Kind, qualifiedName(role.Namespace, role.Name), role.Rules))
+	}
+	for _, b := range s.Bindings {
+		rbac = append(rbac, fmt.Sprintf("%s %s: %s -> %s", b.Kind, qualifiedName(b.Namespace, b.Name), b.Role, orNone(strings.Join(b.Subjects, ", "))))
+	}
+	printList("RBAC", rbac)
+
+	if len(s.Workloads) == 0 {
+		fmt.Println("Workloads:  none")
+		return nil
+	}
+	fmt.Println("Workloads:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "  KIND\tNAME\tREPLICAS\tCPU REQUEST\tMEMORY REQUEST\tCPU LIMIT\tMEMORY LIMIT")
+	for _, wl := range s.Workloads {
+		replicas := fmt.Sprint(wl.Replicas)
+		if wl.PerNode {
+			replicas = "1 per node"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\t%s\n", wl.Kind, qualifiedName(wl.Namespace, wl.Name), replicas,
+			orNone(wl.Requests.CPU), orNone(wl.Requests.Memory), orNone(wl.Limits.CPU), orNone(wl.Limits.Memory))
+	}
+	fmt.Fprintf(w, "  Total\t\t%d\t%s\t%s\t%s\t%s\n", s.Replicas,
+		orNone(s.Requests.CPU), orNone(s.Requests.Memory), orNone(s.Limits.CPU), orNone(s.Limits.Memory))
+	return w.Flush()
+}
+
+// printList prints a heading followed by one indented line per item, or "none".
+func printList(heading string, items []string) {
+	if len(items) == 0 {
+		fmt.Printf("%-12s%s\n", heading+":", "none")
+		return
+	}
+	fmt.Println(heading + ":")
+	for _, item := range items {
+		fmt.Printf("  %s\n", item)
+	}
+}
+
+func qualifiedName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
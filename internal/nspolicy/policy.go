@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+// Package nspolicy checks the namespaces touched by the resources of manifests against namespace policies.
+package nspolicy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+// Violation is a namespace, or a cluster-scoped resource, refused by a policy.
+type Violation struct {
+	// Namespace is the refused namespace, empty for a refused cluster-scoped resource
+	Namespace string
+	// Resources are the resources touching the namespace, such as "Deployment team-c/app", or
+	// the refused cluster-scoped resource
+	Resources []string
+	Reason    string
+}
+
+func (v *Violation) String() string {
+	if v.Namespace == "" {
+		return fmt.Sprintf("%s: %s", strings.Join(v.Resources, ", "), v.Reason)
+	}
+	return fmt.Sprintf("namespace %s (%s): %s", v.Namespace, strings.Join(v.Resources, ", "), v.Reason)
+}
+
+// Scopes tells the scope of the kinds a cluster serves, such as a cluster.Discovery.
+type Scopes interface {
+	// Namespaced reports whether resources of the kind are namespaced, and whether the kind
+	// is known at all
+	Namespaced(apiVersion, kind string) (namespaced, known bool)
+}
+
+// Namespace returns the namespace the resource of doc touches: its own namespace, or
+// defaultNamespace if it has none, or for a Namespace the namespace it creates. Resources of
+// built-in cluster-scoped kinds touch no namespace.
+func Namespace(doc *manifest.Document, defaultNamespace string) (string, bool) {
+	return namespaceOf(doc, defaultNamespace, manifest.ClusterScopedKind(doc.Kind))
+}
+
+func namespaceOf(doc *manifest.Document, defaultNamespace string, clusterScoped bool) (string, bool) {
+	switch {
+	case doc.Kind == "Namespace" && doc.APIVersion == "v1":
+		return doc.Name, true
+	case clusterScoped:
+		return "", false
+	case doc.Namespace != "":
+		return doc.Namespace, true
+	default:
+		return defaultNamespace, true
+	}
+}
+
+// UsesDefaultNamespace reports whether any resource of docs is created in the default namespace.
+func UsesDefaultNamespace(docs []*manifest.Document) bool {
+	return slices.ContainsFunc(docs, func(d *manifest.Document) bool {
+		ns, ok := Namespace(d, "")
+		return ok && ns == ""
+	})
+}
+
+// Check returns the namespaces touched by docs that any of the policies refuses, in order of
+// their name, followed by the cluster-scoped resources refused, in order. Namespaced resources
+// without a namespace are created in defaultNamespace. The scope of a kind is taken from
+// scopes if it knows the kind, such as custom resources of CRDs installed in the cluster.
+// Otherwise, or if scopes is nil, kinds are cluster-scoped if they are built-in
+// cluster-scoped kinds or defined as cluster-scoped by a CRD among docs.
+func Check(policies []config.NamespacePolicy, docs []*manifest.Document, defaultNamespace string, scopes Scopes) ([]*Violation, error) {
+	custom, err := manifest.ClusterScopedCustomKinds(docs)
+	if err != nil {
+		return nil, err
+	}
+	resources := make(map[string][]string)
+	var namespaces []string
+	var clusterScoped []*manifest.Document
+	for _, d := range docs {
+		scoped := manifest.ClusterScopedKind(d.Kind) || custom[d.Kind]
+		if scopes != nil {
+			if namespaced, ok := scopes.Namespaced(d.APIVersion, d.Kind); ok {
+				scoped = !namespaced
+			}
+		}
+		if scoped {
+			clusterScoped = append(clusterScoped, d)
+		}
+		ns, ok := namespaceOf(d, defaultNamespace, scoped)
+		if !ok {
+			continue
+		}
+		if _, ok := resources[ns]; !ok {
+			namespaces = append(namespaces, ns)
+		}
+		resources[ns] = append(resources[ns], d.String())
+	}
+	slices.Sort(namespaces)
+
+	var violations []*Violation
+	for _, ns := range namespaces {
+		for _, p := range policies {
+			if reason := checkNamespace(p, ns); reason != "" {
+				violations = append(violations, &Violation{Namespace: ns, Resources: resources[ns], Reason: reason})
+				break
+			}
+		}
+	}
+	for _, d := range clusterScoped {
+		if slices.ContainsFunc(policies, func(p config.NamespacePolicy) bool {
+			return p.DenyClusterScoped && !slices.Contains(p.AllowClusterScoped, d.Kind)
+		}) {
+			violations = append(violations, &Violation{Resources: []string{d.String()}, Reason: "cluster-scoped resources are denied"})
+		}
+	}
+	return violations, nil
+}
+
+// checkNamespace returns why the policy refuses the namespace, or an empty string if it does not.
+func checkNamespace(p config.NamespacePolicy, ns string) string {
+	for _, pattern := range p.Deny {
+		if config.MatchRepository(pattern, ns) {
+			return fmt.Sprintf("denied by %q", pattern)
+		}
+	}
+	if len(p.Allow) > 0 && !slices.ContainsFunc(p.Allow, func(pattern string) bool {
+		return config.MatchRepository(pattern, ns)
+	}) {
+		return fmt.Sprintf("not in the allowed namespaces %s", strings.Join(p.Allow, ", "))
+	}
+	return ""
+}
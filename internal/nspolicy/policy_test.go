@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package nspolicy
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+const testManifest = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: team-a
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: team-a
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: shared
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: reader
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: token
+  namespace: kube-system
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  scope: Cluster
+  names:
+    kind: Widget
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: global
+`
+
+func TestCheck(t *testing.T) {
+	docs, err := manifest.Split([]byte(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !UsesDefaultNamespace(docs) {
+		t.Errorf("UsesDefaultNamespace() = false, want true")
+	}
+
+	tests := []struct {
+		name     string
+		policies []config.NamespacePolicy
+		want     []string
+	}{
+		{
+			name: "no policy",
+		},
+		{
+			name:     "allow",
+			policies: []config.NamespacePolicy{{Allow: []string{"team-*"}}},
+			want:     []string{"default", "kube-system"},
+		},
+		{
+			name:     "deny",
+			policies: []config.NamespacePolicy{{Deny: []string{"kube-*"}}},
+			want:     []string{"kube-system"},
+		},
+		{
+			name: "every policy applies",
+			policies: []config.NamespacePolicy{
+				{Allow: []string{"team-a", "default", "kube-system"}},
+				{Allow: []string{"team-a", "default"}},
+			},
+			want: []string{"kube-system"},
+		},
+		{
+			name:     "deny cluster-scoped",
+			policies: []config.NamespacePolicy{{DenyClusterScoped: true}},
+			want:     []string{"Namespace team-a", "ClusterRole reader", "CustomResourceDefinition widgets.example.com", "Widget global"},
+		},
+		{
+			name:     "allow cluster-scoped kinds",
+			policies: []config.NamespacePolicy{{DenyClusterScoped: true, AllowClusterScoped: []string{"Namespace", "CustomResourceDefinition", "Widget"}}},
+			want:     []string{"ClusterRole reader"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := Check(tt.policies, docs, "default", nil)
+			if err != nil {
+				t.Fatalf("Check() unexpected error: %v", err)
+			}
+			var got []string
+			for _, v := range violations {
+				if v.Namespace == "" {
+					got = append(got, v.Resources...)
+					continue
+				}
+				got = append(got, v.Namespace)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testScopes serves the kinds mapped to whether they are namespaced
+type testScopes map[string]bool
+
+func (s testScopes) Namespaced(apiVersion, kind string) (bool, bool) {
+	namespaced, ok := s[apiVersion+"/"+kind]
+	return namespaced, ok
+}
+
+func TestCheckDiscoveredScopes(t *testing.T) {
+	docs, err := manifest.Split([]byte(`apiVersion: cert-manager.io/v1
+kind: ClusterIssuer
+metadata:
+  name: letsencrypt
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: local
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	policies := []config.NamespacePolicy{{DenyClusterScoped: true, Deny: []string{"default"}}}
+
+	// Without discovery, custom resources are assumed to be namespaced
+	v, err := Check(policies, docs, "default", nil)
+	if err != nil {
+		t.Fatalf("Check() unexpected error: %v", err)
+	}
+	if len(v) != 1 || v[0].Namespace != "default" {
+		t.Errorf("Check() without scopes = %v, want the default namespace refused", v)
+	}
+
+	// The cluster knows ClusterIssuer from its installed CRD
+	scopes := testScopes{"cert-manager.io/v1/ClusterIssuer": false, "example.com/v1/Widget": true}
+	v, err = Check(policies, docs, "default", scopes)
+	if err != nil {
+		t.Fatalf("Check() unexpected error: %v", err)
+	}
+	var got []string
+	for _, violation := range v {
+		got = append(got, violation.String())
+	}
+	want := []string{
+		"namespace default (Widget local): denied by \"default\"",
+		"ClusterIssuer letsencrypt: cluster-scoped resources are denied",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Check() with scopes = %q, want %q", got, want)
+	}
+}
+
+func TestViolationString(t *testing.T) {
+	docs, err := manifest.Split([]byte(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := Check([]config.NamespacePolicy{{Allow: []string{"kube-system", "default"}}}, docs, "default", nil)
+	if err != nil {
+		t.Fatalf("Check() unexpected error: %v", err)
+	}
+	if len(v) != 1 {
+		t.Fatalf("Check() returned %d violations, want 1", len(v))
+	}
+	want := "namespace team-a (Namespace team-a, Deployment team-a/app): not in the allowed namespaces kube-system, default"
+	if got := v[0].String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
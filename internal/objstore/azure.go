@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package objstore
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// azureVersion is the version of the Blob Storage REST API used
+const azureVersion = "2021-08-06"
+
+// newAzureBucket returns the container of the Azure storage account AZURE_STORAGE_ACCOUNT,
+// authorized by the shared access signature of AZURE_STORAGE_SAS_TOKEN, or by the access token
+// of 'az account get-access-token'. AZURE_STORAGE_ENDPOINT overrides the blob endpoint of the
+// account, e.g. for Azurite.
+func newAzureBucket(ctx context.Context, container string) (*httpBucket, error) {
+	account := os.Getenv("AZURE_STORAGE_ACCOUNT")
+	if account == "" {
+		return nil, fmt.Errorf("AZURE_STORAGE_ACCOUNT must be set to access azblob://%s", container)
+	}
+	endpoint := cmp.Or(os.Getenv("AZURE_STORAGE_ENDPOINT"), fmt.Sprintf("https://%s.blob.core.windows.net", account))
+	base := strings.TrimSuffix(endpoint, "/") + "/" + container
+
+	sas := strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?")
+	var token string
+	if sas == "" {
+		var err error
+		token, err = commandToken(ctx, "az", "account", "get-access-token", "--resource", "https://storage.azure.com/", "--query", "accessToken", "--output", "tsv")
+		if err != nil {
+			return nil, fmt.Errorf("set AZURE_STORAGE_SAS_TOKEN or log in with az to access azblob://%s: %w", container, err)
+		}
+	}
+	client, err := newHTTPClient(base)
+	if err != nil {
+		return nil, err
+	}
+	return &httpBucket{
+		client: client,
+		url: func(key string) string {
+			u := base + "/" + escapeKey(key)
+			if sas != "" {
+				u += "?" + sas
+			}
+			return u
+		},
+		authorize: func(req *http.Request, _ []byte) error {
+			req.Header.Set("X-Ms-Version", azureVersion)
+			req.Header.Set("X-Ms-Date", time.Now().UTC().Format(http.TimeFormat))
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			return nil
+		},
+		putHeader: http.Header{"X-Ms-Blob-Type": []string{"BlockBlob"}},
+	}, nil
+}
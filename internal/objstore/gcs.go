@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// newGCSBucket returns the Google Cloud Storage bucket, accessed through its XML API with the
+// OAuth access token of GOOGLE_OAUTH_ACCESS_TOKEN, or of 'gcloud auth print-access-token'.
+func newGCSBucket(ctx context.Context, name string) (*httpBucket, error) {
+	token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	if token == "" {
+		var err error
+		if token, err = commandToken(ctx, "gcloud", "auth", "print-access-token"); err != nil {
+			return nil, fmt.Errorf("set GOOGLE_OAUTH_ACCESS_TOKEN or log in with gcloud to access gs://%s: %w", name, err)
+		}
+	}
+	base := "https://storage.googleapis.com/" + name
+	client, err := newHTTPClient(base)
+	if err != nil {
+		return nil, err
+	}
+	return &httpBucket{
+		client: client,
+		url: func(key string) string {
+			return base + "/" + escapeKey(key)
+		},
+		authorize: func(req *http.Request, _ []byte) error {
+			req.Header.Set("Authorization", "Bearer "+token)
+			return nil
+		},
+	}, nil
+}
+
+// commandToken returns the access token printed by a cloud CLI.
+func commandToken(ctx context.Context, name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", fmt.Errorf("%s printed no access token", name)
+	}
+	return token, nil
+}
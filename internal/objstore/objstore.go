@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+// Package objstore stores manifests in object storage, such as Amazon S3, Google Cloud
+// Storage, or Azure Blob Storage, laid out as an OCI image layout under a prefix of a bucket.
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+// Schemes of object storage references
+const (
+	SchemeS3    = "s3"
+	SchemeGCS   = "gs"
+	SchemeAzure = "azblob"
+)
+
+var schemes = []string{SchemeS3, SchemeGCS, SchemeAzure}
+
+// errNotFound is returned by buckets for missing objects
+var errNotFound = errors.New("object not found")
+
+// Reference is a tag in an OCI image layout stored in object storage, such as
+// "s3://bucket/manifests:v1.0.0".
+type Reference struct {
+	Scheme string
+	Bucket string
+	// Prefix is the key prefix of the layout in the bucket, empty for the root of the bucket
+	Prefix string
+	// Tag is the tag in the layout, empty if the reference has none
+	Tag string
+}
+
+// IsReference reports whether ref refers to object storage.
+func IsReference(ref string) bool {
+	scheme, _, ok := strings.Cut(ref, "://")
+	return ok && slices.Contains(schemes, scheme)
+}
+
+// ParseReference parses a reference of the form "<scheme>://<bucket>[/<prefix>][:<tag>]",
+// where scheme is s3, gs, or azblob. For azblob, the bucket is the container of the storage
+// account.
+func ParseReference(ref string) (Reference, error) {
+	scheme, rest, ok := strings.Cut(ref, "://")
+	if !ok || !slices.Contains(schemes, scheme) {
+		return Reference{}, fmt.Errorf("%q is not an object storage reference, expected s3://, gs://, or azblob://<bucket>[/<prefix>][:<tag>]", ref)
+	}
+	res := Reference{Scheme: scheme}
+	if i := strings.LastIndex(rest, ":"); i > strings.LastIndex(rest, "/") {
+		rest, res.Tag = rest[:i], rest[i+1:]
+		if res.Tag == "" {
+			return Reference{}, fmt.Errorf("invalid object storage reference %q: empty tag", ref)
+		}
+	}
+	res.Bucket, res.Prefix, _ = strings.Cut(rest, "/")
+	res.Prefix = strings.Trim(res.Prefix, "/")
+	if res.Bucket == "" {
+		return Reference{}, fmt.Errorf("invalid object storage reference %q: empty bucket", ref)
+	}
+	return res, nil
+}
+
+func (r Reference) String() string {
+	s := r.Scheme + "://" + path.Join(r.Bucket, r.Prefix)
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	return s
+}
+
+// bucket reads and writes the objects of a bucket.
+type bucket interface {
+	// get returns the content of the object, or errNotFound
+	get(ctx context.Context, key string) (io.ReadCloser, error)
+	// exists reports whether the object exists
+	exists(ctx context.Context, key string) (bool, error)
+	put(ctx context.Context, key string, data []byte) error
+}
+
+// Open opens the OCI image layout of ref for reading and writing. Credentials are taken from
+// the environment of the respective cloud, see newS3Bucket, newGCSBucket, and newAzureBucket.
+func Open(ctx context.Context, ref Reference) (*Target, error) {
+	var b bucket
+	var err error
+	switch ref.Scheme {
+	case SchemeS3:
+		b, err = newS3Bucket(ctx, ref.Bucket)
+	case SchemeGCS:
+		b, err = newGCSBucket(ctx, ref.Bucket)
+	case SchemeAzure:
+		b, err = newAzureBucket(ctx, ref.Bucket)
+	default:
+		err = fmt.Errorf("unsupported object storage scheme %q", ref.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newTarget(ctx, b, ref.Prefix)
+}
+
+// newHTTPClient returns the client for requests to the service at base, with the HTTP settings
+// the configuration has for its host, such as a proxy or a CA file.
+func newHTTPClient(base string) (*http.Client, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %s: %w", base, err)
+	}
+	return oci.NewHTTPClient(u.Host)
+}
+
+// httpBucket is a bucket served over HTTP, where objects are addressed by URL and requests
+// are authorized by the cloud-specific authorize function.
+type httpBucket struct {
+	client *http.Client
+	// url returns the URL of the object
+	url func(key string) string
+	// authorize authenticates the request, whose body has the given content
+	authorize func(req *http.Request, body []byte) error
+	// putHeader is added to upload requests
+	putHeader http.Header
+}
+
+func (b *httpBucket) get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := b.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (b *httpBucket) exists(ctx context.Context, key string) (bool, error) {
+	resp, err := b.do(ctx, http.MethodHead, key, nil)
+	if errors.Is(err, errNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+func (b *httpBucket) put(ctx context.Context, key string, data []byte) error {
+	resp, err := b.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do sends an authorized request for the object and returns the response if it succeeded.
+func (b *httpBucket) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, b.url(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	if method == http.MethodPut {
+		for k, v := range b.putHeader {
+			req.Header[k] = v
+		}
+	}
+	if err := b.authorize(req, body); err != nil {
+		return nil, err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %w", method, key, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %w", key, errNotFound)
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("%s %s failed: %s: %s", method, key, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
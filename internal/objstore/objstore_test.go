@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/image-spec/specs-go"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    Reference
+		wantErr bool
+	}{
+		{ref: "s3://bucket/team/manifests:v1.0.0", want: Reference{Scheme: "s3", Bucket: "bucket", Prefix: "team/manifests", Tag: "v1.0.0"}},
+		{ref: "gs://bucket", want: Reference{Scheme: "gs", Bucket: "bucket"}},
+		{ref: "azblob://container/manifests/", want: Reference{Scheme: "azblob", Bucket: "container", Prefix: "manifests"}},
+		{ref: "s3://bucket:dev", want: Reference{Scheme: "s3", Bucket: "bucket", Tag: "dev"}},
+		{ref: "s3://bucket/manifests:", wantErr: true},
+		{ref: "s3://", wantErr: true},
+		{ref: "ftp://bucket/manifests:v1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := ParseReference(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseReference() = %+v, want %+v", got, tt.want)
+			}
+			if !tt.wantErr && !IsReference(tt.ref) {
+				t.Errorf("IsReference() = false, want true")
+			}
+		})
+	}
+}
+
+// memBucket is a bucket in memory
+type memBucket struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (b *memBucket) get(_ context.Context, key string) (io.ReadCloser, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data, ok := b.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", key, errNotFound)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (b *memBucket) exists(_ context.Context, key string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.objects[key]
+	return ok, nil
+}
+
+func (b *memBucket) put(_ context.Context, key string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.objects[key] = data
+	return nil
+}
+
+func TestTarget(t *testing.T) {
+	ctx := context.Background()
+	src := memory.New()
+	push := func(mediaType string, v any) v1.Descriptor {
+		t.Helper()
+		data, ok := v.([]byte)
+		if !ok {
+			var err error
+			if data, err = json.Marshal(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		desc := content.NewDescriptorFromBytes(mediaType, data)
+		if err := src.Push(ctx, desc, bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+		return desc
+	}
+	config := push(v1.MediaTypeEmptyJSON, []byte("{}"))
+	layer := push("application/vnd.kubectl-mft.content.v1+yaml", []byte("kind: ConfigMap\n"))
+	manifest := push(v1.MediaTypeImageManifest, v1.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2}, MediaType: v1.MediaTypeImageManifest, Config: config, Layers: []v1.Descriptor{layer},
+	})
+	sig := push(v1.MediaTypeImageManifest, v1.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2}, MediaType: v1.MediaTypeImageManifest, Config: config, Layers: []v1.Descriptor{layer}, Subject: &manifest,
+	})
+	if err := src.Tag(ctx, manifest, "v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &memBucket{objects: make(map[string][]byte)}
+	dst, err := newTarget(ctx, b, "team/manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oras.ExtendedCopy(ctx, src, "v1", dst, "v1", oras.ExtendedCopyOptions{}); err != nil {
+		t.Fatalf("ExtendedCopy() to target failed: %v", err)
+	}
+	for _, key := range []string{"team/manifests/oci-layout", "team/manifests/index.json", "team/manifests/blobs/sha256/" + sig.Digest.Encoded()} {
+		if _, ok := b.objects[key]; !ok {
+			t.Errorf("object %s was not written", key)
+		}
+	}
+
+	// A new target reads the tag and the referrers back from the bucket
+	reopened, err := newTarget(ctx, b, "team/manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := reopened.Resolve(ctx, "v1")
+	if err != nil {
+		t.Fatalf("Resolve() failed: %v", err)
+	}
+	if desc.Digest != manifest.Digest {
+		t.Errorf("Resolve() = %s, want %s", desc.Digest, manifest.Digest)
+	}
+	back := memory.New()
+	if _, err := oras.ExtendedCopy(ctx, reopened, "v1", back, "v1", oras.ExtendedCopyOptions{}); err != nil {
+		t.Fatalf("ExtendedCopy() from target failed: %v", err)
+	}
+	if ok, err := back.Exists(ctx, sig); err != nil || !ok {
+		t.Errorf("referrer was not copied back: %v, %v", ok, err)
+	}
+
+	// Retagging keeps the previous manifest untagged
+	if err := reopened.Tag(ctx, sig, "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if desc, err := reopened.Resolve(ctx, "v1"); err != nil || desc.Digest != sig.Digest {
+		t.Errorf("Resolve() after Tag() = %s, %v, want %s", desc.Digest, err, sig.Digest)
+	}
+	if _, err := reopened.Resolve(ctx, manifest.Digest.String()); err != nil {
+		t.Errorf("Resolve() of the previous manifest failed: %v", err)
+	}
+}
+
+func TestSignV4(t *testing.T) {
+	// The get-vanilla case of the AWS Signature Version 4 test suite
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := s3Credentials{accessKeyID: "AKIDEXAMPLE", secretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	emptyHash := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	signV4(req, emptyHash, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Errorf("X-Amz-Date = %q", got)
+	}
+}
+
+func TestEscapeKey(t *testing.T) {
+	key := "team/app=v1+rc:1@x$&y z~"
+	want := "team/app%3Dv1%2Brc%3A1%40x%24%26y%20z~"
+	if got := escapeKey(key); got != want {
+		t.Errorf("escapeKey(%q) = %q, want %q", key, got, want)
+	}
+
+	// The path sent is the one signed
+	req, err := http.NewRequest(http.MethodGet, "https://bucket.s3.amazonaws.com/"+want, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.URL.Path != "/"+key || req.URL.EscapedPath() != "/"+want {
+		t.Errorf("request path = %q (%q), want %q", req.URL.Path, req.URL.EscapedPath(), "/"+want)
+	}
+}
+
+func TestS3CredentialsFor(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "credentials")
+	data := "[default]\naws_access_key_id = AKIDDEFAULT\naws_secret_access_key = default-secret\n\n" +
+		"[ci]\naws_access_key_id=AKIDCI\naws_secret_access_key=ci-secret\naws_session_token=ci-token\n"
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", file)
+
+	tests := []struct {
+		profile string
+		want    s3Credentials
+	}{
+		{profile: "", want: s3Credentials{accessKeyID: "AKIDDEFAULT", secretAccessKey: "default-secret"}},
+		{profile: "ci", want: s3Credentials{accessKeyID: "AKIDCI", secretAccessKey: "ci-secret", sessionToken: "ci-token"}},
+	}
+	for _, tt := range tests {
+		t.Setenv("AWS_PROFILE", tt.profile)
+		got, err := s3CredentialsFor(context.Background())
+		if err != nil {
+			t.Fatalf("s3CredentialsFor() with profile %q failed: %v", tt.profile, err)
+		}
+		if got != tt.want {
+			t.Errorf("s3CredentialsFor() with profile %q = %+v, want %+v", tt.profile, got, tt.want)
+		}
+	}
+
+	// The environment wins over the file
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+	if got, err := s3CredentialsFor(context.Background()); err != nil || got.accessKeyID != "AKIDENV" {
+		t.Errorf("s3CredentialsFor() = %+v, %v, want the credentials of the environment", got, err)
+	}
+}
@@ -0,0 +1,242 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package objstore
+
+import (
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// s3Credentials are the AWS credentials used to sign requests
+type s3Credentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+}
+
+// newS3Bucket returns the S3 bucket in the region of AWS_REGION or AWS_DEFAULT_REGION, or of
+// the AWS configuration file, defaulting to us-east-1. Credentials are looked up like the AWS
+// CLI does, see s3CredentialsFor. AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL selects an
+// S3-compatible service, such as MinIO, addressed by path. Requests use the HTTP settings of
+// the configuration for the host of the bucket.
+func newS3Bucket(ctx context.Context, name string) (*httpBucket, error) {
+	creds, err := s3CredentialsFor(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("no AWS credentials found to access s3://%s, set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY or configure the AWS CLI: %w", name, err)
+	}
+	region := cmp.Or(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), awsConfigValue(awsConfigFile(), "region"), "us-east-1")
+
+	base := fmt.Sprintf("https://%s.s3.%s.amazonaws.com", name, region)
+	if endpoint := cmp.Or(os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL")); endpoint != "" {
+		base = strings.TrimSuffix(endpoint, "/") + "/" + name
+	}
+	client, err := newHTTPClient(base)
+	if err != nil {
+		return nil, err
+	}
+	return &httpBucket{
+		client: client,
+		url: func(key string) string {
+			return base + "/" + escapeKey(key)
+		},
+		authorize: func(req *http.Request, body []byte) error {
+			sum := sha256.Sum256(body)
+			payloadHash := hex.EncodeToString(sum[:])
+			req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+			if creds.sessionToken != "" {
+				req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+			}
+			signV4(req, payloadHash, creds, region, "s3", time.Now())
+			return nil
+		},
+	}, nil
+}
+
+// s3CredentialsFor returns the AWS credentials of, in order, the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, and optional AWS_SESSION_TOKEN environment variables, the profile of
+// AWS_PROFILE, or "default", in the shared credentials file, and 'aws configure
+// export-credentials', which covers SSO, assumed roles, and instance metadata.
+func s3CredentialsFor(ctx context.Context) (s3Credentials, error) {
+	creds := s3Credentials{
+		accessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.accessKeyID != "" && creds.secretAccessKey != "" {
+		return creds, nil
+	}
+
+	file := cmp.Or(os.Getenv("AWS_SHARED_CREDENTIALS_FILE"), awsFile("credentials"))
+	creds = s3Credentials{
+		accessKeyID:     awsConfigValue(file, "aws_access_key_id"),
+		secretAccessKey: awsConfigValue(file, "aws_secret_access_key"),
+		sessionToken:    awsConfigValue(file, "aws_session_token"),
+	}
+	if creds.accessKeyID != "" && creds.secretAccessKey != "" {
+		return creds, nil
+	}
+
+	args := []string{"configure", "export-credentials", "--format", "process"}
+	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
+		args = append(args, "--profile", profile)
+	}
+	out, err := commandToken(ctx, "aws", args...)
+	if err != nil {
+		return s3Credentials{}, err
+	}
+	var exported struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		SessionToken    string `json:"SessionToken"`
+	}
+	if err := json.Unmarshal([]byte(out), &exported); err != nil {
+		return s3Credentials{}, fmt.Errorf("failed to parse the credentials exported by aws: %w", err)
+	}
+	if exported.AccessKeyID == "" || exported.SecretAccessKey == "" {
+		return s3Credentials{}, fmt.Errorf("aws exported no credentials")
+	}
+	return s3Credentials{accessKeyID: exported.AccessKeyID, secretAccessKey: exported.SecretAccessKey, sessionToken: exported.SessionToken}, nil
+}
+
+// awsFile returns the path of a file in the AWS configuration directory, ~/.aws.
+func awsFile(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".aws", name)
+}
+
+// awsConfigFile returns the path of the AWS configuration file.
+func awsConfigFile() string {
+	return cmp.Or(os.Getenv("AWS_CONFIG_FILE"), awsFile("config"))
+}
+
+// awsConfigValue returns the value of key in the section of the profile of AWS_PROFILE, or
+// "default", of the AWS configuration or shared credentials file at path, or "" if it is not
+// set. Sections of the configuration file are named "profile <name>" except for the default.
+func awsConfigValue(path, key string) string {
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	profile := cmp.Or(os.Getenv("AWS_PROFILE"), "default")
+	inProfile := false
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimSpace(line)
+		if section, ok := strings.CutPrefix(line, "["); ok {
+			section = strings.TrimSpace(strings.TrimSuffix(section, "]"))
+			inProfile = section == profile || section == "profile "+profile
+			continue
+		}
+		if k, v, ok := strings.Cut(line, "="); ok && inProfile && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// signV4 signs the request with AWS Signature Version 4, covering the host and the x-amz-*
+// headers of the request.
+func signV4(req *http.Request, payloadHash string, creds s3Credentials, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		if k := strings.ToLower(k); strings.HasPrefix(k, "x-amz-") {
+			headers[k] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalPath := "/" + uriEncode(strings.TrimPrefix(req.URL.Path, "/"), false)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.accessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery returns the query sorted by key and value, as signed by signV4.
+func canonicalQuery(q url.Values) string {
+	var pairs []string
+	for k, values := range q {
+		for _, v := range values {
+			pairs = append(pairs, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// escapeKey escapes an object key for use in a URL path, the same way signV4 canonicalizes it.
+func escapeKey(key string) string {
+	return uriEncode(key, false)
+}
+
+// uriEncode percent-encodes every byte of s except the unreserved characters, as AWS Signature
+// Version 4 requires, and the slash unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
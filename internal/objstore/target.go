@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package objstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// Target is an OCI image layout in object storage. It implements oras.GraphTarget, so that
+// manifests are copied to and from it with their referrers, such as signatures.
+//
+// Blobs are buffered in memory before they are uploaded, which suits the small artifacts of
+// kubectl-mft. The index is read once when the target is opened and written back by Tag, so
+// concurrent writers to the same layout overwrite each other's tags.
+type Target struct {
+	bucket bucket
+	prefix string
+
+	mu    sync.Mutex
+	index v1.Index
+	// predecessors maps the digest of a subject to the manifests referring to it, built on
+	// first use
+	predecessors map[digest.Digest][]v1.Descriptor
+}
+
+// newTarget opens the layout under prefix, reading its index if it exists.
+func newTarget(ctx context.Context, b bucket, prefix string) (*Target, error) {
+	t := &Target{bucket: b, prefix: prefix}
+	t.index.SchemaVersion = 2
+	t.index.MediaType = v1.MediaTypeImageIndex
+	rc, err := b.get(ctx, t.key(v1.ImageIndexFile))
+	if errors.Is(err, errNotFound) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	defer rc.Close()
+	if err := json.NewDecoder(rc).Decode(&t.index); err != nil {
+		return nil, fmt.Errorf("failed to decode index: %w", err)
+	}
+	return t, nil
+}
+
+func (t *Target) key(name string) string {
+	return path.Join(t.prefix, name)
+}
+
+func (t *Target) blobKey(d digest.Digest) string {
+	return t.key(path.Join(v1.ImageBlobsDir, d.Algorithm().String(), d.Encoded()))
+}
+
+// Fetch returns the content of the blob.
+func (t *Target) Fetch(ctx context.Context, target v1.Descriptor) (io.ReadCloser, error) {
+	rc, err := t.bucket.get(ctx, t.blobKey(target.Digest))
+	if errors.Is(err, errNotFound) {
+		return nil, fmt.Errorf("%s: %w", target.Digest, errdef.ErrNotFound)
+	}
+	return rc, err
+}
+
+// Exists reports whether the blob exists.
+func (t *Target) Exists(ctx context.Context, target v1.Descriptor) (bool, error) {
+	return t.bucket.exists(ctx, t.blobKey(target.Digest))
+}
+
+// Push uploads the blob after verifying its content. Manifests are recorded in the index, as
+// an OCI image layout does for untagged manifests, so that their referrers are found.
+func (t *Target) Push(ctx context.Context, expected v1.Descriptor, r io.Reader) error {
+	data, err := content.ReadAll(r, expected)
+	if err != nil {
+		return err
+	}
+	if err := t.bucket.put(ctx, t.blobKey(expected.Digest), data); err != nil {
+		return err
+	}
+	if isManifest(expected) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if !slices.ContainsFunc(t.index.Manifests, func(d v1.Descriptor) bool { return d.Digest == expected.Digest }) {
+			t.index.Manifests = append(t.index.Manifests, stripRefName(expected))
+		}
+		t.predecessors = nil
+	}
+	return nil
+}
+
+// Resolve returns the descriptor of the manifest with the tag or digest.
+func (t *Target) Resolve(_ context.Context, reference string) (v1.Descriptor, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, d := range t.index.Manifests {
+		if d.Annotations[v1.AnnotationRefName] == reference || d.Digest.String() == reference {
+			return stripRefName(d), nil
+		}
+	}
+	return v1.Descriptor{}, fmt.Errorf("%s: %w", reference, errdef.ErrNotFound)
+}
+
+// Tag points the tag at the manifest and writes the index, replacing a previous manifest of
+// the tag, which stays in the layout untagged.
+func (t *Target) Tag(ctx context.Context, desc v1.Descriptor, reference string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	manifests := make([]v1.Descriptor, 0, len(t.index.Manifests)+1)
+	for _, d := range t.index.Manifests {
+		if d.Annotations[v1.AnnotationRefName] == reference {
+			d = stripRefName(d)
+		}
+		if d.Digest == desc.Digest && d.Annotations[v1.AnnotationRefName] == "" {
+			continue
+		}
+		manifests = append(manifests, d)
+	}
+	tagged := desc
+	tagged.Annotations = maps.Clone(desc.Annotations)
+	if tagged.Annotations == nil {
+		tagged.Annotations = make(map[string]string)
+	}
+	tagged.Annotations[v1.AnnotationRefName] = reference
+	t.index.Manifests = append(manifests, tagged)
+
+	layout, err := json.Marshal(v1.ImageLayout{Version: v1.ImageLayoutVersion})
+	if err != nil {
+		return err
+	}
+	if err := t.bucket.put(ctx, t.key(v1.ImageLayoutFile), layout); err != nil {
+		return err
+	}
+	index, err := json.Marshal(t.index)
+	if err != nil {
+		return err
+	}
+	return t.bucket.put(ctx, t.key(v1.ImageIndexFile), index)
+}
+
+// Predecessors returns the manifests of the layout whose subject is node, such as its signatures.
+func (t *Target) Predecessors(ctx context.Context, node v1.Descriptor) ([]v1.Descriptor, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.predecessors == nil {
+		predecessors := make(map[digest.Digest][]v1.Descriptor)
+		for _, d := range t.index.Manifests {
+			rc, err := t.bucket.get(ctx, t.blobKey(d.Digest))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read manifest %s: %w", d.Digest, err)
+			}
+			var m struct {
+				Subject *v1.Descriptor `json:"subject"`
+			}
+			err = json.NewDecoder(rc).Decode(&m)
+			rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode manifest %s: %w", d.Digest, err)
+			}
+			if m.Subject != nil {
+				predecessors[m.Subject.Digest] = append(predecessors[m.Subject.Digest], stripRefName(d))
+			}
+		}
+		t.predecessors = predecessors
+	}
+	return t.predecessors[node.Digest], nil
+}
+
+// isManifest reports whether the descriptor is an image manifest or index.
+func isManifest(d v1.Descriptor) bool {
+	return d.MediaType == v1.MediaTypeImageManifest || d.MediaType == v1.MediaTypeImageIndex
+}
+
+// stripRefName returns the descriptor without its tag annotation.
+func stripRefName(d v1.Descriptor) v1.Descriptor {
+	if _, ok := d.Annotations[v1.AnnotationRefName]; !ok {
+		return d
+	}
+	annotations := make(map[string]string, len(d.Annotations))
+	for k, v := range d.Annotations {
+		if k != v1.AnnotationRefName {
+			annotations[k] = v
+		}
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	d.Annotations = annotations
+	return d
+}
@@ -0,0 +1,269 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+// annotationAdoptedFrom records the foreign artifact an adopted manifest was converted from
+const annotationAdoptedFrom = "io.kubectl-mft.adopted-from"
+
+// maxAdoptedSize is the largest layer, decompressed archive, and YAML content read from a
+// foreign artifact, so that a malicious layer cannot exhaust memory
+const maxAdoptedSize = 64 << 20
+
+// PullAny pulls the tag like Pull if it is a kubectl-mft artifact. Other artifacts, such as
+// those pushed by 'oras push' or Flux, are adopted instead: the YAML files in their layers,
+// either plain or in tar archives, are packed as a kubectl-mft artifact under the same tag in
+// local storage. It reports whether the artifact was adopted.
+// The mirrors configured for the registry are tried in order before it, as by Pull.
+func (r *Repository) PullAny(ctx context.Context) (bool, error) {
+	sources, err := r.pullSources()
+	if err != nil {
+		return false, err
+	}
+
+	var s *Staged
+	var adopted bool
+	for i, src := range sources {
+		s, adopted, err = r.stagePullAny(ctx, src)
+		if err == nil || i == len(sources)-1 || ctx.Err() != nil {
+			break
+		}
+		r.warnf("failed to pull %s from mirror %s, trying the next source: %v", r.ref, src, err)
+	}
+	if err != nil {
+		return false, err
+	}
+	defer s.Discard()
+	if err := s.Commit(ctx); err != nil {
+		return false, err
+	}
+	return adopted, nil
+}
+
+// stagePullAny pulls or adopts the tag from src into a staging layout, and reports whether
+// the artifact was adopted.
+func (r *Repository) stagePullAny(ctx context.Context, src pullSource) (_ *Staged, adopted bool, err error) {
+	repo, err := r.newRemoteRepository(src.registry, src.repository)
+	if err != nil {
+		return nil, false, err
+	}
+	tag := r.ref.ReferenceOrDefault()
+	desc, err := repo.Resolve(ctx, tag)
+	if err != nil {
+		return nil, false, r.formatCopyError(err)
+	}
+	native := true
+	var m *v1.Manifest
+	if desc.MediaType == v1.MediaTypeImageIndex {
+		// Bundles are kubectl-mft artifacts, other indexes such as multi-platform images are not adopted
+		data, err := content.FetchAll(ctx, repo, desc)
+		if err != nil {
+			return nil, false, r.formatCopyError(err)
+		}
+		var index v1.Index
+		if err := json.Unmarshal(data, &index); err != nil {
+			return nil, false, fmt.Errorf("failed to unmarshal index %s: %w", desc.Digest, err)
+		}
+		if index.ArtifactType != bundleArtifactType {
+			return nil, false, fmt.Errorf("%s is an image index, which cannot be adopted", r.ref)
+		}
+	} else {
+		if _, m, err = fetchManifest(ctx, repo, tag); err != nil {
+			return nil, false, r.formatCopyError(err)
+		}
+		native = isNativeManifest(m)
+	}
+
+	if native {
+		s, store, err := r.newStaged()
+		if err != nil {
+			return nil, false, err
+		}
+		s.event = mft.EventPulled
+		if err := r.pullFrom(ctx, src, store, r.LayoutPath()); err != nil {
+			s.Discard()
+			return nil, false, err
+		}
+		return s, false, nil
+	}
+
+	data, err := r.foreignContent(ctx, repo, m)
+	if err != nil {
+		return nil, false, err
+	}
+
+	workDir, err := newWorkDir()
+	if err != nil {
+		return nil, false, err
+	}
+	defer os.RemoveAll(workDir)
+	manifestPath := filepath.Join(workDir, "adopted.yaml")
+	if err := os.WriteFile(manifestPath, data, 0o644); err != nil {
+		return nil, false, fmt.Errorf("failed to write adopted content: %w", err)
+	}
+
+	annotations := maps.Clone(r.annotations)
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[annotationAdoptedFrom] = r.Name() + "@" + desc.Digest.String()
+	r.SetAnnotations(annotations)
+	s, err := r.Stage(ctx, manifestPath, "")
+	if err != nil {
+		return nil, false, err
+	}
+	s.event = mft.EventPulled
+	return s, true, nil
+}
+
+// ReadRemote returns the content of the tag in the remote registry and the digest of its
+// manifest, without storing anything locally. The YAML files of artifacts not created by
+// kubectl-mft are concatenated, as PullAny adopts them.
+func (r *Repository) ReadRemote(ctx context.Context) ([]byte, string, error) {
+	repo, err := r.newAuthenticatedRepository()
+	if err != nil {
+		return nil, "", err
+	}
+	desc, m, err := fetchManifest(ctx, repo, r.ref.ReferenceOrDefault())
+	if err != nil {
+		return nil, "", r.formatCopyError(err)
+	}
+	var data []byte
+	if isNativeManifest(m) {
+		data, err = readContent(ctx, repo, m)
+	} else {
+		data, err = r.foreignContent(ctx, repo, m)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return data, desc.Digest.String(), nil
+}
+
+// foreignContent returns the YAML files in the layers of a foreign artifact as a multi-document manifest.
+func (r *Repository) foreignContent(ctx context.Context, store content.Fetcher, m *v1.Manifest) ([]byte, error) {
+	var docs [][]byte
+	var total int
+	for _, layer := range m.Layers {
+		if layer.Size > maxAdoptedSize {
+			return nil, fmt.Errorf("layer %s of %d bytes exceeds the limit of %d bytes", layer.Digest, layer.Size, maxAdoptedSize)
+		}
+		data, err := content.FetchAll(ctx, store, layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch layer %s: %w", layer.Digest, err)
+		}
+		found, err := layerYAML(layer, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read layer %s: %w", layer.Digest, err)
+		}
+		for _, doc := range found {
+			if total += len(doc); total > maxAdoptedSize {
+				return nil, fmt.Errorf("YAML content of %s exceeds the limit of %d bytes", r.ref, maxAdoptedSize)
+			}
+		}
+		docs = append(docs, found...)
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("no YAML content found in the layers of %s", r.ref)
+	}
+	return joinYAML(docs), nil
+}
+
+// isNativeManifest reports whether m was created by kubectl-mft.
+func isNativeManifest(m *v1.Manifest) bool {
+	if m.ArtifactType == artifactType || m.Config.MediaType == artifactType {
+		return true
+	}
+	return len(m.Layers) > 0 && (m.Layers[0].MediaType == contentMediaType || m.Layers[0].MediaType == deltaMediaType)
+}
+
+// layerYAML returns the YAML files in a layer of a foreign artifact: the layer itself if it is a
+// YAML file, or the YAML files of a tar archive, optionally gzip compressed, in archive order.
+// Layers of other types are skipped.
+func layerYAML(layer v1.Descriptor, data []byte) ([][]byte, error) {
+	title := layer.Annotations[v1.AnnotationTitle]
+	if strings.Contains(layer.MediaType, "yaml") || isYAMLFile(title) {
+		return [][]byte{data}, nil
+	}
+
+	// Flux and 'oras push' of a directory store tar archives, compressed or not
+	r := io.Reader(bytes.NewReader(data))
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else if !strings.Contains(layer.MediaType, "tar") {
+		return nil, nil
+	}
+	// The decompressed archive is read up to the limit, truncation is reported as such below
+	limited := &io.LimitedReader{R: r, N: maxAdoptedSize}
+	tooLarge := func(err error) error {
+		if limited.N <= 0 {
+			return fmt.Errorf("archive exceeds the limit of %d bytes", maxAdoptedSize)
+		}
+		return err
+	}
+
+	var docs [][]byte
+	tr := tar.NewReader(limited)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, tooLarge(err)
+		}
+		if hdr.Typeflag != tar.TypeReg || !isYAMLFile(hdr.Name) {
+			continue
+		}
+		doc, err := io.ReadAll(io.LimitReader(tr, maxAdoptedSize))
+		if err != nil {
+			return nil, tooLarge(err)
+		}
+		docs = append(docs, doc)
+	}
+}
+
+func isYAMLFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// joinYAML concatenates YAML files into a multi-document manifest.
+func joinYAML(files [][]byte) []byte {
+	var buf bytes.Buffer
+	for i, f := range files {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(f)
+		if len(f) > 0 && f[len(f)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
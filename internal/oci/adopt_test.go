@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+)
+
+// fluxArchive returns a gzip compressed tar archive of files, like Flux and 'oras push' of a directory create.
+func fluxArchive(t *testing.T, files map[string]string, order []string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range order {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPullAny(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	ctx := context.Background()
+	_, host := newFakeRegistry(t)
+
+	// The fake registry shares tags between repositories, so the artifacts use different tags
+	setupListTest(t, host+"/team/app:v1")
+	native, err := NewRepository(host + "/team/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := native.Push(ctx); err != nil {
+		t.Fatalf("Push() failed: %v", err)
+	}
+
+	// A foreign artifact with a Flux archive layer and a plain YAML layer pushed by oras
+	foreign, err := NewRepository(host + "/flux/app:v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := foreign.newAuthenticatedRepository()
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := fluxArchive(t, map[string]string{
+		"deploy/a.yaml": "kind: A\n",
+		"README.md":     "# not a manifest\n",
+		"deploy/b.yml":  "kind: B",
+	}, []string{"deploy/a.yaml", "README.md", "deploy/b.yml"})
+	plain := []byte("kind: C\n")
+	archiveDesc := content.NewDescriptorFromBytes("application/vnd.cncf.flux.content.v1.tar+gzip", archive)
+	plainDesc := content.NewDescriptorFromBytes("application/vnd.oci.image.layer.v1.tar", plain)
+	plainDesc.Annotations = map[string]string{v1.AnnotationTitle: "c.yaml"}
+	for _, l := range []struct {
+		desc v1.Descriptor
+		data []byte
+	}{{archiveDesc, archive}, {plainDesc, plain}} {
+		if err := repo.Push(ctx, l.desc, bytes.NewReader(l.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	desc, err := oras.PackManifest(ctx, repo, oras.PackManifestVersion1_1, "application/vnd.cncf.flux.config.v1+json", oras.PackManifestOptions{
+		Layers: []v1.Descriptor{archiveDesc, plainDesc},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Tag(ctx, desc, "v2"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		tag         string
+		wantAdopted bool
+		want        string
+	}{
+		{name: "native", tag: host + "/team/app:v1", want: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"},
+		{name: "foreign", tag: host + "/flux/app:v2", wantAdopted: true, want: "kind: A\n---\nkind: B\n---\nkind: C\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupListTest(t)
+			r, err := NewRepository(tt.tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			adopted, err := r.PullAny(ctx)
+			if err != nil {
+				t.Fatalf("PullAny() failed: %v", err)
+			}
+			if adopted != tt.wantAdopted {
+				t.Errorf("PullAny() adopted = %v, want %v", adopted, tt.wantAdopted)
+			}
+			dump, err := r.Dump(ctx)
+			if err != nil {
+				t.Fatalf("Dump() failed: %v", err)
+			}
+			defer dump.Close()
+			var buf strings.Builder
+			if _, err := io.Copy(&buf, dump); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("content = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("mirror", func(t *testing.T) {
+		// The origin has no artifacts, the foreign artifact is adopted from its mirror
+		_, origin := newFakeRegistry(t)
+		dir := t.TempDir()
+		t.Setenv("KUBECTL_MFT_CONFIG_DIR", dir)
+		cfg := "mirrors:\n  - registry: " + origin + "\n    endpoints: [" + host + "]\n"
+		if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(cfg), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		setupListTest(t)
+		r, err := NewRepository(origin + "/flux/app:v2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		adopted, err := r.PullAny(ctx)
+		if err != nil {
+			t.Fatalf("PullAny() failed: %v", err)
+		}
+		if !adopted {
+			t.Error("PullAny() adopted = false, want true")
+		}
+	})
+}
+
+func TestLayerYAMLLimit(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "big.yaml", Mode: 0o644, Size: maxAdoptedSize + 1, Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.CopyN(tw, zeroReader{}, maxAdoptedSize+1); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	layer := content.NewDescriptorFromBytes("application/vnd.cncf.flux.content.v1.tar+gzip", buf.Bytes())
+	if _, err := layerYAML(layer, buf.Bytes()); err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+		t.Errorf("layerYAML() of a %d byte archive error = %v, want a size limit error", maxAdoptedSize+1, err)
+	}
+}
+
+// zeroReader is an endless stream of zero bytes.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
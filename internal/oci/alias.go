@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/errdef"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+// annotationAliasOf marks an index entry as an alias and records the tag it was pointed at.
+// An alias is an additional tag on the manifest of its target, so no content is copied.
+const annotationAliasOf = "io.kubectl-mft.alias-of"
+
+// SetAlias points the tag of r at the manifest of target, a tag in the same repository of the
+// writable storage. An existing alias is re-pointed, while an existing regular tag is never
+// replaced. Aliases cannot point at other aliases.
+func (r *Repository) SetAlias(ctx context.Context, target *Repository) error {
+	if r.Name() != target.Name() {
+		return fmt.Errorf("alias %s and target %s must be in the same repository", r.ref, target.ref)
+	}
+	alias, tag := r.ref.ReferenceOrDefault(), target.ref.ReferenceOrDefault()
+	if !tagRegexp.MatchString(alias) {
+		return fmt.Errorf("invalid alias %q, expected a tag", alias)
+	}
+	if alias == tag {
+		return fmt.Errorf("alias %s cannot point at itself", r.ref)
+	}
+
+	layoutStore, err := r.newOCILayoutStore()
+	if err != nil {
+		return err
+	}
+	desc, err := layoutStore.Resolve(ctx, tag)
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return fmt.Errorf("target %s not found in local storage", target.ref)
+		}
+		return fmt.Errorf("failed to resolve target %s: %w", target.ref, err)
+	}
+	if desc.Annotations[annotationAliasOf] != "" {
+		return fmt.Errorf("target %s is an alias itself, point at %s instead", target.ref, desc.Annotations[annotationAliasOf])
+	}
+
+	prev, err := layoutStore.Resolve(ctx, alias)
+	if err == nil && prev.Annotations[annotationAliasOf] == "" {
+		return fmt.Errorf("%s is a tag, not an alias", r.ref)
+	}
+	if err != nil && !errors.Is(err, errdef.ErrNotFound) {
+		return fmt.Errorf("failed to resolve alias %s: %w", r.ref, err)
+	}
+
+	annotations := maps.Clone(desc.Annotations)
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	delete(annotations, v1.AnnotationRefName)
+	annotations[annotationAliasOf] = tag
+	desc.Annotations = annotations
+	if err := layoutStore.Tag(ctx, desc, alias); err != nil {
+		return fmt.Errorf("failed to tag alias: %w", err)
+	}
+	if err := r.SyncMetadata(ctx); err != nil {
+		return err
+	}
+	return r.recordEvent(mft.EventAliased, desc.Digest.String())
+}
+
+// Alias returns the alias information of the tag in the writable storage, or nil if the tag
+// is not an alias.
+func (r *Repository) Alias() (*mft.AliasInfo, error) {
+	if !isLayout(r.userLayoutPath()) {
+		return nil, nil
+	}
+	index, err := loadIndexFile(r.userLayoutPath())
+	if err != nil {
+		return nil, err
+	}
+	name, err := getRepoName(r.storageDir(), r.userLayoutPath())
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range indexAliases(name, index) {
+		if a.Alias == r.ref.ReferenceOrDefault() {
+			return a, nil
+		}
+	}
+	return nil, nil
+}
+
+// Aliases returns the aliases of all repositories in the writable storage, or only of the
+// repository of r if it is not nil.
+func Aliases(r *Repository) (*mft.AliasesResult, error) {
+	layouts := []string{}
+	root := baseDir
+	if r != nil {
+		root = r.storageDir()
+		if isLayout(r.userLayoutPath()) {
+			layouts = append(layouts, r.userLayoutPath())
+		}
+	} else {
+		var err error
+		if layouts, err = findLayouts(baseDir); err != nil {
+			return nil, err
+		}
+	}
+
+	var aliases []*mft.AliasInfo
+	for _, layout := range layouts {
+		index, err := loadIndexFile(layout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OCI index at %s: %w", layout, err)
+		}
+		name, err := getRepoName(root, layout)
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, indexAliases(name, index)...)
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		if aliases[i].Repository != aliases[j].Repository {
+			return aliases[i].Repository < aliases[j].Repository
+		}
+		return aliases[i].Alias < aliases[j].Alias
+	})
+	return mft.NewAliasesResult(aliases), nil
+}
+
+// indexAliases returns the aliases in the index of a layout, comparing each with the manifest
+// its target tag currently refers to.
+func indexAliases(repository string, index *v1.Index) []*mft.AliasInfo {
+	tags := make(map[string]string)
+	for _, d := range index.Manifests {
+		if t := d.Annotations[v1.AnnotationRefName]; t != "" {
+			tags[t] = d.Digest.String()
+		}
+	}
+
+	var aliases []*mft.AliasInfo
+	for _, d := range index.Manifests {
+		target := d.Annotations[annotationAliasOf]
+		if target == "" || d.Annotations[v1.AnnotationRefName] == "" {
+			continue
+		}
+		status := mft.AliasCurrent
+		if dgst, ok := tags[target]; !ok {
+			status = mft.AliasMissing
+		} else if dgst != d.Digest.String() {
+			status = mft.AliasStale
+		}
+		aliases = append(aliases, &mft.AliasInfo{
+			Repository: repository,
+			Alias:      d.Annotations[v1.AnnotationRefName],
+			Target:     target,
+			Digest:     d.Digest.String(),
+			Status:     status,
+		})
+	}
+	return aliases
+}
+
+// clearAlias removes the alias annotation from the tag in store, as copies of an alias are
+// regular tags.
+func clearAlias(ctx context.Context, store *oci.Store, tag string) error {
+	desc, err := store.Resolve(ctx, tag)
+	if err != nil {
+		return fmt.Errorf("failed to resolve reference %s: %w", tag, err)
+	}
+	if desc.Annotations[annotationAliasOf] == "" {
+		return nil
+	}
+	desc.Annotations = maps.Clone(desc.Annotations)
+	delete(desc.Annotations, annotationAliasOf)
+	delete(desc.Annotations, v1.AnnotationRefName)
+	if err := store.Tag(ctx, desc, tag); err != nil {
+		return fmt.Errorf("failed to tag %s: %w", tag, err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+func mustRepository(t *testing.T, tag string) *Repository {
+	t.Helper()
+	r, err := NewRepository(tag)
+	if err != nil {
+		t.Fatalf("NewRepository(%s) failed: %v", tag, err)
+	}
+	return r
+}
+
+func aliasStrings(t *testing.T) []string {
+	t.Helper()
+	res, err := Aliases(nil)
+	if err != nil {
+		t.Fatalf("Aliases() failed: %v", err)
+	}
+	var got []string
+	for _, a := range res.Items() {
+		got = append(got, a.Repository+":"+a.Alias+"->"+a.Target+" "+a.Status)
+	}
+	return got
+}
+
+func TestSetAlias(t *testing.T) {
+	setupListTest(t, "app:v1", "app:v2", "other:v1")
+	ctx := context.Background()
+	stable := mustRepository(t, "app:stable")
+
+	tests := []struct {
+		name    string
+		alias   string
+		target  string
+		wantErr string
+	}{
+		{"other repository", "app:stable", "other:v1", "must be in the same repository"},
+		{"missing target", "app:stable", "app:v9", "not found in local storage"},
+		{"regular tag", "app:v2", "app:v1", "is a tag, not an alias"},
+		{"itself", "app:v1", "app:v1", "cannot point at itself"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := mustRepository(t, tt.alias).SetAlias(ctx, mustRepository(t, tt.target))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("SetAlias() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := stable.SetAlias(ctx, mustRepository(t, "app:v1")); err != nil {
+		t.Fatalf("SetAlias() failed: %v", err)
+	}
+	if err := mustRepository(t, "app:current").SetAlias(ctx, stable); err == nil || !strings.Contains(err.Error(), "is an alias itself") {
+		t.Errorf("SetAlias(alias) error = %v, want alias error", err)
+	}
+	v1Digest, err := mustRepository(t, "app:v1").Digest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, err := stable.Digest(ctx); err != nil || d != v1Digest {
+		t.Errorf("Digest() = %s, %v, want %s", d, err, v1Digest)
+	}
+
+	// Re-pointing
+	if err := stable.SetAlias(ctx, mustRepository(t, "app:v2")); err != nil {
+		t.Fatalf("SetAlias() failed: %v", err)
+	}
+	if got := strings.Join(aliasStrings(t), ","); got != "app:stable->v2 current" {
+		t.Errorf("Aliases() = %s, want app:stable->v2 current", got)
+	}
+	a, err := stable.Alias()
+	if err != nil || a == nil || a.Target != "v2" {
+		t.Errorf("Alias() = %+v, %v, want target v2", a, err)
+	}
+	if a, err := mustRepository(t, "app:v2").Alias(); err != nil || a != nil {
+		t.Errorf("Alias() of a regular tag = %+v, %v, want nil", a, err)
+	}
+}
+
+func TestAliasStatus(t *testing.T) {
+	setupListTest(t, "app:v1")
+	ctx := context.Background()
+	stable := mustRepository(t, "app:stable")
+	if err := stable.SetAlias(ctx, mustRepository(t, "app:v1")); err != nil {
+		t.Fatalf("SetAlias() failed: %v", err)
+	}
+
+	// Packing the target again moves it away from the manifest of the alias
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(manifestPath, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := mustRepository(t, "app:v1").Save(ctx, manifestPath); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	if got := strings.Join(aliasStrings(t), ","); got != "app:stable->v1 stale" {
+		t.Errorf("Aliases() = %s, want app:stable->v1 stale", got)
+	}
+
+	// Copies of an alias are regular tags
+	if err := stable.Copy(ctx, "app:copy", mft.CopyOptions{}); err != nil {
+		t.Fatalf("Copy() failed: %v", err)
+	}
+	if got := strings.Join(aliasStrings(t), ","); got != "app:stable->v1 stale" {
+		t.Errorf("Aliases() after Copy() = %s, want app:stable->v1 stale", got)
+	}
+
+	// Deleting an alias keeps its manifest
+	if _, err := stable.Delete(ctx); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if got := listTags(t); got != "app:copy,app:v1" {
+		t.Errorf("List() = %q, want app:copy,app:v1", got)
+	}
+	if got := aliasStrings(t); len(got) != 0 {
+		t.Errorf("Aliases() = %v, want none", got)
+	}
+}
@@ -0,0 +1,254 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/opencontainers/image-spec/specs-go"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/errdef"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+const (
+	bundleArtifactType = "application/vnd.kubectl-mft.bundle.v1"
+
+	// annotationBundleMember holds the name of a member within the bundle
+	annotationBundleMember = "io.kubectl-mft.bundle.member"
+	// annotationBundleSource records the tag a member was taken from
+	annotationBundleSource = "io.kubectl-mft.bundle.source"
+	// annotationBundleDependsOn holds the comma separated names of the members a member depends on
+	annotationBundleDependsOn = "io.kubectl-mft.bundle.depends-on"
+)
+
+// CreateBundle creates an image index referencing the given member artifacts by digest and tags it.
+// The members, along with their signatures, are copied into the bundle's repository so that the
+// bundle can be pushed and pulled as a single artifact.
+func (r *Repository) CreateBundle(ctx context.Context, members []*mft.BundleMember) error {
+	if len(members) == 0 {
+		return fmt.Errorf("a bundle requires at least one member")
+	}
+	if _, err := mft.SortBundleMembers(members); err != nil {
+		return err
+	}
+
+	layoutStore, err := r.newOCILayoutStore()
+	if err != nil {
+		return err
+	}
+
+	descs := make([]v1.Descriptor, 0, len(members))
+	for _, m := range members {
+		desc, err := copyBundleMember(ctx, m, layoutStore)
+		if err != nil {
+			return err
+		}
+		m.Digest = desc.Digest.String()
+
+		desc.Annotations = map[string]string{
+			annotationBundleMember: m.Name,
+			annotationBundleSource: m.Reference,
+		}
+		if len(m.DependsOn) > 0 {
+			desc.Annotations[annotationBundleDependsOn] = strings.Join(m.DependsOn, ",")
+		}
+		descs = append(descs, desc)
+	}
+
+	index := v1.Index{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		MediaType:    v1.MediaTypeImageIndex,
+		ArtifactType: bundleArtifactType,
+		Manifests:    descs,
+		Annotations: map[string]string{
+			"org.opencontainers.image.title": r.Name(),
+		},
+	}
+	indexJSON, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle index: %w", err)
+	}
+
+	indexDesc := content.NewDescriptorFromBytes(v1.MediaTypeImageIndex, indexJSON)
+	indexDesc.ArtifactType = bundleArtifactType
+	if err := layoutStore.Push(ctx, indexDesc, bytes.NewReader(indexJSON)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		return fmt.Errorf("failed to push bundle index: %w", err)
+	}
+	if err := layoutStore.Tag(ctx, indexDesc, r.ref.ReferenceOrDefault()); err != nil {
+		return fmt.Errorf("failed to tag bundle: %w", err)
+	}
+	if err := r.SyncMetadata(ctx); err != nil {
+		return err
+	}
+	return r.recordEvent(mft.EventPacked, indexDesc.Digest.String())
+}
+
+// copyBundleMember copies the member artifact and its referrers from local storage into dest
+// and returns the descriptor of the member manifest.
+func copyBundleMember(ctx context.Context, m *mft.BundleMember, dest oras.Target) (v1.Descriptor, error) {
+	src, err := NewRepository(m.Reference)
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	srcStore, err := src.newReadOnlyStore(ctx)
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+
+	desc, err := srcStore.Resolve(ctx, src.ref.ReferenceOrDefault())
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return v1.Descriptor{}, fmt.Errorf("bundle member %q: %s not found in local storage", m.Name, m.Reference)
+		}
+		return v1.Descriptor{}, fmt.Errorf("bundle member %q: failed to resolve %s: %w", m.Name, m.Reference, err)
+	}
+	if desc.MediaType != v1.MediaTypeImageManifest {
+		return v1.Descriptor{}, fmt.Errorf("bundle member %q: %s is not a manifest artifact", m.Name, m.Reference)
+	}
+
+	if err := oras.ExtendedCopyGraph(ctx, srcStore, dest, desc, oras.DefaultExtendedCopyGraphOptions); err != nil {
+		return v1.Descriptor{}, fmt.Errorf("bundle member %q: failed to copy %s: %w", m.Name, m.Reference, err)
+	}
+	// Drop annotations such as the source ref name, the bundle sets its own
+	return v1.Descriptor{
+		MediaType:    desc.MediaType,
+		ArtifactType: desc.ArtifactType,
+		Digest:       desc.Digest,
+		Size:         desc.Size,
+	}, nil
+}
+
+// IsBundle reports whether the tag refers to a bundle artifact.
+func (r *Repository) IsBundle(ctx context.Context) (bool, error) {
+	_, err := r.fetchBundleIndex(ctx)
+	if err != nil {
+		if errors.Is(err, errNotBundle) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Bundle returns the members of the bundle in dependency order.
+func (r *Repository) Bundle(ctx context.Context) (*mft.BundleResult, error) {
+	index, err := r.fetchBundleIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]*mft.BundleMember, 0, len(index.Manifests))
+	for _, d := range index.Manifests {
+		m := &mft.BundleMember{
+			Name:      d.Annotations[annotationBundleMember],
+			Reference: d.Annotations[annotationBundleSource],
+			Digest:    d.Digest.String(),
+		}
+		if deps := d.Annotations[annotationBundleDependsOn]; deps != "" {
+			m.DependsOn = strings.Split(deps, ",")
+		}
+		members = append(members, m)
+	}
+
+	sorted, err := mft.SortBundleMembers(members)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle %s: %w", r.ref.ReferenceOrDefault(), err)
+	}
+	return mft.NewBundleResult(sorted), nil
+}
+
+// MemberRepository returns the repository referring to a bundle member by digest.
+// Members are stored in the bundle's own repository.
+func (r *Repository) MemberRepository(m *mft.BundleMember) (*Repository, error) {
+	return NewRepository(r.Name() + "@" + m.Digest)
+}
+
+var errNotBundle = errors.New("not a bundle")
+
+// fetchBundleIndex resolves the tag and decodes the bundle index it points to.
+func (r *Repository) fetchBundleIndex(ctx context.Context) (*v1.Index, error) {
+	layoutStore, err := r.newReadOnlyStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := r.ref.ReferenceOrDefault()
+	desc, err := layoutStore.Resolve(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve reference %s: %w", ref, err)
+	}
+	if desc.MediaType != v1.MediaTypeImageIndex {
+		return nil, fmt.Errorf("%s is %w", ref, errNotBundle)
+	}
+
+	indexJSON, err := content.FetchAll(ctx, layoutStore, desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch content for %s: %w", ref, err)
+	}
+	var index v1.Index
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal bundle index: %w", err)
+	}
+	if index.ArtifactType != bundleArtifactType {
+		return nil, fmt.Errorf("%s is %w", ref, errNotBundle)
+	}
+	return &index, nil
+}
+
+// indexMembers returns the manifests referenced by the image index desc.
+func indexMembers(ctx context.Context, store content.Fetcher, desc v1.Descriptor) ([]v1.Descriptor, error) {
+	indexJSON, err := content.FetchAll(ctx, store, desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch index %s: %w", desc.Digest, err)
+	}
+	var index v1.Index
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal index %s: %w", desc.Digest, err)
+	}
+	return index.Manifests, nil
+}
+
+// deleteOrphanedManifests deletes manifests that are no longer tagged, such as the former members
+// of a deleted bundle or a replaced tag, along with their signatures, unless they are tagged
+// themselves or still referenced by a bundle.
+// Signatures keep manifests from being garbage collected, so this is done explicitly.
+func deleteOrphanedManifests(ctx context.Context, store *oci.Store, layoutPath string, manifests []v1.Descriptor) error {
+	for _, m := range manifests {
+		index, err := loadIndexFile(layoutPath)
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(index.Manifests, func(d v1.Descriptor) bool {
+			return d.Digest == m.Digest && d.Annotations[v1.AnnotationRefName] != ""
+		}) {
+			continue
+		}
+
+		predecessors, err := store.Predecessors(ctx, m)
+		if err != nil {
+			return fmt.Errorf("failed to get predecessors of %s: %w", m.Digest, err)
+		}
+		if slices.ContainsFunc(predecessors, func(p v1.Descriptor) bool {
+			return p.MediaType == v1.MediaTypeImageIndex
+		}) {
+			continue
+		}
+
+		if err := store.Delete(ctx, m); err != nil && !errors.Is(err, errdef.ErrNotFound) {
+			return fmt.Errorf("failed to delete manifest %s: %w", m.Digest, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+func TestBundle(t *testing.T) {
+	origBaseDir, origSystemDirs := baseDir, systemDirs
+	baseDir = t.TempDir()
+	systemDirs = nil
+	t.Cleanup(func() { baseDir, systemDirs = origBaseDir, origSystemDirs })
+
+	ctx := context.Background()
+	contents := map[string]string{
+		"crds:v1": "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\n",
+		"app:v1":  "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n",
+	}
+	for tag, c := range contents {
+		path := filepath.Join(t.TempDir(), "manifest.yaml")
+		if err := os.WriteFile(path, []byte(c), 0o644); err != nil {
+			t.Fatalf("failed to create test manifest: %v", err)
+		}
+		r, err := NewRepository(tag)
+		if err != nil {
+			t.Fatalf("NewRepository() failed: %v", err)
+		}
+		if err := r.Save(ctx, path); err != nil {
+			t.Fatalf("Save(%s) failed: %v", tag, err)
+		}
+	}
+
+	bundle, err := NewRepository("bundle:v1")
+	if err != nil {
+		t.Fatalf("NewRepository() failed: %v", err)
+	}
+	err = bundle.CreateBundle(ctx, []*mft.BundleMember{
+		{Name: "app", Reference: "app:v1", DependsOn: []string{"crds"}},
+		{Name: "crds", Reference: "crds:v1"},
+	})
+	if err != nil {
+		t.Fatalf("CreateBundle() failed: %v", err)
+	}
+
+	isBundle, err := bundle.IsBundle(ctx)
+	if err != nil || !isBundle {
+		t.Fatalf("IsBundle() = %v, %v, want true", isBundle, err)
+	}
+
+	res, err := bundle.Bundle(ctx)
+	if err != nil {
+		t.Fatalf("Bundle() failed: %v", err)
+	}
+	members := res.Members()
+	if len(members) != 2 || members[0].Name != "crds" || members[1].Name != "app" {
+		t.Fatalf("Bundle() members = %+v, want crds then app", members)
+	}
+
+	for _, m := range members {
+		member, err := bundle.MemberRepository(m)
+		if err != nil {
+			t.Fatalf("MemberRepository(%s) failed: %v", m.Name, err)
+		}
+		dump, err := member.Dump(ctx)
+		if err != nil {
+			t.Fatalf("Dump(%s) failed: %v", m.Name, err)
+		}
+		defer dump.Close()
+		var buf strings.Builder
+		if _, err := io.Copy(&buf, dump); err != nil {
+			t.Fatalf("reading Dump() failed: %v", err)
+		}
+		if want := contents[m.Reference]; buf.String() != want {
+			t.Errorf("member %s content = %q, want %q", m.Name, buf.String(), want)
+		}
+	}
+
+	if _, err := bundle.Dump(ctx); err == nil || !strings.Contains(err.Error(), "is a bundle") {
+		t.Errorf("Dump() on a bundle should fail, got: %v", err)
+	}
+
+	plain, err := NewRepository("app:v1")
+	if err != nil {
+		t.Fatalf("NewRepository() failed: %v", err)
+	}
+	if isBundle, err := plain.IsBundle(ctx); err != nil || isBundle {
+		t.Errorf("IsBundle() on a manifest = %v, %v, want false", isBundle, err)
+	}
+
+	if _, err := bundle.Delete(ctx); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := os.Stat(bundle.userLayoutPath()); !os.IsNotExist(err) {
+		t.Errorf("bundle repository should be removed with its members, stat error: %v", err)
+	}
+}
+
+func TestCreateBundleMissingMember(t *testing.T) {
+	origBaseDir := baseDir
+	baseDir = t.TempDir()
+	t.Cleanup(func() { baseDir = origBaseDir })
+
+	bundle, err := NewRepository("bundle:v1")
+	if err != nil {
+		t.Fatalf("NewRepository() failed: %v", err)
+	}
+	err = bundle.CreateBundle(context.Background(), []*mft.BundleMember{{Name: "app", Reference: "missing:v1"}})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("CreateBundle() error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,297 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/oci"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/paths"
+)
+
+// annotationCachedAt records on the index descriptor of a cached tag when it was fetched
+const annotationCachedAt = "io.kubectl-mft.cached-at"
+
+// cacheDir is the storage root of manifests fetched implicitly, see UseCache
+var cacheDir string
+
+// initCacheDir sets the cache storage root of the selected profile.
+func initCacheDir() error {
+	dir, err := paths.CacheDir()
+	if err != nil {
+		return err
+	}
+	if p := paths.Profile(); p != paths.DefaultProfile {
+		dir = filepath.Join(dir, "profiles", p)
+	}
+	cacheDir = filepath.Join(dir, "manifests")
+	return nil
+}
+
+// CacheDir returns the storage root of manifests fetched implicitly.
+func CacheDir() string {
+	return cacheDir
+}
+
+// UseCache makes Pull store the manifest in the cache instead of the writable storage. The
+// cache has the storage layout, but holds manifests fetched implicitly, such as by apply of a
+// tag not in local storage: list does not show them unless asked, no events are recorded for
+// them, and EvictCache removes them once they expire. Manifests in the writable storage and
+// the system overlays take precedence over cached ones.
+func (r *Repository) UseCache() {
+	r.cached = true
+}
+
+// cacheLayoutPath returns the OCI layout directory of the repository in the cache.
+func (r *Repository) cacheLayoutPath() string {
+	return layoutDir(cacheDir, r.Name())
+}
+
+// InCache reports whether the tag is only available from the cache.
+func (r *Repository) InCache() bool {
+	return cacheDir != "" && r.LayoutPath() == r.cacheLayoutPath() && hasReference(r.cacheLayoutPath(), r.Tag())
+}
+
+// CacheExpired reports whether the tag is only available from the cache and was fetched more
+// than ttl ago, so that it should be fetched again.
+func (r *Repository) CacheExpired(ttl time.Duration) bool {
+	if !r.InCache() {
+		return false
+	}
+	index, err := loadIndexFile(r.cacheLayoutPath())
+	if err != nil {
+		return true
+	}
+	for _, d := range index.Manifests {
+		if d.Annotations[v1.AnnotationRefName] == r.Tag() {
+			return cacheExpired(d, ttl, time.Now())
+		}
+	}
+	return true
+}
+
+// markCached records the current time as the fetch time of the tag in store.
+func markCached(ctx context.Context, store *oci.Store, tag string) error {
+	_, err := setTagAnnotation(ctx, store, tag, annotationCachedAt, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// cacheExpired reports whether the cached tag desc was fetched more than ttl before now.
+// Tags without a valid fetch time are expired, pinned tags never are.
+func cacheExpired(desc v1.Descriptor, ttl time.Duration, now time.Time) bool {
+	if isPinned(desc) {
+		return false
+	}
+	fetched, err := time.Parse(time.RFC3339, desc.Annotations[annotationCachedAt])
+	return err != nil || now.Sub(fetched) > ttl
+}
+
+// EvictCache removes the cached tags fetched more than ttl ago, and returns their number.
+// Pinned tags, and the tags sharing their manifest, are kept.
+func EvictCache(ctx context.Context, ttl time.Duration) (int, error) {
+	entries, err := cacheEntries()
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	var expired []layoutEntry
+	for _, e := range entries {
+		if cacheExpired(e.desc, ttl, now) {
+			expired = append(expired, e)
+		}
+	}
+	return removeEntries(ctx, expired, pinnedManifests(entries))
+}
+
+// ClearCache removes every cached manifest except the pinned ones, and returns the number of
+// removed tags.
+func ClearCache(ctx context.Context) (int, error) {
+	entries, err := cacheEntries()
+	if err != nil {
+		return 0, err
+	}
+	pinned := pinnedManifests(entries)
+	if len(pinned) == 0 {
+		if err := os.RemoveAll(cacheDir); err != nil {
+			return 0, fmt.Errorf("failed to clear cache: %w", err)
+		}
+		return len(entries), nil
+	}
+	return removeEntries(ctx, entries, pinned)
+}
+
+// ListCache returns the list information of the cached tags.
+func ListCache(ctx context.Context) ([]*mft.Info, error) {
+	layouts, err := findLayouts(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	var info []*mft.Info
+	for _, layout := range layouts {
+		infos, err := readIndex(ctx, cacheDir, layout)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range infos {
+			i.Cached = true
+		}
+		info = append(info, infos...)
+	}
+	return info, nil
+}
+
+// CacheStats returns the tags in the cache and its size on disk.
+func CacheStats(ttl time.Duration) (*mft.CacheResult, error) {
+	entries, err := cacheEntries()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var items []*mft.CacheEntry
+	for _, e := range entries {
+		item := &mft.CacheEntry{
+			Repository: e.name,
+			Tag:        e.r.Tag(),
+			Digest:     e.desc.Digest.String(),
+			Expired:    cacheExpired(e.desc, ttl, now),
+			Pinned:     isPinned(e.desc),
+		}
+		if fetched, err := time.Parse(time.RFC3339, e.desc.Annotations[annotationCachedAt]); err == nil {
+			item.Fetched = fetched
+		}
+		items = append(items, item)
+	}
+	size, err := dirSize(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	return mft.NewCacheResult(cacheDir, items, size), nil
+}
+
+// layoutEntry is a tag in an OCI layout of a storage root
+type layoutEntry struct {
+	r *Repository
+	// name is the repository name as listed
+	name   string
+	layout string
+	desc   v1.Descriptor
+}
+
+// manifestKey identifies the manifest of the entry within its layout. Deleting a manifest
+// removes every tag of the layout referring to it.
+func (e layoutEntry) manifestKey() string {
+	return e.layout + "@" + e.desc.Digest.String()
+}
+
+// cacheEntries returns the tags in the cache.
+func cacheEntries() ([]layoutEntry, error) {
+	return layoutEntries(cacheDir, true)
+}
+
+// layoutEntries returns the tags in the repository layouts under root, which is the cache if
+// cached. Legacy directories directly in root are not repository layouts and are skipped.
+func layoutEntries(root string, cached bool) ([]layoutEntry, error) {
+	layouts, err := findLayouts(root)
+	if err != nil {
+		return nil, err
+	}
+	var entries []layoutEntry
+	for _, layout := range layouts {
+		name, err := repositoryName(root, layout)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.Contains(name, "/") {
+			continue
+		}
+		index, err := loadIndexFile(layout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OCI index at %s: %w", layout, err)
+		}
+		for _, d := range index.Manifests {
+			tag := d.Annotations[v1.AnnotationRefName]
+			if tag == "" {
+				continue
+			}
+			ref, err := parseReference(name + ":" + tag)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tag %s:%s: %w", name, tag, err)
+			}
+			entries = append(entries, layoutEntry{
+				r:      &Repository{ref: ref, cached: cached},
+				name:   strings.TrimPrefix(name, DefaultRegistry+"/"),
+				layout: layout,
+				desc:   d,
+			})
+		}
+	}
+	return entries, nil
+}
+
+// pinnedManifests returns the keys of the manifests referred to by a pinned tag of entries.
+func pinnedManifests(entries []layoutEntry) map[string]bool {
+	pinned := make(map[string]bool)
+	for _, e := range entries {
+		if isPinned(e.desc) {
+			pinned[e.manifestKey()] = true
+		}
+	}
+	return pinned
+}
+
+// removeEntries deletes the tags of entries whose manifest is not pinned, and returns their
+// number. Deleting a tag deletes its manifest with every tag referring to it, aliases included.
+func removeEntries(ctx context.Context, entries []layoutEntry, pinned map[string]bool) (int, error) {
+	removed := 0
+	for _, e := range entries {
+		if pinned[e.manifestKey()] {
+			continue
+		}
+		removed++
+		if !hasReference(e.layout, e.r.Tag()) {
+			// Already deleted with another tag of its manifest
+			continue
+		}
+		if _, err := e.r.Delete(ctx); err != nil {
+			return removed - 1, err
+		}
+	}
+	return removed, nil
+}
+
+// dirSize returns the total size of the files under dir, or 0 if it does not exist.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == dir {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += fi.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure cache: %w", err)
+	}
+	return size, nil
+}
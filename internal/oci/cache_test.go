@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// setupCacheTest exports app:v1 to an OCI image layout, removes it from local storage, and
+// returns the layout.
+func setupCacheTest(t *testing.T) LayoutReference {
+	t.Helper()
+	setupListTest(t, "ghcr.io/org/app:v1")
+	origCacheDir := cacheDir
+	cacheDir = t.TempDir()
+	t.Cleanup(func() { cacheDir = origCacheDir })
+	ctx := context.Background()
+
+	r, err := NewRepository("ghcr.io/org/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := LayoutReference{Dir: filepath.Join(t.TempDir(), "layout"), Tag: "v1"}
+	store, err := src.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.PushTo(ctx, store, src.Tag); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Delete(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return src
+}
+
+// backdateCache sets the fetch time of every cached tag of the repository name.
+func backdateCache(t *testing.T, name string, fetched time.Time) {
+	t.Helper()
+	path := filepath.Join(layoutDir(cacheDir, name), "index.json")
+	index, err := loadIndexFile(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range index.Manifests {
+		if d.Annotations[v1.AnnotationRefName] != "" {
+			d.Annotations[annotationCachedAt] = fetched.UTC().Format(time.RFC3339)
+		}
+	}
+	data, err := json.Marshal(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	src := setupCacheTest(t)
+	ctx := context.Background()
+	layout, err := src.OpenReadOnly(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRepository("ghcr.io/org/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.UseCache()
+	if err := r.PullFrom(ctx, layout, src.Tag, src.String()); err != nil {
+		t.Fatalf("PullFrom() into the cache failed: %v", err)
+	}
+
+	r, err = NewRepository("ghcr.io/org/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := r.Exists(ctx); err != nil || !exists {
+		t.Fatalf("Exists() = %v, %v, want the cached tag", exists, err)
+	}
+	if !r.InCache() {
+		t.Error("InCache() = false for a cached tag")
+	}
+	if r.CacheExpired(time.Hour) {
+		t.Error("CacheExpired() = true right after fetching")
+	}
+	if got := listTags(t); got != "" {
+		t.Errorf("List() = %q, want cached tags hidden", got)
+	}
+	if _, err := r.Delete(ctx); err == nil {
+		t.Error("Delete() of a cached tag succeeded")
+	}
+
+	stats, err := CacheStats(time.Hour)
+	if err != nil {
+		t.Fatalf("CacheStats() failed: %v", err)
+	}
+	if len(stats.Entries()) != 1 || stats.Entries()[0].Repository != "ghcr.io/org/app" || stats.Entries()[0].Expired || stats.Size() == 0 {
+		t.Errorf("CacheStats() = %+v, size %d", stats.Entries(), stats.Size())
+	}
+
+	if n, err := EvictCache(ctx, time.Hour); err != nil || n != 0 {
+		t.Errorf("EvictCache() = %d, %v, want nothing evicted", n, err)
+	}
+	backdateCache(t, "ghcr.io/org/app", time.Now().Add(-2*time.Hour))
+	if !r.CacheExpired(time.Hour) {
+		t.Error("CacheExpired() = false for an old fetch")
+	}
+	if n, err := EvictCache(ctx, time.Hour); err != nil || n != 1 {
+		t.Errorf("EvictCache() = %d, %v, want the expired tag evicted", n, err)
+	}
+	if exists, _ := r.Exists(ctx); exists {
+		t.Error("Exists() = true after eviction")
+	}
+}
+
+func TestCacheShadowedByStorage(t *testing.T) {
+	src := setupCacheTest(t)
+	ctx := context.Background()
+	layout, err := src.OpenReadOnly(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cached, err := NewRepository("ghcr.io/org/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached.UseCache()
+	if err := cached.PullFrom(ctx, layout, src.Tag, src.String()); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRepository("ghcr.io/org/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.PullFrom(ctx, layout, src.Tag, src.String()); err != nil {
+		t.Fatal(err)
+	}
+	if r.InCache() {
+		t.Error("InCache() = true for a tag in the writable storage")
+	}
+	if r.LayoutPath() != layoutDir(baseDir, "ghcr.io/org/app") {
+		t.Errorf("LayoutPath() = %q, want the writable storage", r.LayoutPath())
+	}
+
+	if _, err := ClearCache(ctx); err != nil {
+		t.Fatalf("ClearCache() failed: %v", err)
+	}
+	if stats, err := CacheStats(time.Hour); err != nil || len(stats.Entries()) != 0 {
+		t.Errorf("CacheStats() after ClearCache() = %v, %v", stats, err)
+	}
+	if got := listTags(t); got != "ghcr.io/org/app:v1" {
+		t.Errorf("List() after ClearCache() = %q", got)
+	}
+}
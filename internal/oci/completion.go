@@ -0,0 +1,210 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"oras.land/oras-go/v2/registry/remote"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/paths"
+)
+
+const (
+	completionCacheFile = "completion-cache.json"
+
+	// localCompletionTTL bounds how long the references of local storage are cached. Changes
+	// made by kubectl-mft invalidate the cache right away, so this only matters for system
+	// storage and manual changes.
+	localCompletionTTL = time.Hour
+	// remoteCompletionTTL bounds how long the tags of a remote repository are cached
+	remoteCompletionTTL = 5 * time.Minute
+)
+
+// completionCache holds the candidates of shell completion, so that completing a tag does not
+// scan every repository of local storage or query the registry on each key press.
+type completionCache struct {
+	// Local maps storage directories to the references stored in them
+	Local map[string]*completionEntry `json:"local,omitempty"`
+	// Remote maps repository names to the tags in their registry
+	Remote map[string]*completionEntry `json:"remote,omitempty"`
+	// Registries maps registry hosts to the names of the repositories in them
+	Registries map[string]*completionEntry `json:"registries,omitempty"`
+}
+
+type completionEntry struct {
+	Generated time.Time `json:"generated"`
+	Values    []string  `json:"values"`
+}
+
+// fresh reports whether the entry exists and is younger than ttl.
+func (e *completionEntry) fresh(ttl time.Duration) bool {
+	return e != nil && time.Since(e.Generated) < ttl
+}
+
+// CompletionReferences returns the references of local storage for shell completion, from the
+// completion cache unless it is stale.
+func CompletionReferences(ctx context.Context) ([]string, error) {
+	c := loadCompletionCache()
+	if e := c.Local[baseDir]; e.fresh(localCompletionTTL) {
+		return e.Values, nil
+	}
+	refs, err := localReferences(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.Local[baseDir] = &completionEntry{Generated: time.Now(), Values: refs}
+	c.save()
+	return refs, nil
+}
+
+// CompletionTags returns the tags of the repository of r in its registry for shell completion,
+// from the completion cache unless it is stale.
+func CompletionTags(ctx context.Context, r *Repository) ([]string, error) {
+	c := loadCompletionCache()
+	if e := c.Remote[r.Name()]; e.fresh(remoteCompletionTTL) {
+		return e.Values, nil
+	}
+	tags, err := r.remoteTags(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	c.Remote[r.Name()] = &completionEntry{Generated: time.Now(), Values: tags}
+	c.save()
+	return tags, nil
+}
+
+// CompletionRepositories returns the names of the repositories in the registry for shell
+// completion, from the completion cache unless it is stale. The registry must serve the
+// catalog API.
+func CompletionRepositories(ctx context.Context, registry string) ([]string, error) {
+	c := loadCompletionCache()
+	if e := c.Registries[registry]; e.fresh(remoteCompletionTTL) {
+		return e.Values, nil
+	}
+	client, err := newAuthClient(registry)
+	if err != nil {
+		return nil, err
+	}
+	reg, err := remote.NewRegistry(registry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry %s: %w", registry, err)
+	}
+	reg.Client = client
+	reg.PlainHTTP = isLocalRegistry(registry)
+	var names []string
+	if err := reg.Repositories(ctx, "", func(repos []string) error {
+		for _, repo := range repos {
+			names = append(names, registry+"/"+repo)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list repositories of %s: %w", registry, err)
+	}
+	c.Registries[registry] = &completionEntry{Generated: time.Now(), Values: names}
+	c.save()
+	return names, nil
+}
+
+// RefreshCompletionCache rebuilds the cached references of local storage and drops the cached
+// remote tags and repositories. It returns the number of cached references.
+func RefreshCompletionCache(ctx context.Context) (int, error) {
+	refs, err := localReferences(ctx)
+	if err != nil {
+		return 0, err
+	}
+	c := loadCompletionCache()
+	c.Local[baseDir] = &completionEntry{Generated: time.Now(), Values: refs}
+	clear(c.Remote)
+	clear(c.Registries)
+	c.save()
+	return len(refs), nil
+}
+
+// invalidateCompletionCache drops the cached references of the writable storage root after
+// it changed.
+func invalidateCompletionCache(root string) {
+	c := loadCompletionCache()
+	if _, ok := c.Local[root]; !ok {
+		return
+	}
+	delete(c.Local, root)
+	c.save()
+}
+
+// localReferences returns the references of every tagged manifest in local storage, sorted.
+func localReferences(ctx context.Context) ([]string, error) {
+	res, err := NewRegistry().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]string, 0, len(res.Items()))
+	for _, i := range res.Items() {
+		refs = append(refs, i.Repository+":"+i.Tag)
+	}
+	slices.Sort(refs)
+	return refs, nil
+}
+
+// loadCompletionCache reads the completion cache. A missing or corrupted cache is treated as
+// empty.
+func loadCompletionCache() *completionCache {
+	c := &completionCache{}
+	if path := completionCachePath(); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			_ = json.Unmarshal(data, c)
+		}
+	}
+	if c.Local == nil {
+		c.Local = map[string]*completionEntry{}
+	}
+	if c.Remote == nil {
+		c.Remote = map[string]*completionEntry{}
+	}
+	if c.Registries == nil {
+		c.Registries = map[string]*completionEntry{}
+	}
+	return c
+}
+
+// save writes the completion cache. The cache is best effort, so failures are ignored.
+func (c *completionCache) save() {
+	path := completionCachePath()
+	if path == "" {
+		return
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), completionCacheFile+".*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err != nil || cerr != nil {
+		return
+	}
+	_ = os.Rename(tmp.Name(), path)
+}
+
+// completionCachePath returns the path of the completion cache, or "" if the cache directory
+// cannot be determined.
+func completionCachePath() string {
+	dir, err := paths.CacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, completionCacheFile)
+}
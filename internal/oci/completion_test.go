@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func completionReferences(t *testing.T) string {
+	t.Helper()
+	refs, err := CompletionReferences(context.Background())
+	if err != nil {
+		t.Fatalf("CompletionReferences() failed: %v", err)
+	}
+	return strings.Join(refs, ",")
+}
+
+func TestCompletionReferences(t *testing.T) {
+	setupListTest(t, "b:v1", "a:v1")
+	ctx := context.Background()
+
+	if got := completionReferences(t); got != "a:v1,b:v1" {
+		t.Fatalf("CompletionReferences() = %s, want a:v1,b:v1", got)
+	}
+
+	// References are served from cache while storage is unchanged
+	c := loadCompletionCache()
+	c.Local[baseDir].Values = append(c.Local[baseDir].Values, "cached:v1")
+	c.save()
+	if got := completionReferences(t); got != "a:v1,b:v1,cached:v1" {
+		t.Errorf("CompletionReferences() = %s, want the cached references", got)
+	}
+
+	// Changes to storage invalidate the cache
+	r, err := NewRepository("c:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(manifestPath, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Save(ctx, manifestPath); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	if got := completionReferences(t); got != "a:v1,b:v1,c:v1" {
+		t.Errorf("CompletionReferences() = %s, want a:v1,b:v1,c:v1 after saving", got)
+	}
+
+	// Refresh rebuilds the references regardless of age and forgets remote tags
+	c = loadCompletionCache()
+	c.Local[baseDir].Values = []string{"cached:v1"}
+	c.Remote["ghcr.io/myorg/app"] = &completionEntry{Generated: time.Now(), Values: []string{"v1"}}
+	c.save()
+	n, err := RefreshCompletionCache(ctx)
+	if err != nil {
+		t.Fatalf("RefreshCompletionCache() failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("RefreshCompletionCache() = %d, want 3", n)
+	}
+	if c := loadCompletionCache(); len(c.Remote) != 0 {
+		t.Errorf("RefreshCompletionCache() kept remote tags %v", c.Remote)
+	}
+
+	// Stale references are rebuilt
+	c = loadCompletionCache()
+	c.Local[baseDir] = &completionEntry{Generated: time.Now().Add(-2 * localCompletionTTL), Values: []string{"stale:v1"}}
+	c.save()
+	if got := completionReferences(t); got != "a:v1,b:v1,c:v1" {
+		t.Errorf("CompletionReferences() = %s, want the stale cache rebuilt", got)
+	}
+}
@@ -0,0 +1,300 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/content/oci"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+// Plan reports the tag and blobs Commit would add to local storage, without committing.
+func (s *Staged) Plan(ctx context.Context) (*mft.PlanResult, error) {
+	src, err := oci.NewFromFS(ctx, os.DirFS(s.LayoutPath()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open staging layout: %w", err)
+	}
+	tag := s.r.ref.ReferenceOrDefault()
+	desc, err := src.Resolve(ctx, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve staged reference %s: %w", tag, err)
+	}
+
+	// Opening the user layout with oci.New would create it, so a missing layout is treated as empty
+	var dst content.ReadOnlyStorage = memory.New()
+	if _, err := os.Stat(s.r.userLayoutPath()); err == nil {
+		if dst, err = oci.NewFromFS(ctx, os.DirFS(s.r.userLayoutPath())); err != nil {
+			return nil, fmt.Errorf("failed to open oci-layout store: %w", err)
+		}
+	}
+
+	nodes, err := planCopy(ctx, src, dst, desc)
+	if err != nil {
+		return nil, err
+	}
+	return mft.NewPlanResult(mft.PlanCreate, []string{s.r.displayName() + ":" + tag}, planBlobs(nodes)), nil
+}
+
+// PlanPush reports the blobs Push would upload to the remote registry. Only existence checks
+// are sent to the registry, nothing is uploaded.
+func (r *Repository) PlanPush(ctx context.Context) (*mft.PlanResult, error) {
+	src, err := r.newReadOnlyStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := src.Resolve(ctx, r.ref.ReferenceOrDefault())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve reference %s: %w", r.ref.ReferenceOrDefault(), err)
+	}
+
+	repo, err := r.newAuthenticatedRepository()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := planCopy(ctx, src, repo, desc)
+	if err != nil {
+		return nil, r.formatCopyError(err)
+	}
+	return mft.NewPlanResult(mft.PlanUpload, []string{r.String()}, planBlobs(nodes)), nil
+}
+
+// PlanDelete reports the tags and blobs Delete would remove from local storage.
+// Like Delete, it returns nil if the tag does not exist.
+func (r *Repository) PlanDelete(ctx context.Context) (*mft.PlanResult, error) {
+	layoutPath := r.userLayoutPath()
+	ref := r.ref.ReferenceOrDefault()
+	if !hasReference(layoutPath, ref) {
+		if r.LayoutPath() != layoutPath {
+			return nil, fmt.Errorf("%s is provided by read-only system storage and cannot be deleted", ref)
+		}
+		return nil, nil
+	}
+
+	store, err := oci.NewFromFS(ctx, os.DirFS(layoutPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open oci-layout store: %w", err)
+	}
+	desc, err := store.Resolve(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve reference %s: %w", ref, err)
+	}
+	if desc.Annotations[annotationAliasOf] != "" {
+		return mft.NewPlanResult(mft.PlanRemove, []string{r.displayName() + ":" + ref}, nil), nil
+	}
+	index, err := loadIndexFile(layoutPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deleting the manifest untags every tag pointing to it
+	var tags []string
+	for _, d := range index.Manifests {
+		if name := d.Annotations[v1.AnnotationRefName]; d.Digest == desc.Digest && name != "" {
+			tags = append(tags, r.displayName()+":"+name)
+		}
+	}
+
+	removed, err := removedManifests(ctx, store, index, desc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Blobs still reachable from a manifest that stays are kept
+	var kept []v1.Descriptor
+	for _, d := range index.Manifests {
+		if !slices.ContainsFunc(removed, func(m v1.Descriptor) bool { return m.Digest == d.Digest }) {
+			kept = append(kept, d)
+		}
+	}
+	keep, err := collectGraph(ctx, store, kept, nil)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []v1.Descriptor
+	if _, err := collectGraph(ctx, store, removed, func(d v1.Descriptor) bool {
+		if keep[d.Digest] {
+			return false
+		}
+		nodes = append(nodes, d)
+		return true
+	}); err != nil {
+		return nil, err
+	}
+
+	res := mft.NewPlanResult(mft.PlanRemove, tags, planBlobs(nodes))
+	if len(kept) == 0 {
+		res.AddNote(fmt.Sprintf("remove repository %s", r.displayName()))
+	}
+	return res, nil
+}
+
+// removedManifests returns the manifests Delete removes along with desc:
+// desc itself, the members of a deleted bundle that are neither tagged nor part of another
+// bundle, and the referrers of all of them.
+func removedManifests(ctx context.Context, store *oci.ReadOnlyStore, index *v1.Index, desc v1.Descriptor) ([]v1.Descriptor, error) {
+	queue := []v1.Descriptor{desc}
+	if desc.MediaType == v1.MediaTypeImageIndex {
+		members, err := indexMembers(ctx, store, desc)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			if slices.ContainsFunc(index.Manifests, func(d v1.Descriptor) bool {
+				return d.Digest == m.Digest && d.Annotations[v1.AnnotationRefName] != ""
+			}) {
+				continue
+			}
+			predecessors, err := store.Predecessors(ctx, m)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get predecessors of %s: %w", m.Digest, err)
+			}
+			if slices.ContainsFunc(predecessors, func(p v1.Descriptor) bool {
+				return p.MediaType == v1.MediaTypeImageIndex && p.Digest != desc.Digest
+			}) {
+				continue
+			}
+			queue = append(queue, m)
+		}
+	}
+
+	var removed []v1.Descriptor
+	seen := make(map[digest.Digest]bool)
+	for len(queue) > 0 {
+		d := queue[0]
+		queue = queue[1:]
+		if seen[d.Digest] {
+			continue
+		}
+		seen[d.Digest] = true
+		removed = append(removed, d)
+
+		// Manifests pointing to a manifest are its referrers, indexes pointing to it are bundles
+		predecessors, err := store.Predecessors(ctx, d)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get predecessors of %s: %w", d.Digest, err)
+		}
+		for _, p := range predecessors {
+			if p.MediaType != v1.MediaTypeImageIndex {
+				queue = append(queue, p)
+			}
+		}
+	}
+	return removed, nil
+}
+
+// planCopy returns the nodes an extended copy of desc from src to dst would transfer: the graphs
+// of desc and of everything referring to it, without the subgraphs dst already has.
+func planCopy(ctx context.Context, src content.ReadOnlyGraphStorage, dst content.ReadOnlyStorage, desc v1.Descriptor) ([]v1.Descriptor, error) {
+	roots, err := findRoots(ctx, src, desc)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []v1.Descriptor
+	var existsErr error
+	if _, err := collectGraph(ctx, src, roots, func(d v1.Descriptor) bool {
+		if existsErr != nil {
+			return false
+		}
+		exists, err := dst.Exists(ctx, d)
+		if err != nil {
+			existsErr = fmt.Errorf("failed to check %s: %w", d.Digest, err)
+			return false
+		}
+		if exists {
+			return false
+		}
+		nodes = append(nodes, d)
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	if existsErr != nil {
+		return nil, existsErr
+	}
+	return nodes, nil
+}
+
+// findRoots returns the nodes without predecessors that desc can be reached from, like
+// oras.ExtendedCopy does to find the referrers to copy along with desc.
+func findRoots(ctx context.Context, store content.ReadOnlyGraphStorage, desc v1.Descriptor) ([]v1.Descriptor, error) {
+	var roots []v1.Descriptor
+	seen := make(map[digest.Digest]bool)
+	queue := []v1.Descriptor{desc}
+	for len(queue) > 0 {
+		d := queue[0]
+		queue = queue[1:]
+		if seen[d.Digest] {
+			continue
+		}
+		seen[d.Digest] = true
+
+		predecessors, err := store.Predecessors(ctx, d)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get predecessors of %s: %w", d.Digest, err)
+		}
+		if len(predecessors) == 0 {
+			roots = append(roots, d)
+		}
+		queue = append(queue, predecessors...)
+	}
+	return roots, nil
+}
+
+// collectGraph walks the graphs of roots and returns the digests of all visited nodes.
+// If visit is not nil, it is called once per node and the successors of a node are only
+// walked if it returns true.
+func collectGraph(ctx context.Context, store content.Fetcher, roots []v1.Descriptor, visit func(v1.Descriptor) bool) (map[digest.Digest]bool, error) {
+	seen := make(map[digest.Digest]bool)
+	var walk func(d v1.Descriptor) error
+	walk = func(d v1.Descriptor) error {
+		if seen[d.Digest] {
+			return nil
+		}
+		seen[d.Digest] = true
+		if visit != nil && !visit(d) {
+			return nil
+		}
+
+		successors, err := content.Successors(ctx, store, d)
+		if err != nil {
+			return fmt.Errorf("failed to get successors of %s: %w", d.Digest, err)
+		}
+		for _, s := range successors {
+			if err := walk(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, root := range roots {
+		if err := walk(root); err != nil {
+			return nil, err
+		}
+	}
+	return seen, nil
+}
+
+// planBlobs converts nodes to the blobs of a plan.
+func planBlobs(nodes []v1.Descriptor) []*mft.PlanBlob {
+	blobs := make([]*mft.PlanBlob, 0, len(nodes))
+	for _, d := range nodes {
+		blobs = append(blobs, &mft.PlanBlob{
+			Digest:    d.Digest.String(),
+			MediaType: d.MediaType,
+			Size:      d.Size,
+		})
+	}
+	return blobs
+}
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+// blobFiles returns the digests of all blobs in the layout.
+func blobFiles(t *testing.T, layoutPath string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(filepath.Join(layoutPath, "blobs", "sha256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var digests []string
+	for _, e := range entries {
+		digests = append(digests, "sha256:"+e.Name())
+	}
+	return digests
+}
+
+func planDigests(res *mft.PlanResult) []string {
+	var digests []string
+	for _, b := range res.Blobs() {
+		digests = append(digests, b.Digest)
+	}
+	slices.Sort(digests)
+	return digests
+}
+
+func TestPlanDelete(t *testing.T) {
+	setupListTest(t, "app:v1", "app:v2")
+	ctx := context.Background()
+
+	r, err := NewRepository("app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := blobFiles(t, r.LayoutPath())
+
+	res, err := r.PlanDelete(ctx)
+	if err != nil {
+		t.Fatalf("PlanDelete() failed: %v", err)
+	}
+	if got := res.Tags(); !slices.Equal(got, []string{"app:v1"}) {
+		t.Errorf("Tags() = %v, want [app:v1]", got)
+	}
+	// The content blobs are shared with v2, only the manifest is removed
+	if got := len(res.Blobs()); got != 1 {
+		t.Errorf("len(Blobs()) = %d, want 1", got)
+	}
+	if got := blobFiles(t, r.LayoutPath()); !slices.Equal(got, before) {
+		t.Fatalf("PlanDelete() changed local storage: %v, want %v", got, before)
+	}
+
+	if _, err := r.Delete(ctx); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	var removed []string
+	after := blobFiles(t, r.LayoutPath())
+	for _, d := range before {
+		if !slices.Contains(after, d) {
+			removed = append(removed, d)
+		}
+	}
+	if want := planDigests(res); !slices.Equal(removed, want) {
+		t.Errorf("Delete() removed %v, PlanDelete() reported %v", removed, want)
+	}
+
+	missing, err := NewRepository("app:v9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := missing.PlanDelete(ctx); err != nil || res != nil {
+		t.Errorf("PlanDelete() of a missing tag = %v, %v, want nil, nil", res, err)
+	}
+}
+
+func TestStagedPlan(t *testing.T) {
+	setupListTest(t, "app:v1")
+	ctx := context.Background()
+
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(manifestPath, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRepository("app:v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := blobFiles(t, r.LayoutPath())
+
+	s, err := r.Stage(ctx, manifestPath, "")
+	if err != nil {
+		t.Fatalf("Stage() failed: %v", err)
+	}
+	defer s.Discard()
+	res, err := s.Plan(ctx)
+	if err != nil {
+		t.Fatalf("Plan() failed: %v", err)
+	}
+	if got := res.Tags(); !slices.Equal(got, []string{"app:v2"}) {
+		t.Errorf("Tags() = %v, want [app:v2]", got)
+	}
+
+	if err := s.Commit(ctx); err != nil {
+		t.Fatalf("Commit() failed: %v", err)
+	}
+	var added []string
+	for _, d := range blobFiles(t, r.LayoutPath()) {
+		if !slices.Contains(before, d) {
+			added = append(added, d)
+		}
+	}
+	if want := planDigests(res); !slices.Equal(added, want) {
+		t.Errorf("Commit() added %v, Plan() reported %v", added, want)
+	}
+}
+
+func TestPlanCopy(t *testing.T) {
+	setupListTest(t, "app:v1")
+	ctx := context.Background()
+
+	r, err := NewRepository("app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := r.newOCILayoutStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := src.Resolve(ctx, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := oci.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := planCopy(ctx, src, dst, desc)
+	if err != nil {
+		t.Fatalf("planCopy() failed: %v", err)
+	}
+	if got, want := len(nodes), len(blobFiles(t, r.LayoutPath())); got != want {
+		t.Errorf("planCopy() to an empty target = %d nodes, want %d", got, want)
+	}
+
+	if _, err := oras.ExtendedCopy(ctx, src, "v1", dst, "v1", oras.ExtendedCopyOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	nodes, err = planCopy(ctx, src, dst, desc)
+	if err != nil {
+		t.Fatalf("planCopy() failed: %v", err)
+	}
+	if len(nodes) != 0 {
+		t.Errorf("planCopy() to an up-to-date target = %v, want none", nodes)
+	}
+}
@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/metadb"
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+// eventsDir holds the append-only event logs of the repositories in the writable storage,
+// one JSON Lines file per repository. Like the metadata index, the dot keeps it apart from
+// repository directories, and the logs outlive the deletion of their repository.
+const eventsDir = ".events"
+
+// eventLogPath returns the event log of the repository.
+func (r *Repository) eventLogPath() string {
+	return layoutDir(filepath.Join(r.storageDir(), eventsDir), r.Name()) + ".jsonl"
+}
+
+// RecordEvent appends an event of the given type for the tag, with the digest it currently
+// resolves to, to the event log of the repository. Operations changing local storage call it;
+// callers that modify a layout directly, such as signing, must call it too.
+func (r *Repository) RecordEvent(ctx context.Context, typ string) error {
+	d, err := r.Digest(ctx)
+	if err != nil {
+		return err
+	}
+	return r.recordEvent(typ, d)
+}
+
+func (r *Repository) recordEvent(typ, digest string) error {
+	if r.cached {
+		return nil
+	}
+	name, err := getRepoName(r.storageDir(), r.userLayoutPath())
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	data, err := json.Marshal(&mft.Event{
+		Time:       now,
+		Type:       typ,
+		Repository: name,
+		Tag:        r.ref.ReferenceOrDefault(),
+		Digest:     digest,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	path := r.eventLogPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create event log directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	// A single write of a whole line, so concurrent writers do not interleave
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record %s event: %w", typ, err)
+	}
+
+	if !metadataIndexEnabled(r.storageDir()) {
+		return nil
+	}
+	db, err := metadb.Open(metadataIndexPath(r.storageDir()))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := db.AddEvent(metadb.Event{Time: now, Data: data}); err != nil {
+		return fmt.Errorf("failed to update metadata index, run 'kubectl mft reindex': %w", err)
+	}
+	return nil
+}
+
+// Events returns the events of all repositories in the writable storage recorded after since,
+// oldest first. A zero since returns all events. They are read from the metadata index if it
+// is enabled, otherwise from the event logs.
+func Events(since time.Time) (*mft.EventsResult, error) {
+	var events []*mft.Event
+	var err error
+	if MetadataIndexEnabled() {
+		events, err = indexedEvents(since)
+	} else {
+		events, err = loggedEvents(since)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mft.NewEventsResult(events), nil
+}
+
+// indexedEvents returns the events recorded after since from the metadata index, oldest first.
+func indexedEvents(since time.Time) ([]*mft.Event, error) {
+	db, err := metadb.Open(MetadataIndexPath())
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	data, err := db.Events(since)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*mft.Event, 0, len(data))
+	for _, d := range data {
+		var e mft.Event
+		if err := json.Unmarshal(d, &e); err != nil {
+			return nil, fmt.Errorf("corrupted event in metadata index, run 'kubectl mft reindex': %w", err)
+		}
+		events = append(events, &e)
+	}
+	return events, nil
+}
+
+// loggedEvents returns the events recorded after since from the event logs, oldest first.
+func loggedEvents(since time.Time) ([]*mft.Event, error) {
+	root := filepath.Join(baseDir, eventsDir)
+	var events []*mft.Event
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && path == root {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".jsonl") {
+			return nil
+		}
+		logEvents, err := readEventLog(path, since)
+		if err != nil {
+			return err
+		}
+		events = append(events, logEvents...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event logs: %w", err)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events, nil
+}
+
+// readEventLog returns the events of the log recorded after since. Lines that cannot be parsed,
+// such as one truncated by an interrupted write, are skipped.
+func readEventLog(path string, since time.Time) ([]*mft.Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []*mft.Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e mft.Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if e.Time.After(since) {
+			events = append(events, &e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return events, nil
+}
@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+func eventStrings(t *testing.T, since time.Time) []string {
+	t.Helper()
+	res, err := Events(since)
+	if err != nil {
+		t.Fatalf("Events() failed: %v", err)
+	}
+	var got []string
+	for _, e := range res.Items() {
+		if e.Digest == "" {
+			t.Errorf("event %s of %s:%s has no digest", e.Type, e.Repository, e.Tag)
+		}
+		got = append(got, e.Type+" "+e.Repository+":"+e.Tag)
+	}
+	return got
+}
+
+func TestEvents(t *testing.T) {
+	start := time.Now()
+	setupListTest(t, "a:v1", "ghcr.io/team/b:v1")
+	ctx := context.Background()
+
+	r, err := NewRepository("a:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Copy(ctx, "a:v2", mft.CopyOptions{}); err != nil {
+		t.Fatalf("Copy() failed: %v", err)
+	}
+	mid := time.Now()
+	if _, err := r.Delete(ctx); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	want := []string{"packed a:v1", "packed ghcr.io/team/b:v1", "copied a:v2", "deleted a:v1"}
+	if got := eventStrings(t, time.Time{}); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Events() = %v, want %v", got, want)
+	}
+	if got := eventStrings(t, start.Add(-time.Second)); len(got) != len(want) {
+		t.Errorf("Events(before start) = %v, want %v", got, want)
+	}
+	if got := eventStrings(t, mid); strings.Join(got, ",") != "deleted a:v1" {
+		t.Errorf("Events(mid) = %v, want [deleted a:v1]", got)
+	}
+	if got := eventStrings(t, time.Now()); len(got) != 0 {
+		t.Errorf("Events(now) = %v, want none", got)
+	}
+
+	// The log outlives the tags of the repository
+	if exists, err := r.Exists(ctx); err != nil || exists {
+		t.Fatalf("Exists() = %v, %v, want false", exists, err)
+	}
+	if _, err := os.Stat(r.eventLogPath()); err != nil {
+		t.Errorf("event log removed: %v", err)
+	}
+
+	// Event logs are not listed as repositories
+	if got := listTags(t); got != "ghcr.io/team/b:v1" {
+		t.Errorf("List() = %q, want ghcr.io/team/b:v1", got)
+	}
+}
+
+func TestEvents_SkipsTruncatedLine(t *testing.T) {
+	setupListTest(t, "a:v1")
+
+	r, err := NewRepository("a:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(r.eventLogPath(), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"time":"2026-`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if got := eventStrings(t, time.Time{}); strings.Join(got, ",") != "packed a:v1" {
+		t.Errorf("Events() = %v, want [packed a:v1]", got)
+	}
+}
+
+func TestEvents_NoLog(t *testing.T) {
+	setupListTest(t)
+	if got := eventStrings(t, time.Time{}); len(got) != 0 {
+		t.Errorf("Events() = %v, want none", got)
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, eventsDir)); !os.IsNotExist(err) {
+		t.Errorf("Events() created the event log directory: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+// GCOptions configures GC
+type GCOptions struct {
+	// OlderThan, if positive, also collects the tags of the writable storage last packed,
+	// pulled, or copied to more than OlderThan ago. Otherwise only the cache is collected.
+	OlderThan time.Duration
+	// DryRun reports what would be removed without removing it
+	DryRun bool
+}
+
+// GC removes every cached tag, expired or not, and with OlderThan the old tags of the writable
+// storage. Pinned tags, and the tags sharing their manifest, are kept. System overlays are
+// never modified.
+func GC(ctx context.Context, opts GCOptions) (*mft.GCResult, error) {
+	res := mft.NewGCResult(opts.DryRun)
+
+	cached, err := cacheEntries()
+	if err != nil {
+		return nil, err
+	}
+	if err := collect(ctx, res, cached, pinnedManifests(cached), opts.DryRun); err != nil {
+		return nil, err
+	}
+	if opts.OlderThan <= 0 {
+		return res, nil
+	}
+
+	stored, err := layoutEntries(baseDir, false)
+	if err != nil {
+		return nil, err
+	}
+	times, err := storedTimes()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-opts.OlderThan)
+	var old []layoutEntry
+	for _, e := range stored {
+		created, ok := times[e.name+":"+e.r.Tag()]
+		if !ok {
+			// Tags stored before events were recorded fall back to the time of their manifest
+			if created, _, err = getManifestMetadata(e.layout, e.desc.Digest); err != nil {
+				return nil, fmt.Errorf("failed to get metadata for %s: %w", e.r, err)
+			}
+		}
+		if created.Before(cutoff) {
+			old = append(old, e)
+		}
+	}
+	if err := collect(ctx, res, old, pinnedManifests(stored), opts.DryRun); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// storedTimes returns the time each tag of the writable storage was last packed, pulled, or
+// copied to, keyed by repository and tag as listed. The time of the manifest blob does not
+// tell, as a tag pulled or copied today may reuse a blob stored long ago.
+func storedTimes() (map[string]time.Time, error) {
+	events, err := Events(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	times := make(map[string]time.Time)
+	for _, e := range events.Items() {
+		switch e.Type {
+		case mft.EventPacked, mft.EventPulled, mft.EventCopied:
+			times[e.Repository+":"+e.Tag] = e.Time
+		}
+	}
+	return times, nil
+}
+
+// collect records entries in res, as kept if their manifest is pinned, and removes the others
+// unless dryRun.
+func collect(ctx context.Context, res *mft.GCResult, entries []layoutEntry, pinned map[string]bool, dryRun bool) error {
+	for _, e := range entries {
+		status := mft.GCRemoved
+		if pinned[e.manifestKey()] {
+			status = mft.GCPinned
+		}
+		res.Add(&mft.GCEntry{
+			Repository: e.name,
+			Tag:        e.r.Tag(),
+			Digest:     e.desc.Digest.String(),
+			Cached:     e.r.cached,
+			Status:     status,
+		})
+	}
+	if dryRun {
+		return nil
+	}
+	_, err := removeEntries(ctx, entries, pinned)
+	return err
+}
@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+// backdateStorage sets the modification time of every file of the writable storage, and the
+// time of every recorded event.
+func backdateStorage(t *testing.T, mtime time.Time) {
+	t.Helper()
+	if err := filepath.WalkDir(baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if strings.HasSuffix(path, ".jsonl") {
+			if err := backdateEvents(path, mtime); err != nil {
+				return err
+			}
+		}
+		return os.Chtimes(path, mtime, mtime)
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// backdateEvents sets the time of every event of the event log at path.
+func backdateEvents(path string, at time.Time) error {
+	events, err := readEventLog(path, time.Time{})
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, e := range events {
+		e.Time = at
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(data, '\n'))
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+func pin(t *testing.T, tag string) {
+	t.Helper()
+	r, err := NewRepository(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetPinned(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func gcStatuses(res *mft.GCResult) map[string]string {
+	statuses := make(map[string]string)
+	for _, e := range res.Entries() {
+		statuses[e.Repository+":"+e.Tag] = e.Status
+	}
+	return statuses
+}
+
+func TestGC_OlderThan(t *testing.T) {
+	setupListTest(t, "ghcr.io/org/app:v1", "ghcr.io/org/other:v1")
+	ctx := context.Background()
+	target, err := NewRepository("ghcr.io/org/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alias, err := NewRepository("ghcr.io/org/app:stable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := alias.SetAlias(ctx, target); err != nil {
+		t.Fatal(err)
+	}
+	pin(t, "ghcr.io/org/app:v1")
+
+	res, err := GC(ctx, GCOptions{})
+	if err != nil {
+		t.Fatalf("GC() failed: %v", err)
+	}
+	if len(res.Entries()) != 0 {
+		t.Errorf("GC() without OlderThan = %v, want the storage untouched", gcStatuses(res))
+	}
+
+	backdateStorage(t, time.Now().Add(-48*time.Hour))
+	res, err = GC(ctx, GCOptions{OlderThan: 24 * time.Hour, DryRun: true})
+	if err != nil {
+		t.Fatalf("GC(DryRun) failed: %v", err)
+	}
+	want := map[string]string{
+		"ghcr.io/org/app:v1":     mft.GCPinned,
+		"ghcr.io/org/app:stable": mft.GCPinned,
+		"ghcr.io/org/other:v1":   mft.GCRemoved,
+	}
+	if got := gcStatuses(res); !maps.Equal(got, want) {
+		t.Errorf("GC(DryRun) = %v, want %v", got, want)
+	}
+	if got := listTags(t); got != "ghcr.io/org/app:stable,ghcr.io/org/app:v1,ghcr.io/org/other:v1" {
+		t.Errorf("List() after a dry run = %q", got)
+	}
+
+	if _, err := GC(ctx, GCOptions{OlderThan: 24 * time.Hour}); err != nil {
+		t.Fatalf("GC() failed: %v", err)
+	}
+	if got := listTags(t); got != "ghcr.io/org/app:stable,ghcr.io/org/app:v1" {
+		t.Errorf("List() after GC() = %q, want the pinned tag and its alias kept", got)
+	}
+}
+
+func TestGC_OlderThanPulledAgain(t *testing.T) {
+	setupListTest(t, "ghcr.io/org/app:v1", "ghcr.io/org/other:v1")
+	ctx := context.Background()
+	backdateStorage(t, time.Now().Add(-48*time.Hour))
+
+	// Copying the old manifest to a tag today records the tag as new, although its blobs are old
+	src, err := NewRepository("ghcr.io/org/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Copy(ctx, "ghcr.io/org/app:v2", mft.CopyOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := GC(ctx, GCOptions{OlderThan: 24 * time.Hour, DryRun: true})
+	if err != nil {
+		t.Fatalf("GC(DryRun) failed: %v", err)
+	}
+	want := map[string]string{
+		"ghcr.io/org/app:v1":   mft.GCRemoved,
+		"ghcr.io/org/other:v1": mft.GCRemoved,
+	}
+	if got := gcStatuses(res); !maps.Equal(got, want) {
+		t.Errorf("GC(DryRun) = %v, want %v", got, want)
+	}
+}
+
+func TestGC_Cache(t *testing.T) {
+	src := setupCacheTest(t)
+	ctx := context.Background()
+	layout, err := src.OpenReadOnly(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{"ghcr.io/org/app:v1", "ghcr.io/org/app:v2"} {
+		r, err := NewRepository(tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.UseCache()
+		if err := r.PullFrom(ctx, layout, src.Tag, src.String()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other, err := NewRepository("ghcr.io/org/other:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.UseCache()
+	if err := other.PullFrom(ctx, layout, src.Tag, src.String()); err != nil {
+		t.Fatal(err)
+	}
+	pin(t, "ghcr.io/org/app:v1")
+
+	res, err := GC(ctx, GCOptions{})
+	if err != nil {
+		t.Fatalf("GC() failed: %v", err)
+	}
+	// app:v2 shares the manifest of the pinned app:v1
+	want := map[string]string{
+		"ghcr.io/org/app:v1":   mft.GCPinned,
+		"ghcr.io/org/app:v2":   mft.GCPinned,
+		"ghcr.io/org/other:v1": mft.GCRemoved,
+	}
+	if got := gcStatuses(res); !maps.Equal(got, want) {
+		t.Errorf("GC() = %v, want %v", got, want)
+	}
+	stats, err := CacheStats(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Entries()) != 2 {
+		t.Errorf("CacheStats() after GC() has %d entries, want the 2 tags of the pinned manifest", len(stats.Entries()))
+	}
+}
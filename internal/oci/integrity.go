@@ -0,0 +1,238 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+// VerifyContent recomputes the digest of every blob reachable from the tag, including
+// signature referrers, and compares it with the descriptor digest.
+// If repair is true and corrupted blobs are found, they are removed and re-pulled from the registry.
+func (r *Repository) VerifyContent(ctx context.Context, repair bool) (*mft.VerifyContentResult, error) {
+	res, manifests, err := r.verifyContent()
+	if err != nil {
+		return nil, err
+	}
+	if !repair || res.OK() {
+		return res, nil
+	}
+
+	if r.LayoutPath() != r.userLayoutPath() {
+		return nil, fmt.Errorf("cannot repair %s: it is provided by read-only system storage", r.ref.ReferenceOrDefault())
+	}
+	if r.ref.Registry == DefaultRegistry {
+		return nil, fmt.Errorf("cannot repair %s: locally packed manifests have no remote source", r.ref.ReferenceOrDefault())
+	}
+
+	// The tag is pulled before any blob is removed, so that a failed pull keeps the content.
+	// Nothing is seeded from local storage, every blob is downloaded again.
+	s, err := r.stagePull(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-pull corrupted blobs: %w", err)
+	}
+	defer s.Discard()
+
+	// Manifest blobs are removed as well, otherwise the copy skips their subtrees as already present
+	paths := make([]string, 0, len(res.Issues())+len(manifests))
+	for _, issue := range res.Issues() {
+		paths = append(paths, issue.Path)
+	}
+	for _, m := range manifests {
+		paths = append(paths, blobPath(r.LayoutPath(), m.Digest))
+	}
+	for _, p := range paths {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove blob %s: %w", p, err)
+		}
+	}
+
+	if err := s.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to restore corrupted blobs: %w", err)
+	}
+
+	repaired, _, err := r.verifyContent()
+	if err != nil {
+		return nil, err
+	}
+	repaired.MarkRepaired(len(res.Issues()))
+	return repaired, nil
+}
+
+// verifyContent verifies the blobs of the tag and its referrers.
+// It reads index.json directly so that a corrupted manifest blob is reported rather than
+// preventing the layout from being opened. It returns the manifests that were checked.
+func (r *Repository) verifyContent() (*mft.VerifyContentResult, []v1.Descriptor, error) {
+	layoutPath := r.LayoutPath()
+	tag := r.ref.ReferenceOrDefault()
+
+	index, err := loadIndexFile(layoutPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var target *v1.Descriptor
+	for i, d := range index.Manifests {
+		if d.Annotations[v1.AnnotationRefName] == tag || d.Digest.String() == tag {
+			target = &index.Manifests[i]
+			break
+		}
+	}
+	if target == nil {
+		return nil, nil, fmt.Errorf("failed to resolve reference %s: not found", tag)
+	}
+
+	res := mft.NewVerifyContentResult(r.Name(), tag)
+	manifests := []v1.Descriptor{*target}
+	if err := verifyManifestTree(layoutPath, *target, res); err != nil {
+		return nil, nil, err
+	}
+
+	// Signatures are stored as referrers whose subject is the tagged manifest
+	for _, d := range index.Manifests {
+		if d.Digest == target.Digest {
+			continue
+		}
+		m, err := readManifestBlob(layoutPath, d.Digest)
+		if err != nil || m.Subject == nil || m.Subject.Digest != target.Digest {
+			continue
+		}
+		manifests = append(manifests, d)
+		if err := verifyManifestTree(layoutPath, d, res); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return res, manifests, nil
+}
+
+// verifyManifestTree verifies a manifest blob and, if it is intact, the config and layer blobs it references.
+// For an image index, such as a bundle, the manifests it references are verified recursively.
+func verifyManifestTree(layoutPath string, desc v1.Descriptor, res *mft.VerifyContentResult) error {
+	if !verifyBlob(layoutPath, desc, res) {
+		return nil
+	}
+
+	if desc.MediaType == v1.MediaTypeImageIndex {
+		data, err := os.ReadFile(blobPath(layoutPath, desc.Digest))
+		if err != nil {
+			return fmt.Errorf("failed to read index blob %s: %w", desc.Digest, err)
+		}
+		var index v1.Index
+		if err := json.Unmarshal(data, &index); err != nil {
+			return fmt.Errorf("failed to unmarshal index %s: %w", desc.Digest, err)
+		}
+		for _, d := range index.Manifests {
+			if err := verifyManifestTree(layoutPath, d, res); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	m, err := readManifestBlob(layoutPath, desc.Digest)
+	if err != nil {
+		return err
+	}
+
+	verifyBlob(layoutPath, m.Config, res)
+	for _, l := range m.Layers {
+		verifyBlob(layoutPath, l, res)
+	}
+	return nil
+}
+
+// verifyBlob checks the size and digest of a single blob, recording an issue on mismatch.
+// It reports whether the blob is intact.
+func verifyBlob(layoutPath string, desc v1.Descriptor, res *mft.VerifyContentResult) bool {
+	path := blobPath(layoutPath, desc.Digest)
+
+	// The empty config blob is not always written to the layout
+	if desc.Digest == v1.DescriptorEmptyJSON.Digest {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return true
+		}
+	}
+
+	reason, err := checkBlob(path, desc)
+	if err != nil {
+		reason = err.Error()
+	}
+	if reason != "" {
+		res.AddIssue(desc.Digest.String(), path, reason)
+		return false
+	}
+	res.AddChecked()
+	return true
+}
+
+// checkBlob returns a non-empty reason if the blob at path does not match desc.
+func checkBlob(path string, desc v1.Descriptor) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "missing", nil
+		}
+		return "", fmt.Errorf("failed to open blob: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat blob: %w", err)
+	}
+	if info.Size() != desc.Size {
+		return fmt.Sprintf("size mismatch: expected %d bytes, got %d", desc.Size, info.Size()), nil
+	}
+
+	got, err := desc.Digest.Algorithm().FromReader(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute digest: %w", err)
+	}
+	if got != desc.Digest {
+		return fmt.Sprintf("digest mismatch: got %s", got), nil
+	}
+	return "", nil
+}
+
+func readManifestBlob(layoutPath string, d digest.Digest) (*v1.Manifest, error) {
+	data, err := os.ReadFile(blobPath(layoutPath, d))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest blob %s: %w", d, err)
+	}
+
+	var m v1.Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal manifest %s: %w", d, err)
+	}
+	return &m, nil
+}
+
+func loadIndexFile(layoutPath string) (*v1.Index, error) {
+	indexData, err := os.ReadFile(filepath.Join(layoutPath, "index.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index.json: %w", err)
+	}
+
+	var index v1.Index
+	if err := json.Unmarshal(indexData, &index); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal index.json: %w", err)
+	}
+	return &index, nil
+}
+
+// blobPath returns the path of a blob within an OCI layout directory.
+func blobPath(layoutPath string, d digest.Digest) string {
+	return filepath.Join(layoutPath, "blobs", d.Algorithm().String(), d.Encoded())
+}
@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+)
+
+const (
+	// KeySetArtifactType is the artifact type of key distribution artifacts, which publish the
+	// public keys of a team for verifiers, see signature.AnnotationKeysRef
+	KeySetArtifactType = "application/vnd.kubectl-mft.keys.v1"
+	// keySetKeyMediaType is the media type of a PEM-encoded public key layer, named by its title
+	keySetKeyMediaType = "application/vnd.kubectl-mft.key.v1+pem"
+	// maxKeySize bounds the size of a fetched key layer
+	maxKeySize = 64 * 1024
+)
+
+// PublicKeyFile is a named PEM-encoded public key of a key distribution artifact.
+type PublicKeyFile struct {
+	Name string
+	PEM  []byte
+}
+
+// PushKeySet publishes keys as a key distribution artifact under the tag of r in the registry
+// and returns the digest of its manifest.
+func (r *Repository) PushKeySet(ctx context.Context, keys []PublicKeyFile) (string, error) {
+	if r.ref.Registry == DefaultRegistry {
+		return "", fmt.Errorf("%s is stored locally only, key sets are published to a registry", r.Name())
+	}
+	if len(keys) == 0 {
+		return "", fmt.Errorf("no public keys to publish")
+	}
+	repo, err := r.newAuthenticatedRepository()
+	if err != nil {
+		return "", err
+	}
+
+	layers := make([]v1.Descriptor, 0, len(keys))
+	for _, k := range keys {
+		layer := content.NewDescriptorFromBytes(keySetKeyMediaType, k.PEM)
+		if err := repo.Push(ctx, layer, bytes.NewReader(k.PEM)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+			return "", r.formatCopyError(err)
+		}
+		layer.Annotations = map[string]string{v1.AnnotationTitle: k.Name}
+		layers = append(layers, layer)
+	}
+	desc, err := oras.PackManifest(ctx, repo, oras.PackManifestVersion1_1, KeySetArtifactType, oras.PackManifestOptions{
+		Layers: layers,
+	})
+	if err != nil {
+		return "", r.formatCopyError(err)
+	}
+	if err := repo.Tag(ctx, desc, r.ref.ReferenceOrDefault()); err != nil {
+		return "", r.formatCopyError(err)
+	}
+	return desc.Digest.String(), nil
+}
+
+// FetchKeySet returns the public keys of the key distribution artifact tagged by r in the registry.
+func (r *Repository) FetchKeySet(ctx context.Context) ([]PublicKeyFile, error) {
+	repo, err := r.newAuthenticatedRepository()
+	if err != nil {
+		return nil, err
+	}
+	_, m, err := fetchManifest(ctx, repo, r.ref.ReferenceOrDefault())
+	if err != nil {
+		return nil, r.formatCopyError(err)
+	}
+	if m.ArtifactType != KeySetArtifactType {
+		return nil, fmt.Errorf("%s is not a key distribution artifact (artifact type %q)", r.ref, m.ArtifactType)
+	}
+
+	var keys []PublicKeyFile
+	for _, layer := range m.Layers {
+		if layer.MediaType != keySetKeyMediaType {
+			continue
+		}
+		if layer.Size > maxKeySize {
+			return nil, fmt.Errorf("key %s of %s exceeds %d bytes", layer.Digest, r.ref, maxKeySize)
+		}
+		data, err := content.FetchAll(ctx, repo, layer)
+		if err != nil {
+			return nil, r.formatCopyError(err)
+		}
+		keys = append(keys, PublicKeyFile{Name: layer.Annotations[v1.AnnotationTitle], PEM: data})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s holds no public keys", r.ref)
+	}
+	return keys, nil
+}
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestKeySet(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	ctx := context.Background()
+	_, host := newFakeRegistry(t)
+	setupListTest(t, host+"/team/app:v1")
+
+	keys := []PublicKeyFile{
+		{Name: "release", PEM: []byte("-----BEGIN PUBLIC KEY-----\nrelease\n-----END PUBLIC KEY-----\n")},
+		{Name: "ci", PEM: []byte("-----BEGIN PUBLIC KEY-----\nci\n-----END PUBLIC KEY-----\n")},
+	}
+	r, err := NewRepository(host + "/team/keys:v3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.PushKeySet(ctx, keys); err != nil {
+		t.Fatalf("PushKeySet() failed: %v", err)
+	}
+
+	got, err := r.FetchKeySet(ctx)
+	if err != nil {
+		t.Fatalf("FetchKeySet() failed: %v", err)
+	}
+	if len(got) != len(keys) {
+		t.Fatalf("FetchKeySet() returned %d keys, want %d", len(got), len(keys))
+	}
+	for i := range keys {
+		if got[i].Name != keys[i].Name || !bytes.Equal(got[i].PEM, keys[i].PEM) {
+			t.Errorf("FetchKeySet()[%d] = %s %q, want %s %q", i, got[i].Name, got[i].PEM, keys[i].Name, keys[i].PEM)
+		}
+	}
+
+	// Other artifacts are not key sets
+	app, err := NewRepository(host + "/team/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Push(ctx); err != nil {
+		t.Fatalf("Push() failed: %v", err)
+	}
+	if _, err := app.FetchKeySet(ctx); err == nil || !strings.Contains(err.Error(), "not a key distribution artifact") {
+		t.Errorf("FetchKeySet() of a manifest error = %v, want not a key distribution artifact", err)
+	}
+
+	local, err := NewRepository("keys:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := local.PushKeySet(ctx, keys); err == nil {
+		t.Error("PushKeySet() to local storage succeeded, want error")
+	}
+}
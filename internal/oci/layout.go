@@ -0,0 +1,206 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/errdef"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+// LayoutScheme prefixes references to a tag in an OCI image layout directory, such as
+// "oci-layout:///mnt/usb/layout:v1.0.0", used to exchange manifests without a registry.
+const LayoutScheme = "oci-layout://"
+
+// LayoutReference is a tag in an OCI image layout directory.
+type LayoutReference struct {
+	Dir string
+	// Tag is the tag in the layout, empty if the reference has none
+	Tag string
+}
+
+// IsLayoutReference reports whether ref refers to an OCI image layout directory.
+func IsLayoutReference(ref string) bool {
+	return strings.HasPrefix(ref, LayoutScheme)
+}
+
+// ParseLayoutReference parses a reference of the form "oci-layout://<dir>[:<tag>]". The tag
+// follows the last colon after the last path separator, so that "oci-layout://C:\layout"
+// has no tag.
+func ParseLayoutReference(ref string) (LayoutReference, error) {
+	s, ok := strings.CutPrefix(ref, LayoutScheme)
+	if !ok {
+		return LayoutReference{}, fmt.Errorf("%q is not an OCI image layout reference, expected %s<dir>[:<tag>]", ref, LayoutScheme)
+	}
+	var l LayoutReference
+	l.Dir = s
+	if i := strings.LastIndex(s, ":"); i > strings.LastIndexAny(s, `/\`) {
+		l.Dir, l.Tag = s[:i], s[i+1:]
+		if l.Tag == "" {
+			return LayoutReference{}, fmt.Errorf("invalid OCI image layout reference %q: empty tag", ref)
+		}
+	}
+	if l.Dir == "" {
+		return LayoutReference{}, fmt.Errorf("invalid OCI image layout reference %q: empty directory", ref)
+	}
+	return l, nil
+}
+
+func (l LayoutReference) String() string {
+	if l.Tag == "" {
+		return LayoutScheme + l.Dir
+	}
+	return LayoutScheme + l.Dir + ":" + l.Tag
+}
+
+// Exists reports whether the OCI image layout directory has the tag.
+func (l LayoutReference) Exists() bool {
+	return hasReference(l.Dir, l.Tag)
+}
+
+// Open opens the OCI image layout directory for writing, creating it if needed.
+func (l LayoutReference) Open() (*oci.Store, error) {
+	store, err := oci.New(l.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OCI image layout %s: %w", l.Dir, err)
+	}
+	return store, nil
+}
+
+// OpenReadOnly opens the OCI image layout directory for reading, without writing anything
+// to it.
+func (l LayoutReference) OpenReadOnly(ctx context.Context) (*oci.ReadOnlyStore, error) {
+	store, err := oci.NewFromFS(ctx, os.DirFS(l.Dir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%s is not an OCI image layout directory", l.Dir)
+		}
+		return nil, fmt.Errorf("failed to open OCI image layout %s: %w", l.Dir, err)
+	}
+	return store, nil
+}
+
+// PushTo copies the manifest and its referrers, such as signatures, to dst under tag, such as
+// an OCI image layout or object storage. An existing tag in dst is replaced. Bundles cannot be
+// pushed, as their members live in other repositories.
+func (r *Repository) PushTo(ctx context.Context, dst oras.Target, tag string) error {
+	isBundle, err := r.IsBundle(ctx)
+	if err != nil {
+		return err
+	}
+	if isBundle {
+		return fmt.Errorf("%s is a bundle, push its members one by one", r.ref)
+	}
+	src, err := r.newReadOnlyStore(ctx)
+	if err != nil {
+		return err
+	}
+	if err := r.extendedCopy(ctx, src, r.Tag(), dst, tag); err != nil {
+		return err
+	}
+	desc, err := dst.Resolve(ctx, tag)
+	if err != nil {
+		return fmt.Errorf("failed to resolve reference %s: %w", tag, err)
+	}
+	return r.recordEvent(mft.EventPushed, desc.Digest.String())
+}
+
+// PullFrom copies the manifest tagged in src, with its referrers, into local storage under the
+// tag of r. Like Pull, the content is staged first, and from is recorded as the source of the
+// tag, see PulledFrom.
+func (r *Repository) PullFrom(ctx context.Context, src oras.ReadOnlyGraphTarget, srcTag, from string) error {
+	s, err := r.StagePullFrom(ctx, src, srcTag, from)
+	if err != nil {
+		return err
+	}
+	defer s.Discard()
+	return s.Commit(ctx)
+}
+
+// StagePullFrom copies the manifest tagged in src into a staging layout like PullFrom, leaving
+// local storage untouched until the staged manifest is committed, e.g. to verify it first.
+func (r *Repository) StagePullFrom(ctx context.Context, src oras.ReadOnlyGraphTarget, srcTag, from string) (_ *Staged, err error) {
+	if _, err := resolveIn(ctx, src, srcTag, from); err != nil {
+		return nil, err
+	}
+	s, staging, err := r.newStaged()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			s.Discard()
+		}
+	}()
+	s.event = mft.EventPulled
+
+	tag := r.Tag()
+	if err := r.extendedCopy(ctx, src, srcTag, staging, tag); err != nil {
+		return nil, err
+	}
+	desc, err := staging.Resolve(ctx, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve pulled reference %s: %w", tag, err)
+	}
+	desc.Annotations = maps.Clone(desc.Annotations)
+	if desc.Annotations == nil {
+		desc.Annotations = make(map[string]string)
+	}
+	desc.Annotations[annotationPulledFrom] = from
+	if err := staging.Tag(ctx, desc, tag); err != nil {
+		return nil, fmt.Errorf("failed to tag pulled reference %s: %w", tag, err)
+	}
+	return s, nil
+}
+
+// RepositoryNameIn returns the repository the manifest tagged in src was packed for, as
+// recorded in its title annotation. from names src in errors.
+func RepositoryNameIn(ctx context.Context, src oras.ReadOnlyTarget, tag, from string) (string, error) {
+	desc, err := resolveIn(ctx, src, tag, from)
+	if err != nil {
+		return "", err
+	}
+	data, err := content.FetchAll(ctx, src, desc)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", from, err)
+	}
+	var m struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return "", fmt.Errorf("failed to unmarshal manifest of %s: %w", from, err)
+	}
+	name := m.Annotations[v1.AnnotationTitle]
+	if name == "" {
+		return "", fmt.Errorf("%s does not record its repository, copy it to a tag of your choice with 'kubectl mft cp'", from)
+	}
+	return name, nil
+}
+
+// resolveIn resolves tag in src, named from in errors.
+func resolveIn(ctx context.Context, src oras.ReadOnlyTarget, tag, from string) (v1.Descriptor, error) {
+	if tag == "" {
+		return v1.Descriptor{}, fmt.Errorf("%s has no tag", from)
+	}
+	desc, err := src.Resolve(ctx, tag)
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return v1.Descriptor{}, fmt.Errorf("%s not found", from)
+		}
+		return v1.Descriptor{}, fmt.Errorf("failed to resolve %s: %w", from, err)
+	}
+	return desc, nil
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLayoutReference(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    LayoutReference
+		wantErr bool
+	}{
+		{ref: "oci-layout:///mnt/usb/layout:v1.0.0", want: LayoutReference{Dir: "/mnt/usb/layout", Tag: "v1.0.0"}},
+		{ref: "oci-layout:///mnt/usb/layout", want: LayoutReference{Dir: "/mnt/usb/layout"}},
+		{ref: "oci-layout://layout:dev", want: LayoutReference{Dir: "layout", Tag: "dev"}},
+		{ref: `oci-layout://C:\exchange\layout`, want: LayoutReference{Dir: `C:\exchange\layout`}},
+		{ref: `oci-layout://C:\exchange\layout:v1`, want: LayoutReference{Dir: `C:\exchange\layout`, Tag: "v1"}},
+		{ref: "oci-layout:///mnt/usb/layout:", wantErr: true},
+		{ref: "oci-layout://", wantErr: true},
+		{ref: "registry.example.com/app:v1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := ParseLayoutReference(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLayoutReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLayoutReference() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPushPullLayout(t *testing.T) {
+	setupListTest(t, "app:v1")
+	ctx := context.Background()
+
+	r, err := NewRepository("app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(t.TempDir(), "layout")
+	src := LayoutReference{Dir: dir, Tag: "v1"}
+	store, err := src.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.PushTo(ctx, store, src.Tag); err != nil {
+		t.Fatalf("PushTo() failed: %v", err)
+	}
+	if !src.Exists() {
+		t.Errorf("Exists() = false after PushTo()")
+	}
+	layout, err := src.OpenReadOnly(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := RepositoryNameIn(ctx, layout, src.Tag, src.String())
+	if err != nil {
+		t.Fatalf("RepositoryNameIn() failed: %v", err)
+	}
+	if name != "local/app" {
+		t.Errorf("RepositoryNameIn() = %q, want %q", name, "local/app")
+	}
+	if _, err := RepositoryNameIn(ctx, layout, "v2", src.String()); err == nil {
+		t.Errorf("RepositoryNameIn() of a missing tag succeeded")
+	}
+
+	dst, err := NewRepository("copy:v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.PullFrom(ctx, layout, src.Tag, src.String()); err != nil {
+		t.Fatalf("PullFrom() failed: %v", err)
+	}
+	res, err := dst.Dump(ctx)
+	if err != nil {
+		t.Fatalf("Dump() failed: %v", err)
+	}
+	defer res.Close()
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, res); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n" {
+		t.Errorf("Dump() = %q", got)
+	}
+	from, err := dst.PulledFrom()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != src.String() {
+		t.Errorf("PulledFrom() = %q, want %q", from, src.String())
+	}
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/paths"
+)
+
+const listCacheFile = "list-cache.json"
+
+// listCacheEntry holds the list information of one OCI layout, valid as long as its
+// index.json has the recorded digest.
+type listCacheEntry struct {
+	Digest digest.Digest `json:"digest"`
+	Infos  []*mft.Info   `json:"infos"`
+}
+
+// listCache caches the list information of OCI layouts by layout directory, so that list only
+// reads the layouts whose index.json changed. It is safe for concurrent use.
+type listCache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]*listCacheEntry
+	// used are the entries looked up or stored since loading, the others are dropped on save
+	used  map[string]*listCacheEntry
+	dirty bool
+}
+
+// loadListCache reads the list cache from the cache directory. A missing or corrupted cache
+// is treated as empty, and a cache directory that cannot be determined disables saving.
+func loadListCache() *listCache {
+	c := &listCache{
+		entries: map[string]*listCacheEntry{},
+		used:    map[string]*listCacheEntry{},
+	}
+	dir, err := paths.CacheDir()
+	if err != nil {
+		return c
+	}
+	c.path = filepath.Join(dir, listCacheFile)
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return c
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		c.entries = map[string]*listCacheEntry{}
+	}
+	return c
+}
+
+// get returns the cached list information of the layout at indexDir if its index.json still
+// has the digest index.
+func (c *listCache) get(indexDir string, index digest.Digest) ([]*mft.Info, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[indexDir]
+	if !ok || e.Digest != index {
+		return nil, false
+	}
+	c.used[indexDir] = e
+	return e.Infos, true
+}
+
+// put caches the list information of the layout at indexDir, whose index.json has the digest index.
+func (c *listCache) put(indexDir string, index digest.Digest, infos []*mft.Info) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.used[indexDir] = &listCacheEntry{Digest: index, Infos: infos}
+	c.dirty = true
+}
+
+// save writes the entries used since loading, dropping layouts that no longer exist.
+// The cache is best effort, so failures are ignored.
+func (c *listCache) save() {
+	if c.path == "" || (!c.dirty && len(c.used) == len(c.entries)) {
+		return
+	}
+	data, err := json.Marshal(c.used)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), listCacheFile+".*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err != nil || cerr != nil {
+		return
+	}
+	_ = os.Rename(tmp.Name(), c.path)
+}
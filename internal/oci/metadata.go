@@ -0,0 +1,232 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/metadb"
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+)
+
+// metadataFile is the metadata index in the writable storage. Registry host names cannot start
+// with a dot, so it never collides with a repository directory.
+const metadataFile = ".metadata.db"
+
+// MetadataIndexPath returns the path of the metadata index of the writable storage.
+func MetadataIndexPath() string {
+	return metadataIndexPath(baseDir)
+}
+
+// metadataIndexPath returns the path of the metadata index of the writable storage root.
+func metadataIndexPath(root string) string {
+	return filepath.Join(root, metadataFile)
+}
+
+// MetadataIndexEnabled reports whether the metadata index has been created with Reindex.
+// While it exists, changes to the writable storage and events are recorded in it, and list,
+// 'tag ls', and 'list --events' read from it.
+func MetadataIndexEnabled() bool {
+	return metadataIndexEnabled(baseDir)
+}
+
+// metadataIndexEnabled reports whether the writable storage root has a metadata index.
+func metadataIndexEnabled(root string) bool {
+	return metadb.Exists(metadataIndexPath(root))
+}
+
+// Reindex rebuilds the metadata index from the OCI layouts and event logs in the writable
+// storage, creating and thereby enabling the index if it does not exist. It returns the number
+// of indexed manifests.
+func Reindex(ctx context.Context) (int, error) {
+	layouts, err := findLayouts(baseDir)
+	if err != nil {
+		return 0, err
+	}
+
+	repositories := make(map[string][]metadb.Record)
+	count := 0
+	for _, layout := range layouts {
+		name, err := repositoryName(baseDir, layout)
+		if err != nil {
+			return 0, err
+		}
+		records, err := layoutRecords(baseDir, layout)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read OCI index at %s: %w", layout, err)
+		}
+		repositories[name] = records
+		count += len(records)
+	}
+
+	logged, err := loggedEvents(time.Time{})
+	if err != nil {
+		return 0, err
+	}
+	events := make([]metadb.Event, 0, len(logged))
+	for _, e := range logged {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal event: %w", err)
+		}
+		events = append(events, metadb.Event{Time: e.Time, Data: data})
+	}
+
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return 0, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	db, err := metadb.Open(MetadataIndexPath())
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	if err := db.Rebuild(repositories, events); err != nil {
+		return 0, fmt.Errorf("failed to rebuild metadata index: %w", err)
+	}
+	return count, nil
+}
+
+// DisableMetadataIndex removes the metadata index, so that list scans the OCI layouts again.
+func DisableMetadataIndex() error {
+	if err := os.Remove(MetadataIndexPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove metadata index: %w", err)
+	}
+	return nil
+}
+
+// SyncMetadata records the current state of the repository in the writable storage in the
+// metadata index, if the index is enabled, and invalidates the completion cache. Operations
+// changing local storage call it; callers that modify a layout directly, such as signing,
+// must call it too.
+func (r *Repository) SyncMetadata(ctx context.Context) error {
+	if r.cached {
+		return nil
+	}
+	root := r.storageDir()
+	invalidateCompletionCache(root)
+	if !metadataIndexEnabled(root) {
+		return nil
+	}
+	records, err := layoutRecords(root, r.userLayoutPath())
+	if err != nil {
+		return fmt.Errorf("failed to update metadata index, run 'kubectl mft reindex': %w", err)
+	}
+	db, err := metadb.Open(metadataIndexPath(root))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := db.PutRepository(r.Name(), records); err != nil {
+		return fmt.Errorf("failed to update metadata index, run 'kubectl mft reindex': %w", err)
+	}
+	return nil
+}
+
+// listMetadataIndex returns the list information of the writable storage root from its metadata index.
+func listMetadataIndex(root string) ([]*mft.Info, error) {
+	db, err := metadb.Open(metadataIndexPath(root))
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	records, err := db.Records()
+	if err != nil {
+		return nil, err
+	}
+	info := make([]*mft.Info, 0, len(records))
+	for _, rec := range records {
+		info = append(info, &mft.Info{
+			Repository: rec.Repository,
+			Tag:        rec.Tag,
+			Size:       mft.FormatSize(rec.Size),
+			Created:    rec.Created,
+			Pinned:     rec.Pinned,
+		})
+	}
+	return info, nil
+}
+
+// indexedTags returns the tags of the repository in the writable storage root from its metadata index.
+func indexedTags(root, repository string) ([]*mft.TagInfo, error) {
+	db, err := metadb.Open(metadataIndexPath(root))
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	records, err := db.RepositoryRecords(repository)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]*mft.TagInfo, 0, len(records))
+	for _, rec := range records {
+		tags = append(tags, &mft.TagInfo{Tag: rec.Tag, Digest: rec.Digest})
+	}
+	return tags, nil
+}
+
+// layoutRecords returns the metadata records of the tagged manifests in the layout at indexDir
+// of the writable storage root. A layout that does not exist has no records.
+func layoutRecords(root, indexDir string) ([]metadb.Record, error) {
+	index, err := loadIndexFile(indexDir)
+	if err != nil {
+		if _, statErr := os.Stat(indexDir); os.IsNotExist(statErr) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	repoName, err := getRepoName(root, indexDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository name: %w", err)
+	}
+
+	// Signatures are untagged manifests whose subject is the signed manifest
+	signed := make(map[string]bool)
+	for _, d := range index.Manifests {
+		if d.Annotations[v1.AnnotationRefName] != "" {
+			continue
+		}
+		m, err := readManifestBlob(indexDir, d.Digest)
+		if err != nil {
+			return nil, err
+		}
+		if m.ArtifactType == signature.SignatureArtifactType && m.Subject != nil {
+			signed[m.Subject.Digest.String()] = true
+		}
+	}
+
+	var records []metadb.Record
+	for _, d := range index.Manifests {
+		tag := d.Annotations[v1.AnnotationRefName]
+		if tag == "" {
+			continue
+		}
+		created, size, err := getManifestMetadata(indexDir, d.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get metadata for %s/%s: %w", repoName, tag, err)
+		}
+		m, err := readManifestBlob(indexDir, d.Digest)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, metadb.Record{
+			Repository:  repoName,
+			Tag:         tag,
+			Digest:      d.Digest.String(),
+			Size:        size,
+			Created:     created,
+			Signed:      signed[d.Digest.String()],
+			Pinned:      isPinned(d),
+			Annotations: m.Annotations,
+		})
+	}
+	return records, nil
+}
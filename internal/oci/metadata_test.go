@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/metadb"
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+)
+
+func metadataRecords(t *testing.T) map[string]metadb.Record {
+	t.Helper()
+	db, err := metadb.Open(MetadataIndexPath())
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer db.Close()
+	records, err := db.Records()
+	if err != nil {
+		t.Fatalf("Records() failed: %v", err)
+	}
+	m := make(map[string]metadb.Record)
+	for _, r := range records {
+		m[r.Repository+":"+r.Tag] = r
+	}
+	return m
+}
+
+func TestMetadataIndex(t *testing.T) {
+	setupListTest(t, "a:v1", "team/b:v1")
+	ctx := context.Background()
+
+	if MetadataIndexEnabled() {
+		t.Fatal("metadata index enabled before reindex")
+	}
+	// Without the index, changes are not recorded
+	r, err := NewRepository("a:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SyncMetadata(ctx); err != nil {
+		t.Fatalf("SyncMetadata() failed: %v", err)
+	}
+	if MetadataIndexEnabled() {
+		t.Fatal("SyncMetadata() created the metadata index")
+	}
+
+	n, err := Reindex(ctx)
+	if err != nil {
+		t.Fatalf("Reindex() failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Reindex() = %d, want 2", n)
+	}
+	records := metadataRecords(t)
+	rec, ok := records["a:v1"]
+	if !ok || rec.Digest == "" || rec.Size == 0 || rec.Created.IsZero() || rec.Signed {
+		t.Errorf("unexpected record for a:v1: %+v", rec)
+	}
+	if rec.Annotations["org.opencontainers.image.title"] == "" {
+		t.Errorf("record for a:v1 lacks manifest annotations: %v", rec.Annotations)
+	}
+	if _, ok := records["team/b:v1"]; !ok {
+		t.Errorf("no record for team/b:v1 in %v", records)
+	}
+
+	// Signing marks the record as signed once synced
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signature.NewSigner(key).Sign(ctx, r.LayoutPath(), r.Tag()); err != nil {
+		t.Fatalf("Sign() failed: %v", err)
+	}
+	if err := r.SyncMetadata(ctx); err != nil {
+		t.Fatalf("SyncMetadata() failed: %v", err)
+	}
+	if !metadataRecords(t)["a:v1"].Signed {
+		t.Error("record for a:v1 not marked as signed")
+	}
+
+	// Storage operations keep the index up to date and list reads from it
+	if err := r.Copy(ctx, "a:v2", mft.CopyOptions{}); err != nil {
+		t.Fatalf("Copy() failed: %v", err)
+	}
+	b, err := NewRepository("team/b:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Delete(ctx); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if got, want := listTags(t), "a:v1,a:v2"; got != want {
+		t.Errorf("List() = %s, want %s", got, want)
+	}
+
+	if err := DisableMetadataIndex(); err != nil {
+		t.Fatalf("DisableMetadataIndex() failed: %v", err)
+	}
+	if MetadataIndexEnabled() {
+		t.Error("metadata index enabled after DisableMetadataIndex()")
+	}
+}
+
+func TestMetadataIndexEventsAndTags(t *testing.T) {
+	setupListTest(t, "a:v1")
+	ctx := context.Background()
+	r, err := NewRepository("a:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Events recorded before the index is enabled are imported by Reindex
+	if _, err := Reindex(ctx); err != nil {
+		t.Fatalf("Reindex() failed: %v", err)
+	}
+	if err := r.Copy(ctx, "a:v2", mft.CopyOptions{}); err != nil {
+		t.Fatalf("Copy() failed: %v", err)
+	}
+
+	// With the index enabled, events and tags are read from it, not from the storage
+	if err := os.RemoveAll(filepath.Join(baseDir, eventsDir)); err != nil {
+		t.Fatal(err)
+	}
+	res, err := Events(time.Time{})
+	if err != nil {
+		t.Fatalf("Events() failed: %v", err)
+	}
+	var got []string
+	for _, e := range res.Items() {
+		got = append(got, e.Type+" "+e.Tag)
+	}
+	if want := []string{mft.EventPacked + " v1", mft.EventCopied + " v2"}; !slices.Equal(got, want) {
+		t.Errorf("Events() = %v, want %v", got, want)
+	}
+
+	repo, err := NewRepository("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tags []string
+	for _, tag := range repo.localTags() {
+		if tag.Digest == "" {
+			t.Errorf("tag %s has no digest", tag.Tag)
+		}
+		tags = append(tags, tag.Tag)
+	}
+	if want := []string{"v1", "v2"}; !slices.Equal(tags, want) {
+		t.Errorf("localTags() = %v, want %v", tags, want)
+	}
+}
@@ -0,0 +1,234 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+// MigrateStorage moves the tags of legacy directories in the writable storage into the layouts
+// of their repositories. Legacy directories are OCI layouts directly in the storage directory,
+// such as the flat "<registry>-<repository>-<tag>" directories of older versions, which the
+// storage layout never creates since repository names have at least two components. As the
+// directory name cannot be split unambiguously, the repository of each tag is read from the
+// title annotation recorded when it was packed.
+//
+// Tags are copied with their referrers, and their blobs keep their modification times, so that
+// list shows the original creation times. A legacy directory is removed once all its tags are
+// in the layout of their repository. Tags whose repository is unknown, or whose destination tag
+// exists with other content, are skipped and their directory is kept, as is a directory without
+// tags. With dryRun, the result reports what would be migrated without modifying storage,
+// including the conflicts between legacy directories that would migrate to the same tag.
+func MigrateStorage(ctx context.Context, dryRun bool) (*mft.MigrateResult, error) {
+	res := mft.NewMigrateResult(dryRun)
+	dirs, err := legacyLayouts()
+	if err != nil {
+		return nil, err
+	}
+
+	// The digests migrated to each tag, which a dry run does not find in storage
+	migrated := make(map[string]string)
+	for _, dir := range dirs {
+		complete, err := migrateLayout(ctx, dir, dryRun, migrated, res)
+		if err != nil {
+			return nil, err
+		}
+		if !complete || dryRun {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, fmt.Errorf("failed to remove legacy directory %s: %w", dir, err)
+		}
+		res.AddRemoved(filepath.Base(dir))
+	}
+
+	if len(res.Removed()) > 0 {
+		invalidateCompletionCache(baseDir)
+		if MetadataIndexEnabled() {
+			if _, err := Reindex(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// legacyLayouts returns the OCI layout directories directly in the writable storage directory.
+func legacyLayouts() ([]string, error) {
+	entries, err := os.ReadDir(baseDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage directory: %w", err)
+	}
+	var dirs []string
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if dir := filepath.Join(baseDir, e.Name()); isLayout(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
+
+// migrateLayout migrates the tags of the legacy directory and reports whether all of them are
+// now in the layout of their repository. A directory without tags is reported as skipped, so
+// that its untagged content is not removed. migrated holds the digests migrated to each tag so
+// far, and is updated.
+func migrateLayout(ctx context.Context, dir string, dryRun bool, migrated map[string]string, res *mft.MigrateResult) (bool, error) {
+	index, err := loadIndexFile(dir)
+	if err != nil {
+		return false, fmt.Errorf("failed to read legacy directory %s: %w", dir, err)
+	}
+	src, err := oci.NewFromFS(ctx, os.DirFS(dir))
+	if err != nil {
+		return false, fmt.Errorf("failed to open legacy directory %s: %w", dir, err)
+	}
+
+	complete := true
+	tagged := false
+	for _, d := range index.Manifests {
+		tag := d.Annotations[v1.AnnotationRefName]
+		if tag == "" {
+			continue
+		}
+		tagged = true
+		m := &mft.Migration{Dir: filepath.Base(dir), Tag: tag, Digest: d.Digest.String()}
+		res.Add(m)
+
+		dest, err := legacyDestination(ctx, src, tag, dir)
+		if err != nil {
+			m.Status, m.Reason = mft.MigrationSkipped, err.Error()
+			complete = false
+			continue
+		}
+		m.Repository = dest.displayName()
+		if prev, ok := migrated[dest.String()]; ok && dryRun {
+			if prev == m.Digest {
+				m.Status = mft.MigrationExists
+			} else {
+				m.Status, m.Reason = mft.MigrationSkipped, fmt.Sprintf("%s would exist with digest %s", dest, prev)
+				complete = false
+			}
+			continue
+		}
+		if m.Status, m.Reason, err = dest.migrateFrom(ctx, src, dir, d, dryRun); err != nil {
+			return false, err
+		}
+		switch m.Status {
+		case mft.MigrationSkipped:
+			complete = false
+		case mft.MigrationMigrated:
+			migrated[dest.String()] = m.Digest
+		}
+	}
+	if !tagged {
+		res.Add(&mft.Migration{Dir: filepath.Base(dir), Status: mft.MigrationSkipped, Reason: "no tags, its untagged content is kept"})
+		return false, nil
+	}
+	return complete, nil
+}
+
+// legacyDestination returns the tag of the repository the manifest tagged in the legacy
+// directory was packed for.
+func legacyDestination(ctx context.Context, src *oci.ReadOnlyStore, tag, dir string) (*Repository, error) {
+	name, err := RepositoryNameIn(ctx, src, tag, filepath.Base(dir)+":"+tag)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(name, "/") {
+		return nil, fmt.Errorf("recorded repository %q has no registry", name)
+	}
+	dest, err := NewRepository(name + ":" + tag)
+	if err != nil {
+		return nil, fmt.Errorf("recorded repository %q: %w", name, err)
+	}
+	return dest, nil
+}
+
+// migrateFrom copies the manifest desc of the legacy directory, with its referrers, to the tag
+// of r, and returns the outcome and the reason a tag was skipped.
+func (r *Repository) migrateFrom(ctx context.Context, src *oci.ReadOnlyStore, dir string, desc v1.Descriptor, dryRun bool) (string, string, error) {
+	if hasReference(r.userLayoutPath(), r.Tag()) {
+		existing, err := r.Digest(ctx)
+		if err != nil {
+			return "", "", err
+		}
+		if existing == desc.Digest.String() {
+			return mft.MigrationExists, "", nil
+		}
+		return mft.MigrationSkipped, fmt.Sprintf("%s exists with digest %s", r, existing), nil
+	}
+	if dryRun {
+		return mft.MigrationMigrated, "", nil
+	}
+
+	store, err := r.newOCILayoutStore()
+	if err != nil {
+		return "", "", err
+	}
+	if err := r.extendedCopy(ctx, src, desc.Digest.String(), store, r.Tag()); err != nil {
+		return "", "", err
+	}
+	if err := preserveBlobTimes(ctx, src, dir, r.userLayoutPath(), desc); err != nil {
+		return "", "", err
+	}
+	if err := r.SyncMetadata(ctx); err != nil {
+		return "", "", err
+	}
+	if err := r.recordEvent(mft.EventMigrated, desc.Digest.String()); err != nil {
+		return "", "", err
+	}
+	return mft.MigrationMigrated, "", nil
+}
+
+// preserveBlobTimes sets the modification times of the blobs of the manifest desc in the
+// layout destDir to those in srcDir. list reports the time of the manifest blob as the
+// creation time of a tag.
+func preserveBlobTimes(ctx context.Context, src content.Fetcher, srcDir, destDir string, desc v1.Descriptor) error {
+	descs := []v1.Descriptor{desc}
+	data, err := content.FetchAll(ctx, src, desc)
+	if err != nil {
+		return fmt.Errorf("failed to fetch manifest %s: %w", desc.Digest, err)
+	}
+	var m struct {
+		Config    *v1.Descriptor  `json:"config"`
+		Layers    []v1.Descriptor `json:"layers"`
+		Manifests []v1.Descriptor `json:"manifests"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("failed to unmarshal manifest %s: %w", desc.Digest, err)
+	}
+	if m.Config != nil {
+		descs = append(descs, *m.Config)
+	}
+	descs = append(descs, m.Layers...)
+	descs = append(descs, m.Manifests...)
+
+	for _, d := range descs {
+		fi, err := os.Stat(blobPath(srcDir, d.Digest))
+		if err != nil {
+			continue
+		}
+		if err := os.Chtimes(blobPath(destDir, d.Digest), fi.ModTime(), fi.ModTime()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to preserve the time of blob %s: %w", d.Digest, err)
+		}
+	}
+	return nil
+}
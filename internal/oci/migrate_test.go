@@ -0,0 +1,190 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+// makeLegacy moves the layout of the repository name into the flat legacy directory dir and
+// backdates its blobs to created.
+func makeLegacy(t *testing.T, name, dir string, created time.Time) {
+	t.Helper()
+	legacy := filepath.Join(baseDir, dir)
+	if err := os.Rename(layoutDir(baseDir, name), legacy); err != nil {
+		t.Fatalf("failed to create legacy directory: %v", err)
+	}
+	err := filepath.WalkDir(filepath.Join(legacy, "blobs"), func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		return os.Chtimes(path, created, created)
+	})
+	if err != nil {
+		t.Fatalf("failed to backdate blobs: %v", err)
+	}
+}
+
+func migrationStatuses(res *mft.MigrateResult) map[string]string {
+	statuses := make(map[string]string)
+	for _, m := range res.Migrations() {
+		statuses[m.Dir+":"+m.Tag] = m.Repository + " " + m.Status
+	}
+	return statuses
+}
+
+func TestMigrateStorage(t *testing.T) {
+	ctx := context.Background()
+	setupListTest(t, "ghcr.io/org/app:v1", "mine:v2")
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	makeLegacy(t, "ghcr.io/org/app", "ghcr.io-org-app-v1", created)
+	makeLegacy(t, "local/mine", "local-mine-v2", created)
+
+	res, err := MigrateStorage(ctx, true)
+	if err != nil {
+		t.Fatalf("MigrateStorage(dry run) failed: %v", err)
+	}
+	want := map[string]string{
+		"ghcr.io-org-app-v1:v1": "ghcr.io/org/app " + mft.MigrationMigrated,
+		"local-mine-v2:v2":      "mine " + mft.MigrationMigrated,
+	}
+	if got := migrationStatuses(res); !maps.Equal(got, want) {
+		t.Errorf("MigrateStorage(dry run) = %v, want %v", got, want)
+	}
+	if len(res.Removed()) != 0 || !isLayout(filepath.Join(baseDir, "local-mine-v2")) {
+		t.Fatalf("MigrateStorage(dry run) modified storage, removed %v", res.Removed())
+	}
+
+	res, err = MigrateStorage(ctx, false)
+	if err != nil {
+		t.Fatalf("MigrateStorage() failed: %v", err)
+	}
+	if len(res.Removed()) != 2 {
+		t.Errorf("MigrateStorage() removed %v, want both legacy directories", res.Removed())
+	}
+	if got := listTags(t); got != "ghcr.io/org/app:v1,mine:v2" {
+		t.Errorf("List() after migration = %q", got)
+	}
+	list, err := NewRegistry().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range list.Items() {
+		if !i.Created.Equal(created) {
+			t.Errorf("%s:%s created %v, want %v", i.Repository, i.Tag, i.Created, created)
+		}
+	}
+
+	res, err = MigrateStorage(ctx, false)
+	if err != nil {
+		t.Fatalf("MigrateStorage() again failed: %v", err)
+	}
+	if len(res.Migrations()) != 0 {
+		t.Errorf("MigrateStorage() again = %v, want no legacy directories", migrationStatuses(res))
+	}
+}
+
+func TestMigrateStorageConflict(t *testing.T) {
+	ctx := context.Background()
+	setupListTest(t, "ghcr.io/org/app:v1")
+	makeLegacy(t, "ghcr.io/org/app", "ghcr.io-org-app-v1", time.Now())
+
+	// Pack different content under the same tag
+	manifestPath := filepath.Join(t.TempDir(), "other.yaml")
+	if err := os.WriteFile(manifestPath, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: other\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRepository("ghcr.io/org/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Save(ctx, manifestPath); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := MigrateStorage(ctx, false)
+	if err != nil {
+		t.Fatalf("MigrateStorage() failed: %v", err)
+	}
+	if skipped := res.Skipped(); len(skipped) != 1 || skipped[0].Reason == "" {
+		t.Errorf("MigrateStorage() skipped %v, want the conflicting tag", migrationStatuses(res))
+	}
+	if len(res.Removed()) != 0 || !isLayout(filepath.Join(baseDir, "ghcr.io-org-app-v1")) {
+		t.Error("MigrateStorage() removed a legacy directory with a skipped tag")
+	}
+}
+
+func TestMigrateStorageDryRunConflict(t *testing.T) {
+	ctx := context.Background()
+	setupListTest(t, "ghcr.io/org/app:v1")
+	makeLegacy(t, "ghcr.io/org/app", "ghcr.io-org-app-v1", time.Now())
+
+	// A second legacy directory records different content for the same tag
+	manifestPath := filepath.Join(t.TempDir(), "other.yaml")
+	if err := os.WriteFile(manifestPath, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: other\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRepository("ghcr.io/org/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Save(ctx, manifestPath); err != nil {
+		t.Fatal(err)
+	}
+	makeLegacy(t, "ghcr.io/org/app", "ghcr.io_org_app_v1", time.Now())
+
+	for _, dryRun := range []bool{true, false} {
+		res, err := MigrateStorage(ctx, dryRun)
+		if err != nil {
+			t.Fatalf("MigrateStorage(%v) failed: %v", dryRun, err)
+		}
+		if skipped := res.Skipped(); len(skipped) != 1 || skipped[0].Dir != "ghcr.io_org_app_v1" {
+			t.Errorf("MigrateStorage(%v) = %v, want the second directory skipped", dryRun, migrationStatuses(res))
+		}
+	}
+}
+
+func TestMigrateStorageUntagged(t *testing.T) {
+	ctx := context.Background()
+	setupListTest(t, "ghcr.io/org/app:v1")
+	makeLegacy(t, "ghcr.io/org/app", "ghcr.io-org-app-v1", time.Now())
+
+	// Untag the manifest of the legacy directory
+	dir := filepath.Join(baseDir, "ghcr.io-org-app-v1")
+	index, err := loadIndexFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range index.Manifests {
+		delete(d.Annotations, v1.AnnotationRefName)
+	}
+	data, err := json.Marshal(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := MigrateStorage(ctx, false)
+	if err != nil {
+		t.Fatalf("MigrateStorage() failed: %v", err)
+	}
+	if skipped := res.Skipped(); len(skipped) != 1 || skipped[0].Tag != "" {
+		t.Errorf("MigrateStorage() = %v, want the untagged directory skipped", migrationStatuses(res))
+	}
+	if len(res.Removed()) != 0 || !isLayout(dir) {
+		t.Error("MigrateStorage() removed a legacy directory without tags")
+	}
+}
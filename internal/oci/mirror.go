@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"strings"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+)
+
+// annotationPulledFrom records the repository a pulled manifest was downloaded from, which
+// differs from the repository of the tag if a mirror served it
+const annotationPulledFrom = "io.kubectl-mft.pulled-from"
+
+// pullSource is a repository the manifest can be pulled from.
+type pullSource struct {
+	registry   string
+	repository string
+}
+
+func (s pullSource) String() string {
+	return s.registry + "/" + s.repository
+}
+
+// pullSources returns the mirrors configured for the registry of r in order, followed by the registry itself.
+func (r *Repository) pullSources() ([]pullSource, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	var sources []pullSource
+	for _, endpoint := range cfg.MirrorsFor(r.ref.Registry) {
+		host, prefix, _ := strings.Cut(strings.TrimSuffix(endpoint, "/"), "/")
+		sources = append(sources, pullSource{registry: host, repository: path.Join(prefix, r.ref.Repository)})
+	}
+	return append(sources, pullSource{registry: r.ref.Registry, repository: r.ref.Repository}), nil
+}
+
+// pullFrom copies the manifest and its referrers from src into store, recording src in the
+// annotations of the tag. If seed is not empty, blobs already present and intact in the
+// layout at seed are read from there instead of being downloaded again.
+func (r *Repository) pullFrom(ctx context.Context, src pullSource, store *oci.Store, seed string) error {
+	repo, err := r.newRemoteRepository(src.registry, src.repository)
+	if err != nil {
+		return err
+	}
+	var source oras.ReadOnlyGraphTarget = repo
+	if seed != "" {
+		source = &seededSource{ReadOnlyGraphTarget: repo, layoutPath: seed}
+	}
+	tag := r.ref.ReferenceOrDefault()
+	srcRef := tag
+	if r.pinned != "" {
+		srcRef = r.pinned.String()
+	}
+	if err := r.extendedCopy(ctx, source, srcRef, store, tag); err != nil {
+		return err
+	}
+	desc, err := store.Resolve(ctx, tag)
+	if err != nil {
+		return fmt.Errorf("failed to resolve pulled reference %s: %w", tag, err)
+	}
+	if err := r.pullSignatureTag(ctx, repo, store, desc); err != nil {
+		return err
+	}
+
+	desc.Annotations = maps.Clone(desc.Annotations)
+	if desc.Annotations == nil {
+		desc.Annotations = make(map[string]string)
+	}
+	desc.Annotations[annotationPulledFrom] = src.String()
+	if err := store.Tag(ctx, desc, tag); err != nil {
+		return fmt.Errorf("failed to tag pulled reference %s: %w", tag, err)
+	}
+	return nil
+}
+
+// seededSource reads blobs from a local layout when it holds them intact, and from the
+// remote repository otherwise.
+type seededSource struct {
+	oras.ReadOnlyGraphTarget
+	layoutPath string
+}
+
+// Fetch returns the local blob if its content matches target, so that a corrupted local
+// blob is downloaded again rather than copied.
+func (s *seededSource) Fetch(ctx context.Context, target v1.Descriptor) (io.ReadCloser, error) {
+	if target.Digest.Validate() == nil {
+		path := blobPath(s.layoutPath, target.Digest)
+		if reason, err := checkBlob(path, target); err == nil && reason == "" {
+			if f, err := os.Open(path); err == nil {
+				return f, nil
+			}
+		}
+	}
+	return s.ReadOnlyGraphTarget.Fetch(ctx, target)
+}
+
+// PulledFrom returns the repository the tag was last pulled from, a mirror or the registry of
+// the tag, or an empty string if it was not pulled.
+func (r *Repository) PulledFrom() (string, error) {
+	index, err := loadIndexFile(r.LayoutPath())
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	for _, d := range index.Manifests {
+		if d.Annotations[v1.AnnotationRefName] == r.ref.ReferenceOrDefault() {
+			return d.Annotations[annotationPulledFrom], nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func TestPullMirrors(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	ctx := context.Background()
+	_, origin := newFakeRegistry(t)
+	_, mirror := newFakeRegistry(t)
+	_, emptyMirror := newFakeRegistry(t)
+
+	// The artifact is published on the origin and, under a prefix, on the mirror
+	for _, tag := range []string{origin + "/team/app:v1", mirror + "/cache/team/app:v1"} {
+		setupListTest(t, tag)
+		r, err := NewRepository(tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Push(ctx); err != nil {
+			t.Fatalf("Push(%s) failed: %v", tag, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		endpoints string
+		want      string
+		warnings  int
+	}{
+		{name: "no mirror", endpoints: "", want: origin + "/team/app"},
+		{name: "mirror", endpoints: "[" + mirror + "/cache]", want: mirror + "/cache/team/app"},
+		{name: "fallback", endpoints: "[" + emptyMirror + ", " + mirror + "/cache]", want: mirror + "/cache/team/app", warnings: 1},
+		{name: "origin fallback", endpoints: "[" + emptyMirror + "]", want: origin + "/team/app", warnings: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("KUBECTL_MFT_CONFIG_DIR", dir)
+			if tt.endpoints != "" {
+				cfg := "mirrors:\n  - registry: " + origin + "\n    endpoints: " + tt.endpoints + "\n"
+				if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(cfg), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			setupListTest(t)
+
+			r, err := NewRepository(origin + "/team/app:v1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var warnings []string
+			r.SetWarningHandler(func(msg string) { warnings = append(warnings, msg) })
+			if err := r.Pull(ctx); err != nil {
+				t.Fatalf("Pull() failed: %v", err)
+			}
+			got, err := r.PulledFrom()
+			if err != nil {
+				t.Fatalf("PulledFrom() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("PulledFrom() = %q, want %q", got, tt.want)
+			}
+			if len(warnings) != tt.warnings {
+				t.Errorf("Pull() warnings = %v, want %d", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestPullSeedsLocalBlobs(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	ctx := context.Background()
+	f, host := newFakeRegistry(t)
+	tag := host + "/team/app:v1"
+	setupListTest(t, tag)
+	r, err := NewRepository(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Push(ctx); err != nil {
+		t.Fatalf("Push() failed: %v", err)
+	}
+
+	// The registry no longer serves blobs, the local copies are used instead
+	f.mu.Lock()
+	blobs := f.blobs
+	f.blobs = make(map[digest.Digest][]byte)
+	f.mu.Unlock()
+	if err := r.Pull(ctx); err != nil {
+		t.Fatalf("Pull() with local blobs failed: %v", err)
+	}
+
+	// A corrupted local blob is downloaded again rather than copied
+	d, err := r.Digest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := readManifestBlob(r.LayoutPath(), digest.Digest(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(blobPath(r.LayoutPath(), m.Layers[0].Digest), []byte("corrupted"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Pull(ctx); err == nil {
+		t.Fatal("Pull() with a corrupted local blob and no remote blob succeeded")
+	}
+	f.mu.Lock()
+	f.blobs = blobs
+	f.mu.Unlock()
+	if err := r.Pull(ctx); err != nil {
+		t.Fatalf("Pull() failed: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/errdef"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+// annotationPinned marks an index entry as pinned, so that GC never removes its manifest
+const annotationPinned = "io.kubectl-mft.pinned"
+
+// SetPinned pins or unpins the tag in the writable storage or the cache. GC never removes
+// the manifest of a pinned tag, nor evicts it from the cache. Packing or pulling the tag
+// again replaces it unpinned.
+func (r *Repository) SetPinned(ctx context.Context, pinned bool) error {
+	target := r
+	if r.InCache() {
+		target = &Repository{ref: r.ref, cached: true}
+	} else if !hasReference(r.userLayoutPath(), r.Tag()) {
+		if r.LayoutPath() != r.userLayoutPath() {
+			return fmt.Errorf("%s is provided by read-only system storage and cannot be pinned", r)
+		}
+		return fmt.Errorf("%s not found in local storage", r)
+	}
+
+	layoutStore, err := target.newOCILayoutStore()
+	if err != nil {
+		return err
+	}
+	value := ""
+	if pinned {
+		value = "true"
+	}
+	desc, err := setTagAnnotation(ctx, layoutStore, r.Tag(), annotationPinned, value)
+	if err != nil {
+		return err
+	}
+	if err := target.SyncMetadata(ctx); err != nil {
+		return err
+	}
+	event := mft.EventUnpinned
+	if pinned {
+		event = mft.EventPinned
+	}
+	return target.recordEvent(event, desc.Digest.String())
+}
+
+// Pinned reports whether the tag is pinned.
+func (r *Repository) Pinned() (bool, error) {
+	index, err := loadIndexFile(r.LayoutPath())
+	if err != nil {
+		return false, err
+	}
+	for _, d := range index.Manifests {
+		if d.Annotations[v1.AnnotationRefName] == r.Tag() {
+			return isPinned(d), nil
+		}
+	}
+	return false, fmt.Errorf("%s not found in local storage", r)
+}
+
+// isPinned reports whether the index entry is pinned.
+func isPinned(desc v1.Descriptor) bool {
+	return desc.Annotations[annotationPinned] == "true"
+}
+
+// setTagAnnotation sets the annotation of the index entry of tag in store, or removes it if
+// value is empty, and returns the updated entry.
+func setTagAnnotation(ctx context.Context, store *oci.Store, tag, key, value string) (v1.Descriptor, error) {
+	desc, err := store.Resolve(ctx, tag)
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return v1.Descriptor{}, fmt.Errorf("tag %s not found", tag)
+		}
+		return v1.Descriptor{}, fmt.Errorf("failed to resolve %s: %w", tag, err)
+	}
+	desc.Annotations = maps.Clone(desc.Annotations)
+	if desc.Annotations == nil {
+		desc.Annotations = make(map[string]string)
+	}
+	if value == "" {
+		delete(desc.Annotations, key)
+	} else {
+		desc.Annotations[key] = value
+	}
+	if err := store.Tag(ctx, desc, tag); err != nil {
+		return v1.Descriptor{}, fmt.Errorf("failed to tag %s: %w", tag, err)
+	}
+	return desc, nil
+}
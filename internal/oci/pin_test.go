@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSetPinned(t *testing.T) {
+	setupListTest(t, "ghcr.io/org/app:v1", "ghcr.io/org/app:v2")
+	ctx := context.Background()
+	r, err := NewRepository("ghcr.io/org/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.SetPinned(ctx, true); err != nil {
+		t.Fatalf("SetPinned(true) failed: %v", err)
+	}
+	if pinned, err := r.Pinned(); err != nil || !pinned {
+		t.Errorf("Pinned() = %v, %v, want true", pinned, err)
+	}
+	res, err := NewRegistry().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range res.Items() {
+		if want := i.Tag == "v1"; i.Pinned != want {
+			t.Errorf("List() %s:%s Pinned = %v, want %v", i.Repository, i.Tag, i.Pinned, want)
+		}
+	}
+
+	if err := r.SetPinned(ctx, false); err != nil {
+		t.Fatalf("SetPinned(false) failed: %v", err)
+	}
+	if pinned, err := r.Pinned(); err != nil || pinned {
+		t.Errorf("Pinned() = %v, %v after unpinning, want false", pinned, err)
+	}
+}
+
+func TestSetPinned_NotFound(t *testing.T) {
+	setupListTest(t)
+	r, err := NewRepository("ghcr.io/org/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetPinned(context.Background(), true); err == nil {
+		t.Error("SetPinned() succeeded for a missing tag")
+	}
+}
+
+func TestSetPinned_Cached(t *testing.T) {
+	src := setupCacheTest(t)
+	ctx := context.Background()
+	layout, err := src.OpenReadOnly(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRepository("ghcr.io/org/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.UseCache()
+	if err := r.PullFrom(ctx, layout, src.Tag, src.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = NewRepository("ghcr.io/org/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetPinned(ctx, true); err != nil {
+		t.Fatalf("SetPinned() failed: %v", err)
+	}
+	backdateCache(t, "ghcr.io/org/app", time.Now().Add(-2*time.Hour))
+	if r.CacheExpired(time.Hour) {
+		t.Error("CacheExpired() = true for a pinned tag")
+	}
+	if n, err := EvictCache(ctx, time.Hour); err != nil || n != 0 {
+		t.Errorf("EvictCache() = %d, %v, want the pinned tag kept", n, err)
+	}
+	if n, err := ClearCache(ctx); err != nil || n != 0 {
+		t.Errorf("ClearCache() = %d, %v, want the pinned tag kept", n, err)
+	}
+	if !r.InCache() {
+		t.Error("InCache() = false, want the pinned tag kept")
+	}
+}
@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+const (
+	checkAPI       = "api"
+	checkReferrers = "referrers"
+	checkChunked   = "chunked-upload"
+	checkArtifact  = "artifact-type"
+	checkCleanup   = "cleanup"
+
+	// probeContent is the content of the layer pushed to check artifact media type acceptance
+	probeContent = "# kubectl-mft registry probe\n"
+)
+
+// ProbeRegistry checks the capabilities of a remote registry kubectl-mft relies on and reports
+// its compatibility. target is a registry host, optionally followed by a repository. The checks
+// that need a repository are skipped without one. The artifact check pushes an untagged manifest
+// to the repository and deletes it afterwards.
+func ProbeRegistry(ctx context.Context, target string) (*mft.RegistryInfoResult, error) {
+	ref, err := parseProbeTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newAuthClient(ref.Registry)
+	if err != nil {
+		return nil, err
+	}
+	scheme := "https"
+	if isLocalRegistry(ref.Registry) {
+		scheme = "http"
+	}
+	res := mft.NewRegistryInfoResult(target)
+
+	status, message := probeAPI(ctx, client, scheme+"://"+ref.Registry+"/v2/")
+	res.Add(checkAPI, status, true, message)
+	if status != mft.RegistryCheckSupported {
+		// The other checks cannot succeed without the distribution API
+		for _, name := range []string{checkChunked, checkArtifact, checkReferrers} {
+			res.Add(name, mft.RegistryCheckSkipped, false, "registry API is not available")
+		}
+		return res, nil
+	}
+	if ref.Repository == "" {
+		for _, name := range []string{checkChunked, checkArtifact, checkReferrers} {
+			res.Add(name, mft.RegistryCheckSkipped, false, "no repository given")
+		}
+		return res, nil
+	}
+
+	repo, err := remote.NewRepository(ref.Registry + "/" + ref.Repository)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create repository %s/%s: %w", ref.Registry, ref.Repository, err)
+	}
+	repo.Client = client
+	repo.PlainHTTP = scheme == "http"
+
+	status, message = probeChunkedUpload(ctx, client, ref, fmt.Sprintf("%s://%s/v2/%s/blobs/uploads/", scheme, ref.Registry, ref.Repository))
+	res.Add(checkChunked, status, false, message)
+
+	desc, status, message := probeArtifact(ctx, repo)
+	res.Add(checkArtifact, status, true, message)
+
+	// The referrers API is probed against the pushed manifest, registries may reject unknown subjects
+	subject := v1.DescriptorEmptyJSON
+	if desc != nil {
+		subject = *desc
+	}
+	supported, err := referrersSupported(ctx, repo, subject)
+	switch {
+	case err != nil:
+		res.Add(checkReferrers, mft.RegistryCheckUnknown, false, err.Error())
+	case supported:
+		res.Add(checkReferrers, mft.RegistryCheckSupported, false, "")
+	default:
+		res.Add(checkReferrers, mft.RegistryCheckUnsupported, false, "signatures are published under sha256-<digest>.sig tags")
+	}
+
+	if desc != nil {
+		if err := repo.Delete(ctx, *desc); err != nil {
+			res.Add(checkCleanup, mft.RegistryCheckUnknown, false,
+				fmt.Sprintf("failed to delete probe manifest %s: %v", desc.Digest, err))
+		}
+	}
+	return res, nil
+}
+
+// parseProbeTarget parses a registry host with an optional repository.
+func parseProbeTarget(target string) (registry.Reference, error) {
+	host, repository, _ := strings.Cut(target, "/")
+	ref := registry.Reference{Registry: host, Repository: repository}
+	if err := ref.ValidateRegistry(); err != nil {
+		return ref, fmt.Errorf("invalid registry %q: %w", target, err)
+	}
+	if repository != "" {
+		if err := ref.ValidateRepository(); err != nil {
+			return ref, fmt.Errorf("invalid repository %q: %w", target, err)
+		}
+	}
+	return ref, nil
+}
+
+// probeAPI checks the OCI distribution API base endpoint.
+func probeAPI(ctx context.Context, client *auth.Client, endpoint string) (mft.RegistryCheckStatus, string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return mft.RegistryCheckUnknown, err.Error()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return mft.RegistryCheckUnknown, err.Error()
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if version := resp.Header.Get("Docker-Distribution-API-Version"); version != "" {
+			return mft.RegistryCheckSupported, version
+		}
+		return mft.RegistryCheckSupported, ""
+	case http.StatusUnauthorized:
+		return mft.RegistryCheckUnknown, "authentication required, log in with 'docker login'"
+	default:
+		return mft.RegistryCheckUnsupported, fmt.Sprintf("%s returned %s", endpoint, resp.Status)
+	}
+}
+
+// probeChunkedUpload starts a blob upload session, reports the minimum chunk length the registry
+// requires, if any, and cancels the session.
+func probeChunkedUpload(ctx context.Context, client *auth.Client, ref registry.Reference, endpoint string) (mft.RegistryCheckStatus, string) {
+	ctx = auth.AppendRepositoryScope(ctx, ref, auth.ActionPull, auth.ActionPush)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return mft.RegistryCheckUnknown, err.Error()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return mft.RegistryCheckUnknown, err.Error()
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusAccepted:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return mft.RegistryCheckUnknown, fmt.Sprintf("no push permission: %s", resp.Status)
+	default:
+		return mft.RegistryCheckUnsupported, fmt.Sprintf("starting an upload returned %s", resp.Status)
+	}
+
+	message := "no minimum chunk length"
+	if minLength := resp.Header.Get("OCI-Chunk-Min-Length"); minLength != "" {
+		message = fmt.Sprintf("minimum chunk length %s bytes", minLength)
+	}
+	if location, err := resp.Location(); err == nil {
+		cancelUpload(ctx, client, location)
+	} else if !errors.Is(err, http.ErrNoLocation) {
+		message += fmt.Sprintf(", invalid upload location: %v", err)
+	}
+	return mft.RegistryCheckSupported, message
+}
+
+// cancelUpload deletes an upload session. Registries expire abandoned sessions, so failures are ignored.
+func cancelUpload(ctx context.Context, client *auth.Client, location *url.URL) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, location.String(), nil)
+	if err != nil {
+		return
+	}
+	if resp, err := client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+}
+
+// probeArtifact pushes an untagged manifest with the kubectl-mft artifact and layer media types
+// and returns its descriptor if the registry accepted it.
+func probeArtifact(ctx context.Context, repo *remote.Repository) (*v1.Descriptor, mft.RegistryCheckStatus, string) {
+	data := []byte(probeContent)
+	layer := content.NewDescriptorFromBytes(contentMediaType, data)
+	if err := repo.Push(ctx, layer, bytes.NewReader(data)); err != nil {
+		return nil, probeStatus(err), fmt.Sprintf("failed to push %s blob: %v", contentMediaType, err)
+	}
+	desc, err := oras.PackManifest(ctx, repo, oras.PackManifestVersion1_1, artifactType, oras.PackManifestOptions{
+		Layers: []v1.Descriptor{layer},
+	})
+	if err != nil {
+		return nil, probeStatus(err), fmt.Sprintf("failed to push %s manifest: %v", artifactType, err)
+	}
+	return &desc, mft.RegistryCheckSupported, artifactType
+}
+
+// probeStatus maps a push error to a check status. Permission errors do not tell whether the
+// registry supports the content.
+func probeStatus(err error) mft.RegistryCheckStatus {
+	var errResp *errcode.ErrorResponse
+	if errors.As(err, &errResp) && (errResp.StatusCode == http.StatusUnauthorized || errResp.StatusCode == http.StatusForbidden) {
+		return mft.RegistryCheckUnknown
+	}
+	return mft.RegistryCheckUnsupported
+}
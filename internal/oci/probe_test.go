@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+func TestProbeRegistry(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	ctx := context.Background()
+	fake, host := newFakeRegistry(t)
+
+	tests := []struct {
+		name   string
+		target string
+		want   map[string]mft.RegistryCheckStatus
+	}{
+		{
+			name:   "registry only",
+			target: host,
+			want: map[string]mft.RegistryCheckStatus{
+				checkAPI:       mft.RegistryCheckSupported,
+				checkChunked:   mft.RegistryCheckSkipped,
+				checkArtifact:  mft.RegistryCheckSkipped,
+				checkReferrers: mft.RegistryCheckSkipped,
+			},
+		},
+		{
+			name:   "repository",
+			target: host + "/team/app",
+			want: map[string]mft.RegistryCheckStatus{
+				checkAPI:       mft.RegistryCheckSupported,
+				checkChunked:   mft.RegistryCheckSupported,
+				checkArtifact:  mft.RegistryCheckSupported,
+				checkReferrers: mft.RegistryCheckUnsupported,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ProbeRegistry(ctx, tt.target)
+			if err != nil {
+				t.Fatalf("ProbeRegistry() failed: %v", err)
+			}
+			if len(res.Checks()) != len(tt.want) {
+				t.Errorf("ProbeRegistry() checks = %d, want %d", len(res.Checks()), len(tt.want))
+			}
+			for _, c := range res.Checks() {
+				if c.Status != tt.want[c.Name] {
+					t.Errorf("check %s = %s (%s), want %s", c.Name, c.Status, c.Message, tt.want[c.Name])
+				}
+			}
+			if !res.Compatible() {
+				t.Error("Compatible() = false, want true")
+			}
+		})
+	}
+
+	// The probe manifest is deleted
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.manifests) != 0 {
+		t.Errorf("ProbeRegistry() left %d manifests", len(fake.manifests))
+	}
+}
+
+func TestParseProbeTarget(t *testing.T) {
+	tests := []struct {
+		target  string
+		wantErr bool
+	}{
+		{target: "ghcr.io"},
+		{target: "localhost:5000/team/app"},
+		{target: "ghcr.io/Team", wantErr: true},
+		{target: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		if _, err := parseProbeTarget(tt.target); (err != nil) != tt.wantErr {
+			t.Errorf("parseProbeTarget(%q) error = %v, wantErr %v", tt.target, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+)
+
+const (
+	// ProvenanceArtifactType is the artifact type of provenance attestations attached to a manifest
+	ProvenanceArtifactType = "application/vnd.kubectl-mft.provenance.v1"
+	// provenanceMediaType is the media type of the attestation layer, typically an in-toto statement
+	provenanceMediaType = "application/vnd.in-toto+json"
+)
+
+// AttachProvenance attaches the provenance attestation at path to the tagged manifest in local
+// storage. It is stored as a referrer like a signature, so push and copy carry it along.
+// The attestation must be a JSON document; its content is not interpreted.
+func (r *Repository) AttachProvenance(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read provenance: %w", err)
+	}
+	if !json.Valid(data) {
+		return "", fmt.Errorf("provenance %s is not valid JSON", path)
+	}
+
+	store, err := r.newOCILayoutStore()
+	if err != nil {
+		return "", err
+	}
+	desc, err := store.Resolve(ctx, r.ref.ReferenceOrDefault())
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve reference %s: %w", r.ref.ReferenceOrDefault(), err)
+	}
+
+	layer := v1.Descriptor{
+		MediaType: provenanceMediaType,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+	exists, err := store.Exists(ctx, layer)
+	if err != nil {
+		return "", fmt.Errorf("failed to check provenance blob: %w", err)
+	}
+	if !exists {
+		if err := store.Push(ctx, layer, bytes.NewReader(data)); err != nil {
+			return "", fmt.Errorf("failed to push provenance blob: %w", err)
+		}
+	}
+
+	manifestDesc, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, ProvenanceArtifactType, oras.PackManifestOptions{
+		Subject: &desc,
+		Layers:  []v1.Descriptor{layer},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to pack provenance manifest: %w", err)
+	}
+	return manifestDesc.Digest.String(), nil
+}
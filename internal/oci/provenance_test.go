@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func TestAttachProvenance(t *testing.T) {
+	setupListTest(t, "app:v1")
+	ctx := context.Background()
+
+	r, err := NewRepository("app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := r.Exists(ctx); err != nil || !exists {
+		t.Fatalf("Exists() = %v, %v", exists, err)
+	}
+
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	if err := os.WriteFile(invalid, []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.AttachProvenance(ctx, invalid); err == nil {
+		t.Error("AttachProvenance() with invalid JSON expected error")
+	}
+
+	path := filepath.Join(t.TempDir(), "provenance.json")
+	if err := os.WriteFile(path, []byte(`{"_type":"https://in-toto.io/Statement/v1"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := r.AttachProvenance(ctx, path)
+	if err != nil {
+		t.Fatalf("AttachProvenance() failed: %v", err)
+	}
+
+	m, err := readManifestBlob(r.LayoutPath(), digest.Digest(d))
+	if err != nil {
+		t.Fatalf("failed to read provenance manifest: %v", err)
+	}
+	if m.ArtifactType != ProvenanceArtifactType {
+		t.Errorf("ArtifactType = %q, want %q", m.ArtifactType, ProvenanceArtifactType)
+	}
+	if m.Subject == nil {
+		t.Fatal("provenance manifest has no subject")
+	}
+	if len(m.Layers) != 1 || m.Layers[0].MediaType != provenanceMediaType {
+		t.Errorf("Layers = %+v, want a single %s layer", m.Layers, provenanceMediaType)
+	}
+
+	// Deleting the tag removes the attestation with it
+	if _, err := r.Delete(ctx); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := os.Stat(blobPath(r.userLayoutPath(), digest.Digest(d))); !os.IsNotExist(err) {
+		t.Errorf("provenance manifest still exists after Delete(): %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"oras.land/oras-go/v2/registry"
+)
+
+// strictReferences makes NewRepository refuse references rejected by CheckReference
+var strictReferences bool
+
+// SetStrictReferences makes NewRepository check references with CheckReference. References
+// are then refused if they parse, but likely name another repository than intended.
+func SetStrictReferences(strict bool) {
+	strictReferences = strict
+}
+
+var (
+	// tagPattern is the syntax of OCI tags
+	tagPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	// componentPattern is the syntax of the path components of OCI repositories
+	componentPattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*$`)
+)
+
+// CheckReference checks that ref names the repository it looks like it names. NewRepository
+// accepts references that parse but are likely typos, such as "localhost:5000", which names
+// the local repository "localhost" with the tag "5000". CheckReference refuses them with an
+// error suggesting the intended reference, as well as:
+//
+//   - whitespace and URL schemes such as https://
+//   - registry hosts and repositories that are not lowercase
+//   - a registry host alone, such as "ghcr.io", which names a local repository
+//   - empty repository path components, tags, and malformed digests
+func CheckReference(ref string) error {
+	if ref == "" {
+		return errors.New("empty reference")
+	}
+	if strings.ContainsAny(ref, " \t\r\n") {
+		return fmt.Errorf("invalid reference %q: it contains whitespace", ref)
+	}
+	if scheme, rest, ok := strings.Cut(ref, "://"); ok {
+		return fmt.Errorf("invalid reference %q: references have no %s:// scheme, use %s", ref, scheme, rest)
+	}
+
+	name, suffix := ref, ""
+	if i := strings.Index(ref, "@"); i >= 0 {
+		name, suffix = ref[:i], ref[i:]
+		if _, err := digest.Parse(suffix[1:]); err != nil {
+			return fmt.Errorf("invalid reference %q: invalid digest %q: %w", ref, suffix[1:], err)
+		}
+	} else if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		name, suffix = ref[:i], ref[i:]
+		if !tagPattern.MatchString(suffix[1:]) {
+			return fmt.Errorf("invalid reference %q: invalid tag %q, tags are up to 128 letters, digits, '_', '.', and '-', not starting with '.' or '-'", ref, suffix[1:])
+		}
+	}
+
+	host, repository, ok := strings.Cut(name, "/")
+	if !ok {
+		host, repository = "", name
+		tag := strings.TrimPrefix(suffix, ":")
+		looksLikeHost := name == "localhost" || strings.Contains(name, ".")
+		switch {
+		case looksLikeHost && tag != "" && strings.Trim(tag, "0123456789") == "":
+			return fmt.Errorf("ambiguous reference %q: it names the local repository %q with the tag %q, add the repository to the registry host and port, e.g. %s/app:v1", ref, name, tag, ref)
+		case looksLikeHost && suffix == "":
+			return fmt.Errorf("ambiguous reference %q: it names a local repository, add the repository to the registry host, e.g. %s/app:v1", ref, ref)
+		}
+	}
+	if host != strings.ToLower(host) {
+		return fmt.Errorf("invalid reference %q: registry hosts are lowercase, use %s", ref, strings.ToLower(host)+"/"+repository+suffix)
+	}
+	if repository != strings.ToLower(repository) {
+		lower := strings.ToLower(repository)
+		if host != "" {
+			lower = host + "/" + lower
+		}
+		return fmt.Errorf("invalid reference %q: repositories are lowercase, use %s", ref, lower+suffix)
+	}
+	if ok && host == "" {
+		return fmt.Errorf("invalid reference %q: the registry host is empty", ref)
+	}
+	if repository == "" || strings.HasPrefix(repository, "/") || strings.HasSuffix(repository, "/") || strings.Contains(repository, "//") {
+		return fmt.Errorf("invalid reference %q: the repository %q has an empty path component", ref, repository)
+	}
+	for c := range strings.SplitSeq(repository, "/") {
+		if !componentPattern.MatchString(c) {
+			return fmt.Errorf("invalid reference %q: invalid repository path component %q, components are lowercase letters and digits separated by '.', '_', '__', or '-'", ref, c)
+		}
+	}
+	if _, err := registry.ParseReference(normalizeTag(ref)); err != nil {
+		return fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+	return nil
+}
+
+// String returns the canonical form of the reference displayed by commands: the repository
+// as listed, without the local/ prefix of local repositories, and the tag or digest.
+func (r *Repository) String() string {
+	name := r.displayName()
+	if r.ref.ValidateReferenceAsDigest() == nil {
+		return name + "@" + r.ref.Reference
+	}
+	return name + ":" + r.Tag()
+}
+
+// displayName returns the repository as displayed by commands, without the local/ prefix of
+// local repositories.
+func (r *Repository) displayName() string {
+	return strings.TrimPrefix(r.Name(), DefaultRegistry+"/")
+}
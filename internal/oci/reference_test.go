@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckReference(t *testing.T) {
+	tests := []struct {
+		ref     string
+		wantErr string
+	}{
+		{ref: "app:v1"},
+		{ref: "app"},
+		{ref: "app:V1.0_RC"},
+		{ref: "local/team/app:v1"},
+		{ref: "myregistry/app:v1.0.0"},
+		{ref: "localhost:5000/app:v1"},
+		{ref: "ghcr.io/org/app@sha256:5778a57faa1a054fd8bab4b78f2b22f92abb651ebe45daee7be9d0e6069e6be3"},
+		{ref: "my-app:5000"},
+
+		{ref: "", wantErr: "empty reference"},
+		{ref: " app:v1", wantErr: "whitespace"},
+		{ref: "https://ghcr.io/org/app:v1", wantErr: "use ghcr.io/org/app:v1"},
+		{ref: "localhost:5000", wantErr: `names the local repository "localhost" with the tag "5000"`},
+		{ref: "registry.example.com:443", wantErr: "e.g. registry.example.com:443/app:v1"},
+		{ref: "ghcr.io", wantErr: "add the repository to the registry host"},
+		{ref: "GHCR.io/org/app:v1", wantErr: "use ghcr.io/org/app:v1"},
+		{ref: "ghcr.io/Org/App:v1", wantErr: "use ghcr.io/org/app:v1"},
+		{ref: "MyApp:v1", wantErr: "use myapp:v1"},
+		{ref: "app:", wantErr: "invalid tag"},
+		{ref: "app:-v1", wantErr: "invalid tag"},
+		{ref: "app@sha256:abc", wantErr: "invalid digest"},
+		{ref: "ghcr.io//app:v1", wantErr: "empty path component"},
+		{ref: "ghcr.io/:v1", wantErr: "empty path component"},
+		{ref: "/app:v1", wantErr: "registry host is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			err := CheckReference(tt.ref)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckReference(%q) = %v", tt.ref, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckReference(%q) = %v, want %q", tt.ref, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStrictReferences(t *testing.T) {
+	if _, err := NewRepository("localhost:5000"); err != nil {
+		t.Fatalf("NewRepository() without strict references failed: %v", err)
+	}
+	SetStrictReferences(true)
+	t.Cleanup(func() { SetStrictReferences(false) })
+	if _, err := NewRepository("localhost:5000"); err == nil {
+		t.Errorf("NewRepository() with strict references accepted an ambiguous reference")
+	}
+}
+
+func TestRepositoryString(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{ref: "app:v1", want: "app:v1"},
+		{ref: "local/app:v1", want: "app:v1"},
+		{ref: "app", want: "app:latest"},
+		{ref: "ghcr.io/org/app:v1", want: "ghcr.io/org/app:v1"},
+		{ref: "localhost:5000/app@sha256:5778a57faa1a054fd8bab4b78f2b22f92abb651ebe45daee7be9d0e6069e6be3", want: "localhost:5000/app@sha256:5778a57faa1a054fd8bab4b78f2b22f92abb651ebe45daee7be9d0e6069e6be3"},
+	}
+	for _, tt := range tests {
+		r, err := NewRepository(tt.ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.String(); got != tt.want {
+			t.Errorf("NewRepository(%q).String() = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+// FuzzCheckReference checks that references accepted by CheckReference parse, and that their
+// canonical form is accepted too and names the same repository and tag.
+func FuzzCheckReference(f *testing.F) {
+	for _, ref := range []string{
+		"app:v1", "local/app", "ghcr.io/org/app:v1", "localhost:5000/a/b:c", "localhost:5000",
+		"GHCR.io/app", "app@sha256:5778a57faa1a054fd8bab4b78f2b22f92abb651ebe45daee7be9d0e6069e6be3",
+		"a//b", "https://x/y", "x.y", "a:b:c", "a@b:c",
+	} {
+		f.Add(ref)
+	}
+	f.Fuzz(func(t *testing.T, ref string) {
+		if CheckReference(ref) != nil {
+			return
+		}
+		r, err := NewRepository(ref)
+		if err != nil {
+			t.Fatalf("CheckReference(%q) passed, but NewRepository() failed: %v", ref, err)
+		}
+		canonical := r.String()
+		if err := CheckReference(canonical); err != nil {
+			t.Fatalf("canonical form %q of %q is refused: %v", canonical, ref, err)
+		}
+		c, err := NewRepository(canonical)
+		if err != nil {
+			t.Fatalf("canonical form %q of %q does not parse: %v", canonical, ref, err)
+		}
+		if c.Name() != r.Name() || c.Tag() != r.Tag() {
+			t.Fatalf("canonical form %q of %q names %s:%s, want %s:%s", canonical, ref, c.Name(), c.Tag(), r.Name(), r.Tag())
+		}
+	})
+}
@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry/remote"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+)
+
+// signatureTag returns the tag under which the signatures of the manifest d are published on
+// registries without the OCI referrers API, following the sha256-<digest>.sig scheme.
+func signatureTag(d digest.Digest) string {
+	return d.Algorithm().String() + "-" + d.Encoded() + ".sig"
+}
+
+// referrersSupported reports whether the remote repository supports the OCI referrers API.
+func referrersSupported(ctx context.Context, repo *remote.Repository, desc v1.Descriptor) (bool, error) {
+	// Listing referrers detects the capability, after which it can only be set to the detected value
+	if err := repo.Referrers(ctx, desc, "", func([]v1.Descriptor) error { return nil }); err != nil {
+		return false, fmt.Errorf("failed to list referrers of %s: %w", desc.Digest, err)
+	}
+	return !errors.Is(repo.SetReferrersCapability(true), remote.ErrReferrersCapabilityAlreadySet), nil
+}
+
+// pushSignatureTag publishes the signatures of desc under signatureTag if the registry lacks the
+// referrers API. Such registries may garbage collect untagged signature manifests, and clients
+// unaware of referrers look for signatures under this tag.
+func (r *Repository) pushSignatureTag(ctx context.Context, src content.ReadOnlyGraphStorage, repo *remote.Repository, desc v1.Descriptor) error {
+	supported, err := referrersSupported(ctx, repo, desc)
+	if err != nil || supported {
+		return err
+	}
+	sigs, err := signatureManifests(ctx, src, desc)
+	if err != nil || len(sigs) == 0 {
+		return err
+	}
+
+	tag := signatureTag(desc.Digest)
+	r.warnf("%s does not support the OCI referrers API, publishing signatures as %s:%s", r.ref.Registry, r.ref.Repository, tag)
+	data, err := json.Marshal(v1.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: v1.MediaTypeImageIndex,
+		Manifests: sigs,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal signature index: %w", err)
+	}
+	indexDesc := content.NewDescriptorFromBytes(v1.MediaTypeImageIndex, data)
+	if err := repo.PushReference(ctx, indexDesc, bytes.NewReader(data), tag); err != nil {
+		return r.formatCopyError(err)
+	}
+	return nil
+}
+
+// pullSignatureTag copies the signatures published under signatureTag into dst if the registry
+// lacks the referrers API, for artifacts whose signatures are not found through the referrers
+// tag schema, e.g. because they were pushed by a client that only uses the .sig tag.
+func (r *Repository) pullSignatureTag(ctx context.Context, repo *remote.Repository, dst oras.Target, desc v1.Descriptor) error {
+	supported, err := referrersSupported(ctx, repo, desc)
+	if err != nil || supported {
+		return err
+	}
+
+	tag := signatureTag(desc.Digest)
+	indexDesc, rc, err := repo.FetchReference(ctx, tag)
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return nil
+		}
+		return r.formatCopyError(err)
+	}
+	data, err := content.ReadAll(rc, indexDesc)
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", tag, err)
+	}
+	var index v1.Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", tag, err)
+	}
+
+	var copied int
+	for _, m := range index.Manifests {
+		exists, err := dst.Exists(ctx, m)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if err := oras.CopyGraph(ctx, repo, dst, m, oras.DefaultCopyGraphOptions); err != nil {
+			return r.formatCopyError(err)
+		}
+		copied++
+	}
+	if copied > 0 {
+		r.warnf("%s does not support the OCI referrers API, took %d signature(s) from %s:%s", r.ref.Registry, copied, r.ref.Repository, tag)
+	}
+	return nil
+}
+
+// signatureManifests returns the signature manifests referring to desc in store.
+func signatureManifests(ctx context.Context, store content.ReadOnlyGraphStorage, desc v1.Descriptor) ([]v1.Descriptor, error) {
+	predecessors, err := store.Predecessors(ctx, desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get predecessors of %s: %w", desc.Digest, err)
+	}
+	var sigs []v1.Descriptor
+	for _, p := range predecessors {
+		if p.MediaType != v1.MediaTypeImageManifest {
+			continue
+		}
+		data, err := content.FetchAll(ctx, store, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch manifest %s: %w", p.Digest, err)
+		}
+		var m v1.Manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal manifest %s: %w", p.Digest, err)
+		}
+		if m.ArtifactType == signature.SignatureArtifactType {
+			sigs = append(sigs, p)
+		}
+	}
+	return sigs, nil
+}
+
+// warnf reports a condition that does not fail the operation, through the handler set with
+// SetWarningHandler or on stderr.
+func (r *Repository) warnf(format string, a ...any) {
+	msg := fmt.Sprintf(format, a...)
+	if r.warn != nil {
+		r.warn(msg)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+}
+
+// SetWarningHandler sets the function receiving warnings, instead of printing them on stderr.
+func (r *Repository) SetWarningHandler(h func(msg string)) {
+	r.warn = h
+}
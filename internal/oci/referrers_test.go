@@ -0,0 +1,199 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+)
+
+// fakeRegistry is a minimal OCI 1.0 registry without the referrers API.
+type fakeRegistry struct {
+	mu        sync.Mutex
+	blobs     map[digest.Digest][]byte
+	manifests map[digest.Digest][]byte
+	types     map[digest.Digest]string
+	tags      map[string]digest.Digest
+}
+
+func newFakeRegistry(t *testing.T) (*fakeRegistry, string) {
+	t.Helper()
+	f := &fakeRegistry{
+		blobs:     make(map[digest.Digest][]byte),
+		manifests: make(map[digest.Digest][]byte),
+		types:     make(map[digest.Digest]string),
+		tags:      make(map[string]digest.Digest),
+	}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, strings.TrimPrefix(srv.URL, "http://")
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	switch {
+	case req.URL.Path == "/v2/":
+		w.WriteHeader(http.StatusOK)
+	case strings.Contains(path, "/blobs/uploads/"):
+		if req.Method == http.MethodPost {
+			w.Header().Set("Location", req.URL.Path+"upload")
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		data, _ := io.ReadAll(req.Body)
+		d := digest.Digest(req.URL.Query().Get("digest"))
+		f.blobs[d] = data
+		w.Header().Set("Docker-Content-Digest", d.String())
+		w.WriteHeader(http.StatusCreated)
+	case strings.Contains(path, "/blobs/"):
+		d := digest.Digest(path[strings.LastIndex(path, "/")+1:])
+		data, ok := f.blobs[d]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		w.Header().Set("Docker-Content-Digest", d.String())
+		if req.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	case strings.Contains(path, "/manifests/"):
+		ref := path[strings.LastIndex(path, "/")+1:]
+		switch req.Method {
+		case http.MethodPut:
+			data, _ := io.ReadAll(req.Body)
+			d := digest.FromBytes(data)
+			f.manifests[d] = data
+			f.types[d] = req.Header.Get("Content-Type")
+			if _, err := digest.Parse(ref); err != nil {
+				f.tags[ref] = d
+			}
+			w.Header().Set("Docker-Content-Digest", d.String())
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			d := digest.Digest(ref)
+			delete(f.manifests, d)
+			for tag, td := range f.tags {
+				if td == d {
+					delete(f.tags, tag)
+				}
+			}
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			d, ok := f.tags[ref]
+			if !ok {
+				d = digest.Digest(ref)
+			}
+			data, ok := f.manifests[d]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", f.types[d])
+			w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+			w.Header().Set("Docker-Content-Digest", d.String())
+			if req.Method == http.MethodGet {
+				_, _ = w.Write(data)
+			}
+		}
+	default:
+		// No referrers API
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// tagged reports whether the tag exists.
+func (f *fakeRegistry) tagged(tag string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.tags[tag]
+	return ok
+}
+
+// untag removes a tag and reports whether it existed.
+func (f *fakeRegistry) untag(tag string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.tags[tag]
+	delete(f.tags, tag)
+	return ok
+}
+
+func TestSignatureTagFallback(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	ctx := context.Background()
+	fake, host := newFakeRegistry(t)
+
+	tag := host + "/team/app:v1"
+	setupListTest(t, tag)
+	r, err := NewRepository(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signature.NewSigner(key).Sign(ctx, r.LayoutPath(), r.Tag()); err != nil {
+		t.Fatalf("Sign() failed: %v", err)
+	}
+
+	var warnings []string
+	r.SetWarningHandler(func(msg string) { warnings = append(warnings, msg) })
+	if err := r.Push(ctx); err != nil {
+		t.Fatalf("Push() failed: %v", err)
+	}
+	store, err := r.newOCILayoutStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := store.Resolve(ctx, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("Push() warnings = %v, want 1", warnings)
+	}
+
+	// Signatures are found through the .sig tag even without the referrers tag schema index
+	if !fake.untag(strings.TrimSuffix(signatureTag(desc.Digest), ".sig")) {
+		t.Fatal("Push() did not create the referrers tag schema index")
+	}
+	if !fake.tagged(signatureTag(desc.Digest)) {
+		t.Fatalf("Push() did not publish %s", signatureTag(desc.Digest))
+	}
+	setupListTest(t)
+	pulled, err := NewRepository(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	warnings = nil
+	pulled.SetWarningHandler(func(msg string) { warnings = append(warnings, msg) })
+	if err := pulled.Pull(ctx); err != nil {
+		t.Fatalf("Pull() failed: %v", err)
+	}
+	if err := signature.NewVerifier([]crypto.PublicKey{&key.PublicKey}).Verify(ctx, pulled.LayoutPath(), pulled.Tag()); err != nil {
+		t.Errorf("Verify() after Pull() failed: %v", err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("Pull() warnings = %v, want 1", warnings)
+	}
+}
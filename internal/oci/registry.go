@@ -4,12 +4,15 @@
 package oci
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/opencontainers/go-digest"
@@ -19,54 +22,165 @@ import (
 	"github.com/chez-shanpu/kubectl-mft/internal/mft"
 )
 
-type Registry struct{}
+type Registry struct {
+	// root is the writable storage instead of the directory set by InitBaseDir, see NewRegistryIn
+	root string
+}
 
 func NewRegistry() *Registry {
 	return &Registry{}
 }
 
+// NewRegistryIn returns the local storage whose writable storage is root instead of the
+// process-wide storage directory, like NewRepositoryIn.
+func NewRegistryIn(root string) *Registry {
+	return &Registry{root: root}
+}
+
 func (r *Registry) List(ctx context.Context) (*mft.ListResult, error) {
-	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
-		return mft.NewListResult(nil), nil
+	var info []*mft.Info
+	seen := make(map[string]bool)
+	cache := loadListCache()
+
+	// Entries in the writable storage shadow entries with the same tag in system overlays
+	writable := cmp.Or(r.root, baseDir)
+	for _, root := range append([]string{writable}, systemDirs...) {
+		var rootInfo []*mft.Info
+		var err error
+		if root == writable && metadataIndexEnabled(root) {
+			rootInfo, err = listMetadataIndex(root)
+		} else {
+			rootInfo, err = listRoot(ctx, root, cache, root != writable)
+		}
+		if err != nil && root != writable {
+			// An unreadable system overlay does not hide the rest of local storage
+			fmt.Fprintf(os.Stderr, "Warning: skipping system storage %s: %v\n", root, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range rootInfo {
+			key := i.Repository + ":" + i.Tag
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			info = append(info, i)
+		}
 	}
+	cache.save()
+
+	return mft.NewListResult(info), nil
+}
+
+// listWorkers is the number of OCI layouts read concurrently by list
+var listWorkers = runtime.GOMAXPROCS(0)
+
+// listRoot walks a storage root and returns information about every tagged manifest in it.
+// Layouts are read concurrently, and unchanged layouts are served from cache. Unreadable
+// layouts of a system overlay are skipped with a warning.
+func listRoot(ctx context.Context, root string, cache *listCache, overlay bool) ([]*mft.Info, error) {
+	layouts, err := findLayouts(root)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]*mft.Info, len(layouts))
+	errs := make([]error, len(layouts))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(listWorkers, len(layouts)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = readLayout(ctx, root, layouts[i], cache)
+			}
+		}()
+	}
+	for i := range layouts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
 
 	var info []*mft.Info
-	if err := filepath.WalkDir(baseDir, func(path string, d os.DirEntry, err error) error {
+	for i, path := range layouts {
+		if errs[i] != nil && overlay {
+			fmt.Fprintf(os.Stderr, "Warning: skipping unreadable OCI index at %s: %v\n", path, errs[i])
+			continue
+		}
+		if errs[i] != nil {
+			return nil, fmt.Errorf("warning: failed to read OCI index at %s: %w", path, errs[i])
+		}
+		info = append(info, results[i]...)
+	}
+	return info, nil
+}
+
+// findLayouts returns the OCI layout directories under root in lexical order.
+// The blobs of a layout are not descended into.
+func findLayouts(root string) ([]string, error) {
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var layouts []string
+	if err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if !d.IsDir() || path == baseDir {
+		if !d.IsDir() || path == root {
 			return nil
 		}
-
-		// Check if this directory contains an index.json (OCI layout marker)
-		if _, err := os.Stat(filepath.Join(path, "index.json")); err != nil {
-			// not an OCI layout directory
-			return nil
+		if d.Name() == "blobs" && isLayout(filepath.Dir(path)) {
+			return filepath.SkipDir
 		}
-		i, err := readIndex(path)
-		if err != nil {
-			return fmt.Errorf("warning: failed to read OCI index at %s: %w", path, err)
+		if isLayout(path) {
+			layouts = append(layouts, path)
 		}
-		info = append(info, i...)
-
 		return nil
 	}); err != nil {
 		return nil, fmt.Errorf("failed to walk manifest directory: %w", err)
 	}
+	return layouts, nil
+}
 
-	return mft.NewListResult(info), nil
+// isLayout reports whether dir contains an index.json (OCI layout marker)
+func isLayout(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "index.json"))
+	return err == nil
+}
+
+// readLayout returns the list information of the layout at indexDir, from cache if the
+// content of its index.json is unchanged.
+func readLayout(ctx context.Context, root, indexDir string, cache *listCache) ([]*mft.Info, error) {
+	indexData, err := os.ReadFile(filepath.Join(indexDir, "index.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index.json: %w", err)
+	}
+	d := digest.FromBytes(indexData)
+	if infos, ok := cache.get(indexDir, d); ok {
+		return infos, nil
+	}
+	infos, err := readIndex(ctx, root, indexDir)
+	if err != nil {
+		return nil, err
+	}
+	cache.put(indexDir, d, infos)
+	return infos, nil
 }
 
 // readIndex reads the index.json file and extracts manifest information
-func readIndex(indexDir string) ([]*mft.Info, error) {
-	repoName, err := getRepoName(indexDir)
+func readIndex(ctx context.Context, root string, indexDir string) ([]*mft.Info, error) {
+	repoName, err := getRepoName(root, indexDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get repository name: %w", err)
 	}
 
-	if _, err := oci.New(indexDir); err != nil {
+	if _, err := oci.NewFromFS(ctx, os.DirFS(indexDir)); err != nil {
 		return nil, fmt.Errorf("failed to open OCI store: %w", err)
 	}
 
@@ -96,20 +210,20 @@ func readIndex(indexDir string) ([]*mft.Info, error) {
 		infos = append(infos, &mft.Info{
 			Repository: repoName,
 			Tag:        tag,
-			Size:       formatSize(size),
+			Size:       mft.FormatSize(size),
 			Created:    created,
+			Pinned:     isPinned(manifest),
 		})
 	}
 
 	return infos, nil
 }
 
-func getRepoName(indexDir string) (string, error) {
-	relPath, err := filepath.Rel(baseDir, indexDir)
+func getRepoName(root string, indexDir string) (string, error) {
+	repoName, err := repositoryName(root, indexDir)
 	if err != nil {
-		return "", fmt.Errorf("failed to get relative path: %w", err)
+		return "", err
 	}
-	repoName := filepath.ToSlash(relPath)
 
 	// Strip the default registry prefix for display
 	repoName = strings.TrimPrefix(repoName, DefaultRegistry+"/")
@@ -130,17 +244,3 @@ func getManifestMetadata(indexDir string, digest digest.Digest) (created time.Ti
 
 	return fileInfo.ModTime(), fileInfo.Size(), nil
 }
-
-// formatSize formats byte size to human-readable format
-func formatSize(bytes int64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%dB", bytes)
-	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
-	}
-	return fmt.Sprintf("%.1f%cB", float64(bytes)/float64(div), "KMGTPE"[exp])
-}
@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupListTest creates manifests for the given tags in a temporary storage directory.
+func setupListTest(t *testing.T, tags ...string) {
+	t.Helper()
+	origBaseDir, origSystemDirs := baseDir, systemDirs
+	baseDir = t.TempDir()
+	systemDirs = nil
+	t.Cleanup(func() { baseDir, systemDirs = origBaseDir, origSystemDirs })
+	t.Setenv("KUBECTL_MFT_CACHE_DIR", t.TempDir())
+
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(manifestPath, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"), 0o644); err != nil {
+		t.Fatalf("failed to create test manifest: %v", err)
+	}
+	for _, tag := range tags {
+		r, err := NewRepository(tag)
+		if err != nil {
+			t.Fatalf("NewRepository() failed: %v", err)
+		}
+		if err := r.Save(context.Background(), manifestPath); err != nil {
+			t.Fatalf("Save(%s) failed: %v", tag, err)
+		}
+	}
+}
+
+func listTags(t *testing.T) string {
+	t.Helper()
+	res, err := NewRegistry().List(context.Background())
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	res.Sort()
+	var got []string
+	for _, i := range res.Items() {
+		got = append(got, i.Repository+":"+i.Tag)
+	}
+	return strings.Join(got, ",")
+}
+
+func TestRegistryList(t *testing.T) {
+	var tags []string
+	for _, name := range []string{"a", "b", "c", "team/d", "team/d/e"} {
+		tags = append(tags, name+":v1", name+":v2")
+	}
+	setupListTest(t, tags...)
+	origWorkers := listWorkers
+	listWorkers = 2
+	t.Cleanup(func() { listWorkers = origWorkers })
+
+	want := "a:v1,a:v2,b:v1,b:v2,c:v1,c:v2,team/d:v1,team/d:v2,team/d/e:v1,team/d/e:v2"
+	if got := listTags(t); got != want {
+		t.Errorf("List() = %s, want %s", got, want)
+	}
+	// The second run is served from cache
+	if got := listTags(t); got != want {
+		t.Errorf("cached List() = %s, want %s", got, want)
+	}
+}
+
+func TestRegistryListCache(t *testing.T) {
+	setupListTest(t, "myapp:v1")
+	if got := listTags(t); got != "myapp:v1" {
+		t.Fatalf("List() = %s", got)
+	}
+
+	// Entries are served from cache while index.json is unchanged
+	cachePath := filepath.Join(os.Getenv("KUBECTL_MFT_CACHE_DIR"), listCacheFile)
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatalf("list cache not written: %v", err)
+	}
+	var entries map[string]*listCacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("failed to parse list cache: %v", err)
+	}
+	for _, e := range entries {
+		e.Infos[0].Tag = "cached"
+	}
+	if data, err = json.Marshal(entries); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(cachePath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := listTags(t); got != "myapp:cached" {
+		t.Errorf("List() = %s, want the cached entry", got)
+	}
+
+	// Changing index.json invalidates the entry, even if its size and modification time are kept
+	indexPath := filepath.Join(baseDir, DefaultRegistry, "myapp", "index.json")
+	fi, err := os.Stat(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(indexPath, bytes.ReplaceAll(index, []byte(`"v1"`), []byte(`"v2"`)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(indexPath, fi.ModTime(), fi.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if got := listTags(t); got != "myapp:v2" {
+		t.Errorf("List() = %s, want myapp:v2 after index.json changed", got)
+	}
+
+	// Corrupted caches are ignored
+	if err := os.WriteFile(cachePath, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := listTags(t); got != "myapp:v2" {
+		t.Errorf("List() = %s with corrupted cache", got)
+	}
+}
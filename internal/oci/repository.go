@@ -4,14 +4,19 @@
 package oci
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
+	"github.com/opencontainers/go-digest"
 	v1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2"
 	"oras.land/oras-go/v2/content"
@@ -20,45 +25,104 @@ import (
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote"
-	"oras.land/oras-go/v2/registry/remote/auth"
-	"oras.land/oras-go/v2/registry/remote/retry"
 
+	"github.com/chez-shanpu/kubectl-mft/internal/delta"
 	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/paths"
 )
 
 const (
 	artifactType     = "application/vnd.kubectl-mft.v1"
 	contentMediaType = "application/vnd.kubectl-mft.content.v1+yaml"
+	deltaMediaType   = "application/vnd.kubectl-mft.content.delta.v1"
+
+	// annotationDeltaBase records the digest of the manifest a delta layer was computed against
+	annotationDeltaBase = "io.kubectl-mft.delta.base"
 
 	// DefaultRegistry is the default registry name used for simple tag names without a slash
 	DefaultRegistry = "local"
 )
 
-const (
-	workingDIR = "/tmp/kubectl-mft"
-)
+var (
+	// workRoot is the parent of the per-invocation working directories
+	workRoot = paths.TempDir()
+
+	baseDir string
 
-var baseDir string
+	// systemDirs are read-only storage overlays searched after baseDir
+	systemDirs []string
+)
 
 // InitBaseDir initializes the base storage directory path.
 // It checks the KUBECTL_MFT_STORAGE_DIR environment variable first,
-// then falls back to the default location under the user's home directory.
+// then falls back to the manifests directory under the kubectl-mft data directory.
+// Read-only overlays are taken from KUBECTL_MFT_SYSTEM_STORAGE_DIR, a list separated
+// by the OS path list separator, defaulting to the manifests directory under the
+// system data directory.
+// Per-invocation working directories are created under KUBECTL_MFT_WORK_DIR when set.
 func InitBaseDir() error {
+	if dir := os.Getenv("KUBECTL_MFT_WORK_DIR"); dir != "" {
+		workRoot = dir
+	}
+
+	systemDirs = []string{filepath.Join(paths.SystemDataDir(), "manifests")}
+	if dirs, ok := os.LookupEnv("KUBECTL_MFT_SYSTEM_STORAGE_DIR"); ok {
+		systemDirs = filepath.SplitList(dirs)
+	}
+
+	if err := initCacheDir(); err != nil {
+		return err
+	}
+
 	if dir := os.Getenv("KUBECTL_MFT_STORAGE_DIR"); dir != "" {
 		baseDir = dir
 		return nil
 	}
 
-	home, err := os.UserHomeDir()
+	dataDir, err := paths.DataDir()
 	if err != nil {
-		return fmt.Errorf("failed to get user home directory: %w", err)
+		return err
 	}
-	baseDir = filepath.Join(home, ".local", "share", "kubectl-mft", "manifests")
+	baseDir = filepath.Join(dataDir, "manifests")
 	return nil
 }
 
+// SetBaseDir replaces the writable storage directory path chosen by InitBaseDir.
+func SetBaseDir(dir string) {
+	baseDir = dir
+}
+
+// BaseDir returns the writable storage directory path.
+func BaseDir() string {
+	return baseDir
+}
+
+// SystemDirs returns the read-only storage overlay directory paths.
+func SystemDirs() []string {
+	return systemDirs
+}
+
+// WorkDir returns the directory under which per-invocation working directories are created.
+func WorkDir() string {
+	return workRoot
+}
+
 type Repository struct {
 	ref *registry.Reference
+	// annotations are added to manifests created by Save and SaveDelta
+	annotations map[string]string
+	// warn receives warnings, see SetWarningHandler
+	warn func(msg string)
+	// bandwidthLimit caps registry transfers in bytes per second, see SetBandwidthLimit
+	bandwidthLimit int64
+	// pinned is the manifest Pull fetches instead of resolving the tag, see SetPinnedDigest
+	pinned digest.Digest
+	// cached makes the cache the writable storage of the repository, see UseCache
+	cached bool
+	// layout is read instead of local storage, see Staged.Repository
+	layout string
+	// root is the writable storage instead of the directory set by InitBaseDir, see NewRepositoryIn
+	root string
 }
 
 func NewRepository(tag string) (*Repository, error) {
@@ -70,13 +134,52 @@ func NewRepository(tag string) (*Repository, error) {
 	return &Repository{ref: ref}, nil
 }
 
-func (r *Repository) Copy(ctx context.Context, dest string) error {
+// NewRepositoryIn returns a repository whose writable storage is root instead of the
+// process-wide storage directory, which an empty root stands for. System overlays and the
+// cache are shared.
+func NewRepositoryIn(root, tag string) (*Repository, error) {
+	r, err := NewRepository(tag)
+	if err != nil {
+		return nil, err
+	}
+	r.root = root
+	return r, nil
+}
+
+// storageDir returns the writable storage of the repository.
+func (r *Repository) storageDir() string {
+	if r.root != "" {
+		return r.root
+	}
+	return baseDir
+}
+
+// SetAnnotations sets annotations to add to the manifests created by Save and SaveDelta.
+// The title annotation is always set to the repository name.
+func (r *Repository) SetAnnotations(annotations map[string]string) {
+	r.annotations = annotations
+}
+
+// manifestAnnotations returns the annotations of manifests created for this repository.
+func (r *Repository) manifestAnnotations() map[string]string {
+	annotations := maps.Clone(r.annotations)
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[v1.AnnotationTitle] = r.Name()
+	return annotations
+}
+
+// Copy copies the manifest and its referrers to dest in local storage. An existing destination
+// tag is only replaced with opts.Force; the manifest it pointed to is then deleted unless it is
+// still tagged or part of a bundle, while blobs shared with the new manifest are kept.
+func (r *Repository) Copy(ctx context.Context, dest string, opts mft.CopyOptions) error {
 	drepo, err := NewRepository(dest)
 	if err != nil {
 		return fmt.Errorf("creating repository: %w", err)
 	}
 
-	sstore, err := r.newOCILayoutStore()
+	sstore, err := r.newReadOnlyStore(ctx)
 	if err != nil {
 		return err
 	}
@@ -96,18 +199,38 @@ func (r *Repository) Copy(ctx context.Context, dest string) error {
 		return err
 	}
 
-	_, err = destStore.Resolve(ctx, drepo.ref.ReferenceOrDefault())
-	if err == nil {
-		return fmt.Errorf("destination tag %q already exists", drepo.ref.ReferenceOrDefault())
+	prev, err := destStore.Resolve(ctx, drepo.ref.ReferenceOrDefault())
+	replaced := err == nil
+	if replaced && !opts.Force {
+		return fmt.Errorf("destination tag %q already exists (use --force to overwrite)", drepo.ref.ReferenceOrDefault())
 	}
-	if !errors.Is(err, errdef.ErrNotFound) {
+	if err != nil && !errors.Is(err, errdef.ErrNotFound) {
 		return fmt.Errorf("failed to check destination tag: %w", err)
 	}
 
-	return r.extendedCopy(ctx, sstore, r.ref.ReferenceOrDefault(), destStore, drepo.ref.ReferenceOrDefault())
+	if err := r.extendedCopy(ctx, sstore, r.ref.ReferenceOrDefault(), destStore, drepo.ref.ReferenceOrDefault()); err != nil {
+		return err
+	}
+	if err := clearAlias(ctx, destStore, drepo.ref.ReferenceOrDefault()); err != nil {
+		return err
+	}
+	if replaced {
+		if err := deleteOrphanedManifests(ctx, destStore, drepo.userLayoutPath(), []v1.Descriptor{prev}); err != nil {
+			return fmt.Errorf("failed to clean up replaced manifest: %w", err)
+		}
+	}
+	if err := drepo.SyncMetadata(ctx); err != nil {
+		return err
+	}
+	return drepo.RecordEvent(ctx, mft.EventCopied)
 }
 
 func (r *Repository) Delete(ctx context.Context) (*mft.DeleteResult, error) {
+	// Opening the layout creates it, so a repository the writable storage does not hold is
+	// not opened at all
+	if _, err := os.Stat(filepath.Join(r.userLayoutPath(), "index.json")); os.IsNotExist(err) {
+		return nil, r.deleteNotFound()
+	}
 	layoutStore, err := r.newOCILayoutStore()
 	if err != nil {
 		return nil, err
@@ -115,107 +238,251 @@ func (r *Repository) Delete(ctx context.Context) (*mft.DeleteResult, error) {
 
 	desc, err := layoutStore.Resolve(ctx, r.ref.ReferenceOrDefault())
 	if err != nil {
-		// If not found, return nil (idempotent behavior)
 		if errors.Is(err, errdef.ErrNotFound) {
-			return nil, nil
+			return nil, r.deleteNotFound()
 		}
 		return nil, fmt.Errorf("failed to resolve reference %s: %w", r.ref.ReferenceOrDefault(), err)
 	}
 
-	if err := layoutStore.Delete(ctx, desc); err != nil {
-		return nil, fmt.Errorf("failed to delete manifest: %w", err)
+	if desc.Annotations[annotationAliasOf] != "" {
+		// The manifest of an alias belongs to its target, only the alias tag is removed
+		if err := layoutStore.Untag(ctx, r.ref.ReferenceOrDefault()); err != nil {
+			return nil, fmt.Errorf("failed to delete alias: %w", err)
+		}
+	} else {
+		var members []v1.Descriptor
+		if desc.MediaType == v1.MediaTypeImageIndex {
+			if members, err = indexMembers(ctx, layoutStore, desc); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := layoutStore.Delete(ctx, desc); err != nil {
+			return nil, fmt.Errorf("failed to delete manifest: %w", err)
+		}
+
+		if err := deleteOrphanedManifests(ctx, layoutStore, r.userLayoutPath(), members); err != nil {
+			return nil, err
+		}
 	}
 
-	indexDir := filepath.Join(baseDir, r.Name())
+	indexDir := r.userLayoutPath()
 	if err := deleteRepositoryIfEmpty(indexDir); err != nil {
 		return nil, fmt.Errorf("failed to delete repository: %w", err)
 	}
+	if err := r.SyncMetadata(ctx); err != nil {
+		return nil, err
+	}
+	if err := r.recordEvent(mft.EventDeleted, desc.Digest.String()); err != nil {
+		return nil, err
+	}
 
-	return mft.NewDeleteResult(
-		r.Name(),
-		r.ref.ReferenceOrDefault(),
-	), nil
+	return mft.NewDeleteResult(r.String()), nil
 }
 
-func (r *Repository) Dump(ctx context.Context) (*mft.DumpResult, error) {
-	layoutStore, err := r.newOCILayoutStore()
+// Dump returns the content of the manifest. The content blob is streamed and verified
+// against its digest while it is read, the reader failing instead of returning io.EOF if it
+// does not match. Content stored as a delta is reconstructed in memory.
+func (r *Repository) Dump(ctx context.Context) (io.ReadCloser, error) {
+	layoutStore, err := r.newReadOnlyStore(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	desc, err := layoutStore.Resolve(ctx, r.ref.ReferenceOrDefault())
+	_, m, err := fetchManifest(ctx, layoutStore, r.ref.ReferenceOrDefault())
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve reference %s: %w", r.ref.ReferenceOrDefault(), err)
+		return nil, err
 	}
 
-	manifestJSON, err := content.FetchAll(ctx, layoutStore, desc)
+	if len(m.Layers) == 1 && m.Layers[0].MediaType != deltaMediaType {
+		rc, err := layoutStore.Fetch(ctx, m.Layers[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch content for %s: %w", r.ref.ReferenceOrDefault(), err)
+		}
+		return newVerifiedReader(rc, m.Layers[0]), nil
+	}
+	b, err := readContent(ctx, layoutStore, m)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch content for %s: %w", r.ref.ReferenceOrDefault(), err)
 	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
 
-	var m v1.Manifest
-	if err := json.Unmarshal(manifestJSON, &m); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal manifest: %w", err)
+// verifiedReader streams a blob, verifying its size and digest as it is read.
+type verifiedReader struct {
+	rc   io.ReadCloser
+	vr   *content.VerifyReader
+	desc v1.Descriptor
+}
+
+func newVerifiedReader(rc io.ReadCloser, desc v1.Descriptor) *verifiedReader {
+	return &verifiedReader{rc: rc, vr: content.NewVerifyReader(rc, desc), desc: desc}
+}
+
+// Read returns io.EOF only once all content has been read and matched the digest.
+func (v *verifiedReader) Read(p []byte) (int, error) {
+	n, err := v.vr.Read(p)
+	if err == io.EOF {
+		if verr := v.vr.Verify(); verr != nil {
+			return n, fmt.Errorf("content blob %s is corrupted: %w", v.desc.Digest, verr)
+		}
+	} else if err != nil {
+		return n, fmt.Errorf("content blob %s is corrupted: %w", v.desc.Digest, err)
+	}
+	return n, err
+}
+
+func (v *verifiedReader) Close() error {
+	return v.rc.Close()
+}
+
+// Path returns the path of the content blob of the manifest. The blob is checked against the
+// size and digest recorded in the manifest, so a missing or modified blob is reported as an
+// error instead of returning a path to content that does not match.
+func (r *Repository) Path(ctx context.Context) (*mft.PathResult, error) {
+	layoutStore, err := r.newReadOnlyStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, m, err := fetchManifest(ctx, layoutStore, r.ref.ReferenceOrDefault())
+	if err != nil {
+		return nil, err
 	}
 
+	if isDelta(m) {
+		return nil, fmt.Errorf("%s is stored as a delta against another version, use 'dump' to reconstruct its content", r.ref.ReferenceOrDefault())
+	}
 	if len(m.Layers) != 1 {
 		return nil, fmt.Errorf("expected a single layer in the manifest, got %d", len(m.Layers))
 	}
 
-	b, err := content.FetchAll(ctx, layoutStore, m.Layers[0])
+	layer := m.Layers[0]
+	path := blobPath(r.LayoutPath(), layer.Digest)
+	reason, err := checkBlob(path, layer)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch content for %s: %w", r.ref.ReferenceOrDefault(), err)
+		return nil, err
+	}
+	if reason != "" {
+		return nil, fmt.Errorf("content blob %s of %s is corrupted (%s), run 'kubectl mft verify-content --repair %s' to restore it from the registry",
+			layer.Digest, r.ref.ReferenceOrDefault(), reason, r.ref.ReferenceOrDefault())
 	}
-	return mft.NewDumpResult(b), nil
+	return mft.NewPathResult(path, layer.Digest.String()), nil
 }
 
-func (r *Repository) Path(ctx context.Context) (*mft.PathResult, error) {
-	layoutStore, err := r.newOCILayoutStore()
+// Pull downloads the manifest and its referrers into a staging layout first, so that an
+// interrupted or failed download leaves no partial content in local storage.
+// On registries without the OCI referrers API, signatures published under the
+// sha256-<digest>.sig tag are downloaded as well.
+// The mirrors configured for the registry are tried in order before it, see PulledFrom.
+func (r *Repository) Pull(ctx context.Context) error {
+	s, err := r.stagePull(ctx, true)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer s.Discard()
+	return s.Commit(ctx)
+}
 
-	desc, err := layoutStore.Resolve(ctx, r.ref.ReferenceOrDefault())
+// StagePull pulls the tag from its sources into a staging layout like Pull, leaving local
+// storage untouched until the staged manifest is committed, e.g. to verify the pulled
+// manifest before it replaces the local copy.
+func (r *Repository) StagePull(ctx context.Context) (*Staged, error) {
+	return r.stagePull(ctx, true)
+}
+
+// stagePull pulls the tag from its sources into a staging layout, leaving local storage
+// untouched until the staged manifest is committed. If seed is true, blobs already present
+// and intact in local storage are copied from there instead of being downloaded again.
+func (r *Repository) stagePull(ctx context.Context, seed bool) (_ *Staged, err error) {
+	sources, err := r.pullSources()
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve reference %s: %w", r.ref.ReferenceOrDefault(), err)
+		return nil, err
 	}
 
-	manifestJSON, err := content.FetchAll(ctx, layoutStore, desc)
+	s, store, err := r.newStaged()
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch content for %s: %w", r.ref.ReferenceOrDefault(), err)
+		return nil, err
 	}
-
-	var m v1.Manifest
-	if err := json.Unmarshal(manifestJSON, &m); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal manifest: %w", err)
+	defer func() {
+		if err != nil {
+			s.Discard()
+		}
+	}()
+	s.event = mft.EventPulled
+	seedPath := ""
+	if seed {
+		seedPath = r.LayoutPath()
 	}
 
-	if len(m.Layers) != 1 {
-		return nil, fmt.Errorf("expected a single layer in the manifest, got %d", len(m.Layers))
+	for i, src := range sources {
+		err = r.pullFrom(ctx, src, store, seedPath)
+		if err == nil || i == len(sources)-1 || ctx.Err() != nil {
+			break
+		}
+		r.warnf("failed to pull %s from mirror %s, trying the next source: %v", r.ref, src, err)
 	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
 
-	layerDigest := m.Layers[0].Digest
-	blobPath := filepath.Join(baseDir, r.Name(), "blobs", layerDigest.Algorithm().String(), layerDigest.Encoded())
+// SetPinnedDigest makes Pull fetch the manifest with the digest instead of the manifest the tag
+// points to in the registry, and store it under the tag, e.g. to reproduce a lockfile after
+// the tag was pushed again. The pulled content is verified against the digest.
+func (r *Repository) SetPinnedDigest(d digest.Digest) {
+	r.pinned = d
+}
 
-	return mft.NewPathResult(blobPath), nil
+// RemoteDigest returns the digest of the manifest the tag points to in its registry. Only the
+// manifest is resolved remotely (a HEAD request), no content is fetched.
+func (r *Repository) RemoteDigest(ctx context.Context) (string, error) {
+	if r.ref.Registry == DefaultRegistry {
+		return "", fmt.Errorf("%s is only in local storage, push it to a registry first", r.ref)
+	}
+	repo, err := r.newAuthenticatedRepository()
+	if err != nil {
+		return "", err
+	}
+	desc, err := repo.Resolve(ctx, r.ref.ReferenceOrDefault())
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve remote reference %s: %w", r.ref, err)
+	}
+	return desc.Digest.String(), nil
 }
 
-func (r *Repository) Pull(ctx context.Context) error {
-	layoutStore, err := r.newOCILayoutStore()
+// UpToDate reports whether the tag exists locally and resolves to the same manifest digest
+// as in the remote registry. Only the manifest is resolved remotely (a HEAD request), no content is fetched.
+func (r *Repository) UpToDate(ctx context.Context) (bool, error) {
+	layoutStore, err := r.newReadOnlyStore(ctx)
 	if err != nil {
-		return err
+		return false, err
+	}
+	local, err := layoutStore.Resolve(ctx, r.ref.ReferenceOrDefault())
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
 	}
 
 	repo, err := r.newAuthenticatedRepository()
 	if err != nil {
-		return err
+		return false, err
+	}
+	remoteDesc, err := repo.Resolve(ctx, r.ref.ReferenceOrDefault())
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve remote reference %s: %w", r.ref, err)
 	}
 
-	return r.extendedCopy(ctx, repo, r.ref.ReferenceOrDefault(), layoutStore, r.ref.ReferenceOrDefault())
+	return local.Digest == remoteDesc.Digest, nil
 }
 
+// Push uploads the manifest and its referrers. On registries without the OCI referrers API,
+// the signatures are additionally published under the sha256-<digest>.sig tag.
 func (r *Repository) Push(ctx context.Context) error {
-	layoutStore, err := r.newOCILayoutStore()
+	layoutStore, err := r.newReadOnlyStore(ctx)
 	if err != nil {
 		return err
 	}
@@ -225,26 +492,43 @@ func (r *Repository) Push(ctx context.Context) error {
 		return err
 	}
 
-	return r.extendedCopy(ctx, layoutStore, r.ref.ReferenceOrDefault(), repo, r.ref.ReferenceOrDefault())
-}
-
-func (r *Repository) Save(ctx context.Context, manifestPath string) (err error) {
-	fs, err := r.newFileStore(ctx, manifestPath)
+	if err := r.extendedCopy(ctx, layoutStore, r.ref.ReferenceOrDefault(), repo, r.ref.ReferenceOrDefault()); err != nil {
+		return err
+	}
+	desc, err := layoutStore.Resolve(ctx, r.ref.ReferenceOrDefault())
 	if err != nil {
+		return fmt.Errorf("failed to resolve reference %s: %w", r.ref.ReferenceOrDefault(), err)
+	}
+	if err := r.pushSignatureTag(ctx, layoutStore, repo, desc); err != nil {
 		return err
 	}
-	defer func() {
-		if closeErr := fs.Close(); closeErr != nil && err == nil {
-			err = fmt.Errorf("warning: failed to close manifestPath content: %w", err)
-		}
-	}()
+	return r.recordEvent(mft.EventPushed, desc.Digest.String())
+}
 
-	layoutStore, err := r.newOCILayoutStore()
+func (r *Repository) Save(ctx context.Context, manifestPath string) error {
+	return r.save(ctx, manifestPath, "")
+}
+
+// SaveDelta packages a Kubernetes manifest as a patch against the content of baseTag
+// in the same repository. The resulting artifact reuses the base layers and adds a
+// single delta layer, so registries only store and transfer the changed lines.
+func (r *Repository) SaveDelta(ctx context.Context, manifestPath string, baseTag string) error {
+	return r.save(ctx, manifestPath, baseTag)
+}
+
+// save stages the manifest and commits it to local storage right away.
+func (r *Repository) save(ctx context.Context, manifestPath, baseTag string) error {
+	s, err := r.Stage(ctx, manifestPath, baseTag)
 	if err != nil {
 		return err
 	}
+	defer s.Discard()
+	return s.Commit(ctx)
+}
 
-	return r.copy(ctx, fs, r.ref.ReferenceOrDefault(), layoutStore, r.ref.ReferenceOrDefault())
+// Registry returns the registry host of the repository, DefaultRegistry for local repositories.
+func (r *Repository) Registry() string {
+	return r.ref.Registry
 }
 
 func (r *Repository) Name() string {
@@ -259,8 +543,35 @@ func (r *Repository) Name() string {
 }
 
 // LayoutPath returns the local OCI layout directory path for this repository.
+// If the tag is not in the writable storage but is provided by a read-only
+// system overlay or the cache, in this order, that layout path is returned.
 func (r *Repository) LayoutPath() string {
-	return filepath.Join(baseDir, r.Name())
+	if r.layout != "" {
+		return r.layout
+	}
+	userPath := r.userLayoutPath()
+	if hasReference(userPath, r.ref.ReferenceOrDefault()) {
+		return userPath
+	}
+	for _, dir := range systemDirs {
+		p := layoutDir(dir, r.Name())
+		if hasReference(p, r.ref.ReferenceOrDefault()) {
+			return p
+		}
+	}
+	if cacheDir != "" && hasReference(r.cacheLayoutPath(), r.ref.ReferenceOrDefault()) {
+		return r.cacheLayoutPath()
+	}
+	return userPath
+}
+
+// userLayoutPath returns the OCI layout directory path in the writable storage, which is
+// the cache if UseCache was called.
+func (r *Repository) userLayoutPath() string {
+	if r.cached {
+		return r.cacheLayoutPath()
+	}
+	return layoutDir(r.storageDir(), r.Name())
 }
 
 // Tag returns the tag or digest reference string used in the OCI layout.
@@ -270,7 +581,11 @@ func (r *Repository) Tag() string {
 
 // Exists checks if the manifest exists in local OCI layout storage.
 func (r *Repository) Exists(ctx context.Context) (bool, error) {
-	layoutStore, err := r.newOCILayoutStore()
+	// Opening the store would create an empty layout for an unknown repository
+	if _, err := os.Stat(r.LayoutPath()); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	layoutStore, err := r.newReadOnlyStore(ctx)
 	if err != nil {
 		return false, err
 	}
@@ -284,6 +599,45 @@ func (r *Repository) Exists(ctx context.Context) (bool, error) {
 	return true, nil
 }
 
+// InStorage reports whether the tag is in the writable storage, or in the cache if UseCache
+// was called. Unlike Exists, it ignores the copies of system storage and the cache, which a
+// pull does not replace.
+func (r *Repository) InStorage() bool {
+	return hasReference(r.userLayoutPath(), r.Tag())
+}
+
+// ReadOnly reports whether the tag is read from a system overlay or the cache instead of the
+// writable storage. Commands that would write to its layout must refuse it.
+func (r *Repository) ReadOnly() bool {
+	return r.LayoutPath() != r.userLayoutPath()
+}
+
+// Digest returns the digest of the manifest of the tag in local storage.
+func (r *Repository) Digest(ctx context.Context) (string, error) {
+	layoutStore, err := r.newReadOnlyStore(ctx)
+	if err != nil {
+		return "", err
+	}
+	desc, err := layoutStore.Resolve(ctx, r.ref.ReferenceOrDefault())
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve reference %s: %w", r.ref.ReferenceOrDefault(), err)
+	}
+	return desc.Digest.String(), nil
+}
+
+// Annotations returns the annotations of the manifest in local storage.
+func (r *Repository) Annotations(ctx context.Context) (map[string]string, error) {
+	layoutStore, err := r.newReadOnlyStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, m, err := fetchManifest(ctx, layoutStore, r.ref.ReferenceOrDefault())
+	if err != nil {
+		return nil, err
+	}
+	return m.Annotations, nil
+}
+
 // copy copies a single manifest between OCI targets.
 func (r *Repository) copy(ctx context.Context, source oras.ReadOnlyTarget, srcRef string, dest oras.Target, destRef string) error {
 	_, err := oras.Copy(ctx, source, srcRef, dest, destRef, oras.DefaultCopyOptions)
@@ -309,6 +663,11 @@ func (r *Repository) formatCopyError(err error) error {
 			r.ref.Registry, r.ref.Repository, r.ref.ReferenceOrDefault())
 	}
 
+	if errors.Is(err, context.Canceled) {
+		return fmt.Errorf("transfer of %s/%s:%s was canceled: %w",
+			r.ref.Registry, r.ref.Repository, r.ref.ReferenceOrDefault(), err)
+	}
+
 	errorMsg := err.Error()
 
 	// Check for common error patterns and provide helpful messages
@@ -333,37 +692,50 @@ func (r *Repository) formatCopyError(err error) error {
 
 // newAuthenticatedRepository creates and configures a repository with authentication
 func (r *Repository) newAuthenticatedRepository() (*remote.Repository, error) {
-	c, err := newCredentialFunc()
+	return r.newRemoteRepository(r.ref.Registry, r.ref.Repository)
+}
+
+// newRemoteRepository creates and configures an authenticated repository in the given registry,
+// such as a mirror of the registry of r.
+func (r *Repository) newRemoteRepository(registry, repository string) (*remote.Repository, error) {
+	client, err := newAuthClient(registry)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create credential for registry %s: %w", r.ref.Registry, err)
+		return nil, err
 	}
 
-	repo, err := remote.NewRepository(filepath.Join(r.ref.Registry, r.ref.Repository))
+	repo, err := remote.NewRepository(path.Join(registry, repository))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create repository %s/%s: %w", r.ref.Registry, r.ref.Repository, err)
+		return nil, fmt.Errorf("failed to create repository %s/%s: %w", registry, repository, err)
 	}
 
-	repo.Client = &auth.Client{
-		Client:     retry.DefaultClient,
-		Cache:      auth.NewCache(),
-		Credential: c,
+	if r.bandwidthLimit > 0 {
+		client.Client.Transport = &throttledTransport{base: client.Client.Transport, limiter: newRateLimiter(r.bandwidthLimit)}
 	}
+	repo.Client = client
 
 	// Enable PlainHTTP for localhost registries (for testing)
-	if isLocalRegistry(r.ref.Registry) {
+	if isLocalRegistry(registry) {
 		repo.PlainHTTP = true
 	}
 
 	return repo, nil
 }
 
-func (r *Repository) newFileStore(ctx context.Context, manifestPath string) (*file.Store, error) {
-	// Clean up working directory to ensure a fresh start for each operation
-	if err := os.RemoveAll(workingDIR); err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("failed to clean working directory: %w", err)
+// newWorkDir creates a working directory private to this invocation so that concurrent
+// operations do not share state. The caller is responsible for removing it.
+func newWorkDir() (string, error) {
+	if err := os.MkdirAll(workRoot, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create working directory root: %w", err)
+	}
+	dir, err := os.MkdirTemp(workRoot, "work-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create working directory: %w", err)
 	}
+	return dir, nil
+}
 
-	fs, err := file.New(workingDIR)
+func (r *Repository) newFileStore(ctx context.Context, workDir, manifestPath string) (*file.Store, error) {
+	fs, err := file.New(workDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create file store: %w", err)
 	}
@@ -381,10 +753,8 @@ func (r *Repository) newFileStore(ctx context.Context, manifestPath string) (*fi
 	}
 
 	manifestDesc, err := oras.PackManifest(ctx, fs, oras.PackManifestVersion1_1, artifactType, oras.PackManifestOptions{
-		Layers: []v1.Descriptor{contentDesc},
-		ManifestAnnotations: map[string]string{
-			"org.opencontainers.image.title": r.Name(),
-		},
+		Layers:              []v1.Descriptor{contentDesc},
+		ManifestAnnotations: r.manifestAnnotations(),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to pack manifestPath: %w", err)
@@ -400,7 +770,7 @@ func (r *Repository) newFileStore(ctx context.Context, manifestPath string) (*fi
 }
 
 func (r *Repository) newOCILayoutStore() (*oci.Store, error) {
-	layoutPath := filepath.Join(baseDir, r.Name())
+	layoutPath := r.userLayoutPath()
 	layoutStore, err := oci.New(layoutPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create oci-layout store: %w", err)
@@ -408,6 +778,111 @@ func (r *Repository) newOCILayoutStore() (*oci.Store, error) {
 	return layoutStore, nil
 }
 
+// fetchManifest resolves ref in the store and decodes the image manifest it points to.
+func fetchManifest(ctx context.Context, store oras.ReadOnlyTarget, ref string) (v1.Descriptor, *v1.Manifest, error) {
+	desc, err := store.Resolve(ctx, ref)
+	if err != nil {
+		return v1.Descriptor{}, nil, fmt.Errorf("failed to resolve reference %s: %w", ref, err)
+	}
+	if desc.MediaType == v1.MediaTypeImageIndex {
+		return v1.Descriptor{}, nil, fmt.Errorf("%s is a bundle, use 'bundle show' to list its members", ref)
+	}
+
+	manifestJSON, err := content.FetchAll(ctx, store, desc)
+	if err != nil {
+		return v1.Descriptor{}, nil, fmt.Errorf("failed to fetch content for %s: %w", ref, err)
+	}
+
+	var m v1.Manifest
+	if err := json.Unmarshal(manifestJSON, &m); err != nil {
+		return v1.Descriptor{}, nil, fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+	return desc, &m, nil
+}
+
+// readContent returns the full manifest content of m.
+// The first layer holds the content itself; any following delta layers are applied in order.
+func readContent(ctx context.Context, store content.Fetcher, m *v1.Manifest) ([]byte, error) {
+	if len(m.Layers) == 0 {
+		return nil, fmt.Errorf("expected at least one layer in the manifest, got 0")
+	}
+	if m.Layers[0].MediaType == deltaMediaType {
+		return nil, fmt.Errorf("first layer must hold the full content, got a delta layer")
+	}
+	if len(m.Layers) > 1 && !isDelta(m) {
+		return nil, fmt.Errorf("expected a single layer in the manifest, got %d", len(m.Layers))
+	}
+
+	b, err := content.FetchAll(ctx, store, m.Layers[0])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range m.Layers[1:] {
+		patch, err := content.FetchAll(ctx, store, l)
+		if err != nil {
+			return nil, err
+		}
+		if b, err = delta.Apply(b, patch); err != nil {
+			return nil, fmt.Errorf("failed to apply delta layer %s: %w", l.Digest, err)
+		}
+	}
+	return b, nil
+}
+
+// isDelta reports whether m stores its content as delta layers on top of a base layer.
+func isDelta(m *v1.Manifest) bool {
+	if len(m.Layers) < 2 {
+		return false
+	}
+	for _, l := range m.Layers[1:] {
+		if l.MediaType != deltaMediaType {
+			return false
+		}
+	}
+	return true
+}
+
+// newReadOnlyStore opens the layout holding the tag for reading.
+// Layouts in a system overlay are opened without writing anything to them.
+func (r *Repository) newReadOnlyStore(ctx context.Context) (oras.ReadOnlyGraphTarget, error) {
+	layoutPath := r.LayoutPath()
+	if layoutPath == r.userLayoutPath() {
+		return r.newOCILayoutStore()
+	}
+	layoutStore, err := oci.NewFromFS(ctx, os.DirFS(layoutPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open read-only oci-layout store %s: %w", layoutPath, err)
+	}
+	return layoutStore, nil
+}
+
+// hasReference reports whether the layout at layoutPath has a manifest with the given tag or digest.
+func hasReference(layoutPath, ref string) bool {
+	index, err := loadIndexFile(layoutPath)
+	if err != nil {
+		return false
+	}
+	for _, d := range index.Manifests {
+		if d.Annotations[v1.AnnotationRefName] == ref || d.Digest.String() == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteNotFound returns the error of Delete for a tag the writable storage does not hold:
+// none, as deleting is idempotent, unless the tag is only cached or in a system overlay.
+func (r *Repository) deleteNotFound() error {
+	if r.InCache() {
+		return fmt.Errorf("%s is only cached, remove it with 'kubectl mft cache clear'", r)
+	}
+	if r.LayoutPath() != r.userLayoutPath() {
+		return fmt.Errorf("%s is provided by read-only system storage and cannot be deleted", r.ref.ReferenceOrDefault())
+	}
+	return nil
+}
+
 func deleteRepositoryIfEmpty(indexDir string) error {
 	indexData, err := os.ReadFile(filepath.Join(indexDir, "index.json"))
 	if err != nil {
@@ -432,7 +907,13 @@ func deleteRepositoryIfEmpty(indexDir string) error {
 
 // parseReference parses and validates the OCI reference.
 // If the tag doesn't contain a slash, it prepends the default registry name.
+// With SetStrictReferences, the reference is checked with CheckReference first.
 func parseReference(tag string) (*registry.Reference, error) {
+	if strictReferences {
+		if err := CheckReference(tag); err != nil {
+			return nil, err
+		}
+	}
 	normalizedTag := normalizeTag(tag)
 	ref, err := registry.ParseReference(normalizedTag)
 	if err != nil {
@@ -1,14 +1,23 @@
 package oci
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2/errdef"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/delta"
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
 )
 
 func TestParseReference(t *testing.T) {
@@ -118,6 +127,13 @@ func TestFormatCopyError(t *testing.T) {
 					"network connection",
 				},
 			},
+			{
+				name:       "canceled transfer",
+				inputError: fmt.Errorf("failed to perform \"Fetch\" on source: %w", context.Canceled),
+				expectedMsg: []string{
+					"transfer of docker.io/user/app:v1.0.0 was canceled",
+				},
+			},
 			{
 				name:       "network error with timeout",
 				inputError: errors.New("request timeout"),
@@ -336,7 +352,7 @@ func TestCopyDifferentTag(t *testing.T) {
 	}
 
 	// Copy to a different tag in a different repository
-	if err := srcRepo.Copy(ctx, "otherrepo:dest"); err != nil {
+	if err := srcRepo.Copy(ctx, "otherrepo:dest", mft.CopyOptions{}); err != nil {
 		t.Fatalf("Copy() failed: %v", err)
 	}
 
@@ -389,7 +405,7 @@ func TestCopyDuplicateTagError(t *testing.T) {
 	}
 
 	// Copy should fail because dest tag already exists
-	err = srcRepo.Copy(ctx, "myrepo:v2")
+	err = srcRepo.Copy(ctx, "myrepo:v2", mft.CopyOptions{})
 	if err == nil {
 		t.Fatal("Copy() should have failed when dest tag already exists")
 	}
@@ -398,6 +414,90 @@ func TestCopyDuplicateTagError(t *testing.T) {
 	}
 }
 
+func TestCopyForce(t *testing.T) {
+	origBaseDir := baseDir
+	baseDir = t.TempDir()
+	t.Cleanup(func() { baseDir = origBaseDir })
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	save := func(tag, data string) *Repository {
+		t.Helper()
+		path := filepath.Join(dir, strings.ReplaceAll(tag, ":", "-")+".yaml")
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatalf("failed to create test manifest: %v", err)
+		}
+		r, err := NewRepository(tag)
+		if err != nil {
+			t.Fatalf("NewRepository(%s) failed: %v", tag, err)
+		}
+		if err := r.Save(ctx, path); err != nil {
+			t.Fatalf("Save(%s) failed: %v", tag, err)
+		}
+		return r
+	}
+	first := save("myrepo:v1", "version: 1\n")
+	second := save("myrepo:v2", "version: 2\n")
+	if err := first.Copy(ctx, "myrepo:staging", mft.CopyOptions{}); err != nil {
+		t.Fatalf("Copy() failed: %v", err)
+	}
+	staging, err := NewRepository("myrepo:staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Move staging from v1 to v2, then to a manifest only staging refers to, then back to v2
+	if err := second.Copy(ctx, "myrepo:staging", mft.CopyOptions{Force: true}); err != nil {
+		t.Fatalf("Copy() with Force failed: %v", err)
+	}
+	orphan := save("other:v3", "version: 3\n")
+	if err := orphan.Copy(ctx, "myrepo:staging", mft.CopyOptions{Force: true}); err != nil {
+		t.Fatalf("Copy() with Force failed: %v", err)
+	}
+	index, err := loadIndexFile(first.LayoutPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v3Digest digest.Digest
+	for _, m := range index.Manifests {
+		if m.Annotations[v1.AnnotationRefName] == "staging" {
+			v3Digest = m.Digest
+		}
+	}
+	if err := second.Copy(ctx, "myrepo:staging", mft.CopyOptions{Force: true}); err != nil {
+		t.Fatalf("Copy() with Force failed: %v", err)
+	}
+
+	var buf strings.Builder
+	res, err := staging.Dump(ctx)
+	if err != nil {
+		t.Fatalf("Dump() failed: %v", err)
+	}
+	defer res.Close()
+	if _, err := io.Copy(&buf, res); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "version: 2\n" {
+		t.Errorf("Dump(staging) = %q, want %q", buf.String(), "version: 2\n")
+	}
+
+	// v1 is still tagged and kept, the manifest only staging pointed to is removed
+	if exists, err := first.Exists(ctx); err != nil || !exists {
+		t.Errorf("Exists(first) = %v, %v, want true", exists, err)
+	}
+	index, err = loadIndexFile(first.LayoutPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Manifests) != 3 {
+		t.Errorf("index has %d manifests, want 3: %+v", len(index.Manifests), index.Manifests)
+	}
+	if _, err := os.Stat(blobPath(first.LayoutPath(), v3Digest)); !os.IsNotExist(err) {
+		t.Errorf("replaced manifest %s was not deleted: %v", v3Digest, err)
+	}
+}
+
 func TestIsLocalRegistry(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -450,3 +550,466 @@ func TestIsLocalRegistry(t *testing.T) {
 		})
 	}
 }
+
+func TestSaveConcurrent(t *testing.T) {
+	origBaseDir, origWorkRoot := baseDir, workRoot
+	baseDir = t.TempDir()
+	workRoot = t.TempDir()
+	t.Cleanup(func() { baseDir, workRoot = origBaseDir, origWorkRoot })
+
+	ctx := context.Background()
+	const n = 8
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path := filepath.Join(t.TempDir(), "manifest.yaml")
+			content := fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test-%d\n", i)
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				errs[i] = err
+				return
+			}
+			repo, err := NewRepository(fmt.Sprintf("app%d:v1", i))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			errs[i] = repo.Save(ctx, path)
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Save(app%d) failed: %v", i, err)
+		}
+		repo, err := NewRepository(fmt.Sprintf("app%d:v1", i))
+		if err != nil {
+			t.Fatalf("NewRepository() failed: %v", err)
+		}
+		res, err := repo.Dump(ctx)
+		if err != nil {
+			t.Fatalf("Dump(app%d) failed: %v", i, err)
+		}
+		defer res.Close()
+		var buf strings.Builder
+		if _, err := io.Copy(&buf, res); err != nil {
+			t.Fatalf("reading Dump() failed: %v", err)
+		}
+		if want := fmt.Sprintf("name: test-%d", i); !strings.Contains(buf.String(), want) {
+			t.Errorf("Dump(app%d) = %q, want it to contain %q", i, buf.String(), want)
+		}
+	}
+
+	entries, err := os.ReadDir(workRoot)
+	if err != nil {
+		t.Fatalf("ReadDir() failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("working directories were not cleaned up: %d entries left", len(entries))
+	}
+}
+
+func TestSaveDeltaDump(t *testing.T) {
+	origBaseDir := baseDir
+	baseDir = t.TempDir()
+	t.Cleanup(func() { baseDir = origBaseDir })
+
+	ctx := context.Background()
+
+	v1Content := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\ndata:\n  key: v1\n"
+	v2Content := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\ndata:\n  key: v2\n  extra: value\n"
+	v3Content := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: renamed\ndata:\n  key: v2\n  extra: value\n"
+
+	files := map[string]string{}
+	for name, c := range map[string]string{"v1": v1Content, "v2": v2Content, "v3": v3Content} {
+		files[name] = filepath.Join(t.TempDir(), name+".yaml")
+		if err := os.WriteFile(files[name], []byte(c), 0o644); err != nil {
+			t.Fatalf("failed to create test manifest: %v", err)
+		}
+	}
+
+	baseRepo, err := NewRepository("myrepo:v1")
+	if err != nil {
+		t.Fatalf("NewRepository(v1) failed: %v", err)
+	}
+	if err := baseRepo.Save(ctx, files["v1"]); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	// v2 is a delta against v1, v3 is a delta against the delta artifact v2
+	for _, tc := range []struct{ tag, base string }{{"v2", "v1"}, {"v3", "v2"}} {
+		repo, err := NewRepository("myrepo:" + tc.tag)
+		if err != nil {
+			t.Fatalf("NewRepository(%s) failed: %v", tc.tag, err)
+		}
+		if err := repo.SaveDelta(ctx, files[tc.tag], tc.base); err != nil {
+			t.Fatalf("SaveDelta(%s) failed: %v", tc.tag, err)
+		}
+	}
+
+	for tag, want := range map[string]string{"v1": v1Content, "v2": v2Content, "v3": v3Content} {
+		repo, err := NewRepository("myrepo:" + tag)
+		if err != nil {
+			t.Fatalf("NewRepository(%s) failed: %v", tag, err)
+		}
+		res, err := repo.Dump(ctx)
+		if err != nil {
+			t.Fatalf("Dump(%s) failed: %v", tag, err)
+		}
+		defer res.Close()
+		var buf strings.Builder
+		if _, err := io.Copy(&buf, res); err != nil {
+			t.Fatalf("reading Dump() failed: %v", err)
+		}
+		if buf.String() != want {
+			t.Errorf("Dump(%s) = %q, want %q", tag, buf.String(), want)
+		}
+	}
+
+	deltaRepo, err := NewRepository("myrepo:v2")
+	if err != nil {
+		t.Fatalf("NewRepository(v2) failed: %v", err)
+	}
+	if _, err := deltaRepo.Path(ctx); err == nil || !strings.Contains(err.Error(), "delta") {
+		t.Errorf("Path() on a delta artifact should fail with a delta error, got: %v", err)
+	}
+}
+
+func TestVerifyContentRepairFailureKeepsBlobs(t *testing.T) {
+	setupListTest(t, "127.0.0.1:1/org/app:v1")
+	t.Setenv("KUBECTL_MFT_CONFIG_DIR", t.TempDir())
+	ctx := context.Background()
+
+	repo, err := NewRepository("127.0.0.1:1/org/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := repo.newOCILayoutStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, m, err := fetchManifest(ctx, store, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	layerPath := blobPath(repo.LayoutPath(), m.Layers[0].Digest)
+	if err := os.WriteFile(layerPath, []byte("tampered\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The registry is unreachable, so the re-pull fails
+	if _, err := repo.VerifyContent(ctx, true); err == nil {
+		t.Fatal("VerifyContent(repair) succeeded without a reachable registry")
+	}
+	for _, p := range []string{layerPath, blobPath(repo.LayoutPath(), desc.Digest)} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("blob %s was removed although the re-pull failed: %v", p, err)
+		}
+	}
+}
+
+func TestSaveDeltaTooManyChanges(t *testing.T) {
+	origBaseDir := baseDir
+	baseDir = t.TempDir()
+	t.Cleanup(func() { baseDir = origBaseDir })
+	ctx := context.Background()
+
+	var v1, v2 strings.Builder
+	for i := 0; i < delta.MaxEdits; i++ {
+		fmt.Fprintf(&v1, "# v1 line %d\n", i)
+		fmt.Fprintf(&v2, "# v2 line %d\n", i)
+	}
+	files := map[string]string{}
+	for name, c := range map[string]string{"v1": v1.String(), "v2": v2.String()} {
+		files[name] = filepath.Join(t.TempDir(), name+".yaml")
+		if err := os.WriteFile(files[name], []byte(c), 0o644); err != nil {
+			t.Fatalf("failed to create test manifest: %v", err)
+		}
+	}
+
+	base, err := NewRepository("myrepo:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := base.Save(ctx, files["v1"]); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	repo, err := NewRepository("myrepo:v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SaveDelta(ctx, files["v2"], "v1"); err != nil {
+		t.Fatalf("SaveDelta() failed: %v", err)
+	}
+	// The manifest is stored in full, so its blob has a path
+	if _, err := repo.Path(ctx); err != nil {
+		t.Errorf("Path() = %v, want the manifest stored in full", err)
+	}
+}
+
+func TestSaveDeltaMissingBase(t *testing.T) {
+	origBaseDir := baseDir
+	baseDir = t.TempDir()
+	t.Cleanup(func() { baseDir = origBaseDir })
+
+	manifestFile := filepath.Join(t.TempDir(), "test.yaml")
+	if err := os.WriteFile(manifestFile, []byte("test: data\n"), 0o644); err != nil {
+		t.Fatalf("failed to create test manifest: %v", err)
+	}
+
+	repo, err := NewRepository("myrepo:v2")
+	if err != nil {
+		t.Fatalf("NewRepository() failed: %v", err)
+	}
+	if err := repo.SaveDelta(context.Background(), manifestFile, "v1"); err == nil {
+		t.Fatal("SaveDelta() should fail when the base tag does not exist")
+	}
+}
+
+func TestVerifyContent(t *testing.T) {
+	origBaseDir := baseDir
+	baseDir = t.TempDir()
+	t.Cleanup(func() { baseDir = origBaseDir })
+
+	ctx := context.Background()
+
+	manifestFile := filepath.Join(t.TempDir(), "test.yaml")
+	if err := os.WriteFile(manifestFile, []byte("apiVersion: v1\nkind: ConfigMap\n"), 0o644); err != nil {
+		t.Fatalf("failed to create test manifest: %v", err)
+	}
+
+	repo, err := NewRepository("myrepo:v1")
+	if err != nil {
+		t.Fatalf("NewRepository() failed: %v", err)
+	}
+	if err := repo.Save(ctx, manifestFile); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	res, err := repo.VerifyContent(ctx, false)
+	if err != nil {
+		t.Fatalf("VerifyContent() failed: %v", err)
+	}
+	if !res.OK() {
+		t.Fatalf("VerifyContent() reported issues for intact content: %+v", res.Issues())
+	}
+
+	// Tamper with the content layer
+	store, err := repo.newOCILayoutStore()
+	if err != nil {
+		t.Fatalf("newOCILayoutStore() failed: %v", err)
+	}
+	_, m, err := fetchManifest(ctx, store, "v1")
+	if err != nil {
+		t.Fatalf("fetchManifest() failed: %v", err)
+	}
+	layerPath := blobPath(repo.LayoutPath(), m.Layers[0].Digest)
+	if err := os.WriteFile(layerPath, []byte("apiVersion: v1\nkind: Tampered\n"), 0o644); err != nil {
+		t.Fatalf("failed to tamper with blob: %v", err)
+	}
+
+	res, err = repo.VerifyContent(ctx, false)
+	if err != nil {
+		t.Fatalf("VerifyContent() failed: %v", err)
+	}
+	if res.OK() || len(res.Issues()) != 1 {
+		t.Fatalf("VerifyContent() expected 1 issue, got %d", len(res.Issues()))
+	}
+	if res.Issues()[0].Digest != m.Layers[0].Digest.String() {
+		t.Errorf("issue digest = %s, want %s", res.Issues()[0].Digest, m.Layers[0].Digest)
+	}
+
+	// Local manifests have no remote source to repair from
+	if _, err := repo.VerifyContent(ctx, true); err == nil || !strings.Contains(err.Error(), "cannot repair") {
+		t.Errorf("VerifyContent(repair) expected 'cannot repair' error, got: %v", err)
+	}
+
+	// A missing blob is reported as well
+	if err := os.Remove(layerPath); err != nil {
+		t.Fatalf("failed to remove blob: %v", err)
+	}
+	res, err = repo.VerifyContent(ctx, false)
+	if err != nil {
+		t.Fatalf("VerifyContent() failed: %v", err)
+	}
+	if res.OK() || res.Issues()[0].Reason != "missing" {
+		t.Errorf("VerifyContent() expected a missing blob issue, got %+v", res.Issues())
+	}
+}
+
+func TestPath(t *testing.T) {
+	setupListTest(t, "app:v1")
+	ctx := context.Background()
+
+	r, err := NewRepository("app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := r.Path(ctx)
+	if err != nil {
+		t.Fatalf("Path() failed: %v", err)
+	}
+	path := blobPath(r.LayoutPath(), digest.Digest(res.Digest()))
+
+	// A modified blob is reported instead of returning its path
+	if err := os.WriteFile(path, []byte("apiVersion: v1\nkind: Tampered\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Path(ctx); err == nil || !strings.Contains(err.Error(), "size mismatch") {
+		t.Errorf("Path() of a modified blob expected a size mismatch error, got: %v", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Path(ctx); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Path() of a missing blob expected a missing error, got: %v", err)
+	}
+}
+
+func TestDumpVerifiesContent(t *testing.T) {
+	setupListTest(t, "app:v1")
+	ctx := context.Background()
+
+	r, err := NewRepository("app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := r.Path(ctx)
+	if err != nil {
+		t.Fatalf("Path() failed: %v", err)
+	}
+	path := blobPath(r.LayoutPath(), digest.Digest(res.Digest()))
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+		wantErr string
+	}{
+		{name: "intact", content: original},
+		{name: "modified", content: append(bytes.Clone(original[:len(original)-1]), '#'), wantErr: "mismatched digest"},
+		{name: "truncated", content: original[:len(original)-1], wantErr: "unexpected EOF"},
+		{name: "trailing data", content: append(bytes.Clone(original), '#'), wantErr: "trailing data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(path, tt.content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			dump, err := r.Dump(ctx)
+			if err != nil {
+				t.Fatalf("Dump() failed: %v", err)
+			}
+			defer dump.Close()
+			got, err := io.ReadAll(dump)
+			if tt.wantErr == "" {
+				if err != nil || !bytes.Equal(got, original) {
+					t.Errorf("reading Dump() = %q, %v, want the original content", got, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("reading Dump() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSystemStorageOverlayUnreadable(t *testing.T) {
+	setupListTest(t, "app:v1")
+	broken := t.TempDir()
+	layout := layoutDir(broken, "local/broken")
+	if err := os.MkdirAll(layout, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(layout, "index.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	systemDirs = []string{broken}
+
+	if got := listTags(t); got != "app:v1" {
+		t.Errorf("List() with an unreadable overlay = %q, want the writable storage listed", got)
+	}
+}
+
+func TestSystemStorageOverlay(t *testing.T) {
+	origBaseDir, origSystemDirs := baseDir, systemDirs
+	t.Cleanup(func() { baseDir, systemDirs = origBaseDir, origSystemDirs })
+
+	ctx := context.Background()
+
+	manifestFile := filepath.Join(t.TempDir(), "test.yaml")
+	if err := os.WriteFile(manifestFile, []byte("apiVersion: v1\nkind: ConfigMap\n"), 0o644); err != nil {
+		t.Fatalf("failed to create test manifest: %v", err)
+	}
+
+	// Populate the system overlay as a platform team would
+	systemDir := t.TempDir()
+	baseDir = systemDir
+	blessed, err := NewRepository("blessed:v1")
+	if err != nil {
+		t.Fatalf("NewRepository() failed: %v", err)
+	}
+	if err := blessed.Save(ctx, manifestFile); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	baseDir = t.TempDir()
+	systemDirs = []string{systemDir}
+
+	exists, err := blessed.Exists(ctx)
+	if err != nil {
+		t.Fatalf("Exists() failed: %v", err)
+	}
+	if !exists {
+		t.Error("Exists() should find the manifest in the system overlay")
+	}
+
+	res, err := blessed.Dump(ctx)
+	if err != nil {
+		t.Fatalf("Dump() failed: %v", err)
+	}
+	defer res.Close()
+	var buf strings.Builder
+	if _, err := io.Copy(&buf, res); err != nil {
+		t.Fatalf("reading Dump() failed: %v", err)
+	}
+	if buf.String() != "apiVersion: v1\nkind: ConfigMap\n" {
+		t.Errorf("Dump() = %q, want the overlay content", buf.String())
+	}
+
+	if _, err := blessed.Delete(ctx); err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Errorf("Delete() expected read-only error, got: %v", err)
+	}
+	if _, err := os.Stat(blessed.userLayoutPath()); !os.IsNotExist(err) {
+		t.Errorf("Delete() of an overlay tag created %s in the writable storage", blessed.userLayoutPath())
+	}
+
+	list, err := NewRegistry().List(ctx)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(list.Items()) != 1 || list.Items()[0].Repository != "blessed" {
+		t.Errorf("List() should include the overlay manifest, got %+v", list.Items())
+	}
+
+	// Copies of overlay manifests are written to the writable storage
+	if err := blessed.Copy(ctx, "blessed:v2", mft.CopyOptions{}); err != nil {
+		t.Fatalf("Copy() failed: %v", err)
+	}
+	list, err = NewRegistry().List(ctx)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(list.Items()) != 2 {
+		t.Errorf("List() expected 2 entries, got %d", len(list.Items()))
+	}
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// LatestSemver is the symbolic reference resolving to the highest stable semver tag.
+const LatestSemver = "latest-semver"
+
+// tagRegexp matches a literal OCI tag as defined by the distribution spec.
+var tagRegexp = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+
+// ResolveSemverTag resolves a symbolic reference such as "myapp:^1.2" or "myapp:latest-semver"
+// to the highest matching semver tag of the repository. Tags that are not symbolic are returned unchanged.
+// If remote is true the tags are listed from the registry, otherwise from local storage.
+// Tags under the default "local" registry are always resolved from local storage.
+func ResolveSemverTag(ctx context.Context, tag string, remote bool) (string, error) {
+	name, expr, ok := splitSymbolicTag(tag)
+	if !ok {
+		return tag, nil
+	}
+
+	r, err := NewRepository(name)
+	if err != nil {
+		return "", err
+	}
+
+	var tags []string
+	if remote && r.ref.Registry != DefaultRegistry {
+		if tags, err = r.remoteTags(ctx, 0); err != nil {
+			return "", err
+		}
+	} else {
+		for _, t := range r.localTags() {
+			tags = append(tags, t.Tag)
+		}
+	}
+
+	resolved, err := selectSemverTag(tags, expr)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", tag, err)
+	}
+	return name + ":" + resolved, nil
+}
+
+// NextPatchTag returns the tag of the next patch release after a semver tag, such as "v1.2.4"
+// for "v1.2.3" or "1.3.0" for "1.3.0-rc.1". A "v" prefix is kept.
+func NextPatchTag(tag string) (string, error) {
+	v, err := semver.NewVersion(tag)
+	if err != nil {
+		return "", fmt.Errorf("%q is not a semantic version", tag)
+	}
+	next := v.IncPatch()
+	return next.Original(), nil
+}
+
+// splitSymbolicTag splits tag into the repository name and a semver expression.
+// It reports false if the reference part is a literal tag or a digest.
+func splitSymbolicTag(tag string) (string, string, bool) {
+	if strings.Contains(tag, "@") {
+		return "", "", false
+	}
+	i := strings.LastIndex(tag, ":")
+	if i < 0 || i < strings.LastIndex(tag, "/") {
+		return "", "", false
+	}
+
+	name, expr := tag[:i], tag[i+1:]
+	if expr == LatestSemver {
+		return name, expr, true
+	}
+	if tagRegexp.MatchString(expr) {
+		return "", "", false
+	}
+	if _, err := semver.NewConstraint(expr); err != nil {
+		return "", "", false
+	}
+	return name, expr, true
+}
+
+// selectSemverTag returns the highest tag satisfying expr. Tags that are not valid
+// semantic versions are ignored, and pre-releases only match constraints that name one.
+func selectSemverTag(tags []string, expr string) (string, error) {
+	var constraint *semver.Constraints
+	if expr != LatestSemver {
+		c, err := semver.NewConstraint(expr)
+		if err != nil {
+			return "", fmt.Errorf("invalid semver constraint %q: %w", expr, err)
+		}
+		constraint = c
+	}
+
+	var best *semver.Version
+	var bestTag string
+	for _, t := range tags {
+		v, err := semver.NewVersion(t)
+		if err != nil {
+			continue
+		}
+		if constraint == nil {
+			if v.Prerelease() != "" {
+				continue
+			}
+		} else if !constraint.Check(v) {
+			continue
+		}
+		if best == nil || v.GreaterThan(best) {
+			best, bestTag = v, t
+		}
+	}
+
+	if best == nil {
+		return "", fmt.Errorf("no tag matches %q", expr)
+	}
+	return bestTag, nil
+}
@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitSymbolicTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      string
+		wantName string
+		wantExpr string
+		wantOK   bool
+	}{
+		{name: "caret range", tag: "myapp:^1.2", wantName: "myapp", wantExpr: "^1.2", wantOK: true},
+		{name: "tilde range with registry", tag: "localhost:5000/app:~1.2", wantName: "localhost:5000/app", wantExpr: "~1.2", wantOK: true},
+		{name: "comparison range", tag: "ghcr.io/org/app:>=1.0, <2.0", wantName: "ghcr.io/org/app", wantExpr: ">=1.0, <2.0", wantOK: true},
+		{name: "latest-semver", tag: "myapp:latest-semver", wantName: "myapp", wantExpr: "latest-semver", wantOK: true},
+		{name: "literal version tag", tag: "myapp:1.2.3", wantOK: false},
+		{name: "literal tag", tag: "myapp:latest", wantOK: false},
+		{name: "registry port without tag", tag: "localhost:5000/app", wantOK: false},
+		{name: "no tag", tag: "myapp", wantOK: false},
+		{name: "digest", tag: "myapp@sha256:abc", wantOK: false},
+		{name: "invalid tag and constraint", tag: "myapp:^^^", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, expr, ok := splitSymbolicTag(tt.tag)
+			if ok != tt.wantOK {
+				t.Fatalf("splitSymbolicTag(%q) ok = %v, want %v", tt.tag, ok, tt.wantOK)
+			}
+			if name != tt.wantName || expr != tt.wantExpr {
+				t.Errorf("splitSymbolicTag(%q) = (%q, %q), want (%q, %q)", tt.tag, name, expr, tt.wantName, tt.wantExpr)
+			}
+		})
+	}
+}
+
+func TestNextPatchTag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    string
+		wantErr bool
+	}{
+		{tag: "v1.2.3", want: "v1.2.4"},
+		{tag: "1.2.3", want: "1.2.4"},
+		{tag: "v1.3.0-rc.1", want: "v1.3.0"},
+		{tag: "latest", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := NextPatchTag(tt.tag)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NextPatchTag(%q) error = %v, wantErr %v", tt.tag, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NextPatchTag(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestSelectSemverTag(t *testing.T) {
+	tags := []string{"latest", "v1.0.0", "1.2.0", "1.2.5", "1.3.0-rc.1", "1.10.0", "2.0.0", "2.1.0-beta"}
+
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{name: "latest-semver skips pre-releases", expr: LatestSemver, want: "2.0.0"},
+		{name: "caret", expr: "^1.2", want: "1.10.0"},
+		{name: "tilde", expr: "~1.2", want: "1.2.5"},
+		{name: "v prefixed tag", expr: "<1.1", want: "v1.0.0"},
+		{name: "pre-release constraint", expr: ">=2.1.0-0", want: "2.1.0-beta"},
+		{name: "no match", expr: "^3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectSemverTag(tags, tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("selectSemverTag(%q) expected error but got %q", tt.expr, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectSemverTag(%q) unexpected error: %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("selectSemverTag(%q) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveSemverTagLocal(t *testing.T) {
+	origBaseDir, origSystemDirs := baseDir, systemDirs
+	baseDir = t.TempDir()
+	systemDirs = nil
+	t.Cleanup(func() { baseDir, systemDirs = origBaseDir, origSystemDirs })
+
+	ctx := context.Background()
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(manifestPath, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"), 0o644); err != nil {
+		t.Fatalf("failed to create test manifest: %v", err)
+	}
+
+	for _, tag := range []string{"1.0.0", "1.4.2", "2.0.0"} {
+		r, err := NewRepository("myapp:" + tag)
+		if err != nil {
+			t.Fatalf("NewRepository() failed: %v", err)
+		}
+		if err := r.Save(ctx, manifestPath); err != nil {
+			t.Fatalf("Save(%s) failed: %v", tag, err)
+		}
+	}
+
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{tag: "myapp:^1", want: "myapp:1.4.2"},
+		{tag: "myapp:latest-semver", want: "myapp:2.0.0"},
+		{tag: "myapp:1.0.0", want: "myapp:1.0.0"},
+	}
+	for _, tt := range tests {
+		// remote is ignored for the default local registry
+		got, err := ResolveSemverTag(ctx, tt.tag, true)
+		if err != nil {
+			t.Fatalf("ResolveSemverTag(%q) unexpected error: %v", tt.tag, err)
+		}
+		if got != tt.want {
+			t.Errorf("ResolveSemverTag(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+
+	if _, err := ResolveSemverTag(ctx, "myapp:^3", false); err == nil {
+		t.Errorf("ResolveSemverTag() expected error for unmatched range")
+	}
+}
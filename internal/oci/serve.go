@@ -0,0 +1,260 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// repositoryNamePattern matches repository names of the OCI distribution spec
+var repositoryNamePattern = regexp.MustCompile(`^[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*(/[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*)*$`)
+
+// registryError is an error response of the OCI distribution spec
+type registryError struct {
+	status  int
+	code    string
+	message string
+}
+
+// RegistryHandler returns a read-only registry implementing the pull API of the OCI distribution
+// spec, including the referrers API, on the layouts of local storage and its system overlays.
+// A repository is named by its storage path, such as 'ghcr.io/myorg/app', or 'local/app' for
+// manifests packed with a simple tag name, which may also be pulled as 'app'.
+func RegistryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeRegistryError(w, &registryError{http.StatusMethodNotAllowed, "UNSUPPORTED", "the registry is read-only"})
+			return
+		}
+		if r.URL.Path == "/v2/" || r.URL.Path == "/v2" {
+			writeRegistryJSON(w, r, "application/json", []byte("{}"))
+			return
+		}
+		path, ok := strings.CutPrefix(r.URL.Path, "/v2/")
+		if !ok {
+			writeRegistryError(w, &registryError{http.StatusNotFound, "UNSUPPORTED", "unknown endpoint"})
+			return
+		}
+
+		var err *registryError
+		switch {
+		case strings.HasSuffix(path, "/tags/list"):
+			err = serveTags(w, r, strings.TrimSuffix(path, "/tags/list"))
+		case strings.Contains(path, "/manifests/"):
+			i := strings.LastIndex(path, "/manifests/")
+			err = serveManifest(w, r, path[:i], path[i+len("/manifests/"):])
+		case strings.Contains(path, "/blobs/"):
+			i := strings.LastIndex(path, "/blobs/")
+			err = serveBlob(w, r, path[:i], path[i+len("/blobs/"):])
+		case strings.Contains(path, "/referrers/"):
+			i := strings.LastIndex(path, "/referrers/")
+			err = serveReferrers(w, r, path[:i], path[i+len("/referrers/"):])
+		default:
+			err = &registryError{http.StatusNotFound, "UNSUPPORTED", "unknown endpoint"}
+		}
+		if err != nil {
+			writeRegistryError(w, err)
+		}
+	})
+}
+
+// servedLayouts returns the layouts of the repository name, in the writable storage first.
+func servedLayouts(name string) ([]string, *registryError) {
+	if !repositoryNamePattern.MatchString(name) {
+		return nil, &registryError{http.StatusBadRequest, "NAME_INVALID", fmt.Sprintf("invalid repository name %q", name)}
+	}
+	names := []string{name}
+	if !strings.Contains(name, "/") {
+		names = append(names, DefaultRegistry+"/"+name)
+	}
+	var layouts []string
+	for _, n := range names {
+		for _, root := range append([]string{baseDir}, systemDirs...) {
+			p := layoutDir(root, n)
+			if _, err := os.Stat(filepath.Join(p, "index.json")); err == nil {
+				layouts = append(layouts, p)
+			}
+		}
+	}
+	if len(layouts) == 0 {
+		return nil, &registryError{http.StatusNotFound, "NAME_UNKNOWN", fmt.Sprintf("repository %s not found", name)}
+	}
+	return layouts, nil
+}
+
+func serveTags(w http.ResponseWriter, r *http.Request, name string) *registryError {
+	layouts, rerr := servedLayouts(name)
+	if rerr != nil {
+		return rerr
+	}
+	var tags []string
+	for _, l := range layouts {
+		index, err := loadIndexFile(l)
+		if err != nil {
+			return internalError(err)
+		}
+		for _, d := range index.Manifests {
+			if tag := d.Annotations[v1.AnnotationRefName]; tag != "" && !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	slices.Sort(tags)
+	data, err := json.Marshal(map[string]any{"name": name, "tags": tags})
+	if err != nil {
+		return internalError(err)
+	}
+	writeRegistryJSON(w, r, "application/json", data)
+	return nil
+}
+
+func serveManifest(w http.ResponseWriter, r *http.Request, name, reference string) *registryError {
+	layouts, rerr := servedLayouts(name)
+	if rerr != nil {
+		return rerr
+	}
+	unknown := &registryError{http.StatusNotFound, "MANIFEST_UNKNOWN", fmt.Sprintf("manifest %s not found in %s", reference, name)}
+	for _, l := range layouts {
+		index, err := loadIndexFile(l)
+		if err != nil {
+			return internalError(err)
+		}
+		for _, d := range index.Manifests {
+			if d.Digest.String() != reference && d.Annotations[v1.AnnotationRefName] != reference {
+				continue
+			}
+			data, err := os.ReadFile(blobPath(l, d.Digest))
+			if errors.Is(err, os.ErrNotExist) {
+				return unknown
+			} else if err != nil {
+				return internalError(err)
+			}
+			w.Header().Set("Docker-Content-Digest", d.Digest.String())
+			writeRegistryJSON(w, r, d.MediaType, data)
+			return nil
+		}
+	}
+	return unknown
+}
+
+func serveBlob(w http.ResponseWriter, r *http.Request, name, reference string) *registryError {
+	d, err := digest.Parse(reference)
+	if err != nil {
+		return &registryError{http.StatusBadRequest, "DIGEST_INVALID", fmt.Sprintf("invalid digest %q", reference)}
+	}
+	layouts, rerr := servedLayouts(name)
+	if rerr != nil {
+		return rerr
+	}
+	for _, l := range layouts {
+		f, err := os.Open(blobPath(l, d))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return internalError(err)
+		}
+		defer f.Close()
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Docker-Content-Digest", d.String())
+		http.ServeContent(w, r, "", time.Time{}, f)
+		return nil
+	}
+	return &registryError{http.StatusNotFound, "BLOB_UNKNOWN", fmt.Sprintf("blob %s not found in %s", d, name)}
+}
+
+func serveReferrers(w http.ResponseWriter, r *http.Request, name, reference string) *registryError {
+	subject, err := digest.Parse(reference)
+	if err != nil {
+		return &registryError{http.StatusBadRequest, "DIGEST_INVALID", fmt.Sprintf("invalid digest %q", reference)}
+	}
+	layouts, rerr := servedLayouts(name)
+	if rerr != nil {
+		return rerr
+	}
+	artifactType := r.URL.Query().Get("artifactType")
+
+	referrers := []v1.Descriptor{}
+	seen := make(map[digest.Digest]bool)
+	for _, l := range layouts {
+		index, err := loadIndexFile(l)
+		if err != nil {
+			return internalError(err)
+		}
+		for _, d := range index.Manifests {
+			if seen[d.Digest] {
+				continue
+			}
+			seen[d.Digest] = true
+			data, err := os.ReadFile(blobPath(l, d.Digest))
+			if err != nil {
+				continue
+			}
+			var m v1.Manifest
+			if err := json.Unmarshal(data, &m); err != nil || m.Subject == nil || m.Subject.Digest != subject {
+				continue
+			}
+			if artifactType != "" && m.ArtifactType != artifactType {
+				continue
+			}
+			referrers = append(referrers, v1.Descriptor{
+				MediaType:    d.MediaType,
+				ArtifactType: m.ArtifactType,
+				Digest:       d.Digest,
+				Size:         d.Size,
+				Annotations:  m.Annotations,
+			})
+		}
+	}
+
+	data, err := json.Marshal(v1.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: v1.MediaTypeImageIndex,
+		Manifests: referrers,
+	})
+	if err != nil {
+		return internalError(err)
+	}
+	if artifactType != "" {
+		w.Header().Set("OCI-Filters-Applied", "artifactType")
+	}
+	writeRegistryJSON(w, r, v1.MediaTypeImageIndex, data)
+	return nil
+}
+
+func internalError(err error) *registryError {
+	return &registryError{http.StatusInternalServerError, "UNKNOWN", err.Error()}
+}
+
+// writeRegistryJSON writes a JSON document, or only its headers for HEAD requests.
+func writeRegistryJSON(w http.ResponseWriter, r *http.Request, mediaType string, data []byte) {
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(data)
+	}
+}
+
+func writeRegistryError(w http.ResponseWriter, e *registryError) {
+	data, _ := json.Marshal(map[string]any{
+		"errors": []map[string]string{{"code": e.code, "message": e.message}},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.status)
+	_, _ = w.Write(data)
+}
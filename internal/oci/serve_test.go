@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/registry/remote"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+)
+
+func TestRegistryHandler(t *testing.T) {
+	setupListTest(t, "app:v1", "ghcr.io/myorg/web:v2")
+	ctx := context.Background()
+
+	r, err := NewRepository("app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signature.NewSigner(key).Sign(ctx, r.LayoutPath(), r.Tag()); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	want, err := r.Digest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(RegistryHandler())
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	for _, name := range []string{"local/app", "app"} {
+		t.Run("pull "+name, func(t *testing.T) {
+			repo, err := remote.NewRepository(host + "/" + name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			repo.PlainHTTP = true
+
+			desc, err := oras.Copy(ctx, repo, "v1", memory.New(), "v1", oras.DefaultCopyOptions)
+			if err != nil {
+				t.Fatalf("Copy failed: %v", err)
+			}
+			if desc.Digest.String() != want {
+				t.Errorf("digest = %s, want %s", desc.Digest, want)
+			}
+
+			var referrers []v1.Descriptor
+			err = repo.Referrers(ctx, desc, signature.SignatureArtifactType, func(descs []v1.Descriptor) error {
+				referrers = append(referrers, descs...)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Referrers failed: %v", err)
+			}
+			if len(referrers) != 1 {
+				t.Errorf("got %d referrers, want the signature", len(referrers))
+			}
+
+			var tags []string
+			if err := repo.Tags(ctx, "", func(t []string) error { tags = append(tags, t...); return nil }); err != nil {
+				t.Fatalf("Tags failed: %v", err)
+			}
+			if strings.Join(tags, ",") != "v1" {
+				t.Errorf("tags = %v, want [v1]", tags)
+			}
+		})
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "base", method: http.MethodGet, path: "/v2/", wantStatus: http.StatusOK},
+		{name: "nested repository", method: http.MethodHead, path: "/v2/ghcr.io/myorg/web/manifests/v2", wantStatus: http.StatusOK},
+		{name: "unknown repository", method: http.MethodGet, path: "/v2/other/manifests/v1", wantStatus: http.StatusNotFound, wantCode: "NAME_UNKNOWN"},
+		{name: "unknown tag", method: http.MethodGet, path: "/v2/app/manifests/v9", wantStatus: http.StatusNotFound, wantCode: "MANIFEST_UNKNOWN"},
+		{name: "invalid digest", method: http.MethodGet, path: "/v2/app/blobs/sha256:abc", wantStatus: http.StatusBadRequest, wantCode: "DIGEST_INVALID"},
+		{name: "path traversal", method: http.MethodGet, path: "/v2/../app/tags/list", wantStatus: http.StatusBadRequest, wantCode: "NAME_INVALID"},
+		{name: "push", method: http.MethodPost, path: "/v2/app/blobs/uploads/", wantStatus: http.StatusMethodNotAllowed, wantCode: "UNSUPPORTED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+			RegistryHandler().ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantCode) {
+				t.Errorf("body = %s, want code %s", rec.Body, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/errdef"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/delta"
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+// Staged is a manifest packed into a temporary OCI layout. Local storage is not touched
+// until Commit, so a failure between packing and committing, such as a signing error,
+// leaves no partial artifact behind. Call Discard when done, whether committed or not.
+type Staged struct {
+	r       *Repository
+	workDir string
+	// event is the type of the event recorded on Commit
+	event string
+}
+
+// Stage packs manifestPath into a temporary OCI layout tagged with the repository tag.
+// If base is not empty, the manifest is stored as a delta against the base tag in the
+// same repository, like SaveDelta.
+func (r *Repository) Stage(ctx context.Context, manifestPath, base string) (_ *Staged, err error) {
+	s, store, err := r.newStaged()
+	if err != nil {
+		return nil, err
+	}
+	s.event = mft.EventPacked
+	defer func() {
+		if err != nil {
+			s.Discard()
+		}
+	}()
+
+	if base != "" {
+		err = r.stageDelta(ctx, store, manifestPath, base)
+	}
+	if base == "" || errors.Is(err, delta.ErrTooManyChanges) {
+		// A manifest that differs too much from its base is stored in full
+		err = r.stageFull(ctx, store, filepath.Join(s.workDir, "files"), manifestPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// StageSign copies the manifest of the tag into a temporary OCI layout, so that signatures
+// can be attached to it there and committed together, or not at all.
+func (r *Repository) StageSign(ctx context.Context) (_ *Staged, err error) {
+	layoutStore, err := r.newReadOnlyStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s, store, err := r.newStaged()
+	if err != nil {
+		return nil, err
+	}
+	s.event = mft.EventSigned
+	defer func() {
+		if err != nil {
+			s.Discard()
+		}
+	}()
+
+	tag := r.ref.ReferenceOrDefault()
+	if err := r.copy(ctx, layoutStore, tag, store, tag); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// newStaged creates an empty staging layout.
+func (r *Repository) newStaged() (*Staged, *oci.Store, error) {
+	workDir, err := newWorkDir()
+	if err != nil {
+		return nil, nil, err
+	}
+	s := &Staged{r: r, workDir: workDir}
+	store, err := oci.New(s.LayoutPath())
+	if err != nil {
+		s.Discard()
+		return nil, nil, fmt.Errorf("failed to create staging layout: %w", err)
+	}
+	return s, store, nil
+}
+
+// LayoutPath returns the path of the staging layout, e.g. for signing the staged manifest.
+func (s *Staged) LayoutPath() string {
+	return filepath.Join(s.workDir, "layout")
+}
+
+// Repository returns a copy of the repository that reads the staging layout instead of
+// local storage, e.g. to verify the staged manifest before committing it.
+func (s *Staged) Repository() *Repository {
+	r := *s.r
+	r.layout = s.LayoutPath()
+	return &r
+}
+
+// Commit copies the staged manifest along with its referrers, such as signatures, into
+// local storage. The tag is only updated once all content has been copied.
+// Commit is not interrupted by cancellation of ctx, so local storage is never left half updated.
+func (s *Staged) Commit(ctx context.Context) error {
+	ctx = context.WithoutCancel(ctx)
+	store, err := oci.New(s.LayoutPath())
+	if err != nil {
+		return fmt.Errorf("failed to open staging layout: %w", err)
+	}
+	layoutStore, err := s.r.newOCILayoutStore()
+	if err != nil {
+		return err
+	}
+	tag := s.r.ref.ReferenceOrDefault()
+	if s.r.cached {
+		if err := markCached(ctx, store, tag); err != nil {
+			return err
+		}
+	}
+	if err := s.r.extendedCopy(ctx, store, tag, layoutStore, tag); err != nil {
+		return err
+	}
+	if err := s.r.SyncMetadata(ctx); err != nil {
+		return err
+	}
+	return s.r.RecordEvent(ctx, s.event)
+}
+
+// Discard removes the staging layout.
+func (s *Staged) Discard() {
+	_ = os.RemoveAll(s.workDir)
+}
+
+// stageFull packs the full manifest content into store.
+func (r *Repository) stageFull(ctx context.Context, store *oci.Store, filesDir, manifestPath string) (err error) {
+	if err := os.MkdirAll(filesDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create working directory: %w", err)
+	}
+	fs, err := r.newFileStore(ctx, filesDir, manifestPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := fs.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("warning: failed to close manifestPath content: %w", closeErr)
+		}
+	}()
+	return r.copy(ctx, fs, r.ref.ReferenceOrDefault(), store, r.ref.ReferenceOrDefault())
+}
+
+// stageDelta packs a patch of the manifest content against the content of baseTag into store.
+// The base layers are only referenced, they are already present in local storage.
+func (r *Repository) stageDelta(ctx context.Context, store *oci.Store, manifestPath, baseTag string) error {
+	layoutStore, err := r.newOCILayoutStore()
+	if err != nil {
+		return err
+	}
+
+	baseDesc, baseManifest, err := fetchManifest(ctx, layoutStore, baseTag)
+	if err != nil {
+		return fmt.Errorf("failed to load base %q: %w", baseTag, err)
+	}
+
+	base, err := readContent(ctx, layoutStore, baseManifest)
+	if err != nil {
+		return fmt.Errorf("failed to fetch content for base %q: %w", baseTag, err)
+	}
+
+	target, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest %q: %w", manifestPath, err)
+	}
+
+	patch, err := delta.Diff(base, target)
+	if err != nil {
+		return err
+	}
+	patchDesc := content.NewDescriptorFromBytes(deltaMediaType, patch)
+	if err := store.Push(ctx, patchDesc, bytes.NewReader(patch)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		return fmt.Errorf("failed to push delta layer: %w", err)
+	}
+	patchDesc.Annotations = map[string]string{
+		annotationDeltaBase: baseDesc.Digest.String(),
+	}
+
+	layers := append(slices.Clone(baseManifest.Layers), patchDesc)
+	manifestDesc, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, artifactType, oras.PackManifestOptions{
+		Layers:              layers,
+		ManifestAnnotations: r.manifestAnnotations(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pack delta manifest: %w", err)
+	}
+
+	if err := store.Tag(ctx, manifestDesc, r.ref.ReferenceOrDefault()); err != nil {
+		return fmt.Errorf("failed to tag delta manifest: %w", err)
+	}
+	return nil
+}
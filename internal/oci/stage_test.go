@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+)
+
+func TestStage(t *testing.T) {
+	setupListTest(t, "app:v1")
+	ctx := context.Background()
+
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(manifestPath, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\ndata:\n  a: b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A discarded stage leaves local storage untouched
+	r, err := NewRepository("app:v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := r.Stage(ctx, manifestPath, "v1")
+	if err != nil {
+		t.Fatalf("Stage() failed: %v", err)
+	}
+	s.Discard()
+	if exists, err := r.Exists(ctx); err != nil || exists {
+		t.Errorf("Exists() after Discard() = %v, %v, want false", exists, err)
+	}
+	if _, err := os.Stat(s.LayoutPath()); !os.IsNotExist(err) {
+		t.Errorf("staging layout still exists after Discard(): %v", err)
+	}
+
+	// Signatures made in the staging layout are committed with the manifest
+	s, err = r.Stage(ctx, manifestPath, "v1")
+	if err != nil {
+		t.Fatalf("Stage() failed: %v", err)
+	}
+	defer s.Discard()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signature.NewSigner(key).Sign(ctx, s.LayoutPath(), r.Tag()); err != nil {
+		t.Fatalf("Sign() failed: %v", err)
+	}
+	if exists, err := r.Exists(ctx); err != nil || exists {
+		t.Errorf("Exists() before Commit() = %v, %v, want false", exists, err)
+	}
+	if err := s.Commit(ctx); err != nil {
+		t.Fatalf("Commit() failed: %v", err)
+	}
+
+	res, err := r.Dump(ctx)
+	if err != nil {
+		t.Fatalf("Dump() failed: %v", err)
+	}
+	defer res.Close()
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, res); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\ndata:\n  a: b\n" {
+		t.Errorf("Dump() = %q", got)
+	}
+	if err := signature.NewVerifier([]crypto.PublicKey{&key.PublicKey}).Verify(ctx, r.LayoutPath(), r.Tag()); err != nil {
+		t.Errorf("Verify() after Commit() failed: %v", err)
+	}
+}
+
+func TestStagedRepository(t *testing.T) {
+	setupListTest(t, "app:v1")
+	ctx := context.Background()
+
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(manifestPath, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: staged\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRepository("app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := r.Stage(ctx, manifestPath, "")
+	if err != nil {
+		t.Fatalf("Stage() failed: %v", err)
+	}
+	defer s.Discard()
+
+	// The staged copy reads the staging layout, the repository still reads local storage
+	staged := s.Repository()
+	if got := staged.LayoutPath(); got != s.LayoutPath() {
+		t.Errorf("LayoutPath() of the staged copy = %q, want %q", got, s.LayoutPath())
+	}
+	if r.LayoutPath() == s.LayoutPath() {
+		t.Error("LayoutPath() of the repository reads the staging layout")
+	}
+	if !hasReference(staged.LayoutPath(), staged.Tag()) {
+		t.Error("staged copy does not resolve the staged tag")
+	}
+}
+
+func TestStageSign(t *testing.T) {
+	setupListTest(t, "app:v1")
+	ctx := context.Background()
+	r, err := NewRepository("app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetPinned(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	before, err := r.Digest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := signature.NewVerifier([]crypto.PublicKey{&key.PublicKey})
+
+	// Signatures of a discarded stage are never attached
+	s, err := r.StageSign(ctx)
+	if err != nil {
+		t.Fatalf("StageSign() failed: %v", err)
+	}
+	if _, err := signature.NewSigner(key).Sign(ctx, s.LayoutPath(), r.Tag()); err != nil {
+		t.Fatalf("Sign() failed: %v", err)
+	}
+	s.Discard()
+	if err := verifier.Verify(ctx, r.LayoutPath(), r.Tag()); err == nil {
+		t.Error("Verify() succeeded after Discard(), want the signature not attached")
+	}
+
+	s, err = r.StageSign(ctx)
+	if err != nil {
+		t.Fatalf("StageSign() failed: %v", err)
+	}
+	defer s.Discard()
+	if _, err := signature.NewSigner(key).Sign(ctx, s.LayoutPath(), r.Tag()); err != nil {
+		t.Fatalf("Sign() failed: %v", err)
+	}
+	if err := s.Commit(ctx); err != nil {
+		t.Fatalf("Commit() failed: %v", err)
+	}
+	if err := verifier.Verify(ctx, r.LayoutPath(), r.Tag()); err != nil {
+		t.Errorf("Verify() after Commit() failed: %v", err)
+	}
+	if after, err := r.Digest(ctx); err != nil || after != before {
+		t.Errorf("Digest() after Commit() = %s, %v, want the signed manifest %s", after, err, before)
+	}
+	if pinned, err := r.Pinned(); err != nil || !pinned {
+		t.Errorf("Pinned() after Commit() = %v, %v, want the pin kept", pinned, err)
+	}
+}
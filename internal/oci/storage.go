@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/platform"
+)
+
+// The storage layout is shared by the writable storage (BaseDir) and the read-only system
+// overlays (SystemDirs). Every repository is an OCI image layout in the directory named
+// after the repository, with one path element per slash-separated component, and the colon
+// of a registry port replaced by a plus sign on Windows, see platform.FromName:
+//
+//	<root>/<registry>/<repository>/index.json   tags are the ref.name annotations of index.json
+//	<root>/<registry>/<repository>/blobs/...    manifests, configs, layers, and signatures
+//	<root>/local/<repository>/...               repositories without a registry (DefaultRegistry)
+//
+// The writable storage additionally holds files whose names start with a dot, which never
+// collide with repository directories:
+//
+//	<root>/.events/<registry>/<repository>.jsonl   event log of the repository
+//	<root>/.metadata.db                            metadata index, see MetadataIndexPath
+//
+// All paths of the layout are built by layoutDir and repositoryName, so that commands
+// reading and writing storage agree on the directory of a repository.
+
+// layoutDir returns the OCI layout directory of the repository name under the storage root.
+func layoutDir(root, name string) string {
+	return filepath.Join(root, platform.FromName(name))
+}
+
+// repositoryName returns the name of the repository whose OCI layout directory is dir under
+// the storage root, the inverse of layoutDir.
+func repositoryName(root, dir string) (string, error) {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get relative path: %w", err)
+	}
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not a repository directory of %s", dir, root)
+	}
+	return platform.ToName(rel), nil
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLayoutDir(t *testing.T) {
+	root := t.TempDir()
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "local/app", want: filepath.Join(root, "local", "app")},
+		{name: "ghcr.io/org/app", want: filepath.Join(root, "ghcr.io", "org", "app")},
+		{name: "localhost:5000/team/app", want: filepath.Join(root, "localhost:5000", "team", "app")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := layoutDir(root, tt.name)
+			if dir != tt.want {
+				t.Errorf("layoutDir() = %q, want %q", dir, tt.want)
+			}
+			name, err := repositoryName(root, dir)
+			if err != nil {
+				t.Fatalf("repositoryName() error = %v", err)
+			}
+			if name != tt.name {
+				t.Errorf("repositoryName() = %q, want %q", name, tt.name)
+			}
+		})
+	}
+}
+
+func TestRepositoryNameOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{root, filepath.Dir(root), filepath.Join(filepath.Dir(root), "other")} {
+		if name, err := repositoryName(root, dir); err == nil {
+			t.Errorf("repositoryName(%q) = %q, want error", dir, name)
+		}
+	}
+}
+
+func TestStorageLayoutAgreement(t *testing.T) {
+	origBaseDir := baseDir
+	baseDir = t.TempDir()
+	t.Cleanup(func() { baseDir = origBaseDir })
+	r, err := NewRepository("ghcr.io/org/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := r.LayoutPath(), layoutDir(BaseDir(), "ghcr.io/org/app"); got != want {
+		t.Errorf("LayoutPath() = %q, want %q", got, want)
+	}
+	if got, want := r.eventLogPath(), filepath.Join(BaseDir(), eventsDir, "ghcr.io", "org", "app.jsonl"); got != want {
+		t.Errorf("eventLogPath() = %q, want %q", got, want)
+	}
+}
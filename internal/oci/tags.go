@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+// Tags lists the tags of the repository in local storage, or in the registry if opts.Remote is set.
+// The repository reference must not include a tag or digest.
+func (r *Repository) Tags(ctx context.Context, opts mft.TagsOptions) (*mft.TagsResult, error) {
+	if r.ref.Reference != "" {
+		return nil, fmt.Errorf("expected a repository without a tag or digest, got %s", r.ref)
+	}
+
+	if !opts.Remote {
+		tags := r.localTags()
+		if !opts.Digests {
+			for _, t := range tags {
+				t.Digest = ""
+			}
+		}
+		return mft.NewTagsResult(r.Name(), tags, opts.Digests), nil
+	}
+
+	if r.ref.Registry == DefaultRegistry {
+		return nil, fmt.Errorf("%s is stored locally only and has no remote registry", r.Name())
+	}
+
+	names, err := r.remoteTags(ctx, opts.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]*mft.TagInfo, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, &mft.TagInfo{Tag: name})
+	}
+
+	if opts.Digests {
+		repo, err := r.newAuthenticatedRepository()
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tags {
+			desc, err := repo.Resolve(ctx, t.Tag)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve %s:%s: %w", r.Name(), t.Tag, err)
+			}
+			t.Digest = desc.Digest.String()
+		}
+	}
+
+	return mft.NewTagsResult(r.Name(), tags, opts.Digests), nil
+}
+
+// localTags returns the tags of the repository in local storage, including read-only overlays,
+// along with their manifest digests. Tags in the writable storage shadow overlay tags of the same name.
+// Layouts that do not exist or cannot be read are skipped.
+func (r *Repository) localTags() []*mft.TagInfo {
+	var tags []*mft.TagInfo
+	seen := make(map[string]bool)
+	roots := append([]string{r.storageDir()}, systemDirs...)
+	if metadataIndexEnabled(r.storageDir()) {
+		// The tags of the writable storage are read from the index, falling back to the layout
+		if indexed, err := indexedTags(r.storageDir(), r.Name()); err == nil {
+			for _, t := range indexed {
+				seen[t.Tag] = true
+			}
+			tags, roots = indexed, systemDirs
+		}
+	}
+	for _, root := range roots {
+		index, err := loadIndexFile(layoutDir(root, r.Name()))
+		if err != nil {
+			continue
+		}
+		for _, d := range index.Manifests {
+			t := d.Annotations[v1.AnnotationRefName]
+			if t == "" || seen[t] {
+				continue
+			}
+			seen[t] = true
+			tags = append(tags, &mft.TagInfo{Tag: t, Digest: d.Digest.String()})
+		}
+	}
+	return tags
+}
+
+// remoteTags returns the tags of the repository in the remote registry, following
+// pagination links. A pageSize of 0 uses the registry default.
+func (r *Repository) remoteTags(ctx context.Context, pageSize int) ([]string, error) {
+	repo, err := r.newAuthenticatedRepository()
+	if err != nil {
+		return nil, err
+	}
+	if pageSize > 0 {
+		repo.TagListPageSize = pageSize
+	}
+
+	var tags []string
+	if err := repo.Tags(ctx, "", func(page []string) error {
+		tags = append(tags, page...)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list tags of %s: %w", r.Name(), err)
+	}
+	return tags, nil
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/mft"
+)
+
+func TestTagsLocal(t *testing.T) {
+	origBaseDir, origSystemDirs := baseDir, systemDirs
+	baseDir = t.TempDir()
+	systemDirs = nil
+	t.Cleanup(func() { baseDir, systemDirs = origBaseDir, origSystemDirs })
+
+	ctx := context.Background()
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(manifestPath, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"), 0o644); err != nil {
+		t.Fatalf("failed to create test manifest: %v", err)
+	}
+	for _, tag := range []string{"latest", "1.10.0", "v1.9.0", "1.2.0", "dev"} {
+		r, err := NewRepository("myapp:" + tag)
+		if err != nil {
+			t.Fatalf("NewRepository() failed: %v", err)
+		}
+		if err := r.Save(ctx, manifestPath); err != nil {
+			t.Fatalf("Save(%s) failed: %v", tag, err)
+		}
+	}
+
+	r, err := NewRepository("myapp")
+	if err != nil {
+		t.Fatalf("NewRepository() failed: %v", err)
+	}
+
+	t.Run("sorted by semver", func(t *testing.T) {
+		res, err := r.Tags(ctx, mft.TagsOptions{})
+		if err != nil {
+			t.Fatalf("Tags() unexpected error: %v", err)
+		}
+		res.Sort()
+
+		var got []string
+		for _, i := range res.Items() {
+			if i.Digest != "" {
+				t.Errorf("Tags() without Digests returned digest %q for %s", i.Digest, i.Tag)
+			}
+			got = append(got, i.Tag)
+		}
+		if want := "1.2.0,v1.9.0,1.10.0,dev,latest"; strings.Join(got, ",") != want {
+			t.Errorf("Tags() = %v, want %s", got, want)
+		}
+	})
+
+	t.Run("digests", func(t *testing.T) {
+		res, err := r.Tags(ctx, mft.TagsOptions{Digests: true})
+		if err != nil {
+			t.Fatalf("Tags() unexpected error: %v", err)
+		}
+		for _, i := range res.Items() {
+			if !strings.HasPrefix(i.Digest, "sha256:") {
+				t.Errorf("Tags() digest of %s = %q, want a sha256 digest", i.Tag, i.Digest)
+			}
+		}
+	})
+
+	t.Run("reference with tag", func(t *testing.T) {
+		tagged, err := NewRepository("myapp:1.2.0")
+		if err != nil {
+			t.Fatalf("NewRepository() failed: %v", err)
+		}
+		if _, err := tagged.Tags(ctx, mft.TagsOptions{}); err == nil {
+			t.Errorf("Tags() expected error for a tagged reference")
+		}
+	})
+
+	t.Run("remote on local registry", func(t *testing.T) {
+		if _, err := r.Tags(ctx, mft.TagsOptions{Remote: true}); err == nil {
+			t.Errorf("Tags() expected error listing remote tags of a local repository")
+		}
+	})
+}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// throttleChunk is the largest read passed through the limiter at once, so that throughput
+// stays smooth instead of alternating bursts and long pauses
+const throttleChunk = 32 * 1024
+
+// ParseBandwidth parses a transfer rate such as "10MB/s", "512KiB/s", or "1048576" into bytes
+// per second. Units are powers of 1024 and the "/s" suffix is optional.
+func ParseBandwidth(s string) (int64, error) {
+	value := strings.TrimSuffix(strings.TrimSpace(s), "/s")
+	multiplier := int64(1)
+	// Longer suffixes come first so that "MiB" is not taken for "B"
+	for _, u := range []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10},
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+		{"B", 1},
+	} {
+		if trimmed, ok := strings.CutSuffix(value, u.suffix); ok {
+			value, multiplier = trimmed, u.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid bandwidth %q, expected a positive rate such as 10MB/s", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// rateLimiter spreads transfers over time so that their combined rate stays below a limit.
+// It is shared by all concurrent transfers of a copy.
+type rateLimiter struct {
+	mu sync.Mutex
+	// bytesPerSecond is the limit
+	bytesPerSecond int64
+	// next is the time the next transfer may start
+	next time.Time
+}
+
+func newRateLimiter(bytesPerSecond int64) *rateLimiter {
+	return &rateLimiter{bytesPerSecond: bytesPerSecond}
+}
+
+// wait blocks until n more bytes may be transferred.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	start := l.next
+	l.next = l.next.Add(time.Duration(int64(n) * int64(time.Second) / l.bytesPerSecond))
+	l.mu.Unlock()
+
+	delay := start.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// throttledReader is a reader whose reads are rate limited.
+type throttledReader struct {
+	ctx     context.Context
+	r       io.ReadCloser
+	limiter *rateLimiter
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > throttleChunk {
+		p = p[:throttleChunk]
+	}
+	n, err := t.r.Read(p)
+	if n > 0 {
+		if werr := t.limiter.wait(t.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+func (t *throttledReader) Close() error {
+	return t.r.Close()
+}
+
+// throttledTransport rate limits the request and response bodies of registry requests,
+// which carry the blobs and manifests of push and pull.
+type throttledTransport struct {
+	base    http.RoundTripper
+	limiter *rateLimiter
+}
+
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(req.Context())
+		req.Body = &throttledReader{ctx: req.Context(), r: req.Body, limiter: t.limiter}
+		// Bodies replayed on redirects and retries are rate limited as well
+		if getBody := req.GetBody; getBody != nil {
+			ctx := req.Context()
+			req.GetBody = func() (io.ReadCloser, error) {
+				body, err := getBody()
+				if err != nil {
+					return nil, err
+				}
+				return &throttledReader{ctx: ctx, r: body, limiter: t.limiter}, nil
+			}
+		}
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &throttledReader{ctx: req.Context(), r: resp.Body, limiter: t.limiter}
+	return resp, nil
+}
+
+// SetBandwidthLimit limits the combined rate of transfers to and from the remote registry,
+// in bytes per second. Zero removes the limit.
+func (r *Repository) SetBandwidthLimit(bytesPerSecond int64) {
+	r.bandwidthLimit = bytesPerSecond
+}
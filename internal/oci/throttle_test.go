@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "10MB/s", want: 10 << 20},
+		{value: "512KiB/s", want: 512 << 10},
+		{value: "1.5G", want: 3 << 29},
+		{value: "2048", want: 2048},
+		{value: "100B/s", want: 100},
+		{value: "0", wantErr: true},
+		{value: "-1MB/s", wantErr: true},
+		{value: "fast", wantErr: true},
+		{value: "10Mbit/s", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBandwidth(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseBandwidth(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBandwidth(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestThrottledReader(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 256<<10)
+	r := &throttledReader{
+		ctx:     context.Background(),
+		r:       io.NopCloser(bytes.NewReader(data)),
+		limiter: newRateLimiter(1 << 20),
+	}
+
+	start := time.Now()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("ReadAll() returned different data")
+	}
+	// 256KiB at 1MiB/s takes a quarter second, less the first chunk that is not delayed
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("reading 256KiB at 1MiB/s took %v, want at least 150ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r = &throttledReader{ctx: ctx, r: io.NopCloser(bytes.NewReader(data)), limiter: newRateLimiter(1 << 10)}
+	if _, err := io.ReadAll(r); err == nil {
+		t.Error("ReadAll() with a canceled context succeeded, want error")
+	}
+}
+
+func TestThrottledTransportGetBody(t *testing.T) {
+	limiter := newRateLimiter(1 << 20)
+	var replayed io.ReadCloser
+	transport := &throttledTransport{
+		base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			replayed = body
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}),
+		limiter: limiter,
+	}
+
+	req, err := http.NewRequest(http.MethodPut, "http://registry.example.com/v2/", bytes.NewReader([]byte("data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip() failed: %v", err)
+	}
+	if _, ok := replayed.(*throttledReader); !ok {
+		t.Errorf("GetBody() returned %T, want a throttled reader", replayed)
+	}
+}
+
+// roundTripFunc is an http.RoundTripper implemented by a function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/retry"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+)
+
+// newAuthClient creates an HTTP client for the registry, authenticating with the Docker
+// credential store and using the proxy, headers, and user agent from the configuration.
+func newAuthClient(registry string) (*auth.Client, error) {
+	c, err := newCredentialFunc()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credential for registry %s: %w", registry, err)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	settings := cfg.HTTP.For(registry)
+	transport, err := newTransport(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &auth.Client{
+		Client:     &http.Client{Transport: retry.NewTransport(transport)},
+		Cache:      auth.NewCache(),
+		Credential: c,
+		Header:     make(http.Header),
+	}
+	for name, value := range settings.Headers {
+		client.Header.Set(name, value)
+	}
+	if settings.UserAgent != "" {
+		client.SetUserAgent(settings.UserAgent)
+	}
+	return client, nil
+}
+
+// NewHTTPClient creates an HTTP client for requests to host that are not registry API calls,
+// such as those to timestamping authorities or object storage, using the proxy, CA file,
+// headers, and user agent configured for host.
+func NewHTTPClient(host string) (*http.Client, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	settings := cfg.HTTP.For(host)
+	transport, err := newTransport(settings)
+	if err != nil {
+		return nil, err
+	}
+	header := make(http.Header)
+	for name, value := range settings.Headers {
+		header.Set(name, value)
+	}
+	if settings.UserAgent != "" {
+		header.Set("User-Agent", settings.UserAgent)
+	}
+	if len(header) == 0 {
+		return &http.Client{Transport: transport}, nil
+	}
+	return &http.Client{Transport: &headerTransport{base: transport, header: header}}, nil
+}
+
+// headerTransport adds the configured headers to requests that do not set them.
+type headerTransport struct {
+	base   http.RoundTripper
+	header http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.header {
+		if req.Header.Get(name) == "" {
+			req.Header[name] = values
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// newTransport creates the transport for a registry. Without a configured proxy, the proxy is
+// taken from the HTTPS_PROXY, HTTP_PROXY, and NO_PROXY environment variables. Certificates
+// chaining to the configured CA file are trusted in addition to the system roots.
+func newTransport(settings config.RegistryHTTP) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	switch settings.Proxy {
+	case "":
+		transport.Proxy = http.ProxyFromEnvironment
+	case config.ProxyDirect:
+		transport.Proxy = nil
+	default:
+		proxy, err := url.Parse(settings.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL for registry %s: %w", settings.Registry, err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if settings.CAFile != "" {
+		roots, err := loadRoots(settings.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CA file for registry %s: %w", settings.Registry, err)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+	return transport, nil
+}
+
+// loadRoots returns the system roots with the PEM encoded certificates in the file at path added.
+func loadRoots(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return roots, nil
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package oci
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewAuthClient(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	ctx := context.Background()
+
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		proxied = append(proxied, req.URL.String())
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(proxy.Close)
+
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = req.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	dir := t.TempDir()
+	t.Setenv("KUBECTL_MFT_CONFIG_DIR", dir)
+	cfg := `
+http:
+  userAgent: kubectl-mft-test
+  headers:
+    X-Team: platform
+  registries:
+    - registry: registry.example.com
+      proxy: ` + proxy.URL + `
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := newAuthClient(host)
+	if err != nil {
+		t.Fatalf("newAuthClient() failed: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/v2/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() failed: %v", err)
+	}
+	resp.Body.Close()
+	if got.Get("X-Team") != "platform" {
+		t.Errorf("X-Team = %q, want %q", got.Get("X-Team"), "platform")
+	}
+	if got.Get("User-Agent") != "kubectl-mft-test" {
+		t.Errorf("User-Agent = %q, want %q", got.Get("User-Agent"), "kubectl-mft-test")
+	}
+
+	// Requests to a registry with a proxy go through it
+	client, err = newAuthClient("registry.example.com")
+	if err != nil {
+		t.Fatalf("newAuthClient() failed: %v", err)
+	}
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, "http://registry.example.com/v2/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() through proxy failed: %v", err)
+	}
+	resp.Body.Close()
+	if len(proxied) != 1 || proxied[0] != "http://registry.example.com/v2/" {
+		t.Errorf("proxied requests = %v, want [http://registry.example.com/v2/]", proxied)
+	}
+}
+
+func TestNewHTTPClientCAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	dir := t.TempDir()
+	t.Setenv("KUBECTL_MFT_CONFIG_DIR", dir)
+	get := func() error {
+		client, err := NewHTTPClient(host)
+		if err != nil {
+			t.Fatalf("NewHTTPClient() failed: %v", err)
+		}
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+	if err := get(); err == nil {
+		t.Fatal("Get() succeeded without the CA file, want a certificate error")
+	}
+
+	caFile := filepath.Join(dir, "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, ca, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := "http:\n  caFile: " + caFile + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(); err != nil {
+		t.Errorf("Get() with the CA file failed: %v", err)
+	}
+}
+
+func TestNewHTTPClientHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = req.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	t.Setenv("KUBECTL_MFT_CONFIG_DIR", dir)
+	cfg := "http:\n  userAgent: mft-test\n  headers:\n    X-Team: platform\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewHTTPClient(strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("NewHTTPClient() failed: %v", err)
+	}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	resp.Body.Close()
+	if got.Get("X-Team") != "platform" || got.Get("User-Agent") != "mft-test" {
+		t.Errorf("request headers = %v, want X-Team and User-Agent from the configuration", got)
+	}
+}
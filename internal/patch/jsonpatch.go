@@ -0,0 +1,232 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package patch
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// operation is a JSON patch operation.
+type operation struct {
+	Op    string    `yaml:"op"`
+	Path  string    `yaml:"path"`
+	From  string    `yaml:"from"`
+	Value yaml.Node `yaml:"value"`
+}
+
+// parseOperations parses the operations of a JSON patch, given as JSON or YAML.
+func parseOperations(data []byte) ([]operation, error) {
+	var ops []operation
+	if err := yaml.Unmarshal(data, &ops); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON patch, expected a list of operations: %w", err)
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("patch is empty")
+	}
+	for i, op := range ops {
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value.Kind == 0 {
+				return nil, fmt.Errorf("operation %d: %s requires a value", i+1, op.Op)
+			}
+		case "move", "copy":
+			if _, err := parsePointer(op.From); err != nil {
+				return nil, fmt.Errorf("operation %d: from: %w", i+1, err)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("operation %d: unsupported op %q", i+1, op.Op)
+		}
+		if _, err := parsePointer(op.Path); err != nil {
+			return nil, fmt.Errorf("operation %d: path: %w", i+1, err)
+		}
+	}
+	return ops, nil
+}
+
+// applyOperations applies the operations in order to the root mapping of a resource.
+func applyOperations(obj *yaml.Node, ops []operation) (*yaml.Node, error) {
+	for _, op := range ops {
+		if err := applyOperation(obj, op); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", op.Op, op.Path, err)
+		}
+	}
+	return obj, nil
+}
+
+func applyOperation(obj *yaml.Node, op operation) error {
+	path, _ := parsePointer(op.Path)
+	switch op.Op {
+	case "add":
+		return add(obj, path, valueNode(&op.Value))
+	case "remove":
+		_, err := remove(obj, path)
+		return err
+	case "replace":
+		return replace(obj, path, valueNode(&op.Value))
+	case "move":
+		from, _ := parsePointer(op.From)
+		value, err := remove(obj, from)
+		if err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+		return add(obj, path, value)
+	case "copy":
+		from, _ := parsePointer(op.From)
+		value, err := get(obj, from)
+		if err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+		return add(obj, path, copyNode(value))
+	case "test":
+		value, err := get(obj, path)
+		if err != nil {
+			return err
+		}
+		var got, want any
+		if err := value.Decode(&got); err != nil {
+			return err
+		}
+		if err := op.Value.Decode(&want); err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(got, want) {
+			return fmt.Errorf("test failed: the value does not match")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported op %q", op.Op)
+}
+
+// parsePointer parses a JSON pointer (RFC 6901) into its reference tokens. The whole resource,
+// the empty pointer, cannot be patched.
+func parsePointer(s string) ([]string, error) {
+	if s == "" {
+		return nil, fmt.Errorf("the root of a resource cannot be patched")
+	}
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", s)
+	}
+	tokens := strings.Split(s[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+// parent returns the node holding the value at path and the last token of path.
+func parent(obj *yaml.Node, path []string) (*yaml.Node, string, error) {
+	n, err := get(obj, path[:len(path)-1])
+	if err != nil {
+		return nil, "", err
+	}
+	return n, path[len(path)-1], nil
+}
+
+// get returns the value at path.
+func get(obj *yaml.Node, path []string) (*yaml.Node, error) {
+	n := obj
+	for i, t := range path {
+		switch n.Kind {
+		case yaml.MappingNode:
+			j := lookup(n, t)
+			if j < 0 {
+				return nil, fmt.Errorf("/%s does not exist", strings.Join(path[:i+1], "/"))
+			}
+			n = n.Content[j]
+		case yaml.SequenceNode:
+			j, err := index(t, len(n.Content)-1)
+			if err != nil {
+				return nil, fmt.Errorf("/%s: %w", strings.Join(path[:i+1], "/"), err)
+			}
+			n = n.Content[j]
+		default:
+			return nil, fmt.Errorf("/%s is not a mapping or list", strings.Join(path[:i], "/"))
+		}
+	}
+	return n, nil
+}
+
+// add sets the value at path, inserting it into a list and replacing the value of a key.
+func add(obj *yaml.Node, path []string, value *yaml.Node) error {
+	p, last, err := parent(obj, path)
+	if err != nil {
+		return err
+	}
+	switch p.Kind {
+	case yaml.MappingNode:
+		if j := lookup(p, last); j >= 0 {
+			p.Content[j] = value
+			return nil
+		}
+		p.Content = append(p.Content, stringNode(last), value)
+	case yaml.SequenceNode:
+		j := len(p.Content)
+		if last != "-" {
+			if j, err = index(last, len(p.Content)); err != nil {
+				return err
+			}
+		}
+		p.Content = append(p.Content[:j], append([]*yaml.Node{value}, p.Content[j:]...)...)
+	default:
+		return fmt.Errorf("the parent is not a mapping or list")
+	}
+	return nil
+}
+
+// replace replaces the existing value at path in place.
+func replace(obj *yaml.Node, path []string, value *yaml.Node) error {
+	if _, err := get(obj, path); err != nil {
+		return err
+	}
+	p, last, err := parent(obj, path)
+	if err != nil {
+		return err
+	}
+	if p.Kind == yaml.MappingNode {
+		p.Content[lookup(p, last)] = value
+	} else {
+		j, _ := index(last, len(p.Content)-1)
+		p.Content[j] = value
+	}
+	return nil
+}
+
+// remove removes the value at path and returns it.
+func remove(obj *yaml.Node, path []string) (*yaml.Node, error) {
+	value, err := get(obj, path)
+	if err != nil {
+		return nil, err
+	}
+	p, last, err := parent(obj, path)
+	if err != nil {
+		return nil, err
+	}
+	switch p.Kind {
+	case yaml.MappingNode:
+		j := lookup(p, last)
+		p.Content = append(p.Content[:j-1], p.Content[j+1:]...)
+	case yaml.SequenceNode:
+		j, _ := index(last, len(p.Content)-1)
+		p.Content = append(p.Content[:j], p.Content[j+1:]...)
+	}
+	return value, nil
+}
+
+// index parses a list index token, which must not exceed maxIndex.
+func index(t string, maxIndex int) (int, error) {
+	i, err := strconv.Atoi(t)
+	if err != nil || i < 0 || (len(t) > 1 && t[0] == '0') {
+		return 0, fmt.Errorf("invalid list index %q", t)
+	}
+	if i > maxIndex {
+		return 0, fmt.Errorf("list index %d is out of range", i)
+	}
+	return i, nil
+}
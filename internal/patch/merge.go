@@ -0,0 +1,242 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package patch
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// directiveKey is the key of strategic merge patch directives in a mapping
+	directiveKey = "$patch"
+	// directiveDelete removes the mapping, or the list element it is merged with
+	directiveDelete = "delete"
+	// directiveReplace replaces the mapping instead of merging it, or the list if it is an
+	// element of its own
+	directiveReplace = "replace"
+)
+
+// mergeKeys are the keys the elements of the lists of a field are merged by in a strategic
+// merge patch, the first key set on every element of the patch is used. Like Kubernetes
+// without schemas of custom resources, lists of other fields are replaced.
+var mergeKeys = map[string][]string{
+	"containers":                {"name"},
+	"initContainers":            {"name"},
+	"ephemeralContainers":       {"name"},
+	"env":                       {"name"},
+	"volumes":                   {"name"},
+	"imagePullSecrets":          {"name"},
+	"volumeMounts":              {"mountPath"},
+	"volumeDevices":             {"devicePath"},
+	"ports":                     {"containerPort", "port"},
+	"hostAliases":               {"ip"},
+	"topologySpreadConstraints": {"topologyKey"},
+	"conditions":                {"type"},
+}
+
+// merge merges patch into dst, a value of field, and returns the result, or nil if the value
+// is deleted. dst may be nil if the field is not set. Null values of a patch mapping remove
+// the key. Nodes of dst are modified in place.
+func merge(dst, patch *yaml.Node, field string, strategic bool) (*yaml.Node, error) {
+	switch {
+	case patch.Kind == yaml.MappingNode:
+		if strategic {
+			directive, err := directiveOf(patch)
+			if err != nil {
+				return nil, err
+			}
+			switch directive {
+			case directiveDelete:
+				return nil, nil
+			case directiveReplace:
+				return merge(nil, withoutDirective(patch), field, strategic)
+			}
+		}
+		if dst == nil || dst.Kind != yaml.MappingNode {
+			dst = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		for i := 0; i+1 < len(patch.Content); i += 2 {
+			key, value := patch.Content[i], patch.Content[i+1]
+			if strategic && strings.HasPrefix(key.Value, "$") {
+				if key.Value == directiveKey {
+					continue
+				}
+				return nil, fmt.Errorf("unsupported strategic merge patch directive %q", key.Value)
+			}
+			j := lookup(dst, key.Value)
+			if isNull(value) {
+				if j >= 0 {
+					dst.Content = append(dst.Content[:j-1], dst.Content[j+1:]...)
+				}
+				continue
+			}
+			var prev *yaml.Node
+			if j >= 0 {
+				prev = dst.Content[j]
+			}
+			merged, err := merge(prev, value, key.Value, strategic)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key.Value, err)
+			}
+			switch {
+			case merged == nil && j >= 0:
+				dst.Content = append(dst.Content[:j-1], dst.Content[j+1:]...)
+			case merged == nil:
+			case j >= 0:
+				dst.Content[j] = merged
+			default:
+				dst.Content = append(dst.Content, valueNode(key), merged)
+			}
+		}
+		return dst, nil
+	case patch.Kind == yaml.SequenceNode && strategic:
+		elements, replace, err := listDirective(patch)
+		if err != nil {
+			return nil, err
+		}
+		if !replace && dst != nil && dst.Kind == yaml.SequenceNode {
+			key, err := mergeKey(field, elements)
+			if err != nil {
+				return nil, err
+			}
+			if key != "" {
+				return mergeList(dst, elements, key)
+			}
+		}
+		list := valueNode(patch)
+		list.Content = list.Content[:0]
+		for _, e := range elements {
+			if e.Kind == yaml.MappingNode {
+				merged, err := merge(nil, e, "", strategic)
+				if err != nil {
+					return nil, err
+				}
+				if merged != nil {
+					list.Content = append(list.Content, merged)
+				}
+				continue
+			}
+			list.Content = append(list.Content, valueNode(e))
+		}
+		return list, nil
+	default:
+		if dst != nil && dst.Kind == yaml.ScalarNode && patch.Kind == yaml.ScalarNode &&
+			dst.ShortTag() == patch.ShortTag() && dst.Value == patch.Value {
+			return dst, nil
+		}
+		value := valueNode(patch)
+		if dst != nil && dst.Kind == yaml.ScalarNode && value.Kind == yaml.ScalarNode {
+			// keep the comments of the patched value
+			value.HeadComment, value.LineComment, value.FootComment = dst.HeadComment, dst.LineComment, dst.FootComment
+		}
+		return value, nil
+	}
+}
+
+// mergeList merges the elements of a patch into the list dst by key. Elements with a
+// "$patch: delete" directive remove the matching element, new elements are appended.
+func mergeList(dst *yaml.Node, elements []*yaml.Node, key string) (*yaml.Node, error) {
+	for _, e := range elements {
+		value := keyValue(e, key)
+		i := -1
+		for j, d := range dst.Content {
+			if d.Kind == yaml.MappingNode && keyValue(d, key) == value {
+				i = j
+				break
+			}
+		}
+		var prev *yaml.Node
+		if i >= 0 {
+			prev = dst.Content[i]
+		}
+		merged, err := merge(prev, e, "", true)
+		if err != nil {
+			return nil, fmt.Errorf("[%s=%s]: %w", key, value, err)
+		}
+		switch {
+		case merged == nil && i >= 0:
+			dst.Content = append(dst.Content[:i], dst.Content[i+1:]...)
+		case merged == nil:
+		case i >= 0:
+			dst.Content[i] = merged
+		default:
+			dst.Content = append(dst.Content, merged)
+		}
+	}
+	return dst, nil
+}
+
+// mergeKey returns the key the elements of a patch of field are merged by, or "" if the list
+// is replaced. Like Kubernetes, it fails if an element of a list merged by key misses the key.
+func mergeKey(field string, elements []*yaml.Node) (string, error) {
+	keys := mergeKeys[field]
+	if len(keys) == 0 || len(elements) == 0 {
+		return "", nil
+	}
+	for _, e := range elements {
+		if e.Kind != yaml.MappingNode {
+			return "", nil
+		}
+	}
+	for _, key := range keys {
+		if !slices.ContainsFunc(elements, func(e *yaml.Node) bool { return keyValue(e, key) == "" }) {
+			return key, nil
+		}
+	}
+	return "", fmt.Errorf("list element does not contain the merge key %s", strings.Join(keys, " or "))
+}
+
+// listDirective returns the elements of the patch list without the elements holding only a
+// "$patch" directive, and whether one of them replaces the list instead of merging it.
+func listDirective(list *yaml.Node) ([]*yaml.Node, bool, error) {
+	var elements []*yaml.Node
+	replace := false
+	for _, e := range list.Content {
+		if e.Kind != yaml.MappingNode || len(e.Content) != 2 || e.Content[0].Value != directiveKey {
+			elements = append(elements, e)
+			continue
+		}
+		if d := e.Content[1].Value; d != directiveReplace {
+			return nil, false, fmt.Errorf("unsupported list %s directive %q", directiveKey, d)
+		}
+		replace = true
+	}
+	return elements, replace, nil
+}
+
+// keyValue returns the scalar value of key in the mapping node, or "" if it is not set.
+func keyValue(m *yaml.Node, key string) string {
+	i := lookup(m, key)
+	if i < 0 || m.Content[i].Kind != yaml.ScalarNode {
+		return ""
+	}
+	return m.Content[i].Value
+}
+
+// directiveOf returns the "$patch" directive of the mapping node, or "" if it has none.
+func directiveOf(m *yaml.Node) (string, error) {
+	i := lookup(m, directiveKey)
+	if i < 0 {
+		return "", nil
+	}
+	switch d := m.Content[i].Value; d {
+	case directiveDelete, directiveReplace:
+		return d, nil
+	default:
+		return "", fmt.Errorf("unsupported %s directive %q", directiveKey, d)
+	}
+}
+
+// withoutDirective returns a copy of the mapping node without its "$patch" directive.
+func withoutDirective(m *yaml.Node) *yaml.Node {
+	c := copyNode(m)
+	if i := lookup(c, directiveKey); i >= 0 {
+		c.Content = append(c.Content[:i-1], c.Content[i+1:]...)
+	}
+	return c
+}
@@ -0,0 +1,286 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+// Package patch applies strategic-merge, JSON merge, and JSON patches to the resources of a manifest.
+package patch
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+// Type is the type of a patch, named like the --type of 'kubectl patch'.
+type Type string
+
+const (
+	// Strategic is a strategic merge patch: lists of known fields, such as containers, are
+	// merged by a key, and "$patch: delete" and "$patch: replace" directives are supported
+	Strategic Type = "strategic"
+	// Merge is a JSON merge patch (RFC 7386): mappings are merged, null removes, lists are replaced
+	Merge Type = "merge"
+	// JSON is a JSON patch (RFC 6902), a list of operations
+	JSON Type = "json"
+)
+
+// Types lists the supported patch types.
+var Types = []Type{Strategic, Merge, JSON}
+
+// ParseType parses a patch type.
+func ParseType(s string) (Type, error) {
+	for _, t := range Types {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported patch type %q, expected strategic, merge, or json", s)
+}
+
+// Target selects the resources a JSON patch applies to. Empty fields match any value.
+type Target struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// ParseTarget parses a target given as <kind>/<name> or <kind>/<namespace>/<name>.
+func ParseTarget(s string) (*Target, error) {
+	parts := strings.Split(s, "/")
+	for _, p := range parts {
+		if p == "" {
+			parts = nil
+			break
+		}
+	}
+	switch len(parts) {
+	case 2:
+		return &Target{Kind: parts[0], Name: parts[1]}, nil
+	case 3:
+		return &Target{Kind: parts[0], Namespace: parts[1], Name: parts[2]}, nil
+	default:
+		return nil, fmt.Errorf("invalid target %q, expected <kind>/<name> or <kind>/<namespace>/<name>", s)
+	}
+}
+
+func (t *Target) matches(d *manifest.Document) bool {
+	return (t.Kind == "" || strings.EqualFold(t.Kind, d.Kind)) &&
+		(t.Namespace == "" || t.Namespace == d.Namespace) &&
+		(t.Name == "" || t.Name == d.Name)
+}
+
+func (t *Target) String() string {
+	parts := []string{t.Kind, t.Namespace, t.Name}
+	if t.Namespace == "" {
+		parts = []string{t.Kind, t.Name}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Patch is a parsed patch file.
+type Patch struct {
+	typ Type
+	// merges are the patch documents of a strategic merge or JSON merge patch
+	merges []mergePatch
+	// ops are the operations of a JSON patch, applied to the resources matching target
+	ops    []operation
+	target *Target
+}
+
+type mergePatch struct {
+	target Target
+	node   *yaml.Node
+}
+
+// Parse parses a patch file of the given type. A strategic merge or JSON merge patch is a
+// multi-document YAML or JSON file, each document naming the resource it applies to by its kind,
+// metadata.name, and optionally metadata.namespace. A JSON patch is a list of operations applied
+// to the resources matching target; target may be nil if the manifest holds a single resource.
+func Parse(typ Type, data []byte, target *Target) (*Patch, error) {
+	p := &Patch{typ: typ, target: target}
+	switch typ {
+	case Strategic, Merge:
+		if target != nil {
+			return nil, fmt.Errorf("a target is only used by JSON patches, %s patches name their resources", typ)
+		}
+		docs, err := manifest.Split(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse patch: %w", err)
+		}
+		for _, d := range docs {
+			if d.Kind == "" || d.Name == "" {
+				return nil, fmt.Errorf("patch document %d must set kind and metadata.name of the resource it patches", len(p.merges)+1)
+			}
+			node, err := parseMapping(d.Raw)
+			if err != nil {
+				return nil, fmt.Errorf("patch for %s: %w", d, err)
+			}
+			p.merges = append(p.merges, mergePatch{
+				target: Target{Kind: d.Kind, Namespace: d.Namespace, Name: d.Name},
+				node:   node,
+			})
+		}
+		if len(p.merges) == 0 {
+			return nil, fmt.Errorf("patch is empty")
+		}
+	case JSON:
+		ops, err := parseOperations(data)
+		if err != nil {
+			return nil, err
+		}
+		p.ops = ops
+	default:
+		return nil, fmt.Errorf("unsupported patch type %q", typ)
+	}
+	return p, nil
+}
+
+// Apply applies the patch to the matching documents and returns all documents, patched ones
+// re-encoded with two-space indentation, and the patched ones. Every patch document must match
+// at least one resource.
+func (p *Patch) Apply(docs []*manifest.Document) (res []*manifest.Document, patched []*manifest.Document, err error) {
+	res = make([]*manifest.Document, len(docs))
+	copy(res, docs)
+
+	switch p.typ {
+	case JSON:
+		target := p.target
+		if target == nil {
+			if len(docs) != 1 {
+				return nil, nil, fmt.Errorf("the manifest holds %d resources, give the target of the JSON patch", len(docs))
+			}
+			target = &Target{}
+		}
+		for i, d := range docs {
+			if !target.matches(d) {
+				continue
+			}
+			if res[i], err = patchDocument(d, func(obj *yaml.Node) (*yaml.Node, error) {
+				return applyOperations(obj, p.ops)
+			}); err != nil {
+				return nil, nil, err
+			}
+			patched = append(patched, res[i])
+		}
+		if len(patched) == 0 {
+			return nil, nil, fmt.Errorf("target %s matches no resource", target)
+		}
+	default:
+		strategic := p.typ == Strategic
+		for _, m := range p.merges {
+			matched := false
+			for i, d := range res {
+				if !m.target.matches(d) {
+					continue
+				}
+				if res[i], err = patchDocument(d, func(obj *yaml.Node) (*yaml.Node, error) {
+					return merge(obj, m.node, "", strategic)
+				}); err != nil {
+					return nil, nil, err
+				}
+				matched = true
+			}
+			if !matched {
+				return nil, nil, fmt.Errorf("patch for %s matches no resource", m.target.String())
+			}
+		}
+		for i, d := range res {
+			if d != docs[i] {
+				patched = append(patched, d)
+			}
+		}
+	}
+	return res, patched, nil
+}
+
+// patchDocument applies fn to the root mapping of d and returns the re-encoded result.
+func patchDocument(d *manifest.Document, fn func(obj *yaml.Node) (*yaml.Node, error)) (*manifest.Document, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(d.Raw, &node); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", d, err)
+	}
+	if len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s is not a YAML mapping", d)
+	}
+	obj, err := fn(node.Content[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to patch %s: %w", d, err)
+	}
+	if obj == nil || obj.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to patch %s: the patched resource is not a mapping", d)
+	}
+	node.Content[0] = obj
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", d, err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", d, err)
+	}
+	res, err := manifest.Split(buf.Bytes())
+	if err != nil || len(res) != 1 {
+		return nil, fmt.Errorf("failed to parse patched %s: %v", d, err)
+	}
+	return res[0], nil
+}
+
+// parseMapping parses a YAML document that must be a mapping.
+func parseMapping(data []byte) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("not a YAML mapping")
+	}
+	return node.Content[0], nil
+}
+
+// lookup returns the index of the value of key in the mapping node, or -1 if it is not set.
+func lookup(m *yaml.Node, key string) int {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func isNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!null"
+}
+
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// copyNode returns a deep copy of n.
+func copyNode(n *yaml.Node) *yaml.Node {
+	c := *n
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		c.Content[i] = copyNode(child)
+	}
+	return &c
+}
+
+// valueNode returns a deep copy of n, a value of a patch, in the block style of manifests, as
+// patches may be written in JSON.
+func valueNode(n *yaml.Node) *yaml.Node {
+	c := copyNode(n)
+	var plain func(n *yaml.Node)
+	plain = func(n *yaml.Node) {
+		n.Style &^= yaml.FlowStyle | yaml.DoubleQuotedStyle | yaml.SingleQuotedStyle
+		for _, child := range n.Content {
+			plain(child)
+		}
+	}
+	plain(c)
+	return c
+}
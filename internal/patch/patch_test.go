@@ -0,0 +1,347 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package patch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+const testManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  level: info
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: prod
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+        - name: app
+          image: nginx:1.25 # pinned
+          env:
+            - name: A
+              value: "1"
+            - name: B
+              value: "2"
+        - name: sidecar
+          image: ghcr.io/org/sidecar:v1
+`
+
+func TestPatchApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     Type
+		patch   string
+		target  string
+		want    string
+		patched []string
+	}{
+		{
+			name: "strategic merges lists by key",
+			typ:  Strategic,
+			patch: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+        - name: app
+          image: nginx:1.26
+          env:
+            - name: B
+              $patch: delete
+            - name: C
+              value: "3"
+`,
+			want: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: prod
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+        - name: app
+          image: nginx:1.26 # pinned
+          env:
+            - name: A
+              value: "1"
+            - name: C
+              value: "3"
+        - name: sidecar
+          image: ghcr.io/org/sidecar:v1
+`,
+			patched: []string{"Deployment prod/app"},
+		},
+		{
+			name: "strategic list replace directive",
+			typ:  Strategic,
+			patch: `kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          env:
+            - $patch: replace
+            - name: C
+              value: "3"
+`,
+			want: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: prod
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+        - name: app
+          image: nginx:1.25 # pinned
+          env:
+            - name: C
+              value: "3"
+        - name: sidecar
+          image: ghcr.io/org/sidecar:v1
+`,
+			patched: []string{"Deployment prod/app"},
+		},
+		{
+			name: "strategic replace directive",
+			typ:  Strategic,
+			patch: `kind: ConfigMap
+metadata:
+  name: config
+data:
+  $patch: replace
+  mode: debug
+`,
+			want: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  mode: debug
+`,
+			patched: []string{"ConfigMap config"},
+		},
+		{
+			name: "merge replaces lists and removes null keys",
+			typ:  Merge,
+			patch: `{"kind": "Deployment", "metadata": {"name": "app"}, "spec": {"replicas": null,
+  "template": {"spec": {"containers": [{"name": "app", "image": "nginx:1.26"}]}}}}`,
+			want: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: prod
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: nginx:1.26
+`,
+			patched: []string{"Deployment prod/app"},
+		},
+		{
+			name: "json patch",
+			typ:  JSON,
+			patch: `- op: replace
+  path: /spec/template/spec/containers/0/image
+  value: nginx:1.26
+- op: remove
+  path: /spec/template/spec/containers/0/env/0
+- op: add
+  path: /spec/template/spec/containers/-
+  value: {name: debug, image: busybox}
+- op: test
+  path: /spec/replicas
+  value: 1
+- op: move
+  from: /spec/replicas
+  path: /spec/minReadySeconds
+`,
+			target: "Deployment/prod/app",
+			want: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: prod
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: nginx:1.26
+          env:
+            - name: B
+              value: "2"
+        - name: sidecar
+          image: ghcr.io/org/sidecar:v1
+        - name: debug
+          image: busybox
+  minReadySeconds: 1
+`,
+			patched: []string{"Deployment prod/app"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var target *Target
+			if tt.target != "" {
+				var err error
+				if target, err = ParseTarget(tt.target); err != nil {
+					t.Fatal(err)
+				}
+			}
+			p, err := Parse(tt.typ, []byte(tt.patch), target)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			docs, err := manifest.Split([]byte(testManifest))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, patched, err := p.Apply(docs)
+			if err != nil {
+				t.Fatalf("Apply() unexpected error: %v", err)
+			}
+			var names []string
+			for _, d := range patched {
+				names = append(names, d.String())
+			}
+			if strings.Join(names, ",") != strings.Join(tt.patched, ",") {
+				t.Errorf("patched = %v, want %v", names, tt.patched)
+			}
+			if len(res) != 2 || string(patched[0].Raw) != tt.want {
+				t.Errorf("patched resource =\n%s\nwant\n%s", patched[0].Raw, tt.want)
+			}
+			for i, d := range res {
+				if d != patched[0] && d != docs[i] {
+					t.Errorf("unpatched %s was re-encoded", d)
+				}
+			}
+		})
+	}
+}
+
+func TestPatchErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     Type
+		patch   string
+		target  *Target
+		wantErr string
+	}{
+		{
+			name:    "no matching resource",
+			typ:     Strategic,
+			patch:   "kind: Service\nmetadata:\n  name: app\n",
+			wantErr: "patch for Service/app matches no resource",
+		},
+		{
+			name:    "strategic list element without merge key",
+			typ:     Strategic,
+			patch:   "kind: Deployment\nmetadata:\n  name: app\nspec:\n  template:\n    spec:\n      containers:\n        - image: nginx:1.26\n",
+			wantErr: "containers: list element does not contain the merge key name",
+		},
+		{
+			name:    "unsupported list directive",
+			typ:     Strategic,
+			patch:   "kind: Deployment\nmetadata:\n  name: app\nspec:\n  template:\n    spec:\n      containers:\n        - $patch: merge\n",
+			wantErr: `unsupported list $patch directive "merge"`,
+		},
+		{
+			name:    "missing name",
+			typ:     Merge,
+			patch:   "kind: ConfigMap\ndata: {}\n",
+			wantErr: "must set kind and metadata.name",
+		},
+		{
+			name:    "json patch without target",
+			typ:     JSON,
+			patch:   "- {op: remove, path: /data}\n",
+			wantErr: "give the target",
+		},
+		{
+			name:    "json patch of a missing path",
+			typ:     JSON,
+			patch:   "- {op: replace, path: /data/missing, value: x}\n",
+			target:  &Target{Kind: "ConfigMap", Name: "config"},
+			wantErr: "/data/missing does not exist",
+		},
+		{
+			name:    "failed test",
+			typ:     JSON,
+			patch:   "- {op: test, path: /data/level, value: debug}\n",
+			target:  &Target{Kind: "ConfigMap", Name: "config"},
+			wantErr: "test failed",
+		},
+		{
+			name:    "unsupported op",
+			typ:     JSON,
+			patch:   "- {op: merge, path: /data}\n",
+			target:  &Target{Kind: "ConfigMap", Name: "config"},
+			wantErr: `unsupported op "merge"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := manifest.Split([]byte(testManifest))
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := Parse(tt.typ, []byte(tt.patch), tt.target)
+			if err == nil {
+				_, _, err = p.Apply(docs)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Target
+		wantErr bool
+	}{
+		{in: "Deployment/app", want: Target{Kind: "Deployment", Name: "app"}},
+		{in: "Deployment/prod/app", want: Target{Kind: "Deployment", Namespace: "prod", Name: "app"}},
+		{in: "app", wantErr: true},
+		{in: "Deployment//app", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseTarget(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && *got != tt.want {
+				t.Errorf("ParseTarget() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
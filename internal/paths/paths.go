@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const appName = "kubectl-mft"
+
+// DefaultProfile is the profile whose data is stored directly in the data directory
+const DefaultProfile = "default"
+
+// profile is the selected profile, see SetProfile
+var profile = DefaultProfile
+
+// SetProfile selects the profile whose manifests, keys, schemas, configuration, and cache are
+// used. Each profile other than the default one has independent data, configuration, and cache
+// directories under profiles/<name> in those of the default profile.
+// An empty name selects the default profile.
+func SetProfile(name string) error {
+	if name == "" {
+		name = DefaultProfile
+	}
+	if strings.ContainsAny(name, "/\\") || name == "." || name == ".." {
+		return fmt.Errorf("invalid profile name %q: must not contain path separators", name)
+	}
+	profile = name
+	return nil
+}
+
+// Profile returns the selected profile.
+func Profile() string {
+	return profile
+}
+
+// DataDir returns the directory holding kubectl-mft data (manifests, keys, and schemas)
+// of the selected profile. The data of profiles other than the default one is stored under
+// profiles/<name> in the base data directory.
+func DataDir() (string, error) {
+	dir, err := baseDataDir()
+	if err != nil {
+		return "", err
+	}
+	return profileDir(dir), nil
+}
+
+// profileDir returns dir for the default profile, and profiles/<name> in dir for other profiles.
+func profileDir(dir string) string {
+	if profile != DefaultProfile {
+		return filepath.Join(dir, "profiles", profile)
+	}
+	return dir
+}
+
+// baseDataDir returns the data directory of the default profile.
+//
+// $XDG_DATA_HOME/kubectl-mft is used when XDG_DATA_HOME is set, on every OS. Otherwise the
+// per-OS default is used: %LOCALAPPDATA%\kubectl-mft on Windows,
+// ~/Library/Application Support/kubectl-mft on macOS, and ~/.local/share/kubectl-mft elsewhere.
+// On macOS an existing ~/.local/share/kubectl-mft is kept so earlier storage stays visible.
+func baseDataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, appName), nil
+	}
+
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+			return filepath.Join(dir, appName), nil
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	legacy := filepath.Join(home, ".local", "share", appName)
+	if runtime.GOOS == "darwin" {
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy, nil
+		}
+		return filepath.Join(home, "Library", "Application Support", appName), nil
+	}
+	return legacy, nil
+}
+
+// ConfigDir returns the directory holding kubectl-mft configuration of the selected profile.
+// It checks the KUBECTL_MFT_CONFIG_DIR environment variable first, then $XDG_CONFIG_HOME on
+// every OS, and falls back to the OS user configuration directory (~/.config on Linux).
+// Profiles other than the default one use profiles/<name> in that directory.
+func ConfigDir() (string, error) {
+	dir, err := baseConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return profileDir(dir), nil
+}
+
+func baseConfigDir() (string, error) {
+	if dir := os.Getenv("KUBECTL_MFT_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, appName), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config directory: %w", err)
+	}
+	return filepath.Join(dir, appName), nil
+}
+
+// CacheDir returns the directory holding disposable kubectl-mft data of the selected profile.
+// It checks the KUBECTL_MFT_CACHE_DIR environment variable first, then $XDG_CACHE_HOME on
+// every OS, and falls back to the OS user cache directory (~/.cache on Linux).
+// Profiles other than the default one use profiles/<name> in that directory.
+func CacheDir() (string, error) {
+	dir, err := baseCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return profileDir(dir), nil
+}
+
+func baseCacheDir() (string, error) {
+	if dir := os.Getenv("KUBECTL_MFT_CACHE_DIR"); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, appName), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache directory: %w", err)
+	}
+	return filepath.Join(dir, appName), nil
+}
+
+// SystemDataDir returns the machine-wide, read-only data directory.
+// It is %ProgramData%\kubectl-mft on Windows and /usr/share/kubectl-mft elsewhere.
+func SystemDataDir() string {
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("ProgramData"); dir != "" {
+			return filepath.Join(dir, appName)
+		}
+		return filepath.Join(`C:\ProgramData`, appName)
+	}
+	return filepath.Join("/usr", "share", appName)
+}
+
+// TempDir returns the directory used for temporary files.
+func TempDir() string {
+	return filepath.Join(os.TempDir(), appName)
+}
@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDataDir_XDGDataHome(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dir)
+
+	got, err := DataDir()
+	if err != nil {
+		t.Fatalf("DataDir() unexpected error: %v", err)
+	}
+	if want := filepath.Join(dir, "kubectl-mft"); got != want {
+		t.Errorf("DataDir() = %q, want %q", got, want)
+	}
+}
+
+func TestDataDir_Default(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("default data directory is OS specific")
+	}
+	home := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("HOME", home)
+
+	got, err := DataDir()
+	if err != nil {
+		t.Fatalf("DataDir() unexpected error: %v", err)
+	}
+	if want := filepath.Join(home, ".local", "share", "kubectl-mft"); got != want {
+		t.Errorf("DataDir() = %q, want %q", got, want)
+	}
+}
+
+func TestDataDir_Profile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dir)
+	t.Setenv("KUBECTL_MFT_CONFIG_DIR", filepath.Join(dir, "config"))
+	t.Setenv("KUBECTL_MFT_CACHE_DIR", filepath.Join(dir, "cache"))
+	t.Cleanup(func() { profile = DefaultProfile })
+
+	tests := []struct {
+		name    string
+		profile string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty selects default", profile: "", want: filepath.Join(dir, "kubectl-mft")},
+		{name: "default", profile: "default", want: filepath.Join(dir, "kubectl-mft")},
+		{name: "named", profile: "work", want: filepath.Join(dir, "kubectl-mft", "profiles", "work")},
+		{name: "path separator", profile: "../work", wantErr: true},
+		{name: "parent", profile: "..", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile = DefaultProfile
+			err := SetProfile(tt.profile)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("SetProfile(%q) expected error", tt.profile)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetProfile(%q) unexpected error: %v", tt.profile, err)
+			}
+			got, err := DataDir()
+			if err != nil {
+				t.Fatalf("DataDir() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DataDir() = %q, want %q", got, tt.want)
+			}
+
+			// Configuration and cache are scoped to the profile in the same way
+			suffix := strings.TrimPrefix(tt.want, filepath.Join(dir, "kubectl-mft"))
+			if got, err := ConfigDir(); err != nil || got != filepath.Join(dir, "config")+suffix {
+				t.Errorf("ConfigDir() = %q, %v, want %q", got, err, filepath.Join(dir, "config")+suffix)
+			}
+			if got, err := CacheDir(); err != nil || got != filepath.Join(dir, "cache")+suffix {
+				t.Errorf("CacheDir() = %q, %v, want %q", got, err, filepath.Join(dir, "cache")+suffix)
+			}
+		})
+	}
+}
+
+func TestConfigDir(t *testing.T) {
+	t.Run("env override", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("KUBECTL_MFT_CONFIG_DIR", dir)
+
+		got, err := ConfigDir()
+		if err != nil {
+			t.Fatalf("ConfigDir() unexpected error: %v", err)
+		}
+		if got != dir {
+			t.Errorf("ConfigDir() = %q, want %q", got, dir)
+		}
+	})
+
+	t.Run("XDG_CONFIG_HOME", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("KUBECTL_MFT_CONFIG_DIR", "")
+		t.Setenv("XDG_CONFIG_HOME", dir)
+
+		got, err := ConfigDir()
+		if err != nil {
+			t.Fatalf("ConfigDir() unexpected error: %v", err)
+		}
+		if want := filepath.Join(dir, "kubectl-mft"); got != want {
+			t.Errorf("ConfigDir() = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestCacheDir_EnvOverride(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("KUBECTL_MFT_CACHE_DIR", dir)
+
+	got, err := CacheDir()
+	if err != nil {
+		t.Fatalf("CacheDir() unexpected error: %v", err)
+	}
+	if got != dir {
+		t.Errorf("CacheDir() = %q, want %q", got, dir)
+	}
+}
+
+func TestCacheDir_XDGCacheHome(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("KUBECTL_MFT_CACHE_DIR", "")
+	t.Setenv("XDG_CACHE_HOME", dir)
+
+	got, err := CacheDir()
+	if err != nil {
+		t.Fatalf("CacheDir() unexpected error: %v", err)
+	}
+	if want := filepath.Join(dir, "kubectl-mft"); got != want {
+		t.Errorf("CacheDir() = %q, want %q", got, want)
+	}
+}
+
+func TestTempDir(t *testing.T) {
+	if want := filepath.Join(os.TempDir(), "kubectl-mft"); TempDir() != want {
+		t.Errorf("TempDir() = %q, want %q", TempDir(), want)
+	}
+}
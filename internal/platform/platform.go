@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+// Package platform isolates the behavior that differs between operating systems, so that
+// the rest of kubectl-mft stays portable and the differences can be tested on any OS.
+package platform
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// escapeColons is set where colons are not allowed in file names
+var escapeColons = runtime.GOOS == "windows"
+
+// colonEscape replaces colons in file names. It cannot occur in repository names, whose
+// only colon separates the port of a registry host.
+const colonEscape = "+"
+
+// FromName returns the relative file path of a slash-separated name, such as a repository
+// name, with one path element per component. On Windows, colons, as in localhost:5000, are
+// replaced by a plus sign.
+func FromName(name string) string {
+	if escapeColons {
+		name = strings.ReplaceAll(name, ":", colonEscape)
+	}
+	return filepath.FromSlash(name)
+}
+
+// ToName returns the slash-separated name of a relative file path, the inverse of FromName.
+func ToName(path string) string {
+	name := filepath.ToSlash(path)
+	if escapeColons {
+		name = strings.ReplaceAll(name, colonEscape, ":")
+	}
+	return name
+}
+
+// DefaultEditor returns the editor used when neither KUBE_EDITOR nor EDITOR is set: notepad
+// on Windows and vi elsewhere, as with kubectl edit.
+func DefaultEditor() string {
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// SplitCommand splits a command line such as an editor setting into its arguments like a
+// shell: single and double quotes group words, and a backslash escapes a quote, a backslash,
+// or whitespace. Other backslashes are kept, so that Windows paths need no escaping.
+func SplitCommand(s string) ([]string, error) {
+	var (
+		args    []string
+		arg     strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+	for _, c := range s {
+		switch {
+		case escaped:
+			if !strings.ContainsRune(`"'\ `+"\t", c) || quote == '\'' {
+				arg.WriteByte('\\')
+			}
+			arg.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote, inArg = c, true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(c)
+			inArg = true
+		}
+	}
+	if escaped {
+		arg.WriteByte('\\')
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, s)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
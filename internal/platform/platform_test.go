@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package platform
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFromName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		escaped string
+	}{
+		{"ghcr.io/org/app", filepath.Join("ghcr.io", "org", "app"), filepath.Join("ghcr.io", "org", "app")},
+		{"local/app", filepath.Join("local", "app"), filepath.Join("local", "app")},
+		{"localhost:5000/app", filepath.Join("localhost:5000", "app"), filepath.Join("localhost+5000", "app")},
+	}
+	orig := escapeColons
+	t.Cleanup(func() { escapeColons = orig })
+
+	for _, escape := range []bool{false, true} {
+		escapeColons = escape
+		for _, tt := range tests {
+			want := tt.want
+			if escape {
+				want = tt.escaped
+			}
+			got := FromName(tt.name)
+			if got != want {
+				t.Errorf("FromName(%q) with escapeColons=%v = %q, want %q", tt.name, escape, got, want)
+			}
+			if back := ToName(got); back != tt.name {
+				t.Errorf("ToName(%q) with escapeColons=%v = %q, want %q", got, escape, back, tt.name)
+			}
+		}
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		wantErr bool
+	}{
+		{command: "vim", want: []string{"vim"}},
+		{command: "  code   --wait ", want: []string{"code", "--wait"}},
+		{command: `"C:\Program Files\Notepad++\notepad++.exe" -multiInst`, want: []string{`C:\Program Files\Notepad++\notepad++.exe`, "-multiInst"}},
+		{command: `C:\tools\vim.exe`, want: []string{`C:\tools\vim.exe`}},
+		{command: `/opt/my\ editor/bin/edit --title 'Edit "app"'`, want: []string{"/opt/my editor/bin/edit", "--title", `Edit "app"`}},
+		{command: `emacs -nw ""`, want: []string{"emacs", "-nw", ""}},
+		{command: "", want: nil},
+		{command: `code "--wait`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := SplitCommand(tt.command)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("SplitCommand(%q) succeeded, want an error", tt.command)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SplitCommand(%q) failed: %v", tt.command, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("SplitCommand(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+//go:build !windows
+
+package platform
+
+import "os"
+
+// IsPrivate reports whether the file at path is only accessible by its owner, that is,
+// whether neither its group nor others have any permission.
+func IsPrivate(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	return info.Mode().Perm()&0o077 == 0, nil
+}
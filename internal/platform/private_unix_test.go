@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+//go:build !windows
+
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsPrivate(t *testing.T) {
+	for _, tt := range []struct {
+		perm os.FileMode
+		want bool
+	}{
+		{0o600, true},
+		{0o400, true},
+		{0o640, false},
+		{0o604, false},
+	} {
+		path := filepath.Join(t.TempDir(), "key")
+		if err := os.WriteFile(path, nil, tt.perm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, tt.perm); err != nil {
+			t.Fatal(err)
+		}
+		got, err := IsPrivate(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("IsPrivate(%v) = %v, want %v", tt.perm, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+//go:build windows
+
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// IsPrivate reports whether the file at path is only accessible by its owner. Access on
+// Windows is controlled by the DACL of the file, which file modes do not reflect: the file
+// is private if the DACL grants access to no one but its owner, SYSTEM, and the
+// Administrators group, as the DACL inherited within the user profile does.
+func IsPrivate(path string) (bool, error) {
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT,
+		windows.OWNER_SECURITY_INFORMATION|windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return false, fmt.Errorf("failed to read the security descriptor of %s: %w", path, err)
+	}
+	owner, _, err := sd.Owner()
+	if err != nil {
+		return false, fmt.Errorf("failed to read the owner of %s: %w", path, err)
+	}
+	dacl, _, err := sd.DACL()
+	if errors.Is(err, windows.ERROR_OBJECT_NOT_FOUND) {
+		// Without a DACL, everyone has full access
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read the DACL of %s: %w", path, err)
+	}
+	if dacl == nil {
+		// A null DACL grants everyone full access as well
+		return false, nil
+	}
+
+	for i := range uint32(dacl.AceCount) {
+		var ace *windows.ACCESS_ALLOWED_ACE
+		if err := windows.GetAce(dacl, i, &ace); err != nil {
+			return false, fmt.Errorf("failed to read the DACL of %s: %w", path, err)
+		}
+		if ace.Header.AceType != windows.ACCESS_ALLOWED_ACE_TYPE {
+			continue
+		}
+		sid := (*windows.SID)(unsafe.Pointer(&ace.SidStart))
+		if sid.Equals(owner) || sid.IsWellKnown(windows.WinLocalSystemSid) || sid.IsWellKnown(windows.WinBuiltinAdministratorsSid) {
+			continue
+		}
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,13 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+//go:build !windows
+
+package platform
+
+import "syscall"
+
+// Umask sets the file mode creation mask of the process and returns the previous mask.
+func Umask(mask int) int {
+	return syscall.Umask(mask)
+}
@@ -0,0 +1,11 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+//go:build windows
+
+package platform
+
+// Umask does nothing on Windows, which has no file mode creation mask, and returns 0.
+func Umask(mask int) int {
+	return 0
+}
@@ -0,0 +1,233 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+// Package rbac analyzes the permissions granted by the RBAC resources of a manifest.
+package rbac
+
+import (
+	"fmt"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+const (
+	kindRole               = "Role"
+	kindClusterRole        = "ClusterRole"
+	kindRoleBinding        = "RoleBinding"
+	kindClusterRoleBinding = "ClusterRoleBinding"
+
+	clusterAdmin = "cluster-admin"
+	// ScopeCluster is the scope of permissions granted in every namespace
+	ScopeCluster = "cluster"
+)
+
+// Grant represents the permissions a rule of a bound role grants to a subject
+type Grant struct {
+	// Subject is the bound subject, such as "ServiceAccount app/controller" or "Group devs"
+	Subject string `json:"subject" yaml:"subject"`
+	// Scope is the namespace the permissions apply to, or "cluster" for cluster-wide grants
+	Scope string `json:"scope" yaml:"scope"`
+	// Binding is the granting binding, such as "RoleBinding app/controller"
+	Binding string `json:"binding" yaml:"binding"`
+	// Role is the bound role, such as "ClusterRole/view"
+	Role            string   `json:"role" yaml:"role"`
+	Verbs           []string `json:"verbs,omitempty" yaml:"verbs,omitempty"`
+	Resources       []string `json:"resources,omitempty" yaml:"resources,omitempty"`
+	ResourceNames   []string `json:"resourceNames,omitempty" yaml:"resourceNames,omitempty"`
+	NonResourceURLs []string `json:"nonResourceURLs,omitempty" yaml:"nonResourceURLs,omitempty"`
+	// Findings flag dangerous permissions, such as wildcards or cluster-admin
+	Findings []string `json:"findings,omitempty" yaml:"findings,omitempty"`
+}
+
+type policyRule struct {
+	APIGroups       []string `yaml:"apiGroups"`
+	Resources       []string `yaml:"resources"`
+	ResourceNames   []string `yaml:"resourceNames"`
+	NonResourceURLs []string `yaml:"nonResourceURLs"`
+	Verbs           []string `yaml:"verbs"`
+}
+
+type role struct {
+	Rules           []policyRule `yaml:"rules"`
+	AggregationRule any          `yaml:"aggregationRule"`
+}
+
+type subject struct {
+	Kind      string `yaml:"kind"`
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
+}
+
+type binding struct {
+	RoleRef struct {
+		Kind string `yaml:"kind"`
+		Name string `yaml:"name"`
+	} `yaml:"roleRef"`
+	Subjects []subject `yaml:"subjects"`
+}
+
+// builtinClusterAdmin are the rules of the built-in cluster-admin ClusterRole
+var builtinClusterAdmin = &role{Rules: []policyRule{
+	{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+	{NonResourceURLs: []string{"*"}, Verbs: []string{"*"}},
+}}
+
+// Analyze returns the permissions granted by the bindings of the manifest, one grant per subject
+// and rule of the bound role, in manifest order. Roles defined outside the manifest cannot be
+// resolved and are flagged, except for the built-in cluster-admin ClusterRole.
+func Analyze(docs []*manifest.Document) ([]*Grant, error) {
+	roles := make(map[string]*role)
+	var bindings []*manifest.Document
+	for _, d := range docs {
+		switch d.Kind {
+		case kindRole, kindClusterRole:
+			var r role
+			if err := yaml.Unmarshal(d.Raw, &r); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", d, err)
+			}
+			roles[roleKey(d.Kind, d.Namespace, d.Name)] = &r
+		case kindRoleBinding, kindClusterRoleBinding:
+			bindings = append(bindings, d)
+		}
+	}
+
+	var grants []*Grant
+	for _, d := range bindings {
+		var b binding
+		if err := yaml.Unmarshal(d.Raw, &b); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", d, err)
+		}
+		scope := ScopeCluster
+		if d.Kind == kindRoleBinding {
+			scope = d.Namespace
+		}
+		roleNamespace := ""
+		if b.RoleRef.Kind == kindRole {
+			roleNamespace = d.Namespace
+		}
+		roleName := b.RoleRef.Kind + "/" + b.RoleRef.Name
+
+		r, ok := roles[roleKey(b.RoleRef.Kind, roleNamespace, b.RoleRef.Name)]
+		var roleFindings []string
+		switch {
+		case ok && r.AggregationRule != nil:
+			roleFindings = append(roleFindings, "aggregated role, permissions depend on the cluster")
+		case !ok && b.RoleRef.Kind == kindClusterRole && b.RoleRef.Name == clusterAdmin:
+			r = builtinClusterAdmin
+		case !ok:
+			r = &role{}
+			roleFindings = append(roleFindings, "role not defined in the manifest")
+		}
+		if b.RoleRef.Kind == kindClusterRole && b.RoleRef.Name == clusterAdmin {
+			roleFindings = append(roleFindings, clusterAdmin)
+		}
+
+		for _, s := range b.Subjects {
+			subjectName := formatSubject(s, d.Namespace)
+			subjectFindings := slices.Clone(roleFindings)
+			if isAnonymous(s) {
+				subjectFindings = append(subjectFindings, "anonymous access")
+			}
+			if s.Kind == "Group" && s.Name == "system:authenticated" {
+				subjectFindings = append(subjectFindings, "all authenticated users")
+			}
+			rules := r.Rules
+			if len(rules) == 0 {
+				// The grant is reported even without rules, e.g. for unresolved roles
+				rules = []policyRule{{}}
+			}
+			for _, rule := range rules {
+				grants = append(grants, &Grant{
+					Subject:         subjectName,
+					Scope:           scope,
+					Binding:         d.Kind + " " + qualifiedName(d.Namespace, d.Name),
+					Role:            roleName,
+					Verbs:           rule.Verbs,
+					Resources:       formatResources(rule),
+					ResourceNames:   rule.ResourceNames,
+					NonResourceURLs: rule.NonResourceURLs,
+					Findings:        append(slices.Clone(subjectFindings), ruleFindings(rule)...),
+				})
+			}
+		}
+	}
+	return grants, nil
+}
+
+func roleKey(kind, namespace, name string) string {
+	if kind == kindClusterRole {
+		namespace = ""
+	}
+	return kind + "/" + namespace + "/" + name
+}
+
+// formatSubject returns a subject such as "ServiceAccount app/controller". Service accounts
+// without a namespace in a RoleBinding are in the namespace of the binding.
+func formatSubject(s subject, bindingNamespace string) string {
+	if s.Kind != "ServiceAccount" {
+		return s.Kind + " " + s.Name
+	}
+	namespace := s.Namespace
+	if namespace == "" {
+		namespace = bindingNamespace
+	}
+	return s.Kind + " " + qualifiedName(namespace, s.Name)
+}
+
+func isAnonymous(s subject) bool {
+	return s.Kind == "Group" && s.Name == "system:unauthenticated" || s.Kind == "User" && s.Name == "system:anonymous"
+}
+
+// formatResources returns the resources of a rule qualified by API group, such as "apps/deployments".
+// Resources of the core group are not qualified.
+func formatResources(rule policyRule) []string {
+	var resources []string
+	for _, group := range rule.APIGroups {
+		for _, resource := range rule.Resources {
+			if group == "" {
+				resources = append(resources, resource)
+			} else {
+				resources = append(resources, group+"/"+resource)
+			}
+		}
+	}
+	return resources
+}
+
+// ruleFindings flags the dangerous permissions of a rule.
+func ruleFindings(rule policyRule) []string {
+	var findings []string
+	if slices.Contains(rule.Verbs, "*") {
+		findings = append(findings, "wildcard verbs")
+	}
+	if slices.Contains(rule.APIGroups, "*") {
+		findings = append(findings, "wildcard API groups")
+	}
+	if slices.Contains(rule.Resources, "*") {
+		findings = append(findings, "wildcard resources")
+	}
+	if slices.Contains(rule.NonResourceURLs, "*") {
+		findings = append(findings, "wildcard URLs")
+	}
+	for _, verb := range []string{"escalate", "bind", "impersonate"} {
+		if slices.Contains(rule.Verbs, verb) {
+			findings = append(findings, "privilege escalation ("+verb+")")
+		}
+	}
+	if slices.Contains(rule.Resources, "secrets") && slices.ContainsFunc(rule.Verbs, func(v string) bool {
+		return v == "get" || v == "list" || v == "watch" || v == "*"
+	}) {
+		findings = append(findings, "secret access")
+	}
+	return findings
+}
+
+func qualifiedName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package rbac
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+const testManifest = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: reader
+rules:
+  - apiGroups: ["", apps]
+    resources: [pods, deployments]
+    verbs: [get, list]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: reader
+roleRef:
+  kind: ClusterRole
+  name: reader
+subjects:
+  - kind: ServiceAccount
+    name: web
+    namespace: app
+  - kind: Group
+    name: system:unauthenticated
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: manager
+  namespace: app
+rules:
+  - apiGroups: ["*"]
+    resources: ["*"]
+    verbs: [escalate]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: manager
+  namespace: app
+roleRef:
+  kind: Role
+  name: manager
+subjects:
+  - kind: ServiceAccount
+    name: manager
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: admin
+roleRef:
+  kind: ClusterRole
+  name: cluster-admin
+subjects:
+  - kind: User
+    name: alice
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: external
+  namespace: app
+roleRef:
+  kind: ClusterRole
+  name: custom
+subjects:
+  - kind: User
+    name: bob
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: secrets
+  namespace: app
+rules:
+  - apiGroups: [""]
+    resources: [secrets]
+    verbs: ["*"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: secrets
+  namespace: app
+roleRef:
+  kind: Role
+  name: secrets
+subjects:
+  - kind: Group
+    name: system:authenticated
+`
+
+func TestAnalyze(t *testing.T) {
+	docs, err := manifest.Split([]byte(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	grants, err := Analyze(docs)
+	if err != nil {
+		t.Fatalf("Analyze() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		subject   string
+		scope     string
+		role      string
+		resources []string
+		findings  []string
+	}{
+		{"ServiceAccount app/web", ScopeCluster, "ClusterRole/reader", []string{"pods", "deployments", "apps/pods", "apps/deployments"}, nil},
+		{"Group system:unauthenticated", ScopeCluster, "ClusterRole/reader", []string{"pods", "deployments", "apps/pods", "apps/deployments"}, []string{"anonymous access"}},
+		{"ServiceAccount app/manager", "app", "Role/manager", []string{"*/*"}, []string{"wildcard API groups", "wildcard resources", "privilege escalation (escalate)"}},
+		{"User alice", ScopeCluster, "ClusterRole/cluster-admin", []string{"*/*"}, []string{"cluster-admin", "wildcard verbs", "wildcard API groups", "wildcard resources"}},
+		{"User alice", ScopeCluster, "ClusterRole/cluster-admin", nil, []string{"cluster-admin", "wildcard verbs", "wildcard URLs"}},
+		{"User bob", "app", "ClusterRole/custom", nil, []string{"role not defined in the manifest"}},
+		{"Group system:authenticated", "app", "Role/secrets", []string{"secrets"}, []string{"all authenticated users", "wildcard verbs", "secret access"}},
+	}
+	if len(grants) != len(tests) {
+		t.Fatalf("Analyze() returned %d grants, want %d", len(grants), len(tests))
+	}
+	for i, tt := range tests {
+		g := grants[i]
+		if g.Subject != tt.subject || g.Scope != tt.scope || g.Role != tt.role {
+			t.Errorf("grants[%d] = %s, %s, %s, want %s, %s, %s", i, g.Subject, g.Scope, g.Role, tt.subject, tt.scope, tt.role)
+		}
+		if !slices.Equal(g.Resources, tt.resources) {
+			t.Errorf("grants[%d].Resources = %v, want %v", i, g.Resources, tt.resources)
+		}
+		if !slices.Equal(g.Findings, tt.findings) {
+			t.Errorf("grants[%d].Findings = %v, want %v", i, g.Findings, tt.findings)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+// Package server exposes the operations of a manifest store over an HTTP API, so that
+// short-lived jobs on one machine can share a single storage directory.
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/chez-shanpu/kubectl-mft/pkg/mft"
+)
+
+// maxManifestSize limits the size of manifests uploaded to be packed.
+const maxManifestSize = 16 << 20
+
+// Server serves the list, dump, pack, and verify operations of a Client:
+//
+//	GET  /v1/manifests              list the stored manifests as JSON
+//	GET  /v1/dump?ref=<ref>         return the content of a manifest
+//	POST /v1/pack?ref=<ref>         pack the manifest in the request body, signing it with the server key
+//	POST /v1/verify?ref=<ref>       verify the signature of a manifest with the trusted keys
+//
+// Packing applies pack.limits of config.yaml and records the change in the metadata index and
+// the event log, like the CLI.
+// Writes are serialized, reads run concurrently.
+type Server struct {
+	client *mft.Client
+	store  mft.Store
+	// token is the bearer token required on every request, if set
+	token string
+	// signer signs packed manifests, if set
+	signer crypto.Signer
+	// verifier verifies manifests instead of the Client, if set
+	verifier VerifyFunc
+	mu       sync.RWMutex
+}
+
+// VerifyFunc verifies the signature of the stored manifest ref and reports whether it was
+// verified. It returns false without an error if verification is disabled for the manifest.
+type VerifyFunc func(ctx context.Context, ref string) (bool, error)
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithToken requires every request to carry the token as a bearer token.
+func WithToken(token string) Option {
+	return func(s *Server) {
+		s.token = token
+	}
+}
+
+// WithSigner signs manifests packed through the server with key.
+func WithSigner(key crypto.Signer) Option {
+	return func(s *Server) {
+		s.signer = key
+	}
+}
+
+// WithVerifier verifies manifests with verify, e.g. to apply a trust policy, instead of with
+// the public keys of the key directory.
+func WithVerifier(verify VerifyFunc) Option {
+	return func(s *Server) {
+		s.verifier = verify
+	}
+}
+
+// New returns a Server for the manifests of store.
+func New(store mft.Store, opts ...Option) (*Server, error) {
+	client, err := mft.New(mft.WithStore(store))
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{client: client, store: store}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Handler returns the HTTP handler of the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/manifests", s.list)
+	mux.HandleFunc("GET /v1/dump", s.dump)
+	mux.HandleFunc("POST /v1/pack", s.pack)
+	mux.HandleFunc("POST /v1/verify", s.verify)
+	return s.authenticate(mux)
+}
+
+// authenticate rejects requests without the bearer token, if one is required.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+				writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	infos, err := s.client.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if infos == nil {
+		infos = []*mft.Info{}
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func (s *Server) dump(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ref, ok := s.existing(w, r)
+	if !ok {
+		return
+	}
+	data, err := s.client.Dump(r.Context(), ref)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(data)
+}
+
+func (s *Server) pack(w http.ResponseWriter, r *http.Request) {
+	ref := r.URL.Query().Get("ref")
+	if ref == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing ref parameter"))
+		return
+	}
+	skipValidation, _ := strconv.ParseBool(r.URL.Query().Get("skipValidation"))
+
+	// The manifest is packed from a file, as the CLI does
+	dir, err := os.MkdirTemp("", "kubectl-mft-serve-")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "manifest.yaml")
+	if err := saveBody(r.Body, path); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, err := s.store.Repository(ref)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	exists, err := repo.Exists(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if exists {
+		writeError(w, http.StatusConflict, fmt.Errorf("%s already exists", ref))
+		return
+	}
+	err = s.client.Pack(r.Context(), ref, mft.PackOptions{ManifestPath: path, SkipValidation: skipValidation, Signer: s.signer})
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"ref": ref, "signed": s.signer != nil})
+}
+
+func (s *Server) verify(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ref, ok := s.existing(w, r)
+	if !ok {
+		return
+	}
+	if s.verifier == nil {
+		if err := s.client.Verify(r.Context(), ref, mft.VerifyOptions{}); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ref": ref, "verified": true})
+		return
+	}
+	verified, err := s.verifier(r.Context(), ref)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ref": ref, "verified": verified})
+}
+
+// existing returns the ref parameter of the request, after checking that the manifest is stored.
+// It writes the error response and returns false otherwise.
+func (s *Server) existing(w http.ResponseWriter, r *http.Request) (string, bool) {
+	ref := r.URL.Query().Get("ref")
+	if ref == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing ref parameter"))
+		return "", false
+	}
+	repo, err := s.store.Repository(ref)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return "", false
+	}
+	exists, err := repo.Exists(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return "", false
+	}
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%s not found", ref))
+		return "", false
+	}
+	return ref, true
+}
+
+// saveBody writes the request body to path, refusing bodies larger than maxManifestSize.
+func saveBody(body io.Reader, path string) error {
+	data, err := io.ReadAll(io.LimitReader(body, maxManifestSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if len(data) > maxManifestSize {
+		return fmt.Errorf("manifest exceeds %d bytes", maxManifestSize)
+	}
+	if len(data) == 0 {
+		return errors.New("empty manifest")
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+	"github.com/chez-shanpu/kubectl-mft/pkg/mft"
+)
+
+const testManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+`
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("KUBECTL_MFT_CONFIG_DIR", filepath.Join(dir, "config"))
+	t.Setenv("KUBECTL_MFT_SYSTEM_STORAGE_DIR", "")
+	store, err := mft.NewLocalStore(filepath.Join(dir, "manifests"))
+	if err != nil {
+		t.Fatalf("NewLocalStore failed: %v", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signature.ImportPublicKeyData("server", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(store, WithToken("secret"), WithSigner(key))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(srv.Close)
+
+	do := func(method, path, token, body string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "missing token", method: "GET", path: "/v1/manifests", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", method: "GET", path: "/v1/manifests", token: "wrong", wantStatus: http.StatusUnauthorized},
+		{name: "empty list", method: "GET", path: "/v1/manifests", token: "secret", wantStatus: http.StatusOK, wantBody: "[]"},
+		{name: "pack", method: "POST", path: "/v1/pack?ref=app:v1&skipValidation=true", token: "secret", body: testManifest, wantStatus: http.StatusCreated, wantBody: `"signed":true`},
+		{name: "pack existing", method: "POST", path: "/v1/pack?ref=app:v1&skipValidation=true", token: "secret", body: testManifest, wantStatus: http.StatusConflict},
+		{name: "pack empty", method: "POST", path: "/v1/pack?ref=app:v2", token: "secret", wantStatus: http.StatusBadRequest},
+		{name: "dump", method: "GET", path: "/v1/dump?ref=app:v1", token: "secret", wantStatus: http.StatusOK, wantBody: testManifest},
+		{name: "dump missing", method: "GET", path: "/v1/dump?ref=app:v9", token: "secret", wantStatus: http.StatusNotFound},
+		{name: "dump without ref", method: "GET", path: "/v1/dump", token: "secret", wantStatus: http.StatusBadRequest},
+		{name: "verify", method: "POST", path: "/v1/verify?ref=app:v1", token: "secret", wantStatus: http.StatusOK, wantBody: `"verified":true`},
+		{name: "list", method: "GET", path: "/v1/manifests", token: "secret", wantStatus: http.StatusOK, wantBody: `"tag":"v1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := do(tt.method, tt.path, tt.token, tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", status, tt.wantStatus, body)
+			}
+			if !strings.Contains(body, tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", body, tt.wantBody)
+			}
+			if status >= 400 {
+				var e struct{ Error string }
+				if err := json.Unmarshal([]byte(body), &e); err != nil || e.Error == "" {
+					t.Errorf("expected a JSON error, got %q", body)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_Verifier(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("KUBECTL_MFT_CONFIG_DIR", filepath.Join(dir, "config"))
+	t.Setenv("KUBECTL_MFT_SYSTEM_STORAGE_DIR", "")
+	store, err := mft.NewLocalStore(filepath.Join(dir, "manifests"))
+	if err != nil {
+		t.Fatalf("NewLocalStore failed: %v", err)
+	}
+	path := filepath.Join(dir, "manifest.yaml")
+	if err := os.WriteFile(path, []byte(testManifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	client, err := mft.New(mft.WithStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ref := range []string{"app:v1", "app:v2"} {
+		if err := client.Pack(context.Background(), ref, mft.PackOptions{ManifestPath: path, SkipValidation: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The verifier decides instead of the keys of the key directory, which verify neither
+	verify := func(_ context.Context, ref string) (bool, error) {
+		if ref == "app:v1" {
+			return false, nil
+		}
+		return false, errors.New("untrusted signer")
+	}
+	s, err := New(store, WithVerifier(verify))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		ref        string
+		wantStatus int
+		wantBody   string
+	}{
+		{ref: "app:v1", wantStatus: http.StatusOK, wantBody: `"verified":false`},
+		{ref: "app:v2", wantStatus: http.StatusUnprocessableEntity, wantBody: "untrusted signer"},
+	}
+	for _, tt := range tests {
+		resp, err := http.Post(srv.URL+"/v1/verify?ref="+tt.ref, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("verify %s: status = %d, want %d: %s", tt.ref, resp.StatusCode, tt.wantStatus, body)
+		}
+		if !strings.Contains(string(body), tt.wantBody) {
+			t.Errorf("verify %s: body = %q, want it to contain %q", tt.ref, body, tt.wantBody)
+		}
+	}
+}
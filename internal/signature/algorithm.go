@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"slices"
+)
+
+// Key algorithms of GenerateKeyPair
+const (
+	AlgorithmECDSAP256 = "ecdsa-p256"
+	AlgorithmECDSAP384 = "ecdsa-p384"
+	AlgorithmEd25519   = "ed25519"
+	AlgorithmRSA4096   = "rsa-4096"
+)
+
+// Algorithms lists the key algorithms of GenerateKeyPair, the default first.
+var Algorithms = []string{AlgorithmECDSAP256, AlgorithmECDSAP384, AlgorithmEd25519, AlgorithmRSA4096}
+
+// minRSABits is the minimum size of RSA keys accepted for signing and verification
+const minRSABits = 2048
+
+// WithAlgorithm generates a key pair of the given algorithm, one of Algorithms, instead of ECDSA P-256.
+func WithAlgorithm(algorithm string) GenerateOption {
+	return func(o *generateOptions) {
+		o.algorithm = algorithm
+	}
+}
+
+// generateKey generates a private key of the algorithm. An empty algorithm generates ECDSA P-256.
+func generateKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case "", AlgorithmECDSAP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgorithmECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case AlgorithmEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	case AlgorithmRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm %q, expected one of %v", algorithm, Algorithms)
+	}
+}
+
+// checkPublicKey returns an error unless signatures of pub can be verified: ECDSA keys on
+// the NIST P-256, P-384, or P-521 curves, Ed25519 keys, and RSA keys of at least 2048 bits.
+func checkPublicKey(pub crypto.PublicKey) error {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !slices.Contains([]elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()}, k.Curve) {
+			return fmt.Errorf("unsupported ECDSA curve %s", k.Curve.Params().Name)
+		}
+	case ed25519.PublicKey:
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSABits {
+			return fmt.Errorf("RSA key of %d bits is too small, at least %d bits are required", k.N.BitLen(), minRSABits)
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+)
+
+func TestGenerateKeyPairAlgorithms(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		want      string
+	}{
+		{algorithm: AlgorithmECDSAP256, want: "ECDSA P-256"},
+		{algorithm: AlgorithmECDSAP384, want: "ECDSA P-384"},
+		{algorithm: AlgorithmEd25519, want: "Ed25519"},
+		{algorithm: AlgorithmRSA4096, want: "RSA 4096"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			cleanup := setupTestKeyDir(t)
+			defer cleanup()
+			ctx := context.Background()
+
+			if err := GenerateKeyPair("k", false, WithAlgorithm(tt.algorithm)); err != nil {
+				t.Fatalf("GenerateKeyPair failed: %v", err)
+			}
+			details, err := InspectKey("k")
+			if err != nil {
+				t.Fatalf("InspectKey failed: %v", err)
+			}
+			if details.Algorithm != tt.want {
+				t.Errorf("Algorithm = %q, want %q", details.Algorithm, tt.want)
+			}
+
+			signer, err := NewSignerFromKeyDir("k")
+			if err != nil {
+				t.Fatalf("NewSignerFromKeyDir failed: %v", err)
+			}
+			layoutPath, tag := setupTestOCILayout(t)
+			if _, err := signer.Sign(ctx, layoutPath, tag); err != nil {
+				t.Fatalf("Sign failed: %v", err)
+			}
+			verifier, err := NewVerifierFromKeyDir()
+			if err != nil {
+				t.Fatalf("NewVerifierFromKeyDir failed: %v", err)
+			}
+			if err := verifier.Verify(ctx, layoutPath, tag); err != nil {
+				t.Errorf("Verify failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestGenerateKeyPairUnknownAlgorithm(t *testing.T) {
+	cleanup := setupTestKeyDir(t)
+	defer cleanup()
+
+	if err := GenerateKeyPair("k", false, WithAlgorithm("dsa")); err == nil {
+		t.Fatal("GenerateKeyPair should fail with an unknown algorithm")
+	}
+	if PrivateKeyExists("k") {
+		t.Error("private key written for an unknown algorithm")
+	}
+}
+
+func TestImportPublicKeyData_Algorithms(t *testing.T) {
+	cleanup := setupTestKeyDir(t)
+	defer cleanup()
+
+	rsa2048, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsa1024, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p224, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		key     crypto.PublicKey
+		wantErr bool
+	}{
+		{name: "rsa-2048", key: &rsa2048.PublicKey},
+		{name: "rsa-1024", key: &rsa1024.PublicKey, wantErr: true},
+		{name: "ecdsa-p224", key: &p224.PublicKey, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := marshalPublicKeyPEM(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = ImportPublicKeyData(tt.name, data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ImportPublicKeyData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
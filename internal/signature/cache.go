@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/paths"
+)
+
+// DefaultVerifyCacheTTL is how long a successful verification is trusted without re-verifying.
+const DefaultVerifyCacheTTL = 24 * time.Hour
+
+const verifyCacheFile = "verify-cache.json"
+
+// VerifyCache remembers successful verifications of manifest digests, keyed by the digest and
+// the fingerprints of the keys that were trusted at the time. Changing the trusted keys
+// invalidates earlier results. Failed verifications are never cached.
+type VerifyCache struct {
+	path string
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// NewVerifyCache creates a cache stored at path whose entries expire after ttl.
+func NewVerifyCache(path string, ttl time.Duration) *VerifyCache {
+	return &VerifyCache{
+		path: path,
+		ttl:  ttl,
+		now:  time.Now,
+	}
+}
+
+// DefaultVerifyCache returns the cache stored in the kubectl-mft cache directory.
+func DefaultVerifyCache() (*VerifyCache, error) {
+	dir, err := paths.CacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewVerifyCache(filepath.Join(dir, verifyCacheFile), DefaultVerifyCacheTTL), nil
+}
+
+// verified reports whether a successful verification for key has been cached and has not expired.
+func (c *VerifyCache) verified(key string) bool {
+	entries := c.load()
+	at, ok := entries[key]
+	return ok && c.now().Sub(at) < c.ttl
+}
+
+// record caches a successful verification for key. Expired entries are dropped.
+func (c *VerifyCache) record(key string) error {
+	entries := c.load()
+	now := c.now()
+	for k, at := range entries {
+		if now.Sub(at) >= c.ttl {
+			delete(entries, k)
+		}
+	}
+	entries[key] = now
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal verification cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), verifyCacheFile+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write verification cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write verification cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write verification cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to write verification cache: %w", err)
+	}
+	return nil
+}
+
+// load reads the cache entries. A missing or corrupted cache is treated as empty.
+func (c *VerifyCache) load() map[string]time.Time {
+	entries := map[string]time.Time{}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return entries
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return map[string]time.Time{}
+	}
+	return entries
+}
+
+// cacheKey identifies a verification of d under tag by the verifier's trusted keys.
+func (v *Verifier) cacheKey(d digest.Digest, tag string) string {
+	var fingerprints []string
+	for _, pub := range v.publicKeys {
+		if fp, err := Fingerprint(pub); err == nil {
+			fingerprints = append(fingerprints, fp)
+		}
+	}
+	slices.Sort(fingerprints)
+	if v.gpg {
+		fingerprints = append(fingerprints, "gpg")
+		fingerprints = append(fingerprints, gpgKeyringFingerprints()...)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fingerprints, ",")))
+	return tag + "@" + d.String() + "|" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"context"
+	"crypto"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+func resolveTestDigest(t *testing.T, layoutPath, tag string) digest.Digest {
+	t.Helper()
+	store, err := oci.New(layoutPath)
+	if err != nil {
+		t.Fatalf("failed to open OCI store: %v", err)
+	}
+	desc, err := store.Resolve(context.Background(), tag)
+	if err != nil {
+		t.Fatalf("failed to resolve %s: %v", tag, err)
+	}
+	return desc.Digest
+}
+
+func TestVerifyCache(t *testing.T) {
+	layoutPath, tag := setupTestOCILayout(t)
+	privKey, pubKey := generateTestKeyPair(t)
+	_, otherPubKey := generateTestKeyPair(t)
+	d := resolveTestDigest(t, layoutPath, tag)
+	ctx := context.Background()
+
+	if _, err := NewSigner(privKey).Sign(ctx, layoutPath, tag); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	cache := NewVerifyCache(filepath.Join(t.TempDir(), "verify-cache.json"), time.Hour)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	verifier := NewVerifier([]crypto.PublicKey{pubKey}).WithCache(cache)
+	if cache.verified(verifier.cacheKey(d, tag)) {
+		t.Fatal("expected empty cache before verification")
+	}
+	if err := verifier.Verify(ctx, layoutPath, tag); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		keys    []crypto.PublicKey
+		elapsed time.Duration
+		want    bool
+	}{
+		{name: "same keys", keys: []crypto.PublicKey{pubKey}, want: true},
+		{name: "additional key", keys: []crypto.PublicKey{otherPubKey, pubKey}, want: false},
+		{name: "different keys", keys: []crypto.PublicKey{otherPubKey}, want: false},
+		{name: "expired", keys: []crypto.PublicKey{pubKey}, elapsed: time.Hour, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache.now = func() time.Time { return now.Add(tt.elapsed) }
+			v := NewVerifier(tt.keys).WithCache(cache)
+			if got := cache.verified(v.cacheKey(d, tag)); got != tt.want {
+				t.Errorf("verified = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyCacheKeyOrder(t *testing.T) {
+	_, pubKey1 := generateTestKeyPair(t)
+	_, pubKey2 := generateTestKeyPair(t)
+	d, tag := digest.FromString("test"), "v1"
+
+	a := NewVerifier([]crypto.PublicKey{pubKey1, pubKey2}).cacheKey(d, tag)
+	b := NewVerifier([]crypto.PublicKey{pubKey2, pubKey1}).cacheKey(d, tag)
+	if a != b {
+		t.Errorf("cache key depends on key order: %s != %s", a, b)
+	}
+}
+
+func TestVerifyCacheKeyGPGKeyring(t *testing.T) {
+	setupGPGHome(t)
+	d, tag := digest.FromString("test"), "v1"
+	verifier := NewVerifier(nil).WithGPG()
+
+	empty := verifier.cacheKey(d, tag)
+	runGPG(t, nil, "--passphrase", "", "--quick-gen-key", "signer@example.com", "ed25519", "sign", "never")
+	imported := verifier.cacheKey(d, tag)
+	if imported == empty {
+		t.Error("cache key does not change when a key is added to the GPG keyring")
+	}
+	runGPG(t, nil, "--quick-set-expire", gpgFingerprint(t, "signer@example.com"), "seconds=1")
+	time.Sleep(2 * time.Second)
+	if verifier.cacheKey(d, tag) == imported {
+		t.Error("cache key does not change when a key of the GPG keyring expires")
+	}
+}
+
+func TestVerifyCacheHitSkipsVerification(t *testing.T) {
+	layoutPath, tag := setupTestOCILayout(t)
+	_, pubKey := generateTestKeyPair(t)
+	d := resolveTestDigest(t, layoutPath, tag)
+
+	cache := NewVerifyCache(filepath.Join(t.TempDir(), "verify-cache.json"), time.Hour)
+	verifier := NewVerifier([]crypto.PublicKey{pubKey}).WithCache(cache)
+	if err := cache.record(verifier.cacheKey(d, tag)); err != nil {
+		t.Fatalf("record failed: %v", err)
+	}
+
+	// The manifest is unsigned, so only a cache hit lets verification succeed
+	if err := verifier.Verify(context.Background(), layoutPath, tag); err != nil {
+		t.Fatalf("Verify should succeed from cache: %v", err)
+	}
+}
+
+func TestVerifyCacheFailureNotCached(t *testing.T) {
+	layoutPath, tag := setupTestOCILayout(t)
+	_, pubKey := generateTestKeyPair(t)
+	d := resolveTestDigest(t, layoutPath, tag)
+
+	cache := NewVerifyCache(filepath.Join(t.TempDir(), "verify-cache.json"), time.Hour)
+	verifier := NewVerifier([]crypto.PublicKey{pubKey}).WithCache(cache)
+	if err := verifier.Verify(context.Background(), layoutPath, tag); err == nil {
+		t.Fatal("Verify should fail when no signature exists")
+	}
+	if cache.verified(verifier.cacheKey(d, tag)) {
+		t.Error("failed verification must not be cached")
+	}
+}
+
+func TestVerifyCacheCorrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "verify-cache.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatalf("failed to write cache: %v", err)
+	}
+
+	cache := NewVerifyCache(path, time.Hour)
+	if cache.verified("key") {
+		t.Fatal("corrupted cache should be treated as empty")
+	}
+	if err := cache.record("key"); err != nil {
+		t.Fatalf("record failed: %v", err)
+	}
+	if !cache.verified("key") {
+		t.Error("expected cache hit after record")
+	}
+}
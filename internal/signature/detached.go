@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+// DetachedSignature is a manifest signature kept in a file instead of being attached to the
+// manifest as a referrer, so that it can travel separately from the registry.
+type DetachedSignature struct {
+	// Subject is the digest of the signed manifest
+	Subject digest.Digest `json:"subject"`
+	// MediaType is the media type of the signature layer it would be attached as
+	MediaType string `json:"mediaType"`
+	// Signature is the signature over Subject
+	Signature []byte `json:"signature"`
+	// Timestamp is the RFC 3161 timestamp token of Signature, if the signer used a timestamping authority
+	Timestamp []byte `json:"timestamp,omitempty"`
+}
+
+// ParseDetachedSignature parses a detached signature file written by SignDetached.
+func ParseDetachedSignature(data []byte) (*DetachedSignature, error) {
+	var s DetachedSignature
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse detached signature: %w", err)
+	}
+	if err := s.Subject.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid subject of detached signature: %w", err)
+	}
+	if len(s.Signature) == 0 {
+		return nil, fmt.Errorf("detached signature holds no signature")
+	}
+	return &s, nil
+}
+
+// Marshal encodes the detached signature as JSON.
+func (s *DetachedSignature) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// SignDetached signs the manifest identified by tag in the OCI layout at layoutPath and returns
+// the signature without storing it in the layout.
+func (s *Signer) SignDetached(ctx context.Context, layoutPath, tag string) (*DetachedSignature, error) {
+	if s.privateKey == nil && s.gpgKey == "" {
+		return nil, fmt.Errorf("no private key available for signing")
+	}
+	desc, err := resolveDescriptor(ctx, layoutPath, tag)
+	if err != nil {
+		return nil, err
+	}
+	sig, mediaType, err := s.signManifest(ctx, desc, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign manifest: %w", err)
+	}
+	token, err := s.timestamp(ctx, sig)
+	if err != nil {
+		return nil, err
+	}
+	return &DetachedSignature{Subject: desc.Digest, MediaType: mediaType, Signature: sig, Timestamp: token}, nil
+}
+
+// VerifyDetached verifies the manifest identified by tag in the OCI layout at layoutPath against
+// a detached signature and returns its timestamp, or nil if it has none. Signatures attached to the
+// manifest and the verification cache are ignored.
+func (v *Verifier) VerifyDetached(ctx context.Context, layoutPath, tag string, sig *DetachedSignature) (*Timestamp, error) {
+	if len(v.publicKeys) == 0 && len(v.retired) == 0 && !v.gpg {
+		return nil, fmt.Errorf("no public keys available for verification")
+	}
+	desc, err := resolveDescriptor(ctx, layoutPath, tag)
+	if err != nil {
+		return nil, err
+	}
+	if sig.Subject != desc.Digest {
+		return nil, fmt.Errorf("detached signature is for manifest %s, but %q is %s", sig.Subject, tag, desc.Digest)
+	}
+	blob := &signatureBlob{mediaType: sig.MediaType, data: sig.Signature, timestamp: sig.Timestamp}
+	_, ts, err := v.verifyDated(ctx, desc, tag, blob)
+	if err != nil {
+		return nil, fmt.Errorf("signature verification failed for %q: %w", tag, err)
+	}
+	return ts, nil
+}
+
+// resolveDescriptor returns the descriptor of the manifest identified by tag in the OCI layout at layoutPath.
+func resolveDescriptor(ctx context.Context, layoutPath, tag string) (v1.Descriptor, error) {
+	store, err := oci.New(layoutPath)
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("failed to open OCI layout: %w", err)
+	}
+	desc, err := store.Resolve(ctx, tag)
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("failed to resolve tag %q: %w", tag, err)
+	}
+	return desc, nil
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"context"
+	"crypto"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func TestSignAndVerifyDetached(t *testing.T) {
+	layoutPath, tag := setupTestOCILayout(t)
+	privKey, pubKey := generateTestKeyPair(t)
+	ctx := context.Background()
+
+	sig, err := NewSigner(privKey).SignDetached(ctx, layoutPath, tag)
+	if err != nil {
+		t.Fatalf("SignDetached failed: %v", err)
+	}
+	if sig.MediaType != SignatureEnvelopeMediaType {
+		t.Errorf("MediaType = %q, want %q", sig.MediaType, SignatureEnvelopeMediaType)
+	}
+
+	// The detached signature is not attached to the manifest
+	verifier := NewVerifier([]crypto.PublicKey{pubKey})
+	if err := verifier.Verify(ctx, layoutPath, tag); err == nil {
+		t.Error("Verify should fail without attached signatures")
+	}
+
+	data, err := sig.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	parsed, err := ParseDetachedSignature(data)
+	if err != nil {
+		t.Fatalf("ParseDetachedSignature failed: %v", err)
+	}
+	if _, err := verifier.VerifyDetached(ctx, layoutPath, tag, parsed); err != nil {
+		t.Fatalf("VerifyDetached failed: %v", err)
+	}
+}
+
+func TestVerifyDetached_Failures(t *testing.T) {
+	layoutPath, tag := setupTestOCILayout(t)
+	privKey, pubKey := generateTestKeyPair(t)
+	_, wrongPubKey := generateTestKeyPair(t)
+	ctx := context.Background()
+
+	sig, err := NewSigner(privKey).SignDetached(ctx, layoutPath, tag)
+	if err != nil {
+		t.Fatalf("SignDetached failed: %v", err)
+	}
+
+	if _, err := NewVerifier([]crypto.PublicKey{wrongPubKey}).VerifyDetached(ctx, layoutPath, tag, sig); err == nil {
+		t.Error("VerifyDetached should fail with wrong public key")
+	}
+
+	other := *sig
+	other.Subject = digest.FromString("other")
+	if _, err := NewVerifier([]crypto.PublicKey{pubKey}).VerifyDetached(ctx, layoutPath, tag, &other); err == nil {
+		t.Error("VerifyDetached should fail for a signature of another manifest")
+	}
+}
+
+func TestParseDetachedSignature_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "not json", data: "signature"},
+		{name: "invalid subject", data: `{"subject":"sha256:abc","mediaType":"x","signature":"c2ln"}`},
+		{name: "empty signature", data: `{"subject":"` + digest.FromString("x").String() + `","mediaType":"x"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseDetachedSignature([]byte(tt.data)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
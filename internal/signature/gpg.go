@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// GPGKeyPrefix selects a key of the local GPG keyring by key ID, fingerprint, or user ID,
+	// e.g. "gpg:alice@example.com".
+	GPGKeyPrefix = "gpg:"
+
+	// SignatureGPGMediaType is the media type for a detached binary OpenPGP signature layer.
+	SignatureGPGMediaType = "application/vnd.kubectl-mft.signature.v1+pgp"
+)
+
+// gpgProgram is the GnuPG executable. The keyring location follows GNUPGHOME.
+var gpgProgram = "gpg"
+
+// GPGAvailable reports whether the gpg executable is installed.
+func GPGAvailable() bool {
+	_, err := exec.LookPath(gpgProgram)
+	return err == nil
+}
+
+// GPGKeysExist reports whether the local GPG keyring holds at least one public key.
+func GPGKeysExist() bool {
+	if !GPGAvailable() {
+		return false
+	}
+	out, err := exec.Command(gpgProgram, "--batch", "--with-colons", "--list-keys").Output()
+	if err != nil {
+		return false
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "pub:") {
+			return true
+		}
+	}
+	return false
+}
+
+// gpgKeyringFingerprints returns the fingerprints of the keys and subkeys of the local GPG
+// keyring prefixed with their validity, e.g. "u:<fingerprint>", so that importing, revoking,
+// or changing the trust of a key changes the result.
+func gpgKeyringFingerprints() []string {
+	out, err := exec.Command(gpgProgram, "--batch", "--with-colons", "--list-keys").Output()
+	if err != nil {
+		return nil
+	}
+	var fingerprints []string
+	validity := ""
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		switch {
+		case (fields[0] == "pub" || fields[0] == "sub") && len(fields) > 1:
+			validity = fields[1]
+		case fields[0] == "fpr" && len(fields) > 9:
+			fingerprints = append(fingerprints, validity+":"+fields[9])
+		}
+	}
+	slices.Sort(fingerprints)
+	return fingerprints
+}
+
+// gpgSign creates a detached binary signature of payload with the given GPG key.
+// Passphrases are requested by gpg-agent.
+func gpgSign(ctx context.Context, keyID string, payload []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, gpgProgram, "--batch", "--yes", "--local-user", keyID, "--detach-sign", "--output", "-")
+	cmd.Stdin = bytes.NewReader(payload)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("gpg failed to sign with key %q: %s", keyID, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// gpgVerify verifies a detached signature of payload against the local GPG keyring.
+// The signing key must be fully or ultimately trusted in the keyring's web of trust, and
+// neither revoked nor expired. If signedAt is set, the key is checked as of that trusted
+// timestamp, so that keys expired since still verify. It returns the fingerprint of the
+// signing key.
+func gpgVerify(ctx context.Context, payload, sig []byte, signedAt time.Time) (string, error) {
+	sigFile, err := os.CreateTemp("", "kubectl-mft-*.sig")
+	if err != nil {
+		return "", fmt.Errorf("failed to create signature file: %w", err)
+	}
+	defer os.Remove(sigFile.Name())
+	if _, err := sigFile.Write(sig); err != nil {
+		sigFile.Close()
+		return "", fmt.Errorf("failed to write signature file: %w", err)
+	}
+	if err := sigFile.Close(); err != nil {
+		return "", fmt.Errorf("failed to write signature file: %w", err)
+	}
+
+	args := []string{"--batch", "--status-fd", "1"}
+	if !signedAt.IsZero() {
+		args = append(args, "--faked-system-time", strconv.FormatInt(signedAt.Unix(), 10)+"!")
+	}
+	cmd := exec.CommandContext(ctx, gpgProgram, append(args, "--verify", sigFile.Name(), "-")...)
+	cmd.Stdin = bytes.NewReader(payload)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	// gpg exits non-zero for bad signatures and unknown keys, the status lines tell why
+	_ = cmd.Run()
+
+	var fingerprint string
+	trusted := false
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		fields := strings.Fields(strings.TrimPrefix(scanner.Text(), "[GNUPG:] "))
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "VALIDSIG":
+			if len(fields) > 1 {
+				fingerprint = fields[1]
+			}
+		case "TRUST_FULLY", "TRUST_ULTIMATE":
+			trusted = true
+		case "BADSIG":
+			return "", fmt.Errorf("bad PGP signature")
+		case "REVKEYSIG", "KEYREVOKED":
+			return "", fmt.Errorf("PGP signing key %s has been revoked", gpgStatusKey(fields))
+		case "EXPKEYSIG", "KEYEXPIRED":
+			if signedAt.IsZero() {
+				return "", fmt.Errorf("PGP signing key %s has expired and the signature has no trusted timestamp", gpgStatusKey(fields))
+			}
+			return "", fmt.Errorf("PGP signing key %s had expired at the time of the signature's timestamp", gpgStatusKey(fields))
+		case "NO_PUBKEY":
+			return "", fmt.Errorf("PGP signing key %s not found in the GPG keyring", fields[len(fields)-1])
+		}
+	}
+	if fingerprint == "" {
+		return "", fmt.Errorf("PGP signature could not be verified")
+	}
+	if !trusted {
+		return "", fmt.Errorf("PGP signing key %s is not trusted in the GPG keyring", fingerprint)
+	}
+	return fingerprint, nil
+}
+
+// gpgStatusKey returns the key named by a status line, which is the second field of
+// REVKEYSIG and EXPKEYSIG lines. KEYREVOKED and KEYEXPIRED lines do not name it.
+func gpgStatusKey(fields []string) string {
+	if len(fields) > 1 && (fields[0] == "REVKEYSIG" || fields[0] == "EXPKEYSIG") {
+		return fields[1]
+	}
+	return "of the signature"
+}
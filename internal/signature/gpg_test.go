@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupGPGHome points GNUPGHOME at a new empty keyring for the duration of the test.
+func setupGPGHome(t *testing.T) string {
+	t.Helper()
+	if !GPGAvailable() {
+		t.Skip("gpg is not installed")
+	}
+	// The gpg-agent socket path must stay short, so avoid the long t.TempDir path
+	home, err := os.MkdirTemp("", "gpg")
+	if err != nil {
+		t.Fatalf("failed to create GNUPGHOME: %v", err)
+	}
+	t.Setenv("GNUPGHOME", home)
+	t.Cleanup(func() {
+		cmd := exec.Command("gpgconf", "--kill", "gpg-agent")
+		cmd.Env = append(os.Environ(), "GNUPGHOME="+home)
+		_ = cmd.Run()
+		os.RemoveAll(home)
+	})
+	return home
+}
+
+func runGPG(t *testing.T, stdin []byte, args ...string) []byte {
+	t.Helper()
+	cmd := exec.Command(gpgProgram, append([]string{"--batch"}, args...)...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("gpg %s failed: %v", strings.Join(args, " "), err)
+	}
+	return out
+}
+
+func TestSignAndVerifyWithGPG(t *testing.T) {
+	setupGPGHome(t)
+	runGPG(t, nil, "--passphrase", "", "--quick-gen-key", "signer@example.com", "ed25519", "sign", "never")
+	pubKey := runGPG(t, nil, "--export", "signer@example.com")
+
+	layoutPath, tag := setupTestOCILayout(t)
+	ctx := context.Background()
+
+	signer, err := NewSignerFromRef(GPGKeyPrefix + "signer@example.com")
+	if err != nil {
+		t.Fatalf("NewSignerFromRef() unexpected error: %v", err)
+	}
+	if _, err := signer.Sign(ctx, layoutPath, tag); err != nil {
+		t.Fatalf("Sign() unexpected error: %v", err)
+	}
+
+	if err := NewVerifier(nil).WithGPG().Verify(ctx, layoutPath, tag); err != nil {
+		t.Errorf("Verify() unexpected error: %v", err)
+	}
+	if err := NewVerifier(nil).Verify(ctx, layoutPath, tag); err == nil {
+		t.Errorf("Verify() without GPG expected error")
+	}
+
+	// A keyring without the signing key
+	setupGPGHome(t)
+	err = NewVerifier(nil).WithGPG().Verify(ctx, layoutPath, tag)
+	if err == nil || !strings.Contains(err.Error(), "not found in the GPG keyring") {
+		t.Errorf("Verify() error = %v, want missing key error", err)
+	}
+
+	// The signing key is known but not trusted
+	runGPG(t, pubKey, "--import")
+	err = NewVerifier(nil).WithGPG().Verify(ctx, layoutPath, tag)
+	if err == nil || !strings.Contains(err.Error(), "is not trusted") {
+		t.Errorf("Verify() error = %v, want untrusted key error", err)
+	}
+}
+
+func TestVerifyWithGPGRevokedOrExpiredKey(t *testing.T) {
+	layoutPath, tag := setupTestOCILayout(t)
+	ctx := context.Background()
+
+	sign := func(t *testing.T, tsaURL string) {
+		t.Helper()
+		runGPG(t, nil, "--passphrase", "", "--quick-gen-key", "signer@example.com", "ed25519", "sign", "never")
+		signer, err := NewSignerFromRef(GPGKeyPrefix + "signer@example.com")
+		if err != nil {
+			t.Fatalf("NewSignerFromRef() unexpected error: %v", err)
+		}
+		if tsaURL != "" {
+			signer = signer.WithTimestampAuthority(tsaURL, nil)
+		}
+		if _, err := signer.Sign(ctx, layoutPath, tag); err != nil {
+			t.Fatalf("Sign() unexpected error: %v", err)
+		}
+	}
+
+	t.Run("revoked", func(t *testing.T) {
+		home := setupGPGHome(t)
+		sign(t, "")
+		revocation, err := os.ReadDir(filepath.Join(home, "openpgp-revocs.d"))
+		if err != nil || len(revocation) == 0 {
+			t.Fatalf("no revocation certificate generated: %v", err)
+		}
+		cert, err := os.ReadFile(filepath.Join(home, "openpgp-revocs.d", revocation[0].Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The generated certificate is guarded against accidental import by a leading colon
+		runGPG(t, bytes.Replace(cert, []byte(":-----BEGIN"), []byte("-----BEGIN"), 1), "--import")
+
+		err = NewVerifier(nil).WithGPG().Verify(ctx, layoutPath, tag)
+		if err == nil || !strings.Contains(err.Error(), "has been revoked") {
+			t.Errorf("Verify() error = %v, want revoked key error", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		setupGPGHome(t)
+		sign(t, "")
+		runGPG(t, nil, "--quick-set-expire", gpgFingerprint(t, "signer@example.com"), "seconds=1")
+		time.Sleep(2 * time.Second)
+
+		err := NewVerifier(nil).WithGPG().Verify(ctx, layoutPath, tag)
+		if err == nil || !strings.Contains(err.Error(), "has expired") {
+			t.Errorf("Verify() error = %v, want expired key error", err)
+		}
+	})
+
+	t.Run("expired after timestamp", func(t *testing.T) {
+		layoutPath, tag = setupTestOCILayout(t)
+		setupGPGHome(t)
+		tsa := newTestTSA(t)
+		// The timestamp must not predate the signing time, which has a precision of seconds
+		tsa.genTime = time.Now().UTC().Truncate(time.Second).Add(time.Second)
+		sign(t, tsa.serve(t))
+		runGPG(t, nil, "--quick-set-expire", gpgFingerprint(t, "signer@example.com"), "seconds=2")
+		time.Sleep(3 * time.Second)
+
+		if err := NewVerifier(nil).WithGPG().WithTimestampRoots(tsa.roots).Verify(ctx, layoutPath, tag); err != nil {
+			t.Errorf("Verify() unexpected error for a signature timestamped before the key expired: %v", err)
+		}
+		err := NewVerifier(nil).WithGPG().WithTimestampRoots(x509.NewCertPool()).Verify(ctx, layoutPath, tag)
+		if err == nil {
+			t.Error("Verify() expected error with an untrusted timestamping authority")
+		}
+	})
+}
+
+// gpgFingerprint returns the fingerprint of the primary key of uid.
+func gpgFingerprint(t *testing.T, uid string) string {
+	t.Helper()
+	for _, line := range strings.Split(string(runGPG(t, nil, "--with-colons", "--list-keys", uid)), "\n") {
+		if fields := strings.Split(line, ":"); fields[0] == "fpr" && len(fields) > 9 {
+			return fields[9]
+		}
+	}
+	t.Fatalf("no fingerprint found for %s", uid)
+	return ""
+}
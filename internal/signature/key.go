@@ -6,14 +6,19 @@ package signature
 import (
 	"crypto"
 	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/rand"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/paths"
+	"github.com/chez-shanpu/kubectl-mft/internal/platform"
 )
 
 const (
@@ -25,26 +30,39 @@ var keyDir string
 
 // InitKeyDir initializes the key storage directory path.
 // It checks the KUBECTL_MFT_KEY_DIR environment variable first,
-// then falls back to the default location under the user's home directory.
+// then falls back to the keys directory under the kubectl-mft data directory.
 func InitKeyDir() error {
 	if dir := os.Getenv("KUBECTL_MFT_KEY_DIR"); dir != "" {
 		keyDir = dir
 		return nil
 	}
 
-	home, err := os.UserHomeDir()
+	dataDir, err := paths.DataDir()
 	if err != nil {
-		return fmt.Errorf("failed to get user home directory: %w", err)
+		return err
 	}
-	keyDir = filepath.Join(home, ".local", "share", "kubectl-mft", "keys")
+	keyDir = filepath.Join(dataDir, "keys")
 	return nil
 }
 
 // KeyInfo holds information about a stored key.
 type KeyInfo struct {
-	Name string
-	Type string // "private" or "public"
-	Path string
+	Name string `json:"name"`
+	Type string `json:"type"` // "private", "public", "pkcs11", or "keychain"
+	Path string `json:"path"`
+	// Fingerprint identifies the key pair, see Fingerprint
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// KeyDetails describes a named key pair in the key directory.
+type KeyDetails struct {
+	Name        string `json:"name"`
+	Algorithm   string `json:"algorithm"`
+	Fingerprint string `json:"fingerprint"`
+	// PrivateKey is where the private key is stored: "file", "pkcs11", "keychain", or "none"
+	PrivateKey    string `json:"privateKey"`
+	PublicKeyPath string `json:"publicKeyPath"`
+	PublicKeyPEM  string `json:"publicKeyPem"`
 }
 
 // KeyDir returns the key storage directory path.
@@ -52,8 +70,8 @@ func KeyDir() string {
 	return keyDir
 }
 
-// validateKeyName checks that the key name is safe for use as a filename.
-func validateKeyName(name string) error {
+// ValidateKeyName checks that the key name is safe for use as a filename.
+func ValidateKeyName(name string) error {
 	if name == "" {
 		return fmt.Errorf("key name must not be empty")
 	}
@@ -76,15 +94,79 @@ func PublicKeyPath(name string) string {
 	return filepath.Join(keyDir, name+pubKeyExt)
 }
 
-// PrivateKeyExists checks if a named private key exists in the key directory.
+// privateKeyExts are the extensions of the files holding or referencing a private key:
+// a key file, a PKCS#11 key reference, or the marker of a key in the OS keychain.
+var privateKeyExts = []string{privKeyExt, pkcs11KeyExt, keychainKeyExt}
+
+// PrivateKeyExists checks if a named private key exists in the key directory,
+// on a hardware token, or in the OS keychain.
 func PrivateKeyExists(name string) bool {
-	if validateKeyName(name) != nil {
+	return privateKeyExt(name) != ""
+}
+
+// PublicKeyExists checks if a named public key exists in the key directory.
+func PublicKeyExists(name string) bool {
+	if ValidateKeyName(name) != nil {
 		return false
 	}
-	_, err := os.Stat(PrivateKeyPath(name))
+	_, err := os.Stat(filepath.Join(keyDir, name+pubKeyExt))
 	return err == nil
 }
 
+// privateKeyExt returns the extension of the file holding or referencing the named private key,
+// or an empty string if there is none.
+func privateKeyExt(name string) string {
+	if ValidateKeyName(name) != nil {
+		return ""
+	}
+	for _, ext := range privateKeyExts {
+		if _, err := os.Stat(filepath.Join(keyDir, name+ext)); err == nil {
+			return ext
+		}
+	}
+	return ""
+}
+
+// replacePrivateKey removes the named private key stored in any other way than keep,
+// so that a newly stored key takes effect.
+func replacePrivateKey(name, keep string) error {
+	for _, ext := range privateKeyExts {
+		path := filepath.Join(keyDir, name+ext)
+		if ext == keep {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		var err error
+		if ext == keychainKeyExt {
+			err = deleteKeychainKey(name)
+		} else {
+			err = os.Remove(path)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to replace private key %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// SigningKeyExists checks if the key referenced for signing is available.
+// See NewSignerFromRef for the supported references.
+func SigningKeyExists(ref string) bool {
+	if path, ok := strings.CutPrefix(ref, SSHKeyPrefix); ok {
+		_, err := os.Stat(expandHome(path))
+		return err == nil
+	}
+	if strings.HasPrefix(ref, SSHAgentPrefix) {
+		return os.Getenv("SSH_AUTH_SOCK") != ""
+	}
+	if strings.HasPrefix(ref, GPGKeyPrefix) {
+		return GPGAvailable()
+	}
+	return PrivateKeyExists(ref)
+}
+
 // PublicKeysExist checks if at least one public key exists in the key directory.
 func PublicKeysExist() bool {
 	entries, err := os.ReadDir(keyDir)
@@ -99,15 +181,22 @@ func PublicKeysExist() bool {
 	return false
 }
 
-// GenerateKeyPair generates an ECDSA P-256 key pair and stores it in the key directory.
+// GenerateKeyPair generates an ECDSA P-256 key pair, or one of the algorithm selected with
+// WithAlgorithm, and stores it in the key directory.
 // The private key is saved as <name>.key and the public key as <name>.pub.
+// With WithKeychain, the private key is stored in the OS keychain instead.
 // If name is empty, "default" is used.
-func GenerateKeyPair(name string, force bool) error {
+func GenerateKeyPair(name string, force bool, opts ...GenerateOption) error {
+	var o generateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	if name == "" {
 		name = "default"
 	}
 
-	if err := validateKeyName(name); err != nil {
+	if err := ValidateKeyName(name); err != nil {
 		return err
 	}
 
@@ -120,28 +209,46 @@ func GenerateKeyPair(name string, force bool) error {
 		if _, err := os.Stat(privPath); err == nil {
 			return fmt.Errorf("private key already exists at %s (use --force to overwrite)", privPath)
 		}
+		if PrivateKeyExists(name) {
+			return fmt.Errorf("private key %q already exists (use --force to overwrite)", name)
+		}
 	}
 
-	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	key, err := generateKey(o.algorithm)
 	if err != nil {
-		return fmt.Errorf("failed to generate ECDSA key: %w", err)
+		return fmt.Errorf("failed to generate key: %w", err)
 	}
 
-	if err := writePrivateKey(privPath, key); err != nil {
+	ext := privKeyExt
+	if o.keychain {
+		ext = keychainKeyExt
+		pemData, err := marshalPrivateKeyPEM(key)
+		if err != nil {
+			return err
+		}
+		if err := writeKeychainKey(name, pemData); err != nil {
+			return err
+		}
+	} else if err := writePrivateKey(privPath, key); err != nil {
 		return err
 	}
 
 	pubPath := PublicKeyPath(name)
-	if err := writePublicKey(pubPath, &key.PublicKey); err != nil {
+	if err := writePublicKey(pubPath, key.Public()); err != nil {
 		// Clean up the private key if public key write fails
-		os.Remove(privPath)
+		if o.keychain {
+			_ = deleteKeychainKey(name)
+		} else {
+			os.Remove(privPath)
+		}
 		return err
 	}
 
-	return nil
+	return replacePrivateKey(name, ext)
 }
 
 // ImportPublicKey copies a PEM-encoded public key file into the key directory.
+// A key in OpenSSH authorized_keys format is converted to PEM.
 // If name is empty, the base name of srcPath (without extension) is used.
 func ImportPublicKey(srcPath, name string) error {
 	if name == "" {
@@ -149,7 +256,7 @@ func ImportPublicKey(srcPath, name string) error {
 		name = strings.TrimSuffix(base, filepath.Ext(base))
 	}
 
-	if err := validateKeyName(name); err != nil {
+	if err := ValidateKeyName(name); err != nil {
 		return err
 	}
 
@@ -157,9 +264,28 @@ func ImportPublicKey(srcPath, name string) error {
 	if err != nil {
 		return fmt.Errorf("failed to read public key file: %w", err)
 	}
+	return ImportPublicKeyData(name, data)
+}
+
+// ImportPublicKeyData stores a PEM-encoded or authorized_keys formatted public key under name,
+// replacing an existing public key of that name.
+func ImportPublicKeyData(name string, data []byte) error {
+	if err := ValidateKeyName(name); err != nil {
+		return err
+	}
 
 	// Validate that it's a valid PEM-encoded public key
-	if _, err := parsePublicKeyPEM(data); err != nil {
+	pub, err := parsePublicKeyPEM(data)
+	if err != nil {
+		var sshErr error
+		if pub, sshErr = ParseAuthorizedKey(data); sshErr != nil {
+			return fmt.Errorf("invalid public key file: %w", err)
+		}
+		if data, err = marshalPublicKeyPEM(pub); err != nil {
+			return err
+		}
+	}
+	if err := checkPublicKey(pub); err != nil {
 		return fmt.Errorf("invalid public key file: %w", err)
 	}
 
@@ -175,16 +301,20 @@ func ImportPublicKey(srcPath, name string) error {
 	return nil
 }
 
-// DeletePrivateKey removes a named private key from the key directory.
+// DeletePrivateKey removes a named private key from the key directory, along with its
+// PKCS#11 key reference or its OS keychain entry.
 func DeletePrivateKey(name string) error {
-	if err := validateKeyName(name); err != nil {
+	if err := ValidateKeyName(name); err != nil {
 		return err
 	}
-	path := filepath.Join(keyDir, name+privKeyExt)
-	if _, err := os.Stat(path); os.IsNotExist(err) {
+	ext := privateKeyExt(name)
+	switch ext {
+	case "":
 		return fmt.Errorf("private key %q not found", name)
+	case keychainKeyExt:
+		return deleteKeychainKey(name)
 	}
-	if err := os.Remove(path); err != nil {
+	if err := os.Remove(filepath.Join(keyDir, name+ext)); err != nil {
 		return fmt.Errorf("failed to delete private key: %w", err)
 	}
 	return nil
@@ -192,7 +322,7 @@ func DeletePrivateKey(name string) error {
 
 // DeletePublicKey removes a named public key from the key directory.
 func DeletePublicKey(name string) error {
-	if err := validateKeyName(name); err != nil {
+	if err := ValidateKeyName(name); err != nil {
 		return err
 	}
 	path := filepath.Join(keyDir, name+pubKeyExt)
@@ -205,7 +335,85 @@ func DeletePublicKey(name string) error {
 	return nil
 }
 
+// Fingerprint returns the SHA-256 fingerprint of the DER encoded SubjectPublicKeyInfo of pub,
+// formatted as "sha256:<hex>". It identifies a key pair independent of its name.
+func Fingerprint(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// InspectKey returns the details of the named key pair. The public key must exist.
+func InspectKey(name string) (*KeyDetails, error) {
+	data, err := ExportPublicKey(name)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := parsePublicKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %q: %w", name, err)
+	}
+	fingerprint, err := Fingerprint(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	storage := "none"
+	switch privateKeyExt(name) {
+	case privKeyExt:
+		storage = "file"
+	case pkcs11KeyExt:
+		storage = "pkcs11"
+	case keychainKeyExt:
+		storage = "keychain"
+	}
+
+	return &KeyDetails{
+		Name:          name,
+		Algorithm:     keyAlgorithm(pub),
+		Fingerprint:   fingerprint,
+		PrivateKey:    storage,
+		PublicKeyPath: PublicKeyPath(name),
+		PublicKeyPEM:  string(data),
+	}, nil
+}
+
+func keyAlgorithm(pub crypto.PublicKey) string {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		return "ECDSA " + k.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", k.N.BitLen())
+	default:
+		return fmt.Sprintf("%T", pub)
+	}
+}
+
+// publicKeyFingerprint returns the fingerprint of the named public key,
+// or an empty string if it does not exist or cannot be parsed.
+func publicKeyFingerprint(name string) string {
+	data, err := os.ReadFile(PublicKeyPath(name))
+	if err != nil {
+		return ""
+	}
+	pub, err := parsePublicKeyPEM(data)
+	if err != nil {
+		return ""
+	}
+	fingerprint, err := Fingerprint(pub)
+	if err != nil {
+		return ""
+	}
+	return fingerprint
+}
+
 // ListKeys returns information about all keys in the key directory.
+// Private keys carry the fingerprint of the public key of the same name.
 func ListKeys() ([]KeyInfo, error) {
 	entries, err := os.ReadDir(keyDir)
 	if err != nil {
@@ -235,8 +443,23 @@ func ListKeys() ([]KeyInfo, error) {
 				Type: "public",
 				Path: path,
 			})
+		} else if before, ok := strings.CutSuffix(name, pkcs11KeyExt); ok {
+			keys = append(keys, KeyInfo{
+				Name: before,
+				Type: "pkcs11",
+				Path: path,
+			})
+		} else if before, ok := strings.CutSuffix(name, keychainKeyExt); ok {
+			keys = append(keys, KeyInfo{
+				Name: before,
+				Type: "keychain",
+				Path: path,
+			})
 		}
 	}
+	for i := range keys {
+		keys[i].Fingerprint = publicKeyFingerprint(keys[i].Name)
+	}
 	return keys, nil
 }
 
@@ -246,7 +469,7 @@ func ExportPublicKey(name string) ([]byte, error) {
 	if name == "" {
 		name = "default"
 	}
-	if err := validateKeyName(name); err != nil {
+	if err := ValidateKeyName(name); err != nil {
 		return nil, err
 	}
 	path := PublicKeyPath(name)
@@ -260,26 +483,36 @@ func ExportPublicKey(name string) ([]byte, error) {
 	return data, nil
 }
 
-// LoadPrivateKey loads the named private key from the key directory.
+// LoadPrivateKey loads the named private key from the key directory or the OS keychain.
+// For a PKCS#11 key reference, the returned signer signs on the hardware token.
 func LoadPrivateKey(name string) (crypto.Signer, error) {
-	if err := validateKeyName(name); err != nil {
+	if err := ValidateKeyName(name); err != nil {
 		return nil, err
 	}
+	switch privateKeyExt(name) {
+	case pkcs11KeyExt:
+		return loadPKCS11Key(name)
+	case keychainKeyExt:
+		return loadKeychainKey(name)
+	}
 
 	path := PrivateKeyPath(name)
-	info, err := os.Stat(path)
+	private, err := platform.IsPrivate(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to stat private key: %w", err)
+		return nil, fmt.Errorf("failed to check the permissions of private key: %w", err)
 	}
-	if info.Mode().Perm()&0o077 != 0 {
-		return nil, fmt.Errorf("private key %q has insecure permissions %v, expected 0600", name, info.Mode().Perm())
+	if !private {
+		return nil, fmt.Errorf("private key %q has insecure permissions, expected it to be accessible only by its owner, such as with mode 0600", name)
 	}
 
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read private key: %w", err)
 	}
+	return parsePrivateKeyPEM(data)
+}
 
+func parsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
 	block, _ := pem.Decode(data)
 	if block == nil {
 		return nil, fmt.Errorf("failed to decode PEM block from private key")
@@ -297,6 +530,25 @@ func LoadPrivateKey(name string) (crypto.Signer, error) {
 	return signer, nil
 }
 
+// LoadPublicKey loads the named public key from the key directory.
+func LoadPublicKey(name string) (crypto.PublicKey, error) {
+	if err := ValidateKeyName(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(PublicKeyPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("public key %q not found", name)
+		}
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	pub, err := parsePublicKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %q: %w", name, err)
+	}
+	return pub, nil
+}
+
 // LoadAllPublicKeys loads all public keys from the key directory.
 func LoadAllPublicKeys() ([]crypto.PublicKey, error) {
 	entries, err := os.ReadDir(keyDir)
@@ -325,38 +577,54 @@ func LoadAllPublicKeys() ([]crypto.PublicKey, error) {
 	return keys, nil
 }
 
-func writePrivateKey(path string, key *ecdsa.PrivateKey) error {
+func writePrivateKey(path string, key crypto.Signer) error {
+	data, err := marshalPrivateKeyPEM(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+	return nil
+}
+
+func marshalPrivateKeyPEM(key crypto.Signer) ([]byte, error) {
 	der, err := x509.MarshalPKCS8PrivateKey(key)
 	if err != nil {
-		return fmt.Errorf("failed to marshal private key: %w", err)
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
 	}
 
 	block := &pem.Block{
 		Type:  "PRIVATE KEY",
 		Bytes: der,
 	}
+	return pem.EncodeToMemory(block), nil
+}
 
-	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
-		return fmt.Errorf("failed to write private key: %w", err)
+func writePublicKey(path string, key crypto.PublicKey) error {
+	data, err := marshalPublicKeyPEM(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write public key: %w", err)
 	}
 	return nil
 }
 
-func writePublicKey(path string, key *ecdsa.PublicKey) error {
+func marshalPublicKeyPEM(key crypto.PublicKey) ([]byte, error) {
 	der, err := x509.MarshalPKIXPublicKey(key)
 	if err != nil {
-		return fmt.Errorf("failed to marshal public key: %w", err)
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
 	}
 
 	block := &pem.Block{
 		Type:  "PUBLIC KEY",
 		Bytes: der,
 	}
-
-	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o644); err != nil {
-		return fmt.Errorf("failed to write public key: %w", err)
-	}
-	return nil
+	return pem.EncodeToMemory(block), nil
 }
 
 func parsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
@@ -303,6 +303,62 @@ func TestListKeys(t *testing.T) {
 	}
 }
 
+func TestInspectKey(t *testing.T) {
+	cleanup := setupTestKeyDir(t)
+	defer cleanup()
+
+	if err := GenerateKeyPair("default", false); err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	priv, err := LoadPrivateKey("default")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey failed: %v", err)
+	}
+	want, err := Fingerprint(priv.Public())
+	if err != nil {
+		t.Fatalf("Fingerprint failed: %v", err)
+	}
+
+	details, err := InspectKey("default")
+	if err != nil {
+		t.Fatalf("InspectKey failed: %v", err)
+	}
+	if details.Fingerprint != want {
+		t.Errorf("Fingerprint = %q, want %q", details.Fingerprint, want)
+	}
+	if details.Algorithm != "ECDSA P-256" {
+		t.Errorf("Algorithm = %q, want %q", details.Algorithm, "ECDSA P-256")
+	}
+	if details.PrivateKey != "file" {
+		t.Errorf("PrivateKey = %q, want %q", details.PrivateKey, "file")
+	}
+
+	keys, err := ListKeys()
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	for _, k := range keys {
+		if k.Fingerprint != want {
+			t.Errorf("ListKeys() %s %s fingerprint = %q, want %q", k.Type, k.Name, k.Fingerprint, want)
+		}
+	}
+
+	if err := DeletePrivateKey("default"); err != nil {
+		t.Fatalf("DeletePrivateKey failed: %v", err)
+	}
+	details, err = InspectKey("default")
+	if err != nil {
+		t.Fatalf("InspectKey failed: %v", err)
+	}
+	if details.PrivateKey != "none" {
+		t.Errorf("PrivateKey = %q, want %q", details.PrivateKey, "none")
+	}
+
+	if _, err := InspectKey("missing"); err == nil {
+		t.Error("InspectKey should fail for a missing key")
+	}
+}
+
 func TestListKeysEmpty(t *testing.T) {
 	cleanup := setupTestKeyDir(t)
 	defer cleanup()
@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	keychainKeyExt = ".keychain"
+
+	// keychainService is the service name under which private keys are stored in the OS keychain
+	keychainService = "kubectl-mft"
+)
+
+// KeychainKeyPath returns the path to the marker of the named key stored in the OS keychain.
+// The marker holds the keychain account of the key.
+func KeychainKeyPath(name string) string {
+	return filepath.Join(keyDir, name+keychainKeyExt)
+}
+
+// GenerateOption configures GenerateKeyPair.
+type GenerateOption func(*generateOptions)
+
+type generateOptions struct {
+	keychain  bool
+	algorithm string
+}
+
+// WithKeychain stores the generated private key in the OS keychain (macOS Keychain,
+// Secret Service on Linux, Windows Credential Manager) instead of a file.
+func WithKeychain() GenerateOption {
+	return func(o *generateOptions) {
+		o.keychain = true
+	}
+}
+
+// writeKeychainKey stores the PEM encoded private key in the OS keychain and writes its marker.
+func writeKeychainKey(name string, pemData []byte) error {
+	account := keychainAccount(name)
+	if err := keyring.Set(keychainService, account, string(pemData)); err != nil {
+		return fmt.Errorf("failed to store private key in OS keychain: %w", err)
+	}
+	if err := os.WriteFile(KeychainKeyPath(name), []byte(account), 0o600); err != nil {
+		_ = keyring.Delete(keychainService, account)
+		return fmt.Errorf("failed to write keychain key marker: %w", err)
+	}
+	return nil
+}
+
+// loadKeychainKey loads the named private key from the OS keychain.
+func loadKeychainKey(name string) (crypto.Signer, error) {
+	account, err := os.ReadFile(KeychainKeyPath(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keychain key marker: %w", err)
+	}
+	data, err := keyring.Get(keychainService, string(account))
+	if err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return nil, fmt.Errorf("private key %q not found in OS keychain", name)
+		}
+		return nil, fmt.Errorf("failed to read private key from OS keychain: %w", err)
+	}
+	return parsePrivateKeyPEM([]byte(data))
+}
+
+// deleteKeychainKey removes the named private key from the OS keychain along with its marker.
+func deleteKeychainKey(name string) error {
+	path := KeychainKeyPath(name)
+	account, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read keychain key marker: %w", err)
+	}
+	if err := keyring.Delete(keychainService, string(account)); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("failed to delete private key from OS keychain: %w", err)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to delete keychain key marker: %w", err)
+	}
+	return nil
+}
+
+// keychainAccount returns the keychain account of the named key. The key directory is part of
+// the account so that keys of the same name in different key directories do not collide.
+func keychainAccount(name string) string {
+	return name + "@" + keyDir
+}
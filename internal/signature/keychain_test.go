@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestGenerateKeyPairInKeychain(t *testing.T) {
+	cleanup := setupTestKeyDir(t)
+	defer cleanup()
+	keyring.MockInit()
+
+	if err := GenerateKeyPair("release", false, WithKeychain()); err != nil {
+		t.Fatalf("GenerateKeyPair() unexpected error: %v", err)
+	}
+	if _, err := os.Stat(PrivateKeyPath("release")); !os.IsNotExist(err) {
+		t.Errorf("private key file should not exist for a keychain key")
+	}
+	if !PrivateKeyExists("release") {
+		t.Errorf("PrivateKeyExists() = false for keychain key")
+	}
+	if err := GenerateKeyPair("release", false); err == nil {
+		t.Errorf("GenerateKeyPair() expected error for existing keychain key")
+	}
+
+	layoutPath, tag := setupTestOCILayout(t)
+	ctx := context.Background()
+	signer, err := NewSignerFromKeyDir("release")
+	if err != nil {
+		t.Fatalf("NewSignerFromKeyDir() unexpected error: %v", err)
+	}
+	if _, err := signer.Sign(ctx, layoutPath, tag); err != nil {
+		t.Fatalf("Sign() unexpected error: %v", err)
+	}
+	verifier, err := NewVerifierFromKeyDir()
+	if err != nil {
+		t.Fatalf("NewVerifierFromKeyDir() unexpected error: %v", err)
+	}
+	if err := verifier.Verify(ctx, layoutPath, tag); err != nil {
+		t.Errorf("Verify() unexpected error: %v", err)
+	}
+
+	keys, err := ListKeys()
+	if err != nil {
+		t.Fatalf("ListKeys() unexpected error: %v", err)
+	}
+	found := false
+	for _, k := range keys {
+		if k.Name == "release" && k.Type == "keychain" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("ListKeys() = %v, want keychain key", keys)
+	}
+
+	// Overwriting with a file key removes the keychain entry
+	if err := GenerateKeyPair("release", true); err != nil {
+		t.Fatalf("GenerateKeyPair() unexpected error: %v", err)
+	}
+	if _, err := keyring.Get(keychainService, keychainAccount("release")); err == nil {
+		t.Errorf("keychain entry should be removed when replaced by a file key")
+	}
+	if _, err := LoadPrivateKey("release"); err != nil {
+		t.Errorf("LoadPrivateKey() unexpected error: %v", err)
+	}
+}
+
+func TestDeleteKeychainKey(t *testing.T) {
+	cleanup := setupTestKeyDir(t)
+	defer cleanup()
+	keyring.MockInit()
+
+	if err := GenerateKeyPair("release", false, WithKeychain()); err != nil {
+		t.Fatalf("GenerateKeyPair() unexpected error: %v", err)
+	}
+	if err := DeletePrivateKey("release"); err != nil {
+		t.Fatalf("DeletePrivateKey() unexpected error: %v", err)
+	}
+	if PrivateKeyExists("release") {
+		t.Errorf("PrivateKeyExists() = true after delete")
+	}
+	if _, err := keyring.Get(keychainService, keychainAccount("release")); err == nil {
+		t.Errorf("keychain entry should be removed")
+	}
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+// AnnotationKeysRef on a signature manifest names the key distribution artifact holding the
+// public key of the signer, such as "registry.company.com/team/keys:v3". It is a hint for
+// fetching unknown keys and not covered by the signature, so fetched keys must be confirmed
+// by their fingerprints before they are trusted.
+const AnnotationKeysRef = "io.kubectl-mft.keys.ref"
+
+// WithKeysRef returns a copy of the Signer that names the key distribution artifact ref in the
+// signatures it creates.
+func (s *Signer) WithKeysRef(ref string) *Signer {
+	c := *s
+	c.keysRef = ref
+	return &c
+}
+
+// KeysRefs returns the key distribution artifacts named by the signatures of the manifest
+// identified by tag in the OCI layout at layoutPath, without duplicates.
+func KeysRefs(ctx context.Context, layoutPath, tag string) ([]string, error) {
+	store, err := oci.New(layoutPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OCI layout: %w", err)
+	}
+	desc, err := store.Resolve(ctx, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve tag %q: %w", tag, err)
+	}
+	predecessors, err := store.Predecessors(ctx, desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get predecessors: %w", err)
+	}
+
+	var refs []string
+	for _, p := range predecessors {
+		if p.ArtifactType != "" && p.ArtifactType != SignatureArtifactType {
+			continue
+		}
+		data, err := content.FetchAll(ctx, store, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch signature manifest: %w", err)
+		}
+		var m v1.Manifest
+		if err := json.Unmarshal(data, &m); err != nil || m.ArtifactType != SignatureArtifactType {
+			continue
+		}
+		if ref := m.Annotations[AnnotationKeysRef]; ref != "" && !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs, nil
+}
+
+// PEMFingerprint returns the fingerprint of a PEM-encoded public key, see Fingerprint.
+func PEMFingerprint(data []byte) (string, error) {
+	pub, err := parsePublicKeyPEM(data)
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %w", err)
+	}
+	return Fingerprint(pub)
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"slices"
+	"testing"
+)
+
+func TestKeysRefs(t *testing.T) {
+	layoutPath, tag := setupTestOCILayout(t)
+	ctx := context.Background()
+
+	refs, err := KeysRefs(ctx, layoutPath, tag)
+	if err != nil || len(refs) != 0 {
+		t.Fatalf("KeysRefs() of an unsigned manifest = %v, %v, want none", refs, err)
+	}
+
+	for _, ref := range []string{"", "registry.example.com/team/keys:v3", "registry.example.com/team/keys:v3", "registry.example.com/ci/keys:v1"} {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signer := NewSigner(key)
+		if ref != "" {
+			signer = signer.WithKeysRef(ref)
+		}
+		if _, err := signer.Sign(ctx, layoutPath, tag); err != nil {
+			t.Fatalf("Sign() failed: %v", err)
+		}
+	}
+
+	refs, err = KeysRefs(ctx, layoutPath, tag)
+	if err != nil {
+		t.Fatalf("KeysRefs() failed: %v", err)
+	}
+	slices.Sort(refs)
+	want := []string{"registry.example.com/ci/keys:v1", "registry.example.com/team/keys:v3"}
+	if !slices.Equal(refs, want) {
+		t.Errorf("KeysRefs() = %v, want %v", refs, want)
+	}
+}
+
+func TestPEMFingerprint(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := marshalPublicKeyPEM(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := PEMFingerprint(data)
+	if err != nil {
+		t.Fatalf("PEMFingerprint() failed: %v", err)
+	}
+	want, err := Fingerprint(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("PEMFingerprint() = %s, want %s", got, want)
+	}
+	if _, err := PEMFingerprint([]byte("not a key")); err == nil {
+		t.Error("PEMFingerprint() of invalid data succeeded, want error")
+	}
+}
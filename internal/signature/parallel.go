@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// verifyWorkers is the number of candidate signatures or keys checked concurrently
+var verifyWorkers = runtime.GOMAXPROCS(0)
+
+// workersKey holds the semaphore of the additional workers of nested firstMatch calls
+type workersKey struct{}
+
+// firstMatch runs check for the candidates 0 to n-1 concurrently and returns the lowest
+// candidate that passes, or -1 and the error of every candidate if none does. Once a
+// candidate passes, higher candidates are skipped and the context of running ones is
+// canceled; lower ones still complete, so that the result does not depend on scheduling.
+//
+// The calling goroutine checks candidates itself, helped by as many workers as are free.
+// firstMatch calls nested in a check, such as the keys of each signature, share the
+// workers of the outermost call, so that at most verifyWorkers checks, and gpg processes,
+// run at once, whichever level has the candidates.
+func firstMatch(ctx context.Context, n int, check func(ctx context.Context, i int) error) (int, []error) {
+	sem, ok := ctx.Value(workersKey{}).(chan struct{})
+	if !ok {
+		sem = make(chan struct{}, max(verifyWorkers-1, 0))
+		ctx = context.WithValue(ctx, workersKey{}, sem)
+	}
+
+	errs := make([]error, n)
+	cancels := make([]context.CancelFunc, n)
+	match := n
+	var mu sync.Mutex
+
+	// start returns the context to check candidate i with, or false if a lower one passed
+	start := func(i int) (context.Context, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if i > match {
+			return nil, false
+		}
+		checkCtx, cancel := context.WithCancel(ctx)
+		cancels[i] = cancel
+		return checkCtx, true
+	}
+	finish := func(i int, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		cancels[i]()
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		if i < match {
+			match = i
+			for _, cancel := range cancels[i+1:] {
+				if cancel != nil {
+					cancel()
+				}
+			}
+		}
+	}
+
+	var next atomic.Int64
+	work := func() {
+		for {
+			i := int(next.Add(1) - 1)
+			if i >= n {
+				return
+			}
+			if checkCtx, ok := start(i); ok {
+				finish(i, check(checkCtx, i))
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+spawn:
+	for range n - 1 {
+		select {
+		case sem <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				work()
+			}()
+		default:
+			break spawn
+		}
+	}
+	work()
+	wg.Wait()
+
+	if match == n {
+		return -1, errs
+	}
+	return match, nil
+}
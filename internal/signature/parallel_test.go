@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFirstMatch(t *testing.T) {
+	orig := verifyWorkers
+	verifyWorkers = 4
+	t.Cleanup(func() { verifyWorkers = orig })
+
+	tests := []struct {
+		name    string
+		n       int
+		passing map[int]bool
+		want    int
+	}{
+		{name: "none", n: 8, want: -1},
+		{name: "no candidates", n: 0, want: -1},
+		{name: "lowest of several", n: 20, passing: map[int]bool{3: true, 7: true, 15: true}, want: 3},
+		{name: "last", n: 20, passing: map[int]bool{19: true}, want: 19},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 20 {
+				got, errs := firstMatch(context.Background(), tt.n, func(_ context.Context, i int) error {
+					// Later candidates finish first, so that scheduling would change a naive result
+					time.Sleep(time.Duration(tt.n-i) * 100 * time.Microsecond)
+					if tt.passing[i] {
+						return nil
+					}
+					return fmt.Errorf("candidate %d", i)
+				})
+				if got != tt.want {
+					t.Fatalf("firstMatch() = %d, want %d", got, tt.want)
+				}
+				if got >= 0 {
+					continue
+				}
+				for i, err := range errs {
+					if want := fmt.Sprintf("candidate %d", i); err == nil || err.Error() != want {
+						t.Fatalf("errs[%d] = %v, want %s", i, err, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFirstMatchCancelsHigherCandidates(t *testing.T) {
+	orig := verifyWorkers
+	verifyWorkers = 4
+	t.Cleanup(func() { verifyWorkers = orig })
+
+	var checked, canceled atomic.Int32
+	got, _ := firstMatch(context.Background(), 100, func(ctx context.Context, i int) error {
+		checked.Add(1)
+		if i == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			canceled.Add(1)
+			return ctx.Err()
+		case <-time.After(10 * time.Second):
+			return errors.New("not canceled")
+		}
+	})
+	if got != 0 {
+		t.Fatalf("firstMatch() = %d, want 0", got)
+	}
+	if n := checked.Load(); n > int32(verifyWorkers) {
+		t.Errorf("%d candidates were checked after the first passed, want at most %d", n, verifyWorkers)
+	}
+	if canceled.Load() != checked.Load()-1 {
+		t.Errorf("%d of %d running checks were canceled", canceled.Load(), checked.Load()-1)
+	}
+}
+
+// peakTracker records the highest number of checks running at once.
+type peakTracker struct {
+	running, peak atomic.Int32
+}
+
+func (p *peakTracker) check(context.Context, int) error {
+	n := p.running.Add(1)
+	defer p.running.Add(-1)
+	for {
+		peak := p.peak.Load()
+		if n <= peak || p.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	return errNoTrustedKey
+}
+
+func TestFirstMatchNestedSharesWorkers(t *testing.T) {
+	orig := verifyWorkers
+	verifyWorkers = 4
+	t.Cleanup(func() { verifyWorkers = orig })
+
+	var p peakTracker
+	got, _ := firstMatch(context.Background(), 8, func(ctx context.Context, _ int) error {
+		if i, _ := firstMatch(ctx, 8, p.check); i < 0 {
+			return errNoTrustedKey
+		}
+		return nil
+	})
+	if got != -1 {
+		t.Fatalf("firstMatch() = %d, want -1", got)
+	}
+	if peak := p.peak.Load(); peak > int32(verifyWorkers) {
+		t.Errorf("%d nested checks ran concurrently, want at most %d", peak, verifyWorkers)
+	}
+}
+
+func TestFirstMatchOneSignatureManyKeys(t *testing.T) {
+	orig := verifyWorkers
+	verifyWorkers = 4
+	t.Cleanup(func() { verifyWorkers = orig })
+
+	// A single signature is checked against every key, which run concurrently
+	var p peakTracker
+	firstMatch(context.Background(), 1, func(ctx context.Context, _ int) error {
+		_, errs := firstMatch(ctx, 32, p.check)
+		return errors.Join(errs...)
+	})
+	if peak := p.peak.Load(); peak < 2 || peak > int32(verifyWorkers) {
+		t.Errorf("%d keys were checked concurrently, want 2 to %d", peak, verifyWorkers)
+	}
+}
+
+func TestVerifyManyKeysAndSignatures(t *testing.T) {
+	layoutPath, tag := setupTestOCILayout(t)
+	ctx := context.Background()
+
+	// Several signatures by untrusted keys, and one by the last of many trusted keys
+	for range 3 {
+		other, _ := generateTestKeyPair(t)
+		if _, err := NewSigner(other).Sign(ctx, layoutPath, tag); err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+	}
+	var keys []crypto.PublicKey
+	for range 30 {
+		_, pub := generateTestKeyPair(t)
+		keys = append(keys, pub)
+	}
+	priv, pub := generateTestKeyPair(t)
+	if _, err := NewSigner(priv).Sign(ctx, layoutPath, tag); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	if err := NewVerifier(append(keys, pub)).Verify(ctx, layoutPath, tag); err != nil {
+		t.Errorf("Verify() failed: %v", err)
+	}
+	err := NewVerifier(keys).Verify(ctx, layoutPath, tag)
+	if err == nil {
+		t.Fatal("Verify() without the signing key should fail")
+	}
+	if want := "none of the available public keys"; !strings.Contains(err.Error(), want) {
+		t.Errorf("Verify() error = %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// SignatureEnvelopeMediaType is the media type of a signature layer holding a DSSE envelope
+	// over a Payload. Unlike the legacy SignatureMediaType and SignatureGPGMediaType layers, which
+	// sign the manifest digest only, these signatures bind the tag as well.
+	SignatureEnvelopeMediaType = "application/vnd.dsse.envelope.v1+json"
+
+	// PayloadType is the DSSE payload type of kubectl-mft signing payloads
+	PayloadType = "application/vnd.kubectl-mft.payload.v1+json"
+
+	// PayloadVersion is the version of the signing payload created by Signer
+	PayloadVersion = 1
+
+	// gpgKeyIDPrefix marks an envelope signature created by the local GPG keyring
+	gpgKeyIDPrefix = "gpg:"
+)
+
+// Payload is the statement signed for a manifest. Verification requires the digest, media type,
+// and tag to match the verified manifest, so a signature cannot be replayed onto another tag.
+// The repository is informational, as mirroring and copying between registries keep signatures.
+type Payload struct {
+	Version    int           `json:"version"`
+	Digest     digest.Digest `json:"digest"`
+	MediaType  string        `json:"mediaType"`
+	Repository string        `json:"repository,omitempty"`
+	Tag        string        `json:"tag"`
+	// Timestamp is the signing time claimed by the signer, see Timestamp for a trusted time
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// envelope is a DSSE envelope, see https://github.com/secure-systems-lab/dsse
+type envelope struct {
+	PayloadType string              `json:"payloadType"`
+	Payload     []byte              `json:"payload"`
+	Signatures  []envelopeSignature `json:"signatures"`
+}
+
+type envelopeSignature struct {
+	// KeyID is the fingerprint of the signing key, or the GPG key prefixed with "gpg:". It is
+	// a hint only, signatures are verified against the trusted keys.
+	KeyID string `json:"keyid,omitempty"`
+	Sig   []byte `json:"sig"`
+}
+
+// pae returns the DSSE pre-authentication encoding of a payload, which is what is signed.
+func pae(payloadType string, payload []byte) []byte {
+	return fmt.Appendf(nil, "DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)
+}
+
+// marshalPayload encodes p canonically: fields in declaration order, no insignificant
+// whitespace, and the timestamp in UTC with second precision.
+func marshalPayload(p Payload) ([]byte, error) {
+	p.Timestamp = p.Timestamp.UTC().Truncate(time.Second)
+	return json.Marshal(p)
+}
+
+// parseEnvelope decodes a signature envelope and its payload.
+func parseEnvelope(data []byte) (*envelope, *Payload, error) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse signature envelope: %w", err)
+	}
+	if env.PayloadType != PayloadType {
+		return nil, nil, fmt.Errorf("unsupported payload type %q", env.PayloadType)
+	}
+	if len(env.Signatures) == 0 {
+		return nil, nil, fmt.Errorf("signature envelope holds no signature")
+	}
+	var p Payload
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse signing payload: %w", err)
+	}
+	if p.Version != PayloadVersion {
+		return nil, nil, fmt.Errorf("unsupported signing payload version %d", p.Version)
+	}
+	return &env, &p, nil
+}
+
+// checkPayload checks that p was signed for the manifest desc under tag. A tag given as a
+// digest, when a manifest is verified by digest, is not compared.
+func checkPayload(p *Payload, desc v1.Descriptor, tag string) error {
+	if p.Digest != desc.Digest {
+		return fmt.Errorf("signature is for manifest %s, not %s", p.Digest, desc.Digest)
+	}
+	if p.MediaType != desc.MediaType {
+		return fmt.Errorf("signature is for media type %q, not %q", p.MediaType, desc.MediaType)
+	}
+	if !strings.Contains(tag, ":") && p.Tag != tag {
+		return fmt.Errorf("signature is for tag %q, not %q", p.Tag, tag)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+// attachSignature attaches a signature layer of the given media type to the manifest tagged tag.
+func attachSignature(t *testing.T, layoutPath, tag string, sig []byte, mediaType string) {
+	t.Helper()
+	ctx := context.Background()
+	store, err := oci.New(layoutPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := store.Resolve(ctx, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layer := v1.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(sig), Size: int64(len(sig))}
+	if err := store.Push(ctx, layer, bytes.NewReader(sig)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, SignatureArtifactType, oras.PackManifestOptions{
+		Subject: &desc,
+		Layers:  []v1.Descriptor{layer},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tagTestManifest tags the manifest tagged tag with another tag.
+func tagTestManifest(t *testing.T, layoutPath, tag, newTag string) {
+	t.Helper()
+	ctx := context.Background()
+	store, err := oci.New(layoutPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := store.Resolve(ctx, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, desc, newTag); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSignPayload(t *testing.T) {
+	layoutPath, tag := setupTestOCILayout(t)
+	privKey, pubKey := generateTestKeyPair(t)
+	ctx := context.Background()
+
+	if _, err := NewSigner(privKey).WithRepository("registry.example.com/team/app").Sign(ctx, layoutPath, tag); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	verifier := NewVerifier([]crypto.PublicKey{pubKey})
+	d, results, err := verifier.Inspect(ctx, layoutPath, tag)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Inspect() = %v, %v, want one result", results, err)
+	}
+	p := results[0].Payload
+	if p == nil {
+		t.Fatal("signature has no payload")
+	}
+	if p.Version != PayloadVersion || p.Digest != d || p.Tag != tag || p.Repository != "registry.example.com/team/app" || p.MediaType != v1.MediaTypeImageManifest || p.Timestamp.IsZero() {
+		t.Errorf("payload = %+v", p)
+	}
+
+	// The signature does not verify under another tag of the same manifest
+	tagTestManifest(t, layoutPath, tag, "latest")
+	if err := verifier.Verify(ctx, layoutPath, tag); err != nil {
+		t.Errorf("Verify(%s) failed: %v", tag, err)
+	}
+	err = verifier.Verify(ctx, layoutPath, "latest")
+	if err == nil || !strings.Contains(err.Error(), `signature is for tag "v1.0.0", not "latest"`) {
+		t.Errorf("Verify(latest) error = %v, want tag mismatch", err)
+	}
+	// Verification by digest does not compare the tag
+	if err := verifier.Verify(ctx, layoutPath, d.String()); err != nil {
+		t.Errorf("Verify(%s) failed: %v", d, err)
+	}
+}
+
+func TestVerifyPayloadMismatch(t *testing.T) {
+	privKey, pubKey := generateTestKeyPair(t)
+
+	tests := []struct {
+		name   string
+		modify func(p *Payload)
+		want   string
+	}{
+		{name: "digest", modify: func(p *Payload) { p.Digest = digest.FromString("other") }, want: "signature is for manifest"},
+		{name: "media type", modify: func(p *Payload) { p.MediaType = v1.MediaTypeImageIndex }, want: "signature is for media type"},
+		{name: "version", modify: func(p *Payload) { p.Version = 2 }, want: "unsupported signing payload version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layoutPath, tag := setupTestOCILayout(t)
+			ctx := context.Background()
+			desc, err := resolveDescriptor(ctx, layoutPath, tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p := Payload{Version: PayloadVersion, Digest: desc.Digest, MediaType: desc.MediaType, Tag: tag}
+			tt.modify(&p)
+			payload, err := marshalPayload(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sig, err := signPayload(privKey, pae(PayloadType, payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			env, err := json.Marshal(envelope{PayloadType: PayloadType, Payload: payload, Signatures: []envelopeSignature{{Sig: sig}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			attachSignature(t, layoutPath, tag, env, SignatureEnvelopeMediaType)
+
+			err = NewVerifier([]crypto.PublicKey{pubKey}).Verify(ctx, layoutPath, tag)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Verify() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyLegacySignature(t *testing.T) {
+	layoutPath, tag := setupTestOCILayout(t)
+	privKey, pubKey := generateTestKeyPair(t)
+	ctx := context.Background()
+
+	desc, err := resolveDescriptor(ctx, layoutPath, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, mediaType, err := NewSigner(privKey).sign(ctx, desc.Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attachSignature(t, layoutPath, tag, sig, mediaType)
+
+	// Signatures over the digest only verify under any tag
+	tagTestManifest(t, layoutPath, tag, "latest")
+	verifier := NewVerifier([]crypto.PublicKey{pubKey})
+	for _, tg := range []string{tag, "latest"} {
+		if err := verifier.Verify(ctx, layoutPath, tg); err != nil {
+			t.Errorf("Verify(%s) of a legacy signature failed: %v", tg, err)
+		}
+	}
+}
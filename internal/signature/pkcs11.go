@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const pkcs11KeyExt = ".pkcs11"
+
+// pkcs11Tool is the OpenSC pkcs11-tool executable used to access PKCS#11 modules.
+var pkcs11Tool = "pkcs11-tool"
+
+// PKCS11Key references an ECDSA private key stored on a hardware token.
+// The reference is stored in the key directory in place of a private key file.
+type PKCS11Key struct {
+	// Module is the path of the PKCS#11 module, e.g. /usr/lib/opensc-pkcs11.so
+	Module string `json:"module"`
+	// Slot is the slot ID of the token
+	Slot string `json:"slot"`
+	// ID is the hex encoded object ID of the key
+	ID string `json:"id,omitempty"`
+	// Label is the object label of the key
+	Label string `json:"label,omitempty"`
+}
+
+// PKCS11KeyPath returns the path to the named PKCS#11 key reference.
+func PKCS11KeyPath(name string) string {
+	return filepath.Join(keyDir, name+pkcs11KeyExt)
+}
+
+// AddPKCS11Key stores a reference to a key on a hardware token under name, so that it can
+// be used for signing like a generated key. The public key is read from the token and
+// stored as <name>.pub for verification.
+func AddPKCS11Key(name string, key PKCS11Key, force bool) error {
+	if err := ValidateKeyName(name); err != nil {
+		return err
+	}
+	if key.Module == "" || key.Slot == "" {
+		return fmt.Errorf("a PKCS#11 module and slot are required")
+	}
+	if key.ID == "" && key.Label == "" {
+		return fmt.Errorf("a PKCS#11 key ID or label is required")
+	}
+
+	if !force && PrivateKeyExists(name) {
+		return fmt.Errorf("private key %q already exists (use --force to overwrite)", name)
+	}
+
+	der, err := key.run(nil, "--read-object", "--type", "pubkey")
+	if err != nil {
+		return fmt.Errorf("failed to read public key from token: %w", err)
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key from token: %w", err)
+	}
+	ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unsupported token key type %T, only ECDSA keys are supported", pub)
+	}
+
+	data, err := json.MarshalIndent(key, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal PKCS#11 key reference: %w", err)
+	}
+	if err := os.MkdirAll(keyDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+	if err := writePublicKey(PublicKeyPath(name), ecdsaPub); err != nil {
+		return err
+	}
+	if err := os.WriteFile(PKCS11KeyPath(name), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write PKCS#11 key reference: %w", err)
+	}
+	return replacePrivateKey(name, pkcs11KeyExt)
+}
+
+// loadPKCS11Key returns a signer for the named PKCS#11 key reference.
+func loadPKCS11Key(name string) (crypto.Signer, error) {
+	data, err := os.ReadFile(PKCS11KeyPath(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PKCS#11 key reference: %w", err)
+	}
+	var key PKCS11Key
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS#11 key reference %q: %w", name, err)
+	}
+
+	pubData, err := os.ReadFile(PublicKeyPath(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key of PKCS#11 key %q: %w", name, err)
+	}
+	pub, err := parsePublicKeyPEM(pubData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key of PKCS#11 key %q: %w", name, err)
+	}
+	ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key of PKCS#11 key %q is not an ECDSA key", name)
+	}
+	return &pkcs11Signer{key: key, pub: ecdsaPub}, nil
+}
+
+// pkcs11Signer signs with an ECDSA key on a hardware token.
+type pkcs11Signer struct {
+	key PKCS11Key
+	pub *ecdsa.PublicKey
+}
+
+func (s *pkcs11Signer) Public() crypto.PublicKey {
+	return s.pub
+}
+
+// Sign signs a precomputed hash on the token. The user PIN is read from KUBECTL_MFT_PKCS11_PIN
+// if set, and requested by pkcs11-tool otherwise.
+func (s *pkcs11Signer) Sign(_ io.Reader, digest []byte, _ crypto.SignerOpts) ([]byte, error) {
+	args := []string{"--sign", "--mechanism", "ECDSA", "--signature-format", "openssl", "--login"}
+	if os.Getenv("KUBECTL_MFT_PKCS11_PIN") != "" {
+		args = append(args, "--pin", "env:KUBECTL_MFT_PKCS11_PIN")
+	}
+	sig, err := s.key.run(digest, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with token: %w", err)
+	}
+	return sig, nil
+}
+
+// run runs pkcs11-tool against the key with input as the input file and returns the output file.
+func (k PKCS11Key) run(input []byte, args ...string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "kubectl-mft-pkcs11-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	outPath := filepath.Join(dir, "out")
+	args = append([]string{"--module", k.Module, "--slot", k.Slot}, args...)
+	if k.ID != "" {
+		args = append(args, "--id", k.ID)
+	}
+	if k.Label != "" {
+		args = append(args, "--label", k.Label)
+	}
+	if input != nil {
+		inPath := filepath.Join(dir, "in")
+		if err := os.WriteFile(inPath, input, 0o600); err != nil {
+			return nil, fmt.Errorf("failed to write input file: %w", err)
+		}
+		args = append(args, "--input-file", inPath)
+	}
+	args = append(args, "--output-file", outPath)
+
+	cmd := exec.Command(pkcs11Tool, args...)
+	cmd.Stdin = os.Stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %s", pkcs11Tool, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", pkcs11Tool, err)
+	}
+
+	out, err := os.ReadFile(outPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s output: %w", pkcs11Tool, err)
+	}
+	return out, nil
+}
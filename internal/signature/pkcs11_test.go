@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// setupFakePKCS11Tool replaces pkcs11-tool with a script that serves a software key through openssl.
+func setupFakePKCS11Tool(t *testing.T) crypto.PublicKey {
+	t.Helper()
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("openssl is not installed")
+	}
+
+	privKey, pubKey := generateTestKeyPair(t)
+	dir := t.TempDir()
+	der, err := x509.MarshalPKCS8PrivateKey(privKey)
+	if err != nil {
+		t.Fatalf("failed to marshal private key: %v", err)
+	}
+	keyPath := filepath.Join(dir, "token.pem")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write private key: %v", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pubKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	pubPath := filepath.Join(dir, "token.der")
+	if err := os.WriteFile(pubPath, pubDER, 0o644); err != nil {
+		t.Fatalf("failed to write public key: %v", err)
+	}
+
+	script := fmt.Sprintf(`#!/bin/sh
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --input-file) in=$2; shift;;
+    --output-file) out=$2; shift;;
+    --read-object) read=1;;
+  esac
+  shift
+done
+if [ -n "$read" ]; then
+  cp %s "$out"
+else
+  openssl pkeyutl -sign -inkey %s -in "$in" -out "$out"
+fi
+`, pubPath, keyPath)
+	toolPath := filepath.Join(dir, "pkcs11-tool")
+	if err := os.WriteFile(toolPath, []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write fake pkcs11-tool: %v", err)
+	}
+
+	orig := pkcs11Tool
+	pkcs11Tool = toolPath
+	t.Cleanup(func() { pkcs11Tool = orig })
+	return pubKey
+}
+
+func TestAddPKCS11KeyAndSign(t *testing.T) {
+	cleanup := setupTestKeyDir(t)
+	defer cleanup()
+	pubKey := setupFakePKCS11Tool(t)
+
+	key := PKCS11Key{Module: "/usr/lib/opensc-pkcs11.so", Slot: "0", ID: "02"}
+	if err := AddPKCS11Key("token", key, false); err != nil {
+		t.Fatalf("AddPKCS11Key() unexpected error: %v", err)
+	}
+	if !PrivateKeyExists("token") {
+		t.Errorf("PrivateKeyExists() = false for PKCS#11 key")
+	}
+	if err := AddPKCS11Key("token", key, false); err == nil {
+		t.Errorf("AddPKCS11Key() expected error for existing key")
+	}
+
+	layoutPath, tag := setupTestOCILayout(t)
+	ctx := context.Background()
+	signer, err := NewSignerFromKeyDir("token")
+	if err != nil {
+		t.Fatalf("NewSignerFromKeyDir() unexpected error: %v", err)
+	}
+	if _, err := signer.Sign(ctx, layoutPath, tag); err != nil {
+		t.Fatalf("Sign() unexpected error: %v", err)
+	}
+
+	// Both the token public key and the stored copy verify the signature
+	if err := NewVerifier([]crypto.PublicKey{pubKey}).Verify(ctx, layoutPath, tag); err != nil {
+		t.Errorf("Verify() unexpected error: %v", err)
+	}
+	verifier, err := NewVerifierFromKeyDir()
+	if err != nil {
+		t.Fatalf("NewVerifierFromKeyDir() unexpected error: %v", err)
+	}
+	if err := verifier.Verify(ctx, layoutPath, tag); err != nil {
+		t.Errorf("Verify() with stored public key unexpected error: %v", err)
+	}
+
+	if err := DeletePrivateKey("token"); err != nil {
+		t.Fatalf("DeletePrivateKey() unexpected error: %v", err)
+	}
+	if PrivateKeyExists("token") {
+		t.Errorf("PrivateKeyExists() = true after delete")
+	}
+}
+
+func TestAddPKCS11KeyRequiresKeySelector(t *testing.T) {
+	cleanup := setupTestKeyDir(t)
+	defer cleanup()
+
+	if err := AddPKCS11Key("token", PKCS11Key{Module: "/usr/lib/opensc-pkcs11.so", Slot: "0"}, false); err == nil {
+		t.Errorf("AddPKCS11Key() expected error without ID or label")
+	}
+}
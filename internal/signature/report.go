@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+// SignatureResult is the verification result of a single signature attached to a manifest.
+type SignatureResult struct {
+	// Digest is the digest of the signature manifest
+	Digest      string            `json:"digest"`
+	MediaType   string            `json:"mediaType,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Payload is the signed statement of an envelope signature
+	Payload *Payload `json:"payload,omitempty"`
+	// KeyFingerprint is the fingerprint of the trusted key that verifies the signature, or of
+	// the GPG key for PGP signatures
+	KeyFingerprint string     `json:"keyFingerprint,omitempty"`
+	Verified       bool       `json:"verified"`
+	Timestamp      *Timestamp `json:"timestamp,omitempty"`
+	// Error is the reason the signature does not verify
+	Error string `json:"error,omitempty"`
+}
+
+// Inspect verifies each signature of the manifest identified by tag on its own, and returns
+// the digest of the manifest with one result per signature. The manifest verifies with
+// Verify if any signature is verified. The verification cache is not used.
+func (v *Verifier) Inspect(ctx context.Context, layoutPath, tag string) (digest.Digest, []SignatureResult, error) {
+	store, err := oci.New(layoutPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to open OCI layout: %w", err)
+	}
+	desc, err := store.Resolve(ctx, tag)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to resolve tag %q: %w", tag, err)
+	}
+	predecessors, err := store.Predecessors(ctx, desc)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get predecessors: %w", err)
+	}
+
+	results := []SignatureResult{}
+	for _, p := range predecessors {
+		sig, isSignature, err := tryExtractSignature(ctx, store, p)
+		if !isSignature {
+			continue
+		}
+		if err != nil {
+			results = append(results, SignatureResult{Digest: p.Digest.String(), Error: err.Error()})
+			continue
+		}
+		results = append(results, v.inspectSignature(ctx, desc, tag, sig))
+	}
+	return desc.Digest, results, nil
+}
+
+// inspectSignature verifies sig of the manifest desc verified under tag.
+func (v *Verifier) inspectSignature(ctx context.Context, desc v1.Descriptor, tag string, sig *signatureBlob) SignatureResult {
+	res := SignatureResult{
+		Digest:      sig.manifest.Digest.String(),
+		MediaType:   sig.mediaType,
+		Annotations: sig.annotations,
+	}
+	if sig.mediaType == SignatureEnvelopeMediaType {
+		if _, payload, err := parseEnvelope(sig.data); err == nil {
+			res.Payload = payload
+		}
+	}
+
+	fingerprint, ts, err := v.verifyDated(ctx, desc, tag, sig)
+	var tsErr *timestampError
+	if errors.As(err, &tsErr) {
+		res.Error = fmt.Sprintf("invalid timestamp: %v", err)
+		return res
+	}
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.KeyFingerprint = fingerprint
+	res.Timestamp = ts
+	res.Verified = true
+	return res
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Requirements are stricter conditions on the signatures of a manifest than verification by
+// any trusted key. The zero value accepts any verified manifest.
+type Requirements struct {
+	// MinSignatures is the number of distinct trusted keys that must have signed the manifest
+	MinSignatures int
+	// MaxAge is the maximum age of the counted signatures, unlimited if zero. The age is taken
+	// from the trusted timestamp of a signature, or else from the signing time of its payload.
+	MaxAge time.Duration
+}
+
+// IsZero reports whether the requirements accept any verified manifest.
+func (r Requirements) IsZero() bool {
+	return r.MinSignatures <= 1 && r.MaxAge == 0
+}
+
+// Check checks the signature results returned by Verifier.Inspect against the requirements.
+func (r Requirements) Check(results []SignatureResult, now time.Time) error {
+	var signers []string
+	stale, undated := 0, 0
+	for _, res := range results {
+		if !res.Verified || slices.Contains(signers, res.KeyFingerprint) {
+			continue
+		}
+		if r.MaxAge > 0 {
+			signed, ok := res.SignedAt()
+			if !ok {
+				undated++
+				continue
+			}
+			if now.Sub(signed) > r.MaxAge {
+				stale++
+				continue
+			}
+		}
+		signers = append(signers, res.KeyFingerprint)
+	}
+
+	want := max(r.MinSignatures, 1)
+	if len(signers) >= want {
+		return nil
+	}
+	msg := fmt.Sprintf("%d signature(s) by distinct trusted keys required, found %d", want, len(signers))
+	if r.MaxAge > 0 {
+		msg = fmt.Sprintf("%d signature(s) by distinct trusted keys made within %s required, found %d", want, r.MaxAge, len(signers))
+	}
+	if stale > 0 {
+		msg += fmt.Sprintf("; %d signature(s) are older", stale)
+	}
+	if undated > 0 {
+		msg += fmt.Sprintf("; %d signature(s) have no signing time", undated)
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+// SignedAt returns the time the signature was made: the time of its trusted timestamp, or else
+// the signing time claimed in its payload. ok is false for legacy signatures without either.
+func (r SignatureResult) SignedAt() (t time.Time, ok bool) {
+	if r.Timestamp != nil {
+		return r.Timestamp.Time, true
+	}
+	if r.Payload != nil && !r.Payload.Timestamp.IsZero() {
+		return r.Payload.Timestamp, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequirementsCheck(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	signed := func(key string, age time.Duration) SignatureResult {
+		return SignatureResult{Verified: true, KeyFingerprint: key, Payload: &Payload{Timestamp: now.Add(-age)}}
+	}
+	timestamped := func(key string, age time.Duration) SignatureResult {
+		// The trusted timestamp takes precedence over the claimed signing time
+		res := signed(key, 0)
+		res.Timestamp = &Timestamp{Time: now.Add(-age)}
+		return res
+	}
+	legacy := SignatureResult{Verified: true, KeyFingerprint: "sha256:legacy"}
+	failed := SignatureResult{Verified: false, Error: "none of the trusted keys verifies the signature"}
+
+	tests := []struct {
+		name    string
+		req     Requirements
+		results []SignatureResult
+		wantErr bool
+	}{
+		{name: "zero", results: []SignatureResult{legacy}},
+		{name: "zero without verified signature", results: []SignatureResult{failed}, wantErr: true},
+		{name: "two keys", req: Requirements{MinSignatures: 2}, results: []SignatureResult{signed("a", 0), failed, legacy}},
+		{name: "same key twice", req: Requirements{MinSignatures: 2}, results: []SignatureResult{signed("a", 0), signed("a", time.Hour)}, wantErr: true},
+		{name: "fresh", req: Requirements{MaxAge: 24 * time.Hour}, results: []SignatureResult{signed("a", time.Hour)}},
+		{name: "stale", req: Requirements{MaxAge: 24 * time.Hour}, results: []SignatureResult{signed("a", 48*time.Hour)}, wantErr: true},
+		{name: "stale timestamp", req: Requirements{MaxAge: 24 * time.Hour}, results: []SignatureResult{timestamped("a", 48*time.Hour)}, wantErr: true},
+		{name: "undated", req: Requirements{MaxAge: 24 * time.Hour}, results: []SignatureResult{legacy}, wantErr: true},
+		{name: "one of two keys stale", req: Requirements{MinSignatures: 2, MaxAge: 24 * time.Hour}, results: []SignatureResult{signed("a", time.Hour), signed("b", 48*time.Hour)}, wantErr: true},
+		{name: "stale and fresh by same key", req: Requirements{MaxAge: 24 * time.Hour}, results: []SignatureResult{signed("a", 48*time.Hour), signed("a", time.Hour)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Check(tt.results, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -7,9 +7,15 @@ import (
 	"bytes"
 	"context"
 	"crypto"
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/opencontainers/go-digest"
 	v1 "github.com/opencontainers/image-spec/specs-go/v1"
@@ -33,6 +39,16 @@ type SignResult struct {
 // Signer performs signing on local OCI layouts.
 type Signer struct {
 	privateKey crypto.Signer
+	// gpgKey selects a key of the local GPG keyring instead of privateKey
+	gpgKey string
+	// timestampURL is the RFC 3161 timestamping authority that timestamps signatures, if any
+	timestampURL string
+	// timestampClient sends the requests to the timestamping authority
+	timestampClient *http.Client
+	// keysRef is the key distribution artifact named in signatures, if any
+	keysRef string
+	// repository is recorded in the signing payload, if set
+	repository string
 }
 
 // NewSigner creates a new Signer with the given private key.
@@ -42,6 +58,31 @@ func NewSigner(privateKey crypto.Signer) *Signer {
 	}
 }
 
+// NewGPGSigner creates a new Signer using the given key of the local GPG keyring.
+func NewGPGSigner(keyID string) *Signer {
+	return &Signer{
+		gpgKey: keyID,
+	}
+}
+
+// WithTimestampAuthority returns a copy of the Signer that timestamps signatures with the
+// RFC 3161 timestamping authority at url, sending the requests with client, or
+// http.DefaultClient if nil.
+func (s *Signer) WithTimestampAuthority(url string, client *http.Client) *Signer {
+	c := *s
+	c.timestampURL = url
+	c.timestampClient = client
+	return &c
+}
+
+// WithRepository returns a copy of the Signer that records the repository in the signing
+// payload of manifest signatures.
+func (s *Signer) WithRepository(repository string) *Signer {
+	c := *s
+	c.repository = repository
+	return &c
+}
+
 // NewSignerFromKeyDir creates a Signer by loading a private key from the key directory.
 func NewSignerFromKeyDir(keyName string) (*Signer, error) {
 	privKey, err := LoadPrivateKey(keyName)
@@ -51,9 +92,46 @@ func NewSignerFromKeyDir(keyName string) (*Signer, error) {
 	return NewSigner(privKey), nil
 }
 
+// NewSignerFromRef creates a Signer from a key reference. The reference is either the name of a
+// private key in the key directory, "ssh:<path>" for an OpenSSH private key file, or
+// "ssh-agent:[<fingerprint|comment>]" for a key held by ssh-agent, or "gpg:<key>" for a key
+// of the local GPG keyring.
+func NewSignerFromRef(ref string) (*Signer, error) {
+	if keyID, ok := strings.CutPrefix(ref, GPGKeyPrefix); ok {
+		if keyID == "" {
+			return nil, fmt.Errorf("GPG key ID must not be empty")
+		}
+		return NewGPGSigner(keyID), nil
+	}
+	if path, ok := strings.CutPrefix(ref, SSHKeyPrefix); ok {
+		privKey, err := LoadSSHPrivateKey(path)
+		if err != nil {
+			return nil, err
+		}
+		return NewSigner(privKey), nil
+	}
+	if selector, ok := strings.CutPrefix(ref, SSHAgentPrefix); ok {
+		privKey, err := LoadSSHAgentKey(selector)
+		if err != nil {
+			return nil, err
+		}
+		return NewSigner(privKey), nil
+	}
+	return NewSignerFromKeyDir(ref)
+}
+
+// Close releases the resources held by the signing key, such as the connection to ssh-agent.
+// Copies of the Signer share its key and must not be used afterwards.
+func (s *Signer) Close() error {
+	if c, ok := s.privateKey.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
 // Sign signs the manifest identified by tag in the OCI layout at layoutPath.
 func (s *Signer) Sign(ctx context.Context, layoutPath, tag string) (*SignResult, error) {
-	if s.privateKey == nil {
+	if s.privateKey == nil && s.gpgKey == "" {
 		return nil, fmt.Errorf("no private key available for signing")
 	}
 
@@ -68,8 +146,8 @@ func (s *Signer) Sign(ctx context.Context, layoutPath, tag string) (*SignResult,
 		return nil, fmt.Errorf("failed to resolve tag %q: %w", tag, err)
 	}
 
-	// Sign the manifest digest
-	sig, err := signDigest(s.privateKey, desc.Digest)
+	// Sign the payload binding the manifest to the tag
+	sig, mediaType, err := s.signManifest(ctx, desc, tag)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign manifest: %w", err)
 	}
@@ -77,7 +155,7 @@ func (s *Signer) Sign(ctx context.Context, layoutPath, tag string) (*SignResult,
 	// Push signature blob to the store
 	sigDigest := digest.FromBytes(sig)
 	sigDesc := v1.Descriptor{
-		MediaType: SignatureMediaType,
+		MediaType: mediaType,
 		Digest:    sigDigest,
 		Size:      int64(len(sig)),
 	}
@@ -85,12 +163,34 @@ func (s *Signer) Sign(ctx context.Context, layoutPath, tag string) (*SignResult,
 	if err := store.Push(ctx, sigDesc, bytes.NewReader(sig)); err != nil {
 		return nil, fmt.Errorf("failed to push signature blob: %w", err)
 	}
+	layers := []v1.Descriptor{sigDesc}
+
+	// Store the timestamp token as a second layer
+	token, err := s.timestamp(ctx, sig)
+	if err != nil {
+		return nil, err
+	}
+	if token != nil {
+		tokenDesc := v1.Descriptor{
+			MediaType: TimestampMediaType,
+			Digest:    digest.FromBytes(token),
+			Size:      int64(len(token)),
+		}
+		if err := store.Push(ctx, tokenDesc, bytes.NewReader(token)); err != nil {
+			return nil, fmt.Errorf("failed to push timestamp blob: %w", err)
+		}
+		layers = append(layers, tokenDesc)
+	}
 
 	// Pack a manifest with the subject pointing to the signed manifest
-	sigManifestDesc, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, SignatureArtifactType, oras.PackManifestOptions{
+	opts := oras.PackManifestOptions{
 		Subject: &desc,
-		Layers:  []v1.Descriptor{sigDesc},
-	})
+		Layers:  layers,
+	}
+	if s.keysRef != "" {
+		opts.ManifestAnnotations = map[string]string{AnnotationKeysRef: s.keysRef}
+	}
+	sigManifestDesc, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, SignatureArtifactType, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to pack signature manifest: %w", err)
 	}
@@ -100,8 +200,76 @@ func (s *Signer) Sign(ctx context.Context, layoutPath, tag string) (*SignResult,
 	}, nil
 }
 
-// signDigest signs the given digest using ECDSA with SHA-256.
-func signDigest(key crypto.Signer, d digest.Digest) ([]byte, error) {
-	hash := sha256.Sum256([]byte(d.String()))
+// SignBlob signs data that is not stored in an OCI layout, such as a trust bundle, and returns
+// the signature with its media type. The signature covers the digest of data like a manifest signature.
+func (s *Signer) SignBlob(ctx context.Context, data []byte) ([]byte, string, error) {
+	if s.privateKey == nil && s.gpgKey == "" {
+		return nil, "", fmt.Errorf("no private key available for signing")
+	}
+	return s.sign(ctx, digest.FromBytes(data))
+}
+
+// sign signs the digest with the configured key and returns the signature with its layer media type.
+func (s *Signer) sign(ctx context.Context, d digest.Digest) ([]byte, string, error) {
+	if s.gpgKey != "" {
+		sig, err := gpgSign(ctx, s.gpgKey, []byte(d.String()))
+		return sig, SignatureGPGMediaType, err
+	}
+	sig, err := signPayload(s.privateKey, []byte(d.String()))
+	return sig, SignatureMediaType, err
+}
+
+// signManifest signs a payload for the manifest desc under tag with the configured key and
+// returns the signature envelope with its layer media type.
+func (s *Signer) signManifest(ctx context.Context, desc v1.Descriptor, tag string) ([]byte, string, error) {
+	payload, err := marshalPayload(Payload{
+		Version:    PayloadVersion,
+		Digest:     desc.Digest,
+		MediaType:  desc.MediaType,
+		Repository: s.repository,
+		Tag:        tag,
+		Timestamp:  time.Now(),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal signing payload: %w", err)
+	}
+	msg := pae(PayloadType, payload)
+
+	var sig envelopeSignature
+	if s.gpgKey != "" {
+		sig.KeyID = gpgKeyIDPrefix + s.gpgKey
+		sig.Sig, err = gpgSign(ctx, s.gpgKey, msg)
+	} else if sig.KeyID, err = Fingerprint(s.privateKey.Public()); err == nil {
+		sig.Sig, err = signPayload(s.privateKey, msg)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := json.Marshal(envelope{PayloadType: PayloadType, Payload: payload, Signatures: []envelopeSignature{sig}})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal signature envelope: %w", err)
+	}
+	return data, SignatureEnvelopeMediaType, nil
+}
+
+// timestamp obtains a timestamp token for sig if a timestamping authority is configured.
+func (s *Signer) timestamp(ctx context.Context, sig []byte) ([]byte, error) {
+	if s.timestampURL == "" {
+		return nil, nil
+	}
+	return RequestTimestamp(ctx, s.timestampClient, s.timestampURL, sig)
+}
+
+// signPayload signs the SHA-256 hash of payload. ECDSA keys produce an ASN.1 signature,
+// RSA keys a PKCS #1 v1.5 signature, and Ed25519 keys sign the hash as the message.
+func signPayload(key crypto.Signer, payload []byte) ([]byte, error) {
+	if s, ok := key.(payloadSigner); ok {
+		return s.signPayload(payload)
+	}
+	hash := sha256.Sum256(payload)
+	if _, ok := key.Public().(ed25519.PublicKey); ok {
+		return key.Sign(rand.Reader, hash[:], crypto.Hash(0))
+	}
 	return key.Sign(rand.Reader, hash[:], crypto.SHA256)
 }
@@ -168,3 +168,92 @@ func TestVerifyMultiplePublicKeys(t *testing.T) {
 		t.Fatalf("Verify should succeed when one of multiple keys matches: %v", err)
 	}
 }
+
+func TestSignerFingerprints(t *testing.T) {
+	layoutPath, tag := setupTestOCILayout(t)
+	privKey, signerPubKey := generateTestKeyPair(t)
+	_, otherPubKey := generateTestKeyPair(t)
+	ctx := context.Background()
+
+	verifier := NewVerifier([]crypto.PublicKey{otherPubKey, signerPubKey})
+	got, err := verifier.SignerFingerprints(ctx, layoutPath, tag)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("SignerFingerprints() of an unsigned manifest = %v, %v, want none", got, err)
+	}
+
+	// Signing twice with the same key yields one fingerprint
+	for range 2 {
+		if _, err := NewSigner(privKey).Sign(ctx, layoutPath, tag); err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+	}
+	got, err = verifier.SignerFingerprints(ctx, layoutPath, tag)
+	if err != nil {
+		t.Fatalf("SignerFingerprints() failed: %v", err)
+	}
+	want, err := Fingerprint(signerPubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("SignerFingerprints() = %v, want [%s]", got, want)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	layoutPath, tag := setupTestOCILayout(t)
+	trustedKey, trustedPubKey := generateTestKeyPair(t)
+	otherKey, _ := generateTestKeyPair(t)
+	ctx := context.Background()
+
+	verifier := NewVerifier([]crypto.PublicKey{trustedPubKey})
+	_, results, err := verifier.Inspect(ctx, layoutPath, tag)
+	if err != nil || len(results) != 0 {
+		t.Fatalf("Inspect() of an unsigned manifest = %v, %v, want no results", results, err)
+	}
+
+	if _, err := NewSigner(trustedKey).WithKeysRef("registry.example.com/team/keys:v1").Sign(ctx, layoutPath, tag); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if _, err := NewSigner(otherKey).Sign(ctx, layoutPath, tag); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	d, results, err := verifier.Inspect(ctx, layoutPath, tag)
+	if err != nil {
+		t.Fatalf("Inspect() failed: %v", err)
+	}
+	if d == "" {
+		t.Error("Inspect() returned no manifest digest")
+	}
+	if len(results) != 2 {
+		t.Fatalf("Inspect() returned %d results, want 2", len(results))
+	}
+	want, err := Fingerprint(trustedPubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var verified, failed int
+	for _, res := range results {
+		if res.Digest == "" || res.MediaType != SignatureEnvelopeMediaType || res.Payload == nil {
+			t.Errorf("result lacks signature details: %+v", res)
+		}
+		if res.Verified {
+			verified++
+			if res.KeyFingerprint != want || res.Error != "" {
+				t.Errorf("verified result = %+v, want key %s", res, want)
+			}
+			if res.Annotations[AnnotationKeysRef] != "registry.example.com/team/keys:v1" {
+				t.Errorf("verified result annotations = %v, want the keys ref", res.Annotations)
+			}
+		} else {
+			failed++
+			if res.KeyFingerprint != "" || res.Error == "" {
+				t.Errorf("failed result = %+v, want an error and no key", res)
+			}
+		}
+	}
+	if verified != 1 || failed != 1 {
+		t.Errorf("got %d verified and %d failed results, want 1 each", verified, failed)
+	}
+}
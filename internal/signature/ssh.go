@@ -0,0 +1,223 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const (
+	// SSHKeyPrefix selects an OpenSSH private key file, e.g. "ssh:~/.ssh/id_ed25519".
+	SSHKeyPrefix = "ssh:"
+	// SSHAgentPrefix selects a key held by ssh-agent by SHA256 fingerprint or comment,
+	// e.g. "ssh-agent:SHA256:abc..." or "ssh-agent:alice@example.com".
+	// If no key is given, the first supported key of the agent is used.
+	SSHAgentPrefix = "ssh-agent:"
+)
+
+// LoadSSHPrivateKey loads an Ed25519 or ECDSA private key from an OpenSSH or PEM key file.
+// Encrypted keys are decrypted with the passphrase in KUBECTL_MFT_SSH_PASSPHRASE.
+func LoadSSHPrivateKey(path string) (crypto.Signer, error) {
+	path = expandHome(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH private key: %w", err)
+	}
+
+	key, err := ssh.ParseRawPrivateKey(data)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		passphrase := os.Getenv("KUBECTL_MFT_SSH_PASSPHRASE")
+		if passphrase == "" {
+			return nil, fmt.Errorf("SSH private key %s is encrypted, set KUBECTL_MFT_SSH_PASSPHRASE or load it into ssh-agent and use %s", path, SSHAgentPrefix)
+		}
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase(data, []byte(passphrase))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH private key: %w", err)
+	}
+
+	switch k := key.(type) {
+	case *ed25519.PrivateKey:
+		return *k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported SSH key type %T, only Ed25519 and ECDSA keys are supported", key)
+	}
+}
+
+// LoadSSHAgentKey returns a signer backed by the ssh-agent listening on SSH_AUTH_SOCK.
+// selector matches the SHA256 fingerprint or the comment of a key; if empty, the first
+// supported key is used. The signer holds the connection to ssh-agent, which is closed by
+// Signer.Close.
+func LoadSSHAgentKey(selector string) (crypto.Signer, error) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, fmt.Errorf("SSH_AUTH_SOCK is not set, no ssh-agent available")
+	}
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ssh-agent: %w", err)
+	}
+	client := agent.NewClient(conn)
+
+	keys, err := client.List()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to list ssh-agent keys: %w", err)
+	}
+	for _, k := range keys {
+		if selector != "" && selector != k.Comment && selector != ssh.FingerprintSHA256(k) {
+			continue
+		}
+		pub, err := agentPublicKey(k)
+		if err != nil {
+			if selector != "" {
+				conn.Close()
+				return nil, err
+			}
+			continue
+		}
+		return &agentSigner{agent: client, key: k, pub: pub, conn: conn}, nil
+	}
+	conn.Close()
+
+	if selector == "" {
+		return nil, fmt.Errorf("ssh-agent holds no Ed25519 or ECDSA P-256 key")
+	}
+	return nil, fmt.Errorf("key %q not found in ssh-agent", selector)
+}
+
+// ParseAuthorizedKey parses a public key in OpenSSH authorized_keys format,
+// e.g. "ssh-ed25519 AAAA... alice@example.com".
+func ParseAuthorizedKey(data []byte) (crypto.PublicKey, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return sshCryptoPublicKey(key)
+}
+
+// payloadSigner is implemented by keys that hash the signed payload themselves
+// and therefore cannot sign a precomputed hash.
+type payloadSigner interface {
+	signPayload(payload []byte) ([]byte, error)
+}
+
+// agentSigner signs with a key held by ssh-agent.
+type agentSigner struct {
+	agent agent.Agent
+	key   ssh.PublicKey
+	pub   crypto.PublicKey
+	// conn is the connection to ssh-agent, if any
+	conn io.Closer
+}
+
+// Close closes the connection to ssh-agent.
+func (s *agentSigner) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+func (s *agentSigner) Public() crypto.PublicKey {
+	return s.pub
+}
+
+// Sign signs message with an Ed25519 key. ECDSA keys are signed through signPayload,
+// as ssh-agent hashes the data itself.
+func (s *agentSigner) Sign(_ io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if _, ok := s.pub.(ed25519.PublicKey); !ok || opts.HashFunc() != 0 {
+		return nil, fmt.Errorf("ssh-agent keys can only sign kubectl-mft signature payloads")
+	}
+	sig, err := s.agent.Sign(s.key, message)
+	if err != nil {
+		return nil, fmt.Errorf("ssh-agent failed to sign: %w", err)
+	}
+	return sig.Blob, nil
+}
+
+// signPayload produces the same signature as signing the payload with the equivalent local key.
+func (s *agentSigner) signPayload(payload []byte) ([]byte, error) {
+	if _, ok := s.pub.(ed25519.PublicKey); ok {
+		hash := sha256.Sum256(payload)
+		return s.Sign(nil, hash[:], crypto.Hash(0))
+	}
+
+	// ecdsa-sha2-nistp256 signs the SHA-256 hash of the data with an SSH encoded (r, s) pair
+	sig, err := s.agent.Sign(s.key, payload)
+	if err != nil {
+		return nil, fmt.Errorf("ssh-agent failed to sign: %w", err)
+	}
+	var rs struct {
+		R *big.Int
+		S *big.Int
+	}
+	if err := ssh.Unmarshal(sig.Blob, &rs); err != nil {
+		return nil, fmt.Errorf("failed to decode ssh-agent signature: %w", err)
+	}
+	return asn1.Marshal(rs)
+}
+
+// agentPublicKey returns the public key of an agent key usable for kubectl-mft signatures.
+func agentPublicKey(k *agent.Key) (crypto.PublicKey, error) {
+	key, err := ssh.ParsePublicKey(k.Marshal())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ssh-agent key %q: %w", k.Comment, err)
+	}
+	pub, err := sshCryptoPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if ec, ok := pub.(*ecdsa.PublicKey); ok && ec.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("ssh-agent key %q: only P-256 ECDSA keys are supported", k.Comment)
+	}
+	return pub, nil
+}
+
+func sshCryptoPublicKey(key ssh.PublicKey) (crypto.PublicKey, error) {
+	ck, ok := key.(ssh.CryptoPublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported SSH key type %s", key.Type())
+	}
+	switch pub := ck.CryptoPublicKey().(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey:
+		return pub, nil
+	default:
+		return nil, fmt.Errorf("unsupported SSH key type %s, only Ed25519 and ECDSA keys are supported", key.Type())
+	}
+}
+
+// expandHome expands a leading "~/" to the user's home directory.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
@@ -0,0 +1,201 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func writeSSHPrivateKey(t *testing.T, key crypto.PrivateKey, passphrase string) string {
+	t.Helper()
+	var block *pem.Block
+	var err error
+	if passphrase != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(key, "test", []byte(passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(key, "test")
+	}
+	if err != nil {
+		t.Fatalf("failed to marshal SSH private key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "id_test")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("failed to write SSH private key: %v", err)
+	}
+	return path
+}
+
+func TestSignAndVerifyWithSSHKeyFile(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	ecKey, _ := generateTestKeyPair(t)
+
+	tests := []struct {
+		name string
+		key  crypto.PrivateKey
+		pub  crypto.PublicKey
+	}{
+		{name: "ed25519", key: edKey, pub: edKey.Public()},
+		{name: "ecdsa", key: ecKey, pub: &ecKey.PublicKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layoutPath, tag := setupTestOCILayout(t)
+			path := writeSSHPrivateKey(t, tt.key, "")
+
+			signer, err := NewSignerFromRef(SSHKeyPrefix + path)
+			if err != nil {
+				t.Fatalf("NewSignerFromRef() unexpected error: %v", err)
+			}
+			ctx := context.Background()
+			if _, err := signer.Sign(ctx, layoutPath, tag); err != nil {
+				t.Fatalf("Sign() unexpected error: %v", err)
+			}
+			if err := NewVerifier([]crypto.PublicKey{tt.pub}).Verify(ctx, layoutPath, tag); err != nil {
+				t.Errorf("Verify() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadSSHPrivateKeyEncrypted(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	path := writeSSHPrivateKey(t, edKey, "secret")
+
+	t.Setenv("KUBECTL_MFT_SSH_PASSPHRASE", "")
+	if _, err := LoadSSHPrivateKey(path); err == nil {
+		t.Errorf("LoadSSHPrivateKey() expected error without passphrase")
+	}
+
+	t.Setenv("KUBECTL_MFT_SSH_PASSPHRASE", "secret")
+	if _, err := LoadSSHPrivateKey(path); err != nil {
+		t.Errorf("LoadSSHPrivateKey() unexpected error: %v", err)
+	}
+}
+
+func TestAgentSignerSignPayload(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	keyring := agent.NewKeyring()
+	for _, k := range []crypto.PrivateKey{edKey, ecKey} {
+		if err := keyring.Add(agent.AddedKey{PrivateKey: k}); err != nil {
+			t.Fatalf("failed to add key to agent: %v", err)
+		}
+	}
+	keys, err := keyring.List()
+	if err != nil {
+		t.Fatalf("failed to list agent keys: %v", err)
+	}
+
+	payload := pae(PayloadType, []byte(`{"version":1}`))
+	for _, k := range keys {
+		t.Run(k.Type(), func(t *testing.T) {
+			pub, err := agentPublicKey(k)
+			if err != nil {
+				t.Fatalf("agentPublicKey() unexpected error: %v", err)
+			}
+			sig, err := signPayload(&agentSigner{agent: keyring, key: k, pub: pub}, payload)
+			if err != nil {
+				t.Fatalf("signPayload() unexpected error: %v", err)
+			}
+			if !verifySignature(pub, payload, sig) {
+				t.Errorf("verifySignature() = false, want true")
+			}
+		})
+	}
+}
+
+func TestSignerCloseClosesAgentConnection(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: edKey}); err != nil {
+		t.Fatalf("failed to add key to agent: %v", err)
+	}
+
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets are not available: %v", err)
+	}
+	defer l.Close()
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// ServeAgent returns once the client closes the connection
+		_ = agent.ServeAgent(keyring, conn)
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+
+	key, err := LoadSSHAgentKey("")
+	if err != nil {
+		t.Fatalf("LoadSSHAgentKey() unexpected error: %v", err)
+	}
+	signer := NewSigner(key)
+	if err := signer.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+	<-served
+}
+
+func TestImportSSHPublicKey(t *testing.T) {
+	cleanup := setupTestKeyDir(t)
+	defer cleanup()
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("failed to convert key: %v", err)
+	}
+	srcPath := filepath.Join(t.TempDir(), "id_ed25519.pub")
+	if err := os.WriteFile(srcPath, ssh.MarshalAuthorizedKey(sshPub), 0o644); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	if err := ImportPublicKey(srcPath, ""); err != nil {
+		t.Fatalf("ImportPublicKey() unexpected error: %v", err)
+	}
+	keys, err := LoadAllPublicKeys()
+	if err != nil {
+		t.Fatalf("LoadAllPublicKeys() unexpected error: %v", err)
+	}
+	if len(keys) != 1 || !pub.Equal(keys[0]) {
+		t.Errorf("LoadAllPublicKeys() = %v, want the imported SSH key", keys)
+	}
+}
@@ -0,0 +1,402 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"mime"
+	"net/http"
+	"os"
+	"time"
+)
+
+// TimestampMediaType is the media type of the RFC 3161 timestamp token stored next to a signature.
+const TimestampMediaType = "application/vnd.kubectl-mft.timestamp.v1+der"
+
+// maxTimestampResponseSize limits the size of a response read from a timestamping authority.
+const maxTimestampResponseSize = 1 << 20
+
+var (
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidRSA           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSA         = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+)
+
+// Timestamp is the time at which a timestamping authority attested that a signature existed.
+type Timestamp struct {
+	Time time.Time `json:"time"`
+	// Authority is the subject of the timestamping authority's certificate
+	Authority string `json:"authority"`
+}
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	Nonce          *big.Int
+	CertReq        bool `asn1:"optional,default:false"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional,utf8"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type encapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time     `asn1:"generalized"`
+	Accuracy       accuracy      `asn1:"optional"`
+	Ordering       bool          `asn1:"optional,default:false"`
+	Nonce          *big.Int      `asn1:"optional"`
+	TSA            asn1.RawValue `asn1:"optional,tag:0"`
+	Extensions     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// RequestTimestamp obtains an RFC 3161 timestamp token for sig from the timestamping authority at url,
+// sending the request with client, or http.DefaultClient if nil.
+func RequestTimestamp(ctx context.Context, client *http.Client, url string, sig []byte) ([]byte, error) {
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+	hash := crypto.SHA256.New()
+	hash.Write(sig)
+	req, err := asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			HashedMessage: hash.Sum(nil),
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/timestamp-query")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request timestamp from %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to request timestamp from %s: %s", url, resp.Status)
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err != nil || mediaType != "application/timestamp-reply" {
+		return nil, fmt.Errorf("failed to request timestamp from %s: unexpected content type %q", url, resp.Header.Get("Content-Type"))
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTimestampResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read timestamp response: %w", err)
+	}
+
+	var tsResp timeStampResp
+	if _, err := asn1.Unmarshal(body, &tsResp); err != nil {
+		return nil, fmt.Errorf("failed to parse timestamp response: %w", err)
+	}
+	// 0 is granted, 1 is grantedWithMods
+	if tsResp.Status.Status > 1 {
+		return nil, fmt.Errorf("timestamping authority rejected the request with status %d %v", tsResp.Status.Status, tsResp.Status.StatusString)
+	}
+	token := tsResp.TimeStampToken.FullBytes
+	if len(token) == 0 {
+		return nil, fmt.Errorf("timestamp response holds no token")
+	}
+	info, _, err := parseTimestampToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 {
+		return nil, fmt.Errorf("timestamp response does not match the request nonce")
+	}
+	if err := checkImprint(info.MessageImprint, sig); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// VerifyTimestamp verifies that token is a valid RFC 3161 timestamp of sig, issued by a timestamping
+// authority whose certificate chains to roots and was valid at the time of the timestamp.
+// The system roots are used if roots is nil.
+func VerifyTimestamp(token, sig []byte, roots *x509.CertPool) (*Timestamp, error) {
+	info, sd, err := parseTimestampToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkImprint(info.MessageImprint, sig); err != nil {
+		return nil, err
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, fmt.Errorf("timestamp token has %d signers, expected 1", len(sd.SignerInfos))
+	}
+	si := sd.SignerInfos[0]
+
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timestamp certificates: %w", err)
+	}
+	cert, err := signerCertificate(si, certs)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSignerInfo(si, cert, sd.EncapContentInfo.EContent); err != nil {
+		return nil, err
+	}
+
+	if roots == nil {
+		if roots, err = x509.SystemCertPool(); err != nil {
+			return nil, fmt.Errorf("failed to load system certificates: %w", err)
+		}
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range certs {
+		intermediates.AddCert(c)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   info.GenTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}); err != nil {
+		return nil, fmt.Errorf("timestamping authority is not trusted: %w", err)
+	}
+	return &Timestamp{Time: info.GenTime, Authority: cert.Subject.String()}, nil
+}
+
+// LoadCertPool reads the PEM encoded certificates in the file at path.
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificates: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// parseTimestampToken parses the CMS SignedData of a timestamp token and its TSTInfo content.
+func parseTimestampToken(token []byte) (*tstInfo, *signedData, error) {
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(token, &ci); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse timestamp token: %w", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, nil, fmt.Errorf("timestamp token is not signed data")
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse timestamp token: %w", err)
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return nil, nil, fmt.Errorf("timestamp token does not hold timestamp info")
+	}
+	var info tstInfo
+	if _, err := asn1.Unmarshal(sd.EncapContentInfo.EContent, &info); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse timestamp info: %w", err)
+	}
+	return &info, &sd, nil
+}
+
+// checkImprint checks that the message imprint of a timestamp is the hash of sig.
+func checkImprint(imprint messageImprint, sig []byte) error {
+	hash, err := hashFor(imprint.HashAlgorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+	h := hash.New()
+	h.Write(sig)
+	if !bytes.Equal(h.Sum(nil), imprint.HashedMessage) {
+		return fmt.Errorf("timestamp does not cover the signature")
+	}
+	return nil
+}
+
+// signerCertificate returns the certificate among certs that si identifies.
+func signerCertificate(si signerInfo, certs []*x509.Certificate) (*x509.Certificate, error) {
+	for _, c := range certs {
+		if si.SID.Class == asn1.ClassContextSpecific {
+			if bytes.Equal(si.SID.Bytes, c.SubjectKeyId) {
+				return c, nil
+			}
+			continue
+		}
+		var ias issuerAndSerial
+		if _, err := asn1.Unmarshal(si.SID.FullBytes, &ias); err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp signer: %w", err)
+		}
+		if bytes.Equal(ias.Issuer.FullBytes, c.RawIssuer) && ias.Serial.Cmp(c.SerialNumber) == 0 {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("timestamp token does not include the certificate of its signer")
+}
+
+// checkSignerInfo checks that the signed attributes of si cover content and are signed by cert.
+func checkSignerInfo(si signerInfo, cert *x509.Certificate, content []byte) error {
+	if len(si.SignedAttrs.FullBytes) == 0 {
+		return fmt.Errorf("timestamp token has no signed attributes")
+	}
+	hash, err := hashFor(si.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	// The signature covers the attributes encoded as a SET rather than with their implicit tag
+	signed := bytes.Clone(si.SignedAttrs.FullBytes)
+	signed[0] = 0x31
+	var attrs []attribute
+	if _, err := asn1.UnmarshalWithParams(signed, &attrs, "set"); err != nil {
+		return fmt.Errorf("failed to parse timestamp signed attributes: %w", err)
+	}
+	var messageDigest []byte
+	for _, a := range attrs {
+		if a.Type.Equal(oidMessageDigest) {
+			if _, err := asn1.Unmarshal(a.Values.Bytes, &messageDigest); err != nil {
+				return fmt.Errorf("failed to parse timestamp message digest: %w", err)
+			}
+		}
+	}
+	h := hash.New()
+	h.Write(content)
+	if messageDigest == nil || !bytes.Equal(messageDigest, h.Sum(nil)) {
+		return fmt.Errorf("timestamp signature does not cover the timestamp info")
+	}
+
+	algo, err := signatureAlgorithm(si.SignatureAlgorithm.Algorithm, hash)
+	if err != nil {
+		return err
+	}
+	if err := cert.CheckSignature(algo, signed, si.Signature); err != nil {
+		return fmt.Errorf("invalid timestamp signature: %w", err)
+	}
+	return nil
+}
+
+// hashFor returns the hash function identified by oid.
+func hashFor(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, nil
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, nil
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("unsupported timestamp hash algorithm %s", oid)
+	}
+}
+
+// signatureAlgorithm returns the x509 signature algorithm for a CMS signature algorithm, which
+// either names the public key algorithm alone or together with the hash.
+func signatureAlgorithm(oid asn1.ObjectIdentifier, hash crypto.Hash) (x509.SignatureAlgorithm, error) {
+	rsa := map[crypto.Hash]x509.SignatureAlgorithm{crypto.SHA256: x509.SHA256WithRSA, crypto.SHA384: x509.SHA384WithRSA, crypto.SHA512: x509.SHA512WithRSA}
+	ecdsa := map[crypto.Hash]x509.SignatureAlgorithm{crypto.SHA256: x509.ECDSAWithSHA256, crypto.SHA384: x509.ECDSAWithSHA384, crypto.SHA512: x509.ECDSAWithSHA512}
+	switch {
+	case oid.Equal(oidRSA):
+		return rsa[hash], nil
+	case oid.Equal(oidECDSA):
+		return ecdsa[hash], nil
+	}
+	for _, algo := range []x509.SignatureAlgorithm{
+		x509.SHA256WithRSA, x509.SHA384WithRSA, x509.SHA512WithRSA,
+		x509.ECDSAWithSHA256, x509.ECDSAWithSHA384, x509.ECDSAWithSHA512,
+	} {
+		if signatureAlgorithmOIDs[algo].Equal(oid) {
+			return algo, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported timestamp signature algorithm %s", oid)
+}
+
+var signatureAlgorithmOIDs = map[x509.SignatureAlgorithm]asn1.ObjectIdentifier{
+	x509.SHA256WithRSA:   {1, 2, 840, 113549, 1, 1, 11},
+	x509.SHA384WithRSA:   {1, 2, 840, 113549, 1, 1, 12},
+	x509.SHA512WithRSA:   {1, 2, 840, 113549, 1, 1, 13},
+	x509.ECDSAWithSHA256: {1, 2, 840, 10045, 4, 3, 2},
+	x509.ECDSAWithSHA384: {1, 2, 840, 10045, 4, 3, 3},
+	x509.ECDSAWithSHA512: {1, 2, 840, 10045, 4, 3, 4},
+}
@@ -0,0 +1,292 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTSA is a timestamping authority issuing tokens signed by a certificate of its own root.
+type testTSA struct {
+	roots *x509.CertPool
+	cert  *x509.Certificate
+	key   *ecdsa.PrivateKey
+	// genTime is the time put into tokens
+	genTime time.Time
+}
+
+func newTestTSA(t *testing.T) *testTSA {
+	t.Helper()
+	now := time.Now()
+	rootKey, _ := generateTestKeyPair(t)
+	rootTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTmpl, rootTmpl, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, _ := x509.ParseCertificate(rootDER)
+
+	tsaKey, _ := generateTestKeyPair(t)
+	tsaTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test TSA"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}
+	tsaDER, err := x509.CreateCertificate(rand.Reader, tsaTmpl, root, &tsaKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(tsaDER)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	return &testTSA{roots: roots, cert: cert, key: tsaKey, genTime: now.UTC().Truncate(time.Second)}
+}
+
+// token creates a timestamp token for the given imprint and nonce.
+func (a *testTSA) token(t *testing.T, imprint messageImprint, nonce *big.Int) []byte {
+	t.Helper()
+	info, err := asn1.Marshal(tstInfo{
+		Version:        1,
+		Policy:         asn1.ObjectIdentifier{1, 2, 3},
+		MessageImprint: imprint,
+		SerialNumber:   big.NewInt(42),
+		GenTime:        a.genTime,
+		Nonce:          nonce,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	infoDigest := sha256.Sum256(info)
+	contentTypeValue, _ := asn1.Marshal(oidTSTInfo)
+	digestValue, _ := asn1.Marshal(infoDigest[:])
+	attrs, err := asn1.MarshalWithParams([]attribute{
+		{Type: oidContentType, Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: contentTypeValue}},
+		{Type: oidMessageDigest, Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: digestValue}},
+	}, "set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrsDigest := sha256.Sum256(attrs)
+	sig, err := a.key.Sign(rand.Reader, attrsDigest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedAttrs := bytes.Clone(attrs)
+	signedAttrs[0] = 0xa0
+
+	sid, _ := asn1.Marshal(issuerAndSerial{Issuer: asn1.RawValue{FullBytes: a.cert.RawIssuer}, Serial: a.cert.SerialNumber})
+	sha256Algo := pkix.AlgorithmIdentifier{Algorithm: oidSHA256}
+	sd, err := asn1.Marshal(signedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256Algo},
+		EncapContentInfo: encapContentInfo{EContentType: oidTSTInfo, EContent: info},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: a.cert.Raw},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: sid},
+			DigestAlgorithm:    sha256Algo,
+			SignedAttrs:        asn1.RawValue{FullBytes: signedAttrs},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: signatureAlgorithmOIDs[x509.ECDSAWithSHA256]},
+			Signature:          sig,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// serve starts an HTTP server answering timestamp requests.
+func (a *testTSA) serve(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req timeStampReq
+		if _, err := asn1.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp, err := asn1.Marshal(timeStampResp{
+			Status:         pkiStatusInfo{Status: 0},
+			TimeStampToken: asn1.RawValue{FullBytes: a.token(t, req.MessageImprint, req.Nonce)},
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		_, _ = w.Write(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestRequestAndVerifyTimestamp(t *testing.T) {
+	tsa := newTestTSA(t)
+	sig := []byte("signature")
+
+	token, err := RequestTimestamp(context.Background(), nil, tsa.serve(t), sig)
+	if err != nil {
+		t.Fatalf("RequestTimestamp failed: %v", err)
+	}
+
+	ts, err := VerifyTimestamp(token, sig, tsa.roots)
+	if err != nil {
+		t.Fatalf("VerifyTimestamp failed: %v", err)
+	}
+	if !ts.Time.Equal(tsa.genTime) {
+		t.Errorf("Time = %v, want %v", ts.Time, tsa.genTime)
+	}
+	if ts.Authority != "CN=Test TSA" {
+		t.Errorf("Authority = %q, want %q", ts.Authority, "CN=Test TSA")
+	}
+
+	if _, err := VerifyTimestamp(token, []byte("other"), tsa.roots); err == nil {
+		t.Error("VerifyTimestamp should fail for another signature")
+	}
+	if _, err := VerifyTimestamp(token, sig, x509.NewCertPool()); err == nil {
+		t.Error("VerifyTimestamp should fail without the TSA root")
+	}
+
+	// The TSA certificate was not valid at the time of the timestamp
+	tsa.genTime = tsa.genTime.Add(-2 * time.Hour)
+	hash := sha256.Sum256(sig)
+	expired := tsa.token(t, messageImprint{HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256}, HashedMessage: hash[:]}, big.NewInt(1))
+	if _, err := VerifyTimestamp(expired, sig, tsa.roots); err == nil {
+		t.Error("VerifyTimestamp should fail for a certificate not valid at the time of the timestamp")
+	}
+}
+
+func TestSignAndVerify_Timestamp(t *testing.T) {
+	layoutPath, tag := setupTestOCILayout(t)
+	privKey, pubKey := generateTestKeyPair(t)
+	tsa := newTestTSA(t)
+	ctx := context.Background()
+
+	signer := NewSigner(privKey).WithTimestampAuthority(tsa.serve(t), nil)
+	if _, err := signer.Sign(ctx, layoutPath, tag); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	verifier := NewVerifier([]crypto.PublicKey{pubKey}).WithTimestampRoots(tsa.roots)
+	if err := verifier.Verify(ctx, layoutPath, tag); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	timestamps, err := verifier.Timestamps(ctx, layoutPath, tag)
+	if err != nil {
+		t.Fatalf("Timestamps failed: %v", err)
+	}
+	if len(timestamps) != 1 || !timestamps[0].Time.Equal(tsa.genTime) {
+		t.Errorf("Timestamps = %v, want one at %v", timestamps, tsa.genTime)
+	}
+
+	// A timestamp from an untrusted authority invalidates the signature
+	untrusted := NewVerifier([]crypto.PublicKey{pubKey}).WithTimestampRoots(x509.NewCertPool())
+	if err := untrusted.Verify(ctx, layoutPath, tag); err == nil {
+		t.Error("Verify should fail with an untrusted timestamping authority")
+	}
+
+	sig, err := signer.SignDetached(ctx, layoutPath, tag)
+	if err != nil {
+		t.Fatalf("SignDetached failed: %v", err)
+	}
+	if sig.Timestamp == nil {
+		t.Fatal("expected a timestamp in the detached signature")
+	}
+	ts, err := verifier.VerifyDetached(ctx, layoutPath, tag, sig)
+	if err != nil {
+		t.Fatalf("VerifyDetached failed: %v", err)
+	}
+	if ts == nil || !ts.Time.Equal(tsa.genTime) {
+		t.Errorf("VerifyDetached timestamp = %v, want %v", ts, tsa.genTime)
+	}
+}
+
+func TestRequestTimestamp_ContentType(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html>captive portal</html>"))
+	}))
+	t.Cleanup(srv.Close)
+
+	_, err := RequestTimestamp(context.Background(), srv.Client(), srv.URL, []byte("signature"))
+	if err == nil || !strings.Contains(err.Error(), "unexpected content type") {
+		t.Errorf("RequestTimestamp() error = %v, want content type error", err)
+	}
+}
+
+func TestVerify_RetiredKey(t *testing.T) {
+	layoutPath, tag := setupTestOCILayout(t)
+	privKey, pubKey := generateTestKeyPair(t)
+	_, otherKey := generateTestKeyPair(t)
+	tsa := newTestTSA(t)
+	ctx := context.Background()
+
+	if _, err := NewSigner(privKey).WithTimestampAuthority(tsa.serve(t), nil).Sign(ctx, layoutPath, tag); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		until   time.Time
+		roots   *x509.CertPool
+		wantErr string
+	}{
+		{name: "retired after the timestamp", until: tsa.genTime.Add(time.Minute), roots: tsa.roots},
+		{name: "retired before the timestamp", until: tsa.genTime.Add(-time.Minute), roots: tsa.roots, wantErr: "was retired"},
+		{name: "untrusted timestamp", until: tsa.genTime.Add(time.Minute), roots: x509.NewCertPool(), wantErr: "was retired"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := NewVerifier([]crypto.PublicKey{otherKey}).
+				WithRetiredKeys([]RetiredKey{{PublicKey: pubKey, Until: tt.until}}).
+				WithTimestampRoots(tt.roots)
+			err := verifier.Verify(ctx, layoutPath, tag)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Verify() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Verify() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
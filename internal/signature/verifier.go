@@ -7,21 +7,35 @@ import (
 	"context"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/opencontainers/go-digest"
 	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/content/oci"
 )
 
 // Verifier performs verification on local OCI layouts.
 type Verifier struct {
 	publicKeys []crypto.PublicKey
+	// retired keys only verify signatures with a trusted timestamp from before their retirement
+	retired []RetiredKey
+	// gpg enables verification of PGP signatures against the local GPG keyring
+	gpg bool
+	// cache skips verification of digests verified recently with the same keys
+	cache *VerifyCache
+	// timestampRoots are the trusted roots of timestamping authorities, the system roots if nil
+	timestampRoots *x509.CertPool
 }
 
 // NewVerifier creates a new Verifier with the given public keys.
@@ -31,7 +45,47 @@ func NewVerifier(publicKeys []crypto.PublicKey) *Verifier {
 	}
 }
 
+// WithGPG returns a copy of the Verifier that also verifies PGP signatures against the local GPG keyring.
+func (v *Verifier) WithGPG() *Verifier {
+	c := *v
+	c.gpg = true
+	return &c
+}
+
+// RetiredKey is a public key that was rotated out. Signatures it made remain valid if a trusted
+// timestamp proves that they were made before the key was retired.
+type RetiredKey struct {
+	PublicKey crypto.PublicKey
+	// Until is the time the key was retired
+	Until time.Time
+}
+
+// WithRetiredKeys returns a copy of the Verifier that also trusts the retired keys for signatures
+// timestamped before their retirement.
+func (v *Verifier) WithRetiredKeys(keys []RetiredKey) *Verifier {
+	c := *v
+	c.retired = keys
+	return &c
+}
+
+// WithCache returns a copy of the Verifier that records successful verifications in cache
+// and trusts unexpired results from it.
+func (v *Verifier) WithCache(cache *VerifyCache) *Verifier {
+	c := *v
+	c.cache = cache
+	return &c
+}
+
+// WithTimestampRoots returns a copy of the Verifier that trusts timestamping authorities
+// chaining to roots instead of the system roots.
+func (v *Verifier) WithTimestampRoots(roots *x509.CertPool) *Verifier {
+	c := *v
+	c.timestampRoots = roots
+	return &c
+}
+
 // NewVerifierFromKeyDir creates a Verifier by loading all public keys from the key directory.
+// PGP signatures are not trusted unless enabled with WithGPG.
 func NewVerifierFromKeyDir() (*Verifier, error) {
 	pubKeys, err := LoadAllPublicKeys()
 	if err != nil {
@@ -40,9 +94,180 @@ func NewVerifierFromKeyDir() (*Verifier, error) {
 	return NewVerifier(pubKeys), nil
 }
 
+// NewVerifierFromKeyNames creates a Verifier trusting only the named public keys of the key directory.
+func NewVerifierFromKeyNames(names []string) (*Verifier, error) {
+	pubKeys := make([]crypto.PublicKey, 0, len(names))
+	for _, name := range names {
+		pub, err := LoadPublicKey(name)
+		if err != nil {
+			return nil, err
+		}
+		pubKeys = append(pubKeys, pub)
+	}
+	return NewVerifier(pubKeys), nil
+}
+
+// VerifyBlob verifies a signature created by Signer.SignBlob over data.
+func (v *Verifier) VerifyBlob(ctx context.Context, data, sig []byte, mediaType string) error {
+	return v.verifyDigest(ctx, digest.FromBytes(data), sig, mediaType)
+}
+
+// verifyDigest verifies a legacy signature of the given media type over the digest d.
+func (v *Verifier) verifyDigest(ctx context.Context, d digest.Digest, sig []byte, mediaType string) error {
+	_, err := v.verifyLegacy(ctx, d, sig, mediaType, time.Time{})
+	return err
+}
+
+// errNoTrustedKey is returned when no trusted key verifies a signature
+var errNoTrustedKey = errors.New("none of the trusted keys verifies the signature")
+
+// errGPGUntrusted is returned for PGP signatures when verification against the local GPG
+// keyring is not enabled
+var errGPGUntrusted = errors.New("PGP signatures are not trusted, verification against the local GPG keyring is not enabled")
+
+// verifyLegacy verifies a signature of the given media type over the digest d, as created
+// before signing payloads were introduced, and returns the fingerprint of the verifying key.
+// signedAt is the trusted timestamp of the signature, zero if it has none.
+func (v *Verifier) verifyLegacy(ctx context.Context, d digest.Digest, sig []byte, mediaType string, signedAt time.Time) (string, error) {
+	switch mediaType {
+	case SignatureGPGMediaType:
+		if !v.gpg {
+			return "", errGPGUntrusted
+		}
+		return gpgVerify(ctx, []byte(d.String()), sig, signedAt)
+	case SignatureMediaType:
+		return v.verifyWithKeys(ctx, []byte(d.String()), sig, signedAt)
+	default:
+		return "", fmt.Errorf("unsupported signature media type %q", mediaType)
+	}
+}
+
+// verifyManifestSignature verifies a signature of the manifest desc verified under tag and
+// returns the fingerprint of the verifying key. Envelope signatures must also have been
+// created for the tag, while legacy signatures cover the manifest digest only. signedAt is
+// the trusted timestamp of the signature, which lets expired and retired keys verify it.
+func (v *Verifier) verifyManifestSignature(ctx context.Context, desc v1.Descriptor, tag string, sig *signatureBlob, signedAt time.Time) (string, error) {
+	if sig.mediaType != SignatureEnvelopeMediaType {
+		return v.verifyLegacy(ctx, desc.Digest, sig.data, sig.mediaType, signedAt)
+	}
+
+	env, payload, err := parseEnvelope(sig.data)
+	if err != nil {
+		return "", err
+	}
+	if err := checkPayload(payload, desc, tag); err != nil {
+		return "", err
+	}
+	msg := pae(env.PayloadType, env.Payload)
+	fingerprints := make([]string, len(env.Signatures))
+	i, errs := firstMatch(ctx, len(env.Signatures), func(ctx context.Context, i int) error {
+		s := env.Signatures[i]
+		var err error
+		switch {
+		case !strings.HasPrefix(s.KeyID, gpgKeyIDPrefix):
+			fingerprints[i], err = v.verifyWithKeys(ctx, msg, s.Sig, signedAt)
+		case !v.gpg:
+			err = errGPGUntrusted
+		default:
+			fingerprints[i], err = gpgVerify(ctx, msg, s.Sig, signedAt)
+		}
+		return err
+	})
+	if i >= 0 {
+		return fingerprints[i], nil
+	}
+
+	var failures []error
+	untrusted := false
+	for _, err := range errs {
+		switch {
+		case errors.Is(err, errGPGUntrusted):
+			untrusted = true
+		case !errors.Is(err, errNoTrustedKey):
+			failures = append(failures, err)
+		}
+	}
+	switch {
+	case len(failures) > 0:
+		return "", errors.Join(failures...)
+	case untrusted:
+		return "", errGPGUntrusted
+	default:
+		return "", errNoTrustedKey
+	}
+}
+
+// verifyWithKeys verifies sig over payload with the trusted public keys and returns the
+// fingerprint of the verifying key, the first in order if several verify. Retired keys are
+// tried last, and only verify if signedAt is before their retirement.
+func (v *Verifier) verifyWithKeys(ctx context.Context, payload, sig []byte, signedAt time.Time) (string, error) {
+	i, _ := firstMatch(ctx, len(v.publicKeys), func(ctx context.Context, i int) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !verifySignature(v.publicKeys[i], payload, sig) {
+			return errNoTrustedKey
+		}
+		return nil
+	})
+	if i < 0 {
+		return v.verifyWithRetiredKeys(payload, sig, signedAt)
+	}
+	return Fingerprint(v.publicKeys[i])
+}
+
+// verifyWithRetiredKeys verifies sig over payload with the retired keys and returns the
+// fingerprint of the verifying key if signedAt is before its retirement.
+func (v *Verifier) verifyWithRetiredKeys(payload, sig []byte, signedAt time.Time) (string, error) {
+	for _, k := range v.retired {
+		if !verifySignature(k.PublicKey, payload, sig) {
+			continue
+		}
+		fingerprint, err := Fingerprint(k.PublicKey)
+		if err != nil {
+			return "", err
+		}
+		if signedAt.IsZero() || !signedAt.Before(k.Until) {
+			return "", fmt.Errorf("signing key %s was retired at %s and the signature has no trusted timestamp from before",
+				fingerprint, k.Until.Format(time.RFC3339))
+		}
+		return fingerprint, nil
+	}
+	return "", errNoTrustedKey
+}
+
+// verifyDated verifies sig of the manifest desc verified under tag and its timestamp, and
+// returns the fingerprint of the verifying key and the timestamp, nil if sig has none. A
+// valid timestamp lets keys that expired or were retired since verify the signature.
+// An invalid timestamp is reported as a timestampError once the signature verifies.
+func (v *Verifier) verifyDated(ctx context.Context, desc v1.Descriptor, tag string, sig *signatureBlob) (string, *Timestamp, error) {
+	ts, tsErr := v.verifyTimestamp(sig)
+	var signedAt time.Time
+	if tsErr == nil && ts != nil {
+		signedAt = ts.Time
+	}
+	fingerprint, err := v.verifyManifestSignature(ctx, desc, tag, sig, signedAt)
+	if err != nil {
+		return "", nil, err
+	}
+	if tsErr != nil {
+		return "", nil, &timestampError{err: tsErr}
+	}
+	return fingerprint, ts, nil
+}
+
+// timestampError is returned for a signature that verifies but carries an invalid timestamp
+type timestampError struct {
+	err error
+}
+
+func (e *timestampError) Error() string {
+	return e.err.Error()
+}
+
 // Verify verifies the manifest identified by tag in the OCI layout at layoutPath.
 func (v *Verifier) Verify(ctx context.Context, layoutPath, tag string) error {
-	if len(v.publicKeys) == 0 {
+	if len(v.publicKeys) == 0 && len(v.retired) == 0 && !v.gpg {
 		return fmt.Errorf("no public keys available for verification")
 	}
 
@@ -57,14 +282,22 @@ func (v *Verifier) Verify(ctx context.Context, layoutPath, tag string) error {
 		return fmt.Errorf("failed to resolve tag %q: %w", tag, err)
 	}
 
+	var cacheKey string
+	if v.cache != nil {
+		cacheKey = v.cacheKey(desc.Digest, tag)
+		if v.cache.verified(cacheKey) {
+			return nil
+		}
+	}
+
 	// Find signature artifacts via predecessors (referrers)
 	predecessors, err := store.Predecessors(ctx, desc)
 	if err != nil {
 		return fmt.Errorf("failed to get predecessors: %w", err)
 	}
 
-	// Try to verify with any signature and any public key
-	var extractErrs []string
+	var extractErrs, sigErrs, timestampErrs []string
+	var sigs []*signatureBlob
 	foundSignature := false
 	for _, p := range predecessors {
 		sig, isSignature, err := tryExtractSignature(ctx, store, p)
@@ -76,40 +309,177 @@ func (v *Verifier) Verify(ctx context.Context, layoutPath, tag string) error {
 			extractErrs = append(extractErrs, err.Error())
 			continue
 		}
-
-		for _, pubKey := range v.publicKeys {
-			if verifySignature(pubKey, desc.Digest, sig) {
-				return nil
-			}
-		}
+		sigs = append(sigs, sig)
 	}
-
 	if !foundSignature {
 		return fmt.Errorf("no signature found for %q", tag)
 	}
 
+	// Try the signatures concurrently, any verifying signature with a valid timestamp
+	// suffices. Failures are reported in the order of the signatures.
+	i, errs := firstMatch(ctx, len(sigs), func(ctx context.Context, i int) error {
+		_, _, err := v.verifyDated(ctx, desc, tag, sigs[i])
+		return err
+	})
+	if i >= 0 {
+		return v.verified(cacheKey)
+	}
+	for _, err := range errs {
+		var tsErr *timestampError
+		switch {
+		case errors.As(err, &tsErr):
+			timestampErrs = append(timestampErrs, err.Error())
+		case !errors.Is(err, errNoTrustedKey) && !errors.Is(err, errGPGUntrusted):
+			sigErrs = append(sigErrs, err.Error())
+		}
+	}
+
 	msg := fmt.Sprintf("signature verification failed for %q: none of the available public keys could verify the signature", tag)
+	if len(sigErrs) > 0 {
+		msg += fmt.Sprintf("; %d signature(s) failed: %s", len(sigErrs), strings.Join(sigErrs, "; "))
+	}
+	if len(timestampErrs) > 0 {
+		msg += fmt.Sprintf("; %d signature(s) had an invalid timestamp: %s", len(timestampErrs), strings.Join(timestampErrs, "; "))
+	}
 	if len(extractErrs) > 0 {
 		msg += fmt.Sprintf("; additionally, %d signature(s) could not be read: %s", len(extractErrs), strings.Join(extractErrs, "; "))
 	}
 	return errors.New(msg)
 }
 
-// verifySignature verifies an ECDSA signature against a digest.
-func verifySignature(pubKey crypto.PublicKey, d digest.Digest, sig []byte) bool {
-	ecdsaKey, ok := pubKey.(*ecdsa.PublicKey)
-	if !ok {
+// Timestamps returns the timestamps of the signatures of the manifest identified by tag that verify
+// with the trusted keys and carry a valid timestamp. The verification cache is not used.
+func (v *Verifier) Timestamps(ctx context.Context, layoutPath, tag string) ([]Timestamp, error) {
+	store, err := oci.New(layoutPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OCI layout: %w", err)
+	}
+	desc, err := store.Resolve(ctx, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve tag %q: %w", tag, err)
+	}
+	predecessors, err := store.Predecessors(ctx, desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get predecessors: %w", err)
+	}
+
+	var timestamps []Timestamp
+	for _, p := range predecessors {
+		sig, isSignature, err := tryExtractSignature(ctx, store, p)
+		if !isSignature || err != nil || sig.timestamp == nil {
+			continue
+		}
+		_, ts, err := v.verifyDated(ctx, desc, tag, sig)
+		if err != nil {
+			continue
+		}
+		timestamps = append(timestamps, *ts)
+	}
+	return timestamps, nil
+}
+
+// SignerFingerprints returns the fingerprints of the trusted public keys that verify a signature
+// of the manifest identified by tag, sorted and without duplicates. PGP signatures are not
+// considered. The verification cache is not used.
+func (v *Verifier) SignerFingerprints(ctx context.Context, layoutPath, tag string) ([]string, error) {
+	store, err := oci.New(layoutPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OCI layout: %w", err)
+	}
+	desc, err := store.Resolve(ctx, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve tag %q: %w", tag, err)
+	}
+	predecessors, err := store.Predecessors(ctx, desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get predecessors: %w", err)
+	}
+
+	var fingerprints []string
+	for _, p := range predecessors {
+		sig, isSignature, err := tryExtractSignature(ctx, store, p)
+		if !isSignature || err != nil || sig.mediaType == SignatureGPGMediaType {
+			continue
+		}
+		if sig.mediaType == SignatureEnvelopeMediaType {
+			env, payload, err := parseEnvelope(sig.data)
+			if err != nil || checkPayload(payload, desc, tag) != nil {
+				continue
+			}
+			for _, s := range env.Signatures {
+				fingerprints = v.appendSigners(fingerprints, pae(env.PayloadType, env.Payload), s.Sig)
+			}
+			continue
+		}
+		fingerprints = v.appendSigners(fingerprints, []byte(desc.Digest.String()), sig.data)
+	}
+	slices.Sort(fingerprints)
+	return fingerprints, nil
+}
+
+// appendSigners appends the fingerprints of the trusted keys verifying sig over payload that
+// are not in fingerprints yet.
+func (v *Verifier) appendSigners(fingerprints []string, payload, sig []byte) []string {
+	for _, pubKey := range v.publicKeys {
+		if !verifySignature(pubKey, payload, sig) {
+			continue
+		}
+		if fingerprint, err := Fingerprint(pubKey); err == nil && !slices.Contains(fingerprints, fingerprint) {
+			fingerprints = append(fingerprints, fingerprint)
+		}
+	}
+	return fingerprints
+}
+
+// verifyTimestamp verifies the timestamp token of sig. It returns nil if sig has no timestamp.
+func (v *Verifier) verifyTimestamp(sig *signatureBlob) (*Timestamp, error) {
+	if sig.timestamp == nil {
+		return nil, nil
+	}
+	return VerifyTimestamp(sig.timestamp, sig.data, v.timestampRoots)
+}
+
+// verified records a successful verification in the cache. Failing to write the cache
+// does not fail the verification.
+func (v *Verifier) verified(cacheKey string) error {
+	if v.cache != nil {
+		_ = v.cache.record(cacheKey)
+	}
+	return nil
+}
+
+// verifySignature verifies an ECDSA, Ed25519, or RSA PKCS #1 v1.5 signature over the SHA-256
+// hash of payload.
+func verifySignature(pubKey crypto.PublicKey, payload, sig []byte) bool {
+	hash := sha256.Sum256(payload)
+	switch key := pubKey.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(key, hash[:], sig)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, hash[:], sig)
+	case *rsa.PublicKey:
+		return key.N.BitLen() >= minRSABits && rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig) == nil
+	default:
 		return false
 	}
-	hash := sha256.Sum256([]byte(d.String()))
-	return ecdsa.VerifyASN1(ecdsaKey, hash[:], sig)
+}
+
+// signatureBlob is a signature extracted from a signature artifact.
+type signatureBlob struct {
+	// manifest is the descriptor of the signature manifest
+	manifest    v1.Descriptor
+	annotations map[string]string
+	mediaType   string
+	data        []byte
+	// timestamp is the RFC 3161 timestamp token of data, if any
+	timestamp []byte
 }
 
 // tryExtractSignature attempts to extract a signature from a predecessor descriptor.
 // Returns (signature, true, nil) if the descriptor is a signature artifact and extraction succeeded.
 // Returns (nil, true, err) if it's a signature artifact but extraction failed.
 // Returns (nil, false, nil) if the descriptor is not a signature artifact.
-func tryExtractSignature(ctx context.Context, store *oci.Store, desc v1.Descriptor) ([]byte, bool, error) {
+func tryExtractSignature(ctx context.Context, store *oci.Store, desc v1.Descriptor) (*signatureBlob, bool, error) {
 	isSignature := desc.ArtifactType == SignatureArtifactType
 
 	if !isSignature && desc.MediaType != v1.MediaTypeImageManifest {
@@ -165,5 +535,20 @@ func tryExtractSignature(ctx context.Context, store *oci.Store, desc v1.Descript
 	if err != nil {
 		return nil, true, err
 	}
-	return sig, true, nil
+	blob := &signatureBlob{
+		manifest:    desc,
+		annotations: manifest.Annotations,
+		mediaType:   manifest.Layers[0].MediaType,
+		data:        sig,
+	}
+
+	for _, layer := range manifest.Layers[1:] {
+		if layer.MediaType != TimestampMediaType {
+			continue
+		}
+		if blob.timestamp, err = content.FetchAll(ctx, store, layer); err != nil {
+			return nil, true, fmt.Errorf("failed to fetch timestamp blob: %w", err)
+		}
+	}
+	return blob, true, nil
 }
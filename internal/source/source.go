@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+// Package source downloads manifests to pack from remote locations.
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+const (
+	// AnnotationURL records the location a packed manifest was downloaded from
+	AnnotationURL = "io.kubectl-mft.source.url"
+	// AnnotationDigest records the digest of the downloaded content
+	AnnotationDigest = "io.kubectl-mft.source.digest"
+	// AnnotationPinnedDigest records the digest the content was required to match when packed
+	AnnotationPinnedDigest = "io.kubectl-mft.source.pinned-digest"
+	// AnnotationDerivedFrom records the artifact, as <reference>@<digest>, a packed manifest was
+	// derived from by editing its content
+	AnnotationDerivedFrom = "io.kubectl-mft.source.derived-from"
+	// AnnotationMergedFrom records the artifacts, as comma-separated <reference>@<digest>, a
+	// packed manifest was combined from
+	AnnotationMergedFrom = "io.kubectl-mft.source.merged-from"
+	// AnnotationPatchType records the type of the patch a derived manifest was created with
+	AnnotationPatchType = "io.kubectl-mft.source.patch-type"
+	// AnnotationPatchDigest records the digest of the patch file a derived manifest was created with
+	AnnotationPatchDigest = "io.kubectl-mft.source.patch-digest"
+
+	// ProvenancePrefix is the prefix of the annotations above, which only kubectl-mft sets
+	ProvenancePrefix = "io.kubectl-mft.source."
+
+	// MaxSize is the largest manifest downloaded
+	MaxSize = 64 << 20
+
+	ociScheme = "oci://"
+)
+
+// allowHTTP is set by SetAllowHTTP
+var allowHTTP bool
+
+// SetAllowHTTP allows downloads over plain http://, which are refused by default because
+// the content could be tampered with in transit.
+func SetAllowHTTP(allow bool) {
+	allowHTTP = allow
+}
+
+// Source is a manifest downloaded from a remote location.
+type Source struct {
+	// URL is the location the manifest was downloaded from. For OCI artifacts, the manifest
+	// digest is appended, so that the recorded URL identifies the exact artifact.
+	URL  string
+	Data []byte
+}
+
+// IsRemote reports whether path is a URL to download rather than a local file:
+// an http(s):// URL or an oci:// reference to an artifact. Fetch only downloads http://
+// URLs if SetAllowHTTP allowed them.
+func IsRemote(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, ociScheme)
+}
+
+// Fetch downloads the manifest at url. oci:// references are read from the registry with the
+// kubectl-mft credentials; the YAML files of artifacts not created by kubectl-mft are concatenated.
+func Fetch(ctx context.Context, url string) (*Source, error) {
+	if ref, ok := strings.CutPrefix(url, ociScheme); ok {
+		return fetchOCI(ctx, ref)
+	}
+	return fetchHTTP(ctx, url)
+}
+
+func fetchHTTP(ctx context.Context, url string) (*Source, error) {
+	if !allowHTTP && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("refusing to download %s over plain HTTP, use an https:// URL", url)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", url, err)
+	}
+	// The proxy, CA file, and headers configured for the host apply to downloads as well
+	client, err := oci.NewHTTPClient(req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !allowHTTP && req.URL.Scheme != "https" {
+			return fmt.Errorf("refusing to follow the redirect to %s over plain HTTP", req.URL)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if len(data) > MaxSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", url, MaxSize)
+	}
+	return &Source{URL: url, Data: data}, nil
+}
+
+func fetchOCI(ctx context.Context, ref string) (*Source, error) {
+	r, err := oci.NewRepository(ref)
+	if err != nil {
+		return nil, err
+	}
+	data, manifestDigest, err := r.ReadRemote(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// A reference by digest already identifies the artifact
+	url := ociScheme + ref
+	if !strings.Contains(ref, "@") {
+		url += "@" + manifestDigest
+	}
+	return &Source{URL: url, Data: data}, nil
+}
+
+// Digest returns the digest of the downloaded content.
+func (s *Source) Digest() digest.Digest {
+	return digest.FromBytes(s.Data)
+}
+
+// Annotations returns the manifest annotations recording the source URL and the content digest.
+// The URL is recorded without its credentials, see redactURL.
+func (s *Source) Annotations() map[string]string {
+	return map[string]string{
+		AnnotationURL:    redactURL(s.URL),
+		AnnotationDigest: s.Digest().String(),
+	}
+}
+
+// redactURL removes the user information and the query of an HTTP URL, which can hold
+// credentials such as a token or the signature of a presigned object storage URL, since
+// annotations are pushed with the artifact.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return raw
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	return u.String()
+}
+
+// ParseSHA256 parses a SHA-256 digest given as sha256:<hex> or as bare hex.
+func ParseSHA256(s string) (digest.Digest, error) {
+	d := digest.Digest(s)
+	if !strings.Contains(s, ":") {
+		d = digest.NewDigestFromEncoded(digest.SHA256, s)
+	}
+	if err := d.Validate(); err != nil || d.Algorithm() != digest.SHA256 {
+		return "", fmt.Errorf("invalid SHA-256 digest %q", s)
+	}
+	return d, nil
+}
+
+// CheckDigest returns an error if the digest of data is not expected.
+func CheckDigest(data []byte, expected digest.Digest) error {
+	if actual := digest.FromBytes(data); actual != expected {
+		return fmt.Errorf("content digest %s does not match the expected digest %s", actual, expected)
+	}
+	return nil
+}
+
+// CheckPinned checks data against the digest pinned in the manifest annotations when it was packed
+// and returns the pinned digest, or an empty digest if none was pinned.
+func CheckPinned(annotations map[string]string, data []byte) (digest.Digest, error) {
+	pinned, ok := annotations[AnnotationPinnedDigest]
+	if !ok {
+		return "", nil
+	}
+	expected, err := digest.Parse(pinned)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s annotation %q: %w", AnnotationPinnedDigest, pinned, err)
+	}
+	return expected, CheckDigest(data, expected)
+}
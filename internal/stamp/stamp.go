@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+// Package stamp renders the annotation templates stamped on every packed manifest.
+package stamp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+)
+
+// Data is the data annotation templates are rendered with.
+type Data struct {
+	// Repository is the repository the manifest is packed into, such as "ghcr.io/org/app"
+	Repository string
+	// Tag is the tag the manifest is packed under
+	Tag string
+	// Git describes the Git checkout containing the manifest file
+	Git Git
+}
+
+// Git describes a Git checkout. Its fields are empty outside of a checkout.
+type Git struct {
+	Commit string
+	Branch string
+	// RemoteURL is the URL of the origin remote, without credentials
+	RemoteURL string
+}
+
+// Render renders the annotation templates. Templates call env to read environment variables
+// and required to fail when a value is empty. Annotations rendering to an empty value are
+// omitted.
+func Render(templates map[string]string, data Data) (map[string]string, error) {
+	annotations := make(map[string]string, len(templates))
+	for key, text := range templates {
+		tmpl, err := template.New(key).Option("missingkey=error").Funcs(funcs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s: %w", key, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("annotation %s: %w", key, err)
+		}
+		if value := strings.TrimSpace(buf.String()); value != "" {
+			annotations[key] = value
+		}
+	}
+	return annotations, nil
+}
+
+var funcs = template.FuncMap{
+	"env": os.Getenv,
+	"required": func(msg string, value string) (string, error) {
+		if value == "" {
+			return "", errors.New(msg)
+		}
+		return value, nil
+	},
+}
+
+// GitInfo describes the Git checkout containing dir. Outside of a checkout, or without git
+// installed, it returns empty fields.
+func GitInfo(ctx context.Context, dir string) Git {
+	var g Git
+	g.Commit = git(ctx, dir, "rev-parse", "HEAD")
+	if g.Commit == "" {
+		return g
+	}
+	if branch := git(ctx, dir, "rev-parse", "--abbrev-ref", "HEAD"); branch != "HEAD" {
+		g.Branch = branch
+	}
+	g.RemoteURL = redact(git(ctx, dir, "config", "--get", "remote.origin.url"))
+	return g
+}
+
+// redact removes the credentials of an HTTP remote URL, such as the token of a CI checkout.
+func redact(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil || u.User == nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return remote
+	}
+	u.User = nil
+	return u.String()
+}
+
+// git runs git in dir and returns its trimmed standard output, or "" if it fails.
+func git(ctx context.Context, dir string, args ...string) string {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,182 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package transform
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+// namespaceMutator sets the namespace of namespaced resources.
+type namespaceMutator string
+
+func (ns namespaceMutator) Mutate(obj *yaml.Node) error {
+	if kind := lookup(obj, "kind"); kind != nil && manifest.ClusterScopedKind(kind.Value) {
+		return nil
+	}
+	metadata, err := ensureMapping(obj, "metadata")
+	if err != nil {
+		return err
+	}
+	setString(metadata, "namespace", string(ns))
+	return nil
+}
+
+// metadataMutator adds labels or annotations to the metadata of resources, replacing existing values.
+type metadataMutator struct {
+	field  string
+	values map[string]string
+}
+
+func (m *metadataMutator) Mutate(obj *yaml.Node) error {
+	metadata, err := ensureMapping(obj, "metadata")
+	if err != nil {
+		return err
+	}
+	values, err := ensureMapping(metadata, m.field)
+	if err != nil {
+		return fmt.Errorf("metadata.%w", err)
+	}
+	for _, key := range slices.Sorted(maps.Keys(m.values)) {
+		setString(values, key, m.values[key])
+	}
+	return nil
+}
+
+// ImageRegistryMutator replaces the registry of container images. It maps registry hosts, such as
+// "docker.io", to their replacement, which may include a repository prefix.
+type ImageRegistryMutator map[string]string
+
+func (m ImageRegistryMutator) Mutate(obj *yaml.Node) error {
+	return eachContainer(obj, func(c *yaml.Node) error {
+		if image := lookup(c, "image"); image != nil && image.Kind == yaml.ScalarNode {
+			image.Value = m.Rewrite(image.Value)
+		}
+		return nil
+	})
+}
+
+// Rewrite returns the image with its registry replaced, or the image unchanged if its registry
+// is not mapped. Images without a registry are on docker.io, for example "nginx" is rewritten to
+// "registry.internal/library/nginx" if docker.io is mapped to registry.internal.
+func (m ImageRegistryMutator) Rewrite(image string) string {
+	registry, rest := manifest.SplitImage(image)
+	if to, ok := m[registry]; ok {
+		return to + "/" + rest
+	}
+	return image
+}
+
+// resourcesMutator sets resource limits and requests on containers that do not set them.
+type resourcesMutator struct {
+	limits   map[string]string
+	requests map[string]string
+}
+
+func (m *resourcesMutator) Mutate(obj *yaml.Node) error {
+	return eachContainer(obj, func(c *yaml.Node) error {
+		resources, err := ensureMapping(c, "resources")
+		if err != nil {
+			return err
+		}
+		for _, field := range []string{"limits", "requests"} {
+			values := m.limits
+			if field == "requests" {
+				values = m.requests
+			}
+			if len(values) == 0 {
+				continue
+			}
+			set, err := ensureMapping(resources, field)
+			if err != nil {
+				return fmt.Errorf("resources.%w", err)
+			}
+			for _, name := range slices.Sorted(maps.Keys(values)) {
+				if lookup(set, name) == nil {
+					setString(set, name, values[name])
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// annotationLastApplied holds the previous configuration of resources applied with 'kubectl apply',
+// including the data of Secrets
+const annotationLastApplied = "kubectl.kubernetes.io/last-applied-configuration"
+
+// RedactSecretsMutator masks the values of Secrets, keeping their keys. Secrets in the items
+// of lists are masked as well. With a Key, each value is replaced with a truncated
+// HMAC-SHA256 of the decoded value, so equal values, and changed values across versions, can
+// still be recognized by whoever holds the key, while the values cannot be guessed from the
+// output. Without a Key, values are replaced with a fixed placeholder.
+type RedactSecretsMutator struct {
+	Key []byte
+}
+
+func (m RedactSecretsMutator) Mutate(obj *yaml.Node) error {
+	kind := lookup(obj, "kind")
+	if kind == nil {
+		return nil
+	}
+	// kind: List, as written by 'kubectl get -o yaml', and typed lists such as SecretList
+	if strings.HasSuffix(kind.Value, "List") {
+		items := lookup(obj, "items")
+		if items == nil || items.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for _, item := range items.Content {
+			if item.Kind != yaml.MappingNode {
+				continue
+			}
+			if err := m.Mutate(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if kind.Value != "Secret" {
+		return nil
+	}
+	for _, field := range []string{"data", "stringData"} {
+		values := lookup(obj, field)
+		if values == nil || values.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 1; i < len(values.Content); i += 2 {
+			value := []byte(values.Content[i].Value)
+			if field == "data" {
+				if decoded, err := base64.StdEncoding.DecodeString(values.Content[i].Value); err == nil {
+					value = decoded
+				}
+			}
+			*values.Content[i] = *stringNode(m.redacted(value))
+		}
+	}
+	if metadata := lookup(obj, "metadata"); metadata != nil {
+		if annotations := lookup(metadata, "annotations"); annotations != nil && lookup(annotations, annotationLastApplied) != nil {
+			setString(annotations, annotationLastApplied, "<redacted>")
+		}
+	}
+	return nil
+}
+
+// redacted returns the replacement of a redacted value.
+func (m RedactSecretsMutator) redacted(value []byte) string {
+	if len(m.Key) == 0 {
+		return "<redacted>"
+	}
+	mac := hmac.New(sha256.New, m.Key)
+	mac.Write(value)
+	return fmt.Sprintf("<redacted hmac-sha256:%x>", mac.Sum(nil)[:8])
+}
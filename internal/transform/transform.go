@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+// Package transform mutates the resources of a manifest before it is applied.
+package transform
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+// Mutator modifies a resource in place.
+type Mutator interface {
+	// Mutate modifies the mapping node at the root of a resource document
+	Mutate(obj *yaml.Node) error
+}
+
+// Pipeline is an ordered list of mutators.
+type Pipeline []Mutator
+
+// New creates the pipeline of a configured transform.
+func New(t config.Transform) (Pipeline, error) {
+	p := make(Pipeline, 0, len(t.Mutators))
+	for i, m := range t.Mutators {
+		mutator, err := newMutator(m)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: mutators[%d]: %w", t.Name, i, err)
+		}
+		p = append(p, mutator)
+	}
+	return p, nil
+}
+
+func newMutator(m config.Mutator) (Mutator, error) {
+	switch m.Type {
+	case "namespace":
+		if m.Namespace == "" {
+			return nil, fmt.Errorf("namespace is required")
+		}
+		return namespaceMutator(m.Namespace), nil
+	case "labels":
+		if len(m.Labels) == 0 {
+			return nil, fmt.Errorf("labels are required")
+		}
+		return &metadataMutator{field: "labels", values: m.Labels}, nil
+	case "annotations":
+		if len(m.Annotations) == 0 {
+			return nil, fmt.Errorf("annotations are required")
+		}
+		return &metadataMutator{field: "annotations", values: m.Annotations}, nil
+	case "image-registry":
+		if len(m.Registries) == 0 {
+			return nil, fmt.Errorf("registries are required")
+		}
+		return ImageRegistryMutator(m.Registries), nil
+	case "resources":
+		if len(m.Limits) == 0 && len(m.Requests) == 0 {
+			return nil, fmt.Errorf("limits or requests are required")
+		}
+		return &resourcesMutator{limits: m.Limits, requests: m.Requests}, nil
+	default:
+		return nil, fmt.Errorf("unsupported mutator type %q", m.Type)
+	}
+}
+
+// Apply runs the pipeline on every document and returns the mutated documents. Documents are
+// re-encoded with two-space indentation, comments are kept. Without mutators, docs are returned as is.
+func (p Pipeline) Apply(docs []*manifest.Document) ([]*manifest.Document, error) {
+	if len(p) == 0 {
+		return docs, nil
+	}
+	res := make([]*manifest.Document, len(docs))
+	for i, d := range docs {
+		var node yaml.Node
+		if err := yaml.Unmarshal(d.Raw, &node); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", d, err)
+		}
+		if len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s is not a YAML mapping", d)
+		}
+		for _, m := range p {
+			if err := m.Mutate(node.Content[0]); err != nil {
+				return nil, fmt.Errorf("failed to transform %s: %w", d, err)
+			}
+		}
+
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&node); err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", d, err)
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", d, err)
+		}
+		mutated, err := manifest.Split(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse transformed %s: %w", d, err)
+		}
+		if len(mutated) != 1 {
+			return nil, fmt.Errorf("transformed %s has %d documents, expected 1", d, len(mutated))
+		}
+		res[i] = mutated[0]
+	}
+	return res, nil
+}
+
+// lookup returns the value of key in the mapping node, or nil if it is not set.
+func lookup(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// ensureMapping returns the mapping value of key in the mapping node, adding it if it is not set
+// and replacing it if it is null.
+func ensureMapping(m *yaml.Node, key string) (*yaml.Node, error) {
+	v := lookup(m, key)
+	switch {
+	case v == nil:
+		v = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		m.Content = append(m.Content, stringNode(key), v)
+	case v.Tag == "!!null":
+		*v = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	case v.Kind != yaml.MappingNode:
+		return nil, fmt.Errorf("%s is not a mapping", key)
+	}
+	return v, nil
+}
+
+// setString sets key to a string in the mapping node.
+func setString(m *yaml.Node, key, value string) {
+	if v := lookup(m, key); v != nil {
+		*v = *stringNode(value)
+		return
+	}
+	m.Content = append(m.Content, stringNode(key), stringNode(value))
+}
+
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// containerFields are the fields of a pod spec that hold containers
+var containerFields = []string{"containers", "initContainers", "ephemeralContainers"}
+
+// eachContainer calls fn with every container of the pod specs nested in the node.
+func eachContainer(node *yaml.Node, fn func(c *yaml.Node) error) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for _, field := range containerFields {
+			containers := lookup(node, field)
+			if containers == nil || containers.Kind != yaml.SequenceNode {
+				continue
+			}
+			for _, c := range containers.Content {
+				if c.Kind != yaml.MappingNode {
+					continue
+				}
+				if err := fn(c); err != nil {
+					return err
+				}
+			}
+		}
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := eachContainer(node.Content[i], fn); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := eachContainer(child, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package transform
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+)
+
+const testManifest = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: app
+---
+# The application
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    app: app
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: nginx:1.25
+          resources:
+            limits:
+              cpu: "2"
+        - name: sidecar
+          image: ghcr.io/org/sidecar:v1
+`
+
+func TestPipelineApply(t *testing.T) {
+	p, err := New(config.Transform{Name: "staging", Mutators: []config.Mutator{
+		{Type: "namespace", Namespace: "staging"},
+		{Type: "labels", Labels: map[string]string{"env": "staging", "managed": "true"}},
+		{Type: "image-registry", Registries: map[string]string{"docker.io": "registry.internal/dockerhub"}},
+		{Type: "resources", Limits: map[string]string{"cpu": "500m", "memory": "256Mi"}},
+	}})
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	docs, err := manifest.Split([]byte(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.Apply(docs)
+	if err != nil {
+		t.Fatalf("Apply() unexpected error: %v", err)
+	}
+
+	want := `apiVersion: v1
+kind: Namespace
+metadata:
+  name: app
+  labels:
+    env: staging
+    managed: "true"
+---
+# The application
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    app: app
+    env: staging
+    managed: "true"
+  namespace: staging
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: registry.internal/dockerhub/library/nginx:1.25
+          resources:
+            limits:
+              cpu: "2"
+              memory: 256Mi
+        - name: sidecar
+          image: ghcr.io/org/sidecar:v1
+          resources:
+            limits:
+              cpu: 500m
+              memory: 256Mi
+`
+	if string(manifest.Join(got)) != want {
+		t.Errorf("Apply() =\n%s\nwant\n%s", manifest.Join(got), want)
+	}
+	if got[1].Namespace != "staging" || got[0].Namespace != "" {
+		t.Errorf("Apply() namespaces = %q, %q, want \"\", staging", got[0].Namespace, got[1].Namespace)
+	}
+}
+
+func TestPipelineApplyEmpty(t *testing.T) {
+	docs, err := manifest.Split([]byte(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Pipeline(nil).Apply(docs)
+	if err != nil {
+		t.Fatalf("Apply() unexpected error: %v", err)
+	}
+	if string(manifest.Join(got)) != string(manifest.Join(docs)) {
+		t.Errorf("Apply() without mutators changed the manifest")
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutator config.Mutator
+	}{
+		{name: "unknown type", mutator: config.Mutator{Type: "replicas"}},
+		{name: "namespace without namespace", mutator: config.Mutator{Type: "namespace"}},
+		{name: "labels without labels", mutator: config.Mutator{Type: "labels"}},
+		{name: "resources without values", mutator: config.Mutator{Type: "resources"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(config.Transform{Name: "t", Mutators: []config.Mutator{tt.mutator}}); err == nil {
+				t.Errorf("New() expected error")
+			}
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	m := ImageRegistryMutator{"docker.io": "registry.internal", "ghcr.io": "registry.internal/ghcr"}
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"nginx", "registry.internal/library/nginx"},
+		{"bitnami/redis:7", "registry.internal/bitnami/redis:7"},
+		{"ghcr.io/org/app@sha256:abc", "registry.internal/ghcr/org/app@sha256:abc"},
+		{"quay.io/org/app:v1", "quay.io/org/app:v1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := m.Rewrite(tt.image); got != tt.want {
+				t.Errorf("Rewrite(%q) = %q, want %q", tt.image, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	const secret = `apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: '{"data":{"password":"aHVudGVyMg=="}}'
+data:
+  password: aHVudGVyMg==
+stringData:
+  same: hunter2
+  other: s3cret
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  password: visible
+---
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: Secret
+    metadata:
+      name: nested
+    data:
+      token: aHVudGVyMg==
+`
+	docs, err := manifest.Split([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Pipeline{RedactSecretsMutator{Key: []byte("key")}}.Apply(docs)
+	if err != nil {
+		t.Fatalf("Apply() unexpected error: %v", err)
+	}
+
+	want := `apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: <redacted>
+data:
+  password: <redacted hmac-sha256:HASH1>
+stringData:
+  same: <redacted hmac-sha256:HASH1>
+  other: <redacted hmac-sha256:HASH2>
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  password: visible
+---
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: Secret
+    metadata:
+      name: nested
+    data:
+      token: <redacted hmac-sha256:HASH1>
+`
+	want = strings.NewReplacer("HASH1", hmacHex("key", "hunter2"), "HASH2", hmacHex("key", "s3cret")).Replace(want)
+	if string(manifest.Join(got)) != want {
+		t.Errorf("Apply() =\n%s\nwant\n%s", manifest.Join(got), want)
+	}
+
+	// Without a key, no hash of the values is printed
+	got, err = Pipeline{RedactSecretsMutator{}}.Apply(docs[:1])
+	if err != nil {
+		t.Fatalf("Apply() unexpected error: %v", err)
+	}
+	if out := string(manifest.Join(got)); strings.Contains(out, "hmac") || strings.Count(out, "<redacted>") != 4 {
+		t.Errorf("Apply() without a key =\n%s\nwant fixed placeholders", out)
+	}
+}
+
+// hmacHex returns the truncated HMAC-SHA256 of value printed by RedactSecretsMutator.
+func hmacHex(key, value string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
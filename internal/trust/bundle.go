@@ -0,0 +1,337 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package trust
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+)
+
+// bundleVersion is the version of the trust bundle format.
+const bundleVersion = 2
+
+// ErrNoRoot is returned by Open when no trust-root key is installed yet.
+var ErrNoRoot = errors.New("no trust-root key installed")
+
+// Bundle is an offline root of trust: the trusted public keys and the trust policy, signed by
+// a trust-root key. Importing it on an air-gapped machine reproduces the verification setup
+// of the machine that exported it.
+type Bundle struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	// Root is the trust-root key that signs the bundle. It is installed apart from the key
+	// directory, so it only verifies later bundles and never manifests.
+	Root   Key    `json:"root"`
+	Keys   []Key  `json:"keys"`
+	Policy Policy `json:"policy"`
+}
+
+// Key is a trusted public key of a bundle.
+type Key struct {
+	Name        string `json:"name"`
+	Fingerprint string `json:"fingerprint"`
+	// PublicKey is the PEM-encoded public key
+	PublicKey string `json:"publicKey"`
+}
+
+// installedRoot is the file format of the installed trust-root key.
+type installedRoot struct {
+	Root Key `json:"root"`
+	// Created is the creation time of the installed bundle; older bundles are rejected
+	Created time.Time `json:"created"`
+	// Keys are the names of the keys the installed bundle imported, which are removed when a
+	// later bundle drops them
+	Keys []string `json:"keys,omitempty"`
+}
+
+// signedBundle is the file format of a trust bundle: the JSON encoded bundle and its signature.
+type signedBundle struct {
+	Payload            []byte `json:"payload"`
+	Signature          []byte `json:"signature"`
+	SignatureMediaType string `json:"signatureMediaType"`
+}
+
+// NewBundle creates a bundle of the named public keys from the key directory and the policy,
+// to be signed by the trust-root key rootName. Every key referenced by the policy must be
+// included, and the trust-root key must not be one of the included keys.
+func NewBundle(rootName string, keyNames []string, policy Policy) (*Bundle, error) {
+	if len(keyNames) == 0 {
+		return nil, fmt.Errorf("a trust bundle requires at least one public key")
+	}
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("invalid trust policy: %w", err)
+	}
+
+	root, err := bundleKey(rootName)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{
+		Version: bundleVersion,
+		Created: time.Now().UTC(),
+		Root:    root,
+		Policy:  policy,
+	}
+	for _, name := range keyNames {
+		k, err := bundleKey(name)
+		if err != nil {
+			return nil, err
+		}
+		if k.Fingerprint == root.Fingerprint {
+			return nil, fmt.Errorf("trust-root key %q must not be included as a trusted key", rootName)
+		}
+		b.Keys = append(b.Keys, k)
+	}
+
+	for _, rule := range policy.Rules {
+		names := slices.Clone(rule.Keys)
+		for _, k := range rule.Retired {
+			names = append(names, k.Key)
+		}
+		for _, name := range names {
+			if !slices.Contains(keyNames, name) {
+				return nil, fmt.Errorf("trust policy rule %q references key %q, which is not included in the bundle", rule.Repository, name)
+			}
+		}
+	}
+	return b, nil
+}
+
+// bundleKey returns the named public key of the key directory.
+func bundleKey(name string) (Key, error) {
+	data, err := signature.ExportPublicKey(name)
+	if err != nil {
+		return Key{}, err
+	}
+	pub, err := signature.LoadPublicKey(name)
+	if err != nil {
+		return Key{}, err
+	}
+	fingerprint, err := signature.Fingerprint(pub)
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{Name: name, Fingerprint: fingerprint, PublicKey: string(data)}, nil
+}
+
+// Sign encodes the bundle and signs it with signer, which must hold the trust-root key.
+func (b *Bundle) Sign(ctx context.Context, signer *signature.Signer) ([]byte, error) {
+	payload, err := json.Marshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal trust bundle: %w", err)
+	}
+	sig, mediaType, err := signer.SignBlob(ctx, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign trust bundle: %w", err)
+	}
+	data, err := json.MarshalIndent(signedBundle{
+		Payload:            payload,
+		Signature:          sig,
+		SignatureMediaType: mediaType,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal trust bundle: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Open decodes a signed trust bundle whose signature verifies with the installed trust-root
+// key. The bundle must not be older than the installed one. It returns ErrNoRoot if no
+// trust-root key is installed.
+func Open(ctx context.Context, data []byte) (*Bundle, error) {
+	installed, err := loadRoot()
+	if err != nil {
+		return nil, err
+	}
+	if installed == nil {
+		return nil, ErrNoRoot
+	}
+	sb, b, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyRoot(ctx, sb, installed.Root); err != nil {
+		return nil, err
+	}
+	if err := installed.checkRollback(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// OpenWithFingerprint decodes a signed trust bundle that is signed by its trust-root key with
+// the given fingerprint. The fingerprint must be obtained out of band; it bootstraps trust on
+// machines that have no trust-root key yet. The bundle must not be older than the installed one.
+func OpenWithFingerprint(ctx context.Context, data []byte, fingerprint string) (*Bundle, error) {
+	sb, b, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if b.Root.Fingerprint != fingerprint {
+		return nil, fmt.Errorf("trust bundle is signed by trust-root key %s, not %s", b.Root.Fingerprint, fingerprint)
+	}
+	if err := verifyRoot(ctx, sb, b.Root); err != nil {
+		return nil, err
+	}
+	installed, err := loadRoot()
+	if err != nil {
+		return nil, err
+	}
+	if err := installed.checkRollback(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// verifyRoot verifies the signature of sb with the trust-root key root.
+func verifyRoot(ctx context.Context, sb *signedBundle, root Key) error {
+	pub, err := root.publicKey()
+	if err != nil {
+		return err
+	}
+	verifier := signature.NewVerifier([]crypto.PublicKey{pub})
+	if err := verifier.VerifyBlob(ctx, sb.Payload, sb.Signature, sb.SignatureMediaType); err != nil {
+		return fmt.Errorf("trust bundle is not signed by trust-root key %s: %w", root.Fingerprint, err)
+	}
+	return nil
+}
+
+// checkRollback rejects a bundle created before the installed one, so that a captured older
+// bundle cannot restore revoked keys or a looser policy. Nothing is rejected if r is nil.
+func (r *installedRoot) checkRollback(b *Bundle) error {
+	if r != nil && b.Created.Before(r.Created) {
+		return fmt.Errorf("trust bundle created %s is older than the installed bundle created %s",
+			b.Created.Format(time.RFC3339), r.Created.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// loadRoot reads the installed trust-root key, or returns nil if none is installed.
+func loadRoot() (*installedRoot, error) {
+	path, err := RootPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read trust-root key: %w", err)
+	}
+	var r installedRoot
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse trust-root key %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// decode decodes a signed trust bundle without verifying its signature.
+func decode(data []byte) (*signedBundle, *Bundle, error) {
+	var sb signedBundle
+	if err := json.Unmarshal(data, &sb); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse trust bundle: %w", err)
+	}
+	if len(sb.Payload) == 0 || len(sb.Signature) == 0 {
+		return nil, nil, fmt.Errorf("failed to parse trust bundle: payload and signature are required")
+	}
+	var b Bundle
+	if err := json.Unmarshal(sb.Payload, &b); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse trust bundle payload: %w", err)
+	}
+	if b.Version != bundleVersion {
+		return nil, nil, fmt.Errorf("unsupported trust bundle version %d", b.Version)
+	}
+	for _, k := range append([]Key{b.Root}, b.Keys...) {
+		pub, err := k.publicKey()
+		if err != nil {
+			return nil, nil, err
+		}
+		fingerprint, err := signature.Fingerprint(pub)
+		if err != nil {
+			return nil, nil, err
+		}
+		if fingerprint != k.Fingerprint {
+			return nil, nil, fmt.Errorf("trust bundle key %q does not match its fingerprint %s", k.Name, k.Fingerprint)
+		}
+		if k != b.Root && fingerprint == b.Root.Fingerprint {
+			return nil, nil, fmt.Errorf("trust bundle includes its trust-root key %q as a trusted key", k.Name)
+		}
+	}
+	if err := b.Policy.validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid trust policy in bundle: %w", err)
+	}
+	return &sb, &b, nil
+}
+
+func (k Key) publicKey() (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(k.PublicKey))
+	if block == nil {
+		return nil, fmt.Errorf("trust bundle key %q: failed to decode PEM block", k.Name)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("trust bundle key %q: %w", k.Name, err)
+	}
+	return pub, nil
+}
+
+// Install imports the keys of the bundle into the key directory, replacing keys of the same
+// name, replaces the installed trust policy with that of the bundle, and installs its
+// trust-root key apart from the key directory. Keys imported by the previously installed
+// bundle and dropped by this one are removed, so that revoking a key takes effect; other keys
+// in the key directory are kept.
+func (b *Bundle) Install() error {
+	prev, err := loadRoot()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(b.Keys))
+	for _, k := range b.Keys {
+		if err := signature.ImportPublicKeyData(k.Name, []byte(k.PublicKey)); err != nil {
+			return fmt.Errorf("failed to import key %q: %w", k.Name, err)
+		}
+		names = append(names, k.Name)
+	}
+	if prev != nil {
+		for _, name := range prev.Keys {
+			if slices.Contains(names, name) || !signature.PublicKeyExists(name) {
+				continue
+			}
+			if err := signature.DeletePublicKey(name); err != nil {
+				return fmt.Errorf("failed to remove key %q dropped from the trust bundle: %w", name, err)
+			}
+		}
+	}
+	if err := writePolicy(b.Policy); err != nil {
+		return err
+	}
+
+	path, err := RootPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(installedRoot{Root: b.Root, Created: b.Created, Keys: names}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal trust-root key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write trust-root key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package trust
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+)
+
+// setupTestDirs points the key and configuration directories to temporary directories.
+func setupTestDirs(t *testing.T) {
+	t.Helper()
+	t.Setenv("KUBECTL_MFT_KEY_DIR", t.TempDir())
+	t.Setenv("KUBECTL_MFT_CONFIG_DIR", t.TempDir())
+	if err := signature.InitKeyDir(); err != nil {
+		t.Fatalf("InitKeyDir failed: %v", err)
+	}
+}
+
+// exportTestBundle generates the trust-root key "root" and the keys "release" and "prod", and
+// returns a bundle of "release" and "prod" signed by "root", with the fingerprint of "root".
+func exportTestBundle(t *testing.T, policy Policy) ([]byte, string) {
+	t.Helper()
+	setupTestDirs(t)
+	for _, name := range []string{"root", "release", "prod"} {
+		if err := signature.GenerateKeyPair(name, false); err != nil {
+			t.Fatalf("GenerateKeyPair failed: %v", err)
+		}
+	}
+	return signTestBundle(t, "root", policy, time.Now())
+}
+
+// signTestBundle returns a bundle of "release" and "prod" created at created, signed by the
+// trust-root key root, with the fingerprint of root.
+func signTestBundle(t *testing.T, root string, policy Policy, created time.Time) ([]byte, string) {
+	t.Helper()
+	b, err := NewBundle(root, []string{"release", "prod"}, policy)
+	if err != nil {
+		t.Fatalf("NewBundle failed: %v", err)
+	}
+	b.Created = created.UTC()
+	signer, err := signature.NewSignerFromKeyDir(root)
+	if err != nil {
+		t.Fatalf("NewSignerFromKeyDir failed: %v", err)
+	}
+	data, err := b.Sign(context.Background(), signer)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	return data, b.Root.Fingerprint
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	policy := Policy{Rules: []Rule{{Repository: "registry.example.com/prod/*", Keys: []string{"prod"}}}}
+	data, fingerprint := exportTestBundle(t, policy)
+	ctx := context.Background()
+
+	// Import on a fresh machine without keys
+	setupTestDirs(t)
+	if _, err := Open(ctx, data); !errors.Is(err, ErrNoRoot) {
+		t.Errorf("Open without a trust root error = %v, want ErrNoRoot", err)
+	}
+	b, err := OpenWithFingerprint(ctx, data, fingerprint)
+	if err != nil {
+		t.Fatalf("OpenWithFingerprint failed: %v", err)
+	}
+	if err := b.Install(); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+
+	for _, name := range []string{"release", "prod"} {
+		if _, err := signature.LoadPublicKey(name); err != nil {
+			t.Errorf("public key %q not imported: %v", name, err)
+		}
+	}
+	// The trust-root key never verifies manifests
+	if _, err := signature.LoadPublicKey("root"); err == nil {
+		t.Error("trust-root key imported into the key directory")
+	}
+	installed, err := LoadPolicy()
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+	if keys, ok := installed.KeysFor("registry.example.com/prod/app"); !ok || len(keys) != 1 || keys[0] != "prod" {
+		t.Errorf("installed policy KeysFor = %v, %v", keys, ok)
+	}
+
+	// Once imported, the trust root verifies later bundles
+	if _, err := Open(ctx, data); err != nil {
+		t.Errorf("Open failed: %v", err)
+	}
+}
+
+func TestOpen_Rollback(t *testing.T) {
+	older, fingerprint := exportTestBundle(t, Policy{})
+	newer, _ := signTestBundle(t, "root", Policy{}, time.Now().Add(time.Hour))
+	ctx := context.Background()
+
+	b, err := OpenWithFingerprint(ctx, newer, fingerprint)
+	if err != nil {
+		t.Fatalf("OpenWithFingerprint failed: %v", err)
+	}
+	if err := b.Install(); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+
+	if _, err := Open(ctx, older); err == nil || !strings.Contains(err.Error(), "older than the installed bundle") {
+		t.Errorf("Open of an older bundle error = %v, want rollback error", err)
+	}
+	if _, err := OpenWithFingerprint(ctx, older, fingerprint); err == nil {
+		t.Error("OpenWithFingerprint should fail for a bundle older than the installed one")
+	}
+	if _, err := Open(ctx, newer); err != nil {
+		t.Errorf("Open of the installed bundle failed: %v", err)
+	}
+}
+
+func TestOpen_Untrusted(t *testing.T) {
+	data, fingerprint := exportTestBundle(t, Policy{})
+	ctx := context.Background()
+
+	// The fingerprint must belong to the trust-root key, not just any bundled key
+	var sb signedBundle
+	if err := json.Unmarshal(data, &sb); err != nil {
+		t.Fatal(err)
+	}
+	var bundle Bundle
+	if err := json.Unmarshal(sb.Payload, &bundle); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenWithFingerprint(ctx, data, bundle.Keys[0].Fingerprint); err == nil {
+		t.Error("OpenWithFingerprint should fail for a key that is not the trust root")
+	}
+	if _, err := OpenWithFingerprint(ctx, data, "sha256:0000"); err == nil {
+		t.Error("OpenWithFingerprint should fail for an unknown fingerprint")
+	}
+	if _, err := OpenWithFingerprint(ctx, data, fingerprint); err != nil {
+		t.Errorf("OpenWithFingerprint failed: %v", err)
+	}
+
+	// A bundle signed by another trust root is rejected
+	if err := signature.GenerateKeyPair("other", false); err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	other, otherFingerprint := signTestBundle(t, "other", Policy{}, time.Now())
+	b, err := OpenWithFingerprint(ctx, other, otherFingerprint)
+	if err != nil {
+		t.Fatalf("OpenWithFingerprint failed: %v", err)
+	}
+	if err := b.Install(); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if _, err := Open(ctx, data); err == nil {
+		t.Error("Open should fail for a bundle signed by another trust-root key")
+	}
+}
+
+func TestOpen_Tampered(t *testing.T) {
+	data, fingerprint := exportTestBundle(t, Policy{})
+
+	var sb signedBundle
+	if err := json.Unmarshal(data, &sb); err != nil {
+		t.Fatal(err)
+	}
+	var b Bundle
+	if err := json.Unmarshal(sb.Payload, &b); err != nil {
+		t.Fatal(err)
+	}
+	b.Policy.Rules = append(b.Policy.Rules, Rule{Repository: "*", Keys: []string{"prod"}})
+	payload, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sb.Payload = payload
+	tampered, err := json.Marshal(sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenWithFingerprint(context.Background(), tampered, fingerprint); err == nil {
+		t.Error("OpenWithFingerprint should fail for a modified bundle")
+	}
+}
+
+func TestNewBundle_RootIncluded(t *testing.T) {
+	setupTestDirs(t)
+	if err := signature.GenerateKeyPair("root", false); err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	if _, err := NewBundle("root", []string{"root"}, Policy{}); err == nil {
+		t.Error("NewBundle should fail when the trust-root key is included as a trusted key")
+	}
+}
+
+func TestNewBundle_PolicyKeyMissing(t *testing.T) {
+	setupTestDirs(t)
+	for _, name := range []string{"root", "release"} {
+		if err := signature.GenerateKeyPair(name, false); err != nil {
+			t.Fatalf("GenerateKeyPair failed: %v", err)
+		}
+	}
+
+	policy := Policy{Rules: []Rule{{Repository: "*", Keys: []string{"prod"}}}}
+	if _, err := NewBundle("root", []string{"release"}, policy); err == nil {
+		t.Error("NewBundle should fail when the policy references a key that is not bundled")
+	}
+}
+
+func TestInstall_RemovesStalePolicy(t *testing.T) {
+	setupTestDirs(t)
+	path, err := PolicyPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("rules:\n  - repository: '*'\n    keys: [old]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &Bundle{Version: bundleVersion}
+	if err := b.Install(); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected installed policy to be removed, got %v", err)
+	}
+}
+
+func TestInstall_RemovesDroppedKeys(t *testing.T) {
+	setupTestDirs(t)
+	for _, name := range []string{"root", "release", "prod"} {
+		if err := signature.GenerateKeyPair(name, false); err != nil {
+			t.Fatalf("GenerateKeyPair failed: %v", err)
+		}
+	}
+	prod, err := signature.LoadPublicKey("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := signature.Fingerprint(prod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1, fingerprint := signTestBundle(t, "root", Policy{}, time.Now())
+	b, err := NewBundle("root", []string{"release"}, Policy{})
+	if err != nil {
+		t.Fatalf("NewBundle failed: %v", err)
+	}
+	b.Created = time.Now().Add(time.Hour).UTC()
+	signer, err := signature.NewSignerFromKeyDir("root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := b.Sign(context.Background(), signer)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	// Import v1 on a fresh machine, then v2, which drops "prod"
+	setupTestDirs(t)
+	ctx := context.Background()
+	installed, err := OpenWithFingerprint(ctx, v1, fingerprint)
+	if err != nil {
+		t.Fatalf("OpenWithFingerprint failed: %v", err)
+	}
+	if err := installed.Install(); err != nil {
+		t.Fatalf("Install of v1 failed: %v", err)
+	}
+	if installed, err = Open(ctx, v2); err != nil {
+		t.Fatalf("Open of v2 failed: %v", err)
+	}
+	if err := installed.Install(); err != nil {
+		t.Fatalf("Install of v2 failed: %v", err)
+	}
+
+	if _, err := signature.LoadPublicKey("release"); err != nil {
+		t.Errorf("public key %q removed: %v", "release", err)
+	}
+	keys, err := signature.LoadAllPublicKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range keys {
+		if f, _ := signature.Fingerprint(k); f == revoked {
+			t.Error("the key dropped by v2 still verifies")
+		}
+	}
+}
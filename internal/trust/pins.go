@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package trust
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/paths"
+)
+
+const pinsFileName = "known-signers.yaml"
+
+// Pins records the fingerprints of the keys that signed the manifests of a repository when
+// it was first pulled in trust-on-first-use mode, like known_hosts does for SSH host keys.
+type Pins struct {
+	Pins []Pin `yaml:"pins,omitempty" json:"pins"`
+}
+
+// Pin is the set of keys a repository is pinned to.
+type Pin struct {
+	Repository string `yaml:"repository" json:"repository"`
+	// Fingerprints are fingerprints of public keys, see signature.Fingerprint
+	Fingerprints []string  `yaml:"fingerprints" json:"fingerprints"`
+	Pinned       time.Time `yaml:"pinned" json:"pinned"`
+}
+
+// PinMismatchError is returned when a manifest is signed by none of the keys its repository is pinned to.
+type PinMismatchError struct {
+	Pin          Pin
+	Fingerprints []string
+}
+
+func (e *PinMismatchError) Error() string {
+	signers := "no trusted key"
+	if len(e.Fingerprints) > 0 {
+		signers = strings.Join(e.Fingerprints, ", ")
+	}
+	return fmt.Sprintf("%s is pinned to key(s) %s since %s, but the manifest is signed by %s",
+		e.Pin.Repository, strings.Join(e.Pin.Fingerprints, ", "), e.Pin.Pinned.Format(time.RFC3339), signers)
+}
+
+// Lookup returns the pin of the repository, or nil if it is not pinned.
+func (p *Pins) Lookup(repository string) *Pin {
+	for i := range p.Pins {
+		if p.Pins[i].Repository == repository {
+			return &p.Pins[i]
+		}
+	}
+	return nil
+}
+
+// Check checks that the manifest of the repository signed by the keys with the given
+// fingerprints may be trusted. An unpinned repository is pinned to those keys, and pinned is
+// true. A pinned repository must be signed by at least one of its keys, otherwise a
+// *PinMismatchError is returned.
+func (p *Pins) Check(repository string, fingerprints []string) (pinned bool, err error) {
+	if pin := p.Lookup(repository); pin != nil {
+		for _, f := range fingerprints {
+			if slices.Contains(pin.Fingerprints, f) {
+				return false, nil
+			}
+		}
+		return false, &PinMismatchError{Pin: *pin, Fingerprints: fingerprints}
+	}
+	if len(fingerprints) == 0 {
+		return false, fmt.Errorf("cannot pin %s: the manifest is signed by no trusted key", repository)
+	}
+	p.Pins = append(p.Pins, Pin{
+		Repository:   repository,
+		Fingerprints: slices.Clone(fingerprints),
+		Pinned:       time.Now().UTC().Truncate(time.Second),
+	})
+	slices.SortFunc(p.Pins, func(a, b Pin) int { return strings.Compare(a.Repository, b.Repository) })
+	return true, nil
+}
+
+// Remove removes the pin of the repository and reports whether it was pinned.
+func (p *Pins) Remove(repository string) bool {
+	n := len(p.Pins)
+	p.Pins = slices.DeleteFunc(p.Pins, func(pin Pin) bool { return pin.Repository == repository })
+	return len(p.Pins) != n
+}
+
+// PinsPath returns the path of the recorded pins.
+func PinsPath() (string, error) {
+	dir, err := paths.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, pinsFileName), nil
+}
+
+// LoadPins reads the recorded pins. A missing file yields no pins.
+func LoadPins() (*Pins, error) {
+	path, err := PinsPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Pins{}, nil
+		}
+		return nil, fmt.Errorf("failed to read pinned signers: %w", err)
+	}
+	var pins Pins
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&pins); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid pinned signers %s: %w", path, err)
+	}
+	return &pins, nil
+}
+
+// SavePins writes the pins, removing the file if there are none.
+func SavePins(pins *Pins) error {
+	path, err := PinsPath()
+	if err != nil {
+		return err
+	}
+	if len(pins.Pins) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove pinned signers: %w", err)
+		}
+		return nil
+	}
+	data, err := yaml.Marshal(pins)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pinned signers: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write pinned signers: %w", err)
+	}
+	return nil
+}
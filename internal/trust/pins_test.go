@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package trust
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestPinsCheck(t *testing.T) {
+	const repo = "registry.example.com/team/app"
+	var pins Pins
+
+	if _, err := pins.Check(repo, nil); err == nil {
+		t.Error("Check() pinned a manifest signed by no trusted key")
+	}
+	pinned, err := pins.Check(repo, []string{"sha256:aaa"})
+	if err != nil || !pinned {
+		t.Fatalf("Check() on first use = %v, %v, want pinned", pinned, err)
+	}
+
+	tests := []struct {
+		name         string
+		fingerprints []string
+		wantErr      bool
+	}{
+		{name: "same key", fingerprints: []string{"sha256:aaa"}},
+		{name: "additional signature", fingerprints: []string{"sha256:aaa", "sha256:bbb"}},
+		{name: "different key", fingerprints: []string{"sha256:bbb"}, wantErr: true},
+		{name: "no trusted key", fingerprints: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pinned, err := pins.Check(repo, tt.fingerprints)
+			if pinned {
+				t.Error("Check() re-pinned a pinned repository")
+			}
+			var mismatch *PinMismatchError
+			if tt.wantErr != errors.As(err, &mismatch) {
+				t.Errorf("Check() error = %v, want mismatch %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if got := pins.Lookup(repo).Fingerprints; !slices.Equal(got, []string{"sha256:aaa"}) {
+		t.Errorf("pinned fingerprints = %v, want [sha256:aaa]", got)
+	}
+	if !pins.Remove(repo) || pins.Remove(repo) {
+		t.Error("Remove() should report the pin only once")
+	}
+	if pinned, err := pins.Check(repo, []string{"sha256:bbb"}); err != nil || !pinned {
+		t.Errorf("Check() after Remove() = %v, %v, want re-pinned", pinned, err)
+	}
+}
+
+func TestSaveAndLoadPins(t *testing.T) {
+	t.Setenv("KUBECTL_MFT_CONFIG_DIR", t.TempDir())
+
+	pins, err := LoadPins()
+	if err != nil || len(pins.Pins) != 0 {
+		t.Fatalf("LoadPins() without file = %v, %v, want none", pins, err)
+	}
+	for _, repo := range []string{"b", "a"} {
+		if _, err := pins.Check(repo, []string{"sha256:" + repo}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SavePins(pins); err != nil {
+		t.Fatalf("SavePins() failed: %v", err)
+	}
+	loaded, err := LoadPins()
+	if err != nil {
+		t.Fatalf("LoadPins() failed: %v", err)
+	}
+	if len(loaded.Pins) != 2 || loaded.Pins[0].Repository != "a" || !loaded.Pins[0].Pinned.Equal(pins.Pins[0].Pinned) {
+		t.Errorf("LoadPins() = %+v, want %+v", loaded.Pins, pins.Pins)
+	}
+
+	// Removing the last pin removes the file
+	loaded.Remove("a")
+	loaded.Remove("b")
+	if err := SavePins(loaded); err != nil {
+		t.Fatalf("SavePins() failed: %v", err)
+	}
+	path, err := PinsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("pins file not removed: %v", err)
+	}
+}
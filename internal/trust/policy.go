@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package trust
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/paths"
+)
+
+const (
+	policyFileName = "trust-policy.yaml"
+	rootFileName   = "trust-root.json"
+)
+
+// Policy restricts which trusted keys may sign the manifests of a repository.
+// Repositories not matched by any rule are verified against all keys in the key directory.
+type Policy struct {
+	// Rules are evaluated in order, the first matching rule wins
+	Rules []Rule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// Rule requires manifests of the repositories matching a pattern to be signed by one of the keys.
+type Rule struct {
+	// Repository is a repository name pattern, see config.MatchRepository
+	Repository string `yaml:"repository" json:"repository"`
+	// Keys are names of public keys in the key directory
+	Keys []string `yaml:"keys" json:"keys"`
+	// Retired are keys rotated out of Keys. Their signatures remain valid if a trusted
+	// timestamp proves they were made before the key was retired.
+	Retired []RetiredKey `yaml:"retired,omitempty" json:"retired,omitempty"`
+}
+
+// RetiredKey is a public key of the key directory that was retired at Until.
+type RetiredKey struct {
+	Key   string    `yaml:"key" json:"key"`
+	Until time.Time `yaml:"until" json:"until"`
+}
+
+// KeysFor returns the keys trusted for the repository by the first matching rule.
+// ok is false if no rule matches.
+func (p Policy) KeysFor(repository string) (keys []string, ok bool) {
+	if rule := p.RuleFor(repository); rule != nil {
+		return rule.Keys, true
+	}
+	return nil, false
+}
+
+// RuleFor returns the first rule matching the repository, or nil if no rule matches.
+func (p Policy) RuleFor(repository string) *Rule {
+	for i, rule := range p.Rules {
+		if config.MatchRepository(rule.Repository, repository) {
+			return &p.Rules[i]
+		}
+	}
+	return nil
+}
+
+// PolicyPath returns the path of the installed trust policy.
+func PolicyPath() (string, error) {
+	dir, err := paths.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, policyFileName), nil
+}
+
+// RootPath returns the path of the installed trust-root key.
+func RootPath() (string, error) {
+	dir, err := paths.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, rootFileName), nil
+}
+
+// LoadPolicy reads the installed trust policy. A missing policy yields an empty policy.
+func LoadPolicy() (*Policy, error) {
+	path, err := PolicyPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Policy{}, nil
+		}
+		return nil, fmt.Errorf("failed to read trust policy: %w", err)
+	}
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trust policy %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// ParsePolicy decodes a trust policy. Unknown fields are rejected to catch typos.
+func ParsePolicy(data []byte) (*Policy, error) {
+	var policy Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (p Policy) validate() error {
+	for i, rule := range p.Rules {
+		if rule.Repository == "" || len(rule.Keys) == 0 {
+			return fmt.Errorf("rules[%d]: repository and keys are required", i)
+		}
+		for j, k := range rule.Retired {
+			if k.Key == "" || k.Until.IsZero() {
+				return fmt.Errorf("rules[%d].retired[%d]: key and until are required", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// writePolicy installs the policy, removing the installed policy if it has no rules.
+func writePolicy(policy Policy) error {
+	path, err := PolicyPath()
+	if err != nil {
+		return err
+	}
+	if len(policy.Rules) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove trust policy: %w", err)
+		}
+		return nil
+	}
+	data, err := yaml.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal trust policy: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write trust policy: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package trust
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{name: "empty", data: "", want: 0},
+		{name: "rules", data: "rules:\n  - repository: registry.example.com/prod/*\n    keys: [prod]\n  - repository: '*'\n    keys: [dev, prod]\n", want: 2},
+		{name: "retired", data: "rules:\n  - repository: '*'\n    keys: [prod-2026]\n    retired:\n      - key: prod-2025\n        until: 2026-01-01T00:00:00Z\n", want: 1},
+		{name: "retired without until", data: "rules:\n  - repository: '*'\n    keys: [prod-2026]\n    retired:\n      - key: prod-2025\n", wantErr: true},
+		{name: "missing keys", data: "rules:\n  - repository: registry.example.com/*\n", wantErr: true},
+		{name: "missing repository", data: "rules:\n  - keys: [prod]\n", wantErr: true},
+		{name: "unknown field", data: "rulez: []\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParsePolicy([]byte(tt.data))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePolicy failed: %v", err)
+			}
+			if len(policy.Rules) != tt.want {
+				t.Errorf("got %d rules, want %d", len(policy.Rules), tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyKeysFor(t *testing.T) {
+	policy := Policy{Rules: []Rule{
+		{Repository: "registry.example.com/prod/*", Keys: []string{"prod"}},
+		{Repository: "registry.example.com/*", Keys: []string{"dev", "prod"}},
+	}}
+
+	tests := []struct {
+		repository string
+		want       []string
+		wantOK     bool
+	}{
+		{repository: "registry.example.com/prod/app", want: []string{"prod"}, wantOK: true},
+		{repository: "registry.example.com/staging/app", want: []string{"dev", "prod"}, wantOK: true},
+		{repository: "local/app", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.repository, func(t *testing.T) {
+			got, ok := policy.KeysFor(tt.repository)
+			if ok != tt.wantOK || !slices.Equal(got, tt.want) {
+				t.Errorf("KeysFor(%q) = %v, %v, want %v, %v", tt.repository, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLoadPolicy_Missing(t *testing.T) {
+	t.Setenv("KUBECTL_MFT_CONFIG_DIR", filepath.Join(t.TempDir(), "missing"))
+
+	policy, err := LoadPolicy()
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+	if len(policy.Rules) != 0 {
+		t.Errorf("expected empty policy, got %d rules", len(policy.Rules))
+	}
+}
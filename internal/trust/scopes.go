@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package trust
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/paths"
+)
+
+const scopesFileName = "key-scopes.yaml"
+
+// Scopes restricts public keys of the key directory to the repositories they were fetched
+// for with --fetch-keys, so that the key of one publisher does not verify the manifests of
+// others. Keys without a scope are trusted for every repository.
+type Scopes struct {
+	Scopes []Scope `yaml:"scopes,omitempty" json:"scopes"`
+}
+
+// Scope trusts a key for the manifests of one repository.
+type Scope struct {
+	// Key is the name of a public key in the key directory
+	Key        string `yaml:"key" json:"key"`
+	Repository string `yaml:"repository" json:"repository"`
+}
+
+// Add scopes the key to the repository, in addition to the repositories it is scoped to already.
+func (s *Scopes) Add(key, repository string) {
+	scope := Scope{Key: key, Repository: repository}
+	if slices.Contains(s.Scopes, scope) {
+		return
+	}
+	s.Scopes = append(s.Scopes, scope)
+	slices.SortFunc(s.Scopes, cmpScope)
+}
+
+// Remove removes the scopes of the key, e.g. after it was deleted, and reports whether it had any.
+func (s *Scopes) Remove(key string) bool {
+	n := len(s.Scopes)
+	s.Scopes = slices.DeleteFunc(s.Scopes, func(scope Scope) bool { return scope.Key == key })
+	return len(s.Scopes) != n
+}
+
+// Allows reports whether the key may verify the manifests of the repository, that is, whether
+// it is unscoped or scoped to the repository.
+func (s *Scopes) Allows(key, repository string) bool {
+	scoped := false
+	for _, scope := range s.Scopes {
+		if scope.Key != key {
+			continue
+		}
+		if scope.Repository == repository {
+			return true
+		}
+		scoped = true
+	}
+	return !scoped
+}
+
+func cmpScope(a, b Scope) int {
+	if c := strings.Compare(a.Key, b.Key); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Repository, b.Repository)
+}
+
+// ScopesPath returns the path of the recorded key scopes.
+func ScopesPath() (string, error) {
+	dir, err := paths.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, scopesFileName), nil
+}
+
+// LoadScopes reads the recorded key scopes. A missing file yields no scopes.
+func LoadScopes() (*Scopes, error) {
+	path, err := ScopesPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Scopes{}, nil
+		}
+		return nil, fmt.Errorf("failed to read key scopes: %w", err)
+	}
+	var scopes Scopes
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&scopes); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid key scopes %s: %w", path, err)
+	}
+	return &scopes, nil
+}
+
+// SaveScopes writes the key scopes, removing the file if there are none.
+func SaveScopes(scopes *Scopes) error {
+	path, err := ScopesPath()
+	if err != nil {
+		return err
+	}
+	if len(scopes.Scopes) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove key scopes: %w", err)
+		}
+		return nil
+	}
+	data, err := yaml.Marshal(scopes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal key scopes: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write key scopes: %w", err)
+	}
+	return nil
+}
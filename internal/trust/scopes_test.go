@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package trust
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestScopes(t *testing.T) {
+	t.Setenv("KUBECTL_MFT_CONFIG_DIR", t.TempDir())
+	var scopes Scopes
+	scopes.Add("team-a", "registry.example.com/team-a/app")
+	scopes.Add("team-a", "registry.example.com/team-a/app")
+	scopes.Add("team-a", "registry.example.com/team-a/db")
+
+	tests := []struct {
+		key, repository string
+		want            bool
+	}{
+		{key: "team-a", repository: "registry.example.com/team-a/app", want: true},
+		{key: "team-a", repository: "registry.example.com/team-a/db", want: true},
+		{key: "team-a", repository: "registry.example.com/team-b/app", want: false},
+		{key: "release", repository: "registry.example.com/team-b/app", want: true},
+	}
+	for _, tt := range tests {
+		if got := scopes.Allows(tt.key, tt.repository); got != tt.want {
+			t.Errorf("Allows(%s, %s) = %v, want %v", tt.key, tt.repository, got, tt.want)
+		}
+	}
+
+	if scopes.Remove("team-b") {
+		t.Error("Remove() of an unscoped key reported scopes")
+	}
+	if err := SaveScopes(&scopes); err != nil {
+		t.Fatalf("SaveScopes failed: %v", err)
+	}
+	loaded, err := LoadScopes()
+	if err != nil {
+		t.Fatalf("LoadScopes failed: %v", err)
+	}
+	if !slices.Equal(loaded.Scopes, scopes.Scopes) {
+		t.Errorf("LoadScopes() = %v, want %v", loaded.Scopes, scopes.Scopes)
+	}
+}
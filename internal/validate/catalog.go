@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package validate
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+	"github.com/chez-shanpu/kubectl-mft/internal/paths"
+)
+
+// catalogLayout is the layout of cached catalog schemas, the same as of registered schemas.
+const catalogLayout = "{{ .Group }}/{{ .ResourceKind }}_{{ .ResourceAPIVersion }}.json"
+
+// maxCatalogSchemaSize limits the size of a schema downloaded from a catalog.
+const maxCatalogSchemaSize = 8 << 20
+
+// defaultCatalog is the location of the schemas of built-in Kubernetes resources, as used by
+// kubeconform's "default" location. Its schemas are cached like those of catalogs, so that
+// offline validation still checks built-in resources.
+var defaultCatalog = "https://raw.githubusercontent.com/yannh/kubernetes-json-schema/master/{{ .NormalizedKubernetesVersion }}-standalone{{ .StrictSuffix }}/{{ .ResourceKind }}{{ .KindSuffix }}.json"
+
+// Path segments of resources must be safe to join into the cache directory
+var (
+	groupPattern   = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]*[a-z0-9])?$`)
+	versionPattern = regexp.MustCompile(`^[a-z0-9]+$`)
+	kindPattern    = regexp.MustCompile(`^[a-z0-9]+$`)
+)
+
+var catalogClient = &http.Client{Timeout: 30 * time.Second}
+
+// catalogResource is the template data of a schema location, as kubeconform renders it.
+type catalogResource struct {
+	NormalizedKubernetesVersion string
+	StrictSuffix                string
+	ResourceKind                string
+	ResourceAPIVersion          string
+	Group                       string
+	KindSuffix                  string
+	// builtin resources are looked up in the default catalog, the others in the configured ones
+	builtin bool
+}
+
+// syncCatalogs downloads the schemas of the built-in resources in the manifest from the default
+// catalog, and those of the custom resources from the catalogs, into the cache directory. It
+// returns the local schema location of the cached default catalog and those of the catalogs.
+// Offline, only schemas already in the cache are used. Catalogs that cannot be reached only
+// produce warnings, since resources without a schema are skipped during validation anyway.
+func syncCatalogs(manifestPath string, catalogs []string, offline bool) (string, []string, error) {
+	cacheDir, err := paths.CacheDir()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var resources []catalogResource
+	if data, err := os.ReadFile(manifestPath); err == nil {
+		resources = manifestResources(data)
+	}
+
+	locations := make([]string, 0, len(catalogs)+1)
+	for i, catalog := range append([]string{defaultCatalog}, catalogs...) {
+		dir := catalogCacheDir(cacheDir, catalog)
+		for _, res := range resources {
+			if res.builtin != (i == 0) {
+				continue
+			}
+			if err := fetchCatalogSchema(catalog, dir, res, offline); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			}
+		}
+		locations = append(locations, filepath.ToSlash(dir)+"/"+catalogLayout)
+	}
+	return locations[0], locations[1:], nil
+}
+
+// catalogCacheDir returns the cache directory of the catalog with the location template.
+func catalogCacheDir(cacheDir, catalog string) string {
+	sum := sha256.Sum256([]byte(catalog))
+	return filepath.Join(cacheDir, "schema-catalogs", hex.EncodeToString(sum[:8]))
+}
+
+// fetchCatalogSchema downloads the schema of res from the catalog into dir unless it is cached.
+func fetchCatalogSchema(catalog, dir string, res catalogResource, offline bool) error {
+	path, err := renderLocation(filepath.ToSlash(dir)+"/"+catalogLayout, res)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil || offline {
+		return nil
+	}
+
+	url, err := renderLocation(catalog, res)
+	if err != nil {
+		return fmt.Errorf("invalid schema catalog %q: %w", catalog, err)
+	}
+	resp, err := catalogClient.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed downloading schema at %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed downloading schema at %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCatalogSchemaSize))
+	if err != nil {
+		return fmt.Errorf("failed downloading schema at %s: %w", url, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create schema cache directory: %w", err)
+	}
+	// Write atomically, so that an interrupted download is not mistaken for a cached schema
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to cache schema: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// customResources returns the distinct resources of the manifest that are not built into
+// Kubernetes.
+func customResources(data []byte) []catalogResource {
+	var custom []catalogResource
+	for _, res := range manifestResources(data) {
+		if !res.builtin {
+			custom = append(custom, res)
+		}
+	}
+	return custom
+}
+
+// manifestResources returns the distinct resources of the manifest. Resources of the core group,
+// of API groups without a dot, and of *.k8s.io groups are built into Kubernetes. Resources whose
+// group, version, or kind are not valid names are skipped, as they cannot have a schema.
+func manifestResources(data []byte) []catalogResource {
+	docs, err := manifest.Split(data)
+	if err != nil {
+		return nil
+	}
+	seen := make(map[catalogResource]bool)
+	var resources []catalogResource
+	for _, d := range docs {
+		group, version, ok := strings.Cut(d.APIVersion, "/")
+		if !ok {
+			group, version = "", d.APIVersion
+		}
+		kind := strings.ToLower(d.Kind)
+		if (group != "" && (!groupPattern.MatchString(group) || strings.Contains(group, ".."))) ||
+			!versionPattern.MatchString(version) || !kindPattern.MatchString(kind) {
+			continue
+		}
+		// Like kubeconform, the kind suffix is "-<first group label>-<version>", and the group
+		// of the core group is its version
+		kindSuffix := "-" + version
+		if group != "" {
+			kindSuffix = "-" + strings.Split(group, ".")[0] + kindSuffix
+		}
+		res := catalogResource{
+			NormalizedKubernetesVersion: "master",
+			StrictSuffix:                "-strict",
+			ResourceKind:                kind,
+			ResourceAPIVersion:          version,
+			Group:                       cmp.Or(group, version),
+			KindSuffix:                  kindSuffix,
+			builtin:                     !strings.Contains(group, ".") || strings.HasSuffix(group, ".k8s.io"),
+		}
+		if !seen[res] {
+			seen[res] = true
+			resources = append(resources, res)
+		}
+	}
+	return resources
+}
+
+// renderLocation renders a schema location template for the resource.
+func renderLocation(location string, res catalogResource) (string, error) {
+	tmpl, err := template.New("location").Parse(location)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, res); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package validate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const testCustomResourceManifest = `apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+---
+apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: api
+`
+
+func TestCustomResources(t *testing.T) {
+	resources := customResources([]byte(testCustomResourceManifest))
+	if len(resources) != 1 {
+		t.Fatalf("expected 1 custom resource, got %+v", resources)
+	}
+	res := resources[0]
+	if res.Group != "cert-manager.io" || res.ResourceKind != "certificate" || res.ResourceAPIVersion != "v1" {
+		t.Errorf("unexpected resource %+v", res)
+	}
+}
+
+func TestManifestResources(t *testing.T) {
+	data := testCustomResourceManifest + `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+---
+apiVersion: ../../etc/v1
+kind: Passwd
+metadata:
+  name: web
+---
+apiVersion: example.com/v1
+kind: ../Escape
+metadata:
+  name: web
+`
+	resources := manifestResources([]byte(data))
+	want := map[string]bool{
+		"cert-manager.io/certificate_v1-cert-manager-v1": false,
+		"apps/deployment_v1-apps-v1":                     true,
+		"networking.k8s.io/ingress_v1-networking-v1":     true,
+		"v1/configmap_v1-v1":                             true,
+	}
+	if len(resources) != len(want) {
+		t.Fatalf("got %+v, want %d resources", resources, len(want))
+	}
+	for _, res := range resources {
+		key := res.Group + "/" + res.ResourceKind + "_" + res.ResourceAPIVersion + res.KindSuffix
+		builtin, ok := want[key]
+		if !ok || builtin != res.builtin {
+			t.Errorf("unexpected resource %s (builtin %v)", key, res.builtin)
+		}
+	}
+}
+
+func TestSyncCatalogs(t *testing.T) {
+	t.Setenv("KUBECTL_MFT_CACHE_DIR", t.TempDir())
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/cert-manager.io/certificate_v1.json" && r.URL.Path != "/default/deployment-apps-v1.json" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"type":"object"}`))
+	}))
+	defer srv.Close()
+	catalog := srv.URL + "/{{ .Group }}/{{ .ResourceKind }}_{{ .ResourceAPIVersion }}.json"
+	orig := defaultCatalog
+	defaultCatalog = srv.URL + "/default/{{ .ResourceKind }}{{ .KindSuffix }}.json"
+	t.Cleanup(func() { defaultCatalog = orig })
+
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(manifestPath, []byte(testCustomResourceManifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Offline with an empty cache nothing is downloaded, not even the default schemas
+	defaultLocation, locations, err := syncCatalogs(manifestPath, []string{catalog}, true)
+	if err != nil {
+		t.Fatalf("syncCatalogs failed: %v", err)
+	}
+	if requests.Load() != 0 {
+		t.Errorf("expected no requests offline, got %d", requests.Load())
+	}
+	if len(locations) != 1 || !strings.HasSuffix(locations[0], "/"+catalogLayout) {
+		t.Fatalf("unexpected locations %v", locations)
+	}
+
+	if _, _, err := syncCatalogs(manifestPath, []string{catalog}, false); err != nil {
+		t.Fatalf("syncCatalogs failed: %v", err)
+	}
+	// One request for the custom resource, and one for each built-in resource
+	if requests.Load() != 3 {
+		t.Errorf("expected 3 requests, got %d", requests.Load())
+	}
+	cachedDefault := strings.TrimSuffix(defaultLocation, catalogLayout) + "apps/deployment_v1.json"
+	if _, err := os.Stat(filepath.FromSlash(cachedDefault)); err != nil {
+		t.Errorf("default schema was not cached: %v", err)
+	}
+	cached := strings.TrimSuffix(locations[0], catalogLayout) + "cert-manager.io/certificate_v1.json"
+	data, err := os.ReadFile(filepath.FromSlash(cached))
+	if err != nil {
+		t.Fatalf("schema was not cached: %v", err)
+	}
+	if string(data) != `{"type":"object"}` {
+		t.Errorf("cached schema = %s", data)
+	}
+
+	// Cached schemas are not downloaded again, schemas not found are looked up again
+	if _, _, err := syncCatalogs(manifestPath, []string{catalog}, false); err != nil {
+		t.Fatalf("syncCatalogs failed: %v", err)
+	}
+	if requests.Load() != 4 {
+		t.Errorf("expected the cached schema to be reused, got %d requests", requests.Load())
+	}
+}
@@ -6,11 +6,16 @@ package validate
 import (
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+	"github.com/chez-shanpu/kubectl-mft/internal/paths"
 )
 
 // CRDManifest represents a CustomResourceDefinition YAML structure.
@@ -43,9 +48,23 @@ type CRDVersion struct {
 
 // SchemaInfo holds metadata about a registered CRD schema.
 type SchemaInfo struct {
-	Group   string `json:"group"`
-	Kind    string `json:"kind"`
-	Version string `json:"version"`
+	Group   string `json:"group" yaml:"group"`
+	Kind    string `json:"kind" yaml:"kind"`
+	Version string `json:"version" yaml:"version"`
+}
+
+// SchemaFilter selects registered schemas. Empty fields match any value.
+type SchemaFilter struct {
+	Group   string
+	Kind    string
+	Version string
+}
+
+// Match reports whether the schema matches the filter. Kinds are compared case-insensitively.
+func (f SchemaFilter) Match(s SchemaInfo) bool {
+	return (f.Group == "" || f.Group == s.Group) &&
+		(f.Kind == "" || strings.EqualFold(f.Kind, s.Kind)) &&
+		(f.Version == "" || f.Version == s.Version)
 }
 
 // schemaIndex is the on-disk index of registered schemas.
@@ -54,16 +73,22 @@ type schemaIndex struct {
 }
 
 // resolveSchemaDir returns the schema directory path.
-// It checks KUBECTL_MFT_SCHEMA_DIR env var first, then falls back to default.
+// It checks KUBECTL_MFT_SCHEMA_DIR env var first, then falls back to the
+// schemas directory under the kubectl-mft data directory.
 func resolveSchemaDir() (string, error) {
 	if dir := os.Getenv("KUBECTL_MFT_SCHEMA_DIR"); dir != "" {
 		return dir, nil
 	}
-	home, err := os.UserHomeDir()
+	dataDir, err := paths.DataDir()
 	if err != nil {
-		return "", fmt.Errorf("failed to get user home directory: %w", err)
+		return "", err
 	}
-	return filepath.Join(home, ".local", "share", "kubectl-mft", "schemas"), nil
+	return filepath.Join(dataDir, "schemas"), nil
+}
+
+// SchemaDir returns the CRD schema storage directory path.
+func SchemaDir() (string, error) {
+	return resolveSchemaDir()
 }
 
 // SchemaLocationTemplate returns the kubeconform schema location template
@@ -73,57 +98,178 @@ func SchemaLocationTemplate() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return dir + "/{{ .Group }}/{{ .ResourceKind }}_{{ .ResourceAPIVersion }}.json", nil
+	return filepath.ToSlash(dir) + "/{{ .Group }}/{{ .ResourceKind }}_{{ .ResourceAPIVersion }}.json", nil
+}
+
+// RegisteredCRD describes the schemas registered for a single CRD.
+type RegisteredCRD struct {
+	Group    string
+	Kind     string
+	Versions []string
 }
 
 // RegisterCRDSchema reads a CRD YAML file and extracts JSON Schema files
 // for each version defined in the CRD.
 func RegisterCRDSchema(crdFilePath string) error {
-	data, err := os.ReadFile(crdFilePath)
+	_, err := RegisterCRDSchemas(crdFilePath)
+	return err
+}
+
+// RegisterCRDSchemas registers the CRDs of a YAML file, which may hold several documents,
+// or of all .yaml, .yml, and .json files below a directory. Nothing is registered if any
+// CRD is invalid.
+func RegisterCRDSchemas(path string) ([]RegisteredCRD, error) {
+	crds, err := ReadCRDs(path)
 	if err != nil {
-		return fmt.Errorf("failed to read CRD file: %w", err)
+		return nil, err
 	}
+	return RegisterCRDs(crds)
+}
 
-	var crd CRDManifest
-	if err := yaml.Unmarshal(data, &crd); err != nil {
-		return fmt.Errorf("failed to parse CRD YAML: %w", err)
+// RegisterCRDData registers the CRDs of multi-document YAML data. Documents that are not CRDs
+// are skipped, but at least one CRD is required. Nothing is registered if any CRD is invalid.
+func RegisterCRDData(data []byte) ([]RegisteredCRD, error) {
+	crds, err := ParseCRDs(data)
+	if err != nil {
+		return nil, err
 	}
+	return RegisterCRDs(crds)
+}
 
-	if crd.Kind != "CustomResourceDefinition" {
-		return fmt.Errorf("expected CustomResourceDefinition, got %q", crd.Kind)
+// ReadCRDs reads the CRDs of a YAML file, which may hold several documents, or of all .yaml,
+// .yml, and .json files below a directory. At least one CRD is required.
+func ReadCRDs(path string) ([]CRDManifest, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CRD file: %w", err)
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CRD file: %w", err)
+		}
+		return ParseCRDs(data)
 	}
 
-	if crd.Spec.Group == "" || crd.Spec.Names.Kind == "" {
-		return fmt.Errorf("CRD is missing required fields (group or kind)")
+	var crds []CRDManifest
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		switch filepath.Ext(p) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("failed to read CRD file: %w", err)
+		}
+		found, err := parseCRDs(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		crds = append(crds, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	if len(crds) == 0 {
+		return nil, fmt.Errorf("no CustomResourceDefinition found in %s", path)
+	}
+	return crds, nil
+}
 
-	if len(crd.Spec.Versions) == 0 {
-		return fmt.Errorf("CRD has no versions defined")
+// ParseCRDs parses the CRDs of multi-document YAML data. Documents that are not CRDs are
+// skipped, but at least one CRD is required.
+func ParseCRDs(data []byte) ([]CRDManifest, error) {
+	crds, err := parseCRDs(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(crds) == 0 {
+		return nil, fmt.Errorf("expected CustomResourceDefinition, found none")
 	}
+	return crds, nil
+}
 
-	idx, err := loadIndex()
+// parseCRDs parses the CRDs of multi-document YAML data. Each CRD must define at least one
+// version with a schema.
+func parseCRDs(data []byte) ([]CRDManifest, error) {
+	docs, err := manifest.Split(data)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to parse CRD YAML: %w", err)
 	}
 
-	for _, ver := range crd.Spec.Versions {
-		if ver.Schema.OpenAPIV3Schema == nil {
+	var crds []CRDManifest
+	for i, doc := range docs {
+		if doc.Kind != "CustomResourceDefinition" {
 			continue
 		}
+		var crd CRDManifest
+		if err := yaml.Unmarshal(doc.Raw, &crd); err != nil {
+			return nil, fmt.Errorf("document %d: failed to parse CRD YAML: %w", i+1, err)
+		}
+		if crd.Spec.Group == "" || crd.Spec.Names.Kind == "" {
+			return nil, fmt.Errorf("document %d: CRD is missing required fields (group or kind)", i+1)
+		}
+		if len(crd.Spec.Versions) == 0 {
+			return nil, fmt.Errorf("document %d: CRD %s/%s has no versions defined", i+1, crd.Spec.Group, crd.Spec.Names.Kind)
+		}
+		if !slices.ContainsFunc(crd.Spec.Versions, func(v CRDVersion) bool { return v.Schema.OpenAPIV3Schema != nil }) {
+			return nil, fmt.Errorf("document %d: CRD %s/%s has no version with an openAPIV3Schema", i+1, crd.Spec.Group, crd.Spec.Names.Kind)
+		}
+		crds = append(crds, crd)
+	}
+	return crds, nil
+}
 
-		if err := saveSchemaFile(crd.Spec.Group, crd.Spec.Names.Kind, ver.Name, ver.Schema.OpenAPIV3Schema); err != nil {
-			return fmt.Errorf("failed to save schema for %s/%s %s: %w", crd.Spec.Group, crd.Spec.Names.Kind, ver.Name, err)
+// RegisterCRDs saves the schemas of all versions of the CRDs and adds them to the index.
+// If saving any schema fails, the schema files written so far are restored, so that
+// either all CRDs are registered or none.
+func RegisterCRDs(crds []CRDManifest) ([]RegisteredCRD, error) {
+	idx, err := loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var written []schemaBackup
+	rollback := func(err error) ([]RegisteredCRD, error) {
+		for _, b := range slices.Backward(written) {
+			b.restore()
 		}
+		return nil, err
+	}
+	registered := make([]RegisteredCRD, 0, len(crds))
+	for _, crd := range crds {
+		r := RegisteredCRD{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}
+		for _, ver := range crd.Spec.Versions {
+			if ver.Schema.OpenAPIV3Schema == nil {
+				continue
+			}
+
+			backup, err := saveSchemaFile(crd.Spec.Group, crd.Spec.Names.Kind, ver.Name, ver.Schema.OpenAPIV3Schema)
+			if err != nil {
+				return rollback(fmt.Errorf("failed to save schema for %s/%s %s: %w", crd.Spec.Group, crd.Spec.Names.Kind, ver.Name, err))
+			}
+			written = append(written, backup)
 
-		info := SchemaInfo{
-			Group:   crd.Spec.Group,
-			Kind:    crd.Spec.Names.Kind,
-			Version: ver.Name,
+			info := SchemaInfo{
+				Group:   crd.Spec.Group,
+				Kind:    crd.Spec.Names.Kind,
+				Version: ver.Name,
+			}
+			idx.addIfNotExists(info)
+			r.Versions = append(r.Versions, ver.Name)
 		}
-		idx.addIfNotExists(info)
+		registered = append(registered, r)
 	}
 
-	return saveIndex(idx)
+	if err := saveIndex(idx); err != nil {
+		return rollback(err)
+	}
+	return registered, nil
 }
 
 // ListSchemas returns all registered CRD schemas.
@@ -135,6 +281,79 @@ func ListSchemas() ([]SchemaInfo, error) {
 	return idx.Schemas, nil
 }
 
+// FilterSchemas returns the registered CRD schemas matching the filter.
+func FilterSchemas(f SchemaFilter) ([]SchemaInfo, error) {
+	schemas, err := ListSchemas()
+	if err != nil {
+		return nil, err
+	}
+	var matched []SchemaInfo
+	for _, s := range schemas {
+		if f.Match(s) {
+			matched = append(matched, s)
+		}
+	}
+	return matched, nil
+}
+
+// ReadSchema returns the JSON schema stored for the schema matching the filter. The group and kind
+// are required; the version may be omitted if only one version of the resource is registered.
+func ReadSchema(f SchemaFilter) (SchemaInfo, []byte, error) {
+	name := f.Group + "/" + f.Kind
+	schemas, err := FilterSchemas(SchemaFilter{Group: f.Group, Kind: f.Kind})
+	if err != nil {
+		return SchemaInfo{}, nil, err
+	}
+	if len(schemas) == 0 {
+		return SchemaInfo{}, nil, fmt.Errorf("schema not found: %s", name)
+	}
+
+	var info SchemaInfo
+	switch {
+	case f.Version != "":
+		i := slices.IndexFunc(schemas, f.Match)
+		if i < 0 {
+			return SchemaInfo{}, nil, fmt.Errorf("schema not found: %s@%s, registered versions: %s", name, f.Version, strings.Join(schemaVersions(schemas), ", "))
+		}
+		info = schemas[i]
+	case len(schemas) == 1:
+		info = schemas[0]
+	default:
+		return SchemaInfo{}, nil, fmt.Errorf("%s has several registered versions, select one of: %s", name, strings.Join(schemaVersions(schemas), ", "))
+	}
+
+	filePath, err := schemaFilePath(info.Group, info.Kind, info.Version)
+	if err != nil {
+		return SchemaInfo{}, nil, err
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return SchemaInfo{}, nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+	return info, data, nil
+}
+
+// ParseSchemaRef parses a "group/kind[@version]" reference to a registered schema.
+func ParseSchemaRef(s string) (SchemaFilter, error) {
+	groupKind, version, hasVersion := strings.Cut(s, "@")
+	if hasVersion && version == "" {
+		return SchemaFilter{}, fmt.Errorf("invalid format %q: expected <group>/<kind>[@<version>]", s)
+	}
+	group, kind, err := ParseGroupKind(groupKind)
+	if err != nil {
+		return SchemaFilter{}, fmt.Errorf("invalid format %q: expected <group>/<kind>[@<version>]", s)
+	}
+	return SchemaFilter{Group: group, Kind: kind, Version: version}, nil
+}
+
+func schemaVersions(schemas []SchemaInfo) []string {
+	versions := make([]string, 0, len(schemas))
+	for _, s := range schemas {
+		versions = append(versions, s.Version)
+	}
+	return versions
+}
+
 // DeleteSchema removes a registered CRD schema by group and kind.
 // It deletes all versions of the specified resource.
 func DeleteSchema(group, kind string) error {
@@ -189,25 +408,52 @@ func ParseGroupKind(s string) (group, kind string, err error) {
 	return parts[0], parts[1], nil
 }
 
-// saveSchemaFile writes the openAPIV3Schema as a JSON Schema file.
+// saveSchemaFile writes the openAPIV3Schema as a JSON Schema file and returns the previous
+// content of the file to restore on failure.
 // The file is stored as: <schemaDir>/<group>/<kind_lowercase>_<version>.json
-func saveSchemaFile(group, kind, version string, schema map[string]any) error {
+func saveSchemaFile(group, kind, version string, schema map[string]any) (schemaBackup, error) {
 	filePath, err := schemaFilePath(group, kind, version)
 	if err != nil {
-		return err
+		return schemaBackup{}, err
 	}
 	dir := filepath.Dir(filePath)
 
 	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return fmt.Errorf("failed to create schema directory: %w", err)
+		return schemaBackup{}, fmt.Errorf("failed to create schema directory: %w", err)
 	}
 
 	data, err := json.MarshalIndent(schema, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal schema: %w", err)
+		return schemaBackup{}, fmt.Errorf("failed to marshal schema: %w", err)
 	}
 
-	return os.WriteFile(filePath, data, 0o644)
+	backup := schemaBackup{path: filePath}
+	if backup.data, err = os.ReadFile(filePath); err == nil {
+		backup.existed = true
+	} else if !os.IsNotExist(err) {
+		return schemaBackup{}, fmt.Errorf("failed to read schema file: %w", err)
+	}
+	if err := os.WriteFile(filePath, data, 0o644); err != nil {
+		backup.restore()
+		return schemaBackup{}, err
+	}
+	return backup, nil
+}
+
+// schemaBackup is the previous content of a schema file overwritten by saveSchemaFile.
+type schemaBackup struct {
+	path    string
+	data    []byte
+	existed bool
+}
+
+// restore puts the previous content of the schema file back, or removes it if it did not exist.
+func (b schemaBackup) restore() {
+	if b.existed {
+		_ = os.WriteFile(b.path, b.data, 0o644)
+	} else {
+		_ = os.Remove(b.path)
+	}
 }
 
 // schemaFilePath returns the file path for a schema.
@@ -6,6 +6,8 @@ package validate
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -234,3 +236,222 @@ func TestParseGroupKind(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterSchemas(t *testing.T) {
+	setupTestSchemaDir(t)
+	crdPath := writeCRDFile(t, t.TempDir(), "crd.yaml", testCRDYAML)
+	if err := RegisterCRDSchema(crdPath); err != nil {
+		t.Fatalf("RegisterCRDSchema failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter SchemaFilter
+		want   int
+	}{
+		{"all", SchemaFilter{}, 2},
+		{"group", SchemaFilter{Group: "example.com"}, 2},
+		{"kind ignores case", SchemaFilter{Kind: "myresource"}, 2},
+		{"version", SchemaFilter{Version: "v2"}, 1},
+		{"other group", SchemaFilter{Group: "cilium.io"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemas, err := FilterSchemas(tt.filter)
+			if err != nil {
+				t.Fatalf("FilterSchemas failed: %v", err)
+			}
+			if len(schemas) != tt.want {
+				t.Errorf("got %d schemas, want %d", len(schemas), tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSchema(t *testing.T) {
+	setupTestSchemaDir(t)
+	crdPath := writeCRDFile(t, t.TempDir(), "crd.yaml", testCRDYAML)
+	if err := RegisterCRDSchema(crdPath); err != nil {
+		t.Fatalf("RegisterCRDSchema failed: %v", err)
+	}
+
+	info, data, err := ReadSchema(SchemaFilter{Group: "example.com", Kind: "MyResource", Version: "v2"})
+	if err != nil {
+		t.Fatalf("ReadSchema failed: %v", err)
+	}
+	if info.Version != "v2" {
+		t.Errorf("Version = %q, want v2", info.Version)
+	}
+	if !strings.Contains(string(data), `"description"`) {
+		t.Errorf("expected the v2 schema, got %s", data)
+	}
+
+	// Without a version, the resource must have a single registered version
+	if _, _, err := ReadSchema(SchemaFilter{Group: "example.com", Kind: "MyResource"}); err == nil {
+		t.Error("expected error for a resource with several versions")
+	}
+	if _, _, err := ReadSchema(SchemaFilter{Group: "example.com", Kind: "MyResource", Version: "v3"}); err == nil {
+		t.Error("expected error for an unregistered version")
+	}
+	if _, _, err := ReadSchema(SchemaFilter{Group: "example.com", Kind: "Other"}); err == nil {
+		t.Error("expected error for an unregistered kind")
+	}
+}
+
+func TestParseSchemaRef(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    SchemaFilter
+		wantErr bool
+	}{
+		{"example.com/MyResource@v1", SchemaFilter{Group: "example.com", Kind: "MyResource", Version: "v1"}, false},
+		{"example.com/MyResource", SchemaFilter{Group: "example.com", Kind: "MyResource"}, false},
+		{"example.com/MyResource@", SchemaFilter{}, true},
+		{"MyResource@v1", SchemaFilter{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSchemaRef(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSchemaRef(%q) error = %v, wantErr = %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSchemaRef(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+const testOtherCRDYAML = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: others.example.org
+spec:
+  group: example.org
+  names:
+    kind: Other
+  versions:
+  - name: v1beta1
+    schema:
+      openAPIV3Schema:
+        type: object
+`
+
+func TestRegisterCRDData_MultiDocument(t *testing.T) {
+	setupTestSchemaDir(t)
+
+	data := testCRDYAML + "---\napiVersion: v1\nkind: Namespace\nmetadata:\n  name: ns\n---\n" + testOtherCRDYAML
+	registered, err := RegisterCRDData([]byte(data))
+	if err != nil {
+		t.Fatalf("RegisterCRDData failed: %v", err)
+	}
+	want := []RegisteredCRD{
+		{Group: "example.com", Kind: "MyResource", Versions: []string{"v1", "v2"}},
+		{Group: "example.org", Kind: "Other", Versions: []string{"v1beta1"}},
+	}
+	if !reflect.DeepEqual(registered, want) {
+		t.Errorf("registered = %+v, want %+v", registered, want)
+	}
+
+	schemas, err := ListSchemas()
+	if err != nil {
+		t.Fatalf("ListSchemas failed: %v", err)
+	}
+	if len(schemas) != 3 {
+		t.Errorf("expected 3 schemas, got %d", len(schemas))
+	}
+}
+
+func TestRegisterCRDData_InvalidCRD(t *testing.T) {
+	setupTestSchemaDir(t)
+
+	invalid := "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: broken\nspec:\n  group: example.net\n"
+	if _, err := RegisterCRDData([]byte(testOtherCRDYAML + "---\n" + invalid)); err == nil {
+		t.Fatal("expected error for a CRD without kind")
+	}
+
+	// No CRD of the bundle is registered
+	schemas, err := ListSchemas()
+	if err != nil {
+		t.Fatalf("ListSchemas failed: %v", err)
+	}
+	if len(schemas) != 0 {
+		t.Errorf("expected 0 schemas, got %d", len(schemas))
+	}
+}
+
+func TestRegisterCRDSchemas_Directory(t *testing.T) {
+	setupTestSchemaDir(t)
+
+	dir := t.TempDir()
+	writeCRDFile(t, dir, "myresource.yaml", testCRDYAML)
+	if err := os.Mkdir(filepath.Join(dir, "nested"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeCRDFile(t, filepath.Join(dir, "nested"), "other.yml", testOtherCRDYAML)
+	writeCRDFile(t, dir, "README.md", "not a manifest")
+
+	registered, err := RegisterCRDSchemas(dir)
+	if err != nil {
+		t.Fatalf("RegisterCRDSchemas failed: %v", err)
+	}
+	if len(registered) != 2 {
+		t.Errorf("expected 2 CRDs, got %+v", registered)
+	}
+
+	if _, err := RegisterCRDSchemas(t.TempDir()); err == nil {
+		t.Error("expected error for a directory without CRDs")
+	}
+}
+
+func TestRegisterCRDSchemas_DirectoryWithInvalidCRD(t *testing.T) {
+	setupTestSchemaDir(t)
+
+	dir := t.TempDir()
+	writeCRDFile(t, dir, "a.yaml", testCRDYAML)
+	noSchema := "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nspec:\n  group: example.net\n  names:\n    kind: Bare\n  versions:\n  - name: v1\n"
+	writeCRDFile(t, dir, "b.yaml", noSchema)
+
+	_, err := RegisterCRDSchemas(dir)
+	if err == nil || !strings.Contains(err.Error(), "no version with an openAPIV3Schema") {
+		t.Fatalf("RegisterCRDSchemas() error = %v, want missing schema error", err)
+	}
+	// The valid CRD read before the invalid one is not registered either
+	schemas, err := ListSchemas()
+	if err != nil {
+		t.Fatalf("ListSchemas failed: %v", err)
+	}
+	if len(schemas) != 0 {
+		t.Errorf("expected 0 schemas, got %+v", schemas)
+	}
+}
+
+func TestRegisterCRDs_Rollback(t *testing.T) {
+	schemaDir := setupTestSchemaDir(t)
+
+	crds, err := ParseCRDs([]byte(testCRDYAML + "---\n" + testOtherCRDYAML))
+	if err != nil {
+		t.Fatalf("ParseCRDs failed: %v", err)
+	}
+	// The schema directory of the second CRD's group cannot be created
+	if err := os.MkdirAll(schemaDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(schemaDir, "example.org"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := RegisterCRDs(crds); err == nil {
+		t.Fatal("RegisterCRDs() succeeded, want an error")
+	}
+	entries, err := os.ReadDir(filepath.Join(schemaDir, "example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("schema files of the first CRD were not removed: %v", entries)
+	}
+	if _, err := os.Stat(filepath.Join(schemaDir, "index.json")); !os.IsNotExist(err) {
+		t.Errorf("index.json was written: %v", err)
+	}
+}
@@ -4,16 +4,23 @@
 package validate
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/yannh/kubeconform/pkg/validator"
+	"gopkg.in/yaml.v3"
 )
 
 // options holds the configuration for manifest validation.
 type options struct {
 	schemaLocations []string
+	catalogs        []string
+	offline         bool
+	warn            func(Problem)
 }
 
 // Option configures the manifest validation behavior.
@@ -26,17 +33,86 @@ func WithSchemaLocations(locations ...string) Option {
 	}
 }
 
+// WithCatalogs adds remote schema catalogs, given as schema location templates such as the one of
+// the community CRDs-catalog.
+// Schemas of the custom resources in the manifest are downloaded from them and cached on disk.
+func WithCatalogs(catalogs ...string) Option {
+	return func(o *options) {
+		o.catalogs = append(o.catalogs, catalogs...)
+	}
+}
+
+// WithOffline makes validation use only schemas that are already cached, of built-in resources
+// and catalogs alike.
+func WithOffline() Option {
+	return func(o *options) {
+		o.offline = true
+	}
+}
+
+// WithWarningHandler makes validation report warnings, such as resources skipped for lack of a
+// schema, to warn instead of printing them to stderr.
+func WithWarningHandler(warn func(Problem)) Option {
+	return func(o *options) {
+		o.warn = warn
+	}
+}
+
+// Problem is a validation problem of a resource in a manifest file.
+type Problem struct {
+	File string
+	// Line is the line of the problem in File, or of the start of the resource, 0 if unknown
+	Line int
+	// Resource is the kind and name of the resource, empty if it could not be parsed
+	Resource string
+	// Path is the JSON pointer of the invalid field, empty for the resource as a whole
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Path != "" {
+		return fmt.Sprintf("%s: %s", p.Path, p.Message)
+	}
+	return p.Message
+}
+
+// Error is returned by ValidateManifest for invalid resources.
+type Error struct {
+	Problems []Problem
+}
+
+func (e *Error) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = "  - " + p.String()
+	}
+	return "\n" + strings.Join(lines, "\n")
+}
+
 // ValidateManifest validates a Kubernetes manifest file using kubeconform.
 // It supports multi-document YAML (separated by ---) and validates each document individually.
 // Documents without apiVersion/kind (e.g. debug container profiles) produce warnings, not errors.
 // Resources with missing schemas (unregistered CRDs) are skipped.
+// Invalid resources are reported as an *Error.
 func ValidateManifest(manifestPath string, opts ...Option) error {
 	o := &options{}
 	for _, opt := range opts {
 		opt(o)
 	}
+	warn := func(level string, p Problem) {
+		if o.warn != nil {
+			o.warn(p)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s: %s\n", level, p.File, p.Message)
+	}
 
-	schemaLocations := buildSchemaLocations(o.schemaLocations)
+	defaultLocation, catalogLocations, err := syncCatalogs(manifestPath, o.catalogs, o.offline)
+	if err != nil {
+		return err
+	}
+	schemaLocations := buildSchemaLocations(defaultLocation, append(o.schemaLocations, catalogLocations...))
 
 	v, err := validator.New(schemaLocations, validator.Opts{
 		Strict:               true,
@@ -46,64 +122,123 @@ func ValidateManifest(manifestPath string, opts ...Option) error {
 		return fmt.Errorf("failed to create validator: %w", err)
 	}
 
-	f, err := os.Open(manifestPath)
+	data, err := os.ReadFile(manifestPath)
 	if err != nil {
 		return fmt.Errorf("failed to open manifest file: %w", err)
 	}
-	defer f.Close()
 
-	results := v.Validate(manifestPath, f)
+	results := v.Validate(manifestPath, io.NopCloser(bytes.NewReader(data)))
 
-	var invalidErrors []string
+	var problems []Problem
+	offset := 0
 	for _, res := range results {
+		// Results are in document order, locate each document to report line numbers
+		start := 0
+		if i := bytes.Index(data[offset:], res.Resource.Bytes); len(res.Resource.Bytes) > 0 && i >= 0 {
+			start = offset + i
+			offset = start + len(res.Resource.Bytes)
+		}
+		line := bytes.Count(data[:start], []byte("\n")) + 1
+		p := Problem{File: manifestPath, Line: line, Resource: resourceName(res)}
+
 		switch res.Status {
 		case validator.Valid:
 			// Validation passed
 		case validator.Invalid:
-			msg := formatInvalidResult(res)
-			invalidErrors = append(invalidErrors, msg)
+			problems = append(problems, invalidProblems(p, res)...)
 		case validator.Error:
 			// Parse errors (e.g. missing apiVersion/kind) are treated as warnings
 			// to support debug container profiles and other non-standard formats
-			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", manifestPath, res.Err)
+			p.Message = res.Err.Error()
+			warn("warning", p)
 		case validator.Skipped:
 			// Resource skipped due to missing schema (unregistered CRD)
-			fmt.Fprintf(os.Stderr, "info: %s: resource skipped (no schema found)\n", manifestPath)
+			p.Message = "resource skipped (no schema found)"
+			warn("info", p)
 		case validator.Empty:
 			// Empty document, skip
 		}
 	}
 
-	if len(invalidErrors) > 0 {
-		return fmt.Errorf("\n%s", strings.Join(invalidErrors, "\n"))
+	if len(problems) > 0 {
+		return &Error{Problems: problems}
 	}
 
 	return nil
 }
 
+// resourceName returns the kind and name of the resource of a result, if it could be parsed.
+func resourceName(res validator.Result) string {
+	sig, err := res.Resource.Signature()
+	if err != nil || sig.Kind == "" {
+		return ""
+	}
+	if sig.Namespace != "" {
+		return fmt.Sprintf("%s %s/%s", sig.Kind, sig.Namespace, sig.Name)
+	}
+	return fmt.Sprintf("%s %s", sig.Kind, sig.Name)
+}
+
 // buildSchemaLocations constructs the full list of schema locations.
-// It always includes the default Kubernetes schemas and appends any custom locations.
-func buildSchemaLocations(custom []string) []string {
-	locations := []string{"default"}
+// It always starts with the cached default Kubernetes schemas and appends any custom locations.
+func buildSchemaLocations(defaultLocation string, custom []string) []string {
+	locations := []string{defaultLocation}
 	locations = append(locations, custom...)
 	return locations
 }
 
-// formatInvalidResult formats a validation error result into a human-readable message.
-// When ValidationErrors are present, only those are shown (res.Err contains redundant
+// invalidProblems returns the problems of an invalid result, based on p.
+// When ValidationErrors are present, only those are reported (res.Err contains redundant
 // schema URL information). res.Err is used as a fallback when ValidationErrors is empty.
-func formatInvalidResult(res validator.Result) string {
-	var parts []string
-	if len(res.ValidationErrors) > 0 {
-		for _, ve := range res.ValidationErrors {
-			if ve.Path != "" {
-				parts = append(parts, fmt.Sprintf("  - %s: %s", ve.Path, ve.Msg))
-			} else {
-				parts = append(parts, fmt.Sprintf("  - %s", ve.Msg))
+func invalidProblems(p Problem, res validator.Result) []Problem {
+	if len(res.ValidationErrors) == 0 {
+		if res.Err != nil {
+			p.Message = res.Err.Error()
+		}
+		return []Problem{p}
+	}
+	problems := make([]Problem, 0, len(res.ValidationErrors))
+	for _, ve := range res.ValidationErrors {
+		q := p
+		q.Path, q.Message = ve.Path, ve.Msg
+		q.Line += fieldLine(res.Resource.Bytes, ve.Path) - 1
+		problems = append(problems, q)
+	}
+	return problems
+}
+
+// fieldLine returns the line within the YAML document of the deepest field of the JSON pointer
+// path that exists, or 1 for the start of the document.
+func fieldLine(doc []byte, path string) int {
+	var root yaml.Node
+	if err := yaml.Unmarshal(doc, &root); err != nil || len(root.Content) == 0 {
+		return 1
+	}
+	node, line := root.Content[0], 1
+	for _, token := range strings.Split(strings.TrimPrefix(path, "/"), "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == token {
+					line, next = node.Content[i].Line, node.Content[i+1]
+					break
+				}
 			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(token); err == nil && i >= 0 && i < len(node.Content) {
+				next = node.Content[i]
+				line = next.Line
+			}
+		}
+		if next == nil {
+			break
 		}
-	} else if res.Err != nil {
-		parts = append(parts, res.Err.Error())
+		node = next
 	}
-	return strings.Join(parts, "\n")
+	return line
 }
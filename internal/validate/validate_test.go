@@ -6,7 +6,11 @@ package validate
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+	"github.com/yannh/kubeconform/pkg/validator"
 )
 
 func writeManifestFile(t *testing.T, dir, name, content string) string {
@@ -245,19 +249,19 @@ func TestBuildSchemaLocations(t *testing.T) {
 			name:      "no custom locations",
 			custom:    nil,
 			wantLen:   1,
-			wantFirst: "default",
+			wantFirst: "/cache/default",
 		},
 		{
 			name:      "with custom locations",
 			custom:    []string{"/custom/path"},
 			wantLen:   2,
-			wantFirst: "default",
+			wantFirst: "/cache/default",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := buildSchemaLocations(tt.custom)
+			result := buildSchemaLocations("/cache/default", tt.custom)
 			if len(result) != tt.wantLen {
 				t.Errorf("got %d locations, want %d", len(result), tt.wantLen)
 			}
@@ -267,3 +271,86 @@ func TestBuildSchemaLocations(t *testing.T) {
 		})
 	}
 }
+
+func TestInvalidProblems(t *testing.T) {
+	doc := []byte(`apiVersion: example.com/v1
+kind: MyResource
+metadata:
+  name: invalid
+spec:
+  foo: 1
+`)
+	res := validator.Result{
+		Resource: resource.Resource{Path: "lines.yaml", Bytes: doc},
+		Status:   validator.Invalid,
+		ValidationErrors: []validator.ValidationError{
+			{Path: "/spec/foo", Msg: "expected string, but got number"},
+			{Path: "", Msg: "missing properties: 'bar'"},
+		},
+	}
+	got := invalidProblems(Problem{File: "lines.yaml", Line: 8, Resource: "MyResource invalid"}, res)
+	want := []Problem{
+		{File: "lines.yaml", Line: 13, Resource: "MyResource invalid", Path: "/spec/foo", Message: "expected string, but got number"},
+		{File: "lines.yaml", Line: 8, Resource: "MyResource invalid", Message: "missing properties: 'bar'"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("invalidProblems() = %+v, want %+v", got, want)
+	}
+}
+
+func TestValidateManifest_WarningHandler(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+---
+name: some-profile
+`
+	path := writeManifestFile(t, t.TempDir(), "warning.yaml", manifest)
+
+	var warnings []Problem
+	err := ValidateManifest(path,
+		WithWarningHandler(func(p Problem) { warnings = append(warnings, p) }),
+	)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	// The ConfigMap may also be reported when its schema cannot be downloaded
+	if len(warnings) == 0 {
+		t.Fatal("expected a warning for the document without kind")
+	}
+	if w := warnings[len(warnings)-1]; w.File != path || w.Line != 6 {
+		t.Errorf("unexpected warning: %+v", w)
+	}
+}
+
+func TestFieldLine(t *testing.T) {
+	doc := []byte(`apiVersion: v1
+kind: Pod
+spec:
+  containers:
+  - name: app
+    image: nginx
+  - name: "a/b"
+    ports:
+    - containerPort: x
+`)
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"", 1},
+		{"/kind", 2},
+		{"/spec/containers/0/image", 6},
+		{"/spec/containers/1/ports/0/containerPort", 9},
+		{"/spec/containers/1/missing", 7},
+		{"/spec/containers/5", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := fieldLine(doc, tt.path); got != tt.want {
+				t.Errorf("fieldLine(%q) = %d, want %d", tt.path, got, tt.want)
+			}
+		})
+	}
+}
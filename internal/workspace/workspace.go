@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package workspace
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the workspace file at the root of a workspace
+const FileName = ".mft.yaml"
+
+// Workspace declares how the manifests of a project are packed, similar to how a compose
+// file anchors container builds. It is read from .mft.yaml at the workspace root.
+type Workspace struct {
+	// TagPrefix is prepended to the tag of every manifest, e.g. "registry.example.com/manifests/"
+	TagPrefix string `yaml:"tagPrefix,omitempty"`
+	// SigningKey is the key reference used for signing, as accepted by --key
+	SigningKey string `yaml:"signingKey,omitempty"`
+	// Annotations are added to every packed manifest
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+	Validation  Validation        `yaml:"validation,omitempty"`
+	Manifests   []Manifest        `yaml:"manifests"`
+
+	// dir is the workspace root, manifest paths are relative to it
+	dir string
+}
+
+// Validation configures manifest validation before packing.
+type Validation struct {
+	// Skip disables validation
+	Skip bool `yaml:"skip,omitempty"`
+	// SchemaLocations are additional kubeconform schema locations
+	SchemaLocations []string `yaml:"schemaLocations,omitempty"`
+}
+
+// Manifest is a manifest file of the workspace and the tag it is packed as.
+type Manifest struct {
+	// Path is the manifest file, relative to the workspace root
+	Path string `yaml:"path"`
+	// Tag is appended to the tag prefix, e.g. "app:v1.0.0"
+	Tag string `yaml:"tag"`
+	// Annotations are added to this manifest, overriding workspace annotations of the same key
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// Target is a manifest file resolved against the workspace.
+type Target struct {
+	Path        string
+	Tag         string
+	Annotations map[string]string
+}
+
+// Find returns the path of the workspace file in dir or its closest parent directory.
+// ok is false if dir is not inside a workspace.
+func Find(dir string) (path string, ok bool, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get absolute path of %q: %w", dir, err)
+	}
+	for {
+		path = filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, true, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false, nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads the workspace file at path.
+func Load(path string) (*Workspace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace file: %w", err)
+	}
+	ws, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace file %s: %w", path, err)
+	}
+	ws.dir, err = filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path of %q: %w", path, err)
+	}
+	return ws, nil
+}
+
+// Parse decodes a workspace file. Unknown fields are rejected to catch typos.
+func Parse(data []byte) (*Workspace, error) {
+	var ws Workspace
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&ws); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(ws.Manifests) == 0 {
+		return nil, fmt.Errorf("manifests: at least one manifest is required")
+	}
+	seen := make(map[string]bool)
+	for i, m := range ws.Manifests {
+		if m.Path == "" || m.Tag == "" {
+			return nil, fmt.Errorf("manifests[%d]: path and tag are required", i)
+		}
+		if seen[m.Tag] {
+			return nil, fmt.Errorf("manifests[%d]: duplicate tag %q", i, m.Tag)
+		}
+		seen[m.Tag] = true
+	}
+	return &ws, nil
+}
+
+// Dir returns the workspace root.
+func (w *Workspace) Dir() string {
+	return w.dir
+}
+
+// Targets returns the manifests of the workspace with their paths resolved against the
+// workspace root, the tag prefix applied, and workspace annotations merged.
+func (w *Workspace) Targets() []Target {
+	targets := make([]Target, 0, len(w.Manifests))
+	for _, m := range w.Manifests {
+		path := m.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(w.dir, path)
+		}
+		annotations := maps.Clone(w.Annotations)
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		maps.Copy(annotations, m.Annotations)
+		targets = append(targets, Target{
+			Path:        path,
+			Tag:         w.TagPrefix + m.Tag,
+			Annotations: annotations,
+		})
+	}
+	return targets
+}
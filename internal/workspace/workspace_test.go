@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "empty", data: ""},
+		{name: "unknown field", data: "tagprefix: ghcr.io/\nmanifests:\n  - path: a.yaml\n    tag: a:v1\n"},
+		{name: "manifest without tag", data: "manifests:\n  - path: a.yaml\n"},
+		{name: "manifest without path", data: "manifests:\n  - tag: a:v1\n"},
+		{name: "duplicate tag", data: "manifests:\n  - path: a.yaml\n    tag: a:v1\n  - path: b.yaml\n    tag: a:v1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.data)); err == nil {
+				t.Errorf("Parse() expected error")
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "deploy", "base")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	want := filepath.Join(root, FileName)
+	if err := os.WriteFile(want, []byte("manifests: []\n"), 0o644); err != nil {
+		t.Fatalf("failed to write workspace file: %v", err)
+	}
+
+	for _, dir := range []string{root, sub} {
+		got, ok, err := Find(dir)
+		if err != nil {
+			t.Fatalf("Find(%q) unexpected error: %v", dir, err)
+		}
+		if !ok || got != want {
+			t.Errorf("Find(%q) = %q, %v, want %q, true", dir, got, ok, want)
+		}
+	}
+
+	if _, ok, err := Find(t.TempDir()); err != nil || ok {
+		t.Errorf("Find() outside a workspace = %v, %v, want false, nil", ok, err)
+	}
+}
+
+func TestTargets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	data := `
+tagPrefix: registry.example.com/manifests/
+annotations:
+  team: platform
+  stage: dev
+manifests:
+  - path: deploy/app.yaml
+    tag: app:v1
+    annotations:
+      stage: prod
+  - path: /abs/db.yaml
+    tag: db:v1
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("failed to write workspace file: %v", err)
+	}
+
+	ws, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if ws.Dir() != dir {
+		t.Errorf("Dir() = %q, want %q", ws.Dir(), dir)
+	}
+
+	want := []Target{
+		{
+			Path:        filepath.Join(dir, "deploy", "app.yaml"),
+			Tag:         "registry.example.com/manifests/app:v1",
+			Annotations: map[string]string{"team": "platform", "stage": "prod"},
+		},
+		{
+			Path:        "/abs/db.yaml",
+			Tag:         "registry.example.com/manifests/db:v1",
+			Annotations: map[string]string{"team": "platform", "stage": "dev"},
+		},
+	}
+	if got := ws.Targets(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Targets() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,301 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+// Package mft is the Go API of kubectl-mft, for tools that pack, distribute, sign, and verify
+// Kubernetes manifests as OCI artifacts without running the CLI.
+//
+// A Client works on a Store, by default the local storage the CLI uses:
+//
+//	c, err := mft.New()
+//	if err != nil {
+//		return err
+//	}
+//	err = c.Pack(ctx, "ghcr.io/myorg/app:v1", mft.PackOptions{ManifestPath: "deployment.yaml", Signer: key})
+//	if err != nil {
+//		return err
+//	}
+//	err = c.Push(ctx, "ghcr.io/myorg/app:v1")
+package mft
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/config"
+	"github.com/chez-shanpu/kubectl-mft/internal/manifest"
+	internalmft "github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+	"github.com/chez-shanpu/kubectl-mft/internal/validate"
+)
+
+// Client packs, distributes, signs, and verifies manifests of a Store.
+type Client struct {
+	store Store
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithStore sets the store of the Client instead of the local storage of the CLI.
+func WithStore(store Store) Option {
+	return func(c *Client) {
+		c.store = store
+	}
+}
+
+// New returns a Client. Without WithStore, it uses the local storage of the CLI.
+func New(opts ...Option) (*Client, error) {
+	c := &Client{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.store == nil {
+		store, err := NewLocalStore("")
+		if err != nil {
+			return nil, err
+		}
+		c.store = store
+	}
+	return c, nil
+}
+
+// PackOptions are the options of Pack
+type PackOptions struct {
+	// ManifestPath is the manifest file to pack
+	ManifestPath string
+	// SkipValidation skips validating the manifest before packing
+	SkipValidation bool
+	// SkipLimits skips checking the manifest against pack.limits of config.yaml
+	SkipLimits bool
+	// SchemaLocations are additional schema locations to validate against, besides the
+	// built-in Kubernetes schemas and the registered CRD schemas
+	SchemaLocations []string
+	// Signer signs the packed manifest, if set
+	Signer crypto.Signer
+	// TimestampURL is the RFC 3161 timestamping authority that timestamps the signature, if set
+	TimestampURL string
+	// TimestampClient sends the requests to TimestampURL, http.DefaultClient if nil
+	TimestampClient *http.Client
+}
+
+// Pack checks, validates, and packs a manifest file under ref, and signs it if opts.Signer is
+// set, applying pack.limits and the validation settings of config.yaml like the CLI.
+// If signing fails, the packed manifest is removed again.
+func (c *Client) Pack(ctx context.Context, ref string, opts PackOptions) error {
+	if opts.ManifestPath == "" {
+		return fmt.Errorf("no manifest file to pack")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if !opts.SkipLimits {
+		if err := checkLimits(cfg, opts.ManifestPath); err != nil {
+			return err
+		}
+	}
+	if !opts.SkipValidation {
+		tmpl, err := validate.SchemaLocationTemplate()
+		if err != nil {
+			return fmt.Errorf("failed to resolve schema directory: %w", err)
+		}
+		validateOpts := []validate.Option{
+			validate.WithSchemaLocations(append([]string{tmpl}, opts.SchemaLocations...)...),
+			validate.WithCatalogs(cfg.Validation.Catalogs...),
+		}
+		if cfg.Validation.Offline {
+			validateOpts = append(validateOpts, validate.WithOffline())
+		}
+		if err := validate.ValidateManifest(opts.ManifestPath, validateOpts...); err != nil {
+			return fmt.Errorf("manifest validation failed: %w", err)
+		}
+	}
+
+	r, err := c.store.Repository(ref)
+	if err != nil {
+		return err
+	}
+	if lr, ok := r.(*localRepository); ok {
+		return lr.pack(ctx, opts)
+	}
+	if err := r.Save(ctx, opts.ManifestPath); err != nil {
+		return err
+	}
+	if opts.Signer == nil {
+		return nil
+	}
+	if err := sign(ctx, r, opts.Signer, opts.TimestampURL, opts.TimestampClient); err != nil {
+		if deleteErr := r.Delete(ctx); deleteErr != nil {
+			return fmt.Errorf("%w (failed to clean up packed data: %v)", err, deleteErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// Push pushes a stored manifest and its signatures to the OCI registry of ref.
+func (c *Client) Push(ctx context.Context, ref string) error {
+	r, err := c.store.Repository(ref)
+	if err != nil {
+		return err
+	}
+	return r.Push(ctx)
+}
+
+// PullOptions are the options of Pull
+type PullOptions struct {
+	// Verify verifies the signature of the pulled manifest, and removes it again if verification fails
+	Verify bool
+	// VerifyOptions are the options of the verification
+	VerifyOptions VerifyOptions
+}
+
+// Pull pulls a manifest and its signatures from the OCI registry of ref into the store.
+func (c *Client) Pull(ctx context.Context, ref string, opts PullOptions) error {
+	r, err := c.store.Repository(ref)
+	if err != nil {
+		return err
+	}
+	if err := r.Pull(ctx); err != nil {
+		return err
+	}
+	if !opts.Verify {
+		return nil
+	}
+	if err := verify(ctx, r, opts.VerifyOptions); err != nil {
+		if deleteErr := r.Delete(ctx); deleteErr != nil {
+			return fmt.Errorf("%w (failed to clean up pulled data: %v)", err, deleteErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// Dump returns the content of a stored manifest.
+func (c *Client) Dump(ctx context.Context, ref string) ([]byte, error) {
+	r, err := c.store.Repository(ref)
+	if err != nil {
+		return nil, err
+	}
+	return r.Dump(ctx)
+}
+
+// SignOptions are the options of Sign
+type SignOptions struct {
+	// Signer signs the manifest
+	Signer crypto.Signer
+	// TimestampURL is the RFC 3161 timestamping authority that timestamps the signature, if set
+	TimestampURL string
+	// TimestampClient sends the requests to TimestampURL, http.DefaultClient if nil
+	TimestampClient *http.Client
+}
+
+// Sign signs a stored manifest and attaches the signature to it.
+func (c *Client) Sign(ctx context.Context, ref string, opts SignOptions) error {
+	if opts.Signer == nil {
+		return fmt.Errorf("no signer given")
+	}
+	r, err := c.store.Repository(ref)
+	if err != nil {
+		return err
+	}
+	return sign(ctx, r, opts.Signer, opts.TimestampURL, opts.TimestampClient)
+}
+
+// VerifyOptions are the options of Verify
+type VerifyOptions struct {
+	// PublicKeys are the trusted keys. If empty, the public keys of the key directory of the
+	// CLI are trusted.
+	PublicKeys []crypto.PublicKey
+}
+
+// Verify verifies that a stored manifest has a valid signature by one of the trusted keys.
+func (c *Client) Verify(ctx context.Context, ref string, opts VerifyOptions) error {
+	r, err := c.store.Repository(ref)
+	if err != nil {
+		return err
+	}
+	return verify(ctx, r, opts)
+}
+
+// List returns the manifests of the store.
+func (c *Client) List(ctx context.Context) ([]*Info, error) {
+	return c.store.List(ctx)
+}
+
+// LoadSigningKey loads a private key of the key directory of the CLI by name.
+func LoadSigningKey(name string) (crypto.Signer, error) {
+	if err := initStorage(); err != nil {
+		return nil, err
+	}
+	return signature.LoadPrivateKey(name)
+}
+
+// sign signs the manifest of r. Signing modifies the layout directly, so for local storage
+// the change is recorded in the metadata index and the event log like the CLI does.
+func sign(ctx context.Context, r Repository, key crypto.Signer, timestampURL string, timestampClient *http.Client) error {
+	if err := signLayout(ctx, r.LayoutPath(), r.Tag(), key, timestampURL, timestampClient); err != nil {
+		return err
+	}
+	lr, ok := r.(*localRepository)
+	if !ok {
+		return nil
+	}
+	if err := lr.SyncMetadata(ctx); err != nil {
+		return err
+	}
+	return lr.RecordEvent(ctx, internalmft.EventSigned)
+}
+
+func signLayout(ctx context.Context, layoutPath, tag string, key crypto.Signer, timestampURL string, timestampClient *http.Client) error {
+	signer := signature.NewSigner(key)
+	if timestampURL != "" {
+		signer = signer.WithTimestampAuthority(timestampURL, timestampClient)
+	}
+	if _, err := signer.Sign(ctx, layoutPath, tag); err != nil {
+		return fmt.Errorf("failed to sign manifest: %w", err)
+	}
+	return nil
+}
+
+// checkLimits refuses the manifest file if it exceeds pack.limits of config.yaml.
+func checkLimits(cfg *config.Config, manifestPath string) error {
+	limits, err := cfg.Pack.Limits.Parsed()
+	if err != nil {
+		return err
+	}
+	if limits == (manifest.Limits{}) {
+		return nil
+	}
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest file: %w", err)
+	}
+	exceeded, err := limits.Check(data)
+	if err != nil {
+		return err
+	}
+	if len(exceeded) > 0 {
+		return fmt.Errorf("manifest exceeds pack.limits of config.yaml:\n  %s", strings.Join(exceeded, "\n  "))
+	}
+	return nil
+}
+
+func verify(ctx context.Context, r Repository, opts VerifyOptions) error {
+	verifier := signature.NewVerifier(opts.PublicKeys)
+	if len(opts.PublicKeys) == 0 {
+		if err := initStorage(); err != nil {
+			return err
+		}
+		v, err := signature.NewVerifierFromKeyDir()
+		if err != nil {
+			return err
+		}
+		verifier = v
+	}
+	return verifier.Verify(ctx, r.LayoutPath(), r.Tag())
+}
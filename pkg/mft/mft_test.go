@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+)
+
+const testManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+data:
+  key: value
+`
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("KUBECTL_MFT_CONFIG_DIR", filepath.Join(dir, "config"))
+	t.Setenv("KUBECTL_MFT_SYSTEM_STORAGE_DIR", "")
+	store, err := NewLocalStore(filepath.Join(dir, "manifests"))
+	if err != nil {
+		t.Fatalf("NewLocalStore failed: %v", err)
+	}
+	c, err := New(WithStore(store))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return c
+}
+
+func TestClient_PackSignVerify(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(path, []byte(testManifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Pack(ctx, "app:v1", PackOptions{ManifestPath: path, SkipValidation: true, Signer: key}); err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+
+	data, err := c.Dump(ctx, "app:v1")
+	if err != nil {
+		t.Fatalf("Dump failed: %v", err)
+	}
+	if string(data) != testManifest {
+		t.Errorf("Dump() = %q, want %q", data, testManifest)
+	}
+
+	if err := c.Verify(ctx, "app:v1", VerifyOptions{PublicKeys: []crypto.PublicKey{&key.PublicKey}}); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+	if err := c.Verify(ctx, "app:v1", VerifyOptions{PublicKeys: []crypto.PublicKey{&other.PublicKey}}); err == nil {
+		t.Error("Verify should fail with an untrusted key")
+	}
+
+	if err := c.Pack(ctx, "app:v2", PackOptions{ManifestPath: path, SkipValidation: true}); err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	if err := c.Sign(ctx, "app:v2", SignOptions{Signer: other}); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if err := c.Verify(ctx, "app:v2", VerifyOptions{PublicKeys: []crypto.PublicKey{&other.PublicKey}}); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+
+	infos, err := c.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	var tags []string
+	for _, i := range infos {
+		tags = append(tags, i.Repository+":"+i.Tag)
+	}
+	if len(tags) != 2 || tags[0] != "app:v1" || tags[1] != "app:v2" {
+		t.Errorf("List() = %v, want [app:v1 app:v2]", tags)
+	}
+}
+
+func TestLocalStore_Dir(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+	baseDir := oci.BaseDir()
+
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(path, []byte(testManifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Pack(ctx, "app:v1", PackOptions{ManifestPath: path, SkipValidation: true, Signer: key}); err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+
+	other, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore failed: %v", err)
+	}
+	if oci.BaseDir() != baseDir {
+		t.Errorf("NewLocalStore changed the storage directory of the process to %s", oci.BaseDir())
+	}
+	infos, err := other.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(infos) != 0 {
+		t.Errorf("List() of another store = %v, want nothing", infos)
+	}
+
+	// Packing records an event in the storage directory of the store, like the CLI
+	dir := c.store.(*LocalStore).dir
+	events, err := os.ReadFile(filepath.Join(dir, ".events", "local", "app.jsonl"))
+	if err != nil {
+		t.Fatalf("no event log in the store: %v", err)
+	}
+	if !strings.Contains(string(events), `"type":"packed"`) {
+		t.Errorf("event log = %s, want a packed event", events)
+	}
+}
+
+func TestClient_PackLimits(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	configDir := os.Getenv("KUBECTL_MFT_CONFIG_DIR")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("pack:\n  limits:\n    maxSize: 10\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(path, []byte(testManifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := c.Pack(ctx, "app:v1", PackOptions{ManifestPath: path, SkipValidation: true})
+	if err == nil || !strings.Contains(err.Error(), "pack.limits") {
+		t.Fatalf("Pack() error = %v, want the manifest refused by pack.limits", err)
+	}
+	if err := c.Pack(ctx, "app:v1", PackOptions{ManifestPath: path, SkipValidation: true, SkipLimits: true}); err != nil {
+		t.Fatalf("Pack with SkipLimits failed: %v", err)
+	}
+}
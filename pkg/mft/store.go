@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+package mft
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	internalmft "github.com/chez-shanpu/kubectl-mft/internal/mft"
+	"github.com/chez-shanpu/kubectl-mft/internal/oci"
+	"github.com/chez-shanpu/kubectl-mft/internal/signature"
+)
+
+// Info describes a stored manifest
+type Info = internalmft.Info
+
+// Store holds packed manifests in OCI layouts.
+type Store interface {
+	// Repository returns the manifest stored under ref, which need not exist yet.
+	Repository(ref string) (Repository, error)
+	// List returns the stored manifests.
+	List(ctx context.Context) ([]*Info, error)
+}
+
+// Repository is a manifest of a Store, identified by its reference.
+type Repository interface {
+	// Save packs the manifest file at manifestPath under the tag of the repository.
+	Save(ctx context.Context, manifestPath string) error
+	// Delete removes the manifest from the store.
+	Delete(ctx context.Context) error
+	// Exists reports whether the manifest is stored.
+	Exists(ctx context.Context) (bool, error)
+	// Dump returns the content of the manifest.
+	Dump(ctx context.Context) ([]byte, error)
+	// Push copies the manifest and its signatures to the OCI registry of its reference.
+	Push(ctx context.Context) error
+	// Pull copies the manifest and its signatures from the OCI registry of its reference.
+	Pull(ctx context.Context) error
+	// LayoutPath returns the directory of the OCI layout holding the manifest.
+	LayoutPath() string
+	// Tag returns the tag or digest of the manifest in its OCI layout.
+	Tag() string
+}
+
+// LocalStore is the local storage of kubectl-mft, shared with the CLI.
+type LocalStore struct {
+	// dir is the storage directory, or empty for the directory the CLI uses
+	dir string
+}
+
+var initStorage = sync.OnceValue(func() error {
+	if err := signature.InitKeyDir(); err != nil {
+		return err
+	}
+	return oci.InitBaseDir()
+})
+
+// NewLocalStore returns the local storage in dir, or in the directory the CLI uses
+// if dir is empty. The read-only system storage and the cache are shared by all stores.
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := initStorage(); err != nil {
+		return nil, err
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+func (s *LocalStore) Repository(ref string) (Repository, error) {
+	r, err := oci.NewRepositoryIn(s.dir, ref)
+	if err != nil {
+		return nil, err
+	}
+	return &localRepository{r}, nil
+}
+
+func (s *LocalStore) List(ctx context.Context) ([]*Info, error) {
+	res, err := internalmft.List(ctx, oci.NewRegistryIn(s.dir))
+	if err != nil {
+		return nil, err
+	}
+	res.Sort()
+	return res.Items(), nil
+}
+
+// localRepository adapts a repository of local storage to Repository
+type localRepository struct {
+	*oci.Repository
+}
+
+func (r *localRepository) Delete(ctx context.Context) error {
+	_, err := r.Repository.Delete(ctx)
+	return err
+}
+
+func (r *localRepository) Dump(ctx context.Context) ([]byte, error) {
+	res, err := r.Repository.Dump(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	return io.ReadAll(res)
+}
+
+// pack packs the manifest in a staging layout and signs it there, like the CLI, so that local
+// storage only changes once signing succeeded. Committing records the change in the metadata
+// index and the event log.
+func (r *localRepository) pack(ctx context.Context, opts PackOptions) error {
+	staged, err := r.Stage(ctx, opts.ManifestPath, "")
+	if err != nil {
+		return err
+	}
+	defer staged.Discard()
+	if opts.Signer != nil {
+		if err := signLayout(ctx, staged.LayoutPath(), r.Tag(), opts.Signer, opts.TimestampURL, opts.TimestampClient); err != nil {
+			return err
+		}
+	}
+	return staged.Commit(ctx)
+}
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of kubectl-mft
+
+//go:build e2e
+
+package test
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Bundle Commands", func() {
+	var crdTag, appTag, bundleTag string
+
+	BeforeEach(func() {
+		crdTag = CreateUniqueTag("bundle-crds")
+		appTag = CreateUniqueTag("bundle-app")
+		bundleTag = fmt.Sprintf("%s/bundle-test:%d", testRegistry.GetRegistryURL(), time.Now().UnixNano())
+
+		crdPath := testFixtures.CreateManifestFile("bundle-crd.yaml", testFixtures.GetCRDManifest())
+		appPath := testFixtures.CreateManifestFile("bundle-app.yaml", testFixtures.GetSimpleManifest())
+
+		By("Packing the member manifests")
+		session := ExecuteKubectlMft("pack", "-f", crdPath, crdTag)
+		Eventually(session, 30*time.Second).Should(gexec.Exit(0))
+		session = ExecuteKubectlMft("pack", "-f", appPath, appTag)
+		Eventually(session, 30*time.Second).Should(gexec.Exit(0))
+	})
+
+	AfterEach(func() {
+		for _, tag := range []string{bundleTag, appTag, crdTag} {
+			session := ExecuteKubectlMft("delete", tag, "--force")
+			Eventually(session, 10*time.Second).Should(gexec.Exit(0))
+		}
+	})
+
+	It("should create, push, and pull a bundle", func() {
+		By("Creating the bundle")
+		session := ExecuteKubectlMft("bundle", "create", bundleTag, "app="+appTag, "crds="+crdTag, "--depends-on", "app=crds")
+		Eventually(session, 30*time.Second).Should(gexec.Exit(0))
+
+		By("Showing the members in dependency order")
+		session = ExecuteKubectlMft("bundle", "show", bundleTag)
+		Eventually(session, 10*time.Second).Should(gexec.Exit(0))
+		Expect(string(session.Out.Contents())).To(MatchRegexp(`(?s)crds\s.*app\s`))
+
+		By("Refusing to dump the bundle itself")
+		session = ExecuteKubectlMft("dump", bundleTag)
+		Eventually(session, 10*time.Second).Should(gexec.Exit(1))
+		Expect(string(session.Err.Contents())).To(ContainSubstring("is a bundle"))
+
+		By("Pushing the bundle")
+		session = ExecuteKubectlMft("bundle", "push", bundleTag)
+		Eventually(session, 30*time.Second).Should(gexec.Exit(0))
+
+		By("Pulling the bundle after deleting it locally")
+		session = ExecuteKubectlMft("delete", bundleTag, "--force")
+		Eventually(session, 10*time.Second).Should(gexec.Exit(0))
+		session = ExecuteKubectlMft("bundle", "pull", bundleTag)
+		Eventually(session, 30*time.Second).Should(gexec.Exit(0))
+
+		session = ExecuteKubectlMft("bundle", "show", bundleTag)
+		Eventually(session, 10*time.Second).Should(gexec.Exit(0))
+		Expect(string(session.Out.Contents())).To(ContainSubstring(appTag))
+	})
+
+	It("should reject a dependency cycle", func() {
+		session := ExecuteKubectlMft("bundle", "create", bundleTag, "app="+appTag, "crds="+crdTag,
+			"--depends-on", "app=crds", "--depends-on", "crds=app", "--skip-sign")
+		Eventually(session, 10*time.Second).Should(gexec.Exit(1))
+		Expect(string(session.Err.Contents())).To(ContainSubstring("dependency cycle"))
+	})
+})
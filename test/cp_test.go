@@ -64,6 +64,28 @@ var _ = Describe("Copy Command", func() {
 			Eventually(session, 10*time.Second).Should(gexec.Exit(0))
 		})
 
+		It("should replace an existing destination tag with --force", func() {
+			destTag := fmt.Sprintf("%s-staging", sourceTag)
+			complexPath := testFixtures.CreateManifestFile("complex.yaml", testFixtures.GetComplexManifest())
+
+			By("Creating destination tag with different content")
+			session := ExecuteKubectlMft("pack", "-f", complexPath, destTag)
+			Eventually(session, 30*time.Second).Should(gexec.Exit(0))
+
+			By("Replacing the destination tag")
+			session = ExecuteKubectlMft("cp", "--force", sourceTag, destTag)
+			Eventually(session, 10*time.Second).Should(gexec.Exit(0))
+
+			By("Verifying destination now has the source content")
+			session = ExecuteKubectlMft("dump", destTag)
+			Eventually(session, 10*time.Second).Should(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(testFixtures.GetSimpleManifest()))
+
+			By("Cleaning up destination tag")
+			session = ExecuteKubectlMft("delete", destTag, "--force")
+			Eventually(session, 10*time.Second).Should(gexec.Exit(0))
+		})
+
 		It("should copy manifest to different repository", func() {
 			destTag := CreateUniqueTag("cp-test-different-repo")
 
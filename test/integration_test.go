@@ -124,6 +124,32 @@ var _ = Describe("Integration Tests", func() {
 			pulledContent := session.Out.Contents()
 			Expect(pulledContent).To(Equal(originalContent))
 		})
+
+		It("should skip pulling with --if-not-present when the local copy is up to date", func() {
+			By("Packing and pushing a manifest")
+			session := ExecuteKubectlMft("pack", "-f", manifestPath, testTag)
+			Eventually(session, 30*time.Second).Should(gexec.Exit(0))
+			session = ExecuteKubectlMft("push", testTag)
+			Eventually(session, 30*time.Second).Should(gexec.Exit(0))
+
+			By("Pulling with --if-not-present")
+			session = ExecuteKubectlMft("pull", "--if-not-present", testTag)
+			Eventually(session, 30*time.Second).Should(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).To(ContainSubstring("is up to date"))
+
+			By("Pulling with --if-not-present after deleting the local copy")
+			session = ExecuteKubectlMft("delete", testTag, "--force")
+			Eventually(session, 10*time.Second).Should(gexec.Exit(0))
+			session = ExecuteKubectlMft("pull", "--if-not-present", testTag)
+			Eventually(session, 30*time.Second).Should(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).NotTo(ContainSubstring("is up to date"))
+
+			session = ExecuteKubectlMft("dump", testTag)
+			Eventually(session, 10*time.Second).Should(gexec.Exit(0))
+			originalContent, err := os.ReadFile(manifestPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(session.Out.Contents()).To(Equal(originalContent))
+		})
 	})
 
 	Describe("Multiple tags in same repository workflow", func() {
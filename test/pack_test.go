@@ -6,6 +6,7 @@
 package test
 
 import (
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -109,4 +110,47 @@ var _ = Describe("Pack Command", func() {
 			Expect(string(session.Out.Contents())).To(Equal(testFixtures.GetComplexManifest()))
 		})
 	})
+
+	Context("Delta against a base tag", func() {
+		var repo string
+
+		BeforeEach(func() {
+			repo = CreateUniqueTag("pack-delta")
+			repo = repo[:strings.LastIndex(repo, ":")]
+		})
+
+		AfterEach(func() {
+			for _, tag := range []string{repo + ":v2", repo + ":v1"} {
+				session := ExecuteKubectlMft("delete", tag, "--force")
+				Eventually(session, 10*time.Second).Should(gexec.Exit(0))
+			}
+		})
+
+		It("should reconstruct the full content on dump", func() {
+			basePath := testFixtures.CreateManifestFile("delta-base.yaml", testFixtures.GetSimpleManifest())
+			updated := strings.Replace(testFixtures.GetSimpleManifest(), "replicas: 1", "replicas: 3", 1)
+			updatedPath := testFixtures.CreateManifestFile("delta-updated.yaml", updated)
+
+			By("Packing the base version")
+			session := ExecuteKubectlMft("pack", "-f", basePath, repo+":v1")
+			Eventually(session, 30*time.Second).Should(gexec.Exit(0))
+
+			By("Packing the updated version as a delta")
+			session = ExecuteKubectlMft("pack", "-f", updatedPath, "--base", "v1", repo+":v2")
+			Eventually(session, 30*time.Second).Should(gexec.Exit(0))
+
+			By("Verifying content via dump")
+			session = ExecuteKubectlMft("dump", repo+":v2")
+			Eventually(session, 10*time.Second).Should(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(updated))
+		})
+
+		It("should fail when the base tag does not exist", func() {
+			manifestPath := testFixtures.CreateManifestFile("delta-missing.yaml", testFixtures.GetSimpleManifest())
+
+			session := ExecuteKubectlMft("pack", "-f", manifestPath, "--base", "missing", repo+":v1")
+			Eventually(session, 30*time.Second).Should(gexec.Exit(1))
+			Expect(session.Err).To(gbytes.Say("failed to load base"))
+		})
+	})
 })
@@ -49,7 +49,7 @@ var _ = Describe("Schema Command", func() {
 			Expect(output).To(ContainSubstring("v1"))
 
 			By("Deleting CRD schema")
-			session = ExecuteKubectlMft("schema", "delete", "example.com/MyResource")
+			session = ExecuteKubectlMft("schema", "delete", "--yes", "example.com/MyResource")
 			Eventually(session, 10*time.Second).Should(gexec.Exit(0))
 
 			By("Verifying schema is removed from list")
@@ -82,7 +82,7 @@ var _ = Describe("Schema Command", func() {
 	Context("Schema error cases", func() {
 		It("should fail to delete non-existent schema", func() {
 			By("Attempting to delete a non-existent schema")
-			session := ExecuteKubectlMft("schema", "delete", "nonexistent.io/FakeResource")
+			session := ExecuteKubectlMft("schema", "delete", "--yes", "nonexistent.io/FakeResource")
 			Eventually(session, 10*time.Second).Should(gexec.Exit(1))
 		})
 
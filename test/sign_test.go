@@ -189,7 +189,7 @@ var _ = Describe("Signing and Verification", func() {
 			Expect(session.Out).To(gbytes.Say("Verified"))
 
 			By("Cleaning up imported key")
-			session = ExecuteKubectlMft("key", "delete", "imported")
+			session = ExecuteKubectlMft("key", "delete", "--yes", "imported")
 			Eventually(session, 10*time.Second).Should(gexec.Exit(0))
 		})
 	})